@@ -0,0 +1,70 @@
+package themisdb
+
+import (
+	"context"
+	"log"
+	"runtime"
+	"runtime/debug"
+)
+
+// watch rolls the transaction back once ctx is done, unless it was committed or rolled
+// back before. The watch ends with the transaction.
+func (tx *Transaction) watch(ctx context.Context) {
+	if ctx.Done() == nil {
+		// ctx is never done; not referencing tx from a callback keeps it collectable
+		return
+	}
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	tx.stopWatch = context.AfterFunc(ctx, func() {
+		tx.abandon(context.Background())
+	})
+}
+
+// detectLeaks sets a finalizer that reports the transaction to onLeak with the stack
+// of its BeginTransaction call and rolls it back, if it becomes unreachable while
+// still active
+func (tx *Transaction) detectLeaks(onLeak func(transactionID string, begunAt []byte)) {
+	begunAt := debug.Stack()
+	runtime.SetFinalizer(tx, func(tx *Transaction) {
+		tx.mu.RLock()
+		leaked := tx.active && !tx.prepared
+		tx.mu.RUnlock()
+		if leaked {
+			onLeak(tx.transactionID, begunAt)
+			go tx.abandon(context.Background())
+		}
+	})
+}
+
+// LogLeak is a TransactionOptions.OnLeak function that logs leaked transactions with
+// the standard logger
+func LogLeak(transactionID string, begunAt []byte) {
+	log.Printf("themisdb: transaction %s was neither committed nor rolled back; rolling it back. BeginTransaction was called at:\n%s", transactionID, begunAt)
+}
+
+// Close rolls the transaction back if it is still active, so that a deferred Close
+// releases it on every path that does not commit. It returns nil if the transaction
+// was committed, rolled back, or expired before. If its node was lost, the transaction
+// ends with an error matching ErrTransactionNodeLost, as the server can only expire
+// it. A prepared transaction is left to Commit or Rollback, as only its coordinator
+// may decide its outcome.
+func (tx *Transaction) Close() error {
+	return tx.abandon(context.Background())
+}
+
+// abandon rolls back a transaction that is neither finished nor prepared
+func (tx *Transaction) abandon(ctx context.Context) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if !tx.active || tx.prepared {
+		return nil
+	}
+	if tx.expired.Load() {
+		tx.active = false
+		tx.stop()
+		return nil
+	}
+	return tx.rollbackLocked(ctx)
+}
@@ -0,0 +1,149 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rollbackCount returns the number of rollbacks the store received
+func (s *memoryStore) rollbackCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rollbacks
+}
+
+func TestTransaction_Close(t *testing.T) {
+	client, store := newMemoryClient(t)
+	ctx := context.Background()
+
+	tx, err := client.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit(ctx))
+	require.NoError(t, tx.Close(), "closing a committed transaction is a no-op")
+	assert.Equal(t, 0, store.rollbackCount())
+
+	tx, err = client.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.Close())
+	assert.Equal(t, 1, store.rollbackCount())
+	assert.False(t, tx.IsActive())
+	require.NoError(t, tx.Close())
+	assert.Equal(t, 1, store.rollbackCount())
+}
+
+func TestTransaction_RollbackOnCancel(t *testing.T) {
+	client, store := newMemoryClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tx, err := client.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	cancel()
+	assert.Eventually(t, func() bool { return !tx.IsActive() }, time.Second, time.Millisecond)
+	assert.Equal(t, 1, store.rollbackCount())
+
+	ctx, cancel = context.WithCancel(context.Background())
+	tx, err = client.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit(ctx))
+	cancel()
+
+	ctx, cancel = context.WithCancel(context.Background())
+	tx, err = client.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.Prepare(ctx))
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	assert.True(t, tx.IsActive(), "a prepared transaction awaits the coordinator's decision")
+	require.NoError(t, tx.Close())
+	assert.Equal(t, 1, store.rollbackCount())
+}
+
+func TestTransaction_OnLeak(t *testing.T) {
+	client, store := newMemoryClient(t)
+	leaked := make(chan string, 1)
+
+	func() {
+		tx, err := client.BeginTransaction(context.Background(), &TransactionOptions{
+			OnLeak: func(id string, begunAt []byte) {
+				assert.Contains(t, string(begunAt), "TestTransaction_OnLeak")
+				leaked <- id
+			},
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, tx.TransactionID())
+	}()
+
+	var id string
+	assert.Eventually(t, func() bool {
+		runtime.GC()
+		select {
+		case id = <-leaked:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "tx-0", id)
+	assert.Eventually(t, func() bool { return store.rollbackCount() == 1 }, time.Second, time.Millisecond)
+}
+
+func TestTransaction_CloseRetriesFailedRollback(t *testing.T) {
+	var mu sync.Mutex
+	var rollbacks int
+	failRollback := true
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/transaction/begin":
+			w.Write([]byte(`{"transaction_id": "tx-1"}`))
+		case "/transaction/rollback":
+			rollbacks++
+			if failRollback {
+				http.Error(w, "try again", http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tx, err := client.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	assert.Error(t, tx.Close())
+	assert.True(t, tx.IsActive(), "a failed rollback keeps the transaction")
+
+	// the context watch survived the failed rollback and rolls back on cancellation
+	mu.Lock()
+	failRollback = false
+	mu.Unlock()
+	cancel()
+	assert.Eventually(t, func() bool { return !tx.IsActive() }, time.Second, time.Millisecond)
+	mu.Lock()
+	assert.Equal(t, 2, rollbacks)
+	mu.Unlock()
+}
+
+func TestTransaction_CloseLostNode(t *testing.T) {
+	node := newAffinityNode(t, "a")
+	client := NewClient(Config{Endpoints: []string{node.URL}})
+	defer client.Close()
+	ctx := context.Background()
+
+	tx, err := client.BeginTransaction(ctx, &TransactionOptions{Heartbeat: time.Hour})
+	require.NoError(t, err)
+	node.Close()
+	assert.ErrorIs(t, tx.Put(ctx, "relational", "users", "u1", map[string]int{}), ErrTransactionNodeLost)
+
+	assert.ErrorIs(t, tx.Close(), ErrTransactionNodeLost)
+	assert.False(t, tx.IsActive(), "a transaction without its node cannot be rolled back")
+	assert.NoError(t, tx.Close())
+}
@@ -0,0 +1,239 @@
+package themisdb
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// Admin provides administrative operations on a ThemisDB server
+type Admin struct {
+	client *Client
+}
+
+// Admin returns the administrative sub-client
+func (c *Client) Admin() *Admin {
+	return &Admin{client: c}
+}
+
+// KeyRange describes a half-open key range [Start, End) within a collection.
+// An empty Start means the beginning of the collection, an empty End its end.
+type KeyRange struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// RangeChecksum holds the Merkle hash of a single key range
+type RangeChecksum struct {
+	Range KeyRange `json:"range"`
+	Hash  string   `json:"hash"`
+	Count int64    `json:"count"`
+}
+
+// CollectionChecksum holds range-level hashes of a collection
+type CollectionChecksum struct {
+	Collection string          `json:"collection"`
+	Algorithm  string          `json:"algorithm"`
+	Root       string          `json:"root"`
+	Ranges     []RangeChecksum `json:"ranges"`
+}
+
+// CollectionChecksum computes Merkle-tree hashes for the given key ranges of a collection.
+// If ranges is empty, the server picks the range split and returns a hash per range.
+func (a *Admin) CollectionChecksum(ctx context.Context, collection string, ranges []KeyRange) (*CollectionChecksum, error) {
+	if err := validateName("collection", collection); err != nil {
+		return nil, err
+	}
+	path := joinPath("/admin/collections", collection) + "/checksum"
+	body := map[string]interface{}{}
+	if len(ranges) > 0 {
+		body["ranges"] = ranges
+	}
+
+	var result CollectionChecksum
+	if err := a.client.request(ctx, "POST", path, body, &result, nil); err != nil {
+		return nil, fmt.Errorf("failed to compute collection checksum: %w", err)
+	}
+	return &result, nil
+}
+
+// DiffChecksums compares two checksums and returns the key ranges whose hashes differ.
+// Ranges present in only one of the checksums are reported as divergent as well.
+func DiffChecksums(a, b *CollectionChecksum) []KeyRange {
+	if a == nil || b == nil {
+		return nil
+	}
+	if a.Root != "" && a.Root == b.Root {
+		return nil
+	}
+
+	other := make(map[KeyRange]string, len(b.Ranges))
+	for _, r := range b.Ranges {
+		other[r.Range] = r.Hash
+	}
+
+	var diff []KeyRange
+	seen := make(map[KeyRange]bool, len(a.Ranges))
+	for _, r := range a.Ranges {
+		seen[r.Range] = true
+		if hash, ok := other[r.Range]; !ok || hash != r.Hash {
+			diff = append(diff, r.Range)
+		}
+	}
+	for _, r := range b.Ranges {
+		if !seen[r.Range] {
+			diff = append(diff, r.Range)
+		}
+	}
+	return diff
+}
+
+// IndexType selects the kind of secondary index
+type IndexType string
+
+const (
+	// EqualityIndex supports equality lookups (default)
+	EqualityIndex IndexType = ""
+	// RangeIndex supports ordered range scans and sorting
+	RangeIndex IndexType = "range"
+	// FulltextIndex supports full-text search
+	FulltextIndex IndexType = "fulltext"
+)
+
+// IndexOptions describes a secondary index
+type IndexOptions struct {
+	// Collection is the indexed collection
+	Collection string `json:"table"`
+	// Column is the indexed field
+	Column string `json:"column"`
+	// Type of index (default: equality)
+	Type IndexType `json:"type,omitempty"`
+	// Unique rejects duplicate values
+	Unique bool `json:"unique,omitempty"`
+	// Collation orders string keys of the index, so sorted scans match locale expectations
+	Collation *Collation `json:"collation,omitempty"`
+}
+
+// CreateIndex creates a secondary index
+func (a *Admin) CreateIndex(ctx context.Context, opts IndexOptions) error {
+	if err := a.client.request(ctx, "POST", "/index/create", opts, nil, nil); err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+	return nil
+}
+
+// DropIndex drops the secondary index on collection.column
+func (a *Admin) DropIndex(ctx context.Context, collection, column string) error {
+	body := map[string]interface{}{
+		"table":  collection,
+		"column": column,
+	}
+	if err := a.client.request(ctx, "POST", "/index/drop", body, nil, nil); err != nil {
+		return fmt.Errorf("failed to drop index: %w", err)
+	}
+	return nil
+}
+
+// CollectionOptions describes a collection to create
+type CollectionOptions struct {
+	// Name of the collection
+	Name string `json:"name"`
+	// Model is the data model holding the collection (default: relational)
+	Model string `json:"model"`
+	// Schema is an optional JSON schema enforced by the server on writes
+	Schema map[string]interface{} `json:"schema,omitempty"`
+}
+
+// CollectionInfo holds statistics and indexes of a collection
+type CollectionInfo struct {
+	Name    string         `json:"name"`
+	Model   string         `json:"model"`
+	Count   int64          `json:"count"`
+	Size    int64          `json:"size_bytes"`
+	Indexes []IndexOptions `json:"indexes"`
+}
+
+// CreateCollection creates a collection
+func (a *Admin) CreateCollection(ctx context.Context, opts CollectionOptions) error {
+	if opts.Model == "" {
+		opts.Model = ModelRelational
+	}
+	if err := validateCollection(opts.Model, opts.Name); err != nil {
+		return err
+	}
+	if err := a.client.request(ctx, "POST", "/admin/collections", opts, nil, nil); err != nil {
+		return fmt.Errorf("failed to create collection: %w", err)
+	}
+	return nil
+}
+
+// DropCollection removes a collection together with its documents and indexes
+func (a *Admin) DropCollection(ctx context.Context, collection string) error {
+	if err := validateName("collection", collection); err != nil {
+		return err
+	}
+	path := joinPath("/admin/collections", collection)
+	if err := a.client.request(ctx, "DELETE", path, nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to drop collection: %w", err)
+	}
+	return nil
+}
+
+// ListCollections returns the collections of a model, or of all models if model is empty
+func (a *Admin) ListCollections(ctx context.Context, model string) ([]CollectionInfo, error) {
+	path := "/admin/collections"
+	if model != "" {
+		if err := validateName("model", model); err != nil {
+			return nil, err
+		}
+		path += "?model=" + url.QueryEscape(model)
+	}
+
+	var response struct {
+		Collections []CollectionInfo `json:"collections"`
+	}
+	if err := a.client.request(ctx, "GET", path, nil, &response, nil); err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	return response.Collections, nil
+}
+
+// CollectionInfo returns the document count, storage size, and indexes of a collection
+func (a *Admin) CollectionInfo(ctx context.Context, collection string) (*CollectionInfo, error) {
+	if err := validateName("collection", collection); err != nil {
+		return nil, err
+	}
+	path := joinPath("/admin/collections", collection)
+
+	var info CollectionInfo
+	if err := a.client.request(ctx, "GET", path, nil, &info, nil); err != nil {
+		return nil, fmt.Errorf("failed to get collection info: %w", err)
+	}
+	return &info, nil
+}
+
+// EnableModel enables a data model on the server
+func (a *Admin) EnableModel(ctx context.Context, model string) error {
+	return a.setModelEnabled(ctx, model, true)
+}
+
+// DisableModel disables a data model on the server. Its collections are kept but
+// requests against them are rejected until the model is enabled again.
+func (a *Admin) DisableModel(ctx context.Context, model string) error {
+	return a.setModelEnabled(ctx, model, false)
+}
+
+// setModelEnabled switches a data model on or off
+func (a *Admin) setModelEnabled(ctx context.Context, model string, enabled bool) error {
+	if err := validateName("model", model); err != nil {
+		return err
+	}
+	path := joinPath("/admin/models", model)
+	body := map[string]interface{}{
+		"enabled": enabled,
+	}
+	if err := a.client.request(ctx, "PATCH", path, body, nil, nil); err != nil {
+		return fmt.Errorf("failed to update model %s: %w", model, err)
+	}
+	return nil
+}
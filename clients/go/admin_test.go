@@ -0,0 +1,140 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdmin_CollectionChecksum(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/admin/collections/users/checksum", r.URL.Path)
+
+		var body struct {
+			Ranges []KeyRange `json:"ranges"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Len(t, body.Ranges, 1)
+		assert.Equal(t, KeyRange{Start: "a", End: "m"}, body.Ranges[0])
+
+		json.NewEncoder(w).Encode(CollectionChecksum{
+			Collection: "users",
+			Algorithm:  "sha256",
+			Root:       "root-hash",
+			Ranges: []RangeChecksum{
+				{Range: KeyRange{Start: "a", End: "m"}, Hash: "h1", Count: 42},
+			},
+		})
+	})
+
+	checksum, err := client.Admin().CollectionChecksum(context.Background(), "users", []KeyRange{{Start: "a", End: "m"}})
+	require.NoError(t, err)
+	assert.Equal(t, "root-hash", checksum.Root)
+	require.Len(t, checksum.Ranges, 1)
+	assert.Equal(t, int64(42), checksum.Ranges[0].Count)
+}
+
+func TestDiffChecksums(t *testing.T) {
+	r1 := KeyRange{Start: "", End: "m"}
+	r2 := KeyRange{Start: "m", End: ""}
+	r3 := KeyRange{Start: "x", End: ""}
+
+	a := &CollectionChecksum{Root: "a", Ranges: []RangeChecksum{{Range: r1, Hash: "h1"}, {Range: r2, Hash: "h2"}}}
+	b := &CollectionChecksum{Root: "b", Ranges: []RangeChecksum{{Range: r1, Hash: "h1"}, {Range: r2, Hash: "changed"}, {Range: r3, Hash: "h3"}}}
+
+	assert.Equal(t, []KeyRange{r2, r3}, DiffChecksums(a, b))
+	assert.Empty(t, DiffChecksums(a, &CollectionChecksum{Root: "a"}))
+	assert.Nil(t, DiffChecksums(nil, b))
+}
+
+func TestAdmin_CreateIndexWithCollation(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		switch r.URL.Path {
+		case "/index/create":
+			assert.Equal(t, "users", body["table"])
+			assert.Equal(t, "name", body["column"])
+			assert.Equal(t, "range", body["type"])
+			assert.Equal(t, map[string]interface{}{"locale": "de-DE", "case_insensitive": true}, body["collation"])
+		case "/index/drop":
+			assert.Equal(t, map[string]interface{}{"table": "users", "column": "name"}, body)
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"success":true}`))
+	})
+
+	ctx := context.Background()
+	err := client.Admin().CreateIndex(ctx, IndexOptions{
+		Collection: "users",
+		Column:     "name",
+		Type:       RangeIndex,
+		Collation:  &Collation{Locale: "de-DE", CaseInsensitive: true},
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Admin().DropIndex(ctx, "users", "name"))
+}
+
+func TestAdmin_Collections(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /admin/collections":
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, map[string]interface{}{"name": "users", "model": "relational"}, body)
+			w.WriteHeader(http.StatusCreated)
+		case "GET /admin/collections":
+			assert.Equal(t, "document", r.URL.Query().Get("model"))
+			w.Write([]byte(`{"collections":[{"name":"articles","model":"document","count":3}]}`))
+		case "GET /admin/collections/users":
+			w.Write([]byte(`{"name":"users","model":"relational","count":42,"size_bytes":4096,"indexes":[{"table":"users","column":"email","unique":true}]}`))
+		case "DELETE /admin/collections/users":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	ctx := context.Background()
+	admin := client.Admin()
+
+	require.NoError(t, admin.CreateCollection(ctx, CollectionOptions{Name: "users"}))
+
+	collections, err := admin.ListCollections(ctx, ModelDocument)
+	require.NoError(t, err)
+	require.Len(t, collections, 1)
+	assert.Equal(t, "articles", collections[0].Name)
+
+	info, err := admin.CollectionInfo(ctx, "users")
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), info.Count)
+	assert.Equal(t, int64(4096), info.Size)
+	assert.Equal(t, []IndexOptions{{Collection: "users", Column: "email", Unique: true}}, info.Indexes)
+
+	require.NoError(t, admin.DropCollection(ctx, "users"))
+	assert.ErrorIs(t, admin.DropCollection(ctx, ""), ErrInvalidInput)
+}
+
+func TestAdmin_ModelLifecycle(t *testing.T) {
+	var calls []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PATCH", r.Method)
+		var body struct {
+			Enabled bool `json:"enabled"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		calls = append(calls, r.URL.Path+" "+strconv.FormatBool(body.Enabled))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	ctx := context.Background()
+
+	require.NoError(t, client.Admin().EnableModel(ctx, ModelTimeseries))
+	require.NoError(t, client.Admin().DisableModel(ctx, ModelGraph))
+	assert.Equal(t, []string{"/admin/models/timeseries true", "/admin/models/graph false"}, calls)
+}
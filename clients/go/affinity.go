@@ -0,0 +1,116 @@
+package themisdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// headerTransactionAffinity carries the affinity token of a transaction, so proxies in
+// front of the cluster route its requests to the node that began it
+const headerTransactionAffinity = "X-Transaction-Affinity"
+
+// TransactionNodeError reports that the node serving a transaction became unreachable,
+// left the cluster topology, or no longer owns the transaction. The transaction is lost
+// and must be retried from the start with a new one.
+type TransactionNodeError struct {
+	TransactionID string
+	// Endpoint is the node that began the transaction
+	Endpoint string
+	// Err is the underlying failure
+	Err error
+}
+
+// Error implements error
+func (e *TransactionNodeError) Error() string {
+	return fmt.Sprintf("transaction %s lost its node %s: %v", e.TransactionID, e.Endpoint, e.Err)
+}
+
+// Unwrap returns the underlying failure
+func (e *TransactionNodeError) Unwrap() error {
+	return e.Err
+}
+
+// Is allows matching with errors.Is(err, ErrTransactionNodeLost)
+func (e *TransactionNodeError) Is(target error) bool {
+	return target == ErrTransactionNodeLost
+}
+
+// pinnedTransactionKey is the context key of requests bound to a transaction's node
+type pinnedTransactionKey struct{}
+
+// pinTransaction binds the requests of ctx to the node that began tx, bypassing the
+// load balancer, failover, and replica reads
+func pinTransaction(ctx context.Context, tx *Transaction) context.Context {
+	return context.WithValue(ctx, pinnedTransactionKey{}, tx)
+}
+
+// sendPinned sends a request of tx to its node and retries it there after transport
+// errors, up to MaxRetries times. The node counts as lost once the retries are exhausted,
+// it left the cluster topology, or it answers 421 Misdirected Request; from then on all
+// further requests of tx fail fast with the same *TransactionNodeError.
+func (c *Client) sendPinned(ctx context.Context, tx *Transaction, req *Request) (*Response, error) {
+	if lost := tx.lost.Load(); lost != nil {
+		return nil, lost
+	}
+	tx.addAffinity(req)
+	backoff := 100 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		if !c.inTopology(tx.endpoint) {
+			return nil, tx.nodeLost(fmt.Errorf("node left the cluster topology"))
+		}
+		resp, err := c.sendTo(ctx, tx.endpoint, req)
+		switch {
+		case err == nil && resp.StatusCode == http.StatusMisdirectedRequest:
+			return nil, tx.nodeLost(&StatusError{StatusCode: resp.StatusCode, Message: string(resp.Body)})
+		case err == nil || ctx.Err() != nil:
+			return resp, err
+		case attempt >= c.maxRetries:
+			return nil, tx.nodeLost(err)
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// addAffinity adds the affinity token of tx to the headers of req
+func (tx *Transaction) addAffinity(req *Request) {
+	if tx.affinity == "" {
+		return
+	}
+	headers := make(map[string]string, len(req.Header)+1)
+	for key, value := range req.Header {
+		headers[key] = value
+	}
+	headers[headerTransactionAffinity] = tx.affinity
+	req.Header = headers
+}
+
+// nodeLost records that the node of tx was lost because of err
+func (tx *Transaction) nodeLost(err error) error {
+	lost := &TransactionNodeError{TransactionID: tx.transactionID, Endpoint: tx.endpoint, Err: err}
+	tx.lost.CompareAndSwap(nil, lost)
+	return tx.lost.Load()
+}
+
+// inTopology reports whether endpoint is a member of the discovered cluster topology.
+// Without discovery every endpoint counts as a member.
+func (c *Client) inTopology(endpoint string) bool {
+	members := c.Topology()
+	if len(members) == 0 {
+		return true
+	}
+	for _, m := range members {
+		if strings.TrimSuffix(m.Endpoint, "/") == endpoint {
+			return true
+		}
+	}
+	return false
+}
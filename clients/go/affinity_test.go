@@ -0,0 +1,163 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// affinityNode is a fake node that begins transactions with an affinity token and
+// records the token of each request
+type affinityNode struct {
+	*httptest.Server
+	mu         sync.Mutex
+	tokens     []string
+	misdirects bool
+}
+
+func newAffinityNode(t *testing.T, name string) *affinityNode {
+	n := &affinityNode{}
+	n.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		if r.URL.Path == "/transaction/begin" {
+			fmt.Fprintf(w, `{"transaction_id":"tx-%s","affinity_token":"node-%s"}`, name, name)
+			return
+		}
+		n.tokens = append(n.tokens, r.Header.Get(headerTransactionAffinity))
+		if n.misdirects {
+			http.Error(w, "transaction is owned by another node", http.StatusMisdirectedRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(n.Close)
+	return n
+}
+
+func (n *affinityNode) requests() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.tokens...)
+}
+
+func TestTransaction_Affinity(t *testing.T) {
+	a, b := newAffinityNode(t, "a"), newAffinityNode(t, "b")
+	client := NewClient(Config{Endpoints: []string{a.URL, b.URL}, LoadBalancer: RoundRobin()})
+	defer client.Close()
+	ctx := context.Background()
+
+	tx, err := client.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, a.URL, tx.Endpoint())
+	for i := 0; i < 3; i++ {
+		require.NoError(t, tx.Put(ctx, "relational", "users", "u1", map[string]int{"n": i}))
+	}
+	require.NoError(t, tx.Commit(ctx))
+	assert.Equal(t, []string{"node-a", "node-a", "node-a", "node-a"}, a.requests())
+	assert.Empty(t, b.requests())
+
+	// the node dies mid-transaction
+	tx, err = client.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	require.Equal(t, b.URL, tx.Endpoint())
+	b.Close()
+	err = tx.Put(ctx, "relational", "users", "u1", map[string]int{})
+	assert.ErrorIs(t, err, ErrTransactionNodeLost)
+	var nodeErr *TransactionNodeError
+	require.ErrorAs(t, err, &nodeErr)
+	assert.Equal(t, "tx-b", nodeErr.TransactionID)
+	assert.Equal(t, b.URL, nodeErr.Endpoint)
+	assert.ErrorIs(t, tx.Rollback(ctx), ErrTransactionNodeLost, "the transaction stays on its node")
+	assert.Len(t, a.requests(), 4)
+
+	// a proxy in front of the node reports that the transaction moved away
+	a.mu.Lock()
+	a.misdirects = true
+	a.mu.Unlock()
+	tx, err = client.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	err = tx.Delete(ctx, "relational", "users", "u1")
+	assert.ErrorIs(t, err, ErrTransactionNodeLost)
+	require.ErrorAs(t, err, &nodeErr)
+	assert.Equal(t, http.StatusMisdirectedRequest, HTTPStatus(nodeErr.Err))
+	requests := len(a.requests())
+	assert.ErrorIs(t, tx.Commit(ctx), ErrTransactionNodeLost)
+	assert.Len(t, a.requests(), requests, "requests of a lost transaction fail fast")
+}
+
+func TestTransaction_NodeLeftTopology(t *testing.T) {
+	var mu sync.Mutex
+	var members []ClusterMember
+	a, b := newAffinityNode(t, "a"), newAffinityNode(t, "b")
+	topology := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"members": members})
+	}))
+	defer topology.Close()
+	setLeader := func(leader, follower *affinityNode) {
+		mu.Lock()
+		defer mu.Unlock()
+		members = []ClusterMember{{ID: "n1", Endpoint: leader.URL, Role: RoleLeader}}
+		if follower != nil {
+			members = append(members, ClusterMember{ID: "n2", Endpoint: follower.URL, Role: RoleFollower})
+		}
+	}
+	setLeader(a, b)
+
+	client := NewClient(Config{
+		Endpoints: []string{a.URL},
+		Discovery: &DiscoveryOptions{Seeds: []string{topology.URL}, RefreshInterval: time.Hour},
+	})
+	defer client.Close()
+	ctx := context.Background()
+	require.NoError(t, client.RefreshTopology(ctx))
+
+	tx, err := client.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.Put(ctx, "relational", "users", "u1", map[string]int{}))
+
+	// leader failover: a is still reachable but no longer a member
+	setLeader(b, nil)
+	require.NoError(t, client.RefreshTopology(ctx))
+	err = tx.Put(ctx, "relational", "users", "u2", map[string]int{})
+	assert.ErrorIs(t, err, ErrTransactionNodeLost)
+	assert.ErrorContains(t, err, "left the cluster topology")
+	assert.Len(t, a.requests(), 1)
+	assert.Empty(t, b.requests(), "the new leader does not know the transaction")
+}
+
+func TestTransaction_TransientNodeError(t *testing.T) {
+	var attempts int32
+	node := newAffinityNode(t, "a")
+	handler := node.Config.Handler
+	node.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/transaction/begin" && atomic.AddInt32(&attempts, 1) == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			conn.Close()
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+	client := NewClient(Config{Endpoints: []string{node.URL}})
+	defer client.Close()
+	ctx := context.Background()
+
+	tx, err := client.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.Put(ctx, "relational", "users", "u1", map[string]int{}), "a dropped connection is retried on the node")
+	require.NoError(t, tx.Commit(ctx))
+	assert.EqualValues(t, 3, atomic.LoadInt32(&attempts))
+	assert.Equal(t, []string{"node-a", "node-a"}, node.requests())
+}
@@ -0,0 +1,387 @@
+package themisdb
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Pipeline is a sequence of aggregation stages, applied in order to the documents of
+// a collection
+type Pipeline []Stage
+
+// Stage is a step of an aggregation pipeline, built with Match, Group, Project, Sort,
+// Limit, or Lookup
+type Stage interface {
+	compile(p *pipelineCompiler) error
+}
+
+// Aggregate runs pipeline over the documents of collection and decodes the resulting
+// rows into result. The pipeline is compiled to AQL with all values passed as bind
+// variables; Pipeline.AQL shows the compiled query.
+//
+//	var revenue []struct {
+//		Country string  `json:"country"`
+//		Orders  int     `json:"orders"`
+//		Total   float64 `json:"total"`
+//	}
+//	err := client.Aggregate(ctx, "orders", themisdb.Pipeline{
+//		themisdb.Match(themisdb.Eq("status", "paid"), themisdb.Gte("total", 10)),
+//		themisdb.Group([]string{"country"}, themisdb.Count("orders"), themisdb.Sum("total", "total")),
+//		themisdb.Sort(themisdb.Desc("total")),
+//		themisdb.Limit(10),
+//	}, &revenue)
+func (c *Client) Aggregate(ctx context.Context, collection string, pipeline Pipeline, result interface{}) error {
+	aql, bindVars, err := pipeline.AQL(collection)
+	if err != nil {
+		return err
+	}
+	if err := c.QueryWithOptions(ctx, aql, &QueryOptions{BindVars: bindVars}, result); err != nil {
+		return fmt.Errorf("failed to aggregate %s: %w", collection, err)
+	}
+	return nil
+}
+
+// AQL compiles the pipeline over collection to an AQL query and its bind variables
+func (p Pipeline) AQL(collection string) (string, map[string]interface{}, error) {
+	if err := validateName("collection", collection); err != nil {
+		return "", nil, err
+	}
+	pc := &pipelineCompiler{row: "d0", bindVars: map[string]interface{}{}}
+	pc.lines = append(pc.lines, "FOR d0 IN "+quoteName(collection))
+	for i, stage := range p {
+		if stage == nil {
+			return "", nil, &ValidationError{Field: "pipeline", Value: fmt.Sprintf("stage %d", i), Reason: "must not be nil"}
+		}
+		if err := stage.compile(pc); err != nil {
+			return "", nil, err
+		}
+	}
+	pc.lines = append(pc.lines, "RETURN "+pc.row)
+	return strings.Join(pc.lines, "\n"), pc.bindVars, nil
+}
+
+// pipelineCompiler accumulates the AQL of a pipeline; row is the variable holding the
+// current row
+type pipelineCompiler struct {
+	lines    []string
+	row      string
+	vars     int
+	bindVars map[string]interface{}
+}
+
+// bind adds a bind variable and returns its reference
+func (pc *pipelineCompiler) bind(value interface{}) string {
+	name := fmt.Sprintf("p%d", len(pc.bindVars))
+	pc.bindVars[name] = value
+	return "@" + name
+}
+
+// newVar returns a fresh variable name
+func (pc *pipelineCompiler) newVar(prefix string) string {
+	pc.vars++
+	return fmt.Sprintf("%s%d", prefix, pc.vars)
+}
+
+// let binds expr to a fresh row variable that becomes the current row
+func (pc *pipelineCompiler) let(expr string) {
+	pc.row = pc.newVar("d")
+	pc.lines = append(pc.lines, fmt.Sprintf("LET %s = %s", pc.row, expr))
+}
+
+// fieldPath returns the AQL attribute path of a dotted field within variable v
+func fieldPath(v, field string) (string, error) {
+	if field == "" {
+		return "", &ValidationError{Field: "field", Value: field, Reason: "must not be empty"}
+	}
+	var b strings.Builder
+	b.WriteString(v)
+	for _, part := range strings.Split(field, ".") {
+		if part == "" || strings.ContainsAny(part, "`\n") {
+			return "", &ValidationError{Field: "field", Value: field, Reason: "invalid path"}
+		}
+		b.WriteString("." + quoteName(part))
+	}
+	return b.String(), nil
+}
+
+// objectKey returns name as a quoted AQL object key
+func objectKey(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, "`\n") {
+		return "", &ValidationError{Field: "output field", Value: name, Reason: "invalid name"}
+	}
+	return quoteName(name), nil
+}
+
+// quoteName returns name as a quoted AQL identifier
+func quoteName(name string) string {
+	return "`" + name + "`"
+}
+
+// Condition is a comparison of a field with a value, used by Match
+type Condition struct {
+	Field string
+	// Op is one of ==, !=, <, <=, >, >=, IN
+	Op    string
+	Value interface{}
+}
+
+// Eq matches documents whose field equals value
+func Eq(field string, value interface{}) Condition { return Condition{field, "==", value} }
+
+// Ne matches documents whose field differs from value
+func Ne(field string, value interface{}) Condition { return Condition{field, "!=", value} }
+
+// Gt matches documents whose field is greater than value
+func Gt(field string, value interface{}) Condition { return Condition{field, ">", value} }
+
+// Gte matches documents whose field is greater than or equal to value
+func Gte(field string, value interface{}) Condition { return Condition{field, ">=", value} }
+
+// Lt matches documents whose field is less than value
+func Lt(field string, value interface{}) Condition { return Condition{field, "<", value} }
+
+// Lte matches documents whose field is less than or equal to value
+func Lte(field string, value interface{}) Condition { return Condition{field, "<=", value} }
+
+// In matches documents whose field is one of values, which must be a slice
+func In(field string, values interface{}) Condition { return Condition{field, "IN", values} }
+
+type matchStage struct {
+	conditions []Condition
+}
+
+// Match keeps the rows that satisfy all conditions
+func Match(conditions ...Condition) Stage {
+	return &matchStage{conditions: conditions}
+}
+
+func (s *matchStage) compile(pc *pipelineCompiler) error {
+	if len(s.conditions) == 0 {
+		return &ValidationError{Field: "match", Value: "", Reason: "needs at least one condition"}
+	}
+	exprs := make([]string, len(s.conditions))
+	for i, cond := range s.conditions {
+		switch cond.Op {
+		case "==", "!=", "<", "<=", ">", ">=", "IN":
+		default:
+			return &ValidationError{Field: "match operator", Value: cond.Op, Reason: "unsupported"}
+		}
+		path, err := fieldPath(pc.row, cond.Field)
+		if err != nil {
+			return err
+		}
+		exprs[i] = fmt.Sprintf("%s %s %s", path, cond.Op, pc.bind(cond.Value))
+	}
+	pc.lines = append(pc.lines, "FILTER "+strings.Join(exprs, " AND "))
+	return nil
+}
+
+// Accumulator computes a value over the rows of a group, built with Count, Sum, Avg,
+// Min, or Max
+type Accumulator struct {
+	// As is the output field
+	As    string
+	fn    string
+	field string
+}
+
+// Count counts the rows of the group into as
+func Count(as string) Accumulator { return Accumulator{As: as, fn: "COUNT"} }
+
+// Sum adds up field over the group into as
+func Sum(as, field string) Accumulator { return Accumulator{As: as, fn: "SUM", field: field} }
+
+// Avg averages field over the group into as
+func Avg(as, field string) Accumulator { return Accumulator{As: as, fn: "AVG", field: field} }
+
+// Min stores the smallest field value of the group in as
+func Min(as, field string) Accumulator { return Accumulator{As: as, fn: "MIN", field: field} }
+
+// Max stores the largest field value of the group in as
+func Max(as, field string) Accumulator { return Accumulator{As: as, fn: "MAX", field: field} }
+
+type groupStage struct {
+	keys         []string
+	accumulators []Accumulator
+}
+
+// Group combines rows with equal values of the key fields into one row holding the
+// keys, under their dotted paths, and the accumulated values. Without keys all rows
+// form a single group.
+func Group(keys []string, accumulators ...Accumulator) Stage {
+	return &groupStage{keys: keys, accumulators: accumulators}
+}
+
+func (s *groupStage) compile(pc *pipelineCompiler) error {
+	if len(s.keys) == 0 && len(s.accumulators) == 0 {
+		return &ValidationError{Field: "group", Value: "", Reason: "needs keys or accumulators"}
+	}
+	var collect, aggregate, fields []string
+	for _, key := range s.keys {
+		path, err := fieldPath(pc.row, key)
+		if err != nil {
+			return err
+		}
+		name, err := objectKey(key)
+		if err != nil {
+			return err
+		}
+		v := pc.newVar("g")
+		collect = append(collect, fmt.Sprintf("%s = %s", v, path))
+		fields = append(fields, fmt.Sprintf("%s: %s", name, v))
+	}
+	for _, acc := range s.accumulators {
+		name, err := objectKey(acc.As)
+		if err != nil {
+			return err
+		}
+		arg := "1"
+		if acc.fn != "COUNT" {
+			if arg, err = fieldPath(pc.row, acc.field); err != nil {
+				return err
+			}
+		}
+		v := pc.newVar("a")
+		aggregate = append(aggregate, fmt.Sprintf("%s = %s(%s)", v, acc.fn, arg))
+		fields = append(fields, fmt.Sprintf("%s: %s", name, v))
+	}
+
+	line := "COLLECT"
+	if len(collect) > 0 {
+		line += " " + strings.Join(collect, ", ")
+	}
+	if len(aggregate) > 0 {
+		line += " AGGREGATE " + strings.Join(aggregate, ", ")
+	}
+	pc.lines = append(pc.lines, line)
+	pc.let("{" + strings.Join(fields, ", ") + "}")
+	return nil
+}
+
+type projectStage struct {
+	fields map[string]string
+}
+
+// Project replaces every row by an object with the given output fields, each mapped to
+// the dotted path of its source field, e.g. {"city": "address.city"}
+func Project(fields map[string]string) Stage {
+	return &projectStage{fields: fields}
+}
+
+func (s *projectStage) compile(pc *pipelineCompiler) error {
+	if len(s.fields) == 0 {
+		return &ValidationError{Field: "project", Value: "", Reason: "needs at least one field"}
+	}
+	outputs := make([]string, 0, len(s.fields))
+	for out := range s.fields {
+		outputs = append(outputs, out)
+	}
+	sort.Strings(outputs)
+
+	fields := make([]string, len(outputs))
+	for i, out := range outputs {
+		name, err := objectKey(out)
+		if err != nil {
+			return err
+		}
+		path, err := fieldPath(pc.row, s.fields[out])
+		if err != nil {
+			return err
+		}
+		fields[i] = fmt.Sprintf("%s: %s", name, path)
+	}
+	pc.let("{" + strings.Join(fields, ", ") + "}")
+	return nil
+}
+
+// SortKey is a field to sort by, built with Asc or Desc
+type SortKey struct {
+	Field      string
+	Descending bool
+}
+
+// Asc sorts by field in ascending order
+func Asc(field string) SortKey { return SortKey{Field: field} }
+
+// Desc sorts by field in descending order
+func Desc(field string) SortKey { return SortKey{Field: field, Descending: true} }
+
+type sortStage struct {
+	keys []SortKey
+}
+
+// Sort orders the rows by keys, the first key taking precedence
+func Sort(keys ...SortKey) Stage {
+	return &sortStage{keys: keys}
+}
+
+func (s *sortStage) compile(pc *pipelineCompiler) error {
+	if len(s.keys) == 0 {
+		return &ValidationError{Field: "sort", Value: "", Reason: "needs at least one key"}
+	}
+	exprs := make([]string, len(s.keys))
+	for i, key := range s.keys {
+		path, err := fieldPath(pc.row, key.Field)
+		if err != nil {
+			return err
+		}
+		exprs[i] = path + " ASC"
+		if key.Descending {
+			exprs[i] = path + " DESC"
+		}
+	}
+	pc.lines = append(pc.lines, "SORT "+strings.Join(exprs, ", "))
+	return nil
+}
+
+type limitStage struct {
+	n int
+}
+
+// Limit keeps the first n rows
+func Limit(n int) Stage {
+	return &limitStage{n: n}
+}
+
+func (s *limitStage) compile(pc *pipelineCompiler) error {
+	if s.n < 0 {
+		return &ValidationError{Field: "limit", Value: fmt.Sprint(s.n), Reason: "must not be negative"}
+	}
+	pc.lines = append(pc.lines, fmt.Sprintf("LIMIT %d", s.n))
+	return nil
+}
+
+type lookupStage struct {
+	from, localField, foreignField, as string
+}
+
+// Lookup adds to every row the field as, an array of the documents of collection from
+// whose foreignField equals the row's localField, e.g.
+// Lookup("customers", "customer_id", "_key", "customer")
+func Lookup(from, localField, foreignField, as string) Stage {
+	return &lookupStage{from: from, localField: localField, foreignField: foreignField, as: as}
+}
+
+func (s *lookupStage) compile(pc *pipelineCompiler) error {
+	if err := validateName("collection", s.from); err != nil {
+		return err
+	}
+	local, err := fieldPath(pc.row, s.localField)
+	if err != nil {
+		return err
+	}
+	j := pc.newVar("j")
+	foreign, err := fieldPath(j, s.foreignField)
+	if err != nil {
+		return err
+	}
+	name, err := objectKey(s.as)
+	if err != nil {
+		return err
+	}
+	pc.let(fmt.Sprintf("MERGE(%s, {%s: (FOR %s IN %s FILTER %s == %s RETURN %s)})",
+		pc.row, name, j, quoteName(s.from), foreign, local, j))
+	return nil
+}
@@ -0,0 +1,86 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipeline_AQL(t *testing.T) {
+	aql, bindVars, err := Pipeline{
+		Match(Eq("status", "paid"), Gte("total", 10), In("address.country", []string{"DE", "AT"})),
+		Lookup("customers", "customer_id", "_key", "customer"),
+		Group([]string{"address.country"}, Count("orders"), Sum("revenue", "total"), Max("largest", "total")),
+		Sort(Desc("revenue"), Asc("address.country")),
+		Limit(5),
+		Project(map[string]string{"country": "address.country", "revenue": "revenue"}),
+	}.AQL("orders")
+	require.NoError(t, err)
+
+	assert.Equal(t, "FOR d0 IN `orders`\n"+
+		"FILTER d0.`status` == @p0 AND d0.`total` >= @p1 AND d0.`address`.`country` IN @p2\n"+
+		"LET d2 = MERGE(d0, {`customer`: (FOR j1 IN `customers` FILTER j1.`_key` == d0.`customer_id` RETURN j1)})\n"+
+		"COLLECT g3 = d2.`address`.`country` AGGREGATE a4 = COUNT(1), a5 = SUM(d2.`total`), a6 = MAX(d2.`total`)\n"+
+		"LET d7 = {`address.country`: g3, `orders`: a4, `revenue`: a5, `largest`: a6}\n"+
+		"SORT d7.`revenue` DESC, d7.`address`.`country` ASC\n"+
+		"LIMIT 5\n"+
+		"LET d8 = {`country`: d7.`address`.`country`, `revenue`: d7.`revenue`}\n"+
+		"RETURN d8", aql)
+	assert.Equal(t, map[string]interface{}{"p0": "paid", "p1": 10, "p2": []string{"DE", "AT"}}, bindVars)
+}
+
+func TestPipeline_AQL_GroupAll(t *testing.T) {
+	aql, bindVars, err := Pipeline{Group(nil, Avg("avg", "total"))}.AQL("orders")
+	require.NoError(t, err)
+	assert.Equal(t, "FOR d0 IN `orders`\nCOLLECT AGGREGATE a1 = AVG(d0.`total`)\nLET d2 = {`avg`: a1}\nRETURN d2", aql)
+	assert.Empty(t, bindVars)
+}
+
+func TestPipeline_AQL_Invalid(t *testing.T) {
+	for name, pipeline := range map[string]Pipeline{
+		"empty match":     {Match()},
+		"operator":        {Match(Condition{Field: "a", Op: "LIKE", Value: "x"})},
+		"field injection": {Match(Eq("a` == 1 OR `b", 1))},
+		"empty path":      {Sort(Asc("a..b"))},
+		"empty group":     {Group(nil)},
+		"output name":     {Project(map[string]string{"": "a"})},
+		"negative limit":  {Limit(-1)},
+		"lookup target":   {Lookup("", "a", "b", "c")},
+		"nil stage":       {nil},
+	} {
+		_, _, err := pipeline.AQL("orders")
+		assert.ErrorIs(t, err, ErrInvalidInput, name)
+	}
+	_, _, err := Pipeline{Limit(1)}.AQL("bad name")
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestClient_Aggregate(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query    string                 `json:"query"`
+			BindVars map[string]interface{} `json:"bind_vars"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Contains(t, body.Query, "COLLECT g1 = d0.`country` AGGREGATE a2 = COUNT(1)")
+		assert.Equal(t, map[string]interface{}{"p0": "paid"}, body.BindVars)
+		w.Write([]byte(`{"data":[{"country":"DE","orders":3},{"country":"FR","orders":1}]}`))
+	})
+
+	var rows []struct {
+		Country string `json:"country"`
+		Orders  int    `json:"orders"`
+	}
+	err := client.Aggregate(context.Background(), "orders", Pipeline{
+		Match(Eq("status", "paid")),
+		Group([]string{"country"}, Count("orders")),
+	}, &rows)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "DE", rows[0].Country)
+	assert.Equal(t, 3, rows[0].Orders)
+}
@@ -0,0 +1,290 @@
+package themisdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Change is a write observed on the server changefeed
+type Change struct {
+	Sequence uint64 `json:"sequence"`
+	// Type is "PUT" or "DELETE"
+	Type       string `json:"type"`
+	Collection string `json:"collection"`
+	UUID       string `json:"uuid"`
+	// Document is the written document of a PUT
+	Document json.RawMessage `json:"document,omitempty"`
+	// Time is when the server recorded the write
+	Time time.Time `json:"time"`
+}
+
+// Alert is raised when a change triggers an alert rule
+type Alert struct {
+	Rule    string    `json:"rule"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+	// Change is the change that triggered the rule
+	Change Change `json:"change"`
+}
+
+// AlertRule decides which changes raise alerts. Observe is called with every watched
+// change in sequence order from a single goroutine, so rules may keep state without
+// locking; it returns a message if the change triggers the rule.
+type AlertRule interface {
+	Name() string
+	Observe(change Change) (message string, triggered bool)
+}
+
+// AlertOptions holds change stream alerting configuration
+type AlertOptions struct {
+	Rules []AlertRule
+	// Collections restricts the watched changes; empty watches all collections
+	Collections []string
+	// Cooldown suppresses further alerts of a rule for this long after it fired
+	// (default: 1m)
+	Cooldown time.Duration
+	// PollTimeout is the long-poll timeout of changefeed requests (default: 20s)
+	PollTimeout time.Duration
+	// OnAlert is called with every alert
+	OnAlert func(Alert)
+	// WebhookURL, if set, receives every alert as a JSON POST request
+	WebhookURL string
+	// WebhookClient sends webhook requests (default: http.DefaultClient)
+	WebhookClient *http.Client
+	// OnError is called with changefeed and webhook errors
+	OnError func(error)
+}
+
+// Alerter evaluates alert rules against the server changefeed, providing lightweight
+// operational alerting without a separate CDC pipeline
+type Alerter struct {
+	client *Client
+	opts   AlertOptions
+	fired  map[string]time.Time
+}
+
+// Alerter returns an alerter; Run starts watching
+func (c *Client) Alerter(opts AlertOptions) *Alerter {
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = time.Minute
+	}
+	if opts.PollTimeout <= 0 {
+		opts.PollTimeout = 20 * time.Second
+	}
+	if opts.WebhookClient == nil {
+		opts.WebhookClient = http.DefaultClient
+	}
+	return &Alerter{client: c, opts: opts, fired: map[string]time.Time{}}
+}
+
+// Run evaluates the rules against every change made after Run was called until ctx is
+// done, and returns ctx.Err(). Changefeed errors are retried with backoff and reported
+// to OnError.
+func (a *Alerter) Run(ctx context.Context) error {
+	var latest struct {
+		LatestSequence uint64 `json:"latest_sequence"`
+	}
+	if err := a.client.request(ctx, "GET", "/changefeed?limit=0", nil, &latest, nil); err != nil {
+		return fmt.Errorf("failed to read changefeed: %w", err)
+	}
+	seq := latest.LatestSequence
+
+	backoff := 100 * time.Millisecond
+	for ctx.Err() == nil {
+		query := url.Values{}
+		query.Set("from_seq", strconv.FormatUint(seq, 10))
+		query.Set("limit", "100")
+		query.Set("long_poll_ms", strconv.FormatInt(a.opts.PollTimeout.Milliseconds(), 10))
+		if len(a.opts.Collections) == 1 {
+			query.Set("key_prefix", a.opts.Collections[0]+":")
+		}
+
+		var result struct {
+			Events []changeEvent `json:"events"`
+		}
+		if err := a.client.request(ctx, "GET", "/changefeed?"+query.Encode(), nil, &result, nil); err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			a.report(fmt.Errorf("failed to watch changefeed: %w", err))
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+			}
+			if backoff < 10*time.Second {
+				backoff *= 2
+			}
+			continue
+		}
+		backoff = 100 * time.Millisecond
+
+		for _, event := range result.Events {
+			seq = event.Sequence
+			if change, ok := a.change(event); ok {
+				a.observe(ctx, change)
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+// change converts a changefeed event of a watched collection into a Change
+func (a *Alerter) change(event changeEvent) (Change, bool) {
+	change, ok := event.change()
+	if !ok || (len(a.opts.Collections) > 0 && !containsString(a.opts.Collections, change.Collection)) {
+		return Change{}, false
+	}
+	return change, true
+}
+
+// observe passes a change to every rule and raises the alerts of triggered rules that
+// are not cooling down
+func (a *Alerter) observe(ctx context.Context, change Change) {
+	for _, rule := range a.opts.Rules {
+		message, triggered := rule.Observe(change)
+		if !triggered {
+			continue
+		}
+		name := rule.Name()
+		if last, ok := a.fired[name]; ok && change.Time.Sub(last) < a.opts.Cooldown {
+			continue
+		}
+		a.fired[name] = change.Time
+
+		alert := Alert{Rule: name, Message: message, Time: change.Time, Change: change}
+		if a.opts.OnAlert != nil {
+			a.opts.OnAlert(alert)
+		}
+		if a.opts.WebhookURL != "" {
+			if err := a.post(ctx, alert); err != nil {
+				a.report(fmt.Errorf("failed to send alert %s: %w", name, err))
+			}
+		}
+	}
+}
+
+// post sends an alert to the webhook
+func (a *Alerter) post(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", a.opts.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := a.opts.WebhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// report passes err to OnError
+func (a *Alerter) report(err error) {
+	if a.opts.OnError != nil {
+		a.opts.OnError(err)
+	}
+}
+
+// rateRule fires when too many changes of one type happen within a window
+type rateRule struct {
+	name       string
+	collection string
+	changeType string
+	threshold  int
+	window     time.Duration
+	times      []time.Time
+}
+
+// RateAlert fires when at least threshold changes of changeType ("PUT" or "DELETE") to
+// collection happen within window, e.g. to detect a spike of deletes. An empty
+// collection matches all watched collections.
+func RateAlert(name, collection, changeType string, threshold int, window time.Duration) AlertRule {
+	return &rateRule{name: name, collection: collection, changeType: changeType, threshold: threshold, window: window}
+}
+
+func (r *rateRule) Name() string { return r.name }
+
+func (r *rateRule) Observe(change Change) (string, bool) {
+	if change.Type != r.changeType || (r.collection != "" && change.Collection != r.collection) {
+		return "", false
+	}
+	r.times = append(r.times, change.Time)
+	cutoff := change.Time.Add(-r.window)
+	i := 0
+	for i < len(r.times) && !r.times[i].After(cutoff) {
+		i++
+	}
+	r.times = r.times[i:]
+	if len(r.times) < r.threshold {
+		return "", false
+	}
+	return fmt.Sprintf("%d %s changes to %s within %s", len(r.times), r.changeType, change.Collection, r.window), true
+}
+
+// flipRule fires when a field changes between two values
+type flipRule struct {
+	name       string
+	collection string
+	field      string
+	from, to   []byte
+	last       map[string]string
+}
+
+// FieldFlipAlert fires when the dotted field of a document in collection changes from
+// the value from to the value to, e.g. FieldFlipAlert("admin-granted", "users", "role",
+// "user", "admin"). A nil from or to matches any value, so nil for both fires on every
+// change of the field. Field values are remembered per document from the first change
+// observed, so the first write of each document after Run never fires.
+func FieldFlipAlert(name, collection, field string, from, to interface{}) AlertRule {
+	r := &flipRule{name: name, collection: collection, field: field, last: map[string]string{}}
+	if from != nil {
+		r.from, _ = json.Marshal(from)
+	}
+	if to != nil {
+		r.to, _ = json.Marshal(to)
+	}
+	return r
+}
+
+func (r *flipRule) Name() string { return r.name }
+
+func (r *flipRule) Observe(change Change) (string, bool) {
+	if change.Collection != r.collection {
+		return "", false
+	}
+	if change.Type == "DELETE" {
+		delete(r.last, change.UUID)
+		return "", false
+	}
+
+	var doc map[string]interface{}
+	if json.Unmarshal(change.Document, &doc) != nil {
+		return "", false
+	}
+	value, _ := lookupField(doc, r.field)
+	encoded, _ := json.Marshal(value)
+	current := string(encoded)
+
+	previous, seen := r.last[change.UUID]
+	r.last[change.UUID] = current
+	if !seen || previous == current {
+		return "", false
+	}
+	if (r.from != nil && previous != string(r.from)) || (r.to != nil && current != string(r.to)) {
+		return "", false
+	}
+	return fmt.Sprintf("%s of %s/%s changed from %s to %s", r.field, change.Collection, change.UUID, previous, current), true
+}
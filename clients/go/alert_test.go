@@ -0,0 +1,125 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateAlert(t *testing.T) {
+	rule := RateAlert("delete-spike", "orders", "DELETE", 3, time.Minute)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	observe := func(typ, collection string, offset time.Duration) bool {
+		_, triggered := rule.Observe(Change{Type: typ, Collection: collection, UUID: "o1", Time: start.Add(offset)})
+		return triggered
+	}
+
+	assert.False(t, observe("DELETE", "orders", 0))
+	assert.False(t, observe("PUT", "orders", time.Second))
+	assert.False(t, observe("DELETE", "users", time.Second))
+	assert.False(t, observe("DELETE", "orders", 30*time.Second))
+	assert.True(t, observe("DELETE", "orders", 50*time.Second))
+	// only the delete at 50s is still within the window
+	assert.False(t, observe("DELETE", "orders", 95*time.Second))
+	assert.True(t, observe("DELETE", "orders", 100*time.Second))
+}
+
+func TestFieldFlipAlert(t *testing.T) {
+	rule := FieldFlipAlert("admin-granted", "users", "access.role", "user", "admin")
+	observe := func(uuid, role string) (string, bool) {
+		doc, _ := json.Marshal(map[string]interface{}{"access": map[string]string{"role": role}})
+		return rule.Observe(Change{Type: "PUT", Collection: "users", UUID: uuid, Document: doc})
+	}
+
+	_, triggered := observe("u1", "admin")
+	assert.False(t, triggered, "first observation has no previous value")
+	_, triggered = observe("u2", "user")
+	assert.False(t, triggered)
+	message, triggered := observe("u2", "admin")
+	assert.True(t, triggered)
+	assert.Equal(t, `access.role of users/u2 changed from "user" to "admin"`, message)
+	_, triggered = observe("u2", "admin")
+	assert.False(t, triggered, "unchanged value")
+
+	rule.Observe(Change{Type: "DELETE", Collection: "users", UUID: "u2"})
+	_, triggered = observe("u2", "user")
+	assert.False(t, triggered, "deleted documents are forgotten")
+
+	anyFlip := FieldFlipAlert("status-changed", "users", "status", nil, nil)
+	anyFlip.Observe(Change{Type: "PUT", Collection: "users", UUID: "u1", Document: json.RawMessage(`{}`)})
+	message, triggered = anyFlip.Observe(Change{Type: "PUT", Collection: "users", UUID: "u1", Document: json.RawMessage(`{"status":"blocked"}`)})
+	assert.True(t, triggered)
+	assert.Equal(t, `status of users/u1 changed from null to "blocked"`, message)
+}
+
+func TestAlerter_Run(t *testing.T) {
+	server := &changefeedServer{store: &memoryStore{docs: map[string]map[string]interface{}{}, revisions: map[string]int{}}}
+	client := newTestClient(t, server.serveHTTP)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hooks := make(chan Alert, 10)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert Alert
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&alert))
+		hooks <- alert
+	}))
+	defer webhook.Close()
+
+	require.NoError(t, client.Put(ctx, "relational", "orders", "o1", map[string]string{"status": "open"}))
+	alerts := make(chan Alert, 10)
+	alerter := client.Alerter(AlertOptions{
+		Rules: []AlertRule{
+			RateAlert("delete-spike", "orders", "DELETE", 2, time.Minute),
+			FieldFlipAlert("reopened", "orders", "status", "closed", "open"),
+		},
+		Collections: []string{"orders"},
+		PollTimeout: 50 * time.Millisecond,
+		OnAlert:     func(alert Alert) { alerts <- alert },
+		WebhookURL:  webhook.URL,
+	})
+	done := make(chan error, 1)
+	go func() { done <- alerter.Run(ctx) }()
+
+	// wait until the alerter follows the changefeed, then trigger both rules
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, client.Put(ctx, "relational", "orders", "o2", map[string]string{"status": "closed"}))
+	require.NoError(t, client.Put(ctx, "relational", "orders", "o2", map[string]string{"status": "open"}))
+	require.NoError(t, client.Put(ctx, "relational", "users", "u1", map[string]string{"status": "open"}))
+	require.NoError(t, client.Delete(ctx, "relational", "orders", "o1"))
+	require.NoError(t, client.Delete(ctx, "relational", "orders", "o2"))
+	require.NoError(t, client.Put(ctx, "relational", "orders", "o3", map[string]string{"status": "new"}))
+	require.NoError(t, client.Delete(ctx, "relational", "orders", "o3"))
+
+	var fired []Alert
+	for len(fired) < 2 {
+		select {
+		case alert := <-alerts:
+			fired = append(fired, alert)
+			assert.Equal(t, alert.Rule, (<-hooks).Rule)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("alerts not raised, got %+v", fired)
+		}
+	}
+	assert.Equal(t, "reopened", fired[0].Rule)
+	assert.Equal(t, "o2", fired[0].Change.UUID)
+	assert.JSONEq(t, `{"status":"open"}`, string(fired[0].Change.Document))
+	assert.Equal(t, "delete-spike", fired[1].Rule)
+	assert.Equal(t, "DELETE", fired[1].Change.Type)
+
+	// the third delete falls into the cooldown of delete-spike
+	select {
+	case alert := <-alerts:
+		t.Fatalf("unexpected alert %+v", alert)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
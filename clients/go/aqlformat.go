@@ -0,0 +1,330 @@
+package themisdb
+
+import (
+	"strconv"
+	"strings"
+)
+
+// aqlTokenKind classifies the tokens of an AQL query
+type aqlTokenKind int
+
+const (
+	aqlIdent aqlTokenKind = iota
+	aqlKeyword
+	aqlString
+	aqlNumber
+	aqlBindVar
+	aqlOperator
+	aqlPunct
+	aqlComment
+)
+
+// aqlToken is a token of an AQL query
+type aqlToken struct {
+	kind aqlTokenKind
+	text string
+	// unary marks a sign or negation operator applying to the next token
+	unary bool
+}
+
+// aqlKeywords are the reserved words of AQL, which are case-insensitive
+var aqlKeywords = map[string]bool{
+	"FOR": true, "IN": true, "FILTER": true, "LET": true, "SORT": true, "LIMIT": true,
+	"COLLECT": true, "INTO": true, "RETURN": true, "DISTINCT": true, "INSERT": true,
+	"UPDATE": true, "REPLACE": true, "REMOVE": true, "UPSERT": true, "WITH": true,
+	"AND": true, "OR": true, "NOT": true, "LIKE": true, "ASC": true, "DESC": true,
+	"OPTIONS": true, "AGGREGATE": true, "KEEP": true, "COUNT": true, "GRAPH": true,
+	"OUTBOUND": true, "INBOUND": true, "ANY": true, "ALL": true, "NONE": true,
+	"SHORTEST_PATH": true, "K_SHORTEST_PATHS": true, "PRUNE": true, "SEARCH": true,
+	"WINDOW": true, "NULL": true, "TRUE": true, "FALSE": true,
+}
+
+// aqlClauses are the keywords that start a clause on a new line in FormatAQL
+var aqlClauses = map[string]bool{
+	"FOR": true, "FILTER": true, "LET": true, "SORT": true, "LIMIT": true, "COLLECT": true,
+	"RETURN": true, "INSERT": true, "UPDATE": true, "REPLACE": true, "REMOVE": true,
+	"UPSERT": true, "PRUNE": true, "SEARCH": true, "WINDOW": true,
+}
+
+// aqlOperators are the operators of two characters
+var aqlOperators = []string{"==", "!=", "<=", ">=", "&&", "||", "..", "=~", "!~", "::"}
+
+// tokenizeAQL splits aql into tokens. It never fails: unterminated strings and
+// comments extend to the end of the query and unknown characters become operators,
+// leaving their diagnosis to the server.
+func tokenizeAQL(aql string) []aqlToken {
+	var tokens []aqlToken
+	// depth is the nesting of brackets, ternaries the depths of open ternary
+	// operators, whose colon is an operator rather than an attribute separator
+	var depth int
+	var ternaries []int
+	for i := 0; i < len(aql); {
+		c := aql[i]
+		start := i
+		var t aqlToken
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case strings.HasPrefix(aql[i:], "//"):
+			i = len(aql)
+			if end := strings.IndexByte(aql[start:], '\n'); end >= 0 {
+				i = start + end
+			}
+			t = aqlToken{kind: aqlComment, text: strings.TrimRight(aql[start:i], " \t\r")}
+		case strings.HasPrefix(aql[i:], "/*"):
+			i = len(aql)
+			if end := strings.Index(aql[start+2:], "*/"); end >= 0 {
+				i = start + 2 + end + 2
+			}
+			t = aqlToken{kind: aqlComment, text: aql[start:i]}
+		case c == '\'' || c == '"' || c == '`':
+			for i++; i < len(aql) && aql[i] != c; i++ {
+				if aql[i] == '\\' {
+					i++
+				}
+			}
+			i = min(i+1, len(aql))
+			t = aqlToken{kind: aqlString, text: aql[start:i]}
+			if c == '`' {
+				t.kind = aqlIdent
+			}
+		case isDigit(c):
+			for i < len(aql) && isDigit(aql[i]) {
+				i++
+			}
+			if i+1 < len(aql) && aql[i] == '.' && isDigit(aql[i+1]) {
+				for i++; i < len(aql) && isDigit(aql[i]); i++ {
+				}
+			}
+			if i < len(aql) && (aql[i] == 'e' || aql[i] == 'E') {
+				j := i + 1
+				if j < len(aql) && (aql[j] == '+' || aql[j] == '-') {
+					j++
+				}
+				if j < len(aql) && isDigit(aql[j]) {
+					for i = j; i < len(aql) && isDigit(aql[i]); i++ {
+					}
+				}
+			}
+			t = aqlToken{kind: aqlNumber, text: aql[start:i]}
+		case c == '@':
+			for i++; i < len(aql) && (aql[i] == '@' && i == start+1 || isIdentChar(aql[i])); i++ {
+			}
+			t = aqlToken{kind: aqlBindVar, text: aql[start:i]}
+		case isIdentChar(c):
+			for i < len(aql) && isIdentChar(aql[i]) {
+				i++
+			}
+			t = aqlToken{kind: aqlIdent, text: aql[start:i]}
+			if word := strings.ToUpper(t.text); aqlKeywords[word] && isAQLKeyword(tokens, word, aql[i:]) {
+				t = aqlToken{kind: aqlKeyword, text: word}
+			}
+		case strings.ContainsRune("()[]{},:.;", rune(c)):
+			i++
+			t = aqlToken{kind: aqlPunct, text: aql[start:i]}
+			for _, op := range aqlOperators {
+				if strings.HasPrefix(aql[start:], op) {
+					i = start + len(op)
+					t = aqlToken{kind: aqlOperator, text: op}
+				}
+			}
+			switch t.text {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				depth--
+			case ":":
+				if n := len(ternaries); n > 0 && ternaries[n-1] == depth {
+					ternaries = ternaries[:n-1]
+					t.kind = aqlOperator
+				}
+			}
+		default:
+			i++
+			t = aqlToken{kind: aqlOperator, text: aql[start:i]}
+			for _, op := range aqlOperators {
+				if strings.HasPrefix(aql[start:], op) {
+					i = start + len(op)
+					t.text = op
+				}
+			}
+			switch t.text {
+			case "-", "+", "!":
+				t.unary = len(tokens) == 0 || opensOperand(tokens[len(tokens)-1])
+			case "?":
+				ternaries = append(ternaries, depth)
+			}
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens
+}
+
+// isAQLKeyword reports whether the word word, followed by rest, is used as a keyword:
+// attribute names after a dot or before a colon and function calls such as COUNT(x)
+// are not
+func isAQLKeyword(tokens []aqlToken, word, rest string) bool {
+	if n := len(tokens); n > 0 && (tokens[n-1].text == "." || tokens[n-1].text == "::") {
+		return false
+	}
+	next := strings.TrimLeft(rest, " \t\r\n")
+	if strings.HasPrefix(next, ":") && !strings.HasPrefix(next, "::") {
+		return false
+	}
+	return !strings.HasPrefix(rest, "(") || aqlClauses[word] || word == "AND" || word == "OR" || word == "NOT" || word == "IN"
+}
+
+// opensOperand reports whether an operand is expected after t, so that a following
+// sign is unary
+func opensOperand(t aqlToken) bool {
+	switch t.kind {
+	case aqlOperator:
+		return true
+	case aqlKeyword:
+		return t.text != "NULL" && t.text != "TRUE" && t.text != "FALSE"
+	case aqlPunct:
+		return t.text != ")" && t.text != "]" && t.text != "}"
+	}
+	return false
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || isDigit(c) || (c|0x20 >= 'a' && c|0x20 <= 'z')
+}
+
+// aqlSpace reports whether a space separates prev and next
+func aqlSpace(prev, next aqlToken) bool {
+	switch {
+	case prev.unary:
+		return false
+	case prev.kind == aqlPunct && strings.Contains("([{.", prev.text), prev.text == "::" || prev.text == "..":
+		return false
+	case next.kind == aqlPunct && strings.Contains(")]},.:;", next.text), next.text == "::" || next.text == "..":
+		return false
+	case next.text == "(":
+		return prev.kind != aqlIdent
+	case next.text == "[":
+		return prev.kind != aqlIdent && prev.kind != aqlBindVar && prev.text != ")" && prev.text != "]"
+	case prev.text == "*" && (next.text == "*" || next.text == "]"):
+		return false
+	}
+	return true
+}
+
+// renderAQL writes tokens with normalized spacing, on one line or with one clause
+// per line and subqueries indented
+func renderAQL(tokens []aqlToken, pretty bool) string {
+	var b strings.Builder
+	var blocks []bool
+	depth := 0
+	lineStart := true
+	newline := func() {
+		b.WriteByte('\n')
+		b.WriteString(strings.Repeat("  ", depth))
+		lineStart = true
+	}
+
+	var prev aqlToken
+	for i, t := range tokens {
+		if t.kind == aqlComment && !pretty {
+			continue
+		}
+		if pretty && !lineStart {
+			switch {
+			case t.kind == aqlKeyword && aqlClauses[t.text]:
+				newline()
+			case t.text == ")" && len(blocks) > 0 && blocks[len(blocks)-1]:
+				depth--
+				newline()
+			case prev.kind == aqlComment && strings.HasPrefix(prev.text, "//"):
+				newline()
+			}
+		} else if t.text == ")" && len(blocks) > 0 && blocks[len(blocks)-1] {
+			depth--
+		}
+		if !lineStart && aqlSpace(prev, t) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(t.text)
+		lineStart = false
+		prev = t
+
+		switch t.text {
+		case "(":
+			block := i+1 < len(tokens) && tokens[i+1].kind == aqlKeyword && aqlClauses[tokens[i+1].text]
+			blocks = append(blocks, block)
+			if block {
+				depth++
+				if pretty {
+					newline()
+				}
+			}
+		case ")":
+			if len(blocks) > 0 {
+				blocks = blocks[:len(blocks)-1]
+			}
+		}
+	}
+	return b.String()
+}
+
+// FormatAQL lays out aql in a stable, readable form: keywords upper case, one clause
+// per line, subqueries indented, and single spaces between tokens. Comments are kept.
+// Formatting does not change the meaning of the query.
+func FormatAQL(aql string) string {
+	return renderAQL(tokenizeAQL(aql), true)
+}
+
+// CanonicalizeAQL returns a one-line canonical form of aql that is equal for queries
+// differing only in whitespace, keyword case, comments, and the names of bind
+// variables, which are renamed to @p1, @p2, ... and collection bind variables to
+// @@c1, @@c2, ... in order of appearance. It identifies a query, e.g. to aggregate
+// query logs across services; it is not meant to be executed.
+func CanonicalizeAQL(aql string) string {
+	tokens := tokenizeAQL(aql)
+	names := map[string]string{}
+	var values, collections int
+	for i, t := range tokens {
+		if t.kind != aqlBindVar {
+			continue
+		}
+		name, ok := names[t.text]
+		if !ok {
+			if strings.HasPrefix(t.text, "@@") {
+				collections++
+				name = "@@c" + strconv.Itoa(collections)
+			} else {
+				values++
+				name = "@p" + strconv.Itoa(values)
+			}
+			names[t.text] = name
+		}
+		tokens[i].text = name
+	}
+	return renderAQL(tokens, false)
+}
+
+// readOnlyAQL reports whether aql has none of the keywords that modify data
+func readOnlyAQL(aql string) bool {
+	for _, t := range tokenizeAQL(aql) {
+		if t.kind != aqlKeyword {
+			continue
+		}
+		switch t.text {
+		case "INSERT", "UPDATE", "REPLACE", "REMOVE", "UPSERT":
+			return false
+		}
+	}
+	return true
+}
+
+// compactAQL returns aql on one line without comments, keeping bind variable names,
+// so that equal results mean the queries are interchangeable
+func compactAQL(aql string) string {
+	return renderAQL(tokenizeAQL(aql), false)
+}
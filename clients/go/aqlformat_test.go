@@ -0,0 +1,62 @@
+package themisdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatAQL(t *testing.T) {
+	for aql, want := range map[string]string{
+		"for u in users filter u.age >= @minAge   && u.active == true sort u.name desc limit 10 return {name: u.name, tags: LENGTH(u.tags[*])}": "" +
+			"FOR u IN users\n" +
+			"FILTER u.age >= @minAge && u.active == TRUE\n" +
+			"SORT u.name DESC\n" +
+			"LIMIT 10\n" +
+			"RETURN {name: u.name, tags: LENGTH(u.tags[*])}",
+		"FOR u IN @@users LET orders = (for o in orders filter o.user == u._key // own orders\n return o) RETURN MERGE(u, {orders, n: -1, r: 1..10, e: 1.5e-3})": "" +
+			"FOR u IN @@users\n" +
+			"LET orders = (\n" +
+			"  FOR o IN orders\n" +
+			"  FILTER o.user == u._key // own orders\n" +
+			"  RETURN o\n" +
+			")\n" +
+			"RETURN MERGE(u, {orders, n: -1, r: 1..10, e: 1.5e-3})",
+		"FOR v IN 1..3 OUTBOUND 'users/1' GRAPH 'social' FILTER NOT (v.x IN [1,2]) RETURN v.a ? {count: v.b} : -v.c": "" +
+			"FOR v IN 1..3 OUTBOUND 'users/1' GRAPH 'social'\n" +
+			"FILTER NOT (v.x IN [1, 2])\n" +
+			"RETURN v.a ? {count: v.b} : -v.c",
+		"UPSERT {key: @k} INSERT {key: @k, n: 1} UPDATE {n: OLD.n + 1} IN counters": "" +
+			"UPSERT {key: @k}\n" +
+			"INSERT {key: @k, n: 1}\n" +
+			"UPDATE {n: OLD.n + 1} IN counters",
+		"for d in docs collect g = d.group with count into n return {g, n: count(n), s: 'for x in y'}": "" +
+			"FOR d IN docs\n" +
+			"COLLECT g = d.group WITH COUNT INTO n\n" +
+			"RETURN {g, n: count(n), s: 'for x in y'}",
+	} {
+		assert.Equal(t, want, FormatAQL(aql))
+		assert.Equal(t, want, FormatAQL(want), "formatting is stable")
+	}
+}
+
+func TestCanonicalizeAQL(t *testing.T) {
+	canonical := CanonicalizeAQL("FOR u IN @@coll FILTER u.email == @email AND u.age > @age RETURN u")
+	assert.Equal(t, "FOR u IN @@c1 FILTER u.email == @p1 AND u.age > @p2 RETURN u", canonical)
+	assert.Equal(t, canonical, CanonicalizeAQL("for u in @@users\n  /* adults */ filter u.email==@mail and u.age>@min\n  return u"))
+	assert.NotEqual(t, canonical, CanonicalizeAQL("FOR u IN @@coll FILTER u.email == @email AND u.age > @email RETURN u"), "reused bind variables differ")
+	assert.NotEqual(t, canonical, CanonicalizeAQL("FOR u IN @@coll FILTER u.Email == @email AND u.age > @age RETURN u"), "attribute names are case-sensitive")
+}
+
+func TestReadOnlyAQL(t *testing.T) {
+	for aql, readOnly := range map[string]bool{
+		"FOR u IN users FILTER u.update > 0 RETURN {remove: u.insert}":   true,
+		"FOR u IN users FILTER u.note == 'REMOVE me' RETURN u // UPDATE": true,
+		"insert {name: 'Ada'} into users":                                false,
+		"FOR u IN users REPLACE u WITH {} IN users":                      false,
+		"LET doomed = (FOR u IN users REMOVE u IN users) RETURN doomed":  false,
+		"UPSERT {name: @n} INSERT {name: @n} UPDATE {} IN users":         false,
+	} {
+		assert.Equal(t, readOnly, readOnlyAQL(aql), aql)
+	}
+}
@@ -0,0 +1,251 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// backfillsCollection stores the checkpoints of named backfill jobs
+const backfillsCollection = "_backfills"
+
+// BackfillOptions holds backfill configuration
+type BackfillOptions struct {
+	// Transform returns the merge patch to apply to a document, or nil to leave it unchanged
+	Transform func(uuid string, doc json.RawMessage) (interface{}, error)
+	// BatchSize is the number of documents between checkpoints (default: 100)
+	BatchSize int
+	// RatePerSecond limits the number of writes per second, zero means unlimited
+	RatePerSecond float64
+	// OnProgress, if set, is called with the current progress after each checkpoint
+	OnProgress func(BackfillReport)
+}
+
+// BackfillReport summarizes the progress of a backfill
+type BackfillReport struct {
+	// Scanned counts documents read during this run
+	Scanned int64
+	// Updated counts documents patched during this run
+	Updated int64
+	// Processed counts documents read across all runs of the backfill
+	Processed int64
+	// Cursor is the UUID of the last processed document
+	Cursor string
+	// Elapsed is the duration of this run
+	Elapsed time.Duration
+	// Done reports whether the whole collection has been backfilled
+	Done bool
+}
+
+// Rate returns the number of documents scanned per second during this run
+func (r BackfillReport) Rate() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Scanned) / r.Elapsed.Seconds()
+}
+
+// backfillState is the stored checkpoint of a backfill
+type backfillState struct {
+	Cursor     string `json:"cursor,omitempty"`
+	Processed  int64  `json:"processed,omitempty"`
+	Backfilled bool   `json:"backfilled,omitempty"`
+}
+
+// Backfill applies opts.Transform to every document of model/collection. Progress is
+// checkpointed under name after each batch, so calling Backfill again with the same name
+// resumes after an interruption; once a backfill completed it is a no-op until ResetBackfill.
+func (c *Client) Backfill(ctx context.Context, name, model, collection string, opts BackfillOptions) (*BackfillReport, error) {
+	return c.runBackfill(ctx, backfillsCollection, name, model, collection, opts)
+}
+
+// ResetBackfill deletes the checkpoint of a backfill so the next run starts from the beginning
+func (c *Client) ResetBackfill(ctx context.Context, name string) error {
+	if err := c.Delete(ctx, ModelRelational, backfillsCollection, name); err != nil {
+		return fmt.Errorf("failed to reset backfill %s: %w", name, err)
+	}
+	return nil
+}
+
+// BackfillJob is a backfill running in the background
+type BackfillJob struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu       sync.Mutex
+	progress BackfillReport
+	report   *BackfillReport
+	err      error
+}
+
+// StartBackfill runs Backfill in a background goroutine.
+// Cancelling ctx or calling Stop interrupts the job; it can be resumed by starting it again.
+func (c *Client) StartBackfill(ctx context.Context, name, model, collection string, opts BackfillOptions) *BackfillJob {
+	ctx, cancel := context.WithCancel(ctx)
+	job := &BackfillJob{cancel: cancel, done: make(chan struct{})}
+
+	onProgress := opts.OnProgress
+	opts.OnProgress = func(r BackfillReport) {
+		job.mu.Lock()
+		job.progress = r
+		job.mu.Unlock()
+		if onProgress != nil {
+			onProgress(r)
+		}
+	}
+
+	go func() {
+		defer close(job.done)
+		defer cancel()
+		report, err := c.Backfill(ctx, name, model, collection, opts)
+
+		job.mu.Lock()
+		defer job.mu.Unlock()
+		job.report, job.err = report, err
+		if report != nil {
+			job.progress = *report
+		}
+	}()
+	return job
+}
+
+// Progress returns the progress as of the last checkpoint
+func (j *BackfillJob) Progress() BackfillReport {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.progress
+}
+
+// Stop interrupts the job and waits for it to exit
+func (j *BackfillJob) Stop() {
+	j.cancel()
+	<-j.done
+}
+
+// Done is closed when the job exits
+func (j *BackfillJob) Done() <-chan struct{} {
+	return j.done
+}
+
+// Wait blocks until the job exits and returns its final report
+func (j *BackfillJob) Wait() (*BackfillReport, error) {
+	<-j.done
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.report, j.err
+}
+
+// runBackfill scans model/collection from the checkpoint stored at stateCollection/name,
+// patching each document with the result of opts.Transform
+func (c *Client) runBackfill(ctx context.Context, stateCollection, name, model, collection string, opts BackfillOptions) (*BackfillReport, error) {
+	if opts.Transform == nil {
+		return nil, fmt.Errorf("backfill %s requires a transform", name)
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	var state backfillState
+	if err := c.loadCheckpoint(ctx, stateCollection, name, &state); err != nil {
+		return nil, fmt.Errorf("failed to load backfill %s: %w", name, err)
+	}
+	report := &BackfillReport{Processed: state.Processed, Cursor: state.Cursor, Done: state.Backfilled}
+	if state.Backfilled {
+		return report, nil
+	}
+
+	var throttle <-chan time.Time
+	if opts.RatePerSecond > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.RatePerSecond))
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	start := time.Now()
+	checkpoint := func() error {
+		report.Elapsed = time.Since(start)
+		fields := map[string]interface{}{
+			"cursor":    report.Cursor,
+			"processed": report.Processed,
+		}
+		if report.Done {
+			fields["backfilled"] = true
+		}
+		if err := c.saveCheckpoint(ctx, stateCollection, name, fields); err != nil {
+			return fmt.Errorf("failed to checkpoint backfill %s: %w", name, err)
+		}
+		if opts.OnProgress != nil {
+			opts.OnProgress(*report)
+		}
+		return nil
+	}
+
+	it := c.Scan(ctx, model, collection, ScanOptions{BatchSize: opts.BatchSize, StartAfter: state.Cursor})
+	for it.Next() {
+		patch, err := opts.Transform(it.UUID(), it.Document())
+		if err != nil {
+			return report, fmt.Errorf("failed to transform %s: %w", it.UUID(), err)
+		}
+		if patch != nil {
+			if throttle != nil {
+				select {
+				case <-throttle:
+				case <-ctx.Done():
+					return report, ctx.Err()
+				}
+			}
+			if err := c.Patch(ctx, model, collection, it.UUID(), patch); err != nil {
+				return report, fmt.Errorf("failed to backfill %s: %w", it.UUID(), err)
+			}
+			report.Updated++
+		}
+
+		report.Scanned++
+		report.Processed++
+		report.Cursor = it.Cursor()
+		if report.Scanned%int64(opts.BatchSize) == 0 {
+			if err := checkpoint(); err != nil {
+				return report, err
+			}
+		}
+	}
+	if err := it.Err(); err != nil {
+		return report, err
+	}
+
+	report.Done = true
+	if err := checkpoint(); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// loadCheckpoint decodes the document collection/name into v, leaving v untouched if it does not exist
+func (c *Client) loadCheckpoint(ctx context.Context, collection, name string, v interface{}) error {
+	if err := validateKey("name", name); err != nil {
+		return err
+	}
+
+	resp, err := c.send(ctx, "GET", entityPath(ModelRelational, collection, name), nil, nil)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(resp.Body) > 0 {
+		if err := json.Unmarshal(resp.Body, v); err != nil {
+			return fmt.Errorf("failed to decode checkpoint: %w", err)
+		}
+	}
+	return nil
+}
+
+// saveCheckpoint merges fields into the document collection/name, creating it if missing
+func (c *Client) saveCheckpoint(ctx context.Context, collection, name string, fields map[string]interface{}) error {
+	_, err := c.Upsert(ctx, ModelRelational, collection, name, fields)
+	return err
+}
@@ -0,0 +1,83 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_StartBackfill(t *testing.T) {
+	client, store := newMemoryClient(t)
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, client.Put(ctx, "document", "events", fmt.Sprintf("e%02d", i), map[string]int{"v": i}))
+	}
+
+	var checkpoints []int64
+	opts := BackfillOptions{
+		BatchSize: 4,
+		Transform: func(uuid string, doc json.RawMessage) (interface{}, error) {
+			return map[string]interface{}{"migrated": true}, nil
+		},
+		OnProgress: func(r BackfillReport) {
+			checkpoints = append(checkpoints, r.Processed)
+		},
+	}
+
+	job := client.StartBackfill(ctx, "mark-events", "document", "events", opts)
+	report, err := job.Wait()
+	require.NoError(t, err)
+	assert.True(t, report.Done)
+	assert.Equal(t, int64(10), report.Updated)
+	assert.Equal(t, []int64{4, 8, 10}, checkpoints)
+	assert.Equal(t, *report, job.Progress())
+	assert.Equal(t, true, store.docs["/api/document/events/e09"]["migrated"])
+
+	report, err = client.Backfill(ctx, "mark-events", "document", "events", opts)
+	require.NoError(t, err)
+	assert.True(t, report.Done)
+	assert.Zero(t, report.Scanned)
+
+	require.NoError(t, client.ResetBackfill(ctx, "mark-events"))
+	report, err = client.Backfill(ctx, "mark-events", "document", "events", opts)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), report.Scanned)
+}
+
+func TestClient_StartBackfillResume(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, client.Put(ctx, "document", "events", fmt.Sprintf("e%d", i), map[string]int{"v": i}))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	job := client.StartBackfill(ctx, "interrupted", "document", "events", BackfillOptions{
+		BatchSize:     1,
+		RatePerSecond: 1000,
+		Transform: func(uuid string, doc json.RawMessage) (interface{}, error) {
+			if uuid == "e2" {
+				cancel()
+			}
+			return map[string]interface{}{"migrated": true}, nil
+		},
+	})
+
+	report, err := job.Wait()
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, report.Done)
+	assert.Equal(t, "e1", job.Progress().Cursor)
+
+	job.Stop()
+
+	report, err = client.Backfill(context.Background(), "interrupted", "document", "events", BackfillOptions{
+		Transform: func(uuid string, doc json.RawMessage) (interface{}, error) { return nil, nil },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), report.Scanned)
+	assert.Equal(t, int64(5), report.Processed)
+}
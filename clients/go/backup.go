@@ -0,0 +1,44 @@
+package themisdb
+
+import (
+	"context"
+	"fmt"
+)
+
+// BackupOptions describes a backup to create
+type BackupOptions struct {
+	// Directory is the directory on the server the checkpoint is written to (default:
+	// a new directory data/backup_<unix time> in the server's working directory)
+	Directory string `json:"directory,omitempty"`
+}
+
+// Backup is a checkpoint written by the server
+type Backup struct {
+	// Directory is the directory on the server holding the checkpoint
+	Directory string `json:"directory"`
+}
+
+// CreateBackup writes a checkpoint of the database to a directory on the server and
+// returns once it is complete. The server keeps no catalog of its backups, so there is
+// no way to list them or to poll their progress; record the directory to restore it
+// later. Like other writes, the backup is bounded by Config.Timeouts.Write.
+func (a *Admin) CreateBackup(ctx context.Context, opts BackupOptions) (*Backup, error) {
+	var backup Backup
+	if err := a.client.request(ctx, "POST", "/admin/backup", opts, &backup, nil); err != nil {
+		return nil, fmt.Errorf("failed to create backup: %w", err)
+	}
+	return &backup, nil
+}
+
+// RestoreBackup replaces the database with the checkpoint in directory on the server,
+// as written by CreateBackup, and returns once the restore is complete
+func (a *Admin) RestoreBackup(ctx context.Context, directory string) error {
+	if directory == "" {
+		return &ValidationError{Field: "directory", Value: directory, Reason: "must not be empty"}
+	}
+	body := map[string]string{"directory": directory}
+	if err := a.client.request(ctx, "POST", "/admin/restore", body, nil, nil); err != nil {
+		return fmt.Errorf("failed to restore backup %s: %w", directory, err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdmin_Backups(t *testing.T) {
+	var requests []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+body["directory"])
+		switch r.URL.Path {
+		case "/admin/backup":
+			directory := body["directory"]
+			if directory == "" {
+				directory = "./data/backup_1767225600"
+			}
+			json.NewEncoder(w).Encode(map[string]string{"status": "ok", "directory": directory})
+		case "/admin/restore":
+			if body["directory"] == "/missing" {
+				http.Error(w, "Failed to restore from checkpoint /missing", http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"status": "ok", "restored_from": body["directory"]})
+		default:
+			http.Error(w, "unexpected request", http.StatusNotFound)
+		}
+	})
+	admin := client.Admin()
+	ctx := context.Background()
+
+	backup, err := admin.CreateBackup(ctx, BackupOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "./data/backup_1767225600", backup.Directory)
+	backup, err = admin.CreateBackup(ctx, BackupOptions{Directory: "/backups/nightly"})
+	require.NoError(t, err)
+	assert.Equal(t, "/backups/nightly", backup.Directory)
+
+	require.NoError(t, admin.RestoreBackup(ctx, backup.Directory))
+	assert.Error(t, admin.RestoreBackup(ctx, "/missing"))
+	assert.ErrorIs(t, admin.RestoreBackup(ctx, ""), ErrInvalidInput)
+
+	assert.Equal(t, []string{
+		"POST /admin/backup ",
+		"POST /admin/backup /backups/nightly",
+		"POST /admin/restore /backups/nightly",
+		"POST /admin/restore /missing",
+	}, requests)
+}
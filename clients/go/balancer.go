@@ -0,0 +1,226 @@
+package themisdb
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LoadBalancer chooses the endpoint of each request (see Config.LoadBalancer).
+// Implementations must be safe for concurrent use.
+type LoadBalancer interface {
+	// Pick returns the endpoint for req, one of endpoints, which is never empty
+	// and must not be modified
+	Pick(req *Request, endpoints []string) string
+	// Done reports the outcome of a request sent to the picked endpoint. err is
+	// set for transport errors and server errors (status >= 500).
+	Done(endpoint string, latency time.Duration, err error)
+}
+
+// sendBalanced sends req to the endpoint picked by the load balancer and reports the outcome
+func (c *Client) sendBalanced(ctx context.Context, req *Request) (*Response, error) {
+	if tx, ok := ctx.Value(pinnedTransactionKey{}).(*Transaction); ok {
+		if tx.endpoint != "" {
+			return c.sendPinned(ctx, tx, req)
+		}
+		tx.addAffinity(req)
+	}
+	if c.balancer == nil {
+		return c.sendTo(ctx, c.getEndpoint(), req)
+	}
+
+	c.mu.RLock()
+	endpoints := c.endpoints
+	c.mu.RUnlock()
+	endpoint := c.balancer.Pick(req, endpoints)
+
+	start := time.Now()
+	resp, err := c.sendTo(ctx, strings.TrimSuffix(endpoint, "/"), req)
+	outcome := err
+	if err == nil && resp.StatusCode >= http.StatusInternalServerError {
+		outcome = &StatusError{StatusCode: resp.StatusCode, Message: string(resp.Body)}
+	}
+	c.balancer.Done(endpoint, time.Since(start), outcome)
+	return resp, err
+}
+
+// RoundRobin returns a LoadBalancer that cycles through the endpoints
+func RoundRobin() LoadBalancer {
+	return &roundRobin{}
+}
+
+type roundRobin struct {
+	next atomic.Uint64
+}
+
+func (b *roundRobin) Pick(_ *Request, endpoints []string) string {
+	return endpoints[(b.next.Add(1)-1)%uint64(len(endpoints))]
+}
+
+func (b *roundRobin) Done(string, time.Duration, error) {}
+
+// LeastOutstanding returns a LoadBalancer that picks the endpoint with the fewest
+// requests in flight, breaking ties in endpoint order
+func LeastOutstanding() LoadBalancer {
+	return &leastOutstanding{outstanding: map[string]int{}}
+}
+
+type leastOutstanding struct {
+	mu          sync.Mutex
+	outstanding map[string]int
+}
+
+func (b *leastOutstanding) Pick(_ *Request, endpoints []string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	best := endpoints[0]
+	for _, e := range endpoints[1:] {
+		if b.outstanding[e] < b.outstanding[best] {
+			best = e
+		}
+	}
+	b.outstanding[best]++
+	return best
+}
+
+func (b *leastOutstanding) Done(endpoint string, _ time.Duration, _ error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.outstanding[endpoint]--; b.outstanding[endpoint] <= 0 {
+		delete(b.outstanding, endpoint)
+	}
+}
+
+// latencyDecay weighs a new latency sample in the moving average
+const latencyDecay = 0.2
+
+// LatencyWeighted returns a LoadBalancer that picks endpoints at random with a
+// probability inversely proportional to their average latency. Failed requests count
+// as latency penalty, so failing endpoints receive little traffic until they recover.
+// Endpoints without samples are treated as the fastest known one.
+func LatencyWeighted(penalty time.Duration) LoadBalancer {
+	if penalty <= 0 {
+		penalty = time.Second
+	}
+	return &latencyWeighted{penalty: penalty, latency: map[string]float64{}, rng: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+type latencyWeighted struct {
+	penalty time.Duration
+	mu      sync.Mutex
+	latency map[string]float64
+	rng     *rand.Rand
+}
+
+func (b *latencyWeighted) Pick(_ *Request, endpoints []string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	fastest := math.Inf(1)
+	for _, e := range endpoints {
+		if l, ok := b.latency[e]; ok && l < fastest {
+			fastest = l
+		}
+	}
+	if math.IsInf(fastest, 1) {
+		fastest = 1
+	}
+	weights := make([]float64, len(endpoints))
+	total := 0.0
+	for i, e := range endpoints {
+		l, ok := b.latency[e]
+		if !ok {
+			l = fastest
+		}
+		weights[i] = 1 / math.Max(l, 1e-6)
+		total += weights[i]
+	}
+	r := b.rng.Float64() * total
+	for i, w := range weights {
+		if r < w {
+			return endpoints[i]
+		}
+		r -= w
+	}
+	return endpoints[len(endpoints)-1]
+}
+
+func (b *latencyWeighted) Done(endpoint string, latency time.Duration, err error) {
+	if err != nil && latency < b.penalty {
+		latency = b.penalty
+	}
+	sample := latency.Seconds()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if avg, ok := b.latency[endpoint]; ok {
+		b.latency[endpoint] = avg + latencyDecay*(sample-avg)
+	} else {
+		b.latency[endpoint] = sample
+	}
+}
+
+// stickyCooldown is how long Sticky avoids an endpoint after a failed request
+const stickyCooldown = 5 * time.Second
+
+// Sticky returns a LoadBalancer that sends all requests with the same key to the same
+// endpoint, using rendezvous hashing so that adding or removing an endpoint only moves
+// the keys of that endpoint. key defaults to the request's namespace, giving each tenant
+// an affine node. An endpoint that fails is skipped for a few seconds.
+func Sticky(key func(req *Request) string) LoadBalancer {
+	if key == nil {
+		key = func(req *Request) string { return req.Header[headerNamespace] }
+	}
+	return &sticky{key: key, down: map[string]time.Time{}}
+}
+
+type sticky struct {
+	key  func(*Request) string
+	mu   sync.Mutex
+	down map[string]time.Time
+}
+
+func (b *sticky) Pick(req *Request, endpoints []string) string {
+	key := b.key(req)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	var best, fallback string
+	var bestScore, fallbackScore uint64
+	for _, e := range endpoints {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write([]byte(e))
+		score := h.Sum64()
+		if score >= fallbackScore {
+			fallback, fallbackScore = e, score
+		}
+		if now.Before(b.down[e]) {
+			continue
+		}
+		if best == "" || score > bestScore {
+			best, bestScore = e, score
+		}
+	}
+	if best == "" {
+		return fallback
+	}
+	return best
+}
+
+func (b *sticky) Done(endpoint string, _ time.Duration, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil {
+		b.down[endpoint] = time.Now().Add(stickyCooldown)
+	} else {
+		delete(b.down, endpoint)
+	}
+}
@@ -0,0 +1,139 @@
+package themisdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingServers starts n servers that record the paths they receive
+func countingServers(t *testing.T, n int) ([]string, func(i int) []string) {
+	var mu sync.Mutex
+	paths := make([][]string, n)
+	endpoints := make([]string, n)
+	for i := range endpoints {
+		i := i
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			paths[i] = append(paths[i], r.URL.Path)
+			mu.Unlock()
+			if r.URL.Path == "/transaction/begin" {
+				fmt.Fprintf(w, `{"transaction_id":"tx-%d"}`, i)
+				return
+			}
+			w.Write([]byte(`{}`))
+		}))
+		t.Cleanup(server.Close)
+		endpoints[i] = server.URL
+	}
+	return endpoints, func(i int) []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), paths[i]...)
+	}
+}
+
+func TestLoadBalancer_RoundRobinWithTransactions(t *testing.T) {
+	endpoints, paths := countingServers(t, 3)
+	client := NewClient(Config{Endpoints: endpoints, LoadBalancer: RoundRobin()})
+	ctx := context.Background()
+
+	for i := 0; i < 6; i++ {
+		require.NoError(t, client.Get(ctx, "relational", "users", "u1", nil))
+	}
+	for i := range endpoints {
+		assert.Len(t, paths(i), 2)
+	}
+
+	tx, err := client.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.Put(ctx, "relational", "users", "u1", map[string]int{"n": 1}))
+	require.NoError(t, tx.Put(ctx, "relational", "users", "u2", map[string]int{"n": 2}))
+	require.NoError(t, tx.Commit(ctx))
+	assert.Equal(t, []string{"/api/relational/users/u1", "/api/relational/users/u1",
+		"/transaction/begin", "/api/relational/users/u1", "/api/relational/users/u2", "/transaction/commit"}, paths(0),
+		"a transaction stays on the node that began it")
+}
+
+func TestLoadBalancer_Default(t *testing.T) {
+	endpoints, paths := countingServers(t, 2)
+	client := NewClient(Config{Endpoints: endpoints})
+	for i := 0; i < 3; i++ {
+		require.NoError(t, client.Get(context.Background(), "relational", "users", "u1", nil))
+	}
+	assert.Len(t, paths(0), 3)
+	assert.Empty(t, paths(1))
+}
+
+func TestLeastOutstanding(t *testing.T) {
+	b := LeastOutstanding()
+	endpoints := []string{"a", "b", "c"}
+	assert.Equal(t, "a", b.Pick(nil, endpoints))
+	assert.Equal(t, "b", b.Pick(nil, endpoints))
+	assert.Equal(t, "c", b.Pick(nil, endpoints))
+	assert.Equal(t, "a", b.Pick(nil, endpoints))
+	b.Done("b", time.Millisecond, nil)
+	assert.Equal(t, "b", b.Pick(nil, endpoints))
+}
+
+func TestLatencyWeighted(t *testing.T) {
+	b := LatencyWeighted(time.Second)
+	endpoints := []string{"fast", "slow", "failing"}
+	for i := 0; i < 10; i++ {
+		b.Done("fast", 2*time.Millisecond, nil)
+		b.Done("slow", 40*time.Millisecond, nil)
+		b.Done("failing", time.Millisecond, errors.New("connection refused"))
+	}
+	picks := map[string]int{}
+	for i := 0; i < 2000; i++ {
+		picks[b.Pick(nil, endpoints)]++
+	}
+	assert.Greater(t, picks["fast"], 1700)
+	assert.Greater(t, picks["slow"], picks["failing"])
+
+	picks = map[string]int{}
+	for i := 0; i < 2000; i++ {
+		picks[b.Pick(nil, []string{"fast", "new"})]++
+	}
+	assert.Greater(t, picks["new"], 700, "endpoints without samples get traffic")
+}
+
+func TestSticky(t *testing.T) {
+	b := Sticky(nil)
+	endpoints := []string{"a", "b", "c", "d"}
+	tenant := func(ns string) *Request { return &Request{Header: map[string]string{headerNamespace: ns}} }
+
+	owners := map[string]string{}
+	used := map[string]bool{}
+	for i := 0; i < 40; i++ {
+		ns := fmt.Sprintf("tenant-%d", i)
+		owners[ns] = b.Pick(tenant(ns), endpoints)
+		assert.Equal(t, owners[ns], b.Pick(tenant(ns), endpoints))
+		used[owners[ns]] = true
+	}
+	assert.Len(t, used, 4, "keys spread over all endpoints")
+
+	// Removing an endpoint only moves its own keys
+	for ns, owner := range owners {
+		if owner != "d" {
+			assert.Equal(t, owner, b.Pick(tenant(ns), endpoints[:3]))
+		}
+	}
+
+	// A failing endpoint is skipped until it succeeds again
+	ns := "tenant-0"
+	owner := owners[ns]
+	b.Done(owner, time.Millisecond, errors.New("timeout"))
+	moved := b.Pick(tenant(ns), endpoints)
+	assert.NotEqual(t, owner, moved)
+	b.Done(owner, time.Millisecond, nil)
+	assert.Equal(t, owner, b.Pick(tenant(ns), endpoints))
+}
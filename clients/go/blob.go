@@ -0,0 +1,412 @@
+package themisdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"time"
+)
+
+// Blob defaults
+const (
+	// DefaultBlobChunkSize is the chunk size of resumable blob uploads
+	DefaultBlobChunkSize = 8 << 20
+	// headerBlobMetadata carries the JSON-encoded metadata of a blob
+	headerBlobMetadata = "X-Themis-Blob-Metadata"
+)
+
+// BlobInfo describes a binary attachment of an entity
+type BlobInfo struct {
+	Name        string            `json:"name"`
+	Size        int64             `json:"size"`
+	ContentType string            `json:"content_type"`
+	ETag        string            `json:"etag"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	ModifiedAt  time.Time         `json:"modified_at"`
+}
+
+// BlobProgress reports the state of a blob upload
+type BlobProgress struct {
+	// UploadID identifies a chunked upload; pass it as BlobOptions.UploadID to resume
+	// the upload from another process. It is empty for single-request uploads.
+	UploadID string
+	Sent     int64
+	// Total is the blob size, or -1 if unknown
+	Total int64
+}
+
+// BlobOptions configures a blob upload
+type BlobOptions struct {
+	// ContentType of the blob (default: application/octet-stream)
+	ContentType string
+	// Metadata is stored with the blob and returned by GetBlob and StatBlob
+	Metadata map[string]string
+	// ChunkSize is the size of upload chunks. Blobs larger than ChunkSize, or of unknown
+	// size, are uploaded in chunks that are retried individually (default: 8 MiB).
+	ChunkSize int
+	// MaxRetries is the number of retries per chunk after transient errors (default: 3)
+	MaxRetries int
+	// UploadID resumes an interrupted chunked upload. The reader must supply the blob
+	// from its start; the part the server already has is skipped.
+	UploadID string
+	// OnProgress is called after the upload starts and after every chunk
+	OnProgress func(BlobProgress)
+}
+
+// blobPath returns the API path of an entity's blob
+func blobPath(model, collection, uuid, name string) string {
+	return entityPath(model, collection, uuid) + "/_blobs/" + EncodeKey(name)
+}
+
+// validateBlob checks the entity and blob name
+func validateBlob(model, collection, uuid, name string) error {
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return err
+	}
+	return validateKey("blob name", name)
+}
+
+// PutBlob stores a binary attachment of an entity, streaming it from r. size is the
+// number of bytes r supplies, or -1 if unknown. It requires the HTTP transport.
+func (c *Client) PutBlob(ctx context.Context, model, collection, uuid, name string, r io.Reader, size int64) (*BlobInfo, error) {
+	return c.PutBlobWithOptions(ctx, model, collection, uuid, name, r, size, nil)
+}
+
+// PutBlobWithOptions is PutBlob with a content type, metadata, and chunking options.
+// Blobs up to ChunkSize are streamed in a single multipart request; larger blobs use a
+// resumable upload whose chunks are retried after transient errors.
+func (c *Client) PutBlobWithOptions(ctx context.Context, model, collection, uuid, name string, r io.Reader, size int64, opts *BlobOptions) (*BlobInfo, error) {
+	if err := validateBlob(model, collection, uuid, name); err != nil {
+		return nil, err
+	}
+	o := BlobOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.ContentType == "" {
+		o.ContentType = "application/octet-stream"
+	}
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = DefaultBlobChunkSize
+	}
+	if o.MaxRetries <= 0 {
+		o.MaxRetries = 3
+	}
+
+	path := blobPath(model, collection, uuid, name)
+	if o.UploadID == "" && size >= 0 && size <= int64(o.ChunkSize) {
+		return c.putBlobMultipart(ctx, path, r, size, o)
+	}
+	return c.putBlobChunked(ctx, path, r, size, o)
+}
+
+// putBlobMultipart streams a blob as a multipart/form-data request with a JSON
+// "metadata" part followed by the "file" part
+func (c *Client) putBlobMultipart(ctx context.Context, path string, r io.Reader, size int64, o BlobOptions) (*BlobInfo, error) {
+	meta, err := json.Marshal(map[string]interface{}{"content_type": o.ContentType, "metadata": o.Metadata, "size": size})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal blob metadata: %w", err)
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		err := func() error {
+			part, err := mw.CreateFormField("metadata")
+			if err != nil {
+				return err
+			}
+			if _, err := part.Write(meta); err != nil {
+				return err
+			}
+			header := textproto.MIMEHeader{}
+			header.Set("Content-Disposition", `form-data; name="file"; filename="blob"`)
+			header.Set("Content-Type", o.ContentType)
+			if part, err = mw.CreatePart(header); err != nil {
+				return err
+			}
+			if _, err := io.CopyN(part, r, size); err != nil {
+				return fmt.Errorf("failed to read blob: %w", err)
+			}
+			return mw.Close()
+		}()
+		pw.CloseWithError(err)
+	}()
+
+	resp, err := c.stream(ctx, "PUT", path, pr, map[string]string{"Content-Type": mw.FormDataContentType()})
+	pr.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload blob: %w", err)
+	}
+	defer resp.Body.Close()
+	if o.OnProgress != nil {
+		o.OnProgress(BlobProgress{Sent: size, Total: size})
+	}
+	return decodeBlobInfo(resp.Body)
+}
+
+// putBlobChunked uploads a blob through an upload session in chunks of o.ChunkSize
+func (c *Client) putBlobChunked(ctx context.Context, path string, r io.Reader, size int64, o BlobOptions) (*BlobInfo, error) {
+	uploadID, offset := o.UploadID, int64(0)
+	if uploadID == "" {
+		var result struct {
+			UploadID string `json:"upload_id"`
+		}
+		body := map[string]interface{}{"size": size, "content_type": o.ContentType, "metadata": o.Metadata}
+		if err := c.request(ctx, "POST", path+"/_uploads", body, &result, nil); err != nil {
+			return nil, fmt.Errorf("failed to start blob upload: %w", err)
+		}
+		uploadID = result.UploadID
+	} else {
+		var err error
+		if offset, err = c.uploadOffset(ctx, path, uploadID); err != nil {
+			return nil, err
+		}
+		if err := skipBytes(r, offset); err != nil {
+			return nil, err
+		}
+	}
+	if size >= 0 {
+		r = io.LimitReader(r, size-offset)
+	}
+	uploadPath := path + "/_uploads/" + EncodeKey(uploadID)
+	progress := func() {
+		if o.OnProgress != nil {
+			o.OnProgress(BlobProgress{UploadID: uploadID, Sent: offset, Total: size})
+		}
+	}
+	progress()
+
+	buf := make([]byte, o.ChunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if err == io.ErrUnexpectedEOF || err == io.EOF {
+			err = nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read blob: %w", err)
+		}
+		if n == 0 {
+			break
+		}
+		if offset, err = c.putChunk(ctx, path, uploadID, buf[:n], offset, size, o.MaxRetries); err != nil {
+			return nil, err
+		}
+		progress()
+		if n < len(buf) {
+			break
+		}
+	}
+	if size >= 0 && offset != size {
+		return nil, fmt.Errorf("failed to upload blob: read %d of %d bytes", offset, size)
+	}
+
+	var info BlobInfo
+	if err := c.request(ctx, "POST", uploadPath+"/complete", map[string]interface{}{"size": offset}, &info, nil); err != nil {
+		return nil, fmt.Errorf("failed to complete blob upload: %w", err)
+	}
+	return &info, nil
+}
+
+// putChunk sends chunk, which starts at offset of the blob, and returns the new offset.
+// After a transient error it asks the server how much it received and resends the rest.
+func (c *Client) putChunk(ctx context.Context, path, uploadID string, chunk []byte, offset, size int64, maxRetries int) (int64, error) {
+	uploadPath := path + "/_uploads/" + EncodeKey(uploadID)
+	total := "*"
+	if size >= 0 {
+		total = strconv.FormatInt(size, 10)
+	}
+	start, end := offset, offset+int64(len(chunk))
+	backoff := 100 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		headers := map[string]string{
+			"Content-Type":   "application/octet-stream",
+			"Content-Range":  fmt.Sprintf("bytes %d-%d/%s", offset, end-1, total),
+			"Content-Length": strconv.FormatInt(end-offset, 10),
+		}
+		resp, err := c.stream(ctx, "PUT", uploadPath, bytes.NewReader(chunk[offset-start:]), headers)
+		if err == nil {
+			resp.Body.Close()
+			return end, nil
+		}
+		var statusErr *StatusError
+		if ctx.Err() != nil || attempt == maxRetries || (errors.As(err, &statusErr) && statusErr.StatusCode < 500) {
+			return offset, fmt.Errorf("failed to upload blob chunk at offset %d: %w", offset, err)
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return offset, ctx.Err()
+		}
+		backoff *= 2
+		if received, err := c.uploadOffset(ctx, path, uploadID); err == nil && received >= start && received <= end {
+			offset = received
+		}
+	}
+}
+
+// uploadOffset returns the number of bytes the server has received for an upload
+func (c *Client) uploadOffset(ctx context.Context, path, uploadID string) (int64, error) {
+	var result struct {
+		Offset int64 `json:"offset"`
+	}
+	if err := c.request(ctx, "GET", path+"/_uploads/"+EncodeKey(uploadID), nil, &result, nil); err != nil {
+		return 0, fmt.Errorf("failed to get blob upload state: %w", err)
+	}
+	return result.Offset, nil
+}
+
+// skipBytes advances r by n bytes, seeking if possible
+func skipBytes(r io.Reader, n int64) error {
+	if n == 0 {
+		return nil
+	}
+	if s, ok := r.(io.Seeker); ok {
+		if _, err := s.Seek(n, io.SeekCurrent); err != nil {
+			return fmt.Errorf("failed to skip uploaded bytes: %w", err)
+		}
+		return nil
+	}
+	if _, err := io.CopyN(io.Discard, r, n); err != nil {
+		return fmt.Errorf("failed to skip uploaded bytes: %w", err)
+	}
+	return nil
+}
+
+// decodeBlobInfo decodes a JSON BlobInfo response
+func decodeBlobInfo(r io.Reader) (*BlobInfo, error) {
+	var info BlobInfo
+	if err := json.NewDecoder(r).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &info, nil
+}
+
+// blobInfoFromHeader reads a BlobInfo from the headers of a blob response
+func blobInfoFromHeader(name string, h http.Header) BlobInfo {
+	info := BlobInfo{
+		Name:        name,
+		Size:        -1,
+		ContentType: h.Get("Content-Type"),
+		ETag:        h.Get("ETag"),
+	}
+	if cl := h.Get("Content-Length"); cl != "" {
+		info.Size, _ = strconv.ParseInt(cl, 10, 64)
+	}
+	if lm, err := http.ParseTime(h.Get("Last-Modified")); err == nil {
+		info.ModifiedAt = lm
+	}
+	if meta := h.Get(headerBlobMetadata); meta != "" {
+		json.Unmarshal([]byte(meta), &info.Metadata)
+	}
+	return info
+}
+
+// GetBlob streams a binary attachment of an entity. The caller must close the reader.
+// If the connection drops mid-transfer, the reader resumes with a range request for the
+// same blob revision. It requires the HTTP transport.
+func (c *Client) GetBlob(ctx context.Context, model, collection, uuid, name string) (io.ReadCloser, BlobInfo, error) {
+	if err := validateBlob(model, collection, uuid, name); err != nil {
+		return nil, BlobInfo{}, err
+	}
+	path := blobPath(model, collection, uuid, name)
+	resp, err := c.stream(ctx, "GET", path, nil, nil)
+	if err != nil {
+		return nil, BlobInfo{}, fmt.Errorf("failed to download blob: %w", err)
+	}
+	info := blobInfoFromHeader(name, resp.Header)
+	return &blobReader{ctx: ctx, client: c, path: path, etag: info.ETag, body: resp.Body, retries: 3}, info, nil
+}
+
+// StatBlob returns the description of a binary attachment without downloading it
+func (c *Client) StatBlob(ctx context.Context, model, collection, uuid, name string) (BlobInfo, error) {
+	if err := validateBlob(model, collection, uuid, name); err != nil {
+		return BlobInfo{}, err
+	}
+	resp, err := c.send(ctx, "HEAD", blobPath(model, collection, uuid, name), nil, nil)
+	if err != nil {
+		return BlobInfo{}, err
+	}
+	return blobInfoFromHeader(name, resp.Header), nil
+}
+
+// DeleteBlob removes a binary attachment of an entity
+func (c *Client) DeleteBlob(ctx context.Context, model, collection, uuid, name string) error {
+	if err := validateBlob(model, collection, uuid, name); err != nil {
+		return err
+	}
+	return c.request(ctx, "DELETE", blobPath(model, collection, uuid, name), nil, nil, nil)
+}
+
+// blobReader reads a blob download and resumes it after connection errors
+type blobReader struct {
+	ctx     context.Context
+	client  *Client
+	path    string
+	etag    string
+	body    io.ReadCloser
+	read    int64
+	retries int
+	// err is the connection error to resume from on the next Read
+	err error
+}
+
+// Read implements io.Reader. Bytes received before a connection error are returned
+// first; the download resumes from the new offset on the next call.
+func (b *blobReader) Read(p []byte) (int, error) {
+	for {
+		if b.err != nil {
+			if err := b.resume(); err != nil {
+				return 0, err
+			}
+		}
+		n, err := b.body.Read(p)
+		b.read += int64(n)
+		if err == nil || err == io.EOF {
+			return n, err
+		}
+		b.err = err
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// resume replaces the broken body with a range request for the rest of the same blob
+// revision. Once the retries are exhausted, the error sticks.
+func (b *blobReader) resume() error {
+	if b.retries == 0 || b.etag == "" || b.ctx.Err() != nil {
+		return b.err
+	}
+	b.retries--
+	b.body.Close()
+
+	headers := map[string]string{"Range": fmt.Sprintf("bytes=%d-", b.read), "If-Match": b.etag}
+	resp, err := b.client.stream(b.ctx, "GET", b.path, nil, headers)
+	if err == nil && resp.StatusCode != http.StatusPartialContent {
+		resp.Body.Close()
+		err = fmt.Errorf("server ignored range request")
+	}
+	if err != nil {
+		b.body = io.NopCloser(bytes.NewReader(nil))
+		b.retries = 0
+		b.err = fmt.Errorf("failed to resume blob download: %w (after %v)", err, b.err)
+		return b.err
+	}
+	b.body = resp.Body
+	b.err = nil
+	return nil
+}
+
+// Close implements io.Closer
+func (b *blobReader) Close() error {
+	return b.body.Close()
+}
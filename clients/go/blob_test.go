@@ -0,0 +1,325 @@
+package themisdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type storedBlob struct {
+	data        []byte
+	contentType string
+	metadata    map[string]string
+}
+
+type blobUpload struct {
+	name        string
+	contentType string
+	metadata    map[string]string
+	data        []byte
+}
+
+// blobServer stores blobs in memory and can drop chunk uploads and downloads halfway
+type blobServer struct {
+	mu            sync.Mutex
+	blobs         map[string]*storedBlob
+	uploads       map[string]*blobUpload
+	failChunk     int
+	chunkRequests int
+	cutDownload   bool
+	ranges        []string
+}
+
+func newBlobServer() *blobServer {
+	return &blobServer{blobs: map[string]*storedBlob{}, uploads: map[string]*blobUpload{}}
+}
+
+func (s *blobServer) info(name string, b *storedBlob) BlobInfo {
+	return BlobInfo{Name: name, Size: int64(len(b.data)), ContentType: b.contentType, ETag: fmt.Sprintf(`"%d"`, len(b.data)), Metadata: b.metadata}
+}
+
+func (s *blobServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, rest, _ := strings.Cut(r.URL.Path, "/_blobs/")
+	name, upload, isUpload := strings.Cut(rest, "/_uploads")
+	upload = strings.TrimPrefix(upload, "/")
+
+	switch {
+	case isUpload && r.Method == "POST" && upload == "":
+		var body blobUpload
+		var req struct {
+			ContentType string            `json:"content_type"`
+			Metadata    map[string]string `json:"metadata"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		body.name, body.contentType, body.metadata = name, req.ContentType, req.Metadata
+		id := "up-" + strconv.Itoa(len(s.uploads)+1)
+		s.uploads[id] = &body
+		json.NewEncoder(w).Encode(map[string]string{"upload_id": id})
+	case isUpload && r.Method == "GET":
+		json.NewEncoder(w).Encode(map[string]int{"offset": len(s.uploads[upload].data)})
+	case isUpload && r.Method == "PUT":
+		u := s.uploads[upload]
+		var start int
+		fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-", &start)
+		if start != len(u.data) {
+			http.Error(w, "offset mismatch", http.StatusConflict)
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		s.chunkRequests++
+		if s.chunkRequests == s.failChunk {
+			u.data = append(u.data, data[:len(data)/2]...)
+			http.Error(w, "connection reset", http.StatusBadGateway)
+			return
+		}
+		u.data = append(u.data, data...)
+		w.WriteHeader(http.StatusNoContent)
+	case isUpload && r.Method == "POST" && strings.HasSuffix(upload, "/complete"):
+		u := s.uploads[strings.TrimSuffix(upload, "/complete")]
+		b := &storedBlob{data: u.data, contentType: u.contentType, metadata: u.metadata}
+		s.blobs[u.name] = b
+		json.NewEncoder(w).Encode(s.info(u.name, b))
+	case r.Method == "PUT":
+		mr, err := r.MultipartReader()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		b := &storedBlob{}
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			data, _ := io.ReadAll(part)
+			switch part.FormName() {
+			case "metadata":
+				var meta struct {
+					Metadata map[string]string `json:"metadata"`
+				}
+				json.Unmarshal(data, &meta)
+				b.metadata = meta.Metadata
+			case "file":
+				b.data, b.contentType = data, part.Header.Get("Content-Type")
+			}
+		}
+		s.blobs[name] = b
+		json.NewEncoder(w).Encode(s.info(name, b))
+	case r.Method == "GET" || r.Method == "HEAD":
+		b, ok := s.blobs[name]
+		if !ok {
+			http.Error(w, "blob not found", http.StatusNotFound)
+			return
+		}
+		info := s.info(name, b)
+		meta, _ := json.Marshal(b.metadata)
+		w.Header().Set("Content-Type", b.contentType)
+		w.Header().Set("ETag", info.ETag)
+		w.Header().Set(headerBlobMetadata, string(meta))
+		data := b.data
+		status := http.StatusOK
+		if rng := r.Header.Get("Range"); rng != "" {
+			s.ranges = append(s.ranges, rng+" "+r.Header.Get("If-Match"))
+			var from int
+			fmt.Sscanf(rng, "bytes=%d-", &from)
+			data, status = data[from:], http.StatusPartialContent
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.WriteHeader(status)
+		if r.Method == "HEAD" {
+			return
+		}
+		if s.cutDownload {
+			s.cutDownload = false
+			w.Write(data[:len(data)/2])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		w.Write(data)
+	case r.Method == "DELETE":
+		delete(s.blobs, name)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestClient_Blob(t *testing.T) {
+	server := newBlobServer()
+	client := newTestClient(t, server.serveHTTP)
+	ctx := context.Background()
+
+	info, err := client.PutBlobWithOptions(ctx, "documents", "contracts", "c1", "scan.pdf",
+		strings.NewReader("%PDF-1.7 contract"), 17, &BlobOptions{
+			ContentType: "application/pdf",
+			Metadata:    map[string]string{"pages": "3"},
+		})
+	require.NoError(t, err)
+	assert.Equal(t, int64(17), info.Size)
+
+	server.cutDownload = true
+	rc, got, err := client.GetBlob(ctx, "documents", "contracts", "c1", "scan.pdf")
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	rc.Close()
+	assert.Equal(t, "%PDF-1.7 contract", string(data))
+	assert.Equal(t, BlobInfo{Name: "scan.pdf", Size: 17, ContentType: "application/pdf", ETag: `"17"`, Metadata: map[string]string{"pages": "3"}}, got)
+	assert.Equal(t, []string{`bytes=8- "17"`}, server.ranges, "the download resumes after the dropped connection")
+
+	stat, err := client.StatBlob(ctx, "documents", "contracts", "c1", "scan.pdf")
+	require.NoError(t, err)
+	assert.Equal(t, int64(17), stat.Size)
+
+	require.NoError(t, client.DeleteBlob(ctx, "documents", "contracts", "c1", "scan.pdf"))
+	_, _, err = client.GetBlob(ctx, "documents", "contracts", "c1", "scan.pdf")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = client.PutBlob(ctx, "documents", "contracts", "c1", "", strings.NewReader(""), 0)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	grpc := NewClient(Config{Protocol: ProtocolGRPC, Endpoints: []string{"localhost:1"}, Timeout: time.Second})
+	defer grpc.Close()
+	_, _, err = grpc.GetBlob(ctx, "documents", "contracts", "c1", "scan.pdf")
+	assert.ErrorIs(t, err, ErrUnsupportedByTransport)
+}
+
+func TestClient_BlobInterceptors(t *testing.T) {
+	server := newBlobServer()
+	var mu sync.Mutex
+	var auth []string
+	base := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auth = append(auth, r.Method+" "+r.Header.Get("Authorization"))
+		mu.Unlock()
+		server.serveHTTP(w, r)
+	})
+	var events []string
+	client := NewClient(Config{
+		Endpoints: base.endpoints,
+		Interceptors: []Interceptor{func(ctx context.Context, req *Request, next Handler) (*Response, error) {
+			headers := map[string]string{"Authorization": "Bearer token"}
+			for key, value := range req.Header {
+				headers[key] = value
+			}
+			req.Header = headers
+			return next(ctx, req)
+		}},
+		Hooks: &RequestHooks{OnResponse: func(ctx context.Context, e *RequestEvent) {
+			events = append(events, fmt.Sprintf("%s %d", e.Method, e.Status))
+		}},
+	})
+	defer client.Close()
+	ctx := context.Background()
+
+	_, err := client.PutBlob(ctx, "documents", "contracts", "c1", "scan.pdf", strings.NewReader("%PDF-1.7"), 8)
+	require.NoError(t, err)
+	rc, _, err := client.GetBlob(ctx, "documents", "contracts", "c1", "scan.pdf")
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	rc.Close()
+	assert.Equal(t, "%PDF-1.7", string(data))
+	assert.Equal(t, []string{"PUT Bearer token", "GET Bearer token"}, auth, "blob requests pass through the interceptors")
+	assert.Equal(t, []string{"PUT 200", "GET 200"}, events, "and are reported to the hooks")
+	var count uint64
+	for _, op := range client.Stats() {
+		if op.Collection == "documents/contracts" {
+			count += op.Count
+		}
+	}
+	assert.Equal(t, uint64(2), count, "and to the latency stats")
+
+	refuse := NewClient(Config{
+		Endpoints: base.endpoints,
+		Interceptors: []Interceptor{func(ctx context.Context, req *Request, next Handler) (*Response, error) {
+			return nil, ErrPermissionDenied
+		}},
+	})
+	defer refuse.Close()
+	_, _, err = refuse.GetBlob(ctx, "documents", "contracts", "c1", "scan.pdf")
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+}
+
+// brokenBody returns its data together with a connection error
+type brokenBody struct {
+	data []byte
+}
+
+func (b *brokenBody) Read(p []byte) (int, error) {
+	n := copy(p, b.data)
+	b.data = b.data[n:]
+	return n, io.ErrUnexpectedEOF
+}
+
+func (b *brokenBody) Close() error { return nil }
+
+func TestBlobReader_PartialReadError(t *testing.T) {
+	server := newBlobServer()
+	client := newTestClient(t, server.serveHTTP)
+	ctx := context.Background()
+	_, err := client.PutBlob(ctx, "documents", "contracts", "c1", "scan.pdf", strings.NewReader("%PDF-1.7 contract"), 17)
+	require.NoError(t, err)
+
+	path := blobPath("documents", "contracts", "c1", "scan.pdf")
+	r := &blobReader{ctx: ctx, client: client, path: path, etag: `"17"`, body: &brokenBody{data: []byte("%PDF-1.")}, retries: 3}
+	buf := make([]byte, 32)
+	n, err := r.Read(buf)
+	require.NoError(t, err, "the bytes before the error are returned without it")
+	assert.Equal(t, "%PDF-1.", string(buf[:n]))
+	rest, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "7 contract", string(rest))
+	assert.Equal(t, []string{`bytes=7- "17"`}, server.ranges, "the download resumes from the new offset")
+
+	// without an ETag the download cannot resume, and the error follows the bytes
+	r = &blobReader{ctx: ctx, client: client, path: path, body: &brokenBody{data: []byte("%PDF")}, retries: 3}
+	data, err := io.ReadAll(r)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, "%PDF", string(data))
+}
+
+func TestClient_BlobChunkedUpload(t *testing.T) {
+	server := newBlobServer()
+	server.failChunk = 2
+	client := newTestClient(t, server.serveHTTP)
+	ctx := context.Background()
+
+	payload := bytes.Repeat([]byte("0123456789"), 10)
+	var progress []BlobProgress
+	info, err := client.PutBlobWithOptions(ctx, "documents", "videos", "v1", "clip.mp4", bytes.NewReader(payload), int64(len(payload)), &BlobOptions{
+		ContentType: "video/mp4",
+		ChunkSize:   40,
+		OnProgress:  func(p BlobProgress) { progress = append(progress, p) },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), info.Size)
+	assert.Equal(t, payload, server.blobs["clip.mp4"].data, "the half-received chunk was completed, not duplicated")
+	assert.Equal(t, []BlobProgress{
+		{UploadID: "up-1", Sent: 0, Total: 100},
+		{UploadID: "up-1", Sent: 40, Total: 100},
+		{UploadID: "up-1", Sent: 80, Total: 100},
+		{UploadID: "up-1", Sent: 100, Total: 100},
+	}, progress)
+
+	// Resume an upload that an earlier process left at 50 bytes, from a stream of unknown size
+	server.uploads["up-9"] = &blobUpload{name: "resumed.bin", data: payload[:50]}
+	info, err = client.PutBlobWithOptions(ctx, "documents", "videos", "v1", "resumed.bin", io.NopCloser(bytes.NewReader(payload)), -1, &BlobOptions{
+		ChunkSize: 30,
+		UploadID:  "up-9",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), info.Size)
+	assert.Equal(t, payload, server.blobs["resumed.bin"].data)
+}
@@ -0,0 +1,360 @@
+package themisdb
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Expiration values for KVCache.Set, matching github.com/patrickmn/go-cache
+const (
+	// NoExpiration keeps an item until it is deleted
+	NoExpiration time.Duration = -1
+	// DefaultExpiration uses CacheOptions.DefaultTTL
+	DefaultExpiration time.Duration = 0
+)
+
+// CacheOptions holds KVCache configuration
+type CacheOptions struct {
+	// Collection stores the items in the kv model (default: "_cache")
+	Collection string
+	// DefaultTTL is the expiration of items set with DefaultExpiration, zero never expires
+	DefaultTTL time.Duration
+	// MaxEntries bounds the local tier, least recently used items are evicted (default: 10000)
+	MaxEntries int
+	// LocalTTL is how long items loaded from ThemisDB stay in the local tier (default: 1m)
+	LocalTTL time.Duration
+	// WriteThrough persists Set and Delete to ThemisDB before updating the local tier.
+	// Without it, they only change the local tier and Delete acts as an invalidation.
+	WriteThrough bool
+	// OnError is called with ThemisDB errors swallowed by the methods without context
+	OnError func(error)
+}
+
+// KVCache is a two-tier cache: a local LRU in front of items stored in ThemisDB.
+// Local misses are read through from ThemisDB. Its methods follow the API of
+// github.com/patrickmn/go-cache, and Ristretto returns a view with the API of
+// github.com/dgraph-io/ristretto, so existing cache code can move onto ThemisDB
+// without rewrites. Values loaded from ThemisDB are returned as json.RawMessage.
+type KVCache struct {
+	client *Client
+	opts   CacheOptions
+	local  *lruCache
+}
+
+// cachedItem is an item document, ExpiresAt is 0 for items that never expire
+type cachedItem struct {
+	Value     json.RawMessage `json:"value"`
+	ExpiresAt int64           `json:"expires_at"`
+}
+
+// KVCache returns a ThemisDB-backed cache
+func (c *Client) KVCache(opts CacheOptions) *KVCache {
+	if opts.Collection == "" {
+		opts.Collection = "_cache"
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = 10000
+	}
+	if opts.LocalTTL <= 0 {
+		opts.LocalTTL = time.Minute
+	}
+	return &KVCache{client: c, opts: opts, local: newLRUCache(opts.MaxEntries)}
+}
+
+// Get returns an item from the local tier or ThemisDB
+func (k *KVCache) Get(key string) (interface{}, bool) {
+	value, found, err := k.GetCtx(context.Background(), key)
+	k.report(err)
+	return value, found
+}
+
+// Set stores an item for d, DefaultExpiration, or NoExpiration
+func (k *KVCache) Set(key string, value interface{}, d time.Duration) {
+	k.report(k.SetCtx(context.Background(), key, value, d))
+}
+
+// SetDefault stores an item with the default expiration
+func (k *KVCache) SetDefault(key string, value interface{}) {
+	k.Set(key, value, DefaultExpiration)
+}
+
+// Add stores an item only if the key is not cached yet
+func (k *KVCache) Add(key string, value interface{}, d time.Duration) error {
+	return k.AddCtx(context.Background(), key, value, d)
+}
+
+// Delete removes an item
+func (k *KVCache) Delete(key string) {
+	k.report(k.DeleteCtx(context.Background(), key))
+}
+
+// Flush empties the local tier; items stored in ThemisDB are kept
+func (k *KVCache) Flush() {
+	k.local.clear()
+}
+
+// ItemCount returns the number of items in the local tier
+func (k *KVCache) ItemCount() int {
+	return k.local.len()
+}
+
+// GetCtx returns an item from the local tier, or loads it from ThemisDB on a local miss
+func (k *KVCache) GetCtx(ctx context.Context, key string) (interface{}, bool, error) {
+	if value, ok := k.local.get(key); ok {
+		return value, true, nil
+	}
+	if err := validateKey("key", key); err != nil {
+		return nil, false, err
+	}
+
+	resp, err := k.client.send(ctx, "GET", entityPath(ModelKV, k.opts.Collection, key), nil, nil)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load %s: %w", key, err)
+	}
+	var item cachedItem
+	if err := json.Unmarshal(resp.Body, &item); err != nil {
+		return nil, false, fmt.Errorf("failed to decode %s: %w", key, err)
+	}
+
+	now := time.Now()
+	if item.ExpiresAt != 0 && item.ExpiresAt <= now.UnixMilli() {
+		return nil, false, nil
+	}
+	expiry := now.Add(k.opts.LocalTTL)
+	if item.ExpiresAt != 0 && item.ExpiresAt < expiry.UnixMilli() {
+		expiry = time.UnixMilli(item.ExpiresAt)
+	}
+	k.local.set(key, item.Value, expiry)
+	return item.Value, true, nil
+}
+
+// SetCtx stores an item for d, DefaultExpiration, or NoExpiration
+func (k *KVCache) SetCtx(ctx context.Context, key string, value interface{}, d time.Duration) error {
+	expiry := k.expiry(d)
+	if k.opts.WriteThrough {
+		item, err := k.item(value, expiry)
+		if err != nil {
+			return err
+		}
+		if err := k.client.Put(ctx, ModelKV, k.opts.Collection, key, item); err != nil {
+			return fmt.Errorf("failed to store %s: %w", key, err)
+		}
+	}
+	k.local.set(key, value, expiry)
+	return nil
+}
+
+// AddCtx stores an item only if the key is not cached yet. With WriteThrough, the
+// check is atomic in ThemisDB; an expired stored item is replaced.
+func (k *KVCache) AddCtx(ctx context.Context, key string, value interface{}, d time.Duration) error {
+	if _, found, err := k.GetCtx(ctx, key); err != nil {
+		return err
+	} else if found {
+		return fmt.Errorf("item %s already exists: %w", key, ErrAlreadyExists)
+	}
+	if !k.opts.WriteThrough {
+		k.local.set(key, value, k.expiry(d))
+		return nil
+	}
+
+	item, err := k.item(value, k.expiry(d))
+	if err != nil {
+		return err
+	}
+	err = k.client.Create(ctx, ModelKV, k.opts.Collection, key, item)
+	switch {
+	case err == nil:
+		k.local.set(key, value, k.expiry(d))
+		return nil
+	case !errors.Is(err, ErrAlreadyExists):
+		return err
+	}
+	// another writer added the key concurrently, or the stored item has expired
+	if _, found, err := k.GetCtx(ctx, key); err != nil {
+		return err
+	} else if found {
+		return fmt.Errorf("item %s already exists: %w", key, ErrAlreadyExists)
+	}
+	return k.SetCtx(ctx, key, value, d)
+}
+
+// DeleteCtx removes an item; deleting an unknown key is not an error
+func (k *KVCache) DeleteCtx(ctx context.Context, key string) error {
+	if k.opts.WriteThrough {
+		if err := validateKey("key", key); err != nil {
+			return err
+		}
+		resp, err := k.client.send(ctx, "DELETE", entityPath(ModelKV, k.opts.Collection, key), nil, nil)
+		if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+			return fmt.Errorf("failed to delete %s: %w", key, err)
+		}
+	}
+	k.local.delete(key)
+	return nil
+}
+
+// expiry converts a go-cache duration into an absolute expiry, zero never expires
+func (k *KVCache) expiry(d time.Duration) time.Time {
+	if d == DefaultExpiration {
+		d = k.opts.DefaultTTL
+	}
+	if d <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(d)
+}
+
+// item encodes value as a stored item
+func (k *KVCache) item(value interface{}, expiry time.Time) (cachedItem, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return cachedItem{}, fmt.Errorf("failed to encode cache item: %w", err)
+	}
+	item := cachedItem{Value: data}
+	if !expiry.IsZero() {
+		item.ExpiresAt = expiry.UnixMilli()
+	}
+	return item, nil
+}
+
+// report passes an error swallowed by a context-free method to OnError
+func (k *KVCache) report(err error) {
+	if err != nil && k.opts.OnError != nil {
+		k.opts.OnError(err)
+	}
+}
+
+// Ristretto returns a view of the cache with the API of github.com/dgraph-io/ristretto.
+// Keys are formatted with fmt.Sprint and costs are ignored; MaxEntries bounds the local tier.
+func (k *KVCache) Ristretto() *RistrettoCache {
+	return &RistrettoCache{cache: k}
+}
+
+// RistrettoCache adapts a KVCache to the API of github.com/dgraph-io/ristretto
+type RistrettoCache struct {
+	cache *KVCache
+}
+
+// Get returns the value of key
+func (r *RistrettoCache) Get(key interface{}) (interface{}, bool) {
+	return r.cache.Get(fmt.Sprint(key))
+}
+
+// Set stores value without expiration and reports whether it was stored
+func (r *RistrettoCache) Set(key, value interface{}, cost int64) bool {
+	return r.SetWithTTL(key, value, cost, 0)
+}
+
+// SetWithTTL stores value for ttl, zero never expires, and reports whether it was stored
+func (r *RistrettoCache) SetWithTTL(key, value interface{}, cost int64, ttl time.Duration) bool {
+	if ttl <= 0 {
+		ttl = NoExpiration
+	}
+	err := r.cache.SetCtx(context.Background(), fmt.Sprint(key), value, ttl)
+	r.cache.report(err)
+	return err == nil
+}
+
+// Del removes key
+func (r *RistrettoCache) Del(key interface{}) {
+	r.cache.Delete(fmt.Sprint(key))
+}
+
+// Clear empties the local tier
+func (r *RistrettoCache) Clear() {
+	r.cache.Flush()
+}
+
+// Wait returns immediately, since writes are applied synchronously
+func (r *RistrettoCache) Wait() {}
+
+// lruCache is a size-bounded in-memory cache with per-entry expiry
+type lruCache struct {
+	mu      sync.Mutex
+	max     int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// lruEntry is an element of lruCache.order, expiry is zero for entries that never expire
+type lruEntry struct {
+	key    string
+	value  interface{}
+	expiry time.Time
+}
+
+// newLRUCache returns an empty cache holding at most max entries
+func newLRUCache(max int) *lruCache {
+	return &lruCache{max: max, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// get returns an unexpired entry and marks it as recently used
+func (l *lruCache) get(key string) (interface{}, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if !entry.expiry.IsZero() && time.Now().After(entry.expiry) {
+		l.order.Remove(elem)
+		delete(l.entries, key)
+		return nil, false
+	}
+	l.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// set stores an entry, evicting the least recently used entry when full
+func (l *lruCache) set(key string, value interface{}, expiry time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, ok := l.entries[key]; ok {
+		elem.Value = &lruEntry{key: key, value: value, expiry: expiry}
+		l.order.MoveToFront(elem)
+		return
+	}
+	l.entries[key] = l.order.PushFront(&lruEntry{key: key, value: value, expiry: expiry})
+	if l.order.Len() > l.max {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// delete removes an entry
+func (l *lruCache) delete(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if elem, ok := l.entries[key]; ok {
+		l.order.Remove(elem)
+		delete(l.entries, key)
+	}
+}
+
+// clear removes all entries
+func (l *lruCache) clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.order.Init()
+	l.entries = make(map[string]*list.Element)
+}
+
+// len returns the number of entries, including expired ones not yet evicted
+func (l *lruCache) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
@@ -0,0 +1,95 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKVCache_ReadThrough(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	ctx := context.Background()
+	require.NoError(t, client.Put(ctx, ModelKV, "_cache", "greeting", cachedItem{Value: json.RawMessage(`"hello"`)}))
+
+	cache := client.KVCache(CacheOptions{})
+	value, found := cache.Get("greeting")
+	require.True(t, found)
+	assert.JSONEq(t, `"hello"`, string(value.(json.RawMessage)))
+	assert.Equal(t, 1, cache.ItemCount())
+
+	// without write-through, sets stay local
+	cache.Set("local", 42, DefaultExpiration)
+	value, found = cache.Get("local")
+	assert.True(t, found)
+	assert.Equal(t, 42, value)
+	_, found, err := client.KVCache(CacheOptions{}).GetCtx(ctx, "local")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	// delete invalidates, the next Get reloads from ThemisDB
+	cache.Delete("greeting")
+	_, found = cache.Get("greeting")
+	assert.True(t, found)
+}
+
+func TestKVCache_WriteThrough(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	ctx := context.Background()
+	cache := client.KVCache(CacheOptions{WriteThrough: true})
+
+	require.NoError(t, cache.SetCtx(ctx, "user:1", map[string]string{"name": "alice"}, time.Hour))
+	other := client.KVCache(CacheOptions{})
+	value, found, err := other.GetCtx(ctx, "user:1")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.JSONEq(t, `{"name":"alice"}`, string(value.(json.RawMessage)))
+
+	assert.ErrorIs(t, cache.Add("user:1", "bob", NoExpiration), ErrAlreadyExists)
+	require.NoError(t, cache.Add("user:2", "bob", NoExpiration))
+
+	require.NoError(t, cache.DeleteCtx(ctx, "user:1"))
+	require.NoError(t, cache.DeleteCtx(ctx, "user:1"))
+	other.Flush()
+	_, found, err = other.GetCtx(ctx, "user:1")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestKVCache_Expiration(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	cache := client.KVCache(CacheOptions{WriteThrough: true, DefaultTTL: 20 * time.Millisecond})
+
+	cache.SetDefault("short", "value")
+	cache.Set("forever", "value", NoExpiration)
+	time.Sleep(40 * time.Millisecond)
+	cache.Flush()
+
+	_, found := cache.Get("short")
+	assert.False(t, found)
+	_, found = cache.Get("forever")
+	assert.True(t, found)
+}
+
+func TestKVCache_Ristretto(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	cache := client.KVCache(CacheOptions{MaxEntries: 2}).Ristretto()
+
+	assert.True(t, cache.Set(1, "one", 1))
+	assert.True(t, cache.Set(2, "two", 1))
+	cache.Wait()
+	_, found := cache.Get(1)
+	assert.True(t, found)
+
+	// the least recently used key is evicted from the local tier
+	assert.True(t, cache.SetWithTTL(3, "three", 1, time.Minute))
+	_, found = cache.Get(2)
+	assert.False(t, found)
+
+	cache.Del(1)
+	_, found = cache.Get(1)
+	assert.False(t, found)
+}
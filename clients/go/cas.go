@@ -0,0 +1,91 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+// getVersioned decodes the document collection/name into v and returns its revision (ETag).
+// exists is false if the document does not exist.
+func (c *Client) getVersioned(ctx context.Context, collection, name string, v interface{}) (rev string, exists bool, err error) {
+	resp, err := c.send(ctx, "GET", entityPath(ModelRelational, collection, name), nil, nil)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if len(resp.Body) > 0 {
+		if err := json.Unmarshal(resp.Body, v); err != nil {
+			return "", false, fmt.Errorf("failed to decode %s: %w", name, err)
+		}
+	}
+	return resp.Header.Get("ETag"), true, nil
+}
+
+// putVersioned writes v to collection/name if the stored revision is still rev, or if
+// the document does not exist when exists is false. It returns ErrConflict otherwise.
+func (c *Client) putVersioned(ctx context.Context, collection, name string, v interface{}, rev string, exists bool) error {
+	headers := map[string]string{"If-None-Match": "*"}
+	if exists {
+		headers = map[string]string{"If-Match": rev}
+	}
+	resp, err := c.send(ctx, "PUT", entityPath(ModelRelational, collection, name), v, headers)
+	if resp != nil && (resp.StatusCode == http.StatusPreconditionFailed || resp.StatusCode == http.StatusConflict) {
+		return ErrConflict
+	}
+	return err
+}
+
+// deleteVersioned deletes collection/name if the stored revision is still rev.
+// It returns ErrConflict otherwise.
+func (c *Client) deleteVersioned(ctx context.Context, collection, name, rev string) error {
+	resp, err := c.send(ctx, "DELETE", entityPath(ModelRelational, collection, name), nil, map[string]string{"If-Match": rev})
+	if resp != nil && (resp.StatusCode == http.StatusPreconditionFailed || resp.StatusCode == http.StatusConflict) {
+		return ErrConflict
+	}
+	return err
+}
+
+// casUpdate reads collection/name into state, lets update modify it, and writes it back
+// if no other writer changed the document in between, retrying with jittered backoff on
+// conflicts. state must be a pointer; it is reset before each attempt. update returns
+// false to skip the write, e.g. when a limit is reached.
+func (c *Client) casUpdate(ctx context.Context, collection, name string, state interface{}, update func(exists bool) (bool, error)) error {
+	if err := validateKey("name", name); err != nil {
+		return err
+	}
+
+	target := reflect.ValueOf(state).Elem()
+	backoff := time.Millisecond
+	for {
+		target.Set(reflect.Zero(target.Type()))
+		rev, exists, err := c.getVersioned(ctx, collection, name, state)
+		if err != nil {
+			return err
+		}
+		write, err := update(exists)
+		if err != nil || !write {
+			return err
+		}
+
+		err = c.putVersioned(ctx, collection, name, state, rev, exists)
+		if err != ErrConflict {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff/2 + time.Duration(rand.Int63n(int64(backoff)))):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if backoff < 100*time.Millisecond {
+			backoff *= 2
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package themisdb
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ChangesOptions configures ReadChanges
+type ChangesOptions struct {
+	// Collection restricts the changes to one collection
+	Collection string
+	// Prefix restricts the changes to UUIDs starting with Prefix; it requires Collection
+	Prefix string
+	// Limit is the maximum number of changes returned (default: 100)
+	Limit int
+	// Wait long-polls the server up to this long while there is no matching change
+	Wait time.Duration
+}
+
+// ReadChanges returns the PUT and DELETE changes recorded on the server changefeed after
+// sequence number after, in sequence order. Pass the Sequence of the last change read
+// to continue. The changefeed only retains changes for the configured CDC retention.
+func (c *Client) ReadChanges(ctx context.Context, after uint64, opts ChangesOptions) ([]Change, error) {
+	if opts.Prefix != "" && opts.Collection == "" {
+		return nil, &ValidationError{Field: "prefix", Value: opts.Prefix, Reason: "requires a collection"}
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 100
+	}
+	query := url.Values{}
+	query.Set("from_seq", strconv.FormatUint(after, 10))
+	query.Set("limit", strconv.Itoa(opts.Limit))
+	if opts.Wait > 0 {
+		query.Set("long_poll_ms", strconv.FormatInt(opts.Wait.Milliseconds(), 10))
+	}
+	if opts.Collection != "" {
+		query.Set("key_prefix", opts.Collection+":"+opts.Prefix)
+	}
+
+	var result struct {
+		Events []changeEvent `json:"events"`
+	}
+	if err := c.request(ctx, "GET", "/changefeed?"+query.Encode(), nil, &result, nil); err != nil {
+		return nil, fmt.Errorf("failed to read changefeed: %w", err)
+	}
+	changes := make([]Change, 0, len(result.Events))
+	for _, event := range result.Events {
+		if change, ok := event.change(); ok {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+// LatestChangeSequence returns the sequence number of the latest change on the server
+// changefeed, the starting point for reading only changes made from now on
+func (c *Client) LatestChangeSequence(ctx context.Context) (uint64, error) {
+	var result struct {
+		LatestSequence uint64 `json:"latest_sequence"`
+	}
+	if err := c.request(ctx, "GET", "/changefeed?limit=0", nil, &result, nil); err != nil {
+		return 0, fmt.Errorf("failed to read changefeed: %w", err)
+	}
+	return result.LatestSequence, nil
+}
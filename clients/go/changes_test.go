@@ -0,0 +1,50 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ReadChanges(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/changefeed", r.URL.Path)
+		if r.URL.Query().Get("limit") == "0" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"events": []changeEvent{}, "latest_sequence": 42})
+			return
+		}
+		assert.Equal(t, "7", r.URL.Query().Get("from_seq"))
+		assert.Equal(t, "10", r.URL.Query().Get("limit"))
+		assert.Equal(t, "1500", r.URL.Query().Get("long_poll_ms"))
+		assert.Equal(t, "orders:2024/", r.URL.Query().Get("key_prefix"))
+		value := `{"total":3}`
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"events": []changeEvent{
+				{Sequence: 8, Type: "PUT", Key: "orders:2024/o1", Value: &value, TimestampMs: 1700000000000},
+				{Sequence: 9, Type: "TRANSACTION_COMMIT", Key: ""},
+				{Sequence: 10, Type: "DELETE", Key: "orders:2024/o2"},
+			},
+			"latest_sequence": 10,
+		})
+	})
+	ctx := context.Background()
+
+	changes, err := client.ReadChanges(ctx, 7, ChangesOptions{Collection: "orders", Prefix: "2024/", Limit: 10, Wait: 1500 * time.Millisecond})
+	require.NoError(t, err)
+	require.Len(t, changes, 2, "only PUT and DELETE changes are returned")
+	assert.Equal(t, Change{Sequence: 8, Type: "PUT", Collection: "orders", UUID: "2024/o1", Document: json.RawMessage(`{"total":3}`), Time: time.UnixMilli(1700000000000)}, changes[0])
+	assert.Equal(t, "DELETE", changes[1].Type)
+	assert.Nil(t, changes[1].Document)
+
+	latest, err := client.LatestChangeSequence(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), latest)
+
+	_, err = client.ReadChanges(ctx, 0, ChangesOptions{Prefix: "2024/"})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
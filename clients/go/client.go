@@ -1,14 +1,15 @@
 package themisdb
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,20 +25,103 @@ const (
 
 // Client is the ThemisDB client
 type Client struct {
-	endpoints  []string
-	httpClient *http.Client
-	mu         sync.RWMutex
-	activeIdx  int
+	endpoints        []string
+	replicas         []string
+	httpClient       *http.Client
+	transport        Transport
+	enums            *enumRegistry
+	schemas          *schemaRegistry
+	plugins          pluginCache
+	prepared         preparedQueries
+	timeouts         OperationTimeouts
+	maxRetries       int
+	hedger           *hedger
+	discovery        *discovery
+	handler          Handler
+	interceptors     []Interceptor
+	queryLog         *queryLogger
+	cache            *responseCache
+	balancer         LoadBalancer
+	root             *Client
+	namespace        string
+	snapshot         *SnapshotRef
+	negotiation      *negotiation
+	stats            *latencyStats
+	hooks            *requestHooks
+	throttle         *throttle
+	writes           *writeGate
+	fieldStats       *fieldStats
+	codec            Codec
+	minServerVersion string
+	closeOnce        sync.Once
+	mu               sync.RWMutex
+	activeIdx        int
+	replicaIdx       uint32
 }
 
 // Config holds client configuration
 type Config struct {
 	// Endpoints is a list of ThemisDB server endpoints
 	Endpoints []string
-	// Timeout for HTTP requests (default: 30s)
+	// Replicas lists read replica endpoints serving eventual and bounded staleness reads
+	Replicas []string
+	// Timeout bounds each request whose kind has no timeout in OperationTimeouts
+	// (default: 30s)
 	Timeout time.Duration
+	// OperationTimeouts sets separate timeouts for reads, writes, queries, and
+	// transactions; a context deadline that expires earlier takes precedence
+	OperationTimeouts OperationTimeouts
 	// MaxRetries for failed requests (default: 3)
 	MaxRetries int
+	// Protocol selects the wire protocol, ProtocolHTTP or ProtocolGRPC (default: http)
+	Protocol string
+	// Transport overrides the transport selected by Protocol
+	Transport Transport
+	// Hedging enables hedged reads across endpoints, nil disables hedging
+	Hedging *HedgingOptions
+	// Discovery learns the endpoints from the cluster topology, nil uses Endpoints as given
+	Discovery *DiscoveryOptions
+	// Interceptors wrap every request, the first interceptor being outermost
+	Interceptors []Interceptor
+	// Namespace scopes every request to a tenant namespace (see WithNamespace)
+	Namespace string
+	// QueryLog samples executed queries to a sink, nil disables query logging
+	QueryLog *QueryLogOptions
+	// Cache enables the client-side Get cache, nil disables it
+	Cache *ResponseCacheOptions
+	// LoadBalancer chooses the endpoint of each request, nil sends all requests to the
+	// first endpoint. Transactions stay on the endpoint that began them.
+	LoadBalancer LoadBalancer
+	// Compression compresses HTTP request bodies and negotiates compressed responses,
+	// nil leaves bodies uncompressed. The gRPC transport ignores it.
+	Compression *CompressionOptions
+	// LatencyBuckets are the upper bounds of the latency histograms reported by Stats
+	// (default: DefaultLatencyBuckets)
+	LatencyBuckets []time.Duration
+	// MinServerVersion is the oldest server version VerifyEndpoints accepts, empty
+	// accepts any
+	MinServerVersion string
+	// Negotiate fetches ServerInfo before the first request and adapts the client to
+	// the server: operations on optional features it does not report fail fast with
+	// ErrFeatureUnsupported, and compression falls back to an encoding it accepts
+	Negotiate bool
+	// Logger logs every attempt of every request: requests and responses at debug
+	// level, retries and server errors at warn, and transport errors at error level.
+	// Nil disables logging.
+	Logger *slog.Logger
+	// Hooks observe every attempt of every request, and configure body logging and
+	// header redaction for Logger as well
+	Hooks *RequestHooks
+	// FieldStats records which fields of the documents read are decoded into the
+	// result types of the application, as reported by Client.FieldStats. It costs an
+	// extra parse of every document read.
+	FieldStats bool
+	// RateLimit limits the request rate of the client and its derived clients, and
+	// retries requests answered with 429 up to MaxRetries times after the wait the
+	// server asks for. Nil disables both.
+	RateLimit *RateLimitOptions
+	// Codec encodes request bodies and decodes responses (default: JSONCodec)
+	Codec Codec
 }
 
 // NewClient creates a new ThemisDB client
@@ -52,102 +136,441 @@ func NewClient(config Config) *Client {
 		config.Endpoints = []string{"http://localhost:8080"}
 	}
 
-	return &Client{
-		endpoints: config.Endpoints,
-		httpClient: &http.Client{
-			Timeout: config.Timeout,
-		},
-		activeIdx: 0,
+	// requests are bounded per operation through their context, so streaming
+	// responses are not cut off by a client-wide timeout
+	httpClient := &http.Client{}
+
+	transport := config.Transport
+	if transport == nil {
+		switch config.Protocol {
+		case ProtocolGRPC:
+			transport = newGRPCTransport()
+		default:
+			t := newHTTPTransport(httpClient)
+			t.compression = newCompression(config.Compression)
+			transport = t
+		}
+	}
+
+	c := &Client{
+		endpoints:        config.Endpoints,
+		replicas:         config.Replicas,
+		httpClient:       httpClient,
+		transport:        transport,
+		timeouts:         config.OperationTimeouts.withDefault(config.Timeout),
+		maxRetries:       config.MaxRetries,
+		enums:            &enumRegistry{},
+		schemas:          &schemaRegistry{},
+		namespace:        config.Namespace,
+		hedger:           newHedger(config.Hedging),
+		discovery:        newDiscovery(config.Discovery, config),
+		queryLog:         newQueryLogger(config.QueryLog),
+		cache:            newResponseCache(config.Cache),
+		balancer:         config.LoadBalancer,
+		stats:            newLatencyStats(config.LatencyBuckets),
+		hooks:            newRequestHooks(config),
+		interceptors:     config.Interceptors,
+		throttle:         newThrottle(config.RateLimit, config.MaxRetries),
+		writes:           &writeGate{},
+		codec:            config.Codec,
+		minServerVersion: config.MinServerVersion,
+		activeIdx:        0,
+	}
+	if c.codec == nil {
+		c.codec = JSONCodec{}
+	}
+	if config.Negotiate {
+		c.negotiation = &negotiation{}
+	}
+	if config.FieldStats {
+		c.fieldStats = &fieldStats{sources: map[string]*sourceFields{}}
+	}
+	final := c.roundTrip
+	if c.throttle != nil {
+		final = c.roundTripThrottled
+	}
+	c.handler = chainInterceptors(config.Interceptors, final)
+	if c.discovery != nil {
+		go c.runDiscovery()
+	}
+	return c
+}
+
+// Close stops topology discovery and releases connections held by the client's transport
+func (c *Client) Close() error {
+	if c.root != nil {
+		return nil
 	}
+	if c.discovery != nil {
+		c.closeOnce.Do(func() { close(c.discovery.stop) })
+	}
+	return c.transport.Close()
 }
 
 // Get retrieves an entity by UUID
 func (c *Client) Get(ctx context.Context, model, collection, uuid string, result interface{}) error {
-	path := fmt.Sprintf("/api/%s/%s/%s", model, collection, uuid)
-	return c.request(ctx, "GET", path, nil, result, nil)
+	if ctx, tx := c.contextTx(ctx); tx != nil {
+		return tx.Get(ctx, model, collection, uuid, result)
+	}
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return err
+	}
+	path := entityPath(model, collection, uuid)
+	if c.cache != nil {
+		_, err := c.cache.get(ctx, c, path, result)
+		return err
+	}
+	return c.readRequest(ctx, "GET", path, nil, result, nil)
 }
 
 // Put creates or updates an entity
 func (c *Client) Put(ctx context.Context, model, collection, uuid string, data interface{}) error {
-	path := fmt.Sprintf("/api/%s/%s/%s", model, collection, uuid)
+	if ctx, tx := c.contextTx(ctx); tx != nil {
+		return tx.Put(ctx, model, collection, uuid, data)
+	}
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return err
+	}
+	if err := c.validateDocument(model, collection, data); err != nil {
+		return err
+	}
+	path := entityPath(model, collection, uuid)
 	return c.request(ctx, "PUT", path, data, nil, nil)
 }
 
 // Delete removes an entity by UUID
 func (c *Client) Delete(ctx context.Context, model, collection, uuid string) error {
-	path := fmt.Sprintf("/api/%s/%s/%s", model, collection, uuid)
+	if ctx, tx := c.contextTx(ctx); tx != nil {
+		return tx.Delete(ctx, model, collection, uuid)
+	}
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return err
+	}
+	path := entityPath(model, collection, uuid)
 	return c.request(ctx, "DELETE", path, nil, nil, nil)
 }
 
+// GetMany retrieves many entities in a single round trip.
+// results must be a pointer to a map keyed by UUID, e.g. *map[string]User.
+// UUIDs that do not exist are returned as missing instead of failing the call.
+func (c *Client) GetMany(ctx context.Context, model, collection string, uuids []string, results interface{}) ([]string, error) {
+	if ctx, tx := c.contextTx(ctx); tx != nil {
+		return tx.GetMany(ctx, model, collection, uuids, results)
+	}
+	return c.getMany(ctx, model, collection, uuids, results, nil)
+}
+
+// getMany performs a multi-get and decodes the found documents into results
+func (c *Client) getMany(ctx context.Context, model, collection string, uuids []string, results interface{}, headers map[string]string) ([]string, error) {
+	if err := validateCollection(model, collection); err != nil {
+		return nil, err
+	}
+	for i, uuid := range uuids {
+		if err := validateKey(fmt.Sprintf("uuids[%d]", i), uuid); err != nil {
+			return nil, err
+		}
+	}
+
+	path := joinPath("/api", model, collection) + "/_mget"
+	body := map[string]interface{}{
+		"uuids": uuids,
+	}
+
+	var response struct {
+		Documents json.RawMessage `json:"documents"`
+		Missing   []string        `json:"missing"`
+	}
+	if err := c.readRequest(ctx, "POST", path, body, &response, headers); err != nil {
+		return nil, err
+	}
+	if len(response.Documents) > 0 {
+		if err := c.decode(response.Documents, results); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal documents: %w", err)
+		}
+		c.fieldStats.record(model+"/"+collection, response.Documents, results)
+	}
+	return response.Missing, nil
+}
+
 // QueryResult holds query results
 type QueryResult struct {
-	Data interface{} `json:"data"`
+	// Data holds the rows as sent by the server; they are decoded only into the
+	// result of the query
+	Data json.RawMessage `json:"data"`
+	// Plan is set when the query was sent with explain
+	Plan json.RawMessage `json:"plan,omitempty"`
+	// Profile is set when the query was sent with profiling
+	Profile json.RawMessage `json:"profile,omitempty"`
+	// Total is the number of matching rows before LIMIT, if the server counted them
+	Total *int64 `json:"total,omitempty"`
+	// Count is the number of rows in Data, if reported by the server
+	Count *int `json:"count,omitempty"`
+	// ExecutionTimeMs is the server-side execution time in milliseconds
+	ExecutionTimeMs float64 `json:"execution_time_ms,omitempty"`
+	// Cursor identifies a server-side cursor holding further rows
+	Cursor string `json:"cursor,omitempty"`
+	// HasMore reports whether the cursor holds further rows
+	HasMore bool `json:"has_more,omitempty"`
+	// Warnings are non-fatal notices, e.g. deprecated functions used by the query
+	Warnings []QueryWarning `json:"warnings,omitempty"`
+}
+
+// Collation controls locale-aware string comparison for sorting, filtering, and indexes
+type Collation struct {
+	// Locale is a BCP 47 language tag such as "de-DE" or "sv"
+	Locale string `json:"locale"`
+	// CaseInsensitive compares strings without regard to case
+	CaseInsensitive bool `json:"case_insensitive,omitempty"`
+	// IgnoreAccents compares strings without regard to diacritics
+	IgnoreAccents bool `json:"ignore_accents,omitempty"`
+	// NumericOrdering sorts digit sequences by numeric value ("item2" < "item10")
+	NumericOrdering bool `json:"numeric_ordering,omitempty"`
+}
+
+// QueryOptions holds per-query configuration
+type QueryOptions struct {
+	// Collation applies to SORT and FILTER string comparisons of the query
+	Collation *Collation
+	// Read sets the consistency of the query, nil reads with strong consistency; its
+	// AsOf or SnapshotID run the query against the data at that point in time
+	Read *ReadOptions
+	// BindVars holds the values of @name placeholders in the query
+	BindVars map[string]interface{}
+	// Hints override decisions of the query planner
+	Hints *QueryHints
+	// ReadOnly declares that the query does not modify data. Queries without INSERT,
+	// UPDATE, REPLACE, REMOVE, or UPSERT are read-only anyway; set it for reads that use
+	// these words otherwise, e.g. as variable names. Only read-only queries are hedged,
+	// served by replicas, and accepted by a client pinned with AtSnapshot; any other
+	// query is treated as a write.
+	ReadOnly bool
 }
 
 // Query executes an AQL query
 func (c *Client) Query(ctx context.Context, aql string, result interface{}) error {
+	if ctx, tx := c.contextTx(ctx); tx != nil {
+		return tx.Query(ctx, aql, result)
+	}
+	return c.query(ctx, aql, nil, result, nil)
+}
+
+// QueryWithOptions executes an AQL query with per-query options
+func (c *Client) QueryWithOptions(ctx context.Context, aql string, opts *QueryOptions, result interface{}) error {
+	if ctx, tx := c.contextTx(ctx); tx != nil {
+		return tx.QueryWithOptions(ctx, aql, opts, result)
+	}
+	return c.query(ctx, aql, opts, result, nil)
+}
+
+// query executes an AQL query and decodes its data into result
+func (c *Client) query(ctx context.Context, aql string, opts *QueryOptions, result interface{}, headers map[string]string) error {
+	_, err := c.execQuery(ctx, aql, opts, nil, result, headers)
+	return err
+}
+
+// execQuery executes an AQL query with extra body fields, decodes its data into result
+// if result is not nil, and returns the full response
+func (c *Client) execQuery(ctx context.Context, aql string, opts *QueryOptions, extra map[string]interface{}, result interface{}, headers map[string]string) (*QueryResult, error) {
 	path := "/api/query"
 	body := map[string]interface{}{
 		"query": aql,
 	}
+	if opts != nil && opts.Collation != nil {
+		body["collation"] = opts.Collation
+	}
+	if opts != nil && len(opts.BindVars) > 0 {
+		body["bind_vars"] = opts.BindVars
+	}
+	if opts != nil && opts.Hints != nil {
+		if err := opts.Hints.validate(); err != nil {
+			return nil, err
+		}
+		body["hints"] = opts.Hints
+	}
+	if opts != nil && opts.Read != nil {
+		if err := opts.Read.validate(); err != nil {
+			return nil, err
+		}
+		if err := c.checkSnapshot(ctx, opts.Read); err != nil {
+			return nil, err
+		}
+		headers = opts.Read.withHeaders(headers)
+	}
+	for key, value := range extra {
+		body[key] = value
+	}
+	req, err := c.newRequest("POST", path, body, headers)
+	if err != nil {
+		return nil, err
+	}
+	if (opts != nil && opts.ReadOnly) || readOnlyAQL(aql) {
+		req.ReadOnly = true
+		req.Idempotent = true
+	}
+
 	var queryResult QueryResult
-	if err := c.request(ctx, "POST", path, body, &queryResult, nil); err != nil {
-		return err
+	start := time.Now()
+	resp, err := c.Do(ctx, req)
+	if err == nil && resp.StatusCode != http.StatusNoContent && len(resp.Body) > 0 {
+		if err = c.decode(resp.Body, &queryResult); err != nil {
+			err = fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	if c.queryLog != nil {
+		c.queryLog.log(aql, opts, headers, start, resp, &queryResult, err)
 	}
-	
-	// Marshal and unmarshal to convert to result type
-	data, err := json.Marshal(queryResult.Data)
 	if err != nil {
-		return fmt.Errorf("failed to marshal query result: %w", err)
+		return nil, err
 	}
-	if err := json.Unmarshal(data, result); err != nil {
-		return fmt.Errorf("failed to unmarshal query result: %w", err)
+	if result == nil || len(queryResult.Data) == 0 {
+		return &queryResult, nil
 	}
-	return nil
+	if err := c.decode(queryResult.Data, result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal query result: %w", err)
+	}
+	c.fieldStats.record(CanonicalizeAQL(aql), queryResult.Data, result)
+	return &queryResult, nil
 }
 
-// request performs an HTTP request
+// request performs an API request through the configured transport
 func (c *Client) request(ctx context.Context, method, path string, body interface{}, result interface{}, headers map[string]string) error {
-	var reqBody io.Reader
-	if body != nil {
-		data, err := json.Marshal(body)
-		if err != nil {
-			return fmt.Errorf("failed to marshal request body: %w", err)
+	req, err := c.newRequest(method, path, body, headers)
+	if err != nil {
+		return err
+	}
+	return c.doDecode(ctx, req, result)
+}
+
+// readRequest performs an idempotent read, which may be hedged (see Config.Hedging)
+func (c *Client) readRequest(ctx context.Context, method, path string, body interface{}, result interface{}, headers map[string]string) error {
+	req, err := c.newRequest(method, path, body, headers)
+	if err != nil {
+		return err
+	}
+	req.ReadOnly = true
+	req.Idempotent = true
+	return c.doDecode(ctx, req, result)
+}
+
+// doDecode sends req and decodes the response body into result
+func (c *Client) doDecode(ctx context.Context, req *Request, result interface{}) error {
+	resp, err := c.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if result != nil && resp.StatusCode != http.StatusNoContent && len(resp.Body) > 0 {
+		if err := c.decode(resp.Body, result); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		if c.fieldStats != nil && entityRead(req) {
+			c.fieldStats.record(collectionOf(req), resp.Body, result)
 		}
-		reqBody = bytes.NewReader(data)
 	}
 
-	endpoint := c.getEndpoint()
-	url := endpoint + path
+	return nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+// send marshals body and performs a request, returning the raw response
+func (c *Client) send(ctx context.Context, method, path string, body interface{}, headers map[string]string) (*Response, error) {
+	req, err := c.newRequest(method, path, body, headers)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
+	return c.Do(ctx, req)
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	for key, value := range headers {
-		req.Header.Set(key, value)
+// newRequest builds a request with a body encoded by the codec of the client
+func (c *Client) newRequest(method, path string, body interface{}, headers map[string]string) (*Request, error) {
+	req := &Request{
+		Method: method,
+		Path:   path,
+		Header: headers,
+	}
+	if body != nil {
+		codec := c.codec
+		if codec == nil {
+			codec = JSONCodec{}
+		}
+		data, err := codec.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		req.Body = data
 	}
+	return req, nil
+}
 
-	resp, err := c.httpClient.Do(req)
+// Do sends a raw API request to the active endpoint through the configured transport.
+// It is the building block for sub-clients of custom server models (see RegisterPlugin).
+// Status codes >= 400 are returned as a *StatusError together with the response.
+func (c *Client) Do(ctx context.Context, req *Request) (*Response, error) {
+	if c.negotiation != nil && req.Path != serverInfoPath {
+		c.negotiate(ctx)
+	}
+	if err := c.withNamespace(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := c.withSnapshot(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := c.writes.wait(ctx, req); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeouts.of(req))
+	defer cancel()
+	if c.hooks != nil {
+		ctx = withAttempts(ctx)
+	}
+	start := time.Now()
+	resp, err := c.handler(ctx, req)
+	c.stats.observe(req, time.Since(start), err != nil || resp.StatusCode >= http.StatusInternalServerError)
+	if c.cache != nil && req.Method != "GET" {
+		c.cache.invalidate(ctx, c, req)
+	}
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+		return resp, &StatusError{StatusCode: resp.StatusCode, Message: string(resp.Body)}
 	}
+	return resp, nil
+}
 
-	if result != nil && resp.StatusCode != http.StatusNoContent {
-		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-			return fmt.Errorf("failed to decode response: %w", err)
-		}
+// roundTrip sends req to the endpoint of its context, to a replica, hedged across
+// endpoints, or to the endpoint chosen by the load balancer
+func (c *Client) roundTrip(ctx context.Context, req *Request) (*Response, error) {
+	if endpoint, ok := ctx.Value(endpointKey{}).(string); ok {
+		return c.sendTo(ctx, endpoint, req)
+	}
+	if c.replicaRead(req) {
+		return c.doReplica(ctx, req)
+	}
+	if c.hedger != nil && req.Idempotent && req.Header["X-Transaction-Id"] == "" && c.endpointCount() > 1 {
+		return c.doHedged(ctx, req)
 	}
+	return c.sendBalanced(ctx, req)
+}
 
-	return nil
+// sendTo sends req to endpoint, reporting the attempt to the request hooks
+func (c *Client) sendTo(ctx context.Context, endpoint string, req *Request) (*Response, error) {
+	if c.hooks != nil {
+		return c.hooks.observe(ctx, endpoint, req, func() (*Response, error) {
+			return c.roundTripTo(ctx, endpoint, req)
+		})
+	}
+	return c.roundTripTo(ctx, endpoint, req)
+}
+
+// roundTripTo sends req to endpoint through the transport and records the endpoint in
+// the response
+func (c *Client) roundTripTo(ctx context.Context, endpoint string, req *Request) (*Response, error) {
+	resp, err := c.transport.RoundTrip(ctx, endpoint, req)
+	if resp != nil && resp.Endpoint == "" {
+		resp.Endpoint = endpoint
+	}
+	return resp, err
 }
 
 // getEndpoint returns the current active endpoint
@@ -157,21 +580,59 @@ func (c *Client) getEndpoint() string {
 	return strings.TrimSuffix(c.endpoints[c.activeIdx], "/")
 }
 
+// endpointCount returns the number of known endpoints
+func (c *Client) endpointCount() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.endpoints)
+}
+
 // TransactionOptions holds transaction configuration
 type TransactionOptions struct {
 	IsolationLevel IsolationLevel
 	Timeout        time.Duration
+	// Heartbeat is the interval of keepalive pings that stop the server from expiring a
+	// long-running transaction; 0 sends none. It should be well below Timeout.
+	Heartbeat time.Duration
+	// OnLeak, if set, is called with the stack of BeginTransaction when the transaction
+	// is garbage collected while still active, before it is rolled back; LogLeak logs it.
+	// A transaction begun with a cancelable context or with a Heartbeat stays reachable
+	// until it ends, so its leaks are only caught by the cancellation of its context.
+	OnLeak func(transactionID string, begunAt []byte)
 }
 
-// Transaction represents an ACID transaction
+// Transaction represents an ACID transaction. It is safe for concurrent use: writes
+// and queries are sent one at a time, with increasing X-Transaction-Seq headers in the
+// order they were issued, while reads may overlap. Commit and Rollback wait for the
+// operations in flight.
 type Transaction struct {
 	client        *Client
 	transactionID string
 	active        bool
-	mu            sync.RWMutex
+	// endpoint is the node that began the transaction, which serves all its requests
+	endpoint string
+	// affinity is the token the server issued to route requests to that node
+	affinity string
+	// lost is set once the node is unreachable
+	lost atomic.Pointer[TransactionNodeError]
+	// prepared is set by Prepare; a prepared transaction only accepts Commit and Rollback
+	prepared bool
+	// mu is held shared by operations in flight and exclusively by Commit and Rollback
+	mu sync.RWMutex
+	// writeMu sequences writes
+	writeMu sync.Mutex
+	seq     uint64
+	// stopHeartbeat ends the heartbeat started by BeginTransaction, nil if there is none
+	stopHeartbeat func()
+	// stopWatch ends the rollback on cancellation of the context of BeginTransaction
+	stopWatch func() bool
+	// expired is set once the heartbeat finds that the server expired the transaction
+	expired atomic.Bool
 }
 
-// BeginTransaction starts a new ACID transaction
+// BeginTransaction starts a new ACID transaction. If ctx is done while the transaction
+// is neither committed, rolled back, nor prepared, it is rolled back, so that abandoned
+// transactions do not hold locks on the server until they time out.
 func (c *Client) BeginTransaction(ctx context.Context, opts *TransactionOptions) (*Transaction, error) {
 	if opts == nil {
 		opts = &TransactionOptions{
@@ -189,24 +650,40 @@ func (c *Client) BeginTransaction(ctx context.Context, opts *TransactionOptions)
 
 	var response struct {
 		TransactionID string `json:"transaction_id"`
+		AffinityToken string `json:"affinity_token"`
 	}
 
-	if err := c.request(ctx, "POST", "/transaction/begin", reqBody, &response, nil); err != nil {
+	resp, err := c.send(ctx, "POST", "/transaction/begin", reqBody, nil)
+	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	if err := json.Unmarshal(resp.Body, &response); err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: failed to decode response: %w", err)
+	}
 
-	return &Transaction{
+	tx := &Transaction{
 		client:        c,
 		transactionID: response.TransactionID,
 		active:        true,
-	}, nil
+		endpoint:      strings.TrimSuffix(resp.Endpoint, "/"),
+		affinity:      response.AffinityToken,
+	}
+	if opts.Heartbeat > 0 {
+		tx.startHeartbeat(opts.Heartbeat)
+	}
+	if opts.OnLeak != nil {
+		tx.detectLeaks(opts.OnLeak)
+	}
+	tx.watch(ctx)
+	return tx, nil
 }
 
-// IsActive returns whether the transaction is still active
+// IsActive returns whether the transaction is still active, i.e. neither committed,
+// rolled back, nor expired by the server
 func (tx *Transaction) IsActive() bool {
 	tx.mu.RLock()
 	defer tx.mu.RUnlock()
-	return tx.active
+	return tx.active && !tx.expired.Load()
 }
 
 // TransactionID returns the transaction ID
@@ -214,82 +691,136 @@ func (tx *Transaction) TransactionID() string {
 	return tx.transactionID
 }
 
+// Endpoint returns the node serving the transaction, empty if the transport did not
+// report it
+func (tx *Transaction) Endpoint() string {
+	return tx.endpoint
+}
+
+// acquire holds the transaction for one operation and returns its context, bound to
+// the transaction's node, and request headers. Writes are exclusive and carry the next
+// sequence number. release must be called when the operation is done.
+func (tx *Transaction) acquire(ctx context.Context, write bool) (_ context.Context, headers map[string]string, release func(), err error) {
+	if write {
+		tx.writeMu.Lock()
+	}
+	tx.mu.RLock()
+	release = func() {
+		tx.mu.RUnlock()
+		if write {
+			tx.writeMu.Unlock()
+		}
+	}
+	if !tx.active {
+		release()
+		return nil, nil, nil, ErrTransactionNotActive
+	}
+	if tx.prepared {
+		release()
+		return nil, nil, nil, ErrTransactionPrepared
+	}
+	if tx.expired.Load() {
+		release()
+		return nil, nil, nil, tx.Err()
+	}
+
+	headers = map[string]string{"X-Transaction-Id": tx.transactionID}
+	if write {
+		tx.seq++
+		headers["X-Transaction-Seq"] = strconv.FormatUint(tx.seq, 10)
+	}
+	return pinTransaction(ctx, tx), headers, release, nil
+}
+
 // Get retrieves an entity within the transaction
 func (tx *Transaction) Get(ctx context.Context, model, collection, uuid string, result interface{}) error {
-	if !tx.IsActive() {
-		return ErrTransactionNotActive
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return err
 	}
-
-	path := fmt.Sprintf("/api/%s/%s/%s", model, collection, uuid)
-	headers := map[string]string{
-		"X-Transaction-Id": tx.transactionID,
+	ctx, headers, release, err := tx.acquire(ctx, false)
+	if err != nil {
+		return err
 	}
+	defer release()
+
+	path := entityPath(model, collection, uuid)
 	return tx.client.request(ctx, "GET", path, nil, result, headers)
 }
 
+// GetMany retrieves many entities within the transaction in a single round trip
+func (tx *Transaction) GetMany(ctx context.Context, model, collection string, uuids []string, results interface{}) ([]string, error) {
+	ctx, headers, release, err := tx.acquire(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return tx.client.getMany(ctx, model, collection, uuids, results, headers)
+}
+
 // Put creates or updates an entity within the transaction
 func (tx *Transaction) Put(ctx context.Context, model, collection, uuid string, data interface{}) error {
-	if !tx.IsActive() {
-		return ErrTransactionNotActive
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return err
 	}
-
-	path := fmt.Sprintf("/api/%s/%s/%s", model, collection, uuid)
-	headers := map[string]string{
-		"X-Transaction-Id": tx.transactionID,
+	if err := tx.client.validateDocument(model, collection, data); err != nil {
+		return err
+	}
+	ctx, headers, release, err := tx.acquire(ctx, true)
+	if err != nil {
+		return err
 	}
+	defer release()
+
+	path := entityPath(model, collection, uuid)
 	return tx.client.request(ctx, "PUT", path, data, nil, headers)
 }
 
 // Delete removes an entity within the transaction
 func (tx *Transaction) Delete(ctx context.Context, model, collection, uuid string) error {
-	if !tx.IsActive() {
-		return ErrTransactionNotActive
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return err
 	}
-
-	path := fmt.Sprintf("/api/%s/%s/%s", model, collection, uuid)
-	headers := map[string]string{
-		"X-Transaction-Id": tx.transactionID,
+	ctx, headers, release, err := tx.acquire(ctx, true)
+	if err != nil {
+		return err
 	}
+	defer release()
+
+	path := entityPath(model, collection, uuid)
 	return tx.client.request(ctx, "DELETE", path, nil, nil, headers)
 }
 
 // Query executes an AQL query within the transaction
 func (tx *Transaction) Query(ctx context.Context, aql string, result interface{}) error {
-	if !tx.IsActive() {
-		return ErrTransactionNotActive
-	}
-
-	path := "/api/query"
-	body := map[string]interface{}{
-		"query": aql,
-	}
-	headers := map[string]string{
-		"X-Transaction-Id": tx.transactionID,
-	}
-	var queryResult QueryResult
-	if err := tx.client.request(ctx, "POST", path, body, &queryResult, headers); err != nil {
-		return err
-	}
+	return tx.QueryWithOptions(ctx, aql, nil, result)
+}
 
-	// Marshal and unmarshal to convert to result type
-	data, err := json.Marshal(queryResult.Data)
+// QueryWithOptions executes an AQL query with per-query options within the transaction
+func (tx *Transaction) QueryWithOptions(ctx context.Context, aql string, opts *QueryOptions, result interface{}) error {
+	ctx, headers, release, err := tx.acquire(ctx, true)
 	if err != nil {
-		return fmt.Errorf("failed to marshal query result: %w", err)
-	}
-	if err := json.Unmarshal(data, result); err != nil {
-		return fmt.Errorf("failed to unmarshal query result: %w", err)
+		return err
 	}
-	return nil
+	defer release()
+	return tx.client.query(ctx, aql, opts, result, headers)
 }
 
 // Commit commits the transaction
 func (tx *Transaction) Commit(ctx context.Context) error {
 	tx.mu.Lock()
 	defer tx.mu.Unlock()
+	return tx.commitLocked(ctx)
+}
 
+// commitLocked commits the transaction while tx.mu is held
+func (tx *Transaction) commitLocked(ctx context.Context) error {
 	if !tx.active {
 		return ErrTransactionNotActive
 	}
+	if tx.expired.Load() {
+		return fmt.Errorf("failed to commit transaction: %w", tx.Err())
+	}
+	ctx = pinTransaction(ctx, tx)
 
 	reqBody := map[string]interface{}{
 		"transaction_id": tx.transactionID,
@@ -300,6 +831,7 @@ func (tx *Transaction) Commit(ctx context.Context) error {
 	}
 
 	tx.active = false
+	tx.stop()
 	return nil
 }
 
@@ -311,16 +843,35 @@ func (tx *Transaction) Rollback(ctx context.Context) error {
 	if !tx.active {
 		return ErrTransactionNotActive
 	}
+	return tx.rollbackLocked(ctx)
+}
+
+// rollbackLocked rolls back the transaction while tx.mu is held. If the rollback fails,
+// the transaction stays active with its heartbeat and context watch, so that it can be
+// rolled back again; a transaction whose node was lost cannot be, and ends with the
+// error.
+func (tx *Transaction) rollbackLocked(ctx context.Context) error {
+	if lost := tx.lost.Load(); lost != nil {
+		tx.active = false
+		tx.stop()
+		return fmt.Errorf("failed to rollback transaction: %w", lost)
+	}
+	ctx = pinTransaction(ctx, tx)
 
 	reqBody := map[string]interface{}{
 		"transaction_id": tx.transactionID,
 	}
 
 	if err := tx.client.request(ctx, "POST", "/transaction/rollback", reqBody, nil, nil); err != nil {
+		if lost := tx.lost.Load(); lost != nil {
+			tx.active = false
+			tx.stop()
+		}
 		return fmt.Errorf("failed to rollback transaction: %w", err)
 	}
 
 	tx.active = false
+	tx.stop()
 	return nil
 }
 
@@ -328,4 +879,65 @@ func (tx *Transaction) Rollback(ctx context.Context) error {
 var (
 	// ErrTransactionNotActive indicates the transaction is no longer active
 	ErrTransactionNotActive = fmt.Errorf("transaction is not active")
+	// ErrUnsupportedByTransport indicates the selected transport cannot carry the operation
+	ErrUnsupportedByTransport = fmt.Errorf("operation not supported by transport")
+	// ErrInvalidEnumValue indicates a document field holds a value outside its registered enum
+	ErrInvalidEnumValue = fmt.Errorf("invalid enum value")
+	// ErrSchemaViolation indicates a document does not match the schema registered for its collection
+	ErrSchemaViolation = fmt.Errorf("schema violation")
+	// ErrInvalidInput indicates an argument was rejected client-side before issuing a request
+	ErrInvalidInput = fmt.Errorf("invalid input")
+	// ErrUnknownPlugin indicates no plugin was registered under the requested name
+	ErrUnknownPlugin = fmt.Errorf("unknown plugin")
+	// ErrAlreadyExists indicates Create found an entity with the same UUID
+	ErrAlreadyExists = fmt.Errorf("entity already exists")
+	// ErrSagaAborted indicates a saga step failed and the saga did not complete
+	ErrSagaAborted = fmt.Errorf("saga aborted")
+	// ErrLeaseHeld indicates a lease is owned by another holder
+	ErrLeaseHeld = fmt.Errorf("lease held by another holder")
+	// ErrLeaseLost indicates a lease expired and was taken over by another holder
+	ErrLeaseLost = fmt.Errorf("lease lost")
+	// ErrConflict indicates a conditional write lost against a concurrent writer
+	ErrConflict = fmt.Errorf("revision conflict")
+	// ErrNotFound indicates the server has no such entity or resource (404)
+	ErrNotFound = fmt.Errorf("not found")
+	// ErrUnauthenticated indicates the request lacked valid credentials (401)
+	ErrUnauthenticated = fmt.Errorf("unauthenticated")
+	// ErrPermissionDenied indicates the credentials do not grant the operation (403)
+	ErrPermissionDenied = fmt.Errorf("permission denied")
+	// ErrRateLimited indicates the server throttled the request (429)
+	ErrRateLimited = fmt.Errorf("rate limited")
+	// ErrUnavailable indicates the server is temporarily unavailable (503)
+	ErrUnavailable = fmt.Errorf("server unavailable")
+	// ErrTimeout indicates a blocking operation exceeded the time bound of its Within variant
+	ErrTimeout = fmt.Errorf("timed out")
+	// ErrVersionMismatch indicates cluster nodes run different server versions
+	ErrVersionMismatch = fmt.Errorf("server version mismatch")
+	// ErrTransactionNodeLost indicates the node serving a transaction failed mid-transaction
+	ErrTransactionNodeLost = fmt.Errorf("transaction node lost")
+	// ErrTransactionPrepared indicates an operation on a transaction that was already prepared
+	ErrTransactionPrepared = fmt.Errorf("transaction is prepared")
+	// ErrTransactionAborted indicates a Coordinator rolled back a distributed transaction
+	ErrTransactionAborted = fmt.Errorf("distributed transaction aborted")
+	// ErrCommitIncomplete indicates a distributed transaction was decided to commit but not
+	// all participants committed yet; Coordinator.Recover finishes it
+	ErrCommitIncomplete = fmt.Errorf("distributed transaction commit incomplete")
+	// ErrMemoryLimit indicates a client-side operation exceeded its configured memory bound
+	ErrMemoryLimit = fmt.Errorf("memory limit exceeded")
+	// ErrNotPrepared indicates ExecutePrepared was called with a name not passed to Prepare
+	ErrNotPrepared = fmt.Errorf("query not prepared")
+	// ErrInsufficientFunds indicates a Transfer would overdraw the source balance
+	ErrInsufficientFunds = fmt.Errorf("insufficient funds")
+	// ErrWrongExpectedVersion indicates AppendEvent found the stream at another version
+	ErrWrongExpectedVersion = fmt.Errorf("wrong expected stream version")
+	// ErrWriterClosed indicates a write was buffered on a Writer after Close
+	ErrWriterClosed = fmt.Errorf("writer closed")
+	// ErrTransactionExpired indicates the server expired a transaction before it was
+	// committed, e.g. because it ran longer than its timeout
+	ErrTransactionExpired = fmt.Errorf("transaction expired")
+	// ErrReadOnly indicates a write was attempted through a client pinned to a snapshot
+	ErrReadOnly = fmt.Errorf("client is read-only")
+	// ErrFeatureUnsupported indicates the server does not support an optional feature,
+	// as found by Config.Negotiate
+	ErrFeatureUnsupported = fmt.Errorf("feature not supported by server")
 )
@@ -2,6 +2,12 @@ package themisdb
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -85,6 +91,9 @@ func TestTransaction_InactiveState(t *testing.T) {
 	err = tx.Query(ctx, "SELECT * FROM users", nil)
 	assert.ErrorIs(t, err, ErrTransactionNotActive)
 
+	_, err = tx.GetMany(ctx, "relational", "users", []string{"123"}, nil)
+	assert.ErrorIs(t, err, ErrTransactionNotActive)
+
 	err = tx.Commit(ctx)
 	assert.ErrorIs(t, err, ErrTransactionNotActive)
 
@@ -92,6 +101,74 @@ func TestTransaction_InactiveState(t *testing.T) {
 	assert.ErrorIs(t, err, ErrTransactionNotActive)
 }
 
+func TestTransaction_ConcurrentWrites(t *testing.T) {
+	var mu sync.Mutex
+	var inFlight, maxInFlight int
+	var seqs []string
+	var events []string
+	release := make(chan struct{})
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/transaction/begin":
+			w.Write([]byte(`{"transaction_id":"tx-1"}`))
+			return
+		case "/transaction/commit":
+			mu.Lock()
+			events = append(events, "commit")
+			mu.Unlock()
+			return
+		}
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		seqs = append(seqs, r.Header.Get("X-Transaction-Seq"))
+		mu.Unlock()
+		if strings.HasSuffix(r.URL.Path, "/slow") {
+			<-release
+		} else {
+			time.Sleep(time.Millisecond)
+		}
+		mu.Lock()
+		inFlight--
+		events = append(events, r.URL.Path)
+		mu.Unlock()
+	})
+	ctx := context.Background()
+	tx, err := client.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, tx.Put(ctx, "relational", "users", strconv.Itoa(i), map[string]int{"n": i}))
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 1, maxInFlight, "writes are sent one at a time")
+	want := make([]string, 20)
+	for i := range want {
+		want[i] = strconv.Itoa(i + 1)
+	}
+	assert.Equal(t, want, seqs)
+
+	// Commit waits for the write in flight
+	go tx.Delete(ctx, "relational", "users", "slow")
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return inFlight == 1
+	}, time.Second, time.Millisecond)
+	committed := make(chan error)
+	go func() { committed <- tx.Commit(ctx) }()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	require.NoError(t, <-committed)
+	assert.Equal(t, []string{"/api/relational/users/slow", "commit"}, events[len(events)-2:])
+	assert.ErrorIs(t, tx.Put(ctx, "relational", "users", "late", nil), ErrTransactionNotActive)
+}
+
 func TestIsolationLevel(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -118,6 +195,54 @@ func TestTransactionOptions_Defaults(t *testing.T) {
 	assert.Equal(t, 30*time.Second, opts.Timeout)
 }
 
+func TestClient_QueryWithOptions(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "FOR u IN users SORT u.name RETURN u.name", body["query"])
+		assert.Equal(t, map[string]interface{}{"locale": "sv", "numeric_ordering": true}, body["collation"])
+		assert.Equal(t, "tx-1", r.Header.Get("X-Transaction-Id"))
+		w.Write([]byte(`{"data":["Ärla","Örjan"]}`))
+	})
+
+	tx := &Transaction{client: client, transactionID: "tx-1", active: true}
+	var names []string
+	err := tx.QueryWithOptions(context.Background(), "FOR u IN users SORT u.name RETURN u.name", &QueryOptions{
+		Collation: &Collation{Locale: "sv", NumericOrdering: true},
+	}, &names)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Ärla", "Örjan"}, names)
+}
+
+func TestClient_GetMany(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/api/relational/users/_mget", r.URL.Path)
+		var body struct {
+			UUIDs []string `json:"uuids"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, []string{"1", "2", "3"}, body.UUIDs)
+		w.Write([]byte(`{"documents":{"1":{"name":"Alice"},"3":{"name":"Carol"}},"missing":["2"]}`))
+	})
+
+	type user struct {
+		Name string `json:"name"`
+	}
+	var users map[string]user
+	missing, err := client.GetMany(context.Background(), "relational", "users", []string{"1", "2", "3"}, &users)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2"}, missing)
+	assert.Equal(t, map[string]user{"1": {Name: "Alice"}, "3": {Name: "Carol"}}, users)
+}
+
+// newTestClient returns a client talking to an httptest server backed by handler
+func newTestClient(t testing.TB, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClient(Config{Endpoints: []string{server.URL}})
+}
+
 // Integration tests - these require a running ThemisDB server
 // Run with: go test -tags=integration
 
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+// adminUsage lists the subcommands of admin
+const adminUsage = `subcommands:
+  info                 print the server version, features, and limits
+  members              list the cluster members
+  models               list the data models
+  collections [model]  list the collections, of one model or of all
+  node <id>            print the state of a cluster node`
+
+// runAdmin runs the admin command, which prints server and cluster state:
+//
+//	themis admin [flags] <subcommand> [arguments]
+func runAdmin(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("admin", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	format := fs.String("format", "", "output format: json (default), jsonl, csv, table, or template")
+	tmpl := fs.String("template", "", "Go template applied to every result, e.g. '{{.id}}'")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "usage: themis admin [flags] <subcommand> [arguments]\n\n"+adminUsage+"\n\nflags:")
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		fs.Usage()
+		return fmt.Errorf("no subcommand given")
+	}
+	p, err := newPrinter(*format, *tmpl)
+	if err != nil {
+		return err
+	}
+
+	admin := e.client.Admin()
+	sub, rest := positional[0], positional[1:]
+	arity := map[string][2]int{
+		"info": {0, 0}, "members": {0, 0}, "models": {0, 0},
+		"collections": {0, 1}, "node": {1, 1},
+	}
+	bounds, ok := arity[sub]
+	if !ok {
+		return fmt.Errorf("unknown admin subcommand %q\n\n%s", sub, adminUsage)
+	}
+	if len(rest) < bounds[0] || len(rest) > bounds[1] {
+		return fmt.Errorf("wrong number of arguments for admin %s\n\n%s", sub, adminUsage)
+	}
+
+	var result interface{}
+	switch sub {
+	case "info":
+		result, err = e.client.ServerInfo(ctx)
+	case "members":
+		result, err = admin.ClusterMembers(ctx)
+	case "models":
+		result, err = e.client.Models(ctx)
+	case "collections":
+		model := ""
+		if len(rest) == 1 {
+			model = rest[0]
+		}
+		result, err = admin.ListCollections(ctx, model)
+	case "node":
+		var status *themisdb.NodeStatus
+		if status, err = admin.NodeStatus(ctx, rest[0]); err == nil {
+			result = struct {
+				*themisdb.NodeStatus
+				ReplicationLag string `json:"replication_lag"`
+			}{status, status.ReplicationLag.String()}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return printResult(e, p, result)
+}
+
+// printResult prints a slice as rows and anything else as a single document
+func printResult(e *env, p *printer, result interface{}) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	var rows []json.RawMessage
+	if json.Unmarshal(data, &rows) != nil {
+		return p.printDocument(e.stdout, data)
+	}
+	return p.print(e.stdout, rows)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_AdminMembers(t *testing.T) {
+	endpoint, requests := recordingServer(t, http.StatusOK, `{"members": [
+		{"id": "n1", "endpoint": "http://db1:8080", "role": "leader"},
+		{"id": "n2", "endpoint": "http://db2:8080", "role": "follower"}
+	]}`)
+
+	out, err := runArgs(t, "", "-endpoint", endpoint, "admin", "members", "-format", "table")
+	require.NoError(t, err)
+	assert.Equal(t, "id  endpoint         role\n"+
+		"n1  http://db1:8080  leader\n"+
+		"n2  http://db2:8080  follower\n", out)
+
+	out, err = runArgs(t, "", "-endpoint", endpoint, "admin", "-template", "{{.id}}", "members")
+	require.NoError(t, err)
+	assert.Equal(t, "n1\nn2\n", out)
+	assert.Equal(t, []string{"GET /cluster/members ", "GET /cluster/members "}, *requests)
+}
+
+func TestRun_AdminNode(t *testing.T) {
+	endpoint, requests := recordingServer(t, http.StatusOK, `{"id": "n2", "role": "follower", "version": "1.4.0", "replication_lag_ms": 1500}`)
+
+	out, err := runArgs(t, "", "-endpoint", endpoint, "admin", "node", "n2", "-format", "jsonl")
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":"n2","role":"follower","version":"1.4.0","draining":false,"active_requests":0,"open_transactions":0,"applied_sequence":0,"leader_sequence":0,"replication_lag":"1.5s"}`+"\n", out)
+	assert.Equal(t, []string{"GET /admin/nodes/n2/status "}, *requests)
+}
+
+func TestRun_AdminInvalid(t *testing.T) {
+	endpoint, requests := recordingServer(t, http.StatusOK, `{}`)
+
+	for _, args := range [][]string{{}, {"reboot"}, {"node"}, {"members", "extra"}, {"collections", "a", "b"}} {
+		_, err := runArgs(t, "", append([]string{"-endpoint", endpoint, "admin"}, args...)...)
+		assert.Error(t, err, args)
+	}
+	assert.Empty(t, *requests)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+// defaultEndpoint is used if neither flags, the environment, nor the config file set
+// an endpoint
+const defaultEndpoint = "http://localhost:8080"
+
+// settings are the connection settings of the CLI. They are read from the config file
+// and overridden by the environment and then by flags.
+type settings struct {
+	Endpoints []string `json:"endpoints"`
+	// Token is sent as a bearer token, APIKey in the X-Api-Key header
+	Token     string `json:"token"`
+	APIKey    string `json:"api_key"`
+	Namespace string `json:"namespace"`
+	// Timeout bounds every request, e.g. "30s"
+	Timeout string `json:"timeout"`
+}
+
+// defaultConfigPath returns $THEMIS_CONFIG, or config.json in the themis directory of
+// the user's configuration directory
+func defaultConfigPath() string {
+	if path := os.Getenv("THEMIS_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "themis", "config.json")
+}
+
+// loadSettings reads the config file at path. A missing file is an error only if
+// required is set, i.e. the path was given explicitly.
+func loadSettings(path string, required bool) (settings, error) {
+	var s settings
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// applyEnv overrides s by the THEMIS_ENDPOINT (comma-separated), THEMIS_TOKEN,
+// THEMIS_API_KEY, THEMIS_NAMESPACE, and THEMIS_TIMEOUT environment variables
+func (s *settings) applyEnv() {
+	if value := os.Getenv("THEMIS_ENDPOINT"); value != "" {
+		s.Endpoints = splitList(value)
+	}
+	for key, field := range map[string]*string{
+		"THEMIS_TOKEN":     &s.Token,
+		"THEMIS_API_KEY":   &s.APIKey,
+		"THEMIS_NAMESPACE": &s.Namespace,
+		"THEMIS_TIMEOUT":   &s.Timeout,
+	} {
+		if value := os.Getenv(key); value != "" {
+			*field = value
+		}
+	}
+}
+
+// config returns the client configuration of s
+func (s settings) config() (themisdb.Config, error) {
+	config := themisdb.Config{Endpoints: s.Endpoints, Namespace: s.Namespace}
+	if len(config.Endpoints) == 0 {
+		config.Endpoints = []string{defaultEndpoint}
+	}
+	if s.Timeout != "" {
+		timeout, err := time.ParseDuration(s.Timeout)
+		if err != nil || timeout <= 0 {
+			return config, fmt.Errorf("invalid timeout %q", s.Timeout)
+		}
+		config.Timeout = timeout
+	}
+
+	headers := map[string]string{}
+	if s.Token != "" {
+		headers["Authorization"] = "Bearer " + s.Token
+	}
+	if s.APIKey != "" {
+		headers["X-Api-Key"] = s.APIKey
+	}
+	if len(headers) > 0 {
+		config.Interceptors = []themisdb.Interceptor{authInterceptor(headers)}
+	}
+	return config, nil
+}
+
+// authInterceptor adds the authentication headers to every request
+func authInterceptor(auth map[string]string) themisdb.Interceptor {
+	return func(ctx context.Context, req *themisdb.Request, next themisdb.Handler) (*themisdb.Response, error) {
+		headers := make(map[string]string, len(req.Header)+len(auth))
+		for key, value := range auth {
+			headers[key] = value
+		}
+		for key, value := range req.Header {
+			headers[key] = value
+		}
+		req.Header = headers
+		return next(ctx, req)
+	}
+}
+
+// splitList splits a comma-separated list, dropping empty elements
+func splitList(s string) []string {
+	var list []string
+	for _, element := range strings.Split(s, ",") {
+		if element = strings.TrimSpace(element); element != "" {
+			list = append(list, element)
+		}
+	}
+	return list
+}
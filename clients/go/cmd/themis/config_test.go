@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSettings(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"endpoints": ["http://db1:8080", "http://db2:8080"], "token": "secret", "timeout": "30s"}`), 0o600))
+
+	s, err := loadSettings(path, true)
+	require.NoError(t, err)
+	assert.Equal(t, settings{Endpoints: []string{"http://db1:8080", "http://db2:8080"}, Token: "secret", Timeout: "30s"}, s)
+	config, err := s.config()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://db1:8080", "http://db2:8080"}, config.Endpoints)
+	assert.Equal(t, 30*time.Second, config.Timeout)
+	assert.Len(t, config.Interceptors, 1)
+
+	s, err = loadSettings(filepath.Join(dir, "missing.json"), false)
+	require.NoError(t, err)
+	config, err = s.config()
+	require.NoError(t, err)
+	assert.Equal(t, []string{defaultEndpoint}, config.Endpoints)
+	assert.Empty(t, config.Interceptors)
+
+	_, err = loadSettings(filepath.Join(dir, "missing.json"), true)
+	assert.Error(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(`{"endpoints": "http://db1:8080"}`), 0o600))
+	_, err = loadSettings(path, false)
+	assert.Error(t, err)
+	_, err = settings{Timeout: "soon"}.config()
+	assert.EqualError(t, err, `invalid timeout "soon"`)
+}
+
+func TestRun_Settings(t *testing.T) {
+	var headers []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Clone())
+		w.Write([]byte(`{"total": 10}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"endpoints": ["`+server.URL+`"], "token": "from-file", "api_key": "key", "namespace": "tenant-a"}`), 0o600))
+	t.Setenv("THEMIS_CONFIG", path)
+	t.Setenv("THEMIS_ENDPOINT", "")
+	t.Setenv("THEMIS_TOKEN", "from-env")
+	t.Setenv("THEMIS_API_KEY", "")
+	t.Setenv("THEMIS_NAMESPACE", "")
+	t.Setenv("THEMIS_TIMEOUT", "")
+
+	_, err := runArgs(t, "", "get", "relational/orders", "o-42")
+	require.NoError(t, err)
+	_, err = runArgs(t, "", "-token", "from-flag", "-namespace", "tenant-b", "get", "relational/orders", "o-42")
+	require.NoError(t, err)
+	require.Len(t, headers, 2)
+	assert.Equal(t, "Bearer from-env", headers[0].Get("Authorization"))
+	assert.Equal(t, "key", headers[0].Get("X-Api-Key"))
+	assert.Equal(t, "Bearer from-flag", headers[1].Get("Authorization"))
+	assert.Equal(t, "tenant-a", headers[0].Get("X-Themis-Namespace"))
+	assert.Equal(t, "tenant-b", headers[1].Get("X-Themis-Namespace"))
+
+	_, err = runArgs(t, "", "-config", filepath.Join(t.TempDir(), "missing.json"), "get", "relational/orders", "o-42")
+	assert.Error(t, err)
+	assert.Len(t, headers, 2)
+}
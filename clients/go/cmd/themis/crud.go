@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// runGet runs the get command, which prints a document:
+//
+//	themis get [flags] <model>/<collection> <uuid>
+func runGet(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	format := fs.String("format", "", "output format: json (default), jsonl, csv, table, or template")
+	tmpl := fs.String("template", "", "Go template applied to the document, e.g. '{{.name}}'")
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return fmt.Errorf("expected <model>/<collection> and <uuid> arguments")
+	}
+	model, collection, err := parseCollection(positional[0])
+	if err != nil {
+		return err
+	}
+	p, err := newPrinter(*format, *tmpl)
+	if err != nil {
+		return err
+	}
+
+	doc, err := e.client.GetRaw(ctx, model, collection, positional[1])
+	if err != nil {
+		return err
+	}
+	return p.printDocument(e.stdout, doc)
+}
+
+// runPut runs the put command, which writes a document given as an argument or read
+// from a file:
+//
+//	themis put [flags] <model>/<collection> <uuid> [json]
+func runPut(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("put", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	file := fs.String("file", "", "read the document from this file (- for stdin)")
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 2 || len(positional) > 3 {
+		return fmt.Errorf("expected <model>/<collection>, <uuid>, and optionally <json> arguments")
+	}
+	model, collection, err := parseCollection(positional[0])
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	switch {
+	case len(positional) == 3 && *file != "":
+		return fmt.Errorf("the document cannot be given both as an argument and with -file")
+	case len(positional) == 3:
+		data = []byte(positional[2])
+	case *file != "":
+		if data, err = readFile(*file, e.stdin); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("no document given; pass it as an argument or with -file")
+	}
+	if !json.Valid(data) {
+		return fmt.Errorf("the document is not valid JSON")
+	}
+	return e.client.Put(ctx, model, collection, positional[1], json.RawMessage(data))
+}
+
+// runDelete runs the delete command, which deletes documents in the order given:
+//
+//	themis delete <model>/<collection> <uuid>...
+func runDelete(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("delete", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 2 {
+		return fmt.Errorf("expected <model>/<collection> and at least one <uuid> argument")
+	}
+	model, collection, err := parseCollection(positional[0])
+	if err != nil {
+		return err
+	}
+	for _, uuid := range positional[1:] {
+		if err := e.client.Delete(ctx, model, collection, uuid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseCollection splits a <model>/<collection> argument
+func parseCollection(arg string) (string, string, error) {
+	model, collection, ok := strings.Cut(arg, "/")
+	if !ok || model == "" || collection == "" || strings.Contains(collection, "/") {
+		return "", "", fmt.Errorf("%q is not <model>/<collection>", arg)
+	}
+	return model, collection, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingServer answers every request with status and body and records the
+// requests as "METHOD path body"
+func recordingServer(t *testing.T, status int, body string) (string, *[]string) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.RequestURI()+" "+string(data))
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, &requests
+}
+
+func TestRun_Get(t *testing.T) {
+	endpoint, requests := recordingServer(t, http.StatusOK, `{"status": "open", "total": 10}`)
+
+	out, err := runArgs(t, "", "-endpoint", endpoint, "get", "relational/orders", "o-42")
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"status\": \"open\",\n  \"total\": 10\n}\n", out)
+	assert.Equal(t, []string{"GET /api/relational/orders/o-42 "}, *requests)
+
+	out, err = runArgs(t, "", "-endpoint", endpoint, "get", "relational/orders", "o-42", "-format", "table")
+	require.NoError(t, err)
+	assert.Equal(t, "status  total\nopen    10\n", out)
+
+	_, err = runArgs(t, "", "-endpoint", endpoint, "get", "orders", "o-42")
+	assert.EqualError(t, err, `"orders" is not <model>/<collection>`)
+}
+
+func TestRun_GetNotFound(t *testing.T) {
+	endpoint, _ := recordingServer(t, http.StatusNotFound, `{"error": "not found"}`)
+
+	out, err := runArgs(t, "", "-endpoint", endpoint, "get", "relational/orders", "o-42")
+	assert.Error(t, err)
+	assert.Empty(t, out)
+}
+
+func TestRun_Put(t *testing.T) {
+	endpoint, requests := recordingServer(t, http.StatusOK, `{}`)
+
+	_, err := runArgs(t, "", "-endpoint", endpoint, "put", "relational/orders", "o-42", `{"total": 10}`)
+	require.NoError(t, err)
+	_, err = runArgs(t, `{"total": 11}`, "-endpoint", endpoint, "put", "relational/orders", "o-43", "-file", "-")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "doc.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"total": 12}`), 0o600))
+	_, err = runArgs(t, "", "-endpoint", endpoint, "put", "-file", path, "relational/orders", "o-44")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`PUT /api/relational/orders/o-42 {"total":10}`,
+		`PUT /api/relational/orders/o-43 {"total":11}`,
+		`PUT /api/relational/orders/o-44 {"total":12}`,
+	}, *requests)
+
+	for _, args := range [][]string{
+		{"relational/orders", "o-42"},
+		{"relational/orders", "o-42", `{"total":`},
+		{"relational/orders", "o-42", `{}`, "-file", path},
+	} {
+		_, err := runArgs(t, "", append([]string{"-endpoint", endpoint, "put"}, args...)...)
+		assert.Error(t, err, args)
+	}
+	assert.Len(t, *requests, 3)
+}
+
+func TestRun_Delete(t *testing.T) {
+	endpoint, requests := recordingServer(t, http.StatusOK, `{}`)
+
+	_, err := runArgs(t, "", "-endpoint", endpoint, "delete", "relational/orders", "o-1", "o-2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"DELETE /api/relational/orders/o-1 ",
+		"DELETE /api/relational/orders/o-2 ",
+	}, *requests)
+
+	_, err = runArgs(t, "", "-endpoint", endpoint, "delete", "relational/orders")
+	assert.Error(t, err)
+}
@@ -0,0 +1,164 @@
+// Command themis is a command line client for ThemisDB, meant for interactive use
+// as well as for shell automation:
+//
+//	themis get relational/orders o-42 -format table
+//	themis put relational/orders o-42 '{"status": "open", "total": 10}'
+//	themis query 'FOR o IN orders FILTER o.total > @min RETURN o' -var min=100 -format jsonl
+//	themis query -query-file open.aql -format csv > open.csv
+//	themis query -q 'FOR o IN orders FILTER o.status == "failed" RETURN 1' && alert
+//	themis export relational/orders > orders.ndjson
+//	themis import relational/orders -file orders.ndjson
+//	themis import relational/users -file legacy.ndjson -transform '@uuid = .id; email = lower(.email); del id'
+//	themis tx -isolation snapshot
+//	themis watch relational/orders -filter status=failed
+//	themis admin members -format table
+//
+// The connection settings are read from a JSON config file (-config, $THEMIS_CONFIG,
+// or themis/config.json in the user's configuration directory):
+//
+//	{"endpoints": ["https://db1:8080", "https://db2:8080"], "token": "...", "namespace": "tenant-a", "timeout": "30s"}
+//
+// THEMIS_ENDPOINT (comma-separated), THEMIS_TOKEN, THEMIS_API_KEY, THEMIS_NAMESPACE,
+// and THEMIS_TIMEOUT override the file, and the global flags override both. The exit
+// status is 0 on success, 1 if a quiet query found no results, and 2 on errors.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+// errNoResults is returned by a quiet query without results
+var errNoResults = errors.New("no results")
+
+// env is the environment a command runs in
+type env struct {
+	client *themisdb.Client
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// command runs a subcommand with its arguments
+type command struct {
+	run     func(ctx context.Context, e *env, args []string) error
+	summary string
+}
+
+// commands are the subcommands by name
+var commands = map[string]command{
+	"get":    {runGet, "print a document"},
+	"put":    {runPut, "write a document"},
+	"delete": {runDelete, "delete documents"},
+	"query":  {runQuery, "run an AQL query and print its results"},
+	"tx":     {runTx, "run statements interactively in a transaction"},
+	"import": {runImport, "load documents from NDJSON into a collection"},
+	"export": {runExport, "write the documents of a collection as NDJSON"},
+	"watch":  {runWatch, "print the changes of a collection as they happen"},
+	"admin":  {runAdmin, "inspect the server and the cluster"},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	switch {
+	case err == nil:
+	case errors.Is(err, errNoResults):
+		os.Exit(1)
+	default:
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "themis:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+// run parses the global flags and runs the subcommand named by the first argument
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("themis", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", defaultConfigPath(), "JSON config file with the connection settings")
+	endpoint := fs.String("endpoint", "", "comma-separated ThemisDB endpoints (default: from the config, or "+defaultEndpoint+")")
+	token := fs.String("token", "", "bearer token sent with every request")
+	namespace := fs.String("namespace", "", "tenant namespace of every request")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: themis [flags] <command> [command flags]\n\ncommands:")
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(stderr, "  %-8s %s\n", name, commands[name].summary)
+		}
+		fmt.Fprintln(stderr, "\nflags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		return fmt.Errorf("unknown command %q", fs.Arg(0))
+	}
+
+	explicit := false
+	fs.Visit(func(f *flag.Flag) { explicit = explicit || f.Name == "config" })
+	s, err := loadSettings(*configPath, explicit)
+	if err != nil {
+		return err
+	}
+	s.applyEnv()
+	if *endpoint != "" {
+		s.Endpoints = splitList(*endpoint)
+	}
+	if *token != "" {
+		s.Token = *token
+	}
+	if *namespace != "" {
+		s.Namespace = *namespace
+	}
+	config, err := s.config()
+	if err != nil {
+		return err
+	}
+
+	client := themisdb.NewClient(config)
+	defer client.Close()
+	return cmd.run(ctx, &env{client: client, stdin: stdin, stdout: stdout, stderr: stderr}, fs.Args()[1:])
+}
+
+// varFlags collects repeated name=value flags
+type varFlags map[string]string
+
+func (v varFlags) String() string {
+	pairs := make([]string, 0, len(v))
+	for name, value := range v {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (v varFlags) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("%q is not name=value", s)
+	}
+	v[name] = value
+	return nil
+}
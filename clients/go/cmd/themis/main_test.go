@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// queryServer answers queries with data and records the request bodies
+func queryServer(t *testing.T, data string) (string, *[]map[string]interface{}) {
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		w.Write([]byte(`{"data": ` + data + `}`))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, &bodies
+}
+
+func runArgs(t *testing.T, stdin string, args ...string) (string, error) {
+	var stdout bytes.Buffer
+	err := run(context.Background(), args, strings.NewReader(stdin), &stdout, &bytes.Buffer{})
+	return stdout.String(), err
+}
+
+func TestRun_Query(t *testing.T) {
+	endpoint, bodies := queryServer(t, `[{"id": 1, "name": "Ada"}, {"id": 2, "name": "Grace"}]`)
+
+	out, err := runArgs(t, "", "-endpoint", endpoint, "query", "FOR u IN users RETURN u")
+	require.NoError(t, err)
+	assert.Equal(t, "[\n  {\n    \"id\": 1,\n    \"name\": \"Ada\"\n  },\n  {\n    \"id\": 2,\n    \"name\": \"Grace\"\n  }\n]\n", out)
+
+	out, err = runArgs(t, "", "-endpoint", endpoint, "query", "-format", "jsonl", "FOR u IN users RETURN u")
+	require.NoError(t, err)
+	assert.Equal(t, "{\"id\":1,\"name\":\"Ada\"}\n{\"id\":2,\"name\":\"Grace\"}\n", out)
+
+	out, err = runArgs(t, "", "-endpoint", endpoint, "query", "FOR u IN users RETURN u", "-template", "{{.id}}: {{.name}}")
+	require.NoError(t, err)
+	assert.Equal(t, "1: Ada\n2: Grace\n", out)
+
+	out, err = runArgs(t, "", "-endpoint", endpoint, "query", "-q", "FOR u IN users RETURN u")
+	require.NoError(t, err)
+	assert.Empty(t, out)
+	assert.Len(t, *bodies, 4)
+}
+
+func TestRun_QueryQuietEmpty(t *testing.T) {
+	endpoint, _ := queryServer(t, `[]`)
+	out, err := runArgs(t, "", "-endpoint", endpoint, "query", "-q", "FOR u IN users RETURN u")
+	assert.ErrorIs(t, err, errNoResults)
+	assert.Empty(t, out)
+
+	out, err = runArgs(t, "", "-endpoint", endpoint, "query", "FOR u IN users RETURN u")
+	require.NoError(t, err)
+	assert.Equal(t, "[]\n", out)
+}
+
+func TestRun_QueryBindVars(t *testing.T) {
+	endpoint, bodies := queryServer(t, `[]`)
+	dir := t.TempDir()
+	queryFile := filepath.Join(dir, "q.aql")
+	require.NoError(t, os.WriteFile(queryFile, []byte("FOR u IN users\nFILTER u.age > @min AND u.id IN @ids\nRETURN u\n"), 0o644))
+	idsFile := filepath.Join(dir, "ids.json")
+	require.NoError(t, os.WriteFile(idsFile, []byte("[1, 2]\n"), 0o644))
+
+	_, err := runArgs(t, "", "-endpoint", endpoint, "query", "-query-file", queryFile,
+		"-var", "min=30", "-var", "name=Ada", "-var-file", "ids="+idsFile)
+	require.NoError(t, err)
+	require.Len(t, *bodies, 1)
+	assert.Equal(t, "FOR u IN users\nFILTER u.age > @min AND u.id IN @ids\nRETURN u", (*bodies)[0]["query"])
+	assert.Equal(t, map[string]interface{}{"min": 30.0, "name": "Ada", "ids": []interface{}{1.0, 2.0}}, (*bodies)[0]["bind_vars"])
+
+	_, err = runArgs(t, "RETURN @x", "-endpoint", endpoint, "query", "-query-file", "-", "-var", "x=[1")
+	require.NoError(t, err)
+	assert.Equal(t, "RETURN @x", (*bodies)[1]["query"])
+	assert.Equal(t, map[string]interface{}{"x": "[1"}, (*bodies)[1]["bind_vars"])
+}
+
+func TestRun_Invalid(t *testing.T) {
+	endpoint, bodies := queryServer(t, `[]`)
+	for _, args := range [][]string{
+		{"query"},
+		{"query", "RETURN 1", "RETURN 2"},
+		{"query", "-query-file", "q.aql", "RETURN 1"},
+		{"query", "-format", "xml", "RETURN 1"},
+		{"query", "-format", "csv", "-template", "{{.}}", "RETURN 1"},
+		{"query", "-template", "{{.", "RETURN 1"},
+		{"query", "-var", "novalue", "RETURN 1"},
+		{"drop"},
+	} {
+		_, err := runArgs(t, "", append([]string{"-endpoint", endpoint}, args...)...)
+		assert.Error(t, err, "%v", args)
+	}
+	assert.Empty(t, *bodies)
+
+	_, err := runArgs(t, "")
+	assert.ErrorIs(t, err, flag.ErrHelp)
+}
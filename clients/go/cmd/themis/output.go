@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+)
+
+// Output formats of query results
+const (
+	formatJSON     = "json"
+	formatJSONL    = "jsonl"
+	formatCSV      = "csv"
+	formatTable    = "table"
+	formatTemplate = "template"
+)
+
+// printer writes rows in an output format
+type printer struct {
+	format string
+	tmpl   *template.Template
+}
+
+// newPrinter returns a printer for format; a template sets the format to template
+func newPrinter(format, tmpl string) (*printer, error) {
+	if tmpl != "" {
+		if format != "" && format != formatTemplate {
+			return nil, fmt.Errorf("-template cannot be combined with -format %s", format)
+		}
+		if !strings.HasSuffix(tmpl, "\n") {
+			tmpl += "\n"
+		}
+		t, err := template.New("row").Funcs(template.FuncMap{"json": toJSON}).Parse(tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %w", err)
+		}
+		return &printer{format: formatTemplate, tmpl: t}, nil
+	}
+	switch format {
+	case "":
+		return &printer{format: formatJSON}, nil
+	case formatJSON, formatJSONL, formatCSV, formatTable:
+		return &printer{format: format}, nil
+	case formatTemplate:
+		return nil, fmt.Errorf("-format template requires -template")
+	}
+	return nil, fmt.Errorf("unknown format %q, must be json, jsonl, csv, table, or template", format)
+}
+
+// print writes rows to w
+func (p *printer) print(w io.Writer, rows []json.RawMessage) error {
+	switch p.format {
+	case formatJSONL:
+		for _, row := range rows {
+			var b bytes.Buffer
+			if err := json.Compact(&b, row); err != nil {
+				return err
+			}
+			b.WriteByte('\n')
+			if _, err := w.Write(b.Bytes()); err != nil {
+				return err
+			}
+		}
+		return nil
+	case formatCSV:
+		return writeCSV(w, rows)
+	case formatTable:
+		return writeTable(w, rows)
+	case formatTemplate:
+		for _, row := range rows {
+			value, err := decodeRow(row)
+			if err != nil {
+				return err
+			}
+			if err := p.tmpl.Execute(w, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if rows == nil {
+		rows = []json.RawMessage{}
+	}
+	data, err := json.MarshalIndent(rows, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// printDocument writes a single document; the JSON format prints it without the
+// enclosing array
+func (p *printer) printDocument(w io.Writer, doc json.RawMessage) error {
+	if p.format != formatJSON {
+		return p.print(w, []json.RawMessage{doc})
+	}
+	var b bytes.Buffer
+	if err := json.Indent(&b, doc, "", "  "); err != nil {
+		return err
+	}
+	b.WriteByte('\n')
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// writeCSV writes rows as CSV with a header line (see tableColumns)
+func writeCSV(w io.Writer, rows []json.RawMessage) error {
+	columns, records := tableColumns(rows)
+	cw := csv.NewWriter(w)
+	if len(columns) > 0 {
+		cw.Write(columns)
+	}
+	for _, record := range records {
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// cellReplacer keeps the values of a table cell on one line and in one column
+var cellReplacer = strings.NewReplacer("\t", " ", "\n", " ")
+
+// writeTable writes rows as a table with aligned columns and a header line (see
+// tableColumns)
+func writeTable(w io.Writer, rows []json.RawMessage) error {
+	columns, records := tableColumns(rows)
+	if len(columns) == 0 {
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(columns, "\t"))
+	for _, record := range records {
+		for i, value := range record {
+			record[i] = cellReplacer.Replace(value)
+		}
+		fmt.Fprintln(tw, strings.Join(record, "\t"))
+	}
+	return tw.Flush()
+}
+
+// tableColumns returns the columns of rows and the values of every row in them. The
+// columns are the fields of the objects in order of first appearance; rows that are
+// not objects have a single column named value.
+func tableColumns(rows []json.RawMessage) ([]string, [][]string) {
+	var columns []string
+	seen := map[string]bool{}
+	records := make([]map[string]json.RawMessage, len(rows))
+	for i, row := range rows {
+		keys, fields, ok := objectFields(row)
+		if !ok {
+			keys, fields = []string{"value"}, map[string]json.RawMessage{"value": row}
+		}
+		for _, key := range keys {
+			if !seen[key] {
+				seen[key] = true
+				columns = append(columns, key)
+			}
+		}
+		records[i] = fields
+	}
+
+	values := make([][]string, len(records))
+	for i, fields := range records {
+		values[i] = make([]string, len(columns))
+		for j, column := range columns {
+			values[i][j] = csvValue(fields[column])
+		}
+	}
+	return columns, values
+}
+
+// objectFields returns the fields of an object in document order
+func objectFields(row json.RawMessage) ([]string, map[string]json.RawMessage, bool) {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(row, &fields) != nil || fields == nil {
+		return nil, nil, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(row))
+	dec.Token()
+	keys := make([]string, 0, len(fields))
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			break
+		}
+		keys = append(keys, key.(string))
+		var skip json.RawMessage
+		if dec.Decode(&skip) != nil {
+			break
+		}
+	}
+	return keys, fields, true
+}
+
+// csvValue formats a field for CSV and tables: strings unquoted, null and missing fields empty,
+// and arrays and objects as JSON
+func csvValue(value json.RawMessage) string {
+	if len(value) == 0 || string(value) == "null" {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(value, &s) == nil {
+		return s
+	}
+	var b bytes.Buffer
+	if json.Compact(&b, value) != nil {
+		return string(value)
+	}
+	return b.String()
+}
+
+// decodeRow decodes a row for a template, keeping numbers exact
+func decodeRow(row json.RawMessage) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(row))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// toJSON is the json template function
+func toJSON(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	return string(data), err
+}
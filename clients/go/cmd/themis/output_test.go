@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rawRows(rows ...string) []json.RawMessage {
+	raw := make([]json.RawMessage, len(rows))
+	for i, row := range rows {
+		raw[i] = json.RawMessage(row)
+	}
+	return raw
+}
+
+func TestPrinter_CSV(t *testing.T) {
+	p, err := newPrinter("csv", "")
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, p.print(&out, rawRows(
+		`{"name": "Ada", "age": 36, "tags": ["math", "code"]}`,
+		`{"name": "Grace, R.", "email": null, "address": {"city": "NYC"}}`,
+	)))
+	assert.Equal(t, "name,age,tags,email,address\n"+
+		"Ada,36,\"[\"\"math\"\",\"\"code\"\"]\",,\n"+
+		"\"Grace, R.\",,,,\"{\"\"city\"\":\"\"NYC\"\"}\"\n", out.String())
+
+	out.Reset()
+	require.NoError(t, p.print(&out, rawRows(`1`, `"two"`)))
+	assert.Equal(t, "value\n1\ntwo\n", out.String())
+
+	out.Reset()
+	require.NoError(t, p.print(&out, nil))
+	assert.Empty(t, out.String())
+}
+
+func TestPrinter_Template(t *testing.T) {
+	p, err := newPrinter("", "{{.id}} {{json .tags}}\n")
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, p.print(&out, rawRows(`{"id": 12345678901234567890, "tags": ["a"]}`)))
+	assert.Equal(t, "12345678901234567890 [\"a\"]\n", out.String())
+
+	p, err = newPrinter("template", "{{.missing.field}}")
+	require.NoError(t, err)
+	assert.Error(t, p.print(&out, rawRows(`1`)))
+}
+
+func TestPrinter_Document(t *testing.T) {
+	p, err := newPrinter("", "")
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, p.printDocument(&out, json.RawMessage(`{"balance":100}`)))
+	assert.Equal(t, "{\n  \"balance\": 100\n}\n", out.String())
+
+	p, err = newPrinter("jsonl", "")
+	require.NoError(t, err)
+	out.Reset()
+	require.NoError(t, p.printDocument(&out, json.RawMessage(`{"balance": 100}`)))
+	assert.Equal(t, "{\"balance\":100}\n", out.String())
+}
+
+func TestPrinter_Table(t *testing.T) {
+	p, err := newPrinter("table", "")
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, p.print(&out, rawRows(
+		`{"name": "Ada", "note": "two\nlines"}`,
+		`{"name": "Grace Hopper", "age": 85}`,
+	)))
+	assert.Equal(t, "name          note       age\n"+
+		"Ada           two lines  \n"+
+		"Grace Hopper             85\n", out.String())
+
+	out.Reset()
+	require.NoError(t, p.print(&out, nil))
+	assert.Empty(t, out.String())
+}
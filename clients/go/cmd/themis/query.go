@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+// runQuery runs the query command:
+//
+//	themis query [flags] [AQL]
+func runQuery(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	queryFile := fs.String("query-file", "", "read the query from this file (- for stdin)")
+	format := fs.String("format", "", "output format: json (default), jsonl, csv, table, or template")
+	tmpl := fs.String("template", "", "Go template applied to every result, e.g. '{{.name}}'")
+	quiet := fs.Bool("q", false, "print nothing; exit with status 1 if there are no results")
+	vars := varFlags{}
+	fs.Var(vars, "var", "bind variable `name=value`, the value parsed as JSON if valid (repeatable)")
+	varFiles := varFlags{}
+	fs.Var(varFiles, "var-file", "bind variable `name=path` read from a file (repeatable)")
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+
+	aql, err := readQuery(positional, *queryFile, e.stdin)
+	if err != nil {
+		return err
+	}
+	bindVars, err := bindVariables(vars, varFiles)
+	if err != nil {
+		return err
+	}
+	p, err := newPrinter(*format, *tmpl)
+	if err != nil {
+		return err
+	}
+
+	var rows []json.RawMessage
+	if err := e.client.QueryWithOptions(ctx, aql, &themisdb.QueryOptions{BindVars: bindVars}, &rows); err != nil {
+		return err
+	}
+	if *quiet {
+		if len(rows) == 0 {
+			return errNoResults
+		}
+		return nil
+	}
+	return p.print(e.stdout, rows)
+}
+
+// parseInterleaved parses args with flags before, between, and after the positional
+// arguments, and returns the positional arguments
+func parseInterleaved(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// readQuery returns the query given as the only positional argument or in file
+func readQuery(positional []string, file string, stdin io.Reader) (string, error) {
+	switch {
+	case len(positional) > 1:
+		return "", fmt.Errorf("expected one query, got %d arguments; quote the query", len(positional))
+	case len(positional) == 1 && file != "":
+		return "", fmt.Errorf("a query argument cannot be combined with -query-file")
+	case len(positional) == 1:
+		return positional[0], nil
+	case file == "":
+		return "", fmt.Errorf("a query argument or -query-file is required")
+	}
+	data, err := readFile(file, stdin)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readFile reads a file, or stdin if path is -
+func readFile(path string, stdin io.Reader) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// bindVariables returns the bind variables of the -var and -var-file flags
+func bindVariables(vars, files varFlags) (map[string]interface{}, error) {
+	bindVars := make(map[string]interface{}, len(vars)+len(files))
+	for name, value := range vars {
+		bindVars[name] = bindValue(value)
+	}
+	for name, path := range files {
+		if _, dup := bindVars[name]; dup {
+			return nil, fmt.Errorf("bind variable %s is set by -var and -var-file", name)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		bindVars[name] = bindValue(strings.TrimSuffix(string(data), "\n"))
+	}
+	return bindVars, nil
+}
+
+// bindValue parses value as JSON, so that numbers, booleans, arrays, and objects keep
+// their type, or takes it as a string if it is not valid JSON
+func bindValue(value string) interface{} {
+	var parsed interface{}
+	if json.Unmarshal([]byte(value), &parsed) == nil {
+		return json.RawMessage(value)
+	}
+	return value
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+// runExport runs the export command, which writes the documents of a collection as
+// NDJSON, the input of import:
+//
+//	themis export [flags] <model>/<collection>
+func runExport(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	file := fs.String("file", "", "write to this file instead of stdout")
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("expected one <model>/<collection> argument")
+	}
+	model, collection, err := parseCollection(positional[0])
+	if err != nil {
+		return err
+	}
+
+	var n int64
+	if *file == "" || *file == "-" {
+		n, err = e.client.Export(ctx, model, collection, e.stdout)
+	} else {
+		f, createErr := os.Create(*file)
+		if createErr != nil {
+			return createErr
+		}
+		n, err = e.client.Export(ctx, model, collection, f)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stderr, "exported %d documents from %s/%s\n", n, model, collection)
+	return nil
+}
+
+// runImport runs the import command, which loads NDJSON as written by export into a
+// collection, or any NDJSON records reshaped by a transform (see transformHelp):
+//
+//	themis import [flags] <model>/<collection>
+func runImport(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	file := fs.String("file", "-", "read from this file (- for stdin)")
+	batch := fs.Int("batch", 500, "documents written per bulk request")
+	onConflict := fs.String("on-conflict", string(themisdb.ImportOverwrite), "existing documents: overwrite, skip, or fail")
+	transformSrc := fs.String("transform", "", "reshape every record with these mapping statements, e.g. '@uuid = .id; total = number(.total)'")
+	transformFile := fs.String("transform-file", "", "read the mapping statements from this file")
+	dryRun := fs.Bool("dry-run", false, "print the records that would be imported instead of importing them")
+	fs.Usage = func() {
+		fmt.Fprintln(e.stderr, "usage: themis import [flags] <model>/<collection>\n\nflags:")
+		fs.PrintDefaults()
+		fmt.Fprintln(e.stderr, "\n"+transformHelp)
+	}
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("expected one <model>/<collection> argument")
+	}
+	model, collection, err := parseCollection(positional[0])
+	if err != nil {
+		return err
+	}
+	conflict := themisdb.ImportConflict(*onConflict)
+	switch conflict {
+	case themisdb.ImportOverwrite, themisdb.ImportSkip, themisdb.ImportFail:
+	default:
+		return fmt.Errorf("unknown conflict mode %q, must be overwrite, skip, or fail", *onConflict)
+	}
+	if *batch <= 0 {
+		return fmt.Errorf("-batch must be positive")
+	}
+	t, err := readTransform(*transformSrc, *transformFile)
+	if err != nil {
+		return err
+	}
+	if *dryRun && t == nil {
+		return fmt.Errorf("-dry-run requires -transform or -transform-file")
+	}
+
+	var r io.Reader = e.stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	if t != nil {
+		if *dryRun {
+			return t.pipe(r, e.stdout)
+		}
+		pr, pw := io.Pipe()
+		go func(r io.Reader) {
+			pw.CloseWithError(t.pipe(r, pw))
+		}(r)
+		defer pr.Close()
+		r = pr
+	}
+	report, err := e.client.Import(ctx, model, collection, r, themisdb.ImportOptions{BatchSize: *batch, OnConflict: conflict})
+	if report != nil {
+		fmt.Fprintf(e.stderr, "read %d, wrote %d, skipped %d documents\n", report.Read, report.Written, report.Skipped)
+	}
+	return err
+}
+
+// readTransform parses the transform given by -transform or -transform-file, or
+// returns nil if there is none
+func readTransform(src, file string) (*transform, error) {
+	switch {
+	case src != "" && file != "":
+		return nil, fmt.Errorf("-transform cannot be combined with -transform-file")
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		src = string(data)
+	case src == "":
+		return nil, nil
+	}
+	return parseTransform(src)
+}
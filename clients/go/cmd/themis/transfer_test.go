@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_Export(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/relational/orders/_scan", r.URL.Path)
+		w.Write([]byte(`{"items": [{"uuid": "o1", "document": {"total": 10}}, {"uuid": "o2", "document": {"total": 5}}], "has_more": false}`))
+	}))
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	require.NoError(t, run(context.Background(), []string{"-endpoint", server.URL, "export", "relational/orders"}, strings.NewReader(""), &stdout, &stderr))
+	assert.Equal(t, "{\"uuid\":\"o1\",\"document\":{\"total\":10}}\n{\"uuid\":\"o2\",\"document\":{\"total\":5}}\n", stdout.String())
+	assert.Equal(t, "exported 2 documents from relational/orders\n", stderr.String())
+
+	path := filepath.Join(t.TempDir(), "orders.ndjson")
+	out, err := runArgs(t, "", "-endpoint", server.URL, "export", "relational/orders", "-file", path)
+	require.NoError(t, err)
+	assert.Empty(t, out)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, stdout.String(), string(data))
+}
+
+func TestRun_Import(t *testing.T) {
+	var batches []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/relational/orders/_bulk", r.URL.Path)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		batches = append(batches, body)
+		docs := len(body["documents"].([]interface{}))
+		json.NewEncoder(w).Encode(map[string]int{"written": docs - 1, "skipped": 1})
+	}))
+	defer server.Close()
+
+	input := `{"uuid": "o1", "document": {"total": 10}}
+{"uuid": "o2", "document": {"total": 5}}
+
+{"uuid": "o3", "document": {"total": 7}}
+`
+	var stderr bytes.Buffer
+	err := run(context.Background(), []string{"-endpoint", server.URL, "import", "relational/orders", "-batch", "2", "-on-conflict", "skip"}, strings.NewReader(input), &bytes.Buffer{}, &stderr)
+	require.NoError(t, err)
+	assert.Equal(t, "read 3, wrote 1, skipped 2 documents\n", stderr.String())
+	require.Len(t, batches, 2)
+	assert.Equal(t, "skip", batches[0]["on_conflict"])
+	assert.Len(t, batches[0]["documents"], 2)
+	assert.Len(t, batches[1]["documents"], 1)
+
+	_, err = runArgs(t, input, "-endpoint", server.URL, "import", "relational/orders", "-on-conflict", "merge")
+	assert.EqualError(t, err, `unknown conflict mode "merge", must be overwrite, skip, or fail`)
+	_, err = runArgs(t, "not json\n", "-endpoint", server.URL, "import", "relational/orders")
+	assert.Error(t, err)
+	assert.Len(t, batches, 2)
+}
+
+func TestRun_ImportTransform(t *testing.T) {
+	var batches []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		batches = append(batches, body)
+		json.NewEncoder(w).Encode(map[string]int{"written": len(body["documents"].([]interface{}))})
+	}))
+	defer server.Close()
+
+	input := `{"id": 1, "email": "ADA@example.com", "total": "10.5"}
+{"id": 2, "email": "grace@example.com", "total": "7"}
+`
+	transform := "@uuid = string(.id); email = lower(.email); total = number(.total); del id"
+	out, err := runArgs(t, input, "-endpoint", server.URL, "import", "relational/users", "-transform", transform, "-dry-run")
+	require.NoError(t, err)
+	assert.Equal(t, `{"document":{"email":"ada@example.com","total":10.5},"uuid":"1"}
+{"document":{"email":"grace@example.com","total":7},"uuid":"2"}
+`, out)
+	assert.Empty(t, batches)
+
+	path := filepath.Join(t.TempDir(), "users.transform")
+	require.NoError(t, os.WriteFile(path, []byte(strings.ReplaceAll(transform, "; ", "\n")), 0o600))
+	_, err = runArgs(t, input, "-endpoint", server.URL, "import", "relational/users", "-transform-file", path)
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"uuid": "1", "document": map[string]interface{}{"email": "ada@example.com", "total": 10.5}},
+		map[string]interface{}{"uuid": "2", "document": map[string]interface{}{"email": "grace@example.com", "total": float64(7)}},
+	}, batches[0]["documents"])
+
+	_, err = runArgs(t, input+`{"id": 3, "total": "n/a"}`+"\n", "-endpoint", server.URL, "import", "relational/users", "-transform", transform, "-batch", "1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `line 3: number: "n/a" is not a number`)
+	assert.Len(t, batches, 3)
+
+	_, err = runArgs(t, input, "-endpoint", server.URL, "import", "relational/users", "-dry-run")
+	assert.EqualError(t, err, "-dry-run requires -transform or -transform-file")
+}
@@ -0,0 +1,543 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// transformHelp describes the mapping language of import -transform
+const transformHelp = `A transform is a list of statements separated by ; or newlines, applied to every
+input record. The document starts as a copy of the record.
+
+  field = expr     set a field of the document; nested fields by dotted path
+  @uuid = expr     set the UUID of the document (default: the record's uuid field)
+  del field        remove a field of the document
+
+Expressions read the input record, so the order of the statements does not matter:
+
+  .  .field  .a.b  .tags.0    the record, or a field of it; missing fields are null
+  "text"  42  true  null      JSON literals
+  string(x)  number(x)  bool(x)  lower(s)  upper(s)  trim(s)
+  split(s, sep)  join(list, sep)  concat(x, ...)  default(x, fallback)
+
+Functions return null for null arguments, except concat, which skips them, and
+default, which returns fallback.`
+
+// transform reshapes import records into documents
+type transform struct {
+	statements []statement
+}
+
+// statement is an assignment to target, or a deletion of it if expr is nil
+type statement struct {
+	target []string
+	uuid   bool
+	expr   expr
+}
+
+// expr is an expression of the mapping language, evaluated against a record
+type expr interface {
+	eval(record interface{}) (interface{}, error)
+}
+
+type (
+	// pathExpr is a field of the record; an empty path is the record itself
+	pathExpr []string
+	// literalExpr is a JSON literal
+	literalExpr struct{ value interface{} }
+	// callExpr is a function call
+	callExpr struct {
+		name string
+		fn   func(args []interface{}) (interface{}, error)
+		args []expr
+	}
+)
+
+// parseTransform parses the statements of a transform (see transformHelp)
+func parseTransform(src string) (*transform, error) {
+	p := &transformParser{src: src}
+	t := &transform{}
+	for {
+		p.skipSeparators()
+		if p.eof() {
+			break
+		}
+		s, err := p.statement()
+		if err != nil {
+			return nil, fmt.Errorf("invalid transform at offset %d: %w", p.pos, err)
+		}
+		t.statements = append(t.statements, s)
+		p.skipSpace()
+		if !p.eof() && p.peek() != ';' && p.peek() != '\n' {
+			return nil, fmt.Errorf("invalid transform at offset %d: expected ; or a new line", p.pos)
+		}
+	}
+	if len(t.statements) == 0 {
+		return nil, fmt.Errorf("the transform is empty")
+	}
+	return t, nil
+}
+
+// apply transforms a record, a JSON object, into the UUID and the document to import
+func (t *transform) apply(data []byte) (string, map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var record interface{}
+	if err := dec.Decode(&record); err != nil {
+		return "", nil, err
+	}
+	fields, ok := record.(map[string]interface{})
+	if !ok {
+		return "", nil, fmt.Errorf("the record is not an object")
+	}
+	doc := deepCopy(fields).(map[string]interface{})
+
+	var uuid interface{} = fields["uuid"]
+	for _, s := range t.statements {
+		if s.expr == nil {
+			deletePath(doc, s.target)
+			continue
+		}
+		value, err := s.expr.eval(record)
+		if err != nil {
+			return "", nil, err
+		}
+		if s.uuid {
+			uuid = value
+			continue
+		}
+		if err := setPath(doc, s.target, deepCopy(value)); err != nil {
+			return "", nil, err
+		}
+	}
+
+	switch id := uuid.(type) {
+	case string:
+		if id != "" {
+			return id, doc, nil
+		}
+	case json.Number:
+		return id.String(), doc, nil
+	}
+	return "", nil, fmt.Errorf("the record has no uuid; set one with @uuid = ...")
+}
+
+// pipe transforms the records read from r, one per line, into the input of
+// Client.Import written to w. Every record is written on its own, so an import reading
+// from a pipe stops at the first record that fails. Blank lines are kept so that line
+// numbers match.
+func (t *transform) pipe(r io.Reader, w io.Writer) error {
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		var out []byte
+		if data = bytes.TrimSpace(data); len(data) > 0 {
+			uuid, doc, transformErr := t.apply(data)
+			if transformErr != nil {
+				return fmt.Errorf("line %d: %w", line, transformErr)
+			}
+			if out, transformErr = json.Marshal(map[string]interface{}{"uuid": uuid, "document": doc}); transformErr != nil {
+				return fmt.Errorf("line %d: %w", line, transformErr)
+			}
+		}
+		if err == io.EOF {
+			_, err = w.Write(out)
+			return err
+		}
+		if _, err := w.Write(append(out, '\n')); err != nil {
+			return err
+		}
+	}
+}
+
+func (p pathExpr) eval(record interface{}) (interface{}, error) {
+	value := record
+	for _, part := range p {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[part]
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, nil
+			}
+			value = v[i]
+		default:
+			return nil, nil
+		}
+	}
+	return value, nil
+}
+
+func (l literalExpr) eval(interface{}) (interface{}, error) {
+	return l.value, nil
+}
+
+func (c *callExpr) eval(record interface{}) (interface{}, error) {
+	args := make([]interface{}, len(c.args))
+	for i, arg := range c.args {
+		value, err := arg.eval(record)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+	value, err := c.fn(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.name, err)
+	}
+	return value, nil
+}
+
+// transformFunc is a function of the mapping language with its number of arguments;
+// a negative arity is the minimum of a variadic function
+type transformFunc struct {
+	arity int
+	fn    func(args []interface{}) (interface{}, error)
+}
+
+var transformFuncs = map[string]transformFunc{
+	"string": {1, nullSafe(func(args []interface{}) (interface{}, error) {
+		return stringOf(args[0]), nil
+	})},
+	"number": {1, nullSafe(func(args []interface{}) (interface{}, error) {
+		switch v := args[0].(type) {
+		case json.Number:
+			return v, nil
+		case string:
+			s := strings.TrimSpace(v)
+			if _, err := strconv.ParseFloat(s, 64); err != nil || !json.Valid([]byte(s)) {
+				return nil, fmt.Errorf("%q is not a number", v)
+			}
+			return json.Number(s), nil
+		}
+		return nil, fmt.Errorf("cannot convert %s to a number", stringOf(args[0]))
+	})},
+	"bool": {1, nullSafe(func(args []interface{}) (interface{}, error) {
+		switch v := args[0].(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a boolean", v)
+			}
+			return b, nil
+		}
+		return nil, fmt.Errorf("cannot convert %s to a boolean", stringOf(args[0]))
+	})},
+	"lower": {1, stringFunc(strings.ToLower)},
+	"upper": {1, stringFunc(strings.ToUpper)},
+	"trim":  {1, stringFunc(strings.TrimSpace)},
+	"split": {2, nullSafe(func(args []interface{}) (interface{}, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot split %s, not a string", stringOf(args[0]))
+		}
+		var list []interface{}
+		for _, part := range strings.Split(s, stringOf(args[1])) {
+			list = append(list, part)
+		}
+		return list, nil
+	})},
+	"join": {2, nullSafe(func(args []interface{}) (interface{}, error) {
+		list, ok := args[0].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot join %s, not an array", stringOf(args[0]))
+		}
+		parts := make([]string, len(list))
+		for i, element := range list {
+			parts[i] = stringOf(element)
+		}
+		return strings.Join(parts, stringOf(args[1])), nil
+	})},
+	"concat": {-1, func(args []interface{}) (interface{}, error) {
+		var b strings.Builder
+		for _, arg := range args {
+			if arg != nil {
+				b.WriteString(stringOf(arg))
+			}
+		}
+		return b.String(), nil
+	}},
+	"default": {2, func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return args[1], nil
+		}
+		return args[0], nil
+	}},
+}
+
+// nullSafe returns null if any argument of fn is null
+func nullSafe(fn func(args []interface{}) (interface{}, error)) func(args []interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		for _, arg := range args {
+			if arg == nil {
+				return nil, nil
+			}
+		}
+		return fn(args)
+	}
+}
+
+// stringFunc lifts a string function into the mapping language
+func stringFunc(fn func(string) string) func(args []interface{}) (interface{}, error) {
+	return nullSafe(func(args []interface{}) (interface{}, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a string", stringOf(args[0]))
+		}
+		return fn(s), nil
+	})
+}
+
+// stringOf formats a value as a string: strings as they are, anything else as JSON
+func stringOf(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// setPath sets the field at path in doc, creating the objects on the way
+func setPath(doc map[string]interface{}, path []string, value interface{}) error {
+	for i, part := range path[:len(path)-1] {
+		next, ok := doc[part].(map[string]interface{})
+		if !ok {
+			if doc[part] != nil {
+				return fmt.Errorf("cannot set %s, %s is not an object", strings.Join(path, "."), strings.Join(path[:i+1], "."))
+			}
+			next = map[string]interface{}{}
+			doc[part] = next
+		}
+		doc = next
+	}
+	doc[path[len(path)-1]] = value
+	return nil
+}
+
+// deletePath removes the field at path from doc if it exists
+func deletePath(doc map[string]interface{}, path []string) {
+	for _, part := range path[:len(path)-1] {
+		next, ok := doc[part].(map[string]interface{})
+		if !ok {
+			return
+		}
+		doc = next
+	}
+	delete(doc, path[len(path)-1])
+}
+
+// deepCopy copies the objects and arrays of a decoded JSON value
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for key, element := range v {
+			c[key] = deepCopy(element)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, element := range v {
+			c[i] = deepCopy(element)
+		}
+		return c
+	}
+	return value
+}
+
+// transformParser is a recursive descent parser of the mapping language
+type transformParser struct {
+	src string
+	pos int
+}
+
+func (p *transformParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *transformParser) peek() byte {
+	return p.src[p.pos]
+}
+
+// skipSpace skips spaces and tabs, but not the newlines separating statements
+func (p *transformParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t' || p.peek() == '\r') {
+		p.pos++
+	}
+}
+
+// skipSeparators skips whitespace and statement separators
+func (p *transformParser) skipSeparators() {
+	for !p.eof() && (unicode.IsSpace(rune(p.peek())) || p.peek() == ';') {
+		p.pos++
+	}
+}
+
+// expect consumes c after optional spaces
+func (p *transformParser) expect(c byte) error {
+	p.skipSpace()
+	if p.eof() || p.peek() != c {
+		return fmt.Errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+// statement parses an assignment or a deletion
+func (p *transformParser) statement() (statement, error) {
+	var s statement
+	if strings.HasPrefix(p.src[p.pos:], "@uuid") {
+		p.pos += len("@uuid")
+		s.uuid = true
+	} else {
+		target, err := p.fieldPath()
+		if err != nil {
+			return s, err
+		}
+		p.skipSpace()
+		if len(target) == 1 && target[0] == "del" && !p.eof() && p.peek() != '=' {
+			if s.target, err = p.fieldPath(); err != nil {
+				return s, err
+			}
+			return s, nil
+		}
+		s.target = target
+	}
+	if err := p.expect('='); err != nil {
+		return s, err
+	}
+	e, err := p.expr()
+	if err != nil {
+		return s, err
+	}
+	s.expr = e
+	return s, nil
+}
+
+// fieldPath parses a dotted path of identifiers, e.g. address.city
+func (p *transformParser) fieldPath() ([]string, error) {
+	var path []string
+	for {
+		name := p.ident()
+		if name == "" {
+			return nil, fmt.Errorf("expected a field name")
+		}
+		path = append(path, name)
+		if p.eof() || p.peek() != '.' {
+			return path, nil
+		}
+		p.pos++
+	}
+}
+
+// ident parses letters, digits, _, and -
+func (p *transformParser) ident() string {
+	start := p.pos
+	for !p.eof() && isFieldChar(rune(p.peek())) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func isFieldChar(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '-'
+}
+
+// expr parses a path, a literal, or a function call
+func (p *transformParser) expr() (expr, error) {
+	p.skipSpace()
+	if p.eof() {
+		return nil, fmt.Errorf("expected an expression")
+	}
+	switch c := p.peek(); {
+	case c == '.':
+		p.pos++
+		if p.eof() || !isFieldChar(rune(p.peek())) {
+			return pathExpr{}, nil
+		}
+		path, err := p.fieldPath()
+		return pathExpr(path), err
+	case c == '"':
+		return p.stringLiteral()
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		p.pos++
+		for !p.eof() && strings.IndexByte("0123456789.eE+-", p.peek()) >= 0 {
+			p.pos++
+		}
+		n := json.Number(p.src[start:p.pos])
+		if !json.Valid([]byte(n)) {
+			return nil, fmt.Errorf("invalid number %s", n)
+		}
+		return literalExpr{n}, nil
+	}
+
+	name := p.ident()
+	switch name {
+	case "":
+		return nil, fmt.Errorf("unexpected %q", p.peek())
+	case "true", "false":
+		return literalExpr{name == "true"}, nil
+	case "null":
+		return literalExpr{nil}, nil
+	}
+	f, ok := transformFuncs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s", name)
+	}
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	call := &callExpr{name: name, fn: f.fn}
+	p.skipSpace()
+	if !p.eof() && p.peek() == ')' {
+		p.pos++
+	} else {
+		for {
+			arg, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			p.skipSpace()
+			if !p.eof() && p.peek() == ',' {
+				p.pos++
+				continue
+			}
+			if err := p.expect(')'); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	if f.arity < 0 && len(call.args) < -f.arity {
+		return nil, fmt.Errorf("%s takes at least %d arguments, got %d", name, -f.arity, len(call.args))
+	}
+	if f.arity >= 0 && len(call.args) != f.arity {
+		return nil, fmt.Errorf("%s takes %d arguments, got %d", name, f.arity, len(call.args))
+	}
+	return call, nil
+}
+
+// stringLiteral parses a string in JSON syntax
+func (p *transformParser) stringLiteral() (expr, error) {
+	dec := json.NewDecoder(strings.NewReader(p.src[p.pos:]))
+	var s string
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("invalid string: %w", err)
+	}
+	p.pos += int(dec.InputOffset())
+	return literalExpr{s}, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransform_Apply(t *testing.T) {
+	tr, err := parseTransform(`
+		@uuid = concat("c-", .id)
+		name = trim(.full_name); email = lower(.email)
+		total = number(.total)
+		active = bool(.active)
+		tags = split(.tags, ",")
+		address.city = default(.city, "unknown")
+		first_tag = split(.tags, ",")
+		del id
+		del full_name; del city
+	`)
+	require.NoError(t, err)
+
+	uuid, doc, err := tr.apply([]byte(`{"id": 17, "full_name": " Ada Lovelace ", "email": "ADA@example.com", "total": "12.50", "active": "true", "tags": "math,code", "city": null, "note": "kept"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "c-17", uuid)
+	assert.Equal(t, map[string]interface{}{
+		"name":      "Ada Lovelace",
+		"email":     "ada@example.com",
+		"total":     json.Number("12.50"),
+		"active":    true,
+		"tags":      []interface{}{"math", "code"},
+		"first_tag": []interface{}{"math", "code"},
+		"address":   map[string]interface{}{"city": "unknown"},
+		"note":      "kept",
+	}, doc)
+}
+
+func TestTransform_Paths(t *testing.T) {
+	tr, err := parseTransform(`copy = .; first = .items.0.sku; missing = .items.9.sku; upper = upper(.nothing)`)
+	require.NoError(t, err)
+
+	uuid, doc, err := tr.apply([]byte(`{"uuid": "o1", "items": [{"sku": "A-1"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, "o1", uuid)
+	assert.Equal(t, "A-1", doc["first"])
+	assert.Nil(t, doc["missing"])
+	assert.Nil(t, doc["upper"])
+	assert.Contains(t, doc, "upper")
+	assert.Equal(t, map[string]interface{}{"uuid": "o1", "items": []interface{}{map[string]interface{}{"sku": "A-1"}}}, doc["copy"])
+}
+
+func TestTransform_Errors(t *testing.T) {
+	for _, src := range []string{
+		"",
+		"name",
+		"name = ",
+		"name = .a .b",
+		"name = unknown(.a)",
+		"name = lower(.a, .b)",
+		"name = concat()",
+		`name = "unterminated`,
+		"@uuid .id",
+		"del",
+	} {
+		_, err := parseTransform(src)
+		assert.Error(t, err, src)
+	}
+
+	tr, err := parseTransform("total = number(.total); address.city = .city")
+	require.NoError(t, err)
+	for _, record := range []string{
+		`[1, 2]`,
+		`{"total": 1}`,
+		`{"uuid": "o1", "total": "twelve"}`,
+		`{"uuid": "o1", "address": "Main St 1", "city": "Berlin"}`,
+	} {
+		_, _, err := tr.apply([]byte(record))
+		assert.Error(t, err, record)
+	}
+}
+
+func TestTransform_DelField(t *testing.T) {
+	tr, err := parseTransform("del = .x; del del")
+	require.NoError(t, err)
+	_, doc, err := tr.apply([]byte(`{"uuid": "o1", "x": 1}`))
+	require.NoError(t, err)
+	assert.NotContains(t, doc, "del")
+	assert.Equal(t, json.Number("1"), doc["x"])
+}
+
+func TestTransform_Pipe(t *testing.T) {
+	tr, err := parseTransform("@uuid = .id; del id")
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, tr.pipe(strings.NewReader("{\"id\": \"a\", \"n\": 1}\n\n{\"id\": \"b\", \"n\": 2}"), &out))
+	assert.Equal(t, "{\"document\":{\"n\":1},\"uuid\":\"a\"}\n\n{\"document\":{\"n\":2},\"uuid\":\"b\"}", out.String())
+
+	err = tr.pipe(strings.NewReader("{\"id\": \"a\"}\n{\"n\": 2}\n"), &bytes.Buffer{})
+	assert.EqualError(t, err, "line 2: the record has no uuid; set one with @uuid = ...")
+}
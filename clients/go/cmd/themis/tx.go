@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+// txHelp describes the statements of the tx command
+const txHelp = `statements:
+  get <model> <collection> <uuid>           print a document
+  put <model> <collection> <uuid> <json>    write a document
+  delete <model> <collection> <uuid>        delete a document
+  query <aql>                               run a query
+  status                                    print the read and write set
+  commit                                    commit and exit
+  rollback                                  roll back and exit
+  help                                      print this help`
+
+// txSession is an interactive transaction with the documents it read and wrote
+type txSession struct {
+	tx        *themisdb.Transaction
+	isolation themisdb.IsolationLevel
+	printer   *printer
+	reads     []string
+	read      map[string]bool
+	// writes are the written documents in order of their first write, ops the last
+	// operation on each
+	writes  []string
+	ops     map[string]string
+	queries int
+}
+
+// runTx runs the tx command, which reads statements from stdin and runs them in one
+// transaction until commit, rollback, or the end of the input, which rolls back:
+//
+//	themis tx [flags]
+func runTx(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("tx", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	isolation := fs.String("isolation", "read_committed", "isolation level: read_committed or snapshot")
+	timeout := fs.Duration("timeout", 5*time.Minute, "server-side timeout of the transaction; kept alive while the session is open")
+	format := fs.String("format", "", "output format of reads and queries: json (default), jsonl, csv, or table")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("tx takes no arguments; statements are read from stdin")
+	}
+	level := themisdb.IsolationLevel(strings.ToUpper(*isolation))
+	if level != themisdb.ReadCommitted && level != themisdb.Snapshot {
+		return fmt.Errorf("unknown isolation level %q, must be read_committed or snapshot", *isolation)
+	}
+	p, err := newPrinter(*format, "")
+	if err != nil {
+		return err
+	}
+
+	tx, err := e.client.BeginTransaction(ctx, &themisdb.TransactionOptions{
+		IsolationLevel: level,
+		Timeout:        *timeout,
+		Heartbeat:      *timeout / 3,
+	})
+	if err != nil {
+		return err
+	}
+	s := &txSession{tx: tx, isolation: level, printer: p, read: map[string]bool{}, ops: map[string]string{}}
+	fmt.Fprintf(e.stderr, "transaction %s begun (%s); type help for the statements\n", tx.TransactionID(), *isolation)
+
+	scanner := bufio.NewScanner(e.stdin)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for {
+		fmt.Fprint(e.stderr, "tx> ")
+		if !scanner.Scan() {
+			break
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		done, err := s.exec(ctx, e, line)
+		if done {
+			return err
+		}
+		if err != nil {
+			fmt.Fprintln(e.stderr, "error:", err)
+		}
+		if !tx.IsActive() {
+			if err := tx.Err(); err != nil {
+				return fmt.Errorf("transaction %s ended: %w", tx.TransactionID(), err)
+			}
+			return fmt.Errorf("transaction %s ended", tx.TransactionID())
+		}
+	}
+	fmt.Fprintln(e.stderr)
+	if err := scanner.Err(); err != nil {
+		tx.Rollback(context.Background())
+		return err
+	}
+	fmt.Fprintln(e.stderr, "end of input, rolling back")
+	return tx.Rollback(context.Background())
+}
+
+// exec runs a statement and reports whether it ended the transaction
+func (s *txSession) exec(ctx context.Context, e *env, line string) (bool, error) {
+	verb, rest := line, ""
+	if end := strings.IndexAny(line, " \t"); end >= 0 {
+		verb, rest = line[:end], strings.TrimSpace(line[end:])
+	}
+	switch strings.ToLower(verb) {
+	case "get":
+		args, err := txArgs(rest, 3, false)
+		if err != nil {
+			return false, err
+		}
+		var doc json.RawMessage
+		if err := s.tx.Get(ctx, args[0], args[1], args[2], &doc); err != nil {
+			return false, err
+		}
+		s.recordRead(strings.Join(args, "/"))
+		return false, s.printer.printDocument(e.stdout, doc)
+	case "put":
+		args, err := txArgs(rest, 3, true)
+		if err != nil {
+			return false, err
+		}
+		if !json.Valid([]byte(args[3])) {
+			return false, fmt.Errorf("put: the document is not valid JSON")
+		}
+		if err := s.tx.Put(ctx, args[0], args[1], args[2], json.RawMessage(args[3])); err != nil {
+			return false, err
+		}
+		s.recordWrite("put", strings.Join(args[:3], "/"))
+		return false, nil
+	case "delete":
+		args, err := txArgs(rest, 3, false)
+		if err != nil {
+			return false, err
+		}
+		if err := s.tx.Delete(ctx, args[0], args[1], args[2]); err != nil {
+			return false, err
+		}
+		s.recordWrite("delete", strings.Join(args, "/"))
+		return false, nil
+	case "query":
+		if rest == "" {
+			return false, fmt.Errorf("query: the query is missing")
+		}
+		var rows []json.RawMessage
+		if err := s.tx.Query(ctx, rest, &rows); err != nil {
+			return false, err
+		}
+		s.queries++
+		return false, s.printer.print(e.stdout, rows)
+	case "status":
+		s.status(e)
+		return false, nil
+	case "commit":
+		if err := s.tx.Commit(ctx); err != nil {
+			return true, err
+		}
+		fmt.Fprintf(e.stderr, "committed %d writes\n", len(s.writes))
+		return true, nil
+	case "rollback":
+		if err := s.tx.Rollback(ctx); err != nil {
+			return true, err
+		}
+		fmt.Fprintf(e.stderr, "rolled back %d writes\n", len(s.writes))
+		return true, nil
+	case "help":
+		fmt.Fprintln(e.stderr, txHelp)
+		return false, nil
+	}
+	return false, errors.New("unknown statement " + verb + "; type help for the statements")
+}
+
+// txArgs splits the n arguments of a statement, followed by the rest of the line as a
+// last argument if withRest is set
+func txArgs(s string, n int, withRest bool) ([]string, error) {
+	var args []string
+	for i := 0; i < n; i++ {
+		s = strings.TrimLeft(s, " \t")
+		end := strings.IndexAny(s, " \t")
+		if end < 0 {
+			end = len(s)
+		}
+		arg := s[:end]
+		s = s[end:]
+		if arg == "" {
+			return nil, fmt.Errorf("expected <model> <collection> <uuid>")
+		}
+		args = append(args, arg)
+	}
+	s = strings.TrimSpace(s)
+	switch {
+	case withRest && s == "":
+		return nil, fmt.Errorf("expected a JSON document after the uuid")
+	case !withRest && s != "":
+		return nil, fmt.Errorf("unexpected %q after the uuid", s)
+	case withRest:
+		args = append(args, s)
+	}
+	return args, nil
+}
+
+// recordRead adds a document to the read set
+func (s *txSession) recordRead(key string) {
+	if !s.read[key] {
+		s.read[key] = true
+		s.reads = append(s.reads, key)
+	}
+}
+
+// recordWrite adds a document to the write set
+func (s *txSession) recordWrite(op, key string) {
+	if _, ok := s.ops[key]; !ok {
+		s.writes = append(s.writes, key)
+	}
+	s.ops[key] = op
+}
+
+// status prints the transaction and its read and write set. The read set holds the
+// documents read with get; documents read by queries are counted, not listed.
+func (s *txSession) status(e *env) {
+	fmt.Fprintf(e.stdout, "transaction %s (%s) on %s\n", s.tx.TransactionID(), strings.ToLower(string(s.isolation)), s.tx.Endpoint())
+	fmt.Fprintf(e.stdout, "reads (%d):\n", len(s.reads))
+	for _, key := range s.reads {
+		fmt.Fprintf(e.stdout, "  %s\n", key)
+	}
+	fmt.Fprintf(e.stdout, "writes (%d):\n", len(s.writes))
+	for _, key := range s.writes {
+		fmt.Fprintf(e.stdout, "  %-6s %s\n", s.ops[key], key)
+	}
+	fmt.Fprintf(e.stdout, "queries: %d\n", s.queries)
+}
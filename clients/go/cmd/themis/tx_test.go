@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// txServer serves a transaction and records its requests
+func txServer(t *testing.T) (string, func() []string) {
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, strings.TrimSpace(r.Method+" "+r.URL.Path+" "+r.Header.Get("X-Transaction-Id")+" "+string(body)))
+		mu.Unlock()
+		switch {
+		case r.URL.Path == "/transaction/begin":
+			w.Write([]byte(`{"transaction_id": "tx-1"}`))
+		case r.URL.Path == "/api/query":
+			w.Write([]byte(`{"data": [{"n": 2}]}`))
+		case r.Method == "GET":
+			w.Write([]byte(`{"total": 10}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), requests...)
+	}
+}
+
+func TestRun_Tx(t *testing.T) {
+	endpoint, requests := txServer(t)
+	input := strings.Join([]string{
+		"get relational orders o1",
+		"put relational orders o1 {\"total\": 12}",
+		"# comments and blank lines are skipped",
+		"",
+		"delete relational orders o2",
+		"put relational orders o2 {\"total\": 1}",
+		"bogus",
+		"put relational orders o3 {not json",
+		"query FOR o IN orders COLLECT WITH COUNT INTO n RETURN {n}",
+		"status",
+		"commit",
+		"get relational orders o1",
+	}, "\n")
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), []string{"-endpoint", endpoint, "tx", "-isolation", "snapshot", "-format", "jsonl"},
+		strings.NewReader(input), &stdout, &stderr)
+	require.NoError(t, err)
+
+	assert.Equal(t, "{\"total\":10}\n{\"n\":2}\n"+
+		"transaction tx-1 (snapshot) on "+endpoint+"\n"+
+		"reads (1):\n  relational/orders/o1\n"+
+		"writes (2):\n  put    relational/orders/o1\n  put    relational/orders/o2\n"+
+		"queries: 1\n", stdout.String())
+	assert.Contains(t, stderr.String(), "error: unknown statement bogus")
+	assert.Contains(t, stderr.String(), "error: put: the document is not valid JSON")
+	assert.Contains(t, stderr.String(), "committed 2 writes")
+
+	sent := requests()
+	require.Len(t, sent, 7, "nothing is sent after the commit")
+	assert.Contains(t, sent[0], `"isolation_level":"SNAPSHOT"`)
+	assert.Equal(t, "GET /api/relational/orders/o1 tx-1", sent[1])
+	assert.Equal(t, `PUT /api/relational/orders/o1 tx-1 {"total":12}`, sent[2])
+	assert.Equal(t, "DELETE /api/relational/orders/o2 tx-1", sent[3])
+	assert.Equal(t, `POST /transaction/commit  {"transaction_id":"tx-1"}`, sent[6])
+}
+
+func TestRun_TxEndOfInput(t *testing.T) {
+	endpoint, requests := txServer(t)
+	var stderr bytes.Buffer
+	err := run(context.Background(), []string{"-endpoint", endpoint, "tx"}, strings.NewReader("put kv k a 1\n"), &bytes.Buffer{}, &stderr)
+	require.NoError(t, err)
+	assert.Contains(t, stderr.String(), "end of input, rolling back")
+	sent := requests()
+	require.Len(t, sent, 3)
+	assert.Equal(t, `POST /transaction/rollback  {"transaction_id":"tx-1"}`, sent[2])
+}
+
+func TestRun_TxInvalid(t *testing.T) {
+	endpoint, requests := txServer(t)
+	for _, args := range [][]string{
+		{"tx", "-isolation", "serializable"},
+		{"tx", "-format", "xml"},
+		{"tx", "get"},
+	} {
+		_, err := runArgs(t, "", append([]string{"-endpoint", endpoint}, args...)...)
+		assert.Error(t, err, "%v", args)
+	}
+	assert.Empty(t, requests())
+}
+
+func TestTxArgs(t *testing.T) {
+	args, err := txArgs("kv  keys\tk1 ", 3, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kv", "keys", "k1"}, args)
+
+	args, err = txArgs(`kv keys k1 {"a": [1, 2]}`, 3, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kv", "keys", "k1", `{"a": [1, 2]}`}, args)
+
+	_, err = txArgs("kv keys", 3, false)
+	assert.Error(t, err)
+	_, err = txArgs("kv keys k1 extra", 3, false)
+	assert.Error(t, err)
+	_, err = txArgs("kv keys k1", 3, true)
+	assert.Error(t, err)
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+// ANSI escape sequences of the colorized diff
+const (
+	colorRed   = "\x1b[31m"
+	colorGreen = "\x1b[32m"
+	colorBold  = "\x1b[1m"
+	colorReset = "\x1b[0m"
+)
+
+// watchFilter compares a field of a document with a value
+type watchFilter struct {
+	field  string
+	value  interface{}
+	negate bool
+}
+
+// parseWatchFilter parses field=value or field!=value; the value is compared as JSON
+// if it is valid JSON, as a string otherwise
+func parseWatchFilter(s string) (watchFilter, error) {
+	f := watchFilter{}
+	field, value, ok := strings.Cut(s, "=")
+	if !ok || field == "" || field == "!" {
+		return f, fmt.Errorf("filter %q is not field=value or field!=value", s)
+	}
+	if strings.HasSuffix(field, "!") {
+		field, f.negate = strings.TrimSuffix(field, "!"), true
+	}
+	f.field = field
+	if json.Unmarshal([]byte(value), &f.value) != nil {
+		f.value = value
+	}
+	return f, nil
+}
+
+// match reports whether the field of doc satisfies the filter
+func (f watchFilter) match(doc map[string]interface{}) bool {
+	var value interface{} = doc
+	for _, part := range strings.Split(f.field, ".") {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			value = nil
+			break
+		}
+		value = obj[part]
+	}
+	equal := fmt.Sprint(value) == fmt.Sprint(f.value) && (value == nil) == (f.value == nil)
+	return equal != f.negate
+}
+
+// watcher prints the changes of a collection as diffs against the versions it saw
+type watcher struct {
+	out     io.Writer
+	color   bool
+	jsonl   bool
+	filters []watchFilter
+	// seen holds the last document seen per UUID, the before state of its next change
+	seen map[string]map[string]interface{}
+}
+
+// runWatch runs the watch command, which tails the changefeed of a collection until
+// interrupted:
+//
+//	themis watch [flags] <model>/<collection>
+func runWatch(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	var filters []watchFilter
+	fs.Func("filter", "show only documents whose `field=value` or field!=value, e.g. status=failed (repeatable)", func(s string) error {
+		f, err := parseWatchFilter(s)
+		filters = append(filters, f)
+		return err
+	})
+	prefix := fs.String("prefix", "", "show only documents whose UUID starts with this prefix")
+	from := fs.Uint64("from", 0, "start after this changefeed sequence number instead of at the latest change")
+	count := fs.Int("n", 0, "exit after this many changes were shown; 0 watches until interrupted")
+	colorMode := fs.String("color", "auto", "colorize diffs: auto, always, or never")
+	format := fs.String("format", "text", "output format: text or jsonl")
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("expected one <model>/<collection> argument")
+	}
+	model, collection, err := parseCollection(positional[0])
+	if err != nil {
+		return err
+	}
+	if *format != "text" && *format != formatJSONL {
+		return fmt.Errorf("unknown format %q, must be text or jsonl", *format)
+	}
+	w := &watcher{out: e.stdout, jsonl: *format == formatJSONL, filters: filters, seen: map[string]map[string]interface{}{}}
+	switch *colorMode {
+	case "auto":
+		w.color = isTerminal(e.stdout) && os.Getenv("NO_COLOR") == ""
+	case "always":
+		w.color = true
+	case "never":
+	default:
+		return fmt.Errorf("unknown color mode %q, must be auto, always, or never", *colorMode)
+	}
+
+	seq := *from
+	if seq == 0 {
+		if seq, err = e.client.LatestChangeSequence(ctx); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(e.stderr, "watching %s/%s from sequence %d\n", model, collection, seq)
+	shown := 0
+	for *count == 0 || shown < *count {
+		changes, err := e.client.ReadChanges(ctx, seq, themisdb.ChangesOptions{
+			Collection: collection,
+			Prefix:     *prefix,
+			Wait:       10 * time.Second,
+		})
+		if errors.Is(err, context.Canceled) || ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, change := range changes {
+			seq = change.Sequence
+			if !w.show(change) {
+				continue
+			}
+			if shown++; *count > 0 && shown == *count {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// show prints change if it passes the filters and reports whether it did
+func (w *watcher) show(change themisdb.Change) bool {
+	before := w.seen[change.UUID]
+	var after map[string]interface{}
+	if change.Type == "PUT" {
+		if json.Unmarshal(change.Document, &after) != nil {
+			after = map[string]interface{}{}
+		}
+		w.seen[change.UUID] = after
+	} else {
+		delete(w.seen, change.UUID)
+	}
+
+	// a delete matches if the deleted document did; unseen deleted documents do not
+	// match any filter
+	doc := after
+	if change.Type != "PUT" {
+		doc = before
+	}
+	for _, f := range w.filters {
+		if doc == nil || !f.match(doc) {
+			return false
+		}
+	}
+
+	if w.jsonl {
+		data, _ := json.Marshal(struct {
+			themisdb.Change
+			Before map[string]interface{} `json:"before"`
+		}{change, before})
+		fmt.Fprintf(w.out, "%s\n", data)
+		return true
+	}
+	header := fmt.Sprintf("%s #%d %s %s/%s", change.Time.Local().Format("15:04:05.000"), change.Sequence, change.Type, change.Collection, change.UUID)
+	if before == nil && change.Type == "PUT" {
+		header += " (first seen)"
+	}
+	fmt.Fprintln(w.out, w.paint(colorBold, header))
+	for _, line := range diffDocuments(before, after) {
+		switch line[0] {
+		case '-':
+			line = w.paint(colorRed, line)
+		case '+':
+			line = w.paint(colorGreen, line)
+		}
+		fmt.Fprintln(w.out, "  "+line)
+	}
+	return true
+}
+
+// paint wraps s in color if colors are enabled
+func (w *watcher) paint(color, s string) string {
+	if !w.color {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// diffDocuments returns the fields that differ between before and after, nested fields
+// by their dotted path, as "- path: old" and "+ path: new" lines in path order
+func diffDocuments(before, after map[string]interface{}) []string {
+	old, updated := map[string]string{}, map[string]string{}
+	flattenDocument("", before, old)
+	flattenDocument("", after, updated)
+	paths := make([]string, 0, len(old)+len(updated))
+	for path := range old {
+		paths = append(paths, path)
+	}
+	for path := range updated {
+		if _, ok := old[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var lines []string
+	for _, path := range paths {
+		o, inOld := old[path]
+		n, inNew := updated[path]
+		if inOld && inNew && o == n {
+			continue
+		}
+		if inOld {
+			lines = append(lines, "- "+path+": "+o)
+		}
+		if inNew {
+			lines = append(lines, "+ "+path+": "+n)
+		}
+	}
+	return lines
+}
+
+// flattenDocument adds the fields of doc, nested under prefix, to fields as compact
+// JSON; objects are flattened, arrays kept whole
+func flattenDocument(prefix string, doc map[string]interface{}, fields map[string]string) {
+	for name, value := range doc {
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenDocument(prefix+name+".", nested, fields)
+			continue
+		}
+		var b bytes.Buffer
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		enc.Encode(value)
+		fields[prefix+name] = strings.TrimSuffix(b.String(), "\n")
+	}
+}
+
+// isTerminal reports whether w is a character device such as a terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
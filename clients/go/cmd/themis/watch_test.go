@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// changefeedServer serves events after the sequence 10 as the latest
+func changefeedServer(t *testing.T, events string) (string, *[]string) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		if r.URL.Query().Get("limit") == "0" {
+			w.Write([]byte(`{"latest_sequence": 10}`))
+			return
+		}
+		if r.URL.Query().Get("from_seq") != "10" {
+			w.Write([]byte(`{"events": []}`))
+			return
+		}
+		w.Write([]byte(`{"events": ` + events + `}`))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, &queries
+}
+
+const testEvents = `[
+	{"sequence": 11, "type": "PUT", "key": "orders:o1", "value": "{\"status\": \"open\", \"total\": 10, \"address\": {\"city\": \"Berlin\"}}", "timestamp_ms": 1767225600000},
+	{"sequence": 12, "type": "PUT", "key": "orders:o2", "value": "{\"status\": \"open\", \"total\": 5}", "timestamp_ms": 1767225601000},
+	{"sequence": 13, "type": "PUT", "key": "orders:o1", "value": "{\"status\": \"failed\", \"total\": 10, \"address\": {\"city\": \"Hamburg\"}, \"error\": \"card declined\"}", "timestamp_ms": 1767225602000},
+	{"sequence": 14, "type": "DELETE", "key": "orders:o1", "timestamp_ms": 1767225603000},
+	{"sequence": 15, "type": "DELETE", "key": "orders:o9", "timestamp_ms": 1767225604000}
+]`
+
+func TestRun_Watch(t *testing.T) {
+	endpoint, queries := changefeedServer(t, testEvents)
+	out, err := runArgs(t, "", "-endpoint", endpoint, "watch", "relational/orders", "-n", "5", "-color", "never")
+	require.NoError(t, err)
+
+	blocks := strings.Split(strings.TrimSpace(out), "\n")
+	assert.Contains(t, blocks[0], " #11 PUT orders/o1 (first seen)")
+	assert.Equal(t, []string{
+		`  + address.city: "Berlin"`,
+		`  + status: "open"`,
+		`  + total: 10`,
+	}, blocks[1:4])
+	assert.Contains(t, blocks[4], " #12 PUT orders/o2 (first seen)")
+	assert.Contains(t, blocks[7], " #13 PUT orders/o1")
+	assert.NotContains(t, blocks[7], "first seen")
+	assert.Equal(t, []string{
+		`  - address.city: "Berlin"`,
+		`  + address.city: "Hamburg"`,
+		`  + error: "card declined"`,
+		`  - status: "open"`,
+		`  + status: "failed"`,
+	}, blocks[8:13])
+	assert.Contains(t, blocks[13], " #14 DELETE orders/o1")
+	assert.Equal(t, `  - address.city: "Hamburg"`, blocks[14])
+	assert.Contains(t, blocks[len(blocks)-1], " #15 DELETE orders/o9")
+
+	require.Len(t, *queries, 2)
+	assert.Contains(t, (*queries)[1], "key_prefix=orders%3A")
+	assert.Contains(t, (*queries)[1], "long_poll_ms=10000")
+}
+
+func TestRun_WatchFilter(t *testing.T) {
+	endpoint, _ := changefeedServer(t, testEvents)
+	out, err := runArgs(t, "", "-endpoint", endpoint, "watch", "-filter", "status=failed", "-filter", "total!=5", "-n", "2", "-color", "always", "relational/orders")
+	require.NoError(t, err)
+	assert.Contains(t, out, "\x1b[1m")
+	assert.Contains(t, out, "\x1b[31m- status: \"open\"\x1b[0m")
+	assert.Contains(t, out, "\x1b[32m+ status: \"failed\"\x1b[0m")
+	assert.Contains(t, out, "#14 DELETE orders/o1", "the deleted document matched")
+	assert.NotContains(t, out, "#11")
+	assert.NotContains(t, out, "o2")
+
+	out, err = runArgs(t, "", "-endpoint", endpoint, "watch", "-format", "jsonl", "-n", "3", "relational/orders")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3)
+	var change struct {
+		Sequence uint64                 `json:"sequence"`
+		Document map[string]interface{} `json:"document"`
+		Before   map[string]interface{} `json:"before"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[2]), &change))
+	assert.Equal(t, uint64(13), change.Sequence)
+	assert.Equal(t, "failed", change.Document["status"])
+	assert.Equal(t, "open", change.Before["status"])
+}
+
+func TestRun_WatchInterrupted(t *testing.T) {
+	endpoint, _ := changefeedServer(t, `[]`)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := run(ctx, []string{"-endpoint", endpoint, "watch", "-from", "20", "relational/orders"}, strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{})
+	assert.NoError(t, err)
+}
+
+func TestRun_WatchInvalid(t *testing.T) {
+	endpoint, queries := changefeedServer(t, `[]`)
+	for _, args := range [][]string{
+		{"watch"},
+		{"watch", "orders"},
+		{"watch", "relational/orders/o1"},
+		{"watch", "-filter", "status", "relational/orders"},
+		{"watch", "-color", "sometimes", "relational/orders"},
+		{"watch", "-format", "csv", "relational/orders"},
+	} {
+		_, err := runArgs(t, "", append([]string{"-endpoint", endpoint}, args...)...)
+		assert.Error(t, err, "%v", args)
+	}
+	assert.Empty(t, *queries)
+}
+
+func TestDiffDocuments(t *testing.T) {
+	assert.Equal(t, []string{`- tags: ["a"]`, `+ tags: ["a","b"]`, `- url: "a&b"`},
+		diffDocuments(map[string]interface{}{"tags": []interface{}{"a"}, "url": "a&b", "n": 1.0},
+			map[string]interface{}{"tags": []interface{}{"a", "b"}, "n": 1.0}))
+	assert.Empty(t, diffDocuments(nil, nil))
+}
@@ -0,0 +1,56 @@
+// Command themiscompat compares two schema snapshots recorded with the schemacompat
+// package and reports changes to the JSON serialization of model types. It exits with
+// status 1 if a change is breaking, so it can gate a deploy in CI:
+//
+//	themiscompat -old deployed.schema.json -new models.schema.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/makr-code/ThemisDB/clients/go/schemacompat"
+)
+
+// errBreaking is returned by run if a change is breaking
+var errBreaking = fmt.Errorf("breaking changes found")
+
+func main() {
+	oldPath := flag.String("old", "", "snapshot of the deployed model types")
+	newPath := flag.String("new", "", "snapshot of the model types to deploy")
+	all := flag.Bool("all", false, "also report compatible changes")
+	flag.Parse()
+
+	if err := run(*oldPath, *newPath, *all, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "themiscompat:", err)
+		os.Exit(1)
+	}
+}
+
+// run compares the snapshots and prints the changes to w
+func run(oldPath, newPath string, all bool, w io.Writer) error {
+	if oldPath == "" || newPath == "" {
+		return fmt.Errorf("-old and -new are required")
+	}
+	old, err := schemacompat.Load(oldPath)
+	if err != nil {
+		return err
+	}
+	updated, err := schemacompat.Load(newPath)
+	if err != nil {
+		return err
+	}
+
+	changes := schemacompat.Compare(old, updated)
+	for _, c := range changes {
+		if all || c.Breaking {
+			fmt.Fprintln(w, c)
+		}
+	}
+	if schemacompat.HasBreaking(changes) {
+		return errBreaking
+	}
+	return nil
+}
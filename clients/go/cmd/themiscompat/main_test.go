@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const oldSnapshot = `{"types": {"User": {"kind": "object", "fields": [
+	{"name": "name", "go_name": "Name", "type": {"kind": "string"}},
+	{"name": "age", "go_name": "Age", "type": {"kind": "integer", "format": "int32"}}
+]}}}`
+
+func writeSnapshot(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestRun_Compatible(t *testing.T) {
+	newPath := writeSnapshot(t, "new.json", `{"types": {"User": {"kind": "object", "fields": [
+		{"name": "name", "go_name": "Name", "type": {"kind": "string"}},
+		{"name": "age", "go_name": "Age", "type": {"kind": "integer", "format": "int64"}}
+	]}}}`)
+	oldPath := writeSnapshot(t, "old.json", oldSnapshot)
+
+	var out bytes.Buffer
+	require.NoError(t, run(oldPath, newPath, false, &out))
+	assert.Empty(t, out.String())
+
+	require.NoError(t, run(oldPath, newPath, true, &out))
+	assert.Equal(t, "User.age: widened from int32 to int64\n", out.String())
+}
+
+func TestRun_Breaking(t *testing.T) {
+	newPath := writeSnapshot(t, "new.json", `{"types": {"User": {"kind": "object", "fields": [
+		{"name": "full_name", "go_name": "Name", "type": {"kind": "string"}},
+		{"name": "age", "go_name": "Age", "type": {"kind": "string"}}
+	]}}}`)
+
+	var out bytes.Buffer
+	err := run(writeSnapshot(t, "old.json", oldSnapshot), newPath, false, &out)
+	assert.ErrorIs(t, err, errBreaking)
+	assert.Equal(t, "User.name: breaking: renamed to \"full_name\"; stored values are no longer read\n"+
+		"User.age: breaking: changed from integer (int32) to string\n", out.String())
+
+	assert.Error(t, run("", newPath, false, &out))
+}
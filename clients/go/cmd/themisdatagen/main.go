@@ -0,0 +1,95 @@
+// Command themisdatagen generates a synthetic dataset from a spec file and loads it
+// into ThemisDB, or writes it as newline-delimited JSON:
+//
+//	themisdatagen -spec shop.json -endpoint http://localhost:8080 -scale 10
+//	themisdatagen -spec shop.json -out shop.ndjson
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+	"github.com/makr-code/ThemisDB/clients/go/datagen"
+)
+
+// options are the command line flags
+type options struct {
+	spec     string
+	seed     int64
+	scale    float64
+	endpoint string
+	workers  int
+	out      string
+}
+
+func main() {
+	var o options
+	flag.StringVar(&o.spec, "spec", "spec.json", "path to the dataset spec file")
+	flag.Int64Var(&o.seed, "seed", 1, "random seed; the same seed generates the same dataset")
+	flag.Float64Var(&o.scale, "scale", 1, "factor applied to the document count of every collection")
+	flag.StringVar(&o.endpoint, "endpoint", "http://localhost:8080", "ThemisDB endpoint to load into")
+	flag.IntVar(&o.workers, "workers", 8, "number of concurrent writes")
+	flag.StringVar(&o.out, "out", "", "write NDJSON to this file (- for stdout) instead of loading")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := run(ctx, o, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "themisdatagen:", err)
+		os.Exit(1)
+	}
+}
+
+// run generates the dataset described by o and loads or writes it
+func run(ctx context.Context, o options, stdout, stderr io.Writer) error {
+	data, err := os.ReadFile(o.spec)
+	if err != nil {
+		return fmt.Errorf("failed to read spec: %w", err)
+	}
+	var spec datagen.Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return fmt.Errorf("failed to parse spec: %w", err)
+	}
+	g, err := datagen.New(spec.Scaled(o.scale), o.seed)
+	if err != nil {
+		return err
+	}
+
+	switch o.out {
+	case "":
+	case "-":
+		return g.WriteNDJSON(stdout)
+	default:
+		f, err := os.Create(o.out)
+		if err != nil {
+			return err
+		}
+		if err := g.WriteNDJSON(f); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+
+	client := themisdb.NewClient(themisdb.Config{Endpoints: []string{o.endpoint}})
+	defer client.Close()
+	total := g.Total()
+	report, err := datagen.Load(ctx, client, g, &datagen.LoadOptions{
+		Workers: o.workers,
+		OnProgress: func(r datagen.LoadReport) {
+			fmt.Fprintf(stderr, "%d/%d documents (%.0f/s)\n", r.Written, total, r.Rate())
+		},
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "loaded %d documents in %s\n", report.Written, report.Elapsed.Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSpec = `{"collections": [
+	{"model": "relational", "name": "customers", "count": 10, "fields": [
+		{"name": "name", "type": "name"}
+	]},
+	{"model": "document", "name": "orders", "count": 40, "fields": [
+		{"name": "customer", "type": "ref", "ref": "customers", "distribution": "zipf"},
+		{"name": "total", "type": "float", "min": 5, "max": 250}
+	]}
+]}`
+
+func writeSpec(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "spec.json")
+	require.NoError(t, os.WriteFile(path, []byte(testSpec), 0o644))
+	return path
+}
+
+func TestRun_NDJSON(t *testing.T) {
+	var stdout bytes.Buffer
+	err := run(context.Background(), options{spec: writeSpec(t), seed: 1, scale: 0.5, out: "-"}, &stdout, &bytes.Buffer{})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	require.Len(t, lines, 25)
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &doc))
+	assert.Equal(t, "customers-000001", doc["uuid"])
+}
+
+func TestRun_Load(t *testing.T) {
+	var puts atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			puts.Add(1)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), options{spec: writeSpec(t), seed: 1, scale: 1, endpoint: server.URL, workers: 4}, &stdout, &stderr)
+	require.NoError(t, err)
+	assert.Equal(t, int64(50), puts.Load())
+	assert.Contains(t, stdout.String(), "loaded 50 documents")
+	assert.Contains(t, stderr.String(), "50/50 documents")
+}
+
+func TestRun_InvalidSpec(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"collections": [{"model": "document", "name": "orders", "fields": [{"name": "c", "type": "ref", "ref": "users"}]}]}`), 0o644))
+	err := run(context.Background(), options{spec: path, scale: 1, out: "-"}, &bytes.Buffer{}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, `ref "users"`)
+}
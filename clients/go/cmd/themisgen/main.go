@@ -0,0 +1,170 @@
+// Command themisgen generates Go code from a ThemisDB schema file.
+//
+// For every enum field annotated in the schema it emits a string type with one
+// constant per allowed value, and a RegisterEnums function that registers the
+// enums with a client for validation on write:
+//
+//	themisgen -schema schema.json -package models -out enums_gen.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// Schema is the themisgen input file
+type Schema struct {
+	Collections []Collection `json:"collections"`
+}
+
+// Collection describes a collection and its annotated fields
+type Collection struct {
+	Model string `json:"model"`
+	Name  string `json:"name"`
+	Enums []Enum `json:"enums"`
+}
+
+// Enum annotates a field with its allowed values
+type Enum struct {
+	Field  string   `json:"field"`
+	Type   string   `json:"type"`
+	Values []string `json:"values"`
+}
+
+func main() {
+	schemaPath := flag.String("schema", "schema.json", "path to the schema file")
+	pkg := flag.String("package", "models", "package name of the generated file")
+	out := flag.String("out", "", "output file (default: stdout)")
+	flag.Parse()
+
+	if err := run(*schemaPath, *pkg, *out); err != nil {
+		fmt.Fprintln(os.Stderr, "themisgen:", err)
+		os.Exit(1)
+	}
+}
+
+// run reads the schema, generates the code, and writes it to out
+func run(schemaPath, pkg, out string) error {
+	data, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return fmt.Errorf("failed to read schema: %w", err)
+	}
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return fmt.Errorf("failed to parse schema: %w", err)
+	}
+
+	code, err := generate(&schema, pkg)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(out, code, 0o644)
+}
+
+// enumType is the template view of an enum
+type enumType struct {
+	Model      string
+	Collection string
+	Field      string
+	Name       string
+	Constants  []enumConstant
+}
+
+// enumConstant is the template view of a single enum value
+type enumConstant struct {
+	Name  string
+	Value string
+}
+
+var codeTemplate = template.Must(template.New("enums").Parse(`// Code generated by themisgen. DO NOT EDIT.
+
+package {{.Package}}
+
+import themisdb "github.com/makr-code/ThemisDB/clients/go"
+{{range .Enums}}
+// {{.Name}} is the enum of {{.Model}}/{{.Collection}}.{{.Field}}
+type {{.Name}} string
+
+const (
+{{- $type := .Name}}
+{{- range .Constants}}
+	{{.Name}} {{$type}} = {{printf "%q" .Value}}
+{{- end}}
+)
+
+// Valid reports whether v is a declared {{.Name}} value
+func (v {{.Name}}) Valid() bool {
+	switch v {
+	case {{range $i, $c := .Constants}}{{if $i}}, {{end}}{{$c.Name}}{{end}}:
+		return true
+	}
+	return false
+}
+{{end}}
+// RegisterEnums registers all schema enums with the client for validation on write
+func RegisterEnums(c *themisdb.Client) {
+{{- range .Enums}}
+	c.RegisterEnum({{printf "%q" .Model}}, {{printf "%q" .Collection}}, {{printf "%q" .Field}}{{range .Constants}}, string({{.Name}}){{end}})
+{{- end}}
+}
+`))
+
+// generate renders the Go source for all enums of the schema
+func generate(schema *Schema, pkg string) ([]byte, error) {
+	var enums []enumType
+	for _, coll := range schema.Collections {
+		for _, e := range coll.Enums {
+			if len(e.Values) == 0 {
+				return nil, fmt.Errorf("enum %s.%s has no values", coll.Name, e.Field)
+			}
+			name := e.Type
+			if name == "" {
+				name = identifier(coll.Name) + identifier(e.Field)
+			}
+			et := enumType{Model: coll.Model, Collection: coll.Name, Field: e.Field, Name: name}
+			for _, v := range e.Values {
+				et.Constants = append(et.Constants, enumConstant{Name: name + identifier(v), Value: v})
+			}
+			enums = append(enums, et)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := codeTemplate.Execute(&buf, map[string]interface{}{"Package": pkg, "Enums": enums}); err != nil {
+		return nil, fmt.Errorf("failed to render code: %w", err)
+	}
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return code, nil
+}
+
+// identifier converts a field name or enum value such as "in_transit" into "InTransit"
+func identifier(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
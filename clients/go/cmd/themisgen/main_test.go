@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	schema := &Schema{Collections: []Collection{{
+		Model: "relational",
+		Name:  "orders",
+		Enums: []Enum{
+			{Field: "status", Type: "OrderStatus", Values: []string{"pending", "in_transit"}},
+			{Field: "shipping.carrier", Values: []string{"dhl"}},
+		},
+	}}}
+
+	code, err := generate(schema, "models")
+	require.NoError(t, err)
+	src := string(code)
+
+	assert.True(t, strings.HasPrefix(src, "// Code generated by themisgen. DO NOT EDIT."))
+	assert.Contains(t, src, "package models")
+	assert.Contains(t, src, `OrderStatusInTransit OrderStatus = "in_transit"`)
+	assert.Contains(t, src, `OrdersShippingCarrierDhl OrdersShippingCarrier = "dhl"`)
+	assert.Contains(t, src, `c.RegisterEnum("relational", "orders", "status", string(OrderStatusPending), string(OrderStatusInTransit))`)
+}
+
+func TestGenerate_EmptyEnum(t *testing.T) {
+	_, err := generate(&Schema{Collections: []Collection{{Name: "orders", Enums: []Enum{{Field: "status"}}}}}, "models")
+	assert.Error(t, err)
+}
+
+func TestIdentifier(t *testing.T) {
+	assert.Equal(t, "InTransit", identifier("in_transit"))
+	assert.Equal(t, "ShippingCarrier", identifier("shipping.carrier"))
+	assert.Equal(t, "A1", identifier("a-1"))
+}
@@ -0,0 +1,61 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Codec encodes request bodies and decodes response bodies. The server speaks JSON
+// over both transports, so a Codec must produce and accept JSON; it replaces the
+// implementation, e.g. by jsoniter or by the generated code of easyjson, which
+// decodes large results with fewer allocations than encoding/json.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec is the default Codec, based on encoding/json
+type JSONCodec struct{}
+
+// Marshal encodes v with json.Marshal
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes data into v with json.Unmarshal
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// decode decodes data into result with the codec of the client. A *json.RawMessage
+// receives a copy of data without being decoded.
+func (c *Client) decode(data []byte, result interface{}) error {
+	if raw, ok := result.(*json.RawMessage); ok {
+		*raw = append((*raw)[:0], data...)
+		return nil
+	}
+	if c.codec == nil {
+		return json.Unmarshal(data, result)
+	}
+	return c.codec.Unmarshal(data, result)
+}
+
+// GetRaw returns an entity as the JSON document sent by the server, without decoding
+// it, e.g. to pass it on to another service or to decode it later
+func (c *Client) GetRaw(ctx context.Context, model, collection, uuid string) (json.RawMessage, error) {
+	var doc json.RawMessage
+	if err := c.Get(ctx, model, collection, uuid, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// QueryRaw executes an AQL query and returns its data as sent by the server, usually
+// a JSON array of the result rows, without decoding it
+func (c *Client) QueryRaw(ctx context.Context, aql string, opts *QueryOptions) (json.RawMessage, error) {
+	var data json.RawMessage
+	if err := c.QueryWithOptions(ctx, aql, opts, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
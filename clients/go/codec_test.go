@@ -0,0 +1,100 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingCodec is a JSONCodec that counts its calls
+type countingCodec struct {
+	JSONCodec
+	marshals, unmarshals atomic.Int32
+}
+
+func (c *countingCodec) Marshal(v interface{}) ([]byte, error) {
+	c.marshals.Add(1)
+	return c.JSONCodec.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v interface{}) error {
+	c.unmarshals.Add(1)
+	return c.JSONCodec.Unmarshal(data, v)
+}
+
+func TestClient_Codec(t *testing.T) {
+	base := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/query" {
+			w.Write([]byte(`{"data": [{"total": 1}, {"total": 2}], "execution_time_ms": 3}`))
+			return
+		}
+		w.Write([]byte(`{"total": 1}`))
+	})
+	codec := &countingCodec{}
+	client := NewClient(Config{Endpoints: base.endpoints, Codec: codec})
+	defer client.Close()
+	ctx := context.Background()
+
+	require.NoError(t, client.Namespace("tenant-a").Put(ctx, "relational", "orders", "o1", map[string]int{"total": 1}))
+	assert.Equal(t, int32(1), codec.marshals.Load())
+
+	var order map[string]int
+	require.NoError(t, client.Get(ctx, "relational", "orders", "o1", &order))
+	assert.Equal(t, 1, order["total"])
+	assert.Equal(t, int32(1), codec.unmarshals.Load())
+
+	// the rows are decoded once, together with the envelope
+	var rows []map[string]int
+	meta, err := client.QueryWithMeta(ctx, "FOR o IN orders RETURN o", nil, &rows)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]int{{"total": 1}, {"total": 2}}, rows)
+	assert.Equal(t, 2, meta.Returned)
+	assert.Equal(t, int32(3), codec.unmarshals.Load())
+}
+
+func TestClient_GetRaw(t *testing.T) {
+	var gets atomic.Int32
+	base := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/query":
+			w.Write([]byte(`{"data": [{"id": 12345678901234567890}]}`))
+		case strings.HasSuffix(r.URL.Path, "/missing"):
+			http.Error(w, `{"error": "not found"}`, http.StatusNotFound)
+		default:
+			gets.Add(1)
+			w.Write([]byte(`{"id": 12345678901234567890, "name": "Ada"}`))
+		}
+	})
+	client := NewClient(Config{Endpoints: base.endpoints, Cache: &ResponseCacheOptions{TTL: time.Minute}})
+	defer client.Close()
+	ctx := context.Background()
+
+	doc, err := client.GetRaw(ctx, "document", "users", "u1")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id": 12345678901234567890, "name": "Ada"}`, string(doc))
+	doc[0] = 'x'
+	cached, err := client.GetRaw(ctx, "document", "users", "u1")
+	require.NoError(t, err)
+	assert.Equal(t, `{"id": 12345678901234567890, "name": "Ada"}`, string(cached), "the cache keeps its own copy")
+	assert.Equal(t, int32(1), gets.Load())
+
+	_, err = client.GetRaw(ctx, "document", "users", "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	data, err := client.QueryRaw(ctx, "FOR u IN users RETURN u", nil)
+	require.NoError(t, err)
+	assert.Equal(t, `[{"id": 12345678901234567890}]`, string(data), "numbers keep their precision")
+}
+
+func TestQueryResult_Rows(t *testing.T) {
+	for data, rows := range map[string]int{"": 0, "null": 0, "[]": 0, `[1, {"a": 2}]`: 2, `{"a": 1}`: 1} {
+		assert.Equal(t, rows, (&QueryResult{Data: json.RawMessage(data)}).rows(), data)
+	}
+}
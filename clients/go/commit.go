@@ -0,0 +1,76 @@
+package themisdb
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// CommitHandle is the pending outcome of Transaction.CommitAsync
+type CommitHandle struct {
+	done chan struct{}
+	err  error
+}
+
+// Done is closed once the commit has completed
+func (h *CommitHandle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Wait blocks until the commit has completed and returns its error, or returns
+// ctx.Err() if ctx is done first; the commit itself continues in that case
+func (h *CommitHandle) Wait(ctx context.Context) error {
+	select {
+	case <-h.done:
+		return h.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WaitWithin is Wait bounded by d. It returns a *TimeoutError matching ErrTimeout if
+// the commit did not complete within d; the commit itself continues in that case.
+func (h *CommitHandle) WaitWithin(ctx context.Context, d time.Duration) error {
+	return within(ctx, "wait for commit", d, h.Wait)
+}
+
+// CommitAsync starts committing the transaction and returns without waiting for the
+// server, so a pipeline can build the next transaction while this one commits. The
+// commit includes every operation issued before CommitAsync; operations issued later
+// wait for the outcome and fail with ErrTransactionNotActive once it succeeded. As
+// with Commit, a failed commit leaves the transaction active for Rollback. ctx bounds
+// the commit request, so it must outlive the call. Wait on the handle before external
+// side effects that depend on the commit being durable:
+//
+//	pending := tx.CommitAsync(ctx)
+//	next, err := client.BeginTransaction(ctx, nil) // build the next batch meanwhile
+//	...
+//	if err := pending.Wait(ctx); err != nil {
+//	    return err
+//	}
+//	notifyDownstream()
+func (tx *Transaction) CommitAsync(ctx context.Context) *CommitHandle {
+	h := &CommitHandle{done: make(chan struct{})}
+	tx.mu.Lock()
+	go func() {
+		defer close(h.done)
+		defer tx.mu.Unlock()
+		h.err = tx.commitLocked(ctx)
+	}()
+	return h
+}
+
+// WaitCommits waits for all handles and returns the errors of failed commits joined,
+// or ctx.Err() if ctx is done first
+func WaitCommits(ctx context.Context, handles ...*CommitHandle) error {
+	var errs []error
+	for _, h := range handles {
+		if err := h.Wait(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
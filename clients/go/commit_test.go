@@ -0,0 +1,87 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransaction_CommitAsync(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	begun := 0
+	release := make(chan struct{})
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			TransactionID string `json:"transaction_id"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/transaction/begin":
+			mu.Lock()
+			begun++
+			fmt.Fprintf(w, `{"transaction_id":"tx-%d"}`, begun)
+			mu.Unlock()
+		case "/transaction/commit":
+			if body.TransactionID == "tx-1" {
+				<-release
+			}
+			if body.TransactionID == "tx-2" {
+				http.Error(w, "write conflict", http.StatusConflict)
+				return
+			}
+			mu.Lock()
+			events = append(events, "commit "+body.TransactionID)
+			mu.Unlock()
+		default:
+			mu.Lock()
+			events = append(events, r.Method+" "+r.Header.Get("X-Transaction-Id"))
+			mu.Unlock()
+		}
+	})
+	ctx := context.Background()
+
+	tx1, err := client.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx1.Put(ctx, "relational", "users", "u1", map[string]int{"n": 1}))
+	pending := tx1.CommitAsync(ctx)
+
+	// The next transaction is built while the first one commits
+	tx2, err := client.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx2.Put(ctx, "relational", "users", "u2", map[string]int{"n": 2}))
+	select {
+	case <-pending.Done():
+		t.Fatal("commit completed before the server answered")
+	default:
+	}
+	late := make(chan error)
+	go func() { late <- tx1.Put(ctx, "relational", "users", "u3", nil) }()
+
+	shortCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, pending.Wait(shortCtx), context.DeadlineExceeded)
+	err = pending.WaitWithin(ctx, 10*time.Millisecond)
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.EqualError(t, err, "wait for commit timed out after 10ms")
+
+	close(release)
+	require.NoError(t, pending.Wait(ctx))
+	assert.ErrorIs(t, <-late, ErrTransactionNotActive, "operations after CommitAsync wait for the outcome")
+	assert.False(t, tx1.IsActive())
+	assert.Equal(t, []string{"PUT tx-1", "PUT tx-2", "commit tx-1"}, events)
+
+	// A failed commit leaves the transaction active for Rollback
+	failed := tx2.CommitAsync(ctx)
+	err = WaitCommits(ctx, pending, failed)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.True(t, tx2.IsActive())
+	require.NoError(t, tx2.Rollback(ctx))
+}
@@ -0,0 +1,197 @@
+package themisdb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Content encodings for CompressionOptions.Algorithm
+const (
+	// CompressionGzip is built in
+	CompressionGzip = "gzip"
+	// CompressionZstd requires a Compressor registered with RegisterCompressor, e.g.
+	// one wrapping github.com/klauspost/compress/zstd
+	CompressionZstd = "zstd"
+)
+
+// defaultCompressionMinSize is the smallest request body compressed by default
+const defaultCompressionMinSize = 1024
+
+// Compressor implements a Content-Encoding
+type Compressor interface {
+	// NewWriter returns a writer that compresses to w
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	// NewReader returns a reader that decompresses r
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// CompressionOptions configures compression of HTTP request and response bodies
+type CompressionOptions struct {
+	// Algorithm is the Content-Encoding of compressed request bodies (default: gzip)
+	Algorithm string
+	// MinSize is the smallest request body that is compressed (default: 1 KiB)
+	MinSize int
+}
+
+// gzipCompressor is the built-in gzip Compressor
+type gzipCompressor struct{}
+
+func (gzipCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil }
+func (gzipCompressor) NewReader(r io.Reader) (io.ReadCloser, error)  { return gzip.NewReader(r) }
+
+// compressorRegistry holds the compressors registered with RegisterCompressor
+var compressorRegistry = struct {
+	mu          sync.RWMutex
+	compressors map[string]Compressor
+}{compressors: map[string]Compressor{CompressionGzip: gzipCompressor{}}}
+
+// RegisterCompressor makes a Content-Encoding available to CompressionOptions and to
+// response decoding, typically from an init function. It panics if name is registered
+// twice or c is nil.
+//
+//	themisdb.RegisterCompressor(themisdb.CompressionZstd, zstdCompressor{})
+func RegisterCompressor(name string, c Compressor) {
+	compressorRegistry.mu.Lock()
+	defer compressorRegistry.mu.Unlock()
+
+	if c == nil {
+		panic("themisdb: RegisterCompressor compressor is nil")
+	}
+	if _, dup := compressorRegistry.compressors[name]; dup {
+		panic("themisdb: RegisterCompressor called twice for " + name)
+	}
+	compressorRegistry.compressors[name] = c
+}
+
+// lookupCompressor returns the compressor registered under name
+func lookupCompressor(name string) (Compressor, bool) {
+	compressorRegistry.mu.RLock()
+	defer compressorRegistry.mu.RUnlock()
+	c, ok := compressorRegistry.compressors[name]
+	return c, ok
+}
+
+// compression is the compression setup of an HTTP transport
+type compression struct {
+	algorithm string
+	minSize   int
+	// negotiated replaces algorithm once restrict found the server does not accept
+	// it; an empty encoding turns compression off
+	negotiated atomic.Pointer[string]
+}
+
+// newCompression returns the compression setup for opts, nil if opts is nil
+func newCompression(opts *CompressionOptions) *compression {
+	if opts == nil {
+		return nil
+	}
+	c := &compression{algorithm: opts.Algorithm, minSize: opts.MinSize}
+	if c.algorithm == "" {
+		c.algorithm = CompressionGzip
+	}
+	if c.minSize <= 0 {
+		c.minSize = defaultCompressionMinSize
+	}
+	return c
+}
+
+// current returns the encoding of compressed request bodies, empty if none
+func (c *compression) current() string {
+	if negotiated := c.negotiated.Load(); negotiated != nil {
+		return *negotiated
+	}
+	return c.algorithm
+}
+
+// restrict falls back to gzip, or else to uncompressed request bodies, if the server
+// does not accept the configured encoding
+func (c *compression) restrict(accepted []string) {
+	algorithm := ""
+	for _, name := range accepted {
+		if name == c.algorithm {
+			algorithm = c.algorithm
+			break
+		}
+		if name == CompressionGzip {
+			algorithm = CompressionGzip
+		}
+	}
+	c.negotiated.Store(&algorithm)
+}
+
+// acceptEncoding lists the registered encodings, the preferred one first
+func (c *compression) acceptEncoding() string {
+	preferred := c.current()
+	if preferred == "" {
+		preferred = c.algorithm
+	}
+	compressorRegistry.mu.RLock()
+	names := make([]string, 0, len(compressorRegistry.compressors))
+	for name := range compressorRegistry.compressors {
+		if name != preferred {
+			names = append(names, name)
+		}
+	}
+	compressorRegistry.mu.RUnlock()
+	sort.Strings(names)
+	return strings.Join(append([]string{preferred}, names...), ", ")
+}
+
+// encode compresses a request body of at least minSize bytes. It returns the body
+// unchanged, with an empty encoding, if compression does not make it smaller.
+func (c *compression) encode(body []byte) ([]byte, string, error) {
+	algorithm := c.current()
+	if len(body) < c.minSize || algorithm == "" {
+		return body, "", nil
+	}
+	compressor, ok := lookupCompressor(algorithm)
+	if !ok {
+		return nil, "", fmt.Errorf("no compressor registered for %q", algorithm)
+	}
+	var buf bytes.Buffer
+	w, err := compressor.NewWriter(&buf)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to compress request: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, "", fmt.Errorf("failed to compress request: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to compress request: %w", err)
+	}
+	if buf.Len() >= len(body) {
+		return body, "", nil
+	}
+	return buf.Bytes(), algorithm, nil
+}
+
+// decodeBody decompresses a response body according to its Content-Encoding and removes
+// the encoding headers
+func decodeBody(header http.Header, body io.Reader) (io.ReadCloser, error) {
+	encoding := header.Get("Content-Encoding")
+	if encoding == "" || encoding == "identity" {
+		return io.NopCloser(body), nil
+	}
+	compressor, ok := lookupCompressor(encoding)
+	if !ok {
+		return nil, fmt.Errorf("unsupported response Content-Encoding %q", encoding)
+	}
+	r, err := compressor.NewReader(body)
+	if err == io.EOF {
+		// HEAD and 204 responses may declare an encoding without a body
+		r, err = io.NopCloser(bytes.NewReader(nil)), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress response: %w", err)
+	}
+	header.Del("Content-Encoding")
+	header.Del("Content-Length")
+	return r, nil
+}
@@ -0,0 +1,110 @@
+package themisdb
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flateCompressor registers as the test-only "x-flate" encoding
+type flateCompressor struct{}
+
+func (flateCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return flate.NewWriter(w, flate.BestSpeed)
+}
+func (flateCompressor) NewReader(r io.Reader) (io.ReadCloser, error) { return flate.NewReader(r), nil }
+
+var registerFlate sync.Once
+
+// compressingServer stores the last request and gzips responses when the client accepts it
+type compressingServer struct {
+	mu             sync.Mutex
+	encodings      []string
+	acceptEncoding string
+	body           []byte
+}
+
+func (s *compressingServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.encodings = append(s.encodings, r.Header.Get("Content-Encoding"))
+	s.acceptEncoding = r.Header.Get("Accept-Encoding")
+
+	var body io.Reader = r.Body
+	switch r.Header.Get("Content-Encoding") {
+	case "gzip":
+		body, _ = gzip.NewReader(r.Body)
+	case "x-flate":
+		body = flate.NewReader(r.Body)
+	}
+	s.body, _ = io.ReadAll(body)
+
+	if r.Method != "GET" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	doc := `{"notes":"` + strings.Repeat("compress me ", 500) + `"}`
+	if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		io.WriteString(w, doc)
+		return
+	}
+	w.Header().Set("Content-Encoding", "gzip")
+	gz := gzip.NewWriter(w)
+	io.WriteString(gz, doc)
+	gz.Close()
+}
+
+func TestClient_Compression(t *testing.T) {
+	server := &compressingServer{}
+	ts := httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+	defer ts.Close()
+	client := NewClient(Config{Endpoints: []string{ts.URL}, Compression: &CompressionOptions{}})
+	ctx := context.Background()
+
+	large := map[string]string{"notes": strings.Repeat("bulk ingest ", 200)}
+	require.NoError(t, client.Put(ctx, "document", "notes", "n1", large))
+	require.NoError(t, client.Put(ctx, "document", "notes", "n2", map[string]string{"notes": "short"}))
+	assert.Equal(t, []string{"gzip", ""}, server.encodings, "bodies under MinSize are sent uncompressed")
+
+	var got map[string]string
+	require.NoError(t, client.Get(ctx, "document", "notes", "n1", &got))
+	assert.Equal(t, strings.Repeat("compress me ", 500), got["notes"])
+	assert.True(t, strings.HasPrefix(server.acceptEncoding, "gzip"), server.acceptEncoding)
+
+	var sent map[string]string
+	require.NoError(t, client.Put(ctx, "document", "notes", "n1", large))
+	require.NoError(t, json.Unmarshal(server.body, &sent))
+	assert.Equal(t, large, sent)
+}
+
+func TestClient_CompressionRegistered(t *testing.T) {
+	registerFlate.Do(func() { RegisterCompressor("x-flate", flateCompressor{}) })
+	assert.Panics(t, func() { RegisterCompressor("x-flate", flateCompressor{}) })
+	assert.Panics(t, func() { RegisterCompressor("x-nil", nil) })
+
+	server := &compressingServer{}
+	ts := httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+	defer ts.Close()
+	ctx := context.Background()
+
+	client := NewClient(Config{Endpoints: []string{ts.URL}, Compression: &CompressionOptions{Algorithm: "x-flate", MinSize: 10}})
+	require.NoError(t, client.Put(ctx, "document", "notes", "n1", map[string]string{"notes": strings.Repeat("a", 100)}))
+	assert.Equal(t, []string{"x-flate"}, server.encodings)
+	assert.True(t, strings.HasPrefix(server.acceptEncoding, "x-flate, "), server.acceptEncoding)
+	assert.Contains(t, server.acceptEncoding, "gzip")
+	assert.JSONEq(t, `{"notes":"`+strings.Repeat("a", 100)+`"}`, string(server.body))
+
+	client = NewClient(Config{Endpoints: []string{ts.URL}, Compression: &CompressionOptions{Algorithm: CompressionZstd}})
+	err := client.Put(ctx, "document", "notes", "n1", map[string]string{"notes": strings.Repeat("a", 2000)})
+	assert.ErrorContains(t, err, `no compressor registered for "zstd"`)
+}
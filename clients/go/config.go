@@ -0,0 +1,230 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// configAuditCollection records every change made through Admin.SetConfig
+const configAuditCollection = "_config_audit"
+
+// LogLevel is a server log level
+type LogLevel string
+
+// Server log levels
+const (
+	LogLevelTrace LogLevel = "trace"
+	LogLevelDebug LogLevel = "debug"
+	LogLevelInfo  LogLevel = "info"
+	LogLevelWarn  LogLevel = "warn"
+	LogLevelError LogLevel = "error"
+)
+
+// ServerFeature is a runtime-toggleable server feature
+type ServerFeature string
+
+// Server features toggled with SetFeature
+const (
+	FeatureSemanticCache ServerFeature = "semantic_cache"
+	FeatureLLMStore      ServerFeature = "llm_store"
+	FeatureCDC           ServerFeature = "cdc"
+	FeatureTimeseries    ServerFeature = "timeseries"
+)
+
+// ServerConfig is the runtime configuration of a server
+type ServerConfig struct {
+	Server struct {
+		Port             int `json:"port"`
+		Threads          int `json:"threads"`
+		RequestTimeoutMs int `json:"request_timeout_ms"`
+	} `json:"server"`
+	Features map[string]bool `json:"features"`
+	// Storage holds the storage engine settings, e.g. block_cache_size_mb
+	Storage map[string]interface{} `json:"rocksdb"`
+	// Raw is the complete configuration document
+	Raw map[string]interface{} `json:"-"`
+}
+
+// Value returns the parameter with a dotted key such as "rocksdb.block_cache_size_mb"
+func (sc *ServerConfig) Value(key string) (interface{}, bool) {
+	var node interface{} = sc.Raw
+	for _, part := range strings.Split(key, ".") {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if node, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+// ConfigChange is a typed change of a runtime parameter, created with SetLogLevel,
+// SetLogFormat, SetRequestTimeout, SetFeature, or SetCDCRetention
+type ConfigChange struct {
+	// Key is the dotted key of the parameter as read by GetConfig
+	Key string
+	// Value is the new value in its wire representation
+	Value interface{}
+
+	writeKey string
+	err      error
+}
+
+// SetLogLevel changes the server log level
+func SetLogLevel(level LogLevel) ConfigChange {
+	change := ConfigChange{Key: "logging.level", Value: string(level)}
+	switch level {
+	case LogLevelTrace, LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError:
+	default:
+		change.err = &ValidationError{Field: "log level", Value: string(level), Reason: "must be trace, debug, info, warn, or error"}
+	}
+	return change
+}
+
+// SetLogFormat changes the server log format to "text" or "json"
+func SetLogFormat(format string) ConfigChange {
+	change := ConfigChange{Key: "logging.format", Value: format}
+	if format != "text" && format != "json" {
+		change.err = &ValidationError{Field: "log format", Value: format, Reason: "must be text or json"}
+	}
+	return change
+}
+
+// SetRequestTimeout changes the server request timeout, between 1s and 5m
+func SetRequestTimeout(d time.Duration) ConfigChange {
+	change := ConfigChange{Key: "server.request_timeout_ms", Value: d.Milliseconds(), writeKey: "request_timeout_ms"}
+	if d < time.Second || d > 5*time.Minute {
+		change.err = &ValidationError{Field: "request timeout", Value: d.String(), Reason: "must be between 1s and 5m"}
+	}
+	return change
+}
+
+// SetFeature enables or disables a server feature
+func SetFeature(feature ServerFeature, enabled bool) ConfigChange {
+	return ConfigChange{Key: "features." + string(feature), Value: enabled}
+}
+
+// SetCDCRetention changes how long changefeed events are retained, between 1h and 365 days
+func SetCDCRetention(d time.Duration) ConfigChange {
+	hours := int64(d / time.Hour)
+	change := ConfigChange{Key: "cdc_retention_hours", Value: hours}
+	if hours < 1 || hours > 8760 {
+		change.err = &ValidationError{Field: "cdc retention", Value: d.String(), Reason: "must be between 1h and 8760h"}
+	}
+	return change
+}
+
+// ConfigAuditEntry records a parameter change made through SetConfig
+type ConfigAuditEntry struct {
+	ID     string      `json:"id"`
+	Time   time.Time   `json:"time"`
+	Key    string      `json:"key"`
+	Old    interface{} `json:"old"`
+	New    interface{} `json:"new"`
+	Reason string      `json:"reason"`
+}
+
+// GetConfig returns the runtime configuration of the server
+func (a *Admin) GetConfig(ctx context.Context) (*ServerConfig, error) {
+	resp, err := a.client.send(ctx, "GET", "/config", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+	var config ServerConfig
+	if err := json.Unmarshal(resp.Body, &config); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
+	}
+	if err := json.Unmarshal(resp.Body, &config.Raw); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
+	}
+	return &config, nil
+}
+
+// SetConfig applies changes to the server's runtime configuration in a single
+// request and records each change with its previous value and reason in the
+// _config_audit collection. It returns the updated configuration.
+func (a *Admin) SetConfig(ctx context.Context, reason string, changes ...ConfigChange) (*ServerConfig, error) {
+	body := map[string]interface{}{}
+	for _, change := range changes {
+		if change.err != nil {
+			return nil, change.err
+		}
+		key := change.Key
+		if change.writeKey != "" {
+			key = change.writeKey
+		}
+		setDotted(body, key, change.Value)
+	}
+
+	before, err := a.GetConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.client.send(ctx, "POST", "/config", body, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set config: %w", err)
+	}
+	after := &ServerConfig{}
+	if err := json.Unmarshal(resp.Body, after); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
+	}
+	if err := json.Unmarshal(resp.Body, &after.Raw); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
+	}
+
+	now := time.Now().UTC()
+	for i, change := range changes {
+		old, _ := before.Value(change.Key)
+		entry := ConfigAuditEntry{
+			ID:     fmt.Sprintf("%020d-%03d-%s", now.UnixNano(), i, randomToken(4)),
+			Time:   now,
+			Key:    change.Key,
+			Old:    old,
+			New:    change.Value,
+			Reason: reason,
+		}
+		if err := a.client.Create(ctx, ModelRelational, configAuditCollection, entry.ID, entry); err != nil {
+			return after, fmt.Errorf("config changed but audit failed: %w", err)
+		}
+	}
+	return after, nil
+}
+
+// ConfigHistory returns the most recent configuration changes, oldest first
+func (a *Admin) ConfigHistory(ctx context.Context, limit int) ([]ConfigAuditEntry, error) {
+	var history []ConfigAuditEntry
+	it := a.client.Scan(ctx, ModelRelational, configAuditCollection, ScanOptions{BatchSize: 500})
+	for it.Next() {
+		var entry ConfigAuditEntry
+		if err := it.Decode(&entry); err != nil {
+			return nil, err
+		}
+		history = append(history, entry)
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read config history: %w", err)
+	}
+	if limit > 0 && len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	return history, nil
+}
+
+// setDotted sets a dotted key in a nested JSON object
+func setDotted(m map[string]interface{}, key string, value interface{}) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := m[part].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			m[part] = child
+		}
+		m = child
+	}
+	m[parts[len(parts)-1]] = value
+}
@@ -0,0 +1,87 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdmin_Config(t *testing.T) {
+	store := &memoryStore{docs: map[string]map[string]interface{}{}, revisions: map[string]int{}}
+	config := map[string]interface{}{
+		"server":   map[string]interface{}{"port": 8080, "threads": 8, "request_timeout_ms": 30000},
+		"features": map[string]interface{}{"cdc": false},
+		"logging":  map[string]interface{}{"level": "info"},
+		"rocksdb":  map[string]interface{}{"block_cache_size_mb": 512},
+	}
+	var posted map[string]interface{}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/config" {
+			store.serveHTTP(w, r)
+			return
+		}
+		if r.Method == "POST" {
+			json.NewDecoder(r.Body).Decode(&posted)
+			if ms, ok := posted["request_timeout_ms"]; ok {
+				config["server"].(map[string]interface{})["request_timeout_ms"] = ms
+			}
+			if features, ok := posted["features"].(map[string]interface{}); ok {
+				for k, v := range features {
+					config["features"].(map[string]interface{})[k] = v
+				}
+			}
+			if logging, ok := posted["logging"].(map[string]interface{}); ok {
+				config["logging"] = logging
+			}
+		}
+		json.NewEncoder(w).Encode(config)
+	})
+	ctx := context.Background()
+	admin := client.Admin()
+
+	current, err := admin.GetConfig(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 30000, current.Server.RequestTimeoutMs)
+	value, ok := current.Value("rocksdb.block_cache_size_mb")
+	assert.True(t, ok)
+	assert.Equal(t, float64(512), value)
+
+	updated, err := admin.SetConfig(ctx, "incident 42", SetLogLevel(LogLevelDebug), SetRequestTimeout(time.Minute), SetFeature(FeatureCDC, true))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"logging":            map[string]interface{}{"level": "debug"},
+		"request_timeout_ms": float64(60000),
+		"features":           map[string]interface{}{"cdc": true},
+	}, posted)
+	assert.Equal(t, 60000, updated.Server.RequestTimeoutMs)
+	assert.True(t, updated.Features["cdc"])
+
+	history, err := admin.ConfigHistory(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "server.request_timeout_ms", history[0].Key)
+	assert.Equal(t, float64(30000), history[0].Old)
+	assert.Equal(t, float64(60000), history[0].New)
+	assert.Equal(t, "incident 42", history[1].Reason)
+	assert.Equal(t, false, history[1].Old)
+}
+
+func TestAdmin_SetConfigValidation(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("invalid change was sent")
+	})
+	admin := client.Admin()
+	ctx := context.Background()
+
+	_, err := admin.SetConfig(ctx, "", SetRequestTimeout(time.Hour))
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = admin.SetConfig(ctx, "", SetLogLevel("verbose"))
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = admin.SetConfig(ctx, "", SetCDCRetention(30*time.Minute))
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
@@ -0,0 +1,164 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Consistency is the consistency level of a read
+type Consistency string
+
+const (
+	// ConsistencyStrong reads the latest committed data from the primary (default)
+	ConsistencyStrong Consistency = "strong"
+	// ConsistencyBoundedStaleness reads from a replica that lags at most MaxStaleness
+	ConsistencyBoundedStaleness Consistency = "bounded_staleness"
+	// ConsistencyEventual reads from any replica
+	ConsistencyEventual Consistency = "eventual"
+)
+
+// Headers carrying the requested read consistency to the server
+const (
+	headerConsistency  = "X-Themis-Consistency"
+	headerMaxStaleness = "X-Themis-Max-Staleness"
+)
+
+// ReadOptions configures the consistency of a read. Non-strong reads are routed to
+// Config.Replicas when replicas are configured and fall back to the primary endpoint
+// if the replica is unreachable or rejects the read, e.g. because it lags too far behind.
+type ReadOptions struct {
+	// Consistency is the required consistency level (default: ConsistencyStrong)
+	Consistency Consistency
+	// MaxStaleness bounds replica lag for ConsistencyBoundedStaleness
+	MaxStaleness time.Duration
+	// AsOf reads the data as it was at a point in time within the server's history
+	// retention, without opening a transaction or deriving an AtSnapshot client
+	AsOf time.Time
+	// SnapshotID reads the data of a snapshot retained on the server; it excludes AsOf
+	SnapshotID string
+}
+
+// snapshot returns the snapshot selected by AsOf or SnapshotID, if any
+func (o *ReadOptions) snapshot() (SnapshotRef, bool) {
+	if o == nil || (o.AsOf.IsZero() && o.SnapshotID == "") {
+		return SnapshotRef{}, false
+	}
+	return SnapshotRef{ID: o.SnapshotID, Time: o.AsOf}, true
+}
+
+// validate checks the consistency level, staleness bound, and snapshot
+func (o *ReadOptions) validate() error {
+	if snapshot, ok := o.snapshot(); ok {
+		if err := snapshot.validate(); err != nil {
+			return err
+		}
+	}
+	switch o.Consistency {
+	case "", ConsistencyStrong, ConsistencyEventual:
+		return nil
+	case ConsistencyBoundedStaleness:
+		if o.MaxStaleness <= 0 {
+			return &ValidationError{Field: "max staleness", Value: o.MaxStaleness.String(), Reason: "must be positive for bounded staleness reads"}
+		}
+		return nil
+	}
+	return &ValidationError{Field: "consistency", Value: string(o.Consistency), Reason: "must be strong, bounded_staleness, or eventual"}
+}
+
+// withHeaders returns headers extended by the consistency and snapshot headers of o
+func (o *ReadOptions) withHeaders(headers map[string]string) map[string]string {
+	snapshot, historical := o.snapshot()
+	if o == nil || (o.Consistency == "" || o.Consistency == ConsistencyStrong) && !historical {
+		return headers
+	}
+	merged := make(map[string]string, len(headers)+2)
+	for key, value := range headers {
+		merged[key] = value
+	}
+	if o.Consistency != "" && o.Consistency != ConsistencyStrong {
+		merged[headerConsistency] = string(o.Consistency)
+	}
+	if o.Consistency == ConsistencyBoundedStaleness {
+		merged[headerMaxStaleness] = strconv.FormatInt(o.MaxStaleness.Milliseconds(), 10)
+	}
+	switch {
+	case snapshot.ID != "":
+		merged[headerSnapshot] = snapshot.ID
+	case historical:
+		merged[headerSnapshotTime] = snapshot.Time.UTC().Format(time.RFC3339Nano)
+	}
+	return merged
+}
+
+// checkSnapshot verifies that the server supports the snapshot selected by o, if any
+func (c *Client) checkSnapshot(ctx context.Context, o *ReadOptions) error {
+	if _, ok := o.snapshot(); !ok {
+		return nil
+	}
+	return c.requireFeature(ctx, FeatureSnapshots)
+}
+
+// GetWithOptions retrieves an entity by UUID with the given read consistency. With
+// AsOf or SnapshotID set, it reads the entity as it was at that point in time.
+func (c *Client) GetWithOptions(ctx context.Context, model, collection, uuid string, result interface{}, opts *ReadOptions) error {
+	if ctx, tx := c.contextTx(ctx); tx != nil {
+		return tx.Get(ctx, model, collection, uuid, result)
+	}
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return err
+	}
+	if opts != nil {
+		if err := opts.validate(); err != nil {
+			return err
+		}
+		if err := c.checkSnapshot(ctx, opts); err != nil {
+			return err
+		}
+	}
+	path := entityPath(model, collection, uuid)
+	return c.readRequest(ctx, "GET", path, nil, result, opts.withHeaders(nil))
+}
+
+// replicaRead reports whether req may be served by a replica
+func (c *Client) replicaRead(req *Request) bool {
+	if !req.Idempotent || req.Header["X-Transaction-Id"] != "" {
+		return false
+	}
+	c.mu.RLock()
+	replicas := len(c.replicas)
+	c.mu.RUnlock()
+	if replicas == 0 {
+		return false
+	}
+	switch Consistency(req.Header[headerConsistency]) {
+	case ConsistencyEventual, ConsistencyBoundedStaleness:
+		return true
+	}
+	return false
+}
+
+// doReplica sends req to the next replica in round-robin order and retries it on the
+// primary endpoint if the replica fails
+func (c *Client) doReplica(ctx context.Context, req *Request) (*Response, error) {
+	idx := atomic.AddUint32(&c.replicaIdx, 1)
+	c.mu.RLock()
+	if len(c.replicas) == 0 {
+		c.mu.RUnlock()
+		return c.sendTo(ctx, c.getEndpoint(), req)
+	}
+	replica := strings.TrimSuffix(c.replicas[int(idx)%len(c.replicas)], "/")
+	c.mu.RUnlock()
+
+	resp, err := c.sendTo(ctx, replica, req)
+	if err == nil && resp.StatusCode < http.StatusInternalServerError {
+		return resp, nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return c.sendTo(ctx, c.getEndpoint(), req)
+}
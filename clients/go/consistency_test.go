@@ -0,0 +1,78 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ReadConsistencyRouting(t *testing.T) {
+	var primaryHeaders, replicaHeaders []http.Header
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryHeaders = append(primaryHeaders, r.Header.Clone())
+		w.Write([]byte(`{"node":"primary","data":[]}`))
+	}))
+	defer primary.Close()
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		replicaHeaders = append(replicaHeaders, r.Header.Clone())
+		w.Write([]byte(`{"node":"replica","data":[]}`))
+	}))
+	defer replica.Close()
+
+	client := NewClient(Config{Endpoints: []string{primary.URL}, Replicas: []string{replica.URL}})
+	ctx := context.Background()
+
+	var result map[string]interface{}
+	require.NoError(t, client.Get(ctx, "relational", "users", "1", &result))
+	assert.Equal(t, "primary", result["node"])
+
+	require.NoError(t, client.GetWithOptions(ctx, "relational", "users", "1", &result, &ReadOptions{Consistency: ConsistencyEventual}))
+	assert.Equal(t, "replica", result["node"])
+	assert.Equal(t, "eventual", replicaHeaders[0].Get("X-Themis-Consistency"))
+
+	opts := &QueryOptions{Read: &ReadOptions{Consistency: ConsistencyBoundedStaleness, MaxStaleness: 5 * time.Second}}
+	var rows []interface{}
+	require.NoError(t, client.QueryWithOptions(ctx, "FOR u IN users RETURN u", opts, &rows))
+	require.Len(t, replicaHeaders, 2)
+	assert.Equal(t, "bounded_staleness", replicaHeaders[1].Get("X-Themis-Consistency"))
+	assert.Equal(t, "5000", replicaHeaders[1].Get("X-Themis-Max-Staleness"))
+
+	// transactional reads stay on the primary
+	tx := &Transaction{client: client, transactionID: "tx-1", active: true}
+	require.NoError(t, tx.QueryWithOptions(ctx, "FOR u IN users RETURN u", opts, &rows))
+	assert.Len(t, replicaHeaders, 2)
+	assert.Len(t, primaryHeaders, 2)
+}
+
+func TestClient_ReplicaFallback(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"node":"primary"}`))
+	}))
+	defer primary.Close()
+	lagging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer lagging.Close()
+
+	client := NewClient(Config{Endpoints: []string{primary.URL}, Replicas: []string{lagging.URL}})
+
+	var result map[string]string
+	opts := &ReadOptions{Consistency: ConsistencyBoundedStaleness, MaxStaleness: time.Second}
+	require.NoError(t, client.GetWithOptions(context.Background(), "relational", "users", "1", &result, opts))
+	assert.Equal(t, "primary", result["node"])
+}
+
+func TestReadOptions_Validate(t *testing.T) {
+	client := NewClient(Config{})
+	ctx := context.Background()
+
+	err := client.GetWithOptions(ctx, "relational", "users", "1", nil, &ReadOptions{Consistency: "linearizable"})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	err = client.GetWithOptions(ctx, "relational", "users", "1", nil, &ReadOptions{Consistency: ConsistencyBoundedStaleness})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
@@ -0,0 +1,102 @@
+package themisdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Consumer gives exactly-once effects to consumers of at-least-once message streams
+// such as Kafka or NATS. It records the ID of each processed message in the same
+// transaction as the writes made for it, so redelivered messages are skipped.
+type Consumer struct {
+	client     *Client
+	name       string
+	collection string
+}
+
+// processedMessage is the stored record of a processed message
+type processedMessage struct {
+	ProcessedAt int64 `json:"processed_at"`
+}
+
+// Consumer returns the idempotent consumer name. Processed message IDs are stored
+// in the collection "_processed_<name>".
+func (c *Client) Consumer(name string) *Consumer {
+	return &Consumer{client: c, name: name, collection: "_processed_" + name}
+}
+
+// ProcessOnce runs fn in a snapshot transaction that also records msgID as processed.
+// If msgID was processed before, fn is not called and processed is false. If fn fails,
+// the transaction is rolled back and the message can be processed again.
+func (c *Consumer) ProcessOnce(ctx context.Context, msgID string, fn func(ctx context.Context, tx *Transaction) error) (processed bool, err error) {
+	if err := validateName("consumer", c.name); err != nil {
+		return false, err
+	}
+	if err := validateKey("msgID", msgID); err != nil {
+		return false, err
+	}
+
+	tx, err := c.client.BeginTransaction(ctx, &TransactionOptions{IsolationLevel: Snapshot})
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if tx.IsActive() {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	err = tx.Create(ctx, ModelRelational, c.collection, msgID, processedMessage{ProcessedAt: time.Now().UnixMilli()})
+	if errors.Is(err, ErrAlreadyExists) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to record message %s: %w", msgID, err)
+	}
+
+	if err := fn(ctx, tx); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Processed reports whether msgID has been processed
+func (c *Consumer) Processed(ctx context.Context, msgID string) (bool, error) {
+	var record processedMessage
+	_, exists, err := c.client.getVersioned(ctx, c.collection, msgID, &record)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up message %s: %w", msgID, err)
+	}
+	return exists, nil
+}
+
+// Prune forgets message IDs processed before the given time, bounding the size of the
+// processed collection. Only prune IDs older than the longest possible redelivery delay.
+func (c *Consumer) Prune(ctx context.Context, before time.Time) (int, error) {
+	var expired []string
+	it := c.client.Scan(ctx, ModelRelational, c.collection, ScanOptions{BatchSize: 500})
+	for it.Next() {
+		var record processedMessage
+		if err := it.Decode(&record); err != nil {
+			return 0, err
+		}
+		if record.ProcessedAt < before.UnixMilli() {
+			expired = append(expired, it.UUID())
+		}
+	}
+	if err := it.Err(); err != nil {
+		return 0, fmt.Errorf("failed to prune %s: %w", c.name, err)
+	}
+
+	for i, id := range expired {
+		if err := c.client.Delete(ctx, ModelRelational, c.collection, id); err != nil {
+			return i, fmt.Errorf("failed to prune %s: %w", c.name, err)
+		}
+	}
+	return len(expired), nil
+}
@@ -0,0 +1,72 @@
+package themisdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumer_ProcessOnce(t *testing.T) {
+	client, store := newMemoryClient(t)
+	ctx := context.Background()
+	consumer := client.Consumer("orders")
+
+	credit := func(ctx context.Context, tx *Transaction) error {
+		return tx.Put(ctx, "relational", "ledger", "entry-1", map[string]int{"amount": 10})
+	}
+
+	processed, err := consumer.ProcessOnce(ctx, "msg-1", credit)
+	require.NoError(t, err)
+	assert.True(t, processed)
+
+	processed, err = consumer.ProcessOnce(ctx, "msg-1", func(ctx context.Context, tx *Transaction) error {
+		t.Error("redelivered message processed twice")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.False(t, processed)
+	assert.Equal(t, 1, store.commits)
+
+	done, err := consumer.Processed(ctx, "msg-1")
+	require.NoError(t, err)
+	assert.True(t, done)
+}
+
+func TestConsumer_ProcessOnceFailure(t *testing.T) {
+	client, store := newMemoryClient(t)
+	ctx := context.Background()
+	consumer := client.Consumer("orders")
+
+	errDownstream := errors.New("downstream unavailable")
+	_, err := consumer.ProcessOnce(ctx, "msg-2", func(ctx context.Context, tx *Transaction) error {
+		return errDownstream
+	})
+	assert.ErrorIs(t, err, errDownstream)
+	assert.Equal(t, 1, store.rollbacks)
+}
+
+func TestConsumer_Prune(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	ctx := context.Background()
+	consumer := client.Consumer("orders")
+
+	old := time.Now().Add(-48 * time.Hour).UnixMilli()
+	require.NoError(t, client.Put(ctx, ModelRelational, "_processed_orders", "old", processedMessage{ProcessedAt: old}))
+	_, err := consumer.ProcessOnce(ctx, "new", func(ctx context.Context, tx *Transaction) error { return nil })
+	require.NoError(t, err)
+
+	pruned, err := consumer.Prune(ctx, time.Now().Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, pruned)
+
+	done, err := consumer.Processed(ctx, "new")
+	require.NoError(t, err)
+	assert.True(t, done)
+	done, err = consumer.Processed(ctx, "old")
+	require.NoError(t, err)
+	assert.False(t, done)
+}
@@ -0,0 +1,88 @@
+package themisdb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// sequencesCollection stores the counters of the sequences returned by Client.Sequence
+const sequencesCollection = "_sequences"
+
+// Increment atomically adds delta to the numeric field of a document on the server and
+// returns the new value, without a read-modify-write round trip. A missing document or
+// field counts as zero, so the first increment creates it. field may be a dotted path
+// into nested objects. Increment is not retried: a failed request may or may not have
+// been applied.
+func (c *Client) Increment(ctx context.Context, model, collection, uuid, field string, delta int64) (int64, error) {
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return 0, err
+	}
+	if field == "" {
+		return 0, &ValidationError{Field: "field", Value: field, Reason: "must not be empty"}
+	}
+
+	body := map[string]interface{}{
+		"uuid":  uuid,
+		"field": field,
+		"delta": delta,
+	}
+	var response struct {
+		Value int64 `json:"value"`
+	}
+	err := c.request(ctx, "POST", joinPath("/api", model, collection)+"/_increment", body, &response, nil)
+	if c.cache != nil {
+		c.cache.invalidate(ctx, c, &Request{Path: entityPath(model, collection, uuid)})
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment %s of %s/%s/%s: %w", field, model, collection, uuid, err)
+	}
+	return response.Value, nil
+}
+
+// SequenceOptions holds sequence configuration
+type SequenceOptions struct {
+	// BlockSize is the number of values reserved per server round trip (default: 100)
+	BlockSize int64
+}
+
+// Sequence hands out unique, increasing int64 values starting at 1. Values are reserved
+// from the server in blocks with a single Increment and then handed out locally, so
+// most calls to Next need no round trip. Values of a block not used before the process
+// exits are skipped, and several processes sharing a sequence interleave their blocks,
+// so values are unique but neither gapless nor globally ordered.
+type Sequence struct {
+	client *Client
+	name   string
+	opts   SequenceOptions
+
+	mu   sync.Mutex
+	next int64
+	last int64
+}
+
+// Sequence returns the sequence name, stored in the collection _sequences
+func (c *Client) Sequence(name string, opts SequenceOptions) *Sequence {
+	if opts.BlockSize <= 0 {
+		opts.BlockSize = 100
+	}
+	return &Sequence{client: c, name: name, opts: opts}
+}
+
+// Next returns the next value of the sequence, reserving a new block when the current
+// one is used up
+func (s *Sequence) Next(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.next == 0 || s.next > s.last {
+		last, err := s.client.Increment(ctx, ModelRelational, sequencesCollection, s.name, "value", s.opts.BlockSize)
+		if err != nil {
+			return 0, fmt.Errorf("failed to reserve values of sequence %s: %w", s.name, err)
+		}
+		s.next, s.last = last-s.opts.BlockSize+1, last
+	}
+	value := s.next
+	s.next++
+	return value, nil
+}
@@ -0,0 +1,99 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Increment(t *testing.T) {
+	client, store := newMemoryClient(t)
+	ctx := context.Background()
+
+	value, err := client.Increment(ctx, "relational", "pages", "home", "views", 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), value, "a missing document counts as zero")
+	value, err = client.Increment(ctx, "relational", "pages", "home", "views", 41)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), value)
+	value, err = client.Increment(ctx, "relational", "pages", "home", "views", -2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(40), value)
+	assert.Equal(t, 40.0, store.docs["/api/relational/pages/home"]["views"])
+
+	_, err = client.Increment(ctx, "relational", "pages", "home", "", 1)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = client.Increment(ctx, "relational", "pages", "", "views", 1)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestClient_IncrementInvalidatesCache(t *testing.T) {
+	base, _ := newMemoryClient(t)
+	client := NewClient(Config{Endpoints: base.endpoints, Cache: &ResponseCacheOptions{TTL: time.Hour}})
+	defer client.Close()
+	ctx := context.Background()
+	require.NoError(t, client.Put(ctx, "relational", "pages", "home", map[string]int64{"views": 0}))
+
+	var page map[string]int64
+	require.NoError(t, client.Get(ctx, "relational", "pages", "home", &page))
+	assert.Equal(t, int64(0), page["views"])
+	_, err := client.Increment(ctx, "relational", "pages", "home", "views", 5)
+	require.NoError(t, err)
+	require.NoError(t, client.Get(ctx, "relational", "pages", "home", &page))
+	assert.Equal(t, int64(5), page["views"], "Increment invalidates the cached entity")
+}
+
+func TestSequence_Next(t *testing.T) {
+	var mu sync.Mutex
+	increments := 0
+	store := &memoryStore{docs: make(map[string]map[string]interface{}), revisions: make(map[string]int)}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/_increment") {
+			mu.Lock()
+			increments++
+			mu.Unlock()
+		}
+		store.serveHTTP(w, r)
+	})
+	ctx := context.Background()
+
+	seq := client.Sequence("orders", SequenceOptions{BlockSize: 10})
+	other := client.Sequence("orders", SequenceOptions{BlockSize: 10})
+	seen := make(map[int64]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s := seq
+			if i%2 == 1 {
+				s = other
+			}
+			for j := 0; j < 10; j++ {
+				value, err := s.Next(ctx)
+				assert.NoError(t, err)
+				mu.Lock()
+				assert.False(t, seen[value], "value %d handed out twice", value)
+				seen[value] = true
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Len(t, seen, 80)
+	assert.Equal(t, 8, increments, "values are reserved in blocks")
+	for value := int64(1); value <= 80; value++ {
+		assert.True(t, seen[value], "sequences sharing a name split the values without gaps while running")
+	}
+
+	first, err := client.Sequence("invoices", SequenceOptions{}).Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), first)
+	assert.Equal(t, 100.0, store.docs["/api/relational/_sequences/invoices"]["value"])
+}
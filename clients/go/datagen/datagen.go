@@ -0,0 +1,426 @@
+// Package datagen generates synthetic ThemisDB datasets for demos, benchmarks, and
+// load tests.
+//
+// A Spec describes the collections to generate, how many documents each holds, and
+// how every field is sampled. Fields can follow uniform, normal, or zipf
+// distributions, and ref fields point to documents of an earlier collection, so
+// relations such as orders referencing customers stay consistent. Generation is
+// deterministic for a given seed.
+//
+//	g, err := datagen.New(spec, 42)
+//	report, err := datagen.Load(ctx, client, g, nil)
+package datagen
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// FieldType is the kind of value generated for a field
+type FieldType string
+
+// Field types
+const (
+	// TypeSequence is the 1-based position of the document in its collection
+	TypeSequence FieldType = "sequence"
+	TypeInt      FieldType = "int"
+	TypeFloat    FieldType = "float"
+	// TypeBool is true with probability Rate
+	TypeBool FieldType = "bool"
+	// TypeString is a random lowercase string of Length characters
+	TypeString FieldType = "string"
+	// TypeText is a sentence of Words words
+	TypeText FieldType = "text"
+	// TypeName is a person's full name
+	TypeName FieldType = "name"
+	// TypeEmail is a unique e-mail address at example.com
+	TypeEmail FieldType = "email"
+	// TypeEnum is one of Values, picked according to Weights
+	TypeEnum FieldType = "enum"
+	// TypeTime is an RFC 3339 timestamp between From and To
+	TypeTime FieldType = "time"
+	// TypeUUID is a random version 4 UUID
+	TypeUUID FieldType = "uuid"
+	// TypeRef is the key of a document of the collection named by Ref
+	TypeRef FieldType = "ref"
+)
+
+// Distribution is the distribution of numeric fields and references
+type Distribution string
+
+// Distributions
+const (
+	Uniform Distribution = "uniform"
+	// Normal samples around Mean with StdDev, clamped to [Min, Max] if Max is set
+	Normal Distribution = "normal"
+	// Zipf favours small values: Min is the most frequent value, with frequency
+	// falling off by Skew. For references it models a few hot documents.
+	Zipf Distribution = "zipf"
+)
+
+// Spec describes a synthetic dataset
+type Spec struct {
+	// Collections are generated in order; a ref field may only name an earlier collection
+	Collections []Collection `json:"collections"`
+}
+
+// Collection describes the documents of a collection
+type Collection struct {
+	Model  string  `json:"model"`
+	Name   string  `json:"name"`
+	Count  int     `json:"count"`
+	Fields []Field `json:"fields"`
+}
+
+// Field describes how a field is generated. Dotted names create nested objects.
+type Field struct {
+	Name         string       `json:"name"`
+	Type         FieldType    `json:"type"`
+	Distribution Distribution `json:"distribution,omitempty"`
+	Min          float64      `json:"min,omitempty"`
+	Max          float64      `json:"max,omitempty"`
+	Mean         float64      `json:"mean,omitempty"`
+	StdDev       float64      `json:"stddev,omitempty"`
+	// Skew is the zipf exponent, greater than 1 (default: 1.2)
+	Skew    float64   `json:"skew,omitempty"`
+	Values  []string  `json:"values,omitempty"`
+	Weights []float64 `json:"weights,omitempty"`
+	Ref     string    `json:"ref,omitempty"`
+	// Rate is the probability of true for bool fields (default: 0.5)
+	Rate   float64 `json:"rate,omitempty"`
+	Length int     `json:"length,omitempty"`
+	Words  int     `json:"words,omitempty"`
+	// From and To bound time fields (default: the year before 2024-01-01)
+	From time.Time `json:"from,omitempty"`
+	To   time.Time `json:"to,omitempty"`
+	// Nulls is the fraction of documents that omit the field
+	Nulls float64 `json:"nulls,omitempty"`
+}
+
+// Scaled returns a copy of the spec with every document count multiplied by factor.
+// Non-empty collections keep at least one document.
+func (s *Spec) Scaled(factor float64) *Spec {
+	scaled := &Spec{Collections: make([]Collection, len(s.Collections))}
+	for i, c := range s.Collections {
+		if c.Count > 0 {
+			c.Count = max(1, int(math.Round(float64(c.Count)*factor)))
+		}
+		scaled.Collections[i] = c
+	}
+	return scaled
+}
+
+// Document is a generated document
+type Document struct {
+	Model      string
+	Collection string
+	UUID       string
+	Data       map[string]interface{}
+}
+
+// Generator generates the documents of a Spec
+type Generator struct {
+	spec   Spec
+	seed   int64
+	counts map[string]int
+}
+
+// New validates spec and returns a generator whose output is determined by seed
+func New(spec *Spec, seed int64) (*Generator, error) {
+	g := &Generator{spec: *spec, seed: seed, counts: map[string]int{}}
+	for _, c := range spec.Collections {
+		if c.Name == "" || c.Model == "" {
+			return nil, fmt.Errorf("collection %q: model and name are required", c.Name)
+		}
+		if _, ok := g.counts[c.Name]; ok {
+			return nil, fmt.Errorf("collection %q is defined twice", c.Name)
+		}
+		if c.Count < 0 {
+			return nil, fmt.Errorf("collection %q: negative count", c.Name)
+		}
+		for _, f := range c.Fields {
+			if err := g.validateField(f); err != nil {
+				return nil, fmt.Errorf("collection %q, field %q: %w", c.Name, f.Name, err)
+			}
+		}
+		g.counts[c.Name] = c.Count
+	}
+	return g, nil
+}
+
+// validateField checks a field against the collections defined before it
+func (g *Generator) validateField(f Field) error {
+	if f.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch f.Distribution {
+	case "", Uniform, Normal, Zipf:
+	default:
+		return fmt.Errorf("unknown distribution %q", f.Distribution)
+	}
+	if f.Max < f.Min {
+		return fmt.Errorf("max is less than min")
+	}
+	if f.Distribution == Zipf && f.Skew != 0 && f.Skew <= 1 {
+		return fmt.Errorf("zipf skew must be greater than 1")
+	}
+	switch f.Type {
+	case TypeSequence, TypeInt, TypeFloat, TypeBool, TypeString, TypeText, TypeName, TypeEmail, TypeUUID:
+	case TypeEnum:
+		if len(f.Values) == 0 {
+			return fmt.Errorf("enum without values")
+		}
+		if len(f.Weights) > 0 && len(f.Weights) != len(f.Values) {
+			return fmt.Errorf("%d weights for %d values", len(f.Weights), len(f.Values))
+		}
+	case TypeTime:
+		if !f.From.IsZero() && !f.To.IsZero() && f.To.Before(f.From) {
+			return fmt.Errorf("to is before from")
+		}
+	case TypeRef:
+		count, ok := g.counts[f.Ref]
+		if !ok {
+			return fmt.Errorf("ref %q does not name an earlier collection", f.Ref)
+		}
+		if count == 0 {
+			return fmt.Errorf("ref %q has no documents", f.Ref)
+		}
+	default:
+		return fmt.Errorf("unknown type %q", f.Type)
+	}
+	return nil
+}
+
+// Total returns the number of documents the generator produces
+func (g *Generator) Total() int {
+	total := 0
+	for _, c := range g.spec.Collections {
+		total += c.Count
+	}
+	return total
+}
+
+// Key returns the key of the i-th (0-based) document of a collection
+func Key(collection string, i int) string {
+	return fmt.Sprintf("%s-%06d", collection, i+1)
+}
+
+// Generate calls fn with every document, collection by collection in spec order.
+// It stops at the first error returned by fn.
+func (g *Generator) Generate(fn func(Document) error) error {
+	rng := rand.New(rand.NewSource(g.seed))
+	for _, c := range g.spec.Collections {
+		samplers := make([]func(i int) (interface{}, bool), len(c.Fields))
+		for j, f := range c.Fields {
+			samplers[j] = g.sampler(rng, f)
+		}
+		for i := 0; i < c.Count; i++ {
+			data := map[string]interface{}{}
+			for j, f := range c.Fields {
+				if v, ok := samplers[j](i); ok {
+					setPath(data, f.Name, v)
+				}
+			}
+			if err := fn(Document{Model: c.Model, Collection: c.Name, UUID: Key(c.Name, i), Data: data}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteNDJSON writes every document as a line of JSON with its model, collection,
+// uuid, and data
+func (g *Generator) WriteNDJSON(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	err := g.Generate(func(doc Document) error {
+		return enc.Encode(map[string]interface{}{
+			"model":      doc.Model,
+			"collection": doc.Collection,
+			"uuid":       doc.UUID,
+			"data":       doc.Data,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// sampler returns the value generator of a field. ok is false for omitted values.
+func (g *Generator) sampler(rng *rand.Rand, f Field) func(i int) (interface{}, bool) {
+	value := g.valueSampler(rng, f)
+	if f.Nulls <= 0 {
+		return func(i int) (interface{}, bool) { return value(i), true }
+	}
+	return func(i int) (interface{}, bool) {
+		if rng.Float64() < f.Nulls {
+			return nil, false
+		}
+		return value(i), true
+	}
+}
+
+func (g *Generator) valueSampler(rng *rand.Rand, f Field) func(i int) interface{} {
+	switch f.Type {
+	case TypeSequence:
+		return func(i int) interface{} { return i + 1 }
+	case TypeInt:
+		number := numberSampler(rng, f)
+		return func(int) interface{} { return int64(math.Round(number())) }
+	case TypeFloat:
+		number := numberSampler(rng, f)
+		return func(int) interface{} { return number() }
+	case TypeBool:
+		rate := f.Rate
+		if rate == 0 {
+			rate = 0.5
+		}
+		return func(int) interface{} { return rng.Float64() < rate }
+	case TypeString:
+		length := f.Length
+		if length <= 0 {
+			length = 8
+		}
+		return func(int) interface{} {
+			b := make([]byte, length)
+			for k := range b {
+				b[k] = byte('a' + rng.Intn(26))
+			}
+			return string(b)
+		}
+	case TypeText:
+		words := f.Words
+		if words <= 0 {
+			words = 12
+		}
+		return func(int) interface{} {
+			w := make([]string, words)
+			for k := range w {
+				w[k] = loremWords[rng.Intn(len(loremWords))]
+			}
+			text := strings.Join(w, " ")
+			return strings.ToUpper(text[:1]) + text[1:] + "."
+		}
+	case TypeName:
+		return func(int) interface{} {
+			return firstNames[rng.Intn(len(firstNames))] + " " + lastNames[rng.Intn(len(lastNames))]
+		}
+	case TypeEmail:
+		return func(i int) interface{} {
+			first := strings.ToLower(firstNames[rng.Intn(len(firstNames))])
+			last := strings.ToLower(lastNames[rng.Intn(len(lastNames))])
+			return fmt.Sprintf("%s.%s%d@example.com", first, last, i+1)
+		}
+	case TypeEnum:
+		cumulative := make([]float64, len(f.Values))
+		total := 0.0
+		for k := range f.Values {
+			weight := 1.0
+			if len(f.Weights) > 0 {
+				weight = f.Weights[k]
+			}
+			total += weight
+			cumulative[k] = total
+		}
+		return func(int) interface{} {
+			r := rng.Float64() * total
+			for k, c := range cumulative {
+				if r < c {
+					return f.Values[k]
+				}
+			}
+			return f.Values[len(f.Values)-1]
+		}
+	case TypeTime:
+		to := f.To
+		if to.IsZero() {
+			to = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		}
+		from := f.From
+		if from.IsZero() {
+			from = to.AddDate(-1, 0, 0)
+		}
+		span := to.Sub(from)
+		return func(int) interface{} {
+			offset := time.Duration(rng.Int63n(int64(span)/int64(time.Second)+1)) * time.Second
+			return from.Add(offset).UTC().Format(time.RFC3339)
+		}
+	case TypeUUID:
+		return func(int) interface{} {
+			b := make([]byte, 16)
+			rng.Read(b)
+			b[6] = b[6]&0x0f | 0x40
+			b[8] = b[8]&0x3f | 0x80
+			return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+		}
+	case TypeRef:
+		ref := f
+		ref.Min, ref.Max = 0, float64(g.counts[f.Ref]-1)
+		index := numberSampler(rng, ref)
+		return func(int) interface{} { return Key(f.Ref, int(math.Round(index()))) }
+	}
+	return func(int) interface{} { return nil }
+}
+
+// numberSampler samples the distribution of a numeric field
+func numberSampler(rng *rand.Rand, f Field) func() float64 {
+	switch f.Distribution {
+	case Normal:
+		return func() float64 {
+			v := f.Mean + rng.NormFloat64()*f.StdDev
+			if f.Max > f.Min {
+				v = math.Min(math.Max(v, f.Min), f.Max)
+			}
+			return v
+		}
+	case Zipf:
+		skew := f.Skew
+		if skew == 0 {
+			skew = 1.2
+		}
+		zipf := rand.NewZipf(rng, skew, 1, uint64(f.Max-f.Min))
+		return func() float64 { return f.Min + float64(zipf.Uint64()) }
+	default:
+		return func() float64 { return f.Min + rng.Float64()*(f.Max-f.Min) }
+	}
+}
+
+// setPath sets a dotted path in doc, creating nested objects
+func setPath(doc map[string]interface{}, path string, value interface{}) {
+	parts := strings.Split(path, ".")
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := doc[p].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			doc[p] = next
+		}
+		doc = next
+	}
+	doc[parts[len(parts)-1]] = value
+}
+
+var firstNames = []string{
+	"Ada", "Alan", "Amara", "Ben", "Chen", "Clara", "David", "Elena", "Emil", "Fatima",
+	"Grace", "Hannah", "Hiro", "Isabel", "Jonas", "Kofi", "Lara", "Leon", "Maya", "Mateo",
+	"Nina", "Noah", "Olga", "Omar", "Priya", "Rosa", "Sami", "Sofia", "Tom", "Yara",
+}
+
+var lastNames = []string{
+	"Becker", "Costa", "Dubois", "Fischer", "Garcia", "Hansen", "Ivanova", "Jensen", "Kim", "Kowalski",
+	"Lopez", "Meyer", "Nakamura", "Novak", "Okafor", "Patel", "Rossi", "Schmidt", "Silva", "Tanaka",
+	"Weber", "Wong", "Yilmaz", "Zimmermann",
+}
+
+var loremWords = []string{
+	"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit", "sed", "do",
+	"eiusmod", "tempor", "incididunt", "ut", "labore", "et", "dolore", "magna", "aliqua", "enim",
+	"ad", "minim", "veniam", "quis", "nostrud", "exercitation", "ullamco", "laboris", "nisi", "aliquip",
+}
@@ -0,0 +1,133 @@
+package datagen
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func shopSpec() *Spec {
+	return &Spec{Collections: []Collection{
+		{Model: "relational", Name: "customers", Count: 50, Fields: []Field{
+			{Name: "id", Type: TypeSequence},
+			{Name: "name", Type: TypeName},
+			{Name: "email", Type: TypeEmail},
+			{Name: "address.city", Type: TypeEnum, Values: []string{"Berlin", "Lisbon", "Osaka"}, Weights: []float64{8, 1, 1}},
+			{Name: "vip", Type: TypeBool, Rate: 0.1},
+			{Name: "phone", Type: TypeString, Length: 10, Nulls: 0.5},
+		}},
+		{Model: "document", Name: "orders", Count: 500, Fields: []Field{
+			{Name: "customer", Type: TypeRef, Ref: "customers", Distribution: Zipf},
+			{Name: "total", Type: TypeFloat, Distribution: Normal, Mean: 80, StdDev: 30, Min: 1, Max: 500},
+			{Name: "items", Type: TypeInt, Min: 1, Max: 5},
+			{Name: "placed_at", Type: TypeTime, From: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)},
+			{Name: "note", Type: TypeText, Words: 4},
+			{Name: "tracking", Type: TypeUUID},
+		}},
+	}}
+}
+
+func collect(t *testing.T, g *Generator) []Document {
+	var docs []Document
+	require.NoError(t, g.Generate(func(d Document) error {
+		docs = append(docs, d)
+		return nil
+	}))
+	return docs
+}
+
+func TestGenerator(t *testing.T) {
+	g, err := New(shopSpec(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, 550, g.Total())
+
+	docs := collect(t, g)
+	require.Len(t, docs, 550)
+	assert.Equal(t, docs, collect(t, g), "generation is deterministic")
+
+	first := docs[0]
+	assert.Equal(t, "customers-000001", first.UUID)
+	assert.Equal(t, 1, first.Data["id"])
+	assert.Regexp(t, `^[a-z]+\.[a-z]+1@example\.com$`, first.Data["email"])
+	assert.Contains(t, []string{"Berlin", "Lisbon", "Osaka"}, first.Data["address"].(map[string]interface{})["city"])
+
+	customers := map[string]bool{}
+	berlin, phones := 0, 0
+	for _, d := range docs[:50] {
+		customers[d.UUID] = true
+		if d.Data["address"].(map[string]interface{})["city"] == "Berlin" {
+			berlin++
+		}
+		if _, ok := d.Data["phone"]; ok {
+			phones++
+			assert.Len(t, d.Data["phone"], 10)
+		}
+	}
+	assert.Greater(t, berlin, 25, "weights favour Berlin")
+	assert.Greater(t, phones, 10)
+	assert.Less(t, phones, 40)
+
+	refs := map[string]int{}
+	for _, d := range docs[50:] {
+		assert.Equal(t, "document", d.Model)
+		ref := d.Data["customer"].(string)
+		assert.True(t, customers[ref], "ref %s exists", ref)
+		refs[ref]++
+		total := d.Data["total"].(float64)
+		assert.True(t, total >= 1 && total <= 500)
+		items := d.Data["items"].(int64)
+		assert.True(t, items >= 1 && items <= 5)
+		placed, err := time.Parse(time.RFC3339, d.Data["placed_at"].(string))
+		require.NoError(t, err)
+		assert.Equal(t, time.June, placed.Month())
+		assert.Len(t, strings.Fields(d.Data["note"].(string)), 4)
+		assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, d.Data["tracking"])
+	}
+	assert.Greater(t, refs["customers-000001"], refs["customers-000050"], "zipf references favour the first customers")
+}
+
+func TestGenerator_Invalid(t *testing.T) {
+	for name, spec := range map[string]*Spec{
+		"forward ref":   {Collections: []Collection{{Model: "document", Name: "orders", Count: 1, Fields: []Field{{Name: "c", Type: TypeRef, Ref: "customers"}}}}},
+		"empty enum":    {Collections: []Collection{{Model: "document", Name: "orders", Fields: []Field{{Name: "s", Type: TypeEnum}}}}},
+		"unknown type":  {Collections: []Collection{{Model: "document", Name: "orders", Fields: []Field{{Name: "s", Type: "blob"}}}}},
+		"bounds":        {Collections: []Collection{{Model: "document", Name: "orders", Fields: []Field{{Name: "n", Type: TypeInt, Min: 5, Max: 1}}}}},
+		"duplicate":     {Collections: []Collection{{Model: "document", Name: "orders"}, {Model: "document", Name: "orders"}}},
+		"missing model": {Collections: []Collection{{Name: "orders"}}},
+	} {
+		_, err := New(spec, 1)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestSpec_Scaled(t *testing.T) {
+	scaled := shopSpec().Scaled(0.01)
+	assert.Equal(t, 1, scaled.Collections[0].Count)
+	assert.Equal(t, 5, scaled.Collections[1].Count)
+	assert.Equal(t, 50, shopSpec().Collections[0].Count)
+}
+
+func TestGenerator_WriteNDJSON(t *testing.T) {
+	g, err := New(shopSpec().Scaled(0.1), 1)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, g.WriteNDJSON(&buf))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 55)
+	var doc struct {
+		Model      string                 `json:"model"`
+		Collection string                 `json:"collection"`
+		UUID       string                 `json:"uuid"`
+		Data       map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[54]), &doc))
+	assert.Equal(t, "orders", doc.Collection)
+	assert.Equal(t, "orders-000050", doc.UUID)
+	assert.Contains(t, doc.Data, "customer")
+}
@@ -0,0 +1,137 @@
+package datagen
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+// LoadOptions configures Load
+type LoadOptions struct {
+	// Workers is the number of concurrent writes (default: 8)
+	Workers int
+	// OnProgress, if set, is called after every ProgressEvery documents and after each collection
+	OnProgress func(LoadReport)
+	// ProgressEvery is the number of documents between progress reports (default: 1000)
+	ProgressEvery int
+}
+
+// LoadReport summarizes a load
+type LoadReport struct {
+	// Written counts stored documents
+	Written int64
+	// Collections counts stored documents per collection name
+	Collections map[string]int64
+	// Elapsed is the duration of the load
+	Elapsed time.Duration
+}
+
+// Rate returns the number of documents written per second
+func (r LoadReport) Rate() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Written) / r.Elapsed.Seconds()
+}
+
+// Load writes the documents of g with client. Collections are loaded one after another,
+// so documents referenced by ref fields exist before the documents referring to them.
+// Load stops at the first failed write.
+func Load(ctx context.Context, client themisdb.ThemisClient, g *Generator, opts *LoadOptions) (*LoadReport, error) {
+	o := LoadOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Workers <= 0 {
+		o.Workers = 8
+	}
+	if o.ProgressEvery <= 0 {
+		o.ProgressEvery = 1000
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := time.Now()
+	report := &LoadReport{Collections: map[string]int64{}}
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+		jobs     chan Document
+		current  string
+	)
+	progress := func() {
+		if o.OnProgress != nil {
+			r := *report
+			r.Elapsed = time.Since(start)
+			r.Collections = make(map[string]int64, len(report.Collections))
+			for name, n := range report.Collections {
+				r.Collections[name] = n
+			}
+			o.OnProgress(r)
+		}
+	}
+	worker := func(jobs <-chan Document) {
+		defer wg.Done()
+		for doc := range jobs {
+			err := client.Put(ctx, doc.Model, doc.Collection, doc.UUID, doc.Data)
+			mu.Lock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to write %s/%s/%s: %w", doc.Model, doc.Collection, doc.UUID, err)
+					cancel()
+				}
+			} else {
+				report.Written++
+				report.Collections[doc.Collection]++
+				if report.Written%int64(o.ProgressEvery) == 0 {
+					progress()
+				}
+			}
+			mu.Unlock()
+		}
+	}
+	// finish waits for the writes of the current collection
+	finish := func() error {
+		if jobs == nil {
+			return nil
+		}
+		close(jobs)
+		wg.Wait()
+		jobs = nil
+		if firstErr != nil {
+			return firstErr
+		}
+		progress()
+		return nil
+	}
+
+	err := g.Generate(func(doc Document) error {
+		if jobs == nil || doc.Collection != current {
+			if err := finish(); err != nil {
+				return err
+			}
+			current = doc.Collection
+			jobs = make(chan Document, o.Workers)
+			wg.Add(o.Workers)
+			for i := 0; i < o.Workers; i++ {
+				go worker(jobs)
+			}
+		}
+		select {
+		case jobs <- doc:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	if ferr := finish(); ferr != nil {
+		err = ferr
+	}
+	report.Elapsed = time.Since(start)
+	return report, err
+}
@@ -0,0 +1,49 @@
+package datagen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/makr-code/ThemisDB/clients/go/themistest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	client, fake := themistest.NewClient(t)
+	g, err := New(shopSpec(), 3)
+	require.NoError(t, err)
+
+	var reports []LoadReport
+	report, err := Load(context.Background(), client, g, &LoadOptions{
+		Workers:       4,
+		ProgressEvery: 200,
+		OnProgress:    func(r LoadReport) { reports = append(reports, r) },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(550), report.Written)
+	assert.Equal(t, map[string]int64{"customers": 50, "orders": 500}, report.Collections)
+	assert.Equal(t, 50, fake.Len("relational", "customers"))
+	assert.Equal(t, 500, fake.Len("document", "orders"))
+
+	order, ok := fake.Document("document", "orders", "orders-000001")
+	require.True(t, ok)
+	_, ok = fake.Document("relational", "customers", order["customer"].(string))
+	assert.True(t, ok, "referenced customer exists")
+
+	require.NotEmpty(t, reports)
+	assert.Equal(t, int64(550), reports[len(reports)-1].Written)
+	assert.Equal(t, int64(50), reports[0].Written, "progress is reported after each collection")
+}
+
+func TestLoad_StopsOnError(t *testing.T) {
+	client, fake := themistest.NewClient(t)
+	g, err := New(shopSpec(), 3)
+	require.NoError(t, err)
+
+	fake.FailNext(507, "disk full")
+	report, err := Load(context.Background(), client, g, &LoadOptions{Workers: 2})
+	assert.ErrorContains(t, err, "disk full")
+	assert.Less(t, report.Written, int64(550))
+	assert.Zero(t, fake.Len("document", "orders"), "later collections are not loaded")
+}
@@ -0,0 +1,234 @@
+package themisdb
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// DebugBundleOptions controls what CollectDebugBundleWithOptions gathers
+type DebugBundleOptions struct {
+	// Window is how far back slow queries and errors are collected (default: 1h)
+	Window time.Duration
+	// MaxEntries limits the number of slow queries and errors (default: 500 each)
+	MaxEntries int
+	// MetricsSnapshots is the number of metrics snapshots taken (default: 1)
+	MetricsSnapshots int
+	// MetricsInterval is the time between metrics snapshots (default: 10s)
+	MetricsInterval time.Duration
+	// RedactPattern matches configuration keys whose values are replaced with
+	// "[REDACTED]" (default: password, secret, token, credential, and private key names)
+	RedactPattern *regexp.Regexp
+}
+
+// DebugFile is a file of a debug bundle
+type DebugFile struct {
+	Name string
+	Data []byte
+}
+
+// DebugBundle holds diagnostics collected from a server for a support escalation
+type DebugBundle struct {
+	CreatedAt time.Time
+	Endpoint  string
+	Files     []DebugFile
+	// Failures maps the files that could not be collected to the error, the bundle is still usable
+	Failures map[string]string
+}
+
+// File returns the contents of a bundle file
+func (b *DebugBundle) File(name string) ([]byte, bool) {
+	for _, f := range b.Files {
+		if f.Name == name {
+			return f.Data, true
+		}
+	}
+	return nil, false
+}
+
+// WriteTo writes the bundle as a zip archive including a manifest.json. It implements io.WriterTo.
+func (b *DebugBundle) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	zw := zip.NewWriter(cw)
+
+	manifest := map[string]interface{}{
+		"created_at": b.CreatedAt,
+		"endpoint":   b.Endpoint,
+		"failures":   b.Failures,
+	}
+	var names []string
+	for _, f := range b.Files {
+		names = append(names, f.Name)
+	}
+	manifest["files"] = names
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return cw.n, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	files := append([]DebugFile{{Name: "manifest.json", Data: data}}, b.Files...)
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: b.CreatedAt})
+		if err != nil {
+			return cw.n, fmt.Errorf("failed to write %s: %w", f.Name, err)
+		}
+		if _, err := fw.Write(f.Data); err != nil {
+			return cw.n, fmt.Errorf("failed to write %s: %w", f.Name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return cw.n, fmt.Errorf("failed to write archive: %w", err)
+	}
+	return cw.n, nil
+}
+
+// countingWriter counts the bytes written to w
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// defaultRedactPattern matches configuration keys holding credentials
+var defaultRedactPattern = regexp.MustCompile(`(?i)password|secret|token|credential|private_?key|api_?key`)
+
+// CollectDebugBundle gathers server metrics, statistics, health, configuration, slow
+// queries, and errors of the last hour into a bundle for support escalations:
+//
+//	bundle, err := client.Admin().CollectDebugBundle(ctx)
+//	f, _ := os.Create("themis-debug.zip")
+//	_, err = bundle.WriteTo(f)
+func (a *Admin) CollectDebugBundle(ctx context.Context) (*DebugBundle, error) {
+	return a.CollectDebugBundleWithOptions(ctx, nil)
+}
+
+// CollectDebugBundleWithOptions is CollectDebugBundle with options. Files that cannot be
+// collected are recorded in DebugBundle.Failures; an error is returned only if nothing
+// could be collected or ctx is done.
+func (a *Admin) CollectDebugBundleWithOptions(ctx context.Context, opts *DebugBundleOptions) (*DebugBundle, error) {
+	o := DebugBundleOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Window <= 0 {
+		o.Window = time.Hour
+	}
+	if o.MaxEntries <= 0 {
+		o.MaxEntries = 500
+	}
+	if o.MetricsSnapshots <= 0 {
+		o.MetricsSnapshots = 1
+	}
+	if o.MetricsInterval <= 0 {
+		o.MetricsInterval = 10 * time.Second
+	}
+	if o.RedactPattern == nil {
+		o.RedactPattern = defaultRedactPattern
+	}
+
+	root := a.client
+	if root.root != nil {
+		root = root.root
+	}
+	bundle := &DebugBundle{
+		CreatedAt: time.Now().UTC(),
+		Endpoint:  root.getEndpoint(),
+		Failures:  map[string]string{},
+	}
+	since := bundle.CreatedAt.Add(-o.Window).Format(time.RFC3339Nano)
+	limit := strconv.Itoa(o.MaxEntries)
+
+	add := func(name, path string, transform func([]byte) ([]byte, error)) {
+		resp, err := a.client.send(ctx, "GET", path, nil, nil)
+		var data []byte
+		if err == nil {
+			data = resp.Body
+			if transform != nil {
+				data, err = transform(data)
+			}
+		}
+		if err != nil {
+			bundle.Failures[name] = err.Error()
+			return
+		}
+		bundle.Files = append(bundle.Files, DebugFile{Name: name, Data: data})
+	}
+
+	add("health.json", "/health", nil)
+	add("stats.json", "/stats", nil)
+	add("transactions.json", "/transaction/stats", nil)
+	add("config.json", "/config", func(data []byte) ([]byte, error) {
+		return redactJSON(data, o.RedactPattern)
+	})
+	add("slow_queries.json", "/admin/slow-queries?"+url.Values{"since": {since}, "limit": {limit}}.Encode(), nil)
+	add("errors.json", "/admin/logs?"+url.Values{"level": {string(LogLevelError)}, "since": {since}, "limit": {limit}, "wait_ms": {"0"}}.Encode(), nil)
+
+	for i := 1; i <= o.MetricsSnapshots; i++ {
+		if i > 1 {
+			select {
+			case <-time.After(o.MetricsInterval):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		name := "metrics.prom"
+		if o.MetricsSnapshots > 1 {
+			name = fmt.Sprintf("metrics-%d.prom", i)
+		}
+		add(name, "/metrics", nil)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(bundle.Files) == 0 {
+		return nil, fmt.Errorf("failed to collect debug bundle: no diagnostics available from %s", bundle.Endpoint)
+	}
+	return bundle, nil
+}
+
+// redactJSON replaces the values of object keys matching pattern
+func redactJSON(data []byte, pattern *regexp.Regexp) ([]byte, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	var redact func(v interface{}) interface{}
+	redact = func(v interface{}) interface{} {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for key, value := range v {
+				if pattern.MatchString(key) {
+					v[key] = "[REDACTED]"
+				} else {
+					v[key] = redact(value)
+				}
+			}
+		case []interface{}:
+			for i, value := range v {
+				v[i] = redact(value)
+			}
+		}
+		return v
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(redact(doc)); err != nil {
+		return nil, fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,87 @@
+package themisdb
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdmin_CollectDebugBundle(t *testing.T) {
+	var metricsCalls int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Write([]byte(`{"status":"ok"}`))
+		case "/stats":
+			w.Write([]byte(`{"documents":42}`))
+		case "/config":
+			w.Write([]byte(`{"server":{"port":8080},"auth":{"jwt_secret":"s3cr3t","users":[{"name":"admin","password":"pw"}]}}`))
+		case "/metrics":
+			metricsCalls++
+			w.Write([]byte("themis_requests_total 7\n"))
+		case "/admin/logs":
+			assert.Equal(t, "error", r.URL.Query().Get("level"))
+			assert.Equal(t, "0", r.URL.Query().Get("wait_ms"))
+			w.Write([]byte(`{"entries":[{"seq":1,"level":"error","message":"disk full"}],"next":1}`))
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	})
+
+	bundle, err := client.Admin().CollectDebugBundleWithOptions(context.Background(), &DebugBundleOptions{
+		MetricsSnapshots: 2,
+		MetricsInterval:  time.Millisecond,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, metricsCalls)
+	assert.Contains(t, bundle.Failures, "slow_queries.json")
+	assert.Contains(t, bundle.Failures, "transactions.json")
+
+	config, ok := bundle.File("config.json")
+	require.True(t, ok)
+	assert.NotContains(t, string(config), "s3cr3t")
+	assert.NotContains(t, string(config), `"pw"`)
+	assert.Contains(t, string(config), `"port": 8080`)
+
+	var buf bytes.Buffer
+	n, err := bundle.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	files := map[string][]byte{}
+	for _, f := range archive.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		files[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+	assert.Contains(t, string(files["errors.json"]), "disk full")
+	assert.Equal(t, "themis_requests_total 7\n", string(files["metrics-2.prom"]))
+
+	var manifest struct {
+		Files    []string          `json:"files"`
+		Failures map[string]string `json:"failures"`
+	}
+	require.NoError(t, json.Unmarshal(files["manifest.json"], &manifest))
+	assert.Equal(t, []string{"health.json", "stats.json", "config.json", "errors.json", "metrics-1.prom", "metrics-2.prom"}, manifest.Files)
+	assert.Len(t, manifest.Failures, 2)
+}
+
+func TestAdmin_CollectDebugBundleUnavailable(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+
+	_, err := client.Admin().CollectDebugBundle(context.Background())
+	assert.ErrorContains(t, err, "no diagnostics available")
+}
@@ -0,0 +1,341 @@
+// Package dedupe finds and merges duplicate documents of a ThemisDB collection, for
+// master-data-management style cleanup jobs.
+//
+// Comparing every pair of documents does not scale, so candidates are generated by
+// blocking: each BlockingKey maps a document to keys, e.g. its normalized postcode or
+// the first letters of its name, and only documents sharing a key are compared. A
+// Scorer rates each candidate pair between 0 and 1; pairs at or above the threshold
+// are matches, and documents connected by matches form a cluster. Merge folds the
+// duplicates of a cluster into a survivor in one transaction and rewrites the
+// references of other collections to them:
+//
+//	d := dedupe.New(client, dedupe.Config{
+//		Model:      "relational",
+//		Collection: "customers",
+//		Keys:       []dedupe.BlockingKey{dedupe.FieldKey("postcode"), dedupe.PrefixKey("name", 4)},
+//		Score:      dedupe.WeightedFields(map[string]float64{"name": 2, "street": 1, "email": 1}),
+//		References: []dedupe.Reference{{Model: "relational", Collection: "orders", Field: "customer_id"}},
+//	})
+//	result, err := d.Find(ctx)
+//	...
+//	for _, cluster := range result.Clusters {
+//		_, err := d.Merge(ctx, cluster[0], cluster[1:]...)
+//		...
+//	}
+package dedupe
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+// Document is a document of the deduplicated collection
+type Document struct {
+	UUID   string
+	Fields map[string]interface{}
+}
+
+// Value returns the value of a dotted field path, nil if it is missing
+func (d Document) Value(field string) interface{} {
+	var v interface{} = d.Fields
+	for _, part := range strings.Split(field, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[part]
+	}
+	return v
+}
+
+// String returns the value of a dotted field path formatted as a string, "" if it is
+// missing
+func (d Document) String(field string) string {
+	switch v := d.Value(field).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// BlockingKey returns the blocking keys of a document; documents are compared only if
+// they share a key of the same BlockingKey. A document without keys is not compared.
+type BlockingKey func(doc Document) []string
+
+// Scorer rates the similarity of two documents from 0 (different) to 1 (identical)
+type Scorer func(a, b Document) float64
+
+// Config configures a Deduper
+type Config struct {
+	Model      string
+	Collection string
+	// Keys generate the candidate pairs; at least one is required
+	Keys []BlockingKey
+	// Score rates candidate pairs; it is required for Find
+	Score Scorer
+	// Threshold is the minimum score of a match (default: 0.9)
+	Threshold float64
+	// MaxBlockSize skips blocks with more documents, which usually come from a key that
+	// is not selective enough, e.g. an empty field (default: 1000)
+	MaxBlockSize int
+	// References are the fields of other collections that Merge rewrites
+	References []Reference
+	// Combine returns the merged document of a survivor and its duplicates (default:
+	// the survivor's fields, with fields it lacks taken from the duplicates in order)
+	Combine func(survivor Document, duplicates []Document) map[string]interface{}
+}
+
+// Deduper finds and merges duplicates in a collection
+type Deduper struct {
+	client themisdb.ThemisClient
+	cfg    Config
+}
+
+// New returns a Deduper for the collection of cfg
+func New(client themisdb.ThemisClient, cfg Config) *Deduper {
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = 0.9
+	}
+	if cfg.MaxBlockSize <= 0 {
+		cfg.MaxBlockSize = 1000
+	}
+	if cfg.Combine == nil {
+		cfg.Combine = fillMissing
+	}
+	return &Deduper{client: client, cfg: cfg}
+}
+
+// Match is a pair of documents scored at or above the threshold
+type Match struct {
+	A, B  string
+	Score float64
+}
+
+// Result is the outcome of Find
+type Result struct {
+	// Documents is the number of documents scanned
+	Documents int
+	// Comparisons is the number of candidate pairs scored
+	Comparisons int
+	// Matches are sorted by descending score
+	Matches []Match
+	// Clusters are the groups of documents connected by matches, each sorted by UUID
+	Clusters [][]string
+	// SkippedBlocks are the blocking keys of blocks larger than MaxBlockSize
+	SkippedBlocks []string
+}
+
+// Find scans the collection and returns the duplicates among its documents. The
+// documents are held in memory while they are compared.
+func (d *Deduper) Find(ctx context.Context) (*Result, error) {
+	if len(d.cfg.Keys) == 0 {
+		return nil, &themisdb.ValidationError{Field: "keys", Value: d.cfg.Collection, Reason: "at least one blocking key is required"}
+	}
+	if d.cfg.Score == nil {
+		return nil, &themisdb.ValidationError{Field: "score", Value: d.cfg.Collection, Reason: "a scorer is required"}
+	}
+
+	var docs []Document
+	it := d.client.Scan(ctx, d.cfg.Model, d.cfg.Collection, themisdb.ScanOptions{BatchSize: 500})
+	for it.Next() {
+		doc := Document{UUID: it.UUID()}
+		if err := it.Decode(&doc.Fields); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", it.UUID(), err)
+		}
+		docs = append(docs, doc)
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan %s/%s: %w", d.cfg.Model, d.cfg.Collection, err)
+	}
+
+	result := &Result{Documents: len(docs)}
+	compared := map[[2]int]bool{}
+	clusters := newUnionFind(len(docs))
+	for k, key := range d.cfg.Keys {
+		blocks := map[string][]int{}
+		for i, doc := range docs {
+			for _, value := range key(doc) {
+				blocks[value] = append(blocks[value], i)
+			}
+		}
+		for value, members := range blocks {
+			if len(members) > d.cfg.MaxBlockSize {
+				result.SkippedBlocks = append(result.SkippedBlocks, fmt.Sprintf("%d:%s", k, value))
+				continue
+			}
+			for x := 0; x < len(members); x++ {
+				for y := x + 1; y < len(members); y++ {
+					pair := [2]int{members[x], members[y]}
+					if compared[pair] {
+						continue
+					}
+					compared[pair] = true
+					result.Comparisons++
+					score := d.cfg.Score(docs[pair[0]], docs[pair[1]])
+					if score >= d.cfg.Threshold {
+						result.Matches = append(result.Matches, Match{A: docs[pair[0]].UUID, B: docs[pair[1]].UUID, Score: score})
+						clusters.union(pair[0], pair[1])
+					}
+				}
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(result.Matches, func(i, j int) bool {
+		if result.Matches[i].Score != result.Matches[j].Score {
+			return result.Matches[i].Score > result.Matches[j].Score
+		}
+		return result.Matches[i].A+"\x00"+result.Matches[i].B < result.Matches[j].A+"\x00"+result.Matches[j].B
+	})
+	sort.Strings(result.SkippedBlocks)
+	result.Clusters = clusters.groups(docs)
+	return result, nil
+}
+
+// unionFind groups document indexes connected by matches
+type unionFind []int
+
+func newUnionFind(n int) unionFind {
+	u := make(unionFind, n)
+	for i := range u {
+		u[i] = i
+	}
+	return u
+}
+
+func (u unionFind) find(i int) int {
+	for u[i] != i {
+		u[i] = u[u[i]]
+		i = u[i]
+	}
+	return i
+}
+
+func (u unionFind) union(a, b int) {
+	u[u.find(a)] = u.find(b)
+}
+
+// groups returns the UUIDs of the groups with more than one document, each sorted,
+// ordered by their first UUID
+func (u unionFind) groups(docs []Document) [][]string {
+	byRoot := map[int][]string{}
+	for i, doc := range docs {
+		root := u.find(i)
+		byRoot[root] = append(byRoot[root], doc.UUID)
+	}
+	var groups [][]string
+	for _, group := range byRoot {
+		if len(group) > 1 {
+			sort.Strings(group)
+			groups = append(groups, group)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0] < groups[j][0] })
+	return groups
+}
+
+// FieldKey blocks on the normalized values of fields, joined; documents missing all
+// of them get no key
+func FieldKey(fields ...string) BlockingKey {
+	return func(doc Document) []string {
+		values := make([]string, len(fields))
+		empty := true
+		for i, field := range fields {
+			values[i] = Normalize(doc.String(field))
+			empty = empty && values[i] == ""
+		}
+		if empty {
+			return nil
+		}
+		return []string{strings.Join(values, "|")}
+	}
+}
+
+// PrefixKey blocks on the first n characters of the normalized value of field
+func PrefixKey(field string, n int) BlockingKey {
+	return func(doc Document) []string {
+		value := []rune(Normalize(doc.String(field)))
+		if len(value) == 0 {
+			return nil
+		}
+		if len(value) > n {
+			value = value[:n]
+		}
+		return []string{string(value)}
+	}
+}
+
+// WeightedFields scores documents by the weighted mean Similarity of the normalized
+// values of fields. Fields missing from both documents are left out; a field missing
+// from one of them scores 0.
+func WeightedFields(weights map[string]float64) Scorer {
+	return func(a, b Document) float64 {
+		var total, sum float64
+		for field, weight := range weights {
+			x, y := Normalize(a.String(field)), Normalize(b.String(field))
+			if x == "" && y == "" {
+				continue
+			}
+			total += weight
+			sum += weight * Similarity(x, y)
+		}
+		if total == 0 {
+			return 0
+		}
+		return sum / total
+	}
+}
+
+// Normalize lowercases s and removes everything but letters and digits, so that
+// spelling variants like "Main St." and "main st" compare equal
+func Normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Similarity returns 1 minus the Levenshtein distance of a and b divided by the length
+// of the longer string, 1 for two empty strings
+func Similarity(a, b string) float64 {
+	x, y := []rune(a), []rune(b)
+	longest := len(x)
+	if len(y) > longest {
+		longest = len(y)
+	}
+	if longest == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(y)+1)
+	cur := make([]int, len(y)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(x); i++ {
+		cur[0] = i
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(y)])/float64(longest)
+}
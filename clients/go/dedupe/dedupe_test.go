@@ -0,0 +1,85 @@
+package dedupe
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+	"github.com/makr-code/ThemisDB/clients/go/themistest"
+)
+
+// seedCustomers stores customers, two pairs of which are duplicates
+func seedCustomers(t *testing.T, fake *themistest.Fake) {
+	customers := map[string]map[string]interface{}{
+		"c1": {"name": "Ada Lovelace", "postcode": "10115", "email": "ada@example.com"},
+		"c2": {"name": "ada lovelace.", "postcode": "10115"},
+		"c3": {"name": "Ada Lovelance", "postcode": "10 115", "phone": "+49 30 1234"},
+		"c4": {"name": "Alan Turing", "postcode": "10115"},
+		"c5": {"name": "Grace Hopper", "postcode": "20095"},
+		"c6": {"name": "Grace Hoper", "postcode": "20095"},
+		"c7": {"name": "Grace Hopper"},
+	}
+	for uuid, doc := range customers {
+		require.NoError(t, fake.Seed("relational", "customers", uuid, doc))
+	}
+}
+
+func TestDeduper_Find(t *testing.T) {
+	client, fake := themistest.NewClient(t)
+	seedCustomers(t, fake)
+
+	d := New(client, Config{
+		Model:      "relational",
+		Collection: "customers",
+		Keys:       []BlockingKey{FieldKey("postcode")},
+		Score:      WeightedFields(map[string]float64{"name": 1}),
+		Threshold:  0.85,
+	})
+	result, err := d.Find(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 7, result.Documents)
+	assert.Equal(t, 7, result.Comparisons, "4 customers in 10115 and 2 in 20095; c7 has no postcode")
+	assert.Equal(t, [][]string{{"c1", "c2", "c3"}, {"c5", "c6"}}, result.Clusters)
+	require.NotEmpty(t, result.Matches)
+	assert.Equal(t, Match{A: "c1", B: "c2", Score: 1}, result.Matches[0])
+
+	// a second key finds c7, which has no postcode; pairs are scored once
+	d.cfg.Keys = append(d.cfg.Keys, PrefixKey("name", 5))
+	result, err = d.Find(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"c1", "c2", "c3"}, {"c5", "c6", "c7"}}, result.Clusters)
+	assert.Equal(t, 9, result.Comparisons)
+
+	d.cfg.MaxBlockSize = 3
+	result, err = d.Find(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0:10115"}, result.SkippedBlocks)
+}
+
+func TestDeduper_FindInvalid(t *testing.T) {
+	client, _ := themistest.NewClient(t)
+	_, err := New(client, Config{Model: "relational", Collection: "customers", Score: WeightedFields(nil)}).Find(context.Background())
+	assert.ErrorIs(t, err, themisdb.ErrInvalidInput)
+	_, err = New(client, Config{Model: "relational", Collection: "customers", Keys: []BlockingKey{FieldKey("name")}}).Find(context.Background())
+	assert.ErrorIs(t, err, themisdb.ErrInvalidInput)
+}
+
+func TestSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, Similarity("", ""))
+	assert.Equal(t, 1.0, Similarity("hopper", "hopper"))
+	assert.Equal(t, 0.0, Similarity("abc", ""))
+	assert.InDelta(t, 1-1.0/6, Similarity("hopper", "hoper"), 1e-9)
+	assert.InDelta(t, 1-3.0/7, Similarity("kitten", "sitting"), 1e-9)
+	assert.Equal(t, "mainst12", Normalize("Main St. 12"))
+}
+
+func TestBlockingKeys(t *testing.T) {
+	doc := Document{UUID: "c1", Fields: map[string]interface{}{"name": "Ada Lovelace", "address": map[string]interface{}{"zip": 10115.0}}}
+	assert.Equal(t, []string{"adalovelace|10115"}, FieldKey("name", "address.zip")(doc))
+	assert.Equal(t, []string{"ada"}, PrefixKey("name", 3)(doc))
+	assert.Nil(t, FieldKey("email")(doc))
+	assert.Nil(t, PrefixKey("email", 3)(doc))
+}
@@ -0,0 +1,185 @@
+package dedupe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+// Reference is a field of a collection that holds the UUID of a document of the
+// deduplicated collection, e.g. the customer_id of orders
+type Reference struct {
+	Model      string
+	Collection string
+	// Field is the dotted path of the referencing field
+	Field string
+}
+
+// MergeReport is the outcome of Merge
+type MergeReport struct {
+	Survivor string
+	// Merged are the duplicates folded into the survivor and deleted
+	Merged []string
+	// Rewritten is the number of referencing documents changed to the survivor, per
+	// "<collection>.<field>"
+	Rewritten map[string]int
+}
+
+// Merge folds duplicates into survivor in one transaction: the survivor is replaced by
+// the Combine of it and the duplicates, every configured reference to a duplicate is
+// changed to the survivor, and the duplicates are deleted. Concurrent writes to the
+// documents involved are retried as a whole.
+func (d *Deduper) Merge(ctx context.Context, survivor string, duplicates ...string) (*MergeReport, error) {
+	if len(duplicates) == 0 {
+		return nil, &themisdb.ValidationError{Field: "duplicates", Value: survivor, Reason: "must not be empty"}
+	}
+	for _, dup := range duplicates {
+		if dup == survivor {
+			return nil, &themisdb.ValidationError{Field: "duplicates", Value: dup, Reason: "must not contain the survivor"}
+		}
+	}
+	for _, ref := range d.cfg.References {
+		if err := validateName(ref.Collection); err != nil {
+			return nil, err
+		}
+		for _, part := range strings.Split(ref.Field, ".") {
+			if err := validateName(part); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var report *MergeReport
+	err := d.client.RunTransaction(ctx, func(ctx context.Context, tx *themisdb.Transaction) error {
+		report = &MergeReport{Survivor: survivor, Merged: duplicates, Rewritten: map[string]int{}}
+		keep, err := d.load(ctx, tx, survivor)
+		if err != nil {
+			return err
+		}
+		dups := make([]Document, len(duplicates))
+		for i, uuid := range duplicates {
+			if dups[i], err = d.load(ctx, tx, uuid); err != nil {
+				return err
+			}
+		}
+		if err := tx.Put(ctx, d.cfg.Model, d.cfg.Collection, survivor, d.cfg.Combine(keep, dups)); err != nil {
+			return err
+		}
+
+		merged := make(map[string]bool, len(duplicates))
+		for _, uuid := range duplicates {
+			merged[uuid] = true
+		}
+		for _, ref := range d.cfg.References {
+			var skip map[string]bool
+			if ref.Model == d.cfg.Model && ref.Collection == d.cfg.Collection {
+				// the duplicates are deleted below
+				skip = merged
+			}
+			n, err := rewrite(ctx, tx, ref, duplicates, skip, survivor)
+			if err != nil {
+				return fmt.Errorf("failed to rewrite %s.%s: %w", ref.Collection, ref.Field, err)
+			}
+			report.Rewritten[ref.Collection+"."+ref.Field] += n
+		}
+
+		for _, uuid := range duplicates {
+			if err := tx.Delete(ctx, d.cfg.Model, d.cfg.Collection, uuid); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge %v into %s: %w", duplicates, survivor, err)
+	}
+	return report, nil
+}
+
+// load reads a document of the deduplicated collection within tx
+func (d *Deduper) load(ctx context.Context, tx *themisdb.Transaction, uuid string) (Document, error) {
+	doc := Document{UUID: uuid}
+	if err := tx.Get(ctx, d.cfg.Model, d.cfg.Collection, uuid, &doc.Fields); err != nil {
+		if errors.Is(err, themisdb.ErrNotFound) {
+			return doc, fmt.Errorf("document %s: %w", uuid, err)
+		}
+		return doc, err
+	}
+	return doc, nil
+}
+
+// rewrite changes ref from any of duplicates to survivor in every document of its
+// collection except those in skip, and returns the number of documents changed
+func rewrite(ctx context.Context, tx *themisdb.Transaction, ref Reference, duplicates []string, skip map[string]bool, survivor string) (int, error) {
+	aql := fmt.Sprintf("FOR d IN %s FILTER %s IN @duplicates RETURN d._key", quote(ref.Collection), path("d", ref.Field))
+	var keys []string
+	opts := &themisdb.QueryOptions{BindVars: map[string]interface{}{"duplicates": duplicates}}
+	if err := tx.QueryWithOptions(ctx, aql, opts, &keys); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, key := range keys {
+		if skip[key] {
+			continue
+		}
+		if err := tx.Patch(ctx, ref.Model, ref.Collection, key, nest(ref.Field, survivor)); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// nest returns a merge patch setting the dotted field to value
+func nest(field string, value interface{}) map[string]interface{} {
+	parts := strings.Split(field, ".")
+	patch := map[string]interface{}{parts[len(parts)-1]: value}
+	for i := len(parts) - 2; i >= 0; i-- {
+		patch = map[string]interface{}{parts[i]: patch}
+	}
+	return patch
+}
+
+// fillMissing is the default Combine: the survivor's fields, with fields it lacks taken
+// from the duplicates in order
+func fillMissing(survivor Document, duplicates []Document) map[string]interface{} {
+	merged := make(map[string]interface{}, len(survivor.Fields))
+	for k, v := range survivor.Fields {
+		merged[k] = v
+	}
+	for _, dup := range duplicates {
+		for k, v := range dup.Fields {
+			if existing, ok := merged[k]; !ok || existing == nil {
+				merged[k] = v
+			}
+		}
+	}
+	return merged
+}
+
+// quote returns name as an AQL identifier
+func quote(name string) string {
+	return "`" + name + "`"
+}
+
+// path returns the AQL attribute path of a dotted field within variable v
+func path(v, field string) string {
+	var b strings.Builder
+	b.WriteString(v)
+	for _, part := range strings.Split(field, ".") {
+		b.WriteString("." + quote(part))
+	}
+	return b.String()
+}
+
+// validateName rejects names that cannot be embedded in a query
+func validateName(name string) error {
+	if name == "" || strings.ContainsAny(name, "`\n") {
+		return &themisdb.ValidationError{Field: "reference", Value: name, Reason: "must be a non-empty name without backticks or newlines"}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package dedupe
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+	"github.com/makr-code/ThemisDB/clients/go/themistest"
+)
+
+func TestDeduper_Merge(t *testing.T) {
+	client, fake := themistest.NewClient(t)
+	ctx := context.Background()
+	seedCustomers(t, fake)
+	require.NoError(t, fake.Seed("relational", "orders", "o1", map[string]interface{}{"customer_id": "c2"}))
+	require.NoError(t, fake.Seed("relational", "orders", "o2", map[string]interface{}{"customer_id": "c4"}))
+	require.NoError(t, fake.Seed("relational", "orders", "o3", map[string]interface{}{"billing": map[string]interface{}{"customer": "c3"}}))
+	require.NoError(t, fake.Seed("relational", "customers", "c8", map[string]interface{}{"name": "Byron", "referred_by": "c3"}))
+
+	d := New(client, Config{
+		Model:      "relational",
+		Collection: "customers",
+		References: []Reference{
+			{Model: "relational", Collection: "orders", Field: "customer_id"},
+			{Model: "relational", Collection: "orders", Field: "billing.customer"},
+			{Model: "relational", Collection: "customers", Field: "referred_by"},
+		},
+	})
+	report, err := d.Merge(ctx, "c1", "c2", "c3")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"orders.customer_id": 1, "orders.billing.customer": 1, "customers.referred_by": 1}, report.Rewritten)
+
+	survivor, _ := fake.Document("relational", "customers", "c1")
+	assert.Equal(t, "Ada Lovelace", survivor["name"], "the survivor's fields win")
+	assert.Equal(t, "+49 30 1234", survivor["phone"], "missing fields are taken from the duplicates")
+	for _, uuid := range []string{"c2", "c3"} {
+		_, ok := fake.Document("relational", "customers", uuid)
+		assert.False(t, ok, "duplicate %s is deleted", uuid)
+	}
+	o1, _ := fake.Document("relational", "orders", "o1")
+	assert.Equal(t, "c1", o1["customer_id"])
+	o2, _ := fake.Document("relational", "orders", "o2")
+	assert.Equal(t, "c4", o2["customer_id"])
+	o3, _ := fake.Document("relational", "orders", "o3")
+	assert.Equal(t, map[string]interface{}{"customer": "c1"}, o3["billing"])
+	c8, _ := fake.Document("relational", "customers", "c8")
+	assert.Equal(t, "c1", c8["referred_by"])
+	assert.Equal(t, 0, fake.OpenTransactions())
+}
+
+func TestDeduper_MergeFailures(t *testing.T) {
+	client, fake := themistest.NewClient(t)
+	ctx := context.Background()
+	seedCustomers(t, fake)
+	d := New(client, Config{Model: "relational", Collection: "customers"})
+
+	_, err := d.Merge(ctx, "c1")
+	assert.ErrorIs(t, err, themisdb.ErrInvalidInput)
+	_, err = d.Merge(ctx, "c1", "c1")
+	assert.ErrorIs(t, err, themisdb.ErrInvalidInput)
+
+	_, err = d.Merge(ctx, "c1", "c2", "missing")
+	assert.ErrorIs(t, err, themisdb.ErrNotFound)
+	_, ok := fake.Document("relational", "customers", "c2")
+	assert.True(t, ok, "a failed merge changes nothing")
+
+	d.cfg.References = []Reference{{Model: "relational", Collection: "orders", Field: "a`b"}}
+	_, err = d.Merge(ctx, "c1", "c2")
+	assert.ErrorIs(t, err, themisdb.ErrInvalidInput)
+}
@@ -0,0 +1,165 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Cluster member roles reported by /cluster/members
+const (
+	// RoleLeader accepts writes
+	RoleLeader = "leader"
+	// RoleFollower replicates from the leader and serves reads
+	RoleFollower = "follower"
+)
+
+// DiscoveryOptions configures cluster topology discovery. The client learns all
+// nodes from a seed's /cluster/members endpoint, sends requests to the leader, and
+// uses the followers as hedging targets and read replicas.
+type DiscoveryOptions struct {
+	// Seeds are queried for the topology (default: Config.Endpoints)
+	Seeds []string
+	// RefreshInterval is the period of topology refreshes (default: 30s)
+	RefreshInterval time.Duration
+}
+
+// ClusterMember is a node of a ThemisDB cluster
+type ClusterMember struct {
+	ID       string `json:"id"`
+	Endpoint string `json:"endpoint"`
+	Role     string `json:"role"`
+}
+
+// discovery holds the state of topology discovery
+type discovery struct {
+	opts     DiscoveryOptions
+	members  []ClusterMember
+	replicas bool
+	stop     chan struct{}
+}
+
+// newDiscovery returns the discovery state for opts, or nil if discovery is disabled.
+// Discovered followers only replace Config.Replicas if none were configured.
+func newDiscovery(opts *DiscoveryOptions, config Config) *discovery {
+	if opts == nil {
+		return nil
+	}
+	o := *opts
+	if len(o.Seeds) == 0 {
+		o.Seeds = config.Endpoints
+	}
+	if o.RefreshInterval <= 0 {
+		o.RefreshInterval = 30 * time.Second
+	}
+	return &discovery{opts: o, replicas: len(config.Replicas) == 0, stop: make(chan struct{})}
+}
+
+// Topology returns the cluster members learned by the last discovery, or nil if
+// discovery is disabled or has not completed yet
+func (c *Client) Topology() []ClusterMember {
+	if c.root != nil {
+		return c.root.Topology()
+	}
+	if c.discovery == nil {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]ClusterMember(nil), c.discovery.members...)
+}
+
+// RefreshTopology queries the seeds, then the known members, for the cluster
+// topology and routes requests to the current leader
+func (c *Client) RefreshTopology(ctx context.Context) error {
+	if c.root != nil {
+		return c.root.RefreshTopology(ctx)
+	}
+	if c.discovery == nil {
+		return fmt.Errorf("topology discovery is not configured")
+	}
+
+	c.mu.RLock()
+	candidates := append(append([]string(nil), c.discovery.opts.Seeds...), c.endpoints...)
+	c.mu.RUnlock()
+
+	var lastErr error
+	for _, endpoint := range candidates {
+		members, err := c.fetchMembers(ctx, strings.TrimSuffix(endpoint, "/"))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return c.applyTopology(members)
+	}
+	return fmt.Errorf("failed to discover cluster topology: %w", lastErr)
+}
+
+// fetchMembers reads /cluster/members from a single node
+func (c *Client) fetchMembers(ctx context.Context, endpoint string) ([]ClusterMember, error) {
+	req, err := c.newRequest("GET", "/cluster/members", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.transport.RoundTrip(ctx, endpoint, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, &StatusError{StatusCode: resp.StatusCode, Message: string(resp.Body)}
+	}
+
+	var result struct {
+		Members []ClusterMember `json:"members"`
+	}
+	if err := json.Unmarshal(resp.Body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return result.Members, nil
+}
+
+// applyTopology makes the leader the active endpoint, followed by the followers
+func (c *Client) applyTopology(members []ClusterMember) error {
+	var leader string
+	var followers []string
+	for _, m := range members {
+		switch m.Role {
+		case RoleLeader:
+			leader = m.Endpoint
+		case RoleFollower:
+			followers = append(followers, m.Endpoint)
+		}
+	}
+	if leader == "" {
+		return fmt.Errorf("cluster topology has no leader")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.discovery.members = members
+	c.endpoints = append([]string{leader}, followers...)
+	c.activeIdx = 0
+	if c.discovery.replicas {
+		c.replicas = followers
+	}
+	return nil
+}
+
+// runDiscovery refreshes the topology until the client is closed
+func (c *Client) runDiscovery() {
+	ticker := time.NewTicker(c.discovery.opts.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeouts.Read)
+		c.RefreshTopology(ctx)
+		cancel()
+
+		select {
+		case <-c.discovery.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
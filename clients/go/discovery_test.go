@@ -0,0 +1,89 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clusterNode is a fake node that reports a shared topology and counts writes
+type clusterNode struct {
+	*httptest.Server
+	writes int32
+}
+
+func TestClient_Discovery(t *testing.T) {
+	var mu sync.Mutex
+	var members []ClusterMember
+	newNode := func() *clusterNode {
+		n := &clusterNode{}
+		n.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/cluster/members" {
+				mu.Lock()
+				defer mu.Unlock()
+				json.NewEncoder(w).Encode(map[string]interface{}{"members": members})
+				return
+			}
+			if r.Method == "PUT" {
+				atomic.AddInt32(&n.writes, 1)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		return n
+	}
+	a, b := newNode(), newNode()
+	defer a.Close()
+	defer b.Close()
+	setTopology := func(leader, follower *clusterNode) {
+		mu.Lock()
+		defer mu.Unlock()
+		members = []ClusterMember{
+			{ID: "n1", Endpoint: leader.URL, Role: RoleLeader},
+			{ID: "n2", Endpoint: follower.URL, Role: RoleFollower},
+		}
+	}
+	setTopology(a, b)
+
+	client := NewClient(Config{
+		Endpoints: []string{b.URL},
+		Discovery: &DiscoveryOptions{RefreshInterval: time.Hour},
+	})
+	defer client.Close()
+	ctx := context.Background()
+
+	require.NoError(t, client.RefreshTopology(ctx))
+	assert.Len(t, client.Topology(), 2)
+	require.NoError(t, client.Put(ctx, "relational", "users", "1", map[string]string{}))
+	assert.EqualValues(t, 1, atomic.LoadInt32(&a.writes))
+
+	// leader failover
+	setTopology(b, a)
+	require.NoError(t, client.RefreshTopology(ctx))
+	require.NoError(t, client.Put(ctx, "relational", "users", "1", map[string]string{}))
+	assert.EqualValues(t, 1, atomic.LoadInt32(&b.writes))
+	assert.Equal(t, b.URL, client.Topology()[0].Endpoint)
+}
+
+func TestClient_DiscoveryNoLeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"members":[{"id":"n1","endpoint":"http://n1","role":"follower"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		Endpoints: []string{server.URL},
+		Discovery: &DiscoveryOptions{RefreshInterval: time.Hour},
+	})
+	defer client.Close()
+
+	assert.Error(t, client.RefreshTopology(context.Background()))
+	assert.Error(t, NewClient(Config{}).RefreshTopology(context.Background()))
+}
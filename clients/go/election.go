@@ -0,0 +1,235 @@
+package themisdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ElectionOptions holds leader election configuration
+type ElectionOptions struct {
+	// TTL is how long leadership survives without renewal (default: 15s)
+	TTL time.Duration
+	// RetryInterval is how often followers retry and observers poll (default: TTL/3)
+	RetryInterval time.Duration
+}
+
+// Election elects a single leader among candidates sharing an election name.
+// Leadership is a lease that the leader renews automatically every TTL/3;
+// each new term carries a higher fencing token.
+type Election struct {
+	client    *Client
+	name      string
+	candidate string
+	opts      ElectionOptions
+
+	mu    sync.Mutex
+	lease *Lease
+	stop  chan struct{}
+	done  chan struct{}
+	lost  chan struct{}
+}
+
+// Election returns a leader election for name in which this process runs as candidate
+func (c *Client) Election(name, candidate string, opts ElectionOptions) *Election {
+	if opts.TTL <= 0 {
+		opts.TTL = 15 * time.Second
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = opts.TTL / 3
+	}
+	return &Election{client: c, name: "election/" + name, candidate: candidate, opts: opts}
+}
+
+// Campaign blocks until the candidate becomes leader or ctx is done, and returns the
+// fencing token of the new term. Leadership is renewed in the background until Resign
+// is called or renewal fails, which closes the channel returned by Lost.
+func (e *Election) Campaign(ctx context.Context) (int64, error) {
+	for {
+		token, elected, err := e.TryCampaign(ctx)
+		if err != nil || elected {
+			return token, err
+		}
+
+		select {
+		case <-time.After(e.opts.RetryInterval):
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// TryCampaign makes a single attempt to become leader and reports whether the
+// candidate leads, returning the fencing token of its term if so
+func (e *Election) TryCampaign(ctx context.Context) (token int64, elected bool, err error) {
+	e.mu.Lock()
+	if e.lease != nil {
+		token := e.lease.Token
+		e.mu.Unlock()
+		return token, true, nil
+	}
+	e.mu.Unlock()
+
+	lease, err := e.client.AcquireLease(ctx, e.name, e.candidate, e.opts.TTL)
+	if errors.Is(err, ErrLeaseHeld) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to campaign for %s: %w", e.name, err)
+	}
+	e.elected(lease)
+	return lease.Token, true, nil
+}
+
+// CampaignWithin is Campaign bounded by d. It returns a *TimeoutError matching
+// ErrTimeout if the candidate was not elected within d.
+func (e *Election) CampaignWithin(ctx context.Context, d time.Duration) (int64, error) {
+	var token int64
+	err := within(ctx, "campaign for "+e.name, d, func(ctx context.Context) (err error) {
+		token, err = e.Campaign(ctx)
+		return err
+	})
+	return token, err
+}
+
+// elected records the lease of a new term and starts renewing it
+func (e *Election) elected(lease *Lease) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.lease = lease
+	e.stop = make(chan struct{})
+	e.done = make(chan struct{})
+	e.lost = make(chan struct{})
+	go e.renew(lease, e.stop, e.done, e.lost)
+}
+
+// renew extends the lease every TTL/3 until stopped, closing lost if the lease lapses
+func (e *Election) renew(lease *Lease, stop, done, lost chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(e.opts.TTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), e.opts.TTL/3)
+		renewed, err := e.client.RenewLease(ctx, lease, e.opts.TTL)
+		cancel()
+
+		switch {
+		case err == nil:
+			lease = renewed
+			e.mu.Lock()
+			e.lease = renewed
+			e.mu.Unlock()
+		case errors.Is(err, ErrLeaseLost) || !time.Now().Before(lease.ExpiresAt):
+			e.mu.Lock()
+			e.lease = nil
+			e.mu.Unlock()
+			close(lost)
+			return
+		}
+	}
+}
+
+// IsLeader reports whether the candidate currently holds leadership
+func (e *Election) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lease != nil
+}
+
+// Token returns the fencing token of the current term, zero if not leader
+func (e *Election) Token() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.lease == nil {
+		return 0
+	}
+	return e.lease.Token
+}
+
+// Lost returns a channel that is closed when leadership won by the last Campaign is lost.
+// It returns nil if Campaign has not succeeded yet.
+func (e *Election) Lost() <-chan struct{} {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lost
+}
+
+// Resign stops renewing and releases leadership so another candidate can take over immediately
+func (e *Election) Resign(ctx context.Context) error {
+	e.mu.Lock()
+	stop, done := e.stop, e.done
+	e.stop = nil
+	e.mu.Unlock()
+	if stop == nil {
+		return nil
+	}
+	close(stop)
+	<-done
+
+	e.mu.Lock()
+	lease := e.lease
+	e.lease = nil
+	e.mu.Unlock()
+	if lease == nil {
+		return nil
+	}
+	if err := e.client.ReleaseLease(ctx, lease); err != nil && !errors.Is(err, ErrLeaseLost) {
+		return fmt.Errorf("failed to resign from %s: %w", e.name, err)
+	}
+	return nil
+}
+
+// Leader returns the lease of the current leader, or nil if there is none
+func (e *Election) Leader(ctx context.Context) (*Lease, error) {
+	lease, err := e.client.GetLease(ctx, e.name)
+	if err != nil || lease == nil || !time.Now().Before(lease.ExpiresAt) {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// Observe polls the election every RetryInterval and sends the current leader whenever
+// it changes; a zero Lease means there is no leader. The channel is closed when ctx is done.
+func (e *Election) Observe(ctx context.Context) <-chan Lease {
+	ch := make(chan Lease, 1)
+	go func() {
+		defer close(ch)
+
+		var last Lease
+		first := true
+		for {
+			leader, err := e.Leader(ctx)
+			if err == nil {
+				var current Lease
+				if leader != nil {
+					current = *leader
+				}
+				if first || current.Holder != last.Holder || current.Token != last.Token {
+					select {
+					case ch <- current:
+					case <-ctx.Done():
+						return
+					}
+					last, first = current, false
+				}
+			}
+
+			select {
+			case <-time.After(e.opts.RetryInterval):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,193 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// leaseServer is an in-memory implementation of the lease endpoints
+type leaseServer struct {
+	mu     sync.Mutex
+	leases map[string]*Lease
+	tokens int64
+	// rejectRenew makes renewals fail as if the lease was taken over
+	rejectRenew bool
+}
+
+func (s *leaseServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Method == "GET" {
+		name, _ := DecodeKey(strings.TrimPrefix(r.URL.EscapedPath(), "/lease/"))
+		lease, ok := s.leases[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(lease)
+		return
+	}
+
+	var body struct {
+		Name   string `json:"name"`
+		Holder string `json:"holder"`
+		Token  int64  `json:"token"`
+		TTL    int64  `json:"ttl_ms"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	current := s.leases[body.Name]
+	live := current != nil && time.Now().Before(current.ExpiresAt)
+	expires := time.Now().Add(time.Duration(body.TTL) * time.Millisecond)
+
+	switch r.URL.Path {
+	case "/lease/acquire":
+		if live && current.Holder != body.Holder {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		s.tokens++
+		current = &Lease{Name: body.Name, Holder: body.Holder, Token: s.tokens, ExpiresAt: expires}
+		s.leases[body.Name] = current
+	case "/lease/renew":
+		if s.rejectRenew || current == nil || current.Token != body.Token {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		current.ExpiresAt = expires
+	case "/lease/release":
+		if current != nil && current.Token == body.Token {
+			delete(s.leases, body.Name)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	json.NewEncoder(w).Encode(current)
+}
+
+func newLeaseClient(t *testing.T) (*Client, *leaseServer) {
+	server := &leaseServer{leases: make(map[string]*Lease)}
+	return newTestClient(t, server.serveHTTP), server
+}
+
+func TestClient_Leases(t *testing.T) {
+	client, _ := newLeaseClient(t)
+	ctx := context.Background()
+
+	lease, err := client.AcquireLease(ctx, "reindex", "worker-1", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), lease.Token)
+
+	_, err = client.AcquireLease(ctx, "reindex", "worker-2", time.Minute)
+	assert.ErrorIs(t, err, ErrLeaseHeld)
+
+	current, err := client.GetLease(ctx, "reindex")
+	require.NoError(t, err)
+	assert.Equal(t, "worker-1", current.Holder)
+
+	require.NoError(t, client.ReleaseLease(ctx, lease))
+	current, err = client.GetLease(ctx, "reindex")
+	require.NoError(t, err)
+	assert.Nil(t, current)
+}
+
+func TestElection_Failover(t *testing.T) {
+	client, _ := newLeaseClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	opts := ElectionOptions{TTL: 90 * time.Millisecond, RetryInterval: 10 * time.Millisecond}
+
+	a := client.Election("scheduler", "node-a", opts)
+	b := client.Election("scheduler", "node-b", opts)
+	observed := a.Observe(ctx)
+
+	tokenA, err := a.Campaign(ctx)
+	require.NoError(t, err)
+	assert.True(t, a.IsLeader())
+
+	elected := make(chan int64, 1)
+	go func() {
+		token, err := b.Campaign(ctx)
+		assert.NoError(t, err)
+		elected <- token
+	}()
+
+	// renewals keep node-a in office for several TTLs
+	time.Sleep(3 * opts.TTL)
+	assert.True(t, a.IsLeader())
+	assert.False(t, b.IsLeader())
+
+	require.NoError(t, a.Resign(ctx))
+	tokenB := <-elected
+	assert.Greater(t, tokenB, tokenA)
+	assert.Equal(t, tokenB, b.Token())
+
+	var holders []string
+	for lease := range observed {
+		if lease.Holder != "" && (len(holders) == 0 || holders[len(holders)-1] != lease.Holder) {
+			holders = append(holders, lease.Holder)
+		}
+		if lease.Holder == "node-b" {
+			break
+		}
+	}
+	assert.Equal(t, "node-b", holders[len(holders)-1])
+	require.NoError(t, b.Resign(ctx))
+}
+
+func TestElection_TryCampaign(t *testing.T) {
+	client, _ := newLeaseClient(t)
+	ctx := context.Background()
+	opts := ElectionOptions{TTL: time.Second, RetryInterval: 5 * time.Millisecond}
+	a := client.Election("scheduler", "node-a", opts)
+	b := client.Election("scheduler", "node-b", opts)
+
+	token, elected, err := a.TryCampaign(ctx)
+	require.NoError(t, err)
+	assert.True(t, elected)
+	again, elected, err := a.TryCampaign(ctx)
+	require.NoError(t, err)
+	assert.True(t, elected)
+	assert.Equal(t, token, again)
+
+	_, elected, err = b.TryCampaign(ctx)
+	require.NoError(t, err)
+	assert.False(t, elected)
+	_, err = b.CampaignWithin(ctx, 30*time.Millisecond)
+	assert.ErrorIs(t, err, ErrTimeout)
+
+	require.NoError(t, a.Resign(ctx))
+	tokenB, err := b.CampaignWithin(ctx, time.Second)
+	require.NoError(t, err)
+	assert.Greater(t, tokenB, token)
+	require.NoError(t, b.Resign(ctx))
+}
+
+func TestElection_Lost(t *testing.T) {
+	client, server := newLeaseClient(t)
+	ctx := context.Background()
+
+	e := client.Election("scheduler", "node-a", ElectionOptions{TTL: 30 * time.Millisecond})
+	_, err := e.Campaign(ctx)
+	require.NoError(t, err)
+
+	server.mu.Lock()
+	server.rejectRenew = true
+	server.mu.Unlock()
+
+	select {
+	case <-e.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("leadership loss not detected")
+	}
+	assert.False(t, e.IsLeader())
+	assert.NoError(t, e.Resign(ctx))
+}
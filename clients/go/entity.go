@@ -0,0 +1,270 @@
+package themisdb
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// EntityCollection is implemented by entity types that name their model and collection.
+// Types without it are stored in the relational model, in a collection named after
+// the type in snake case, e.g. OrderItem in "order_item".
+type EntityCollection interface {
+	ThemisCollection() (model, collection string)
+}
+
+// entityMeta locates the themis-tagged fields of an entity type
+type entityMeta struct {
+	uuid, rev, createdAt int
+	// stripped are the JSON names of the uuid and rev fields, which are not stored
+	stripped []string
+}
+
+// entityMetas caches entityMeta by struct type
+var entityMetas sync.Map
+
+// entityMetaOf returns the field layout of the struct type t. Fields are tagged
+// themis:"uuid" (string), themis:"rev" (string), and themis:"created_at" (time.Time).
+func entityMetaOf(t reflect.Type) (*entityMeta, error) {
+	if cached, ok := entityMetas.Load(t); ok {
+		return cached.(*entityMeta), nil
+	}
+
+	meta := &entityMeta{uuid: -1, rev: -1, createdAt: -1}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("themis")
+		if tag == "" {
+			continue
+		}
+		want := reflect.TypeOf("")
+		switch tag {
+		case "uuid":
+			meta.uuid = i
+		case "rev":
+			meta.rev = i
+		case "created_at":
+			meta.createdAt = i
+			want = reflect.TypeOf(time.Time{})
+		default:
+			return nil, fmt.Errorf("unknown themis tag %q on %s.%s: %w", tag, t.Name(), field.Name, ErrInvalidInput)
+		}
+		if field.Type != want {
+			return nil, fmt.Errorf("field %s.%s tagged %q must be of type %s: %w", t.Name(), field.Name, tag, want, ErrInvalidInput)
+		}
+		if tag == "uuid" || tag == "rev" {
+			meta.stripped = append(meta.stripped, jsonFieldName(field))
+		}
+	}
+	if meta.uuid < 0 {
+		return nil, fmt.Errorf("type %s has no field tagged themis:\"uuid\": %w", t.Name(), ErrInvalidInput)
+	}
+
+	entityMetas.Store(t, meta)
+	return meta, nil
+}
+
+// jsonFieldName returns the key encoding/json uses for field
+func jsonFieldName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" {
+		return name
+	}
+	return field.Name
+}
+
+// entityLocation returns the model, collection, and struct value of an entity,
+// which must be a non-nil pointer to a struct
+func entityLocation(entity interface{}) (string, string, reflect.Value, *entityMeta, error) {
+	v := reflect.ValueOf(entity)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return "", "", reflect.Value{}, nil, fmt.Errorf("entity must be a non-nil pointer to a struct, got %T: %w", entity, ErrInvalidInput)
+	}
+	meta, err := entityMetaOf(v.Elem().Type())
+	if err != nil {
+		return "", "", reflect.Value{}, nil, err
+	}
+	if named, ok := entity.(EntityCollection); ok {
+		model, collection := named.ThemisCollection()
+		return model, collection, v.Elem(), meta, nil
+	}
+	return ModelRelational, snakeCase(v.Elem().Type().Name()), v.Elem(), meta, nil
+}
+
+// snakeCase converts a Go type name such as OrderItem to order_item
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// PutEntity stores a struct tagged with themis:"uuid", deriving model and collection
+// from its type. An empty uuid field is filled with a random UUID and a zero
+// created_at field with the current time. If the entity has a non-empty rev field,
+// the write only succeeds if the stored revision still matches (ErrConflict otherwise);
+// afterwards rev holds the new revision if the server reports it.
+func (c *Client) PutEntity(ctx context.Context, entity interface{}) error {
+	if ctx, tx := c.contextTx(ctx); tx != nil {
+		return tx.PutEntity(ctx, entity)
+	}
+	return c.putEntity(ctx, entity, nil)
+}
+
+// GetEntity loads the entity whose uuid field is set, populating its rev field
+func (c *Client) GetEntity(ctx context.Context, entity interface{}) error {
+	if ctx, tx := c.contextTx(ctx); tx != nil {
+		return tx.GetEntity(ctx, entity)
+	}
+	return c.getEntity(ctx, entity, nil)
+}
+
+// DeleteEntity removes the entity whose uuid field is set
+func (c *Client) DeleteEntity(ctx context.Context, entity interface{}) error {
+	model, collection, v, meta, err := entityLocation(entity)
+	if err != nil {
+		return err
+	}
+	return c.Delete(ctx, model, collection, v.Field(meta.uuid).String())
+}
+
+// putEntity implements PutEntity with extra request headers
+func (c *Client) putEntity(ctx context.Context, entity interface{}, headers map[string]string) error {
+	model, collection, v, meta, err := entityLocation(entity)
+	if err != nil {
+		return err
+	}
+
+	uuidField := v.Field(meta.uuid)
+	if uuidField.String() == "" {
+		uuidField.SetString(newUUID())
+	}
+	uuid := uuidField.String()
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return err
+	}
+	if meta.createdAt >= 0 && v.Field(meta.createdAt).Interface().(time.Time).IsZero() {
+		v.Field(meta.createdAt).Set(reflect.ValueOf(time.Now().UTC()))
+	}
+
+	data, err := entityDocument(entity, meta)
+	if err != nil {
+		return err
+	}
+	if err := c.validateDocument(model, collection, data); err != nil {
+		return err
+	}
+
+	rev := ""
+	if meta.rev >= 0 {
+		rev = v.Field(meta.rev).String()
+	}
+	if rev != "" {
+		merged := map[string]string{"If-Match": rev}
+		for key, value := range headers {
+			merged[key] = value
+		}
+		headers = merged
+	}
+
+	resp, err := c.send(ctx, "PUT", entityPath(model, collection, uuid), data, headers)
+	if rev != "" && resp != nil && (resp.StatusCode == http.StatusPreconditionFailed || resp.StatusCode == http.StatusConflict) {
+		return ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	if meta.rev >= 0 && resp.Header.Get("ETag") != "" {
+		v.Field(meta.rev).SetString(resp.Header.Get("ETag"))
+	}
+	return nil
+}
+
+// getEntity implements GetEntity with extra request headers
+func (c *Client) getEntity(ctx context.Context, entity interface{}, headers map[string]string) error {
+	model, collection, v, meta, err := entityLocation(entity)
+	if err != nil {
+		return err
+	}
+	uuid := v.Field(meta.uuid).String()
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return err
+	}
+
+	req, err := c.newRequest("GET", entityPath(model, collection, uuid), nil, headers)
+	if err != nil {
+		return err
+	}
+	req.Idempotent = headers["X-Transaction-Id"] == ""
+	resp, err := c.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(resp.Body, entity); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	v.Field(meta.uuid).SetString(uuid)
+	if meta.rev >= 0 {
+		v.Field(meta.rev).SetString(resp.Header.Get("ETag"))
+	}
+	return nil
+}
+
+// entityDocument encodes an entity without its uuid and rev fields
+func entityDocument(entity interface{}, meta *entityMeta) (map[string]interface{}, error) {
+	raw, err := json.Marshal(entity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal entity: %w", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("failed to marshal entity: %w", err)
+	}
+	for _, name := range meta.stripped {
+		delete(doc, name)
+	}
+	return doc, nil
+}
+
+// newUUID returns a random version 4 UUID
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// PutEntity stores a tagged struct within the transaction (see Client.PutEntity)
+func (tx *Transaction) PutEntity(ctx context.Context, entity interface{}) error {
+	ctx, headers, release, err := tx.acquire(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return tx.client.putEntity(ctx, entity, headers)
+}
+
+// GetEntity loads a tagged struct within the transaction (see Client.GetEntity)
+func (tx *Transaction) GetEntity(ctx context.Context, entity interface{}) error {
+	ctx, headers, release, err := tx.acquire(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return tx.client.getEntity(ctx, entity, headers)
+}
@@ -0,0 +1,88 @@
+package themisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type OrderItem struct {
+	ID        string    `json:"id" themis:"uuid"`
+	Rev       string    `json:"rev,omitempty" themis:"rev"`
+	CreatedAt time.Time `json:"created_at" themis:"created_at"`
+	SKU       string    `json:"sku"`
+	Quantity  int       `json:"quantity"`
+}
+
+type taggedUser struct {
+	UUID string `themis:"uuid"`
+	Name string `json:"name"`
+}
+
+func (taggedUser) ThemisCollection() (string, string) {
+	return ModelDocument, "users"
+}
+
+func TestClient_PutGetEntity(t *testing.T) {
+	client, store := newMemoryClient(t)
+	ctx := context.Background()
+
+	item := &OrderItem{SKU: "A-1", Quantity: 2}
+	require.NoError(t, client.PutEntity(ctx, item))
+	assert.Len(t, item.ID, 36)
+	assert.False(t, item.CreatedAt.IsZero())
+
+	doc := store.docs["/api/relational/order_item/"+item.ID]
+	require.NotNil(t, doc)
+	assert.NotContains(t, doc, "id")
+	assert.NotContains(t, doc, "rev")
+	assert.Equal(t, "A-1", doc["sku"])
+
+	loaded := &OrderItem{ID: item.ID}
+	require.NoError(t, client.GetEntity(ctx, loaded))
+	assert.Equal(t, 2, loaded.Quantity)
+	assert.True(t, item.CreatedAt.Equal(loaded.CreatedAt))
+	assert.NotEmpty(t, loaded.Rev)
+
+	// writes with a stale revision fail
+	loaded.Quantity = 3
+	require.NoError(t, client.PutEntity(ctx, loaded))
+	stale := &OrderItem{ID: item.ID, Rev: `"1"`, SKU: "A-1"}
+	assert.ErrorIs(t, client.PutEntity(ctx, stale), ErrConflict)
+
+	require.NoError(t, client.DeleteEntity(ctx, loaded))
+	assert.ErrorIs(t, client.GetEntity(ctx, loaded), ErrNotFound)
+}
+
+func TestClient_EntityCollection(t *testing.T) {
+	client, store := newMemoryClient(t)
+	ctx := context.Background()
+
+	user := &taggedUser{UUID: "u-1", Name: "alice"}
+	require.NoError(t, client.PutEntity(ctx, user))
+	assert.Contains(t, store.docs, "/api/document/users/u-1")
+
+	tx := &Transaction{client: client, transactionID: "tx-1", active: true}
+	loaded := &taggedUser{UUID: "u-1"}
+	require.NoError(t, tx.GetEntity(ctx, loaded))
+	assert.Equal(t, "alice", loaded.Name)
+}
+
+func TestEntityMapping_Invalid(t *testing.T) {
+	client := NewClient(Config{})
+	ctx := context.Background()
+
+	var untagged struct{ Name string }
+	assert.ErrorIs(t, client.PutEntity(ctx, &untagged), ErrInvalidInput)
+	assert.ErrorIs(t, client.PutEntity(ctx, taggedUser{UUID: "u-1"}), ErrInvalidInput)
+
+	var wrongType struct {
+		ID int `themis:"uuid"`
+	}
+	assert.ErrorIs(t, client.PutEntity(ctx, &wrongType), ErrInvalidInput)
+	assert.Equal(t, "order_item", snakeCase("OrderItem"))
+	assert.Equal(t, "http_server", snakeCase("HTTPServer"))
+}
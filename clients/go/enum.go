@@ -0,0 +1,118 @@
+package themisdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// EnumError reports a document field holding a value outside its declared enum
+type EnumError struct {
+	Model      string
+	Collection string
+	Field      string
+	Value      interface{}
+	Allowed    []string
+}
+
+// Error implements error
+func (e *EnumError) Error() string {
+	return fmt.Sprintf("invalid value %v for enum field %s of %s/%s (allowed: %s)",
+		e.Value, e.Field, e.Model, e.Collection, strings.Join(e.Allowed, ", "))
+}
+
+// Unwrap allows matching with errors.Is(err, ErrInvalidEnumValue)
+func (e *EnumError) Unwrap() error {
+	return ErrInvalidEnumValue
+}
+
+// enumRegistry holds declared enum fields per model/collection
+type enumRegistry struct {
+	mu     sync.RWMutex
+	fields map[string]map[string][]string
+}
+
+// RegisterEnum declares the allowed values of a document field.
+// Writes through Put are validated client-side against all registered enums of the collection.
+// Nested fields use dotted paths, e.g. "shipping.status".
+func (c *Client) RegisterEnum(model, collection, field string, values ...string) {
+	c.enums.mu.Lock()
+	defer c.enums.mu.Unlock()
+
+	if c.enums.fields == nil {
+		c.enums.fields = make(map[string]map[string][]string)
+	}
+	key := model + "/" + collection
+	if c.enums.fields[key] == nil {
+		c.enums.fields[key] = make(map[string][]string)
+	}
+	c.enums.fields[key][field] = append([]string(nil), values...)
+}
+
+// validateEnums checks data against the enums registered for model/collection
+func (c *Client) validateEnums(model, collection string, data interface{}) error {
+	c.enums.mu.RLock()
+	fields := c.enums.fields[model+"/"+collection]
+	c.enums.mu.RUnlock()
+	if len(fields) == 0 || data == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal document for validation: %w", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, ok := lookupField(doc, name)
+		if !ok || value == nil {
+			continue
+		}
+		allowed := fields[name]
+		if !containsValue(allowed, value) {
+			return &EnumError{Model: model, Collection: collection, Field: name, Value: value, Allowed: allowed}
+		}
+	}
+	return nil
+}
+
+// lookupField resolves a dotted field path within a decoded document
+func lookupField(doc map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = doc
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// containsValue reports whether value is one of the allowed enum values
+func containsValue(allowed []string, value interface{}) bool {
+	s, ok := value.(string)
+	if !ok {
+		s = fmt.Sprint(value)
+	}
+	for _, a := range allowed {
+		if a == s {
+			return true
+		}
+	}
+	return false
+}
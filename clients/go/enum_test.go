@@ -0,0 +1,46 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_RegisterEnum(t *testing.T) {
+	var writes int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writes++
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client.RegisterEnum("relational", "orders", "status", "pending", "shipped")
+	client.RegisterEnum("relational", "orders", "shipping.carrier", "dhl", "ups")
+	ctx := context.Background()
+
+	require.NoError(t, client.Put(ctx, "relational", "orders", "1", map[string]interface{}{"status": "pending"}))
+	require.NoError(t, client.Put(ctx, "relational", "orders", "2", map[string]interface{}{"total": 10}))
+	require.NoError(t, client.Put(ctx, "relational", "users", "1", map[string]interface{}{"status": "anything"}))
+
+	err := client.Put(ctx, "relational", "orders", "3", map[string]interface{}{"status": "lost"})
+	assert.ErrorIs(t, err, ErrInvalidEnumValue)
+	var enumErr *EnumError
+	require.ErrorAs(t, err, &enumErr)
+	assert.Equal(t, "status", enumErr.Field)
+	assert.Equal(t, "lost", enumErr.Value)
+
+	err = client.Put(ctx, "relational", "orders", "4", struct {
+		Shipping struct {
+			Carrier string `json:"carrier"`
+		} `json:"shipping"`
+	}{Shipping: struct {
+		Carrier string `json:"carrier"`
+	}{Carrier: "fedex"}})
+	assert.ErrorIs(t, err, ErrInvalidEnumValue)
+
+	tx := &Transaction{client: client, transactionID: "tx-1", active: true}
+	assert.ErrorIs(t, tx.Put(ctx, "relational", "orders", "5", map[string]string{"status": "lost"}), ErrInvalidEnumValue)
+
+	assert.Equal(t, 3, writes)
+}
@@ -0,0 +1,229 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+// projectionsCollection stores the checkpoints of projections
+const projectionsCollection = "_projections"
+
+// ProjectionFunc applies an event to read models. It runs in the transaction that also
+// advances the projection's checkpoint, so it must only write through tx; it may run
+// more than once for an event if the transaction is retried.
+type ProjectionFunc func(ctx context.Context, tx *themisdb.Transaction, event Event) error
+
+// ReadModel is a collection maintained by a projection
+type ReadModel struct {
+	Model      string
+	Collection string
+}
+
+// Projection maintains read models from the events of the store
+type Projection struct {
+	// Name identifies the checkpoint of the projection
+	Name string
+	// Stream restricts the projection to one stream; empty projects all streams
+	Stream string
+	// EventTypes restricts the projection to events of these types; empty projects all
+	EventTypes []string
+	// ReadModels are the collections Handle writes; RebuildProjection clears them
+	ReadModels []ReadModel
+	Handle     ProjectionFunc
+}
+
+// checkpoint is the stored progress of a projection
+type checkpoint struct {
+	// Position is the changefeed position of the last event handled
+	Position uint64 `json:"position"`
+	// Replayed holds the version of each stream replayed by the last rebuild; events up
+	// to these versions are skipped until Position reaches ReplayedUntil
+	Replayed      map[string]int64 `json:"replayed,omitempty"`
+	ReplayedUntil uint64           `json:"replayed_until,omitempty"`
+	UpdatedAt     int64            `json:"updated_at"`
+}
+
+// RunProjection applies the events appended after the projection's checkpoint to its
+// read models in commit order, then follows new events until ctx is done. Each event is
+// handled in its own transaction together with the checkpoint update, so a restarted
+// or concurrently running instance continues exactly where the last commit left off.
+// A failing Handle stops the projection and is returned; the event is handled again
+// on the next run. A projection without a checkpoint starts at the oldest event still
+// retained by the changefeed; RebuildProjection starts it from the stored events.
+func (s *Store) RunProjection(ctx context.Context, p Projection) error {
+	if err := validateProjection(p); err != nil {
+		return err
+	}
+	cp, err := s.loadCheckpoint(ctx, p.Name)
+	if err != nil {
+		return err
+	}
+
+	err = s.SubscribeAll(ctx, cp.Position, func(ctx context.Context, e Event) error {
+		return s.project(ctx, p, e)
+	})
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("projection %s stopped: %w", p.Name, err)
+	}
+	return err
+}
+
+// RebuildProjection clears the read models of p, resets its checkpoint, and applies
+// every stored event again, stream by stream in version order. RunProjection then
+// continues with the events appended since the rebuild started, skipping those the
+// rebuild already applied. A projection must not run while it is rebuilt; an
+// interrupted rebuild is started over.
+func (s *Store) RebuildProjection(ctx context.Context, p Projection) error {
+	if err := validateProjection(p); err != nil {
+		return err
+	}
+	start, err := s.client.LatestChangeSequence(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild projection %s: %w", p.Name, err)
+	}
+	for _, rm := range p.ReadModels {
+		if err := s.clear(ctx, rm); err != nil {
+			return fmt.Errorf("failed to rebuild projection %s: %w", p.Name, err)
+		}
+	}
+
+	replayed := map[string]int64{}
+	opts := themisdb.ScanOptions{BatchSize: 500}
+	if p.Stream != "" {
+		opts.Prefix = p.Stream + "/"
+	}
+	it := s.client.Scan(ctx, themisdb.ModelRelational, eventsCollection, opts)
+	for it.Next() {
+		var r record
+		if err := it.Decode(&r); err != nil {
+			return fmt.Errorf("failed to rebuild projection %s: failed to decode event %s: %w", p.Name, it.UUID(), err)
+		}
+		e := r.event()
+		if p.matches(e) {
+			err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *themisdb.Transaction) error {
+				return p.Handle(ctx, tx, e)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to rebuild projection %s: event %d of stream %s: %w", p.Name, e.Version, e.Stream, err)
+			}
+		}
+		replayed[e.Stream] = e.Version
+	}
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("failed to rebuild projection %s: %w", p.Name, err)
+	}
+
+	end, err := s.client.LatestChangeSequence(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild projection %s: %w", p.Name, err)
+	}
+	cp := checkpoint{Position: start, UpdatedAt: time.Now().UnixMilli()}
+	if end > start {
+		cp.Replayed, cp.ReplayedUntil = replayed, end
+	}
+	if err := s.client.Put(ctx, themisdb.ModelRelational, projectionsCollection, p.Name, cp); err != nil {
+		return fmt.Errorf("failed to rebuild projection %s: %w", p.Name, err)
+	}
+	return nil
+}
+
+// ProjectionPosition returns the changefeed position of the last event handled by the
+// projection name, 0 if it has not run. Its distance to Client.LatestChangeSequence is
+// the lag of the read models.
+func (s *Store) ProjectionPosition(ctx context.Context, name string) (uint64, error) {
+	cp, err := s.loadCheckpoint(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	return cp.Position, nil
+}
+
+// project handles e and advances the checkpoint in one transaction, unless the
+// checkpoint shows that e was handled already
+func (s *Store) project(ctx context.Context, p Projection, e Event) error {
+	return s.client.RunTransaction(ctx, func(ctx context.Context, tx *themisdb.Transaction) error {
+		var cp checkpoint
+		err := tx.Get(ctx, themisdb.ModelRelational, projectionsCollection, p.Name, &cp)
+		if err != nil && !errors.Is(err, themisdb.ErrNotFound) {
+			return err
+		}
+		if cp.Position >= e.Position {
+			return nil
+		}
+
+		skip := !p.matches(e) || (cp.Replayed != nil && e.Version <= cp.Replayed[e.Stream])
+		if !skip {
+			if err := p.Handle(ctx, tx, e); err != nil {
+				return err
+			}
+		}
+		cp.Position = e.Position
+		cp.UpdatedAt = time.Now().UnixMilli()
+		if cp.Position >= cp.ReplayedUntil {
+			cp.Replayed, cp.ReplayedUntil = nil, 0
+		}
+		return tx.Put(ctx, themisdb.ModelRelational, projectionsCollection, p.Name, cp)
+	})
+}
+
+// loadCheckpoint returns the checkpoint of the projection name, zero if it has none
+func (s *Store) loadCheckpoint(ctx context.Context, name string) (checkpoint, error) {
+	var cp checkpoint
+	err := s.client.Get(ctx, themisdb.ModelRelational, projectionsCollection, name, &cp)
+	if err != nil && !errors.Is(err, themisdb.ErrNotFound) {
+		return cp, fmt.Errorf("failed to load checkpoint of projection %s: %w", name, err)
+	}
+	return cp, nil
+}
+
+// clear deletes every document of a read model
+func (s *Store) clear(ctx context.Context, rm ReadModel) error {
+	var uuids []string
+	it := s.client.Scan(ctx, rm.Model, rm.Collection, themisdb.ScanOptions{BatchSize: 500})
+	for it.Next() {
+		uuids = append(uuids, it.UUID())
+	}
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("failed to clear %s/%s: %w", rm.Model, rm.Collection, err)
+	}
+	for _, uuid := range uuids {
+		if err := s.client.Delete(ctx, rm.Model, rm.Collection, uuid); err != nil && !errors.Is(err, themisdb.ErrNotFound) {
+			return fmt.Errorf("failed to clear %s/%s: %w", rm.Model, rm.Collection, err)
+		}
+	}
+	return nil
+}
+
+// matches reports whether the projection handles e
+func (p Projection) matches(e Event) bool {
+	if p.Stream != "" && e.Stream != p.Stream {
+		return false
+	}
+	if len(p.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range p.EventTypes {
+		if t == e.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// validateProjection checks the required fields of a projection
+func validateProjection(p Projection) error {
+	if p.Name == "" {
+		return &themisdb.ValidationError{Field: "projection", Value: p.Name, Reason: "must have a name"}
+	}
+	if p.Handle == nil {
+		return &themisdb.ValidationError{Field: "projection", Value: p.Name, Reason: "must have a Handle function"}
+	}
+	if p.Stream != "" {
+		return validateStream(p.Stream)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+	"github.com/makr-code/ThemisDB/clients/go/themistest"
+)
+
+// cartTotals projects the item quantity of every cart into the read model "cart_totals"
+func cartTotals() Projection {
+	return Projection{
+		Name:       "cart-totals",
+		EventTypes: []string{"ItemAdded"},
+		ReadModels: []ReadModel{{Model: "relational", Collection: "cart_totals"}},
+		Handle: func(ctx context.Context, tx *themisdb.Transaction, e Event) error {
+			var added itemAdded
+			if err := e.Decode(&added); err != nil {
+				return err
+			}
+			var total struct {
+				Quantity int `json:"quantity"`
+			}
+			if err := tx.Get(ctx, "relational", "cart_totals", e.Stream, &total); err != nil && !errors.Is(err, themisdb.ErrNotFound) {
+				return err
+			}
+			total.Quantity += added.Quantity
+			return tx.Put(ctx, "relational", "cart_totals", e.Stream, total)
+		},
+	}
+}
+
+// quantity returns the projected quantity of a cart
+func quantity(fake *themistest.Fake, cart string) float64 {
+	doc, _ := fake.Document("relational", "cart_totals", cart)
+	q, _ := doc["quantity"].(float64)
+	return q
+}
+
+func TestStore_RunProjection(t *testing.T) {
+	client, fake := themistest.NewClient(t)
+	store := New(client, Options{PollTimeout: time.Second})
+	ctx := context.Background()
+	add := func(cart string, n int) {
+		_, err := store.Append(ctx, cart, Any, EventData{Type: "ItemAdded", Data: itemAdded{SKU: "a", Quantity: n}})
+		require.NoError(t, err)
+	}
+	add("cart-1", 1)
+	add("cart-2", 5)
+	_, err := store.Append(ctx, "cart-1", Any, EventData{Type: "CartViewed"})
+	require.NoError(t, err)
+
+	runCtx, stop := context.WithCancel(ctx)
+	done := make(chan error)
+	go func() { done <- store.RunProjection(runCtx, cartTotals()) }()
+	assert.Eventually(t, func() bool { return quantity(fake, "cart-1") == 1 && quantity(fake, "cart-2") == 5 }, time.Second, 5*time.Millisecond)
+	add("cart-1", 2)
+	assert.Eventually(t, func() bool { return quantity(fake, "cart-1") == 3 }, time.Second, 5*time.Millisecond)
+	stop()
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	position, err := store.ProjectionPosition(ctx, "cart-totals")
+	require.NoError(t, err)
+	assert.NotZero(t, position)
+
+	// a restarted projection continues after its checkpoint
+	add("cart-2", 1)
+	runCtx, stop = context.WithCancel(ctx)
+	defer stop()
+	go store.RunProjection(runCtx, cartTotals())
+	assert.Eventually(t, func() bool { return quantity(fake, "cart-2") == 6 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, float64(3), quantity(fake, "cart-1"), "events before the checkpoint are not applied again")
+}
+
+func TestStore_RunProjectionHandlerError(t *testing.T) {
+	client, _ := themistest.NewClient(t)
+	store := New(client, Options{PollTimeout: time.Second})
+	ctx := context.Background()
+	_, err := store.Append(ctx, "cart-1", NoStream, EventData{Type: "ItemAdded"})
+	require.NoError(t, err)
+
+	failing := errors.New("read model unavailable")
+	p := Projection{Name: "failing", Handle: func(ctx context.Context, tx *themisdb.Transaction, e Event) error {
+		return failing
+	}}
+	err = store.RunProjection(ctx, p)
+	assert.ErrorIs(t, err, failing)
+	position, err := store.ProjectionPosition(ctx, "failing")
+	require.NoError(t, err)
+	assert.Zero(t, position, "the checkpoint does not advance past a failed event")
+
+	assert.ErrorIs(t, store.RunProjection(ctx, Projection{Name: "no-handler"}), themisdb.ErrInvalidInput)
+}
+
+func TestStore_RebuildProjection(t *testing.T) {
+	client, fake := themistest.NewClient(t)
+	store := New(client, Options{PollTimeout: time.Second})
+	ctx := context.Background()
+	for _, cart := range []string{"cart-1", "cart-1", "cart-2"} {
+		_, err := store.Append(ctx, cart, Any, EventData{Type: "ItemAdded", Data: itemAdded{SKU: "a", Quantity: 2}})
+		require.NoError(t, err)
+	}
+	require.NoError(t, fake.Seed("relational", "cart_totals", "cart-1", map[string]int{"quantity": 100}))
+	require.NoError(t, fake.Seed("relational", "cart_totals", "stale", map[string]int{"quantity": 1}))
+
+	require.NoError(t, store.RebuildProjection(ctx, cartTotals()))
+	assert.Equal(t, float64(4), quantity(fake, "cart-1"))
+	assert.Equal(t, float64(2), quantity(fake, "cart-2"))
+	_, ok := fake.Document("relational", "cart_totals", "stale")
+	assert.False(t, ok, "read models are cleared before the replay")
+
+	// running after the rebuild does not apply the replayed events again
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	go store.RunProjection(runCtx, cartTotals())
+	_, err := store.Append(ctx, "cart-2", Any, EventData{Type: "ItemAdded", Data: itemAdded{SKU: "a", Quantity: 1}})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return quantity(fake, "cart-2") == 3 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, float64(4), quantity(fake, "cart-1"))
+}
+
+func TestProjection_Project(t *testing.T) {
+	client, fake := themistest.NewClient(t)
+	store := New(client, Options{})
+	ctx := context.Background()
+	p := cartTotals()
+	require.NoError(t, client.Put(ctx, "relational", projectionsCollection, p.Name, checkpoint{
+		Position: 10, Replayed: map[string]int64{"cart-1": 3}, ReplayedUntil: 12,
+	}))
+	added := func(version int64, position uint64) Event {
+		return Event{Stream: "cart-1", Version: version, Type: "ItemAdded", Data: []byte(`{"quantity":1}`), Position: position}
+	}
+
+	require.NoError(t, store.project(ctx, p, added(2, 9)))
+	require.NoError(t, store.project(ctx, p, added(3, 11)))
+	assert.Zero(t, quantity(fake, "cart-1"), "events before the checkpoint or replayed by a rebuild are skipped")
+	require.NoError(t, store.project(ctx, p, added(4, 12)))
+	assert.Equal(t, float64(1), quantity(fake, "cart-1"))
+
+	doc, _ := fake.Document("relational", projectionsCollection, p.Name)
+	assert.Equal(t, float64(12), doc["position"])
+	assert.NotContains(t, doc, "replayed", "the replayed versions are dropped once the rebuild is passed")
+}
@@ -0,0 +1,99 @@
+package eventstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+// Snapshot is the state of a stream's aggregate as of a version
+type Snapshot struct {
+	Version int64
+	State   json.RawMessage
+	Time    time.Time
+}
+
+// snapshotRecord is the stored document of a snapshot
+type snapshotRecord struct {
+	Version int64           `json:"version"`
+	State   json.RawMessage `json:"state"`
+	Time    int64           `json:"time"`
+}
+
+// SaveSnapshot stores state as the state of stream after the event with version
+// version. Only the latest snapshot of a stream is kept; saving one older than the
+// stored snapshot does nothing.
+func (s *Store) SaveSnapshot(ctx context.Context, stream string, version int64, state interface{}) error {
+	if err := validateStream(stream); err != nil {
+		return err
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	err = s.client.RunTransaction(ctx, func(ctx context.Context, tx *themisdb.Transaction) error {
+		var stored snapshotRecord
+		err := tx.Get(ctx, themisdb.ModelRelational, snapshotsCollection, stream, &stored)
+		if err != nil && !errors.Is(err, themisdb.ErrNotFound) {
+			return err
+		}
+		if err == nil && stored.Version >= version {
+			return nil
+		}
+		return tx.Put(ctx, themisdb.ModelRelational, snapshotsCollection, stream, snapshotRecord{Version: version, State: data, Time: time.Now().UnixMilli()})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save snapshot of stream %s: %w", stream, err)
+	}
+	return nil
+}
+
+// LoadSnapshot returns the latest snapshot of stream, or nil if it has none
+func (s *Store) LoadSnapshot(ctx context.Context, stream string) (*Snapshot, error) {
+	if err := validateStream(stream); err != nil {
+		return nil, err
+	}
+	var stored snapshotRecord
+	err := s.client.Get(ctx, themisdb.ModelRelational, snapshotsCollection, stream, &stored)
+	if errors.Is(err, themisdb.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load snapshot of stream %s: %w", stream, err)
+	}
+	return &Snapshot{Version: stored.Version, State: stored.State, Time: time.UnixMilli(stored.Time)}, nil
+}
+
+// Load rebuilds the state of stream: it decodes the latest snapshot, if any, into state
+// and passes the events after it to apply in order. It returns the version of the
+// stream it read up to, the expected version for the next Append.
+func (s *Store) Load(ctx context.Context, stream string, state interface{}, apply func(Event) error) (int64, error) {
+	snap, err := s.LoadSnapshot(ctx, stream)
+	if err != nil {
+		return 0, err
+	}
+	var version int64
+	if snap != nil {
+		if err := json.Unmarshal(snap.State, state); err != nil {
+			return 0, fmt.Errorf("failed to decode snapshot of stream %s: %w", stream, err)
+		}
+		version = snap.Version
+	}
+
+	events, err := s.ReadForward(ctx, stream, version+1, 0)
+	if err != nil {
+		return 0, err
+	}
+	for _, e := range events {
+		if err := apply(e); err != nil {
+			return 0, fmt.Errorf("failed to apply event %d of stream %s: %w", e.Version, stream, err)
+		}
+		version = e.Version
+	}
+	return version, nil
+}
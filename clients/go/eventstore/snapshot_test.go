@@ -0,0 +1,60 @@
+package eventstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/makr-code/ThemisDB/clients/go/themistest"
+)
+
+// cart is an aggregate rebuilt from ItemAdded events
+type cart struct {
+	Items map[string]int `json:"items"`
+}
+
+func (c *cart) apply(e Event) error {
+	var added itemAdded
+	if err := e.Decode(&added); err != nil {
+		return err
+	}
+	if c.Items == nil {
+		c.Items = map[string]int{}
+	}
+	c.Items[added.SKU] += added.Quantity
+	return nil
+}
+
+func TestStore_Snapshots(t *testing.T) {
+	client, _ := themistest.NewClient(t)
+	store := New(client, Options{})
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		_, err := store.Append(ctx, "cart-1", Any, EventData{Type: "ItemAdded", Data: itemAdded{SKU: "a", Quantity: 1}})
+		require.NoError(t, err)
+	}
+
+	snap, err := store.LoadSnapshot(ctx, "cart-1")
+	require.NoError(t, err)
+	assert.Nil(t, snap)
+
+	var c cart
+	version, err := store.Load(ctx, "cart-1", &c, c.apply)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), version)
+	assert.Equal(t, 3, c.Items["a"])
+
+	require.NoError(t, store.SaveSnapshot(ctx, "cart-1", 2, cart{Items: map[string]int{"a": 10}}))
+	require.NoError(t, store.SaveSnapshot(ctx, "cart-1", 1, cart{Items: map[string]int{"a": 99}}), "older snapshots are ignored")
+	snap, err = store.LoadSnapshot(ctx, "cart-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), snap.Version)
+
+	var fromSnapshot cart
+	version, err = store.Load(ctx, "cart-1", &fromSnapshot, fromSnapshot.apply)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), version)
+	assert.Equal(t, 11, fromSnapshot.Items["a"], "only events after the snapshot are applied")
+}
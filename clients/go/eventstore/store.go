@@ -0,0 +1,264 @@
+// Package eventstore stores event-sourced streams in ThemisDB.
+//
+// A stream is the ordered history of one aggregate, e.g. "order-42". Append adds events
+// to a stream if it is still at the version the caller decided on, so concurrent
+// writers of an aggregate cannot overwrite each other's decisions; ReadForward and
+// ReadBackward read a stream, and snapshots shorten the replay of long streams:
+//
+//	store := eventstore.New(client, eventstore.Options{})
+//	var order Order
+//	version, err := store.Load(ctx, "order-42", &order, order.Apply)
+//	...
+//	_, err = store.Append(ctx, "order-42", version, eventstore.EventData{Type: "OrderShipped", Data: shipped})
+//	if errors.Is(err, themisdb.ErrWrongExpectedVersion) {
+//		// reload and decide again
+//	}
+//
+// SubscribeStream and SubscribeAll deliver stored events and then new ones as they are
+// appended, fed by the server changefeed. RunProjection builds on them to maintain the
+// read models of a CQRS application, with checkpoints and rebuilds.
+//
+// Events are stored with the layout of Client.AppendEvent and Client.ReadEvents, in the
+// relational collections "_events" (keyed "<stream>/<zero-padded version>") and
+// "_streams" (the current version of each stream), so both APIs can be mixed.
+package eventstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+// Collections of the event store
+const (
+	eventsCollection    = "_events"
+	streamsCollection   = "_streams"
+	snapshotsCollection = "_snapshots"
+)
+
+// Expected versions of Append with a special meaning
+const (
+	// Any appends regardless of the stream version
+	Any int64 = -1
+	// NoStream appends only if the stream has no events yet
+	NoStream int64 = 0
+)
+
+// Options configures a Store
+type Options struct {
+	// PollTimeout is the long-poll timeout of the changefeed requests of subscriptions
+	// (default: 20s)
+	PollTimeout time.Duration
+	// OnError is called with the read and changefeed errors of subscriptions, which are
+	// retried with backoff
+	OnError func(error)
+}
+
+// Store appends and reads event streams
+type Store struct {
+	client themisdb.ThemisClient
+	opts   Options
+}
+
+// EventData is an event to append
+type EventData struct {
+	// Type names the kind of event, e.g. "OrderPlaced"
+	Type string
+	// Data is encoded as JSON
+	Data     interface{}
+	Metadata map[string]string
+}
+
+// Event is a stored event
+type Event struct {
+	Stream   string
+	Version  int64
+	Type     string
+	Data     json.RawMessage
+	Metadata map[string]string
+	// Time is when the event was appended
+	Time time.Time
+	// Position is the changefeed sequence number of the event, set by SubscribeAll
+	Position uint64
+}
+
+// Decode unmarshals the event data into v
+func (e Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// record is the stored document of an event; its fields are a superset of
+// themisdb.StoredEvent
+type record struct {
+	Stream   string            `json:"stream"`
+	Version  int64             `json:"version"`
+	Time     int64             `json:"time"`
+	Type     string            `json:"type,omitempty"`
+	Data     json.RawMessage   `json:"data"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// event converts a stored record into an Event
+func (r record) event() Event {
+	return Event{Stream: r.Stream, Version: r.Version, Type: r.Type, Data: r.Data, Metadata: r.Metadata, Time: time.UnixMilli(r.Time)}
+}
+
+// streamHead is the stored version of a stream
+type streamHead struct {
+	Version int64 `json:"version"`
+}
+
+// New returns an event store on client
+func New(client themisdb.ThemisClient, opts Options) *Store {
+	if opts.PollTimeout <= 0 {
+		opts.PollTimeout = 20 * time.Second
+	}
+	return &Store{client: client, opts: opts}
+}
+
+// Append appends events to stream if it is at expectedVersion, NoStream for a new
+// stream or Any to skip the check, and returns the new version of the stream. All events
+// are appended in one transaction. It fails with themisdb.ErrWrongExpectedVersion if the
+// stream is at another version; write conflicts with concurrent appends are retried.
+func (s *Store) Append(ctx context.Context, stream string, expectedVersion int64, events ...EventData) (int64, error) {
+	if err := validateStream(stream); err != nil {
+		return 0, err
+	}
+	if expectedVersion < Any {
+		return 0, &themisdb.ValidationError{Field: "expected version", Value: strconv.FormatInt(expectedVersion, 10), Reason: "must be Any, NoStream, or a version"}
+	}
+	if len(events) == 0 {
+		return 0, &themisdb.ValidationError{Field: "events", Value: stream, Reason: "must not be empty"}
+	}
+	data := make([]json.RawMessage, len(events))
+	for i, e := range events {
+		raw, err := json.Marshal(e.Data)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode event %d: %w", i, err)
+		}
+		data[i] = raw
+	}
+
+	var version int64
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *themisdb.Transaction) error {
+		var head streamHead
+		if err := tx.Get(ctx, themisdb.ModelRelational, streamsCollection, stream, &head); err != nil && !errors.Is(err, themisdb.ErrNotFound) {
+			return err
+		}
+		if expectedVersion != Any && head.Version != expectedVersion {
+			return fmt.Errorf("%w: stream is at version %d, expected %d", themisdb.ErrWrongExpectedVersion, head.Version, expectedVersion)
+		}
+
+		now := time.Now().UnixMilli()
+		version = head.Version
+		for i, e := range events {
+			version++
+			r := record{Stream: stream, Version: version, Time: now, Type: e.Type, Data: data[i], Metadata: e.Metadata}
+			if err := tx.Create(ctx, themisdb.ModelRelational, eventsCollection, eventKey(stream, version), r); err != nil {
+				if errors.Is(err, themisdb.ErrAlreadyExists) {
+					return fmt.Errorf("%w: version %d exists", themisdb.ErrConflict, version)
+				}
+				return err
+			}
+		}
+		return tx.Put(ctx, themisdb.ModelRelational, streamsCollection, stream, streamHead{Version: version})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to append to stream %s: %w", stream, err)
+	}
+	return version, nil
+}
+
+// Version returns the current version of stream, 0 if it has no events
+func (s *Store) Version(ctx context.Context, stream string) (int64, error) {
+	if err := validateStream(stream); err != nil {
+		return 0, err
+	}
+	var head streamHead
+	err := s.client.Get(ctx, themisdb.ModelRelational, streamsCollection, stream, &head)
+	if err != nil && !errors.Is(err, themisdb.ErrNotFound) {
+		return 0, fmt.Errorf("failed to read version of stream %s: %w", stream, err)
+	}
+	return head.Version, nil
+}
+
+// ReadForward returns up to max events of stream from version from on in ascending
+// version order; max <= 0 reads to the end of the stream
+func (s *Store) ReadForward(ctx context.Context, stream string, from int64, max int) ([]Event, error) {
+	if err := validateStream(stream); err != nil {
+		return nil, err
+	}
+	opts := themisdb.ScanOptions{Prefix: stream + "/", BatchSize: 500}
+	if from > 1 {
+		opts.StartAfter = eventKey(stream, from-1)
+	}
+	if max > 0 && max < opts.BatchSize {
+		opts.BatchSize = max
+	}
+
+	var events []Event
+	it := s.client.Scan(ctx, themisdb.ModelRelational, eventsCollection, opts)
+	for (max <= 0 || len(events) < max) && it.Next() {
+		var r record
+		if err := it.Decode(&r); err != nil {
+			return nil, fmt.Errorf("failed to decode event %s: %w", it.UUID(), err)
+		}
+		events = append(events, r.event())
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stream %s: %w", stream, err)
+	}
+	return events, nil
+}
+
+// ReadBackward returns up to max events of stream from version from down in descending
+// version order, e.g. the latest events; from <= 0 starts at the end of the stream and
+// max <= 0 reads to its beginning
+func (s *Store) ReadBackward(ctx context.Context, stream string, from int64, max int) ([]Event, error) {
+	version, err := s.Version(ctx, stream)
+	if err != nil {
+		return nil, err
+	}
+	if from <= 0 || from > version {
+		from = version
+	}
+	last := int64(1)
+	if max > 0 && from-int64(max)+1 > last {
+		last = from - int64(max) + 1
+	}
+
+	if from < last {
+		return nil, nil
+	}
+
+	events, err := s.ReadForward(ctx, stream, last, int(from-last+1))
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events, nil
+}
+
+// validateStream rejects stream names that cannot be told apart in event keys
+func validateStream(stream string) error {
+	if stream == "" {
+		return &themisdb.ValidationError{Field: "stream", Value: stream, Reason: "must not be empty"}
+	}
+	if strings.Contains(stream, "/") {
+		return &themisdb.ValidationError{Field: "stream", Value: stream, Reason: "must not contain '/'"}
+	}
+	return nil
+}
+
+// eventKey returns the key of an event; versions are zero-padded so keys sort by version
+func eventKey(stream string, version int64) string {
+	return fmt.Sprintf("%s/%019d", stream, version)
+}
@@ -0,0 +1,110 @@
+package eventstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+	"github.com/makr-code/ThemisDB/clients/go/themistest"
+)
+
+type itemAdded struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+func TestStore_Append(t *testing.T) {
+	client, _ := themistest.NewClient(t)
+	store := New(client, Options{})
+	ctx := context.Background()
+
+	version, err := store.Append(ctx, "cart-1", NoStream,
+		EventData{Type: "ItemAdded", Data: itemAdded{SKU: "a", Quantity: 1}, Metadata: map[string]string{"user": "u1"}},
+		EventData{Type: "ItemAdded", Data: itemAdded{SKU: "b", Quantity: 2}},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), version)
+
+	_, err = store.Append(ctx, "cart-1", 1, EventData{Type: "CartCleared"})
+	assert.ErrorIs(t, err, themisdb.ErrWrongExpectedVersion)
+	_, err = store.Append(ctx, "cart-1", NoStream, EventData{Type: "CartCleared"})
+	assert.ErrorIs(t, err, themisdb.ErrWrongExpectedVersion)
+
+	version, err = store.Append(ctx, "cart-1", Any, EventData{Type: "CartCleared"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), version)
+	version, err = store.Version(ctx, "cart-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), version)
+	version, err = store.Version(ctx, "cart-2")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), version)
+
+	_, err = store.Append(ctx, "carts/1", NoStream, EventData{Type: "ItemAdded"})
+	assert.ErrorIs(t, err, themisdb.ErrInvalidInput)
+	_, err = store.Append(ctx, "cart-1", 3)
+	assert.ErrorIs(t, err, themisdb.ErrInvalidInput)
+}
+
+func TestStore_Read(t *testing.T) {
+	client, _ := themistest.NewClient(t)
+	store := New(client, Options{})
+	ctx := context.Background()
+	for i := 1; i <= 5; i++ {
+		_, err := store.Append(ctx, "cart-1", int64(i-1), EventData{Type: "ItemAdded", Data: itemAdded{SKU: "sku", Quantity: i}})
+		require.NoError(t, err)
+	}
+	_, err := store.Append(ctx, "cart-10", NoStream, EventData{Type: "ItemAdded"})
+	require.NoError(t, err)
+
+	events, err := store.ReadForward(ctx, "cart-1", 1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, versions(events), "events of cart-10 are not part of cart-1")
+	var added itemAdded
+	require.NoError(t, events[2].Decode(&added))
+	assert.Equal(t, 3, added.Quantity)
+	assert.Equal(t, "ItemAdded", events[2].Type)
+	assert.Equal(t, "cart-1", events[2].Stream)
+
+	events, err = store.ReadForward(ctx, "cart-1", 3, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3, 4}, versions(events))
+
+	events, err = store.ReadBackward(ctx, "cart-1", 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{5, 4}, versions(events))
+	events, err = store.ReadBackward(ctx, "cart-1", 3, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3, 2, 1}, versions(events))
+	events, err = store.ReadBackward(ctx, "cart-2", 0, 0)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestStore_CompatibleWithAppendEvent(t *testing.T) {
+	client, _ := themistest.NewClient(t)
+	store := New(client, Options{})
+	ctx := context.Background()
+
+	_, err := client.AppendEvent(ctx, "cart-1", 0, itemAdded{SKU: "a", Quantity: 1})
+	require.NoError(t, err)
+	version, err := store.Append(ctx, "cart-1", 1, EventData{Type: "ItemAdded", Data: itemAdded{SKU: "b", Quantity: 1}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), version)
+
+	stored, err := client.ReadEvents(ctx, "cart-1", 0)
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+	assert.JSONEq(t, `{"sku":"b","quantity":1}`, string(stored[1].Data))
+}
+
+func versions(events []Event) []int64 {
+	var v []int64
+	for _, e := range events {
+		v = append(v, e.Version)
+	}
+	return v
+}
@@ -0,0 +1,152 @@
+package eventstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+// Handler processes an event delivered by a subscription; an error stops the
+// subscription
+type Handler func(ctx context.Context, event Event) error
+
+// SubscribeStream delivers the events of stream after version afterVersion to handler
+// in version order: first the stored ones, then new ones as they are appended. It runs
+// until ctx is done, returning ctx.Err(), or handler fails, returning its error. Read
+// and changefeed errors are retried with backoff and reported to OnError.
+func (s *Store) SubscribeStream(ctx context.Context, stream string, afterVersion int64, handler Handler) error {
+	if err := validateStream(stream); err != nil {
+		return err
+	}
+
+	// changes after seq wake the subscription up; the events are read from the stream
+	// itself, so none is skipped or delivered twice
+	var seq uint64
+	retry := s.newBackoff()
+	for {
+		latest, err := s.client.LatestChangeSequence(ctx)
+		if err == nil {
+			seq = latest
+			break
+		}
+		if err := retry.wait(ctx, err); err != nil {
+			return err
+		}
+	}
+
+	caughtUp := false
+	for ctx.Err() == nil {
+		if !caughtUp {
+			events, err := s.ReadForward(ctx, stream, afterVersion+1, 0)
+			if err != nil {
+				if err := retry.wait(ctx, err); err != nil {
+					return err
+				}
+				continue
+			}
+			for _, e := range events {
+				if err := handler(ctx, e); err != nil {
+					return err
+				}
+				afterVersion = e.Version
+			}
+			caughtUp = true
+		}
+
+		changes, err := s.client.ReadChanges(ctx, seq, themisdb.ChangesOptions{
+			Collection: eventsCollection,
+			Prefix:     stream + "/",
+			Wait:       s.opts.PollTimeout,
+		})
+		if err != nil {
+			if err := retry.wait(ctx, err); err != nil {
+				return err
+			}
+			continue
+		}
+		retry.reset()
+		if len(changes) > 0 {
+			seq = changes[len(changes)-1].Sequence
+			caughtUp = false
+		}
+	}
+	return ctx.Err()
+}
+
+// SubscribeAll delivers the events of all streams appended after changefeed position
+// afterPosition to handler, with Event.Position set, in the order they were committed.
+// Events of one stream arrive in version order. Pass 0 to replay the events still
+// retained by the changefeed, or Client.LatestChangeSequence to start from now; a
+// subscriber that records the Position of each handled event can resume after it. It
+// runs until ctx is done or handler fails, like SubscribeStream.
+func (s *Store) SubscribeAll(ctx context.Context, afterPosition uint64, handler Handler) error {
+	retry := s.newBackoff()
+	for ctx.Err() == nil {
+		changes, err := s.client.ReadChanges(ctx, afterPosition, themisdb.ChangesOptions{
+			Collection: eventsCollection,
+			Wait:       s.opts.PollTimeout,
+		})
+		if err != nil {
+			if err := retry.wait(ctx, err); err != nil {
+				return err
+			}
+			continue
+		}
+		retry.reset()
+
+		for _, change := range changes {
+			if change.Type == "PUT" {
+				var r record
+				if err := json.Unmarshal(change.Document, &r); err != nil {
+					return fmt.Errorf("failed to decode event %s: %w", change.UUID, err)
+				}
+				event := r.event()
+				event.Position = change.Sequence
+				if err := handler(ctx, event); err != nil {
+					return err
+				}
+			}
+			afterPosition = change.Sequence
+		}
+	}
+	return ctx.Err()
+}
+
+// backoff delays the retries of failed subscription requests
+type backoff struct {
+	onError func(error)
+	delay   time.Duration
+}
+
+// newBackoff returns a backoff reporting errors to OnError
+func (s *Store) newBackoff() *backoff {
+	return &backoff{onError: s.opts.OnError, delay: 100 * time.Millisecond}
+}
+
+// wait reports err and waits, doubling the delay up to 10s; it returns ctx.Err() if
+// ctx is done first
+func (b *backoff) wait(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if b.onError != nil {
+		b.onError(err)
+	}
+	select {
+	case <-time.After(b.delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if b.delay < 10*time.Second {
+		b.delay *= 2
+	}
+	return nil
+}
+
+// reset restores the initial delay after a successful request
+func (b *backoff) reset() {
+	b.delay = 100 * time.Millisecond
+}
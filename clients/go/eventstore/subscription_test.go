@@ -0,0 +1,122 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/makr-code/ThemisDB/clients/go/themistest"
+)
+
+// collector records delivered events
+type collector struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (c *collector) handle(ctx context.Context, e Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, e)
+	return nil
+}
+
+func (c *collector) versions() []int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return versions(c.events)
+}
+
+func TestStore_SubscribeStream(t *testing.T) {
+	client, _ := themistest.NewClient(t)
+	store := New(client, Options{PollTimeout: time.Second})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		_, err := store.Append(ctx, "cart-1", Any, EventData{Type: "ItemAdded"})
+		require.NoError(t, err)
+	}
+
+	c := &collector{}
+	done := make(chan error)
+	go func() { done <- store.SubscribeStream(ctx, "cart-1", 1, c.handle) }()
+	assert.Eventually(t, func() bool { return len(c.versions()) == 2 }, time.Second, 5*time.Millisecond, "stored events are caught up")
+
+	_, err := store.Append(ctx, "cart-2", Any, EventData{Type: "ItemAdded"})
+	require.NoError(t, err)
+	_, err = store.Append(ctx, "cart-1", 3, EventData{Type: "ItemAdded"}, EventData{Type: "ItemAdded"})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return len(c.versions()) == 4 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []int64{2, 3, 4, 5}, c.versions())
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestStore_SubscribeAll(t *testing.T) {
+	client, _ := themistest.NewClient(t)
+	store := New(client, Options{PollTimeout: time.Second})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err := store.Append(ctx, "cart-1", NoStream, EventData{Type: "ItemAdded"}, EventData{Type: "ItemAdded"})
+	require.NoError(t, err)
+	_, err = store.Append(ctx, "cart-2", NoStream, EventData{Type: "ItemAdded"})
+	require.NoError(t, err)
+
+	c := &collector{}
+	go store.SubscribeAll(ctx, 0, c.handle)
+	assert.Eventually(t, func() bool { return len(c.versions()) == 3 }, time.Second, 5*time.Millisecond)
+	_, err = store.Append(ctx, "cart-1", 2, EventData{Type: "ItemRemoved"})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return len(c.versions()) == 4 }, time.Second, 5*time.Millisecond)
+
+	c.mu.Lock()
+	events := append([]Event(nil), c.events...)
+	c.mu.Unlock()
+	assert.Equal(t, []string{"cart-1", "cart-1", "cart-2", "cart-1"}, []string{events[0].Stream, events[1].Stream, events[2].Stream, events[3].Stream})
+	assert.Equal(t, "ItemRemoved", events[3].Type)
+	for i := 1; i < len(events); i++ {
+		assert.Greater(t, events[i].Position, events[i-1].Position)
+	}
+
+	// resuming after a recorded position skips the events handled before
+	resumed := &collector{}
+	stop := errors.New("stop")
+	err = store.SubscribeAll(ctx, events[1].Position, func(ctx context.Context, e Event) error {
+		resumed.handle(ctx, e)
+		if e.Stream == "cart-2" {
+			return stop
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, stop, "a handler error stops the subscription")
+	assert.Equal(t, []int64{1}, resumed.versions())
+}
+
+func TestStore_SubscribeRetries(t *testing.T) {
+	client, fake := themistest.NewClient(t)
+	var mu sync.Mutex
+	var reported []error
+	store := New(client, Options{PollTimeout: time.Second, OnError: func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, err)
+	}})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err := store.Append(ctx, "cart-1", NoStream, EventData{Type: "ItemAdded"})
+	require.NoError(t, err)
+
+	fake.FailNext(400, "changefeed unavailable")
+	c := &collector{}
+	go store.SubscribeAll(ctx, 0, c.handle)
+	assert.Eventually(t, func() bool { return len(c.versions()) == 1 }, 2*time.Second, 5*time.Millisecond)
+	mu.Lock()
+	assert.Len(t, reported, 1)
+	mu.Unlock()
+}
@@ -0,0 +1,126 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// headerTTL carries the time to live of a written document in milliseconds
+const headerTTL = "X-Themis-TTL"
+
+// CollectionTTL is the expiration policy of a collection
+type CollectionTTL struct {
+	// TTL is how long documents live (zero: documents do not expire)
+	TTL time.Duration
+	// Field, if set, names a timestamp field (RFC 3339 or Unix milliseconds) from which
+	// TTL is measured instead of the document's last write
+	Field string
+}
+
+// PutWithTTL creates or replaces an entity that the server deletes once ttl has passed
+// without another PutWithTTL or Touch. It overrides the collection's TTL for the document.
+func (c *Client) PutWithTTL(ctx context.Context, model, collection, uuid string, data interface{}, ttl time.Duration) error {
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return err
+	}
+	if err := validateTTL(ttl); err != nil {
+		return err
+	}
+	if err := c.validateDocument(model, collection, data); err != nil {
+		return err
+	}
+	path := entityPath(model, collection, uuid)
+	return c.request(ctx, "PUT", path, data, nil, ttlHeaders(ttl))
+}
+
+// Touch resets the expiration of an existing entity to ttl from now without changing
+// its content, e.g. to keep an active session alive. It fails with ErrNotFound if the
+// entity does not exist or has already expired.
+func (c *Client) Touch(ctx context.Context, model, collection, uuid string, ttl time.Duration) error {
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return err
+	}
+	if err := validateTTL(ttl); err != nil {
+		return err
+	}
+	headers := ttlHeaders(ttl)
+	headers["Content-Type"] = ContentTypeMergePatch
+	path := entityPath(model, collection, uuid)
+	if err := c.request(ctx, "PATCH", path, map[string]interface{}{}, nil, headers); err != nil {
+		return fmt.Errorf("failed to touch %s/%s/%s: %w", model, collection, uuid, err)
+	}
+	return nil
+}
+
+// SetCollectionTTL sets the expiration policy of a collection: documents written without
+// their own TTL are deleted by the server once policy.TTL has passed. A zero TTL removes
+// the policy; documents written with PutWithTTL keep their own expiration.
+func (a *Admin) SetCollectionTTL(ctx context.Context, model, collection string, policy CollectionTTL) error {
+	if err := validateCollection(model, collection); err != nil {
+		return err
+	}
+	if policy.TTL < 0 {
+		return &ValidationError{Field: "ttl", Value: policy.TTL.String(), Reason: "must not be negative"}
+	}
+	path := joinPath("/admin/collections", collection, "ttl") + "?model=" + url.QueryEscape(model)
+	if policy.TTL == 0 {
+		resp, err := a.client.send(ctx, "DELETE", path, nil, nil)
+		if err != nil && (resp == nil || resp.StatusCode != http.StatusNotFound) {
+			return fmt.Errorf("failed to remove TTL of %s/%s: %w", model, collection, err)
+		}
+		return nil
+	}
+
+	body := map[string]interface{}{
+		"ttl_ms": policy.TTL.Milliseconds(),
+	}
+	if policy.Field != "" {
+		body["field"] = policy.Field
+	}
+	if err := a.client.request(ctx, "PUT", path, body, nil, nil); err != nil {
+		return fmt.Errorf("failed to set TTL of %s/%s: %w", model, collection, err)
+	}
+	return nil
+}
+
+// CollectionTTL returns the expiration policy of a collection, a zero policy if there is none
+func (a *Admin) CollectionTTL(ctx context.Context, model, collection string) (CollectionTTL, error) {
+	if err := validateCollection(model, collection); err != nil {
+		return CollectionTTL{}, err
+	}
+	path := joinPath("/admin/collections", collection, "ttl") + "?model=" + url.QueryEscape(model)
+
+	resp, err := a.client.send(ctx, "GET", path, nil, nil)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return CollectionTTL{}, nil
+	}
+	if err != nil {
+		return CollectionTTL{}, fmt.Errorf("failed to get TTL of %s/%s: %w", model, collection, err)
+	}
+	var response struct {
+		TTL   int64  `json:"ttl_ms"`
+		Field string `json:"field"`
+	}
+	if err := json.Unmarshal(resp.Body, &response); err != nil {
+		return CollectionTTL{}, fmt.Errorf("failed to get TTL of %s/%s: failed to decode response: %w", model, collection, err)
+	}
+	return CollectionTTL{TTL: time.Duration(response.TTL) * time.Millisecond, Field: response.Field}, nil
+}
+
+// validateTTL checks the time to live of a document, which the server tracks in milliseconds
+func validateTTL(ttl time.Duration) error {
+	if ttl < time.Millisecond {
+		return &ValidationError{Field: "ttl", Value: ttl.String(), Reason: "must be at least 1ms"}
+	}
+	return nil
+}
+
+// ttlHeaders returns the headers setting the time to live of a written document
+func ttlHeaders(ttl time.Duration) map[string]string {
+	return map[string]string{headerTTL: strconv.FormatInt(ttl.Milliseconds(), 10)}
+}
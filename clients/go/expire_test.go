@@ -0,0 +1,81 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_PutWithTTL(t *testing.T) {
+	var requests []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("X-Themis-TTL")+" "+r.Header.Get("Content-Type")+" "+string(body))
+		if r.URL.Path == "/api/kv/sessions/gone" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	ctx := context.Background()
+
+	require.NoError(t, client.PutWithTTL(ctx, "kv", "sessions", "s1", map[string]string{"user": "ada"}, 30*time.Minute))
+	require.NoError(t, client.Touch(ctx, "kv", "sessions", "s1", time.Hour))
+	assert.ErrorIs(t, client.Touch(ctx, "kv", "sessions", "gone", time.Hour), ErrNotFound)
+	assert.Equal(t, []string{
+		`PUT /api/kv/sessions/s1 1800000 application/json {"user":"ada"}`,
+		`PATCH /api/kv/sessions/s1 3600000 application/merge-patch+json {}`,
+		`PATCH /api/kv/sessions/gone 3600000 application/merge-patch+json {}`,
+	}, requests)
+
+	assert.ErrorIs(t, client.PutWithTTL(ctx, "kv", "sessions", "s1", nil, 0), ErrInvalidInput)
+	assert.ErrorIs(t, client.Touch(ctx, "kv", "sessions", "s1", time.Microsecond), ErrInvalidInput)
+	assert.Len(t, requests, 3)
+}
+
+func TestAdmin_SetCollectionTTL(t *testing.T) {
+	var policy map[string]interface{}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/admin/collections/events/ttl", r.URL.Path)
+		assert.Equal(t, "document", r.URL.Query().Get("model"))
+		switch r.Method {
+		case "PUT":
+			policy = map[string]interface{}{}
+			json.NewDecoder(r.Body).Decode(&policy)
+		case "DELETE":
+			if policy == nil {
+				http.Error(w, "no policy", http.StatusNotFound)
+				return
+			}
+			policy = nil
+		case "GET":
+			if policy == nil {
+				http.Error(w, "no policy", http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(policy)
+		}
+	})
+	admin := client.Admin()
+	ctx := context.Background()
+
+	require.NoError(t, admin.SetCollectionTTL(ctx, "document", "events", CollectionTTL{TTL: 7 * 24 * time.Hour, Field: "occurred_at"}))
+	assert.Equal(t, map[string]interface{}{"ttl_ms": float64(604800000), "field": "occurred_at"}, policy)
+	got, err := admin.CollectionTTL(ctx, "document", "events")
+	require.NoError(t, err)
+	assert.Equal(t, CollectionTTL{TTL: 7 * 24 * time.Hour, Field: "occurred_at"}, got)
+
+	require.NoError(t, admin.SetCollectionTTL(ctx, "document", "events", CollectionTTL{}))
+	require.NoError(t, admin.SetCollectionTTL(ctx, "document", "events", CollectionTTL{}), "removing a missing policy succeeds")
+	got, err = admin.CollectionTTL(ctx, "document", "events")
+	require.NoError(t, err)
+	assert.Zero(t, got)
+
+	assert.ErrorIs(t, admin.SetCollectionTTL(ctx, "document", "events", CollectionTTL{TTL: -time.Second}), ErrInvalidInput)
+}
@@ -0,0 +1,117 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// QueryPlan is the execution plan chosen by the server for an AQL query
+type QueryPlan struct {
+	// Mode is the execution strategy, e.g. "index_optimized" or "full_scan_fallback"
+	Mode string `json:"mode,omitempty"`
+	// Indexes lists the indexes the plan reads from
+	Indexes []string `json:"indexes,omitempty"`
+	// EstimatedRows is the optimizer's estimate of the rows scanned
+	EstimatedRows int64 `json:"estimated_rows,omitempty"`
+	// Raw holds the complete plan as returned by the server
+	Raw json.RawMessage `json:"-"`
+}
+
+// StageProfile holds execution statistics of a single query stage
+type StageProfile struct {
+	// Name of the stage, e.g. "aql.for" or "aql.sort"
+	Name string
+	// Rows is the number of rows produced by the stage
+	Rows int64
+	// Duration is the time spent in the stage
+	Duration time.Duration
+}
+
+// QueryProfile holds execution statistics of an AQL query
+type QueryProfile struct {
+	// Plan is the executed plan
+	Plan *QueryPlan
+	// IndexesUsed lists the indexes read during execution
+	IndexesUsed []string
+	// RowsScanned counts rows read from storage
+	RowsScanned int64
+	// RowsReturned counts rows in the result
+	RowsReturned int64
+	// Duration is the total server-side execution time
+	Duration time.Duration
+	// Stages breaks Duration down per execution stage
+	Stages []StageProfile
+}
+
+// Explain returns the execution plan of an AQL query
+func (c *Client) Explain(ctx context.Context, aql string) (*QueryPlan, error) {
+	res, err := c.execQuery(ctx, aql, nil, map[string]interface{}{"explain": true}, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain query: %w", err)
+	}
+	return decodePlan(res.Plan)
+}
+
+// QueryWithProfile executes an AQL query, decodes its data into result, and returns
+// execution statistics such as index usage, rows scanned, and time per stage
+func (c *Client) QueryWithProfile(ctx context.Context, aql string, opts *QueryOptions, result interface{}) (*QueryProfile, error) {
+	res, err := c.execQuery(ctx, aql, opts, map[string]interface{}{"explain": true, "profile": true}, result, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw struct {
+		IndexesUsed  []string `json:"indexes_used"`
+		RowsScanned  int64    `json:"rows_scanned"`
+		RowsReturned int64    `json:"rows_returned"`
+		TimeMs       float64  `json:"time_ms"`
+		Stages       []struct {
+			Name   string  `json:"name"`
+			Rows   int64   `json:"rows"`
+			TimeMs float64 `json:"time_ms"`
+		} `json:"stages"`
+	}
+	if len(res.Profile) > 0 {
+		if err := json.Unmarshal(res.Profile, &raw); err != nil {
+			return nil, fmt.Errorf("failed to decode query profile: %w", err)
+		}
+	}
+
+	plan, err := decodePlan(res.Plan)
+	if err != nil {
+		return nil, err
+	}
+	profile := &QueryProfile{
+		Plan:         plan,
+		IndexesUsed:  raw.IndexesUsed,
+		RowsScanned:  raw.RowsScanned,
+		RowsReturned: raw.RowsReturned,
+		Duration:     millisToDuration(raw.TimeMs),
+	}
+	for _, stage := range raw.Stages {
+		profile.Stages = append(profile.Stages, StageProfile{
+			Name:     stage.Name,
+			Rows:     stage.Rows,
+			Duration: millisToDuration(stage.TimeMs),
+		})
+	}
+	return profile, nil
+}
+
+// decodePlan decodes a plan returned by the server, keeping the raw JSON
+func decodePlan(raw json.RawMessage) (*QueryPlan, error) {
+	plan := &QueryPlan{Raw: raw}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, plan); err != nil {
+			return nil, fmt.Errorf("failed to decode query plan: %w", err)
+		}
+	}
+	return plan, nil
+}
+
+// millisToDuration converts fractional milliseconds reported by the server
+func millisToDuration(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
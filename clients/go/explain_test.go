@@ -0,0 +1,53 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Explain(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, true, body["explain"])
+		assert.Nil(t, body["profile"])
+		w.Write([]byte(`{"data":[],"plan":{"mode":"index_optimized","indexes":["users.email"],"estimated_rows":12,"order":["email"]}}`))
+	})
+
+	plan, err := client.Explain(context.Background(), "FOR u IN users FILTER u.email == 'a' RETURN u")
+	require.NoError(t, err)
+	assert.Equal(t, "index_optimized", plan.Mode)
+	assert.Equal(t, []string{"users.email"}, plan.Indexes)
+	assert.Equal(t, int64(12), plan.EstimatedRows)
+	assert.Contains(t, string(plan.Raw), `"order"`)
+}
+
+func TestClient_QueryWithProfile(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, true, body["profile"])
+		w.Write([]byte(`{
+			"data":[{"name":"Alice"}],
+			"plan":{"mode":"full_scan_fallback"},
+			"profile":{"rows_scanned":1000,"rows_returned":1,"time_ms":12.5,"indexes_used":[],
+				"stages":[{"name":"aql.for","rows":1000,"time_ms":10},{"name":"aql.filter","rows":1,"time_ms":2.5}]}
+		}`))
+	})
+
+	var users []map[string]string
+	profile, err := client.QueryWithProfile(context.Background(), "FOR u IN users FILTER u.name == 'Alice' RETURN u", nil, &users)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]string{{"name": "Alice"}}, users)
+	assert.Equal(t, "full_scan_fallback", profile.Plan.Mode)
+	assert.Equal(t, int64(1000), profile.RowsScanned)
+	assert.Equal(t, 12500*time.Microsecond, profile.Duration)
+	require.Len(t, profile.Stages, 2)
+	assert.Equal(t, StageProfile{Name: "aql.filter", Rows: 1, Duration: 2500 * time.Microsecond}, profile.Stages[1])
+}
@@ -0,0 +1,250 @@
+package themisdb
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FieldUsage counts how often a field of the documents of a source was received and
+// decoded
+type FieldUsage struct {
+	// Field is the path of the field, nested fields separated by dots; the elements of
+	// arrays share the path of the array
+	Field string
+	// Received is the number of times the field was in a decoded document, Decoded the
+	// number of times the result type had a field for it
+	Received uint64
+	Decoded  uint64
+	// Bytes is the total encoded size of the received values
+	Bytes uint64
+}
+
+// FieldReport is the field usage of the documents read from one source
+type FieldReport struct {
+	// Source is "<model>/<collection>" for entity reads, or the canonical AQL of a
+	// query (see CanonicalizeAQL)
+	Source string
+	// Documents is the number of documents decoded
+	Documents uint64
+	// Fields are sorted by path
+	Fields []FieldUsage
+}
+
+// Unused returns the fields never decoded, largest first: candidates for projections
+// or for removal from the documents
+func (r FieldReport) Unused() []FieldUsage {
+	var unused []FieldUsage
+	for _, f := range r.Fields {
+		if f.Decoded == 0 {
+			unused = append(unused, f)
+		}
+	}
+	sort.SliceStable(unused, func(i, j int) bool { return unused[i].Bytes > unused[j].Bytes })
+	return unused
+}
+
+// decodedFields is the set of JSON fields a Go type decodes
+type decodedFields struct {
+	// any is set for maps, interfaces, and custom unmarshalers, which may use every field
+	any bool
+	// fields holds the nested fields by lower-case name, as encoding/json matches
+	// names case-insensitively; nil for scalar types
+	fields map[string]*decodedFields
+}
+
+// anyFields decodes every field
+var anyFields = &decodedFields{any: true}
+
+// unmarshalerType is the type of json.Unmarshaler
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// decodedFieldsCache holds the decodedFields of the types seen so far
+var decodedFieldsCache sync.Map
+
+// decodedFieldsOf returns the fields decoded by t
+func decodedFieldsOf(t reflect.Type) *decodedFields {
+	for {
+		if t.Implements(unmarshalerType) || reflect.PointerTo(t).Implements(unmarshalerType) {
+			return anyFields
+		}
+		if t.Kind() != reflect.Pointer && t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			break
+		}
+		t = t.Elem()
+	}
+	if cached, ok := decodedFieldsCache.Load(t); ok {
+		return cached.(*decodedFields)
+	}
+	switch {
+	case t.Kind() == reflect.Map, t.Kind() == reflect.Interface:
+		return anyFields
+	case t.Kind() != reflect.Struct:
+		return &decodedFields{}
+	}
+
+	// stored before the fields are filled in, so recursive types terminate
+	df := &decodedFields{fields: map[string]*decodedFields{}}
+	decodedFieldsCache.Store(t, df)
+	addStructFields(df, t)
+	return df
+}
+
+// addStructFields adds the JSON fields of struct type t to df, including those of
+// embedded structs
+func addStructFields(df *decodedFields, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if tag == "-" {
+			continue
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && tag == "" && ft.Kind() == reflect.Struct {
+			addStructFields(df, ft)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		name := tag
+		if name == "" {
+			name = f.Name
+		}
+		if _, dup := df.fields[strings.ToLower(name)]; !dup {
+			df.fields[strings.ToLower(name)] = decodedFieldsOf(f.Type)
+		}
+	}
+}
+
+// sourceFields accumulates the field usage of one source
+type sourceFields struct {
+	documents uint64
+	fields    map[string]*FieldUsage
+}
+
+// fieldStats records the field usage of decoded documents per source
+type fieldStats struct {
+	mu      sync.Mutex
+	sources map[string]*sourceFields
+}
+
+// record counts the fields of the documents in data, an object or an array of
+// objects, that were decoded into result
+func (s *fieldStats) record(source string, data []byte, result interface{}) {
+	if s == nil || result == nil || len(data) == 0 {
+		return
+	}
+	df := decodedFieldsOf(reflect.TypeOf(result))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	src := s.sources[source]
+	if src == nil {
+		src = &sourceFields{fields: map[string]*FieldUsage{}}
+		s.sources[source] = src
+	}
+	var docs []json.RawMessage
+	if json.Unmarshal(data, &docs) != nil {
+		docs = []json.RawMessage{data}
+	}
+	for _, doc := range docs {
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(doc, &fields) == nil {
+			src.documents++
+			src.add("", fields, df)
+		}
+	}
+}
+
+// add counts fields, nested under prefix, against the fields df decodes
+func (src *sourceFields) add(prefix string, fields map[string]json.RawMessage, df *decodedFields) {
+	for name, value := range fields {
+		path := prefix + name
+		usage := src.fields[path]
+		if usage == nil {
+			usage = &FieldUsage{Field: path}
+			src.fields[path] = usage
+		}
+		usage.Received++
+		usage.Bytes += uint64(len(value))
+
+		sub := df
+		if !df.any {
+			if sub = df.fields[strings.ToLower(name)]; sub == nil {
+				continue
+			}
+		}
+		usage.Decoded++
+		if sub.any || sub.fields != nil {
+			src.addNested(path+".", value, sub)
+		}
+	}
+}
+
+// addNested counts the fields of value if it is an object, or of the objects in it if
+// it is an array
+func (src *sourceFields) addNested(prefix string, value json.RawMessage, df *decodedFields) {
+	var nested map[string]json.RawMessage
+	if json.Unmarshal(value, &nested) == nil {
+		src.add(prefix, nested, df)
+		return
+	}
+	var elements []json.RawMessage
+	if json.Unmarshal(value, &elements) == nil {
+		for _, element := range elements {
+			src.addNested(prefix, element, df)
+		}
+	}
+}
+
+// report returns the field usage of every source, sorted by source
+func (s *fieldStats) report() []FieldReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reports := make([]FieldReport, 0, len(s.sources))
+	for source, src := range s.sources {
+		r := FieldReport{Source: source, Documents: src.documents, Fields: make([]FieldUsage, 0, len(src.fields))}
+		for _, usage := range src.fields {
+			r.Fields = append(r.Fields, *usage)
+		}
+		sort.Slice(r.Fields, func(i, j int) bool { return r.Fields[i].Field < r.Fields[j].Field })
+		reports = append(reports, r)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Source < reports[j].Source })
+	return reports
+}
+
+// entityRead reports whether req reads a single entity
+func entityRead(req *Request) bool {
+	path, _, _ := strings.Cut(req.Path, "?")
+	return req.Method == "GET" && strings.HasPrefix(path, "/api/") && strings.Count(path, "/") == 4 && collectionOf(req) != ""
+}
+
+// FieldStats returns which fields of the documents read so far were decoded into the
+// result types of the application, per collection and query. Fields that are never
+// decoded are transferred and parsed for nothing; FieldReport.Unused lists them as
+// candidates for projections. Documents decoded into maps or interfaces count all
+// their fields as decoded. It returns nil unless Config.FieldStats is set.
+func (c *Client) FieldStats() []FieldReport {
+	if c.fieldStats == nil {
+		return nil
+	}
+	return c.fieldStats.report()
+}
+
+// ResetFieldStats discards the field usage recorded so far, e.g. after a deployment
+// changed the result types
+func (c *Client) ResetFieldStats() {
+	if c.fieldStats == nil {
+		return
+	}
+	c.fieldStats.mu.Lock()
+	c.fieldStats.sources = map[string]*sourceFields{}
+	c.fieldStats.mu.Unlock()
+}
@@ -0,0 +1,77 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_FieldStats(t *testing.T) {
+	const user = `{"name": "Alice", "email": "a@example.com", "avatar": "iVBORw0KGgoAAAANSUhEUg", "address": {"city": "Berlin", "zip": "10115"}, "orders": [{"sku": "a", "note": "gift"}, {"sku": "b"}]}`
+	base := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/relational/users/_mget":
+			w.Write([]byte(`{"documents": [` + user + `, {"name": "Bob"}], "missing": []}`))
+		case "/api/query":
+			w.Write([]byte(`{"data": [` + user + `]}`))
+		default:
+			w.Write([]byte(user))
+		}
+	})
+	client := NewClient(Config{Endpoints: base.endpoints, FieldStats: true, Cache: &ResponseCacheOptions{TTL: time.Minute}})
+	defer client.Close()
+	ctx := context.Background()
+
+	type Order struct {
+		SKU string `json:"sku"`
+	}
+	type User struct {
+		Name    string `json:"name"`
+		Address struct {
+			City string
+		} `json:"address"`
+		Orders []Order `json:"orders"`
+		Secret string  `json:"-"`
+	}
+	var u User
+	require.NoError(t, client.Get(ctx, "relational", "users", "u1", &u))
+	require.NoError(t, client.Namespace("tenant-a").Get(ctx, "relational", "users", "u1", &u), "cache hits are recorded")
+	var users []User
+	_, err := client.GetMany(ctx, "relational", "users", []string{"u1", "u2"}, &users)
+	require.NoError(t, err)
+	var rows []map[string]interface{}
+	require.NoError(t, client.Query(ctx, "for u in users return u", &rows))
+
+	reports := client.FieldStats()
+	require.Len(t, reports, 2)
+	query, stats := reports[0], reports[1]
+	assert.Equal(t, "FOR u IN users RETURN u", query.Source)
+	assert.Empty(t, query.Unused(), "maps decode every field")
+	assert.Len(t, query.Fields, 9)
+
+	assert.Equal(t, "relational/users", stats.Source)
+	assert.Equal(t, uint64(4), stats.Documents)
+	fields := map[string]FieldUsage{}
+	for _, f := range stats.Fields {
+		fields[f.Field] = f
+	}
+	assert.Equal(t, FieldUsage{Field: "name", Received: 4, Decoded: 4, Bytes: 26}, fields["name"])
+	assert.Equal(t, uint64(3), fields["address.city"].Decoded, "names match case-insensitively")
+	assert.Equal(t, uint64(0), fields["address.zip"].Decoded)
+	assert.Equal(t, uint64(6), fields["orders.sku"].Received)
+	assert.Equal(t, uint64(3), fields["orders.note"].Received)
+
+	var unused []string
+	for _, f := range stats.Unused() {
+		unused = append(unused, f.Field)
+	}
+	assert.Equal(t, []string{"avatar", "email", "address.zip", "orders.note"}, unused, "largest first")
+
+	client.ResetFieldStats()
+	assert.Empty(t, client.FieldStats())
+	assert.Nil(t, NewClient(Config{}).FieldStats())
+}
@@ -0,0 +1,361 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Evaluation reasons and error codes of FlagResolution, as defined by OpenFeature
+const (
+	ReasonStatic         = "STATIC"
+	ReasonTargetingMatch = "TARGETING_MATCH"
+	ReasonDefault        = "DEFAULT"
+	ReasonDisabled       = "DISABLED"
+	ReasonError          = "ERROR"
+
+	ErrorFlagNotFound     = "FLAG_NOT_FOUND"
+	ErrorTypeMismatch     = "TYPE_MISMATCH"
+	ErrorParse            = "PARSE_ERROR"
+	ErrorProviderNotReady = "PROVIDER_NOT_READY"
+)
+
+// Flag is a feature flag document
+type Flag struct {
+	// Disabled flags always resolve to the caller's default value
+	Disabled bool `json:"disabled,omitempty"`
+	// Variants maps variant names to flag values
+	Variants map[string]interface{} `json:"variants"`
+	// DefaultVariant is served when no rule matches
+	DefaultVariant string `json:"default_variant"`
+	// Rules are evaluated in order, the first match selects its variant
+	Rules []FlagRule `json:"rules,omitempty"`
+}
+
+// FlagRule serves Variant if the evaluation context attribute is one of Values
+type FlagRule struct {
+	Attribute string   `json:"attribute"`
+	Values    []string `json:"values"`
+	Variant   string   `json:"variant"`
+}
+
+// FlagResolution is the result of a flag evaluation, mirroring the resolution
+// details of an OpenFeature provider
+type FlagResolution struct {
+	Value     interface{}
+	Variant   string
+	Reason    string
+	ErrorCode string
+}
+
+// FlagStoreOptions holds feature flag store configuration
+type FlagStoreOptions struct {
+	// Collection stores the flag documents in the kv model (default: "_flags")
+	Collection string
+	// PollTimeout is the long-poll timeout of changefeed requests (default: 20s)
+	PollTimeout time.Duration
+	// OnChange is called with the key of every flag changed after Start
+	OnChange func(key string)
+	// OnError is called with errors of the background changefeed watch
+	OnError func(error)
+}
+
+// FlagStore stores feature flags in ThemisDB and keeps a local copy live through
+// the server changefeed. Its evaluation methods follow the OpenFeature provider
+// interface, so an OpenFeature provider is a thin wrapper around a FlagStore.
+type FlagStore struct {
+	client *Client
+	opts   FlagStoreOptions
+
+	mu    sync.RWMutex
+	flags map[string]Flag
+	ready bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// changeEvent is an event of the server changefeed; Key is "<collection>:<uuid>"
+type changeEvent struct {
+	Sequence uint64  `json:"sequence"`
+	Type     string  `json:"type"`
+	Key      string  `json:"key"`
+	Value    *string `json:"value"`
+	// TimestampMs is when the server recorded the change, in Unix milliseconds
+	TimestampMs int64 `json:"timestamp_ms,omitempty"`
+}
+
+// change converts a PUT or DELETE event into a Change
+func (e changeEvent) change() (Change, bool) {
+	if e.Type != "PUT" && e.Type != "DELETE" {
+		return Change{}, false
+	}
+	collection, uuid, ok := strings.Cut(e.Key, ":")
+	if !ok {
+		return Change{}, false
+	}
+	change := Change{Sequence: e.Sequence, Type: e.Type, Collection: collection, UUID: uuid, Time: time.Now()}
+	if e.TimestampMs > 0 {
+		change.Time = time.UnixMilli(e.TimestampMs)
+	}
+	if e.Value != nil {
+		change.Document = json.RawMessage(*e.Value)
+	}
+	return change, true
+}
+
+// FlagStore returns a feature flag store; call Start before evaluating flags
+func (c *Client) FlagStore(opts FlagStoreOptions) *FlagStore {
+	if opts.Collection == "" {
+		opts.Collection = "_flags"
+	}
+	if opts.PollTimeout <= 0 {
+		opts.PollTimeout = 20 * time.Second
+	}
+	return &FlagStore{client: c, opts: opts, flags: map[string]Flag{}}
+}
+
+// SetFlag creates or replaces a flag
+func (f *FlagStore) SetFlag(ctx context.Context, key string, flag Flag) error {
+	if err := f.client.Put(ctx, ModelKV, f.opts.Collection, key, flag); err != nil {
+		return fmt.Errorf("failed to set flag %s: %w", key, err)
+	}
+	return nil
+}
+
+// DeleteFlag removes a flag
+func (f *FlagStore) DeleteFlag(ctx context.Context, key string) error {
+	if err := f.client.Delete(ctx, ModelKV, f.opts.Collection, key); err != nil {
+		return fmt.Errorf("failed to delete flag %s: %w", key, err)
+	}
+	return nil
+}
+
+// Start loads all flags and watches the changefeed for updates until Close
+func (f *FlagStore) Start(ctx context.Context) error {
+	seq, err := f.latestSequence(ctx)
+	if err != nil {
+		return err
+	}
+
+	flags := map[string]Flag{}
+	it := f.client.Scan(ctx, ModelKV, f.opts.Collection, ScanOptions{BatchSize: 500})
+	for it.Next() {
+		var flag Flag
+		if err := it.Decode(&flag); err != nil {
+			return fmt.Errorf("failed to decode flag %s: %w", it.UUID(), err)
+		}
+		flags[it.UUID()] = flag
+	}
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("failed to load flags: %w", err)
+	}
+
+	f.mu.Lock()
+	f.flags = flags
+	f.ready = true
+	f.mu.Unlock()
+
+	watchCtx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+	f.done = make(chan struct{})
+	go f.watch(watchCtx, seq)
+	return nil
+}
+
+// Close stops watching the changefeed
+func (f *FlagStore) Close() {
+	if f.cancel != nil {
+		f.cancel()
+		<-f.done
+	}
+}
+
+// Flag returns the local copy of a flag
+func (f *FlagStore) Flag(key string) (Flag, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	flag, ok := f.flags[key]
+	return flag, ok
+}
+
+// BooleanEvaluation resolves a boolean flag
+func (f *FlagStore) BooleanEvaluation(ctx context.Context, flag string, defaultValue bool, evalCtx map[string]interface{}) FlagResolution {
+	return f.evaluate(flag, defaultValue, evalCtx, func(v interface{}) (interface{}, bool) {
+		b, ok := v.(bool)
+		return b, ok
+	})
+}
+
+// StringEvaluation resolves a string flag
+func (f *FlagStore) StringEvaluation(ctx context.Context, flag string, defaultValue string, evalCtx map[string]interface{}) FlagResolution {
+	return f.evaluate(flag, defaultValue, evalCtx, func(v interface{}) (interface{}, bool) {
+		s, ok := v.(string)
+		return s, ok
+	})
+}
+
+// FloatEvaluation resolves a numeric flag as float64
+func (f *FlagStore) FloatEvaluation(ctx context.Context, flag string, defaultValue float64, evalCtx map[string]interface{}) FlagResolution {
+	return f.evaluate(flag, defaultValue, evalCtx, func(v interface{}) (interface{}, bool) {
+		n, ok := v.(float64)
+		return n, ok
+	})
+}
+
+// IntEvaluation resolves an integral numeric flag as int64
+func (f *FlagStore) IntEvaluation(ctx context.Context, flag string, defaultValue int64, evalCtx map[string]interface{}) FlagResolution {
+	return f.evaluate(flag, defaultValue, evalCtx, func(v interface{}) (interface{}, bool) {
+		n, ok := v.(float64)
+		if !ok || n != math.Trunc(n) {
+			return nil, false
+		}
+		return int64(n), true
+	})
+}
+
+// ObjectEvaluation resolves a flag of any JSON type
+func (f *FlagStore) ObjectEvaluation(ctx context.Context, flag string, defaultValue interface{}, evalCtx map[string]interface{}) FlagResolution {
+	return f.evaluate(flag, defaultValue, evalCtx, func(v interface{}) (interface{}, bool) {
+		return v, true
+	})
+}
+
+// evaluate selects the variant of flag for evalCtx and converts its value with convert
+func (f *FlagStore) evaluate(key string, defaultValue interface{}, evalCtx map[string]interface{}, convert func(interface{}) (interface{}, bool)) FlagResolution {
+	f.mu.RLock()
+	ready := f.ready
+	flag, found := f.flags[key]
+	f.mu.RUnlock()
+
+	fallback := func(reason, code string) FlagResolution {
+		return FlagResolution{Value: defaultValue, Reason: reason, ErrorCode: code}
+	}
+	switch {
+	case !ready:
+		return fallback(ReasonError, ErrorProviderNotReady)
+	case !found:
+		return fallback(ReasonError, ErrorFlagNotFound)
+	case flag.Disabled:
+		return fallback(ReasonDisabled, "")
+	}
+
+	variant, reason := flag.DefaultVariant, ReasonStatic
+	if len(flag.Rules) > 0 {
+		reason = ReasonDefault
+	}
+	for _, rule := range flag.Rules {
+		if attr, ok := evalCtx[rule.Attribute]; ok && containsString(rule.Values, fmt.Sprint(attr)) {
+			variant, reason = rule.Variant, ReasonTargetingMatch
+			break
+		}
+	}
+
+	raw, ok := flag.Variants[variant]
+	if !ok {
+		return fallback(ReasonError, ErrorParse)
+	}
+	value, ok := convert(raw)
+	if !ok {
+		return fallback(ReasonError, ErrorTypeMismatch)
+	}
+	return FlagResolution{Value: value, Variant: variant, Reason: reason}
+}
+
+// latestSequence returns the latest changefeed sequence number
+func (f *FlagStore) latestSequence(ctx context.Context) (uint64, error) {
+	var result struct {
+		LatestSequence uint64 `json:"latest_sequence"`
+	}
+	if err := f.client.request(ctx, "GET", "/changefeed?limit=0", nil, &result, nil); err != nil {
+		return 0, fmt.Errorf("failed to read changefeed: %w", err)
+	}
+	return result.LatestSequence, nil
+}
+
+// watch applies changefeed events after seq to the local flags until ctx is cancelled
+func (f *FlagStore) watch(ctx context.Context, seq uint64) {
+	defer close(f.done)
+
+	prefix := f.opts.Collection + ":"
+	backoff := 100 * time.Millisecond
+	for ctx.Err() == nil {
+		query := url.Values{}
+		query.Set("from_seq", strconv.FormatUint(seq, 10))
+		query.Set("limit", "100")
+		query.Set("long_poll_ms", strconv.FormatInt(f.opts.PollTimeout.Milliseconds(), 10))
+		query.Set("key_prefix", prefix)
+
+		var result struct {
+			Events []changeEvent `json:"events"`
+		}
+		if err := f.client.request(ctx, "GET", "/changefeed?"+query.Encode(), nil, &result, nil); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if f.opts.OnError != nil {
+				f.opts.OnError(fmt.Errorf("failed to watch flags: %w", err))
+			}
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return
+			}
+			if backoff < 10*time.Second {
+				backoff *= 2
+			}
+			continue
+		}
+		backoff = 100 * time.Millisecond
+
+		for _, event := range result.Events {
+			seq = event.Sequence
+			if strings.HasPrefix(event.Key, prefix) {
+				f.apply(strings.TrimPrefix(event.Key, prefix), event)
+			}
+		}
+	}
+}
+
+// apply updates the local copy of a flag from a changefeed event
+func (f *FlagStore) apply(key string, event changeEvent) {
+	f.mu.Lock()
+	switch event.Type {
+	case "PUT":
+		var flag Flag
+		if event.Value == nil || json.Unmarshal([]byte(*event.Value), &flag) != nil {
+			f.mu.Unlock()
+			if f.opts.OnError != nil {
+				f.opts.OnError(fmt.Errorf("failed to decode flag %s", key))
+			}
+			return
+		}
+		f.flags[key] = flag
+	case "DELETE":
+		delete(f.flags, key)
+	default:
+		f.mu.Unlock()
+		return
+	}
+	f.mu.Unlock()
+
+	if f.opts.OnChange != nil {
+		f.opts.OnChange(key)
+	}
+}
+
+// containsString reports whether values contains s
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
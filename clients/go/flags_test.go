@@ -0,0 +1,134 @@
+package themisdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// changefeedServer serves entities from a memoryStore and records their writes in a changefeed
+type changefeedServer struct {
+	store  *memoryStore
+	mu     sync.Mutex
+	events []changeEvent
+}
+
+func (s *changefeedServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/changefeed" {
+		s.serveChangefeed(w, r)
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	s.store.serveHTTP(w, r)
+
+	if parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/"), "/"); len(parts) == 3 && (r.Method == "PUT" || r.Method == "DELETE") {
+		s.mu.Lock()
+		event := changeEvent{Sequence: uint64(len(s.events) + 1), Type: r.Method, Key: parts[1] + ":" + parts[2]}
+		if r.Method == "PUT" {
+			value := string(body)
+			event.Value = &value
+		}
+		s.events = append(s.events, event)
+		s.mu.Unlock()
+	}
+}
+
+func (s *changefeedServer) serveChangefeed(w http.ResponseWriter, r *http.Request) {
+	from, _ := strconv.ParseUint(r.URL.Query().Get("from_seq"), 10, 64)
+	wait, _ := strconv.Atoi(r.URL.Query().Get("long_poll_ms"))
+	prefix := r.URL.Query().Get("key_prefix")
+
+	deadline := time.Now().Add(time.Duration(wait) * time.Millisecond)
+	for {
+		s.mu.Lock()
+		var events []changeEvent
+		for _, e := range s.events {
+			if e.Sequence > from && strings.HasPrefix(e.Key, prefix) {
+				events = append(events, e)
+			}
+		}
+		latest := len(s.events)
+		s.mu.Unlock()
+
+		if len(events) > 0 || time.Now().After(deadline) {
+			json.NewEncoder(w).Encode(map[string]interface{}{"events": events, "latest_sequence": latest})
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFlagStore_Evaluation(t *testing.T) {
+	server := &changefeedServer{store: &memoryStore{docs: map[string]map[string]interface{}{}, revisions: map[string]int{}}}
+	client := newTestClient(t, server.serveHTTP)
+	ctx := context.Background()
+
+	flags := client.FlagStore(FlagStoreOptions{})
+	res := flags.BooleanEvaluation(ctx, "new-checkout", false, nil)
+	assert.Equal(t, ErrorProviderNotReady, res.ErrorCode)
+
+	require.NoError(t, flags.SetFlag(ctx, "new-checkout", Flag{
+		Variants:       map[string]interface{}{"on": true, "off": false},
+		DefaultVariant: "off",
+		Rules:          []FlagRule{{Attribute: "plan", Values: []string{"pro", "enterprise"}, Variant: "on"}},
+	}))
+	require.NoError(t, flags.SetFlag(ctx, "max-items", Flag{
+		Variants:       map[string]interface{}{"small": 10, "fraction": 2.5},
+		DefaultVariant: "small",
+	}))
+	require.NoError(t, flags.Start(ctx))
+	defer flags.Close()
+
+	res = flags.BooleanEvaluation(ctx, "new-checkout", false, map[string]interface{}{"plan": "pro"})
+	assert.Equal(t, FlagResolution{Value: true, Variant: "on", Reason: ReasonTargetingMatch}, res)
+	res = flags.BooleanEvaluation(ctx, "new-checkout", true, map[string]interface{}{"plan": "free"})
+	assert.Equal(t, FlagResolution{Value: false, Variant: "off", Reason: ReasonDefault}, res)
+
+	res = flags.IntEvaluation(ctx, "max-items", 1, nil)
+	assert.Equal(t, FlagResolution{Value: int64(10), Variant: "small", Reason: ReasonStatic}, res)
+	res = flags.StringEvaluation(ctx, "max-items", "x", nil)
+	assert.Equal(t, FlagResolution{Value: "x", Reason: ReasonError, ErrorCode: ErrorTypeMismatch}, res)
+	res = flags.FloatEvaluation(ctx, "missing", 0.5, nil)
+	assert.Equal(t, FlagResolution{Value: 0.5, Reason: ReasonError, ErrorCode: ErrorFlagNotFound}, res)
+}
+
+func TestFlagStore_LiveUpdates(t *testing.T) {
+	server := &changefeedServer{store: &memoryStore{docs: map[string]map[string]interface{}{}, revisions: map[string]int{}}}
+	client := newTestClient(t, server.serveHTTP)
+	ctx := context.Background()
+
+	changes := make(chan string, 10)
+	flags := client.FlagStore(FlagStoreOptions{PollTimeout: 50 * time.Millisecond, OnChange: func(key string) { changes <- key }})
+	require.NoError(t, flags.Start(ctx))
+	defer flags.Close()
+
+	require.NoError(t, flags.SetFlag(ctx, "banner", Flag{Variants: map[string]interface{}{"text": "hello"}, DefaultVariant: "text"}))
+	select {
+	case key := <-changes:
+		assert.Equal(t, "banner", key)
+	case <-time.After(2 * time.Second):
+		t.Fatal("flag change was not observed")
+	}
+	assert.Equal(t, "hello", flags.StringEvaluation(ctx, "banner", "", nil).Value)
+
+	require.NoError(t, flags.SetFlag(ctx, "banner", Flag{Disabled: true, Variants: map[string]interface{}{"text": "hello"}, DefaultVariant: "text"}))
+	<-changes
+	assert.Equal(t, ReasonDisabled, flags.StringEvaluation(ctx, "banner", "", nil).Reason)
+
+	require.NoError(t, flags.DeleteFlag(ctx, "banner"))
+	<-changes
+	_, found := flags.Flag("banner")
+	assert.False(t, found)
+}
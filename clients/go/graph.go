@@ -0,0 +1,176 @@
+package themisdb
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Direction selects which edges a graph operation follows
+type Direction string
+
+const (
+	// Outbound follows edges from the vertex to its targets
+	Outbound Direction = "OUTBOUND"
+	// Inbound follows edges pointing at the vertex
+	Inbound Direction = "INBOUND"
+	// AnyDirection follows edges regardless of direction
+	AnyDirection Direction = "ANY"
+)
+
+// Graph provides access to the graph model
+type Graph struct {
+	client *Client
+}
+
+// Graph returns the graph model sub-client
+func (c *Client) Graph() *Graph {
+	return &Graph{client: c}
+}
+
+// Vertex is a node of the graph
+type Vertex struct {
+	ID         string                 `json:"id"`
+	Label      string                 `json:"label,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// Edge is a directed, optionally weighted connection between two vertices
+type Edge struct {
+	ID         string                 `json:"id,omitempty"`
+	From       string                 `json:"from"`
+	To         string                 `json:"to"`
+	Label      string                 `json:"label,omitempty"`
+	Weight     float64                `json:"weight,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// Neighbor is a vertex adjacent to another vertex and the edge connecting them
+type Neighbor struct {
+	VertexID string `json:"vertex_id"`
+	Edge     Edge   `json:"edge"`
+}
+
+// TraversalOptions holds graph traversal configuration
+type TraversalOptions struct {
+	// Depth is the maximum number of hops from the start vertex (default: 1)
+	Depth int
+	// Direction of edges to follow (default: Outbound)
+	Direction Direction
+	// EdgeFilter restricts the traversal to edges with one of these labels
+	EdgeFilter []string
+}
+
+// TraversalResult holds the vertices reached by a traversal
+type TraversalResult struct {
+	StartVertex  string   `json:"start_vertex"`
+	MaxDepth     int      `json:"max_depth"`
+	VisitedCount int      `json:"visited_count"`
+	Visited      []string `json:"visited"`
+}
+
+// Path is a sequence of vertices and the edges between them
+type Path struct {
+	Vertices []string `json:"vertices"`
+	Edges    []Edge   `json:"edges"`
+	Cost     float64  `json:"cost"`
+}
+
+// AddVertex creates or replaces a vertex
+func (g *Graph) AddVertex(ctx context.Context, vertex Vertex) error {
+	if err := g.client.request(ctx, "POST", "/graph/vertex", vertex, nil, nil); err != nil {
+		return fmt.Errorf("failed to add vertex: %w", err)
+	}
+	return nil
+}
+
+// AddEdge creates an edge and returns its ID
+func (g *Graph) AddEdge(ctx context.Context, edge Edge) (string, error) {
+	var response struct {
+		ID string `json:"id"`
+	}
+	if err := g.client.request(ctx, "POST", "/graph/edge", edge, &response, nil); err != nil {
+		return "", fmt.Errorf("failed to add edge: %w", err)
+	}
+	return response.ID, nil
+}
+
+// DeleteEdge removes an edge by ID
+func (g *Graph) DeleteEdge(ctx context.Context, id string) error {
+	if err := validateKey("id", id); err != nil {
+		return err
+	}
+	path := joinPath("/graph/edge", id)
+	if err := g.client.request(ctx, "DELETE", path, nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete edge: %w", err)
+	}
+	return nil
+}
+
+// GetNeighbors returns the vertices directly connected to uuid in the given direction
+func (g *Graph) GetNeighbors(ctx context.Context, uuid string, direction Direction) ([]Neighbor, error) {
+	if err := validateKey("uuid", uuid); err != nil {
+		return nil, err
+	}
+	if direction == "" {
+		direction = Outbound
+	}
+	path := joinPath("/graph/neighbors", uuid) + "?direction=" + url.QueryEscape(string(direction))
+
+	var response struct {
+		Neighbors []Neighbor `json:"neighbors"`
+	}
+	if err := g.client.request(ctx, "GET", path, nil, &response, nil); err != nil {
+		return nil, fmt.Errorf("failed to get neighbors: %w", err)
+	}
+	return response.Neighbors, nil
+}
+
+// Traverse performs a breadth-first traversal starting at startUUID
+func (g *Graph) Traverse(ctx context.Context, startUUID string, opts TraversalOptions) (*TraversalResult, error) {
+	body := traversalBody(opts)
+	body["start_vertex"] = startUUID
+
+	var result TraversalResult
+	if err := g.client.readRequest(ctx, "POST", "/graph/traverse", body, &result, nil); err != nil {
+		return nil, fmt.Errorf("failed to traverse graph: %w", err)
+	}
+	return &result, nil
+}
+
+// ShortestPath returns the cheapest path between two vertices, using edge weights if present.
+// opts.Depth bounds the path length, zero means unbounded.
+func (g *Graph) ShortestPath(ctx context.Context, fromUUID, toUUID string, opts TraversalOptions) (*Path, error) {
+	body := traversalBody(opts)
+	body["from"] = fromUUID
+	body["to"] = toUUID
+	if opts.Depth == 0 {
+		delete(body, "max_depth")
+	}
+
+	var path Path
+	if err := g.client.readRequest(ctx, "POST", "/graph/shortest-path", body, &path, nil); err != nil {
+		return nil, fmt.Errorf("failed to find shortest path: %w", err)
+	}
+	return &path, nil
+}
+
+// traversalBody converts traversal options into request fields, applying defaults
+func traversalBody(opts TraversalOptions) map[string]interface{} {
+	if opts.Depth <= 0 {
+		opts.Depth = 1
+	}
+	if opts.Direction == "" {
+		opts.Direction = Outbound
+	}
+
+	body := map[string]interface{}{
+		"max_depth": opts.Depth,
+		"direction": strings.ToUpper(string(opts.Direction)),
+	}
+	if len(opts.EdgeFilter) > 0 {
+		body["edge_labels"] = opts.EdgeFilter
+	}
+	return body
+}
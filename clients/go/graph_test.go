@@ -0,0 +1,94 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraph_AddVertexAndEdge(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/graph/vertex":
+			var v Vertex
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&v))
+			assert.Equal(t, "alice", v.ID)
+			w.WriteHeader(http.StatusNoContent)
+		case "/graph/edge":
+			var e Edge
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&e))
+			assert.Equal(t, Edge{From: "alice", To: "bob", Label: "knows"}, e)
+			w.Write([]byte(`{"id":"e1"}`))
+		case "/graph/edge/e1":
+			assert.Equal(t, "DELETE", r.Method)
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	})
+
+	g := client.Graph()
+	ctx := context.Background()
+	require.NoError(t, g.AddVertex(ctx, Vertex{ID: "alice", Properties: map[string]interface{}{"age": 30}}))
+
+	id, err := g.AddEdge(ctx, Edge{From: "alice", To: "bob", Label: "knows"})
+	require.NoError(t, err)
+	assert.Equal(t, "e1", id)
+	require.NoError(t, g.DeleteEdge(ctx, id))
+}
+
+func TestGraph_GetNeighbors(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/graph/neighbors/alice", r.URL.Path)
+		assert.Equal(t, "INBOUND", r.URL.Query().Get("direction"))
+		w.Write([]byte(`{"neighbors":[{"vertex_id":"bob","edge":{"id":"e1","from":"bob","to":"alice"}}]}`))
+	})
+
+	neighbors, err := client.Graph().GetNeighbors(context.Background(), "alice", Inbound)
+	require.NoError(t, err)
+	require.Len(t, neighbors, 1)
+	assert.Equal(t, "bob", neighbors[0].VertexID)
+	assert.Equal(t, "e1", neighbors[0].Edge.ID)
+}
+
+func TestGraph_Traverse(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/graph/traverse", r.URL.Path)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "alice", body["start_vertex"])
+		assert.Equal(t, float64(2), body["max_depth"])
+		assert.Equal(t, "ANY", body["direction"])
+		assert.Equal(t, []interface{}{"knows"}, body["edge_labels"])
+		w.Write([]byte(`{"start_vertex":"alice","max_depth":2,"visited_count":2,"visited":["alice","bob"]}`))
+	})
+
+	result, err := client.Graph().Traverse(context.Background(), "alice", TraversalOptions{
+		Depth:      2,
+		Direction:  AnyDirection,
+		EdgeFilter: []string{"knows"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, result.Visited)
+}
+
+func TestGraph_ShortestPath(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/graph/shortest-path", r.URL.Path)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "alice", body["from"])
+		assert.Equal(t, "carol", body["to"])
+		assert.NotContains(t, body, "max_depth")
+		w.Write([]byte(`{"vertices":["alice","bob","carol"],"edges":[{"from":"alice","to":"bob"},{"from":"bob","to":"carol"}],"cost":2}`))
+	})
+
+	path, err := client.Graph().ShortestPath(context.Background(), "alice", "carol", TraversalOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob", "carol"}, path.Vertices)
+	assert.Len(t, path.Edges, 2)
+	assert.Equal(t, float64(2), path.Cost)
+}
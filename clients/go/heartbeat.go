@@ -0,0 +1,81 @@
+package themisdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// startHeartbeat pings the server every interval until stopHeartbeat is called, so it
+// does not expire the transaction while the client is still working on it
+func (tx *Transaction) startHeartbeat(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	var once sync.Once
+	tx.stopHeartbeat = func() {
+		once.Do(cancel)
+		<-done
+	}
+	go tx.heartbeat(ctx, interval, done)
+}
+
+// heartbeat is the loop of startHeartbeat. It ends when ctx is done, the server reports
+// the transaction unknown or expired, or its node is lost; other failures are retried
+// on the next tick.
+func (tx *Transaction) heartbeat(ctx context.Context, interval time.Duration, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	reqBody := map[string]interface{}{
+		"transaction_id": tx.transactionID,
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pingCtx, cancel := context.WithTimeout(pinTransaction(ctx, tx), interval)
+		err := tx.client.request(pingCtx, "POST", "/transaction/heartbeat", reqBody, nil, nil)
+		cancel()
+
+		var statusErr *StatusError
+		switch {
+		case err == nil || ctx.Err() != nil:
+		case errors.Is(err, ErrNotFound) || (errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusGone):
+			tx.expired.Store(true)
+			return
+		case errors.Is(err, ErrTransactionNodeLost):
+			return
+		}
+	}
+}
+
+// stop ends the heartbeat and the context watch of the transaction
+func (tx *Transaction) stop() {
+	if tx.stopWatch != nil {
+		tx.stopWatch()
+	}
+	if tx.stopHeartbeat != nil {
+		tx.stopHeartbeat()
+	}
+}
+
+// Err returns why the transaction can no longer be used although it was not committed
+// or rolled back: a *TransactionNodeError if its node was lost, or an error matching
+// ErrTransactionExpired if the heartbeat found that the server expired it. It returns
+// nil otherwise.
+func (tx *Transaction) Err() error {
+	if lost := tx.lost.Load(); lost != nil {
+		return lost
+	}
+	if tx.expired.Load() {
+		return fmt.Errorf("%w: transaction %s", ErrTransactionExpired, tx.transactionID)
+	}
+	return nil
+}
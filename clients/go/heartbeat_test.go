@@ -0,0 +1,74 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// heartbeatServer serves transactions whose heartbeats fail with status gone after
+// expireAfter pings; expireAfter 0 never expires them
+func heartbeatServer(t *testing.T, expireAfter int32) (*Client, *atomic.Int32) {
+	var pings atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/transaction/begin":
+			w.Write([]byte(`{"transaction_id":"tx-1"}`))
+		case "/transaction/heartbeat":
+			if n := pings.Add(1); expireAfter > 0 && n > expireAfter {
+				w.WriteHeader(http.StatusGone)
+			}
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	return client, &pings
+}
+
+func TestTransaction_Heartbeat(t *testing.T) {
+	client, pings := heartbeatServer(t, 0)
+	ctx := context.Background()
+
+	tx, err := client.BeginTransaction(ctx, &TransactionOptions{IsolationLevel: Snapshot, Timeout: time.Second, Heartbeat: 5 * time.Millisecond})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return pings.Load() >= 3 }, time.Second, time.Millisecond)
+	assert.NoError(t, tx.Err())
+	require.NoError(t, tx.Commit(ctx))
+
+	stopped := pings.Load()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, stopped, pings.Load(), "the heartbeat stops on commit")
+
+	tx, err = client.BeginTransaction(ctx, &TransactionOptions{Heartbeat: 5 * time.Millisecond})
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback(ctx))
+	stopped = pings.Load()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, stopped, pings.Load(), "the heartbeat stops on rollback")
+
+	tx, err = client.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, stopped, pings.Load(), "transactions have no heartbeat by default")
+}
+
+func TestTransaction_HeartbeatExpired(t *testing.T) {
+	client, _ := heartbeatServer(t, 2)
+	ctx := context.Background()
+
+	tx, err := client.BeginTransaction(ctx, &TransactionOptions{Heartbeat: 5 * time.Millisecond})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return tx.Err() != nil }, time.Second, time.Millisecond)
+	assert.ErrorIs(t, tx.Err(), ErrTransactionExpired)
+	assert.False(t, tx.IsActive())
+
+	err = tx.Put(ctx, "relational", "users", "u1", map[string]string{"name": "Ada"})
+	assert.ErrorIs(t, err, ErrTransactionExpired)
+	assert.ErrorIs(t, tx.Commit(ctx), ErrTransactionExpired)
+	assert.NoError(t, tx.Rollback(ctx))
+}
@@ -0,0 +1,150 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HedgingOptions configures hedged reads. If a Get, GetMany, or Query has not returned
+// within the given percentile of recent read latencies, the same request is sent to
+// the next endpoint and the first response wins; the slower request is cancelled.
+// Write queries are never hedged: only AQL without INSERT, UPDATE, REPLACE, REMOVE, or
+// UPSERT is, unless QueryOptions.ReadOnly opts a query in.
+type HedgingOptions struct {
+	// Percentile of recent latencies after which a read is hedged (default: 0.95)
+	Percentile float64
+	// InitialDelay is the hedging threshold until MinSamples latencies were observed (default: 50ms)
+	InitialDelay time.Duration
+	// MinDelay is the lower bound of the hedging threshold (default: 1ms)
+	MinDelay time.Duration
+	// MinSamples is the number of latencies required before the percentile is used (default: 20)
+	MinSamples int
+	// Window is the number of recent latencies tracked (default: 1000)
+	Window int
+}
+
+// hedger tracks read latencies and derives the hedging threshold from them
+type hedger struct {
+	opts HedgingOptions
+
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+// newHedger returns a hedger for opts, or nil if hedging is disabled
+func newHedger(opts *HedgingOptions) *hedger {
+	if opts == nil {
+		return nil
+	}
+	o := *opts
+	if o.Percentile <= 0 || o.Percentile >= 1 {
+		o.Percentile = 0.95
+	}
+	if o.InitialDelay <= 0 {
+		o.InitialDelay = 50 * time.Millisecond
+	}
+	if o.MinDelay <= 0 {
+		o.MinDelay = time.Millisecond
+	}
+	if o.MinSamples <= 0 {
+		o.MinSamples = 20
+	}
+	if o.Window <= 0 {
+		o.Window = 1000
+	}
+	return &hedger{opts: o}
+}
+
+// observe records the latency of a completed read
+func (h *hedger) observe(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.samples) < h.opts.Window {
+		h.samples = append(h.samples, d)
+		return
+	}
+	h.samples[h.next] = d
+	h.next = (h.next + 1) % h.opts.Window
+}
+
+// delay returns how long to wait for the primary response before hedging
+func (h *hedger) delay() time.Duration {
+	h.mu.Lock()
+	if len(h.samples) < h.opts.MinSamples {
+		h.mu.Unlock()
+		return h.opts.InitialDelay
+	}
+	sorted := append([]time.Duration(nil), h.samples...)
+	h.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	d := sorted[int(h.opts.Percentile*float64(len(sorted)-1))]
+	if d < h.opts.MinDelay {
+		d = h.opts.MinDelay
+	}
+	return d
+}
+
+// hedgeResult is the outcome of one of the racing requests
+type hedgeResult struct {
+	resp *Response
+	err  error
+}
+
+// doHedged sends req to the active endpoint and, if it is slow or fails, to the next one,
+// returning the first successful response
+func (c *Client) doHedged(ctx context.Context, req *Request) (*Response, error) {
+	c.mu.RLock()
+	primary := strings.TrimSuffix(c.endpoints[c.activeIdx], "/")
+	secondary := strings.TrimSuffix(c.endpoints[(c.activeIdx+1)%len(c.endpoints)], "/")
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan hedgeResult, 2)
+	launch := func(endpoint string) {
+		go func() {
+			resp, err := c.sendTo(ctx, endpoint, req)
+			results <- hedgeResult{resp: resp, err: err}
+		}()
+	}
+
+	start := time.Now()
+	launch(primary)
+	pending, hedged := 1, false
+	timer := time.NewTimer(c.hedger.delay())
+	defer timer.Stop()
+
+	var last hedgeResult
+	for {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil && r.resp.StatusCode < http.StatusInternalServerError {
+				c.hedger.observe(time.Since(start))
+				return r.resp, nil
+			}
+			last = r
+			if !hedged {
+				hedged = true
+				pending++
+				launch(secondary)
+			} else if pending == 0 {
+				return last.resp, last.err
+			}
+		case <-timer.C:
+			if !hedged {
+				hedged = true
+				pending++
+				launch(secondary)
+			}
+		}
+	}
+}
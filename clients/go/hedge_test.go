@@ -0,0 +1,140 @@
+package themisdb
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_HedgedGet(t *testing.T) {
+	cancelled := make(chan struct{}, 1)
+	var fastCalls int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+			select {
+			case cancelled <- struct{}{}:
+			default:
+			}
+		case <-time.After(2 * time.Second):
+			w.Write([]byte(`{"node":"slow"}`))
+		}
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fastCalls, 1)
+		w.Write([]byte(`{"node":"fast"}`))
+	}))
+	defer fast.Close()
+
+	client := NewClient(Config{
+		Endpoints: []string{slow.URL, fast.URL},
+		Hedging:   &HedgingOptions{InitialDelay: 20 * time.Millisecond},
+	})
+	ctx := context.Background()
+
+	var result map[string]string
+	start := time.Now()
+	require.NoError(t, client.Get(ctx, "relational", "users", "1", &result))
+	assert.Equal(t, "fast", result["node"])
+	assert.Less(t, time.Since(start), time.Second)
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("slow request was not cancelled")
+	}
+
+	// writes and transactional reads are never hedged
+	atomic.StoreInt32(&fastCalls, 0)
+	writeCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	assert.Error(t, client.Put(writeCtx, "relational", "users", "1", map[string]string{}))
+	tx := &Transaction{client: client, transactionID: "tx-1", active: true}
+	assert.Error(t, tx.Get(writeCtx, "relational", "users", "1", &result))
+	assert.Zero(t, atomic.LoadInt32(&fastCalls))
+}
+
+func TestClient_HedgedGetFailover(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[1,2]}`))
+	}))
+	defer healthy.Close()
+
+	client := NewClient(Config{
+		Endpoints: []string{failing.URL, healthy.URL},
+		Hedging:   &HedgingOptions{InitialDelay: time.Second},
+	})
+
+	var rows []int
+	start := time.Now()
+	require.NoError(t, client.Query(context.Background(), "FOR x IN xs RETURN x", &rows))
+	assert.Equal(t, []int{1, 2}, rows)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestHedger_Delay(t *testing.T) {
+	h := newHedger(&HedgingOptions{Percentile: 0.9, MinSamples: 10, Window: 10, InitialDelay: time.Second})
+	assert.Equal(t, time.Second, h.delay())
+
+	for i := 1; i <= 20; i++ {
+		h.observe(time.Duration(i) * time.Millisecond)
+	}
+	// only the 10 most recent samples (11ms..20ms) are kept
+	assert.Equal(t, 19*time.Millisecond, h.delay())
+	assert.Nil(t, newHedger(nil))
+}
+
+func TestClient_HedgedQuery(t *testing.T) {
+	var calls int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(100 * time.Millisecond):
+		}
+		w.Write([]byte(`{"data":[]}`))
+	})
+	first, second := httptest.NewServer(handler), httptest.NewServer(handler)
+	defer first.Close()
+	defer second.Close()
+
+	client := NewClient(Config{
+		Endpoints: []string{first.URL, second.URL},
+		Hedging:   &HedgingOptions{InitialDelay: 10 * time.Millisecond},
+	})
+	ctx := context.Background()
+
+	require.NoError(t, client.Query(ctx, "FOR u IN users RETURN u", nil))
+	assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+
+	// write queries are never hedged, so they are applied once
+	for _, aql := range []string{
+		"INSERT {name: 'Ada'} INTO users",
+		"FOR u IN users FILTER u.inactive UPDATE u WITH {archived: true} IN users",
+		"FOR u IN users FILTER u.inactive REMOVE u IN users",
+		"UPSERT {name: 'Ada'} INSERT {name: 'Ada'} UPDATE {} IN users",
+	} {
+		atomic.StoreInt32(&calls, 0)
+		require.NoError(t, client.Query(ctx, aql, nil))
+		time.Sleep(20 * time.Millisecond)
+		assert.EqualValues(t, 1, atomic.LoadInt32(&calls), aql)
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	require.NoError(t, client.QueryWithOptions(ctx, "LET remove = 1 RETURN remove", &QueryOptions{ReadOnly: true}, nil))
+	assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,43 @@
+package themisdb
+
+// QueryHints override decisions of the server's query planner without changing the
+// AQL text, e.g. to work around a plan regression until the planner is fixed. Hints
+// the planner cannot honor, such as an unknown index, fail the query.
+type QueryHints struct {
+	// ForceIndex maps a collection to the index the planner must use for it
+	ForceIndex map[string]string `json:"force_index,omitempty"`
+	// Shards restricts the query to the listed shards (default: all)
+	Shards []string `json:"shards,omitempty"`
+	// Partitions restricts the query to the listed partitions (default: all)
+	Partitions []string `json:"partitions,omitempty"`
+	// DisableRules turns off optimizer rules by name, e.g. "use-index-for-sort"
+	DisableRules []string `json:"disable_rules,omitempty"`
+}
+
+// validate checks the names used by the hints
+func (h *QueryHints) validate() error {
+	for collection, index := range h.ForceIndex {
+		if err := validateName("collection", collection); err != nil {
+			return err
+		}
+		if err := validateName("index", index); err != nil {
+			return err
+		}
+	}
+	for _, shard := range h.Shards {
+		if err := validateName("shard", shard); err != nil {
+			return err
+		}
+	}
+	for _, partition := range h.Partitions {
+		if err := validateName("partition", partition); err != nil {
+			return err
+		}
+	}
+	for _, rule := range h.DisableRules {
+		if err := validateName("optimizer rule", rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
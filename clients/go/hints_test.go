@@ -0,0 +1,68 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_QueryHints(t *testing.T) {
+	var bodies []map[string]interface{}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies = append(bodies, body)
+		w.Write([]byte(`{"data":[],"profile":{"indexes_used":["orders_by_customer"]}}`))
+	})
+	ctx := context.Background()
+	hints := &QueryHints{
+		ForceIndex:   map[string]string{"orders": "orders_by_customer"},
+		Shards:       []string{"shard-2"},
+		DisableRules: []string{"use-index-for-sort"},
+	}
+
+	var orders []map[string]interface{}
+	require.NoError(t, client.QueryWithOptions(ctx, "FOR o IN orders RETURN o", &QueryOptions{Hints: hints}, &orders))
+	profile, err := client.QueryWithProfile(ctx, "FOR o IN orders RETURN o", &QueryOptions{Hints: hints}, &orders)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders_by_customer"}, profile.IndexesUsed)
+	require.NoError(t, client.Query(ctx, "FOR o IN orders RETURN o", &orders))
+
+	require.Len(t, bodies, 3)
+	want := map[string]interface{}{
+		"force_index":   map[string]interface{}{"orders": "orders_by_customer"},
+		"shards":        []interface{}{"shard-2"},
+		"disable_rules": []interface{}{"use-index-for-sort"},
+	}
+	assert.Equal(t, want, bodies[0]["hints"])
+	assert.Equal(t, want, bodies[1]["hints"])
+	assert.NotContains(t, bodies[2], "hints", "queries without hints leave the planner alone")
+}
+
+func TestQueryHints_Validate(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("invalid hints must not be sent")
+	})
+	tests := []struct {
+		name  string
+		hints QueryHints
+		err   string
+	}{
+		{"collection", QueryHints{ForceIndex: map[string]string{"bad/name": "idx"}}, `invalid collection "bad/name"`},
+		{"index", QueryHints{ForceIndex: map[string]string{"orders": ""}}, `invalid index ""`},
+		{"shard", QueryHints{Shards: []string{"-1"}}, `invalid shard "-1"`},
+		{"partition", QueryHints{Partitions: []string{"2024 Q1"}}, `invalid partition "2024 Q1"`},
+		{"rule", QueryHints{DisableRules: []string{""}}, `invalid optimizer rule ""`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := client.QueryWithOptions(context.Background(), "FOR o IN orders RETURN o", &QueryOptions{Hints: &tt.hints}, nil)
+			assert.ErrorIs(t, err, ErrInvalidInput)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package themisdb
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRedactedHeaders are the request headers whose values are replaced in
+// RequestEvent, in addition to RequestHooks.RedactHeaders
+var DefaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "X-Api-Key", "X-Signature"}
+
+// RequestEvent describes one attempt to send a request to an endpoint
+type RequestEvent struct {
+	Method   string
+	Path     string
+	Endpoint string
+	// Attempt counts the attempts of the request, starting at 1. Replica reads that
+	// fall back to the primary, hedged reads, and retries make further attempts.
+	Attempt int
+	// Header holds the request headers, sensitive values replaced by [REDACTED]
+	Header map[string]string
+	// Body is the request body, truncated to RequestHooks.MaxBodySize; nil unless
+	// body logging is enabled
+	Body []byte
+	// Status is the status code of the response, 0 if there is none
+	Status int
+	// ResponseBody is the response body, truncated like Body
+	ResponseBody []byte
+	// Duration is the latency of the attempt, zero in OnRequest
+	Duration time.Duration
+	// Err is the transport error of the attempt; in OnRetry, that of the previous one
+	Err error
+}
+
+// RequestHooks observes every attempt of every request. Hooks are called
+// synchronously on the goroutine sending the request and must be fast; hedged
+// attempts may call them concurrently.
+type RequestHooks struct {
+	// OnRequest is called before each attempt
+	OnRequest func(ctx context.Context, e *RequestEvent)
+	// OnResponse is called when an attempt receives a response, of any status
+	OnResponse func(ctx context.Context, e *RequestEvent)
+	// OnRetry is called before each attempt after the first, with the status or
+	// error of the previous attempt
+	OnRetry func(ctx context.Context, e *RequestEvent)
+	// OnError is called when an attempt fails without a response
+	OnError func(ctx context.Context, e *RequestEvent)
+	// MaxBodySize includes up to this many bytes of request and response bodies in
+	// the events; 0 omits bodies
+	MaxBodySize int
+	// RedactHeaders lists further headers whose values are replaced
+	RedactHeaders []string
+}
+
+// requestHooks dispatches the events of the attempts to Config.Logger and Config.Hooks
+type requestHooks struct {
+	logger *slog.Logger
+	hooks  RequestHooks
+	redact map[string]bool
+}
+
+// newRequestHooks returns the hooks of config, or nil if it has neither a logger nor hooks
+func newRequestHooks(config Config) *requestHooks {
+	if config.Logger == nil && config.Hooks == nil {
+		return nil
+	}
+	h := &requestHooks{logger: config.Logger, redact: map[string]bool{}}
+	if config.Hooks != nil {
+		h.hooks = *config.Hooks
+	}
+	for _, names := range [][]string{DefaultRedactedHeaders, h.hooks.RedactHeaders} {
+		for _, name := range names {
+			h.redact[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+	return h
+}
+
+// attemptsKey is the context key of the attempt counter of a request
+type attemptsKey struct{}
+
+// attempts counts the attempts of a request and keeps the outcome of the last one
+type attempts struct {
+	mu     sync.Mutex
+	n      int
+	status int
+	err    error
+}
+
+// withAttempts returns a context counting the attempts of one request
+func withAttempts(ctx context.Context) context.Context {
+	return context.WithValue(ctx, attemptsKey{}, &attempts{})
+}
+
+// begin returns the event of the next attempt of req to endpoint, and the event for
+// OnRetry if it is not the first
+func (h *requestHooks) begin(ctx context.Context, endpoint string, req *Request) (e, retry *RequestEvent) {
+	e = &RequestEvent{Method: req.Method, Path: req.Path, Endpoint: endpoint, Attempt: 1, Body: h.truncate(req.Body)}
+	if len(req.Header) > 0 {
+		e.Header = make(map[string]string, len(req.Header))
+		for name, value := range req.Header {
+			if h.redact[http.CanonicalHeaderKey(name)] {
+				value = redacted
+			}
+			e.Header[name] = value
+		}
+	}
+	if a, ok := ctx.Value(attemptsKey{}).(*attempts); ok {
+		a.mu.Lock()
+		a.n++
+		e.Attempt = a.n
+		if a.n > 1 {
+			r := *e
+			r.Status, r.Err = a.status, a.err
+			retry = &r
+		}
+		a.mu.Unlock()
+	}
+	return e, retry
+}
+
+// truncate returns body cut to MaxBodySize, nil if bodies are not logged
+func (h *requestHooks) truncate(body []byte) []byte {
+	if h.hooks.MaxBodySize <= 0 || body == nil {
+		return nil
+	}
+	if len(body) > h.hooks.MaxBodySize {
+		body = body[:h.hooks.MaxBodySize]
+	}
+	return append([]byte(nil), body...)
+}
+
+// observe sends one attempt of req through send and reports it to the hooks
+func (h *requestHooks) observe(ctx context.Context, endpoint string, req *Request, send func() (*Response, error)) (*Response, error) {
+	e, retry := h.begin(ctx, endpoint, req)
+	if retry != nil {
+		h.log(ctx, slog.LevelWarn, "themisdb retry", retry)
+		if h.hooks.OnRetry != nil {
+			h.hooks.OnRetry(ctx, retry)
+		}
+	}
+	h.log(ctx, slog.LevelDebug, "themisdb request", e)
+	if h.hooks.OnRequest != nil {
+		h.hooks.OnRequest(ctx, e)
+	}
+
+	start := time.Now()
+	resp, err := send()
+	done := *e
+	done.Duration = time.Since(start)
+	if resp != nil {
+		done.Status = resp.StatusCode
+		done.ResponseBody = h.truncate(resp.Body)
+	}
+	if a, ok := ctx.Value(attemptsKey{}).(*attempts); ok {
+		a.mu.Lock()
+		a.status, a.err = done.Status, err
+		a.mu.Unlock()
+	}
+
+	if err != nil {
+		done.Err = err
+		h.log(ctx, slog.LevelError, "themisdb request failed", &done)
+		if h.hooks.OnError != nil {
+			h.hooks.OnError(ctx, &done)
+		}
+		return resp, err
+	}
+	level := slog.LevelDebug
+	if done.Status >= http.StatusInternalServerError {
+		level = slog.LevelWarn
+	}
+	h.log(ctx, level, "themisdb response", &done)
+	if h.hooks.OnResponse != nil {
+		h.hooks.OnResponse(ctx, &done)
+	}
+	return resp, nil
+}
+
+// log writes e to the logger, if there is one and it is enabled for level
+func (h *requestHooks) log(ctx context.Context, level slog.Level, msg string, e *RequestEvent) {
+	if h.logger == nil || !h.logger.Enabled(ctx, level) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("method", e.Method),
+		slog.String("path", e.Path),
+		slog.String("endpoint", e.Endpoint),
+		slog.Int("attempt", e.Attempt),
+	}
+	if e.Status != 0 {
+		attrs = append(attrs, slog.Int("status", e.Status))
+	}
+	if e.Duration != 0 {
+		attrs = append(attrs, slog.Duration("duration", e.Duration))
+	}
+	if e.Err != nil {
+		attrs = append(attrs, slog.String("error", e.Err.Error()))
+	}
+	if len(e.Header) > 0 {
+		headers := make([]any, 0, len(e.Header))
+		for name, value := range e.Header {
+			headers = append(headers, slog.String(name, value))
+		}
+		attrs = append(attrs, slog.Group("header", headers...))
+	}
+	if e.Body != nil {
+		attrs = append(attrs, slog.String("body", strings.ToValidUTF8(string(e.Body), "?")))
+	}
+	if e.ResponseBody != nil {
+		attrs = append(attrs, slog.String("response_body", strings.ToValidUTF8(string(e.ResponseBody), "?")))
+	}
+	h.logger.LogAttrs(ctx, level, msg, attrs...)
+}
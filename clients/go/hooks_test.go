@@ -0,0 +1,126 @@
+package themisdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_RequestHooks(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name": "Alice", "email": "alice@example.com"}`))
+	}))
+	defer primary.Close()
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "replica lagging", http.StatusServiceUnavailable)
+	}))
+	defer replica.Close()
+
+	var mu sync.Mutex
+	var events []string
+	var retry, response *RequestEvent
+	record := func(kind string) func(context.Context, *RequestEvent) {
+		return func(_ context.Context, e *RequestEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, kind)
+			switch kind {
+			case "retry":
+				retry = e
+			case "response":
+				response = e
+			}
+		}
+	}
+	var logs bytes.Buffer
+	auth := func(ctx context.Context, req *Request, next Handler) (*Response, error) {
+		headers := map[string]string{"Authorization": "Bearer secret", "X-Request-Id": "r1"}
+		for key, value := range req.Header {
+			headers[key] = value
+		}
+		req.Header = headers
+		return next(ctx, req)
+	}
+	client := NewClient(Config{
+		Endpoints:    []string{primary.URL},
+		Replicas:     []string{replica.URL},
+		Interceptors: []Interceptor{auth},
+		Logger:       slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		Hooks: &RequestHooks{
+			OnRequest:     record("request"),
+			OnResponse:    record("response"),
+			OnRetry:       record("retry"),
+			OnError:       record("error"),
+			MaxBodySize:   16,
+			RedactHeaders: []string{"x-request-id"},
+		},
+	})
+	defer client.Close()
+
+	var user map[string]interface{}
+	require.NoError(t, client.Namespace("tenant-a").GetWithOptions(context.Background(), "relational", "users", "u1", &user, &ReadOptions{Consistency: ConsistencyEventual}))
+	assert.Equal(t, "Alice", user["name"])
+
+	assert.Equal(t, []string{"request", "response", "retry", "request", "response"}, events)
+	require.NotNil(t, retry)
+	assert.Equal(t, 2, retry.Attempt)
+	assert.Equal(t, http.StatusServiceUnavailable, retry.Status, "retry reports the previous attempt")
+	assert.Equal(t, primary.URL, retry.Endpoint)
+
+	assert.Equal(t, "GET", response.Method)
+	assert.Equal(t, "/api/relational/users/u1", response.Path)
+	assert.Equal(t, 2, response.Attempt)
+	assert.Equal(t, http.StatusOK, response.Status)
+	assert.Positive(t, response.Duration)
+	assert.Equal(t, `{"name": "Alice"`, string(response.ResponseBody), "bodies are capped")
+	assert.Equal(t, redacted, response.Header["Authorization"])
+	assert.Equal(t, redacted, response.Header["X-Request-Id"])
+	assert.Equal(t, "eventual", response.Header[headerConsistency])
+	assert.Equal(t, "tenant-a", response.Header[headerNamespace])
+
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		lines = append(lines, entry)
+	}
+	require.Len(t, lines, 5)
+	assert.Equal(t, "WARN", lines[1]["level"], "server errors are logged as warnings")
+	assert.Equal(t, "themisdb retry", lines[2]["msg"])
+	assert.Equal(t, float64(2), lines[2]["attempt"])
+	assert.Equal(t, float64(200), lines[4]["status"])
+	assert.Equal(t, redacted, lines[4]["header"].(map[string]interface{})["Authorization"])
+	assert.NotContains(t, logs.String(), "secret")
+}
+
+func TestClient_RequestHooksError(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	var failed *RequestEvent
+	var logs bytes.Buffer
+	client := NewClient(Config{
+		Endpoints: []string{down.URL},
+		Logger:    slog.New(slog.NewTextHandler(&logs, nil)),
+		Hooks:     &RequestHooks{OnError: func(_ context.Context, e *RequestEvent) { failed = e }},
+	})
+	defer client.Close()
+
+	require.Error(t, client.Put(context.Background(), "relational", "users", "u1", map[string]string{"name": "Alice"}))
+	require.NotNil(t, failed)
+	assert.Equal(t, "PUT", failed.Method)
+	assert.Equal(t, 1, failed.Attempt)
+	assert.Error(t, failed.Err)
+	assert.Nil(t, failed.Body, "bodies are omitted by default")
+	assert.Contains(t, logs.String(), "level=ERROR msg=\"themisdb request failed\" method=PUT")
+	assert.NotContains(t, logs.String(), "level=DEBUG", "debug logs are filtered by the handler")
+}
@@ -0,0 +1,407 @@
+// Package integrity checks the references between ThemisDB collections, e.g. after a
+// partial migration or a bug that deleted parents without their children.
+//
+// Each Relation names a field of a child collection that holds the UUID of a document
+// of a parent collection. Scan walks the child collections in batches, looks up the
+// referenced parents with one multi-get per batch, and streams a Finding for every
+// child whose parent does not exist (an orphan) and, for required relations, every
+// child without a reference. Relations may repair their findings by deleting or
+// unlinking the children; each repair re-checks the child and its parent in a
+// transaction, so a parent created in the meantime is never lost.
+//
+//	stream := integrity.Scan(ctx, client, integrity.Config{
+//		Relations: []integrity.Relation{
+//			{Collection: "orders", Field: "customer_id", ParentCollection: "customers", Required: true},
+//			{Collection: "order_lines", Field: "order_id", ParentCollection: "orders", Repair: integrity.RepairDelete},
+//		},
+//	})
+//	_, err := stream.WriteTo(os.Stdout)
+//	fmt.Println(stream.Report().MissingParents)
+package integrity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+// Kind is the kind of a dangling reference
+type Kind string
+
+const (
+	// Orphaned is a child referencing a parent that does not exist
+	Orphaned Kind = "orphaned"
+	// Unreferenced is a child of a required relation without a reference
+	Unreferenced Kind = "unreferenced"
+)
+
+// Action repairs a finding
+type Action string
+
+const (
+	// RepairNone only reports findings (default)
+	RepairNone Action = "none"
+	// RepairDelete deletes the child
+	RepairDelete Action = "delete"
+	// RepairUnlink removes the reference field from an orphaned child; unreferenced
+	// children are left as they are
+	RepairUnlink Action = "unlink"
+)
+
+// Relation is a reference from a field of a child collection to a parent collection
+type Relation struct {
+	// Name identifies the relation in findings (default: "<collection>.<field>")
+	Name string
+	// Model is the data model of the child collection (default: relational)
+	Model      string
+	Collection string
+	// Field is the dotted path of the field holding the parent UUID
+	Field string
+	// ParentModel is the data model of the parent collection (default: Model)
+	ParentModel      string
+	ParentCollection string
+	// Required reports children without a reference as Unreferenced
+	Required bool
+	// Repair is applied to every finding of the relation unless Config.DryRun is set
+	Repair Action
+}
+
+// Config configures a Scan
+type Config struct {
+	Relations []Relation
+	// BatchSize is the number of children whose parents are looked up at once
+	// (default: 500)
+	BatchSize int
+	// DryRun reports findings without repairing them
+	DryRun bool
+}
+
+// Finding is a dangling reference
+type Finding struct {
+	Relation string `json:"relation"`
+	Kind     Kind   `json:"kind"`
+	// Child is the UUID of the referencing document
+	Child string `json:"child"`
+	// Parent is the UUID of the missing parent, empty for Unreferenced findings
+	Parent string `json:"parent,omitempty"`
+	// Repaired is the repair applied to the child, empty if it was not repaired, e.g.
+	// because the parent was created after the scan found it missing
+	Repaired Action `json:"repaired,omitempty"`
+}
+
+// Report summarizes a finished Scan
+type Report struct {
+	// Checked is the number of children scanned, per relation
+	Checked map[string]int
+	// Findings is the number of findings, per relation
+	Findings map[string]int
+	// MissingParents are the distinct UUIDs of missing parents, sorted, per relation;
+	// restoring them repairs the orphans instead of deleting them
+	MissingParents map[string][]string
+	// Repaired is the number of findings repaired
+	Repaired int
+}
+
+// Stream delivers the findings of a Scan as they are found
+type Stream struct {
+	// Findings receives the findings in child UUID order per relation and is closed
+	// when the scan ends
+	Findings <-chan Finding
+
+	report *Report
+	err    error
+	done   chan struct{}
+}
+
+// Err returns the error that ended the scan once Findings is closed, nil if every
+// relation was scanned
+func (s *Stream) Err() error {
+	<-s.done
+	return s.err
+}
+
+// Report returns the summary of the scan once Findings is closed. After a failed scan
+// it covers the children scanned until then.
+func (s *Stream) Report() *Report {
+	<-s.done
+	return s.report
+}
+
+// WriteTo writes every finding as a line of JSON to w until the scan ends. It
+// implements io.WriterTo.
+func (s *Stream) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	for finding := range s.Findings {
+		line, _ := json.Marshal(finding)
+		n, err := fmt.Fprintf(w, "%s\n", line)
+		written += int64(n)
+		if err != nil {
+			for range s.Findings {
+			}
+			return written, err
+		}
+	}
+	return written, s.Err()
+}
+
+// Scan checks the relations of cfg one after another. The findings must be received
+// from the returned stream, or ctx cancelled, for the scan to make progress.
+func Scan(ctx context.Context, client themisdb.ThemisClient, cfg Config) *Stream {
+	findings := make(chan Finding, 100)
+	stream := &Stream{
+		Findings: findings,
+		report:   &Report{Checked: map[string]int{}, Findings: map[string]int{}, MissingParents: map[string][]string{}},
+		done:     make(chan struct{}),
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+
+	relations := make([]Relation, len(cfg.Relations))
+	for i, rel := range cfg.Relations {
+		relations[i] = rel.withDefaults()
+		if err := relations[i].validate(); err != nil {
+			stream.err = err
+			close(findings)
+			close(stream.done)
+			return stream
+		}
+	}
+
+	go func() {
+		defer close(stream.done)
+		defer close(findings)
+		for _, rel := range relations {
+			s := &scan{client: client, cfg: cfg, rel: rel, report: stream.report, findings: findings}
+			if err := s.run(ctx); err != nil {
+				stream.err = fmt.Errorf("failed to check %s: %w", rel.Name, err)
+				return
+			}
+		}
+	}()
+	return stream
+}
+
+// withDefaults returns the relation with its defaults filled in
+func (r Relation) withDefaults() Relation {
+	if r.Model == "" {
+		r.Model = themisdb.ModelRelational
+	}
+	if r.ParentModel == "" {
+		r.ParentModel = r.Model
+	}
+	if r.Repair == "" {
+		r.Repair = RepairNone
+	}
+	if r.Name == "" {
+		r.Name = r.Collection + "." + r.Field
+	}
+	return r
+}
+
+// validate checks the fields a scan cannot do without; names are validated by the
+// client
+func (r Relation) validate() error {
+	switch {
+	case r.Collection == "":
+		return &themisdb.ValidationError{Field: "collection", Value: r.Name, Reason: "must not be empty"}
+	case r.ParentCollection == "":
+		return &themisdb.ValidationError{Field: "parent collection", Value: r.Name, Reason: "must not be empty"}
+	}
+	for _, part := range strings.Split(r.Field, ".") {
+		if part == "" {
+			return &themisdb.ValidationError{Field: "field", Value: r.Field, Reason: "must be a dotted path without empty parts"}
+		}
+	}
+	switch r.Repair {
+	case RepairNone, RepairDelete, RepairUnlink:
+		return nil
+	}
+	return &themisdb.ValidationError{Field: "repair", Value: string(r.Repair), Reason: "must be none, delete, or unlink"}
+}
+
+// child is a scanned child and the parent UUID it references, empty if none
+type child struct {
+	uuid   string
+	parent string
+}
+
+// scan checks a single relation
+type scan struct {
+	client   themisdb.ThemisClient
+	cfg      Config
+	rel      Relation
+	report   *Report
+	findings chan<- Finding
+	missing  map[string]bool
+}
+
+// run scans the children of the relation in batches
+func (s *scan) run(ctx context.Context) error {
+	s.missing = map[string]bool{}
+	defer func() {
+		if len(s.missing) == 0 {
+			return
+		}
+		parents := make([]string, 0, len(s.missing))
+		for uuid := range s.missing {
+			parents = append(parents, uuid)
+		}
+		sort.Strings(parents)
+		s.report.MissingParents[s.rel.Name] = parents
+	}()
+
+	it := s.client.Scan(ctx, s.rel.Model, s.rel.Collection, themisdb.ScanOptions{BatchSize: s.cfg.BatchSize})
+	batch := make([]child, 0, s.cfg.BatchSize)
+	for it.Next() {
+		var doc map[string]interface{}
+		if err := it.Decode(&doc); err != nil {
+			return fmt.Errorf("failed to decode %s: %w", it.UUID(), err)
+		}
+		batch = append(batch, child{uuid: it.UUID(), parent: reference(doc, s.rel.Field)})
+		if len(batch) == s.cfg.BatchSize {
+			if err := s.check(ctx, batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	return s.check(ctx, batch)
+}
+
+// check looks up the parents of a batch of children and reports the dangling ones
+func (s *scan) check(ctx context.Context, batch []child) error {
+	s.report.Checked[s.rel.Name] += len(batch)
+	var parents []string
+	seen := map[string]bool{}
+	for _, c := range batch {
+		if c.parent != "" && !seen[c.parent] {
+			seen[c.parent] = true
+			parents = append(parents, c.parent)
+		}
+	}
+	missing := map[string]bool{}
+	if len(parents) > 0 {
+		var docs []json.RawMessage
+		uuids, err := s.client.GetMany(ctx, s.rel.ParentModel, s.rel.ParentCollection, parents, &docs)
+		if err != nil {
+			return err
+		}
+		for _, uuid := range uuids {
+			missing[uuid] = true
+			s.missing[uuid] = true
+		}
+	}
+
+	for _, c := range batch {
+		var finding Finding
+		switch {
+		case c.parent == "" && s.rel.Required:
+			finding = Finding{Relation: s.rel.Name, Kind: Unreferenced, Child: c.uuid}
+		case missing[c.parent]:
+			finding = Finding{Relation: s.rel.Name, Kind: Orphaned, Child: c.uuid, Parent: c.parent}
+		default:
+			continue
+		}
+		if !s.cfg.DryRun {
+			repaired, err := s.repair(ctx, finding)
+			if err != nil {
+				return fmt.Errorf("failed to repair %s: %w", c.uuid, err)
+			}
+			finding.Repaired = repaired
+			if repaired != "" {
+				s.report.Repaired++
+			}
+		}
+		s.report.Findings[s.rel.Name]++
+		select {
+		case s.findings <- finding:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// repair applies the repair action of the relation to the child of a finding if it
+// still dangles, and returns the action applied
+func (s *scan) repair(ctx context.Context, f Finding) (Action, error) {
+	action := s.rel.Repair
+	if action == RepairNone || (action == RepairUnlink && f.Kind == Unreferenced) {
+		return "", nil
+	}
+
+	var applied Action
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *themisdb.Transaction) error {
+		applied = ""
+		var doc map[string]interface{}
+		if err := tx.Get(ctx, s.rel.Model, s.rel.Collection, f.Child, &doc); err != nil {
+			if errors.Is(err, themisdb.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+		parent := reference(doc, s.rel.Field)
+		if parent != f.Parent {
+			// the child changed since it was scanned
+			return nil
+		}
+		if parent != "" {
+			var existing json.RawMessage
+			err := tx.Get(ctx, s.rel.ParentModel, s.rel.ParentCollection, parent, &existing)
+			if err == nil {
+				return nil
+			}
+			if !errors.Is(err, themisdb.ErrNotFound) {
+				return err
+			}
+		}
+
+		applied = action
+		if action == RepairDelete {
+			return tx.Delete(ctx, s.rel.Model, s.rel.Collection, f.Child)
+		}
+		return tx.Patch(ctx, s.rel.Model, s.rel.Collection, f.Child, nest(s.rel.Field, nil))
+	})
+	return applied, err
+}
+
+// reference returns the parent UUID held by the dotted field of doc, empty if it is
+// missing or null
+func reference(doc map[string]interface{}, field string) string {
+	var v interface{} = doc
+	for _, part := range strings.Split(field, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		v = m[part]
+	}
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// nest returns a merge patch setting the dotted field to value
+func nest(field string, value interface{}) map[string]interface{} {
+	parts := strings.Split(field, ".")
+	patch := map[string]interface{}{parts[len(parts)-1]: value}
+	for i := len(parts) - 2; i >= 0; i-- {
+		patch = map[string]interface{}{parts[i]: patch}
+	}
+	return patch
+}
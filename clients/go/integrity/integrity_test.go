@@ -0,0 +1,145 @@
+package integrity
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+	"github.com/makr-code/ThemisDB/clients/go/themistest"
+)
+
+// seedShop stores customers, orders, and order lines with dangling references
+func seedShop(t *testing.T, fake *themistest.Fake) {
+	docs := map[string]map[string]map[string]interface{}{
+		"customers": {
+			"c1": {"name": "Ada"},
+		},
+		"orders": {
+			"o1": {"customer_id": "c1"},
+			"o2": {"customer_id": "c2"},
+			"o3": {"customer_id": "c2"},
+			"o4": {"note": "imported without customer"},
+			"o5": {"billing": map[string]interface{}{"customer": "c3"}, "customer_id": "c1"},
+		},
+		"order_lines": {
+			"l1": {"order_id": "o1"},
+			"l2": {"order_id": "o9"},
+			"l3": {},
+		},
+	}
+	for collection, byUUID := range docs {
+		for uuid, doc := range byUUID {
+			require.NoError(t, fake.Seed("relational", collection, uuid, doc))
+		}
+	}
+}
+
+func collect(stream *Stream) []Finding {
+	var findings []Finding
+	for f := range stream.Findings {
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+func TestScan(t *testing.T) {
+	client, fake := themistest.NewClient(t)
+	seedShop(t, fake)
+
+	stream := Scan(context.Background(), client, Config{
+		BatchSize: 2,
+		Relations: []Relation{
+			{Collection: "orders", Field: "customer_id", ParentCollection: "customers", Required: true},
+			{Name: "billing", Collection: "orders", Field: "billing.customer", ParentCollection: "customers"},
+			{Collection: "order_lines", Field: "order_id", ParentCollection: "orders"},
+		},
+	})
+	assert.Equal(t, []Finding{
+		{Relation: "orders.customer_id", Kind: Orphaned, Child: "o2", Parent: "c2"},
+		{Relation: "orders.customer_id", Kind: Orphaned, Child: "o3", Parent: "c2"},
+		{Relation: "orders.customer_id", Kind: Unreferenced, Child: "o4"},
+		{Relation: "billing", Kind: Orphaned, Child: "o5", Parent: "c3"},
+		{Relation: "order_lines.order_id", Kind: Orphaned, Child: "l2", Parent: "o9"},
+	}, collect(stream))
+	require.NoError(t, stream.Err())
+
+	report := stream.Report()
+	assert.Equal(t, map[string]int{"orders.customer_id": 5, "billing": 5, "order_lines.order_id": 3}, report.Checked)
+	assert.Equal(t, map[string]int{"orders.customer_id": 3, "billing": 1, "order_lines.order_id": 1}, report.Findings)
+	assert.Equal(t, map[string][]string{"orders.customer_id": {"c2"}, "billing": {"c3"}, "order_lines.order_id": {"o9"}}, report.MissingParents)
+	assert.Zero(t, report.Repaired)
+	assert.Equal(t, 5, fake.Len("relational", "orders"), "nothing is repaired by default")
+}
+
+func TestScan_Repair(t *testing.T) {
+	client, fake := themistest.NewClient(t)
+	seedShop(t, fake)
+	relations := []Relation{
+		{Collection: "orders", Field: "customer_id", ParentCollection: "customers", Required: true, Repair: RepairUnlink},
+		{Collection: "order_lines", Field: "order_id", ParentCollection: "orders", Required: true, Repair: RepairDelete},
+	}
+
+	stream := Scan(context.Background(), client, Config{Relations: relations, DryRun: true})
+	assert.Len(t, collect(stream), 5)
+	assert.Zero(t, stream.Report().Repaired)
+
+	// the missing customer of o3 shows up before the repair
+	require.NoError(t, fake.Seed("relational", "customers", "c2", map[string]interface{}{"name": "Grace"}))
+	stream = Scan(context.Background(), client, Config{Relations: relations})
+	assert.Equal(t, []Finding{
+		{Relation: "orders.customer_id", Kind: Unreferenced, Child: "o4"},
+		{Relation: "order_lines.order_id", Kind: Orphaned, Child: "l2", Parent: "o9", Repaired: RepairDelete},
+		{Relation: "order_lines.order_id", Kind: Unreferenced, Child: "l3", Repaired: RepairDelete},
+	}, collect(stream))
+	require.NoError(t, stream.Err())
+	assert.Equal(t, 2, stream.Report().Repaired)
+	assert.Equal(t, 1, fake.Len("relational", "order_lines"))
+	assert.Equal(t, 0, fake.OpenTransactions())
+
+	require.NoError(t, fake.Seed("relational", "orders", "o6", map[string]interface{}{"customer_id": "c9", "total": 10}))
+	stream = Scan(context.Background(), client, Config{Relations: relations[:1]})
+	assert.Equal(t, []Finding{
+		{Relation: "orders.customer_id", Kind: Unreferenced, Child: "o4"},
+		{Relation: "orders.customer_id", Kind: Orphaned, Child: "o6", Parent: "c9", Repaired: RepairUnlink},
+	}, collect(stream))
+	o6, _ := fake.Document("relational", "orders", "o6")
+	assert.Equal(t, map[string]interface{}{"total": 10.0}, o6)
+}
+
+func TestScan_WriteTo(t *testing.T) {
+	client, fake := themistest.NewClient(t)
+	seedShop(t, fake)
+
+	var buf bytes.Buffer
+	stream := Scan(context.Background(), client, Config{Relations: []Relation{{Collection: "order_lines", Field: "order_id", ParentCollection: "orders"}}})
+	_, err := stream.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, `{"relation":"order_lines.order_id","kind":"orphaned","child":"l2","parent":"o9"}`+"\n", buf.String())
+}
+
+func TestScan_Errors(t *testing.T) {
+	client, fake := themistest.NewClient(t)
+	for _, rel := range []Relation{
+		{Field: "customer_id", ParentCollection: "customers"},
+		{Collection: "orders", Field: "customer_id"},
+		{Collection: "orders", Field: "billing..customer", ParentCollection: "customers"},
+		{Collection: "orders", Field: "customer_id", ParentCollection: "customers", Repair: "restore"},
+	} {
+		stream := Scan(context.Background(), client, Config{Relations: []Relation{rel}})
+		assert.Empty(t, collect(stream))
+		assert.ErrorIs(t, stream.Err(), themisdb.ErrInvalidInput, "%+v", rel)
+	}
+
+	seedShop(t, fake)
+	fake.FailNext(500, "disk error")
+	stream := Scan(context.Background(), client, Config{Relations: []Relation{{Collection: "orders", Field: "customer_id", ParentCollection: "customers"}}})
+	assert.Empty(t, collect(stream))
+	err := stream.Err()
+	require.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "failed to check orders.customer_id"), err.Error())
+}
@@ -0,0 +1,25 @@
+package themisdb
+
+import "context"
+
+// Handler sends a request and returns the raw response. Status codes >= 400 are
+// reported through Response.StatusCode, not the error.
+type Handler func(ctx context.Context, req *Request) (*Response, error)
+
+// Interceptor wraps every request sent by the client. It may modify the request,
+// e.g. add headers or sign it, call next zero or more times, and inspect or replace
+// the response. Interceptors run before endpoint selection, so a hedged or replica
+// read passes through them once.
+type Interceptor func(ctx context.Context, req *Request, next Handler) (*Response, error)
+
+// chainInterceptors wraps final in interceptors, the first interceptor being outermost
+func chainInterceptors(interceptors []Interceptor, final Handler) Handler {
+	handler := final
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], handler
+		handler = func(ctx context.Context, req *Request) (*Response, error) {
+			return interceptor(ctx, req, next)
+		}
+	}
+	return handler
+}
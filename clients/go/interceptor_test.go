@@ -0,0 +1,68 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Interceptors(t *testing.T) {
+	var order []string
+	var gotHeader string
+	base := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Signature")
+		w.Write([]byte(`{"name":"alice"}`))
+	})
+
+	trace := func(name string) Interceptor {
+		return func(ctx context.Context, req *Request, next Handler) (*Response, error) {
+			order = append(order, name+">")
+			resp, err := next(ctx, req)
+			order = append(order, "<"+name)
+			return resp, err
+		}
+	}
+	sign := func(ctx context.Context, req *Request, next Handler) (*Response, error) {
+		headers := map[string]string{"X-Signature": "sig:" + req.Method + " " + req.Path}
+		for key, value := range req.Header {
+			headers[key] = value
+		}
+		req.Header = headers
+		return next(ctx, req)
+	}
+
+	client := NewClient(Config{
+		Endpoints:    base.endpoints,
+		Interceptors: []Interceptor{trace("outer"), trace("inner"), sign},
+	})
+
+	var result map[string]string
+	require.NoError(t, client.Get(context.Background(), "relational", "users", "1", &result))
+	assert.Equal(t, "alice", result["name"])
+	assert.Equal(t, []string{"outer>", "inner>", "<inner", "<outer"}, order)
+	assert.Equal(t, "sig:GET /api/relational/users/1", gotHeader)
+}
+
+func TestClient_InterceptorShortCircuit(t *testing.T) {
+	cached := func(ctx context.Context, req *Request, next Handler) (*Response, error) {
+		if req.Method == "GET" {
+			return &Response{StatusCode: http.StatusOK, Body: []byte(`{"name":"cached"}`)}, nil
+		}
+		return next(ctx, req)
+	}
+	client := NewClient(Config{Endpoints: []string{"http://unreachable.invalid"}, Interceptors: []Interceptor{cached}})
+
+	var result map[string]string
+	require.NoError(t, client.Get(context.Background(), "relational", "users", "1", &result))
+	assert.Equal(t, "cached", result["name"])
+
+	// status errors of intercepted responses are still reported by Do
+	notFound := func(ctx context.Context, req *Request, next Handler) (*Response, error) {
+		return &Response{StatusCode: http.StatusNotFound, Body: []byte("missing")}, nil
+	}
+	client = NewClient(Config{Interceptors: []Interceptor{notFound}})
+	assert.Error(t, client.Get(context.Background(), "relational", "users", "1", &result))
+}
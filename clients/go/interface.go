@@ -0,0 +1,152 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// ThemisClient is the method set of Client. Services can depend on it instead of
+// *Client to substitute their own test doubles; package themistest provides an
+// in-memory Client that needs no running server.
+type ThemisClient interface {
+	// Documents
+	Get(ctx context.Context, model, collection, uuid string, result interface{}) error
+	GetWithOptions(ctx context.Context, model, collection, uuid string, result interface{}, opts *ReadOptions) error
+	GetWithMeta(ctx context.Context, model, collection, uuid string, result interface{}) (*GetMeta, error)
+	GetRaw(ctx context.Context, model, collection, uuid string) (json.RawMessage, error)
+	GetMany(ctx context.Context, model, collection string, uuids []string, results interface{}) ([]string, error)
+	GetVersion(ctx context.Context, model, collection, uuid string, version uint64, result interface{}) error
+	ListVersions(ctx context.Context, model, collection, uuid string) ([]DocumentVersion, error)
+	RevertTo(ctx context.Context, model, collection, uuid string, version uint64) error
+	Put(ctx context.Context, model, collection, uuid string, data interface{}) error
+	PutWithVector(ctx context.Context, model, collection, uuid string, data interface{}, vector []float32) error
+	PutWithTTL(ctx context.Context, model, collection, uuid string, data interface{}, ttl time.Duration) error
+	Touch(ctx context.Context, model, collection, uuid string, ttl time.Duration) error
+	Create(ctx context.Context, model, collection, uuid string, data interface{}) error
+	Upsert(ctx context.Context, model, collection, uuid string, data interface{}) (created bool, err error)
+	Patch(ctx context.Context, model, collection, uuid string, patch interface{}) error
+	Increment(ctx context.Context, model, collection, uuid, field string, delta int64) (int64, error)
+	Delete(ctx context.Context, model, collection, uuid string) error
+	GetEntity(ctx context.Context, entity interface{}) error
+	PutEntity(ctx context.Context, entity interface{}) error
+	DeleteEntity(ctx context.Context, entity interface{}) error
+	Scan(ctx context.Context, model, collection string, opts ScanOptions) *Scanner
+	Export(ctx context.Context, model, collection string, w io.Writer) (int64, error)
+	Import(ctx context.Context, model, collection string, r io.Reader, opts ImportOptions) (*ImportReport, error)
+	Writer(opts WriterOptions) *Writer
+	Temporal(model, collection string) *Temporal
+	PutBlob(ctx context.Context, model, collection, uuid, name string, r io.Reader, size int64) (*BlobInfo, error)
+	PutBlobWithOptions(ctx context.Context, model, collection, uuid, name string, r io.Reader, size int64, opts *BlobOptions) (*BlobInfo, error)
+	GetBlob(ctx context.Context, model, collection, uuid, name string) (io.ReadCloser, BlobInfo, error)
+	StatBlob(ctx context.Context, model, collection, uuid, name string) (BlobInfo, error)
+	DeleteBlob(ctx context.Context, model, collection, uuid, name string) error
+
+	// Queries
+	Query(ctx context.Context, aql string, result interface{}) error
+	QueryWithOptions(ctx context.Context, aql string, opts *QueryOptions, result interface{}) error
+	QueryWithProfile(ctx context.Context, aql string, opts *QueryOptions, result interface{}) (*QueryProfile, error)
+	QueryWithMeta(ctx context.Context, aql string, opts *QueryOptions, result interface{}) (*ResultMeta, error)
+	QueryRaw(ctx context.Context, aql string, opts *QueryOptions) (json.RawMessage, error)
+	Prepare(ctx context.Context, name, aql string) error
+	ExecutePrepared(ctx context.Context, name string, params map[string]interface{}, result interface{}) error
+	QueryRange(ctx context.Context, q RangeQuery, result interface{}) error
+	Aggregate(ctx context.Context, collection string, pipeline Pipeline, result interface{}) error
+	QueryJoin(ctx context.Context, join *Join, result interface{}) error
+	MapReduce(ctx context.Context, model, collection string, job MapReduceJob, out func(key string, value json.RawMessage) error) (*MapReduceReport, error)
+	Explain(ctx context.Context, aql string) (*QueryPlan, error)
+	LiveQuery(ctx context.Context, aql string, handler LiveQueryHandler) error
+	LiveQueryWithOptions(ctx context.Context, aql string, opts *LiveQueryOptions, handler LiveQueryHandler) error
+	VectorSearch(ctx context.Context, collection string, q VectorQuery) ([]VectorResult, error)
+	Graph() *Graph
+
+	// Transactions
+	BeginTransaction(ctx context.Context, opts *TransactionOptions) (*Transaction, error)
+	PreparedTransaction(id string) *Transaction
+	DecisionLog() DecisionLog
+	Saga(id string, steps ...SagaStep) *Saga
+	RunTransaction(ctx context.Context, fn func(ctx context.Context, tx *Transaction) error) error
+	Transfer(ctx context.Context, t Transfer) error
+	ClaimUnique(ctx context.Context, scope, value, owner string, fn func(ctx context.Context, tx *Transaction) error) error
+	ReleaseUnique(ctx context.Context, scope, value, owner string) error
+	AppendEvent(ctx context.Context, stream string, expectedVersion int64, event interface{}) (int64, error)
+	ReadEvents(ctx context.Context, stream string, afterVersion int64) ([]StoredEvent, error)
+
+	// Coordination
+	AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (*Lease, error)
+	GetLease(ctx context.Context, name string) (*Lease, error)
+	RenewLease(ctx context.Context, lease *Lease, ttl time.Duration) (*Lease, error)
+	ReleaseLease(ctx context.Context, lease *Lease) error
+	Election(name, candidate string, opts ElectionOptions) *Election
+	Locks(opts LockOptions) *Locks
+	Sequence(name string, opts SequenceOptions) *Sequence
+	Semaphore(name string, limit int, opts SemaphoreOptions) *Semaphore
+	FixedWindowLimiter(name string, limit int, window time.Duration) *FixedWindowLimiter
+	TokenBucketLimiter(name string, rate float64, burst int) *TokenBucketLimiter
+
+	// Messaging and application state
+	Queue(name string, opts QueueOptions) *Queue
+	Consumer(name string) *Consumer
+	SessionStore(opts SessionStoreOptions) *SessionStore
+	KVCache(opts CacheOptions) *KVCache
+	FlagStore(opts FlagStoreOptions) *FlagStore
+	Alerter(opts AlertOptions) *Alerter
+	ReadChanges(ctx context.Context, after uint64, opts ChangesOptions) ([]Change, error)
+	LatestChangeSequence(ctx context.Context) (uint64, error)
+
+	// Schema and data maintenance
+	Models(ctx context.Context) ([]ModelInfo, error)
+	Model(ctx context.Context, name string) (info ModelInfo, ok bool, err error)
+	RegisterEnum(model, collection, field string, values ...string)
+	RegisterSchema(model, collection string, jsonSchema map[string]interface{}) error
+	Migration(name string) *Migration
+	Backfill(ctx context.Context, name, model, collection string, opts BackfillOptions) (*BackfillReport, error)
+	StartBackfill(ctx context.Context, name, model, collection string, opts BackfillOptions) *BackfillJob
+	ResetBackfill(ctx context.Context, name string) error
+
+	// Cluster and connection
+	Admin() *Admin
+	Namespace(ns string) *Client
+	AtSnapshot(snapshot SnapshotRef) *Client
+	ServerInfo(ctx context.Context) (*ServerInfo, error)
+	Stats() []OperationStats
+	FieldStats() []FieldReport
+	ResetFieldStats()
+	Ping(ctx context.Context) error
+	VerifyEndpoints(ctx context.Context) (*EndpointReport, error)
+	Topology() []ClusterMember
+	RefreshTopology(ctx context.Context) error
+	SwitchPrimary(ctx context.Context, nodeID string, opts *SwitchoverOptions) (*SwitchoverReport, error)
+	Plugin(name string) (interface{}, error)
+	Do(ctx context.Context, req *Request) (*Response, error)
+	Close() error
+}
+
+// ThemisTransaction is the method set of Transaction
+type ThemisTransaction interface {
+	IsActive() bool
+	TransactionID() string
+	Endpoint() string
+	Get(ctx context.Context, model, collection, uuid string, result interface{}) error
+	GetMany(ctx context.Context, model, collection string, uuids []string, results interface{}) ([]string, error)
+	Put(ctx context.Context, model, collection, uuid string, data interface{}) error
+	Create(ctx context.Context, model, collection, uuid string, data interface{}) error
+	Upsert(ctx context.Context, model, collection, uuid string, data interface{}) (bool, error)
+	Patch(ctx context.Context, model, collection, uuid string, patch interface{}) error
+	Delete(ctx context.Context, model, collection, uuid string) error
+	GetEntity(ctx context.Context, entity interface{}) error
+	PutEntity(ctx context.Context, entity interface{}) error
+	Query(ctx context.Context, aql string, result interface{}) error
+	QueryWithOptions(ctx context.Context, aql string, opts *QueryOptions, result interface{}) error
+	QueryWithMeta(ctx context.Context, aql string, opts *QueryOptions, result interface{}) (*ResultMeta, error)
+	Prepare(ctx context.Context) error
+	Commit(ctx context.Context) error
+	CommitAsync(ctx context.Context) *CommitHandle
+	Rollback(ctx context.Context) error
+}
+
+var (
+	_ ThemisClient      = (*Client)(nil)
+	_ ThemisTransaction = (*Transaction)(nil)
+)
@@ -0,0 +1,286 @@
+package themisdb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Join is a query over the documents of several collections, matched on fields, as in
+// a relational join. Fields are referenced as "<alias>.<path>", e.g. "c.address.city".
+// It compiles to AQL with all values passed as bind variables; Join.AQL shows the
+// compiled query.
+//
+//	var rows []struct {
+//		Order    string  `json:"order"`
+//		Customer string  `json:"customer"`
+//		Paid     float64 `json:"paid"`
+//	}
+//	err := client.QueryJoin(ctx, themisdb.NewJoin("orders", "o").
+//		Inner("customers", "c", themisdb.On("c._key", "o.customer_id")).
+//		Left("payments", "p", themisdb.On("p.order_id", "o._key")).
+//		Where(themisdb.Eq("o.status", "open")).
+//		Select(themisdb.Col("o._key", "order"), themisdb.Col("c.name", "customer"), themisdb.Col("p.amount", "paid")).
+//		OrderBy(themisdb.Desc("o.total")), &rows)
+type Join struct {
+	from    joinSource
+	joins   []joinSource
+	where   []Condition
+	columns []Column
+	sort    []SortKey
+	// limit is the number of rows kept, nil for all
+	limit *int
+}
+
+// joinSource is a collection of a join under its alias
+type joinSource struct {
+	collection, alias string
+	left              bool
+	on                []JoinOn
+}
+
+// JoinOn requires two fields to be equal, built with On
+type JoinOn struct {
+	Left, Right string
+}
+
+// On matches the documents whose field left equals field right
+func On(left, right string) JoinOn { return JoinOn{Left: left, Right: right} }
+
+// Column is a projected field of a join, built with Col
+type Column struct {
+	// Field is the "<alias>.<path>" of the source field
+	Field string
+	// As is the output field, by default the last element of the path
+	As string
+}
+
+// Col projects field into the output field as, or into the last element of its path
+// if as is empty
+func Col(field, as string) Column { return Column{Field: field, As: as} }
+
+// NewJoin starts a join over the documents of collection, referenced as alias
+func NewJoin(collection, alias string) *Join {
+	return &Join{from: joinSource{collection: collection, alias: alias}}
+}
+
+// Inner joins the documents of collection that satisfy on; rows without a match are
+// dropped
+func (j *Join) Inner(collection, alias string, on ...JoinOn) *Join {
+	j.joins = append(j.joins, joinSource{collection: collection, alias: alias, on: on})
+	return j
+}
+
+// Left joins the documents of collection that satisfy on; rows without a match are
+// kept, with the fields of alias null
+func (j *Join) Left(collection, alias string, on ...JoinOn) *Join {
+	j.joins = append(j.joins, joinSource{collection: collection, alias: alias, left: true, on: on})
+	return j
+}
+
+// Where keeps the rows that satisfy all conditions, whose fields are "<alias>.<path>"
+func (j *Join) Where(conditions ...Condition) *Join {
+	j.where = append(j.where, conditions...)
+	return j
+}
+
+// Select sets the fields of the rows. Without columns every row is an object holding
+// the joined documents under their aliases.
+func (j *Join) Select(columns ...Column) *Join {
+	j.columns = append(j.columns, columns...)
+	return j
+}
+
+// OrderBy orders the rows by keys, whose fields are "<alias>.<path>"
+func (j *Join) OrderBy(keys ...SortKey) *Join {
+	j.sort = append(j.sort, keys...)
+	return j
+}
+
+// Limit keeps the first n rows
+func (j *Join) Limit(n int) *Join {
+	j.limit = &n
+	return j
+}
+
+// QueryJoin runs join and decodes the resulting rows into result, typically a pointer
+// to a slice of structs whose fields are tagged with the output fields of the columns
+func (c *Client) QueryJoin(ctx context.Context, join *Join, result interface{}) error {
+	aql, bindVars, err := join.AQL()
+	if err != nil {
+		return err
+	}
+	if err := c.QueryWithOptions(ctx, aql, &QueryOptions{BindVars: bindVars}, result); err != nil {
+		return fmt.Errorf("failed to join %s: %w", join.from.collection, err)
+	}
+	return nil
+}
+
+// AQL compiles the join to an AQL query and its bind variables
+func (j *Join) AQL() (string, map[string]interface{}, error) {
+	jc := &joinCompiler{pipelineCompiler: pipelineCompiler{bindVars: map[string]interface{}{}}, aliases: map[string]bool{}}
+	if err := jc.declare(j.from); err != nil {
+		return "", nil, err
+	}
+	jc.lines = append(jc.lines, fmt.Sprintf("FOR %s IN %s", j.from.alias, quoteName(j.from.collection)))
+	for _, src := range j.joins {
+		if err := jc.join(src); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if len(j.where) > 0 {
+		exprs := make([]string, len(j.where))
+		for i, cond := range j.where {
+			switch cond.Op {
+			case "==", "!=", "<", "<=", ">", ">=", "IN":
+			default:
+				return "", nil, &ValidationError{Field: "where operator", Value: cond.Op, Reason: "unsupported"}
+			}
+			path, err := jc.field(cond.Field)
+			if err != nil {
+				return "", nil, err
+			}
+			exprs[i] = fmt.Sprintf("%s %s %s", path, cond.Op, jc.bind(cond.Value))
+		}
+		jc.lines = append(jc.lines, "FILTER "+strings.Join(exprs, " AND "))
+	}
+	if len(j.sort) > 0 {
+		exprs := make([]string, len(j.sort))
+		for i, key := range j.sort {
+			path, err := jc.field(key.Field)
+			if err != nil {
+				return "", nil, err
+			}
+			exprs[i] = path + " ASC"
+			if key.Descending {
+				exprs[i] = path + " DESC"
+			}
+		}
+		jc.lines = append(jc.lines, "SORT "+strings.Join(exprs, ", "))
+	}
+	if j.limit != nil {
+		if *j.limit < 0 {
+			return "", nil, &ValidationError{Field: "limit", Value: fmt.Sprint(*j.limit), Reason: "must not be negative"}
+		}
+		jc.lines = append(jc.lines, fmt.Sprintf("LIMIT %d", *j.limit))
+	}
+
+	row, err := jc.row(j.columns)
+	if err != nil {
+		return "", nil, err
+	}
+	jc.lines = append(jc.lines, "RETURN "+row)
+	return strings.Join(jc.lines, "\n"), jc.bindVars, nil
+}
+
+// joinCompiler accumulates the AQL of a join; aliases are the aliases declared so far
+type joinCompiler struct {
+	pipelineCompiler
+	aliases map[string]bool
+	order   []string
+}
+
+// declare checks the collection and alias of src and makes the alias referable
+func (jc *joinCompiler) declare(src joinSource) error {
+	if err := validateName("collection", src.collection); err != nil {
+		return err
+	}
+	if !isAlias(src.alias) {
+		return &ValidationError{Field: "alias", Value: src.alias, Reason: "must be a letter followed by letters, digits, and underscores, and not a keyword"}
+	}
+	if jc.aliases[src.alias] {
+		return &ValidationError{Field: "alias", Value: src.alias, Reason: "is used twice"}
+	}
+	jc.aliases[src.alias] = true
+	jc.order = append(jc.order, src.alias)
+	return nil
+}
+
+// join adds the loop over the documents of src matching its conditions
+func (jc *joinCompiler) join(src joinSource) error {
+	if len(src.on) == 0 {
+		return &ValidationError{Field: "join", Value: src.alias, Reason: "needs at least one On condition"}
+	}
+	if err := jc.declare(src); err != nil {
+		return err
+	}
+	exprs := make([]string, len(src.on))
+	for i, on := range src.on {
+		left, err := jc.field(on.Left)
+		if err != nil {
+			return err
+		}
+		right, err := jc.field(on.Right)
+		if err != nil {
+			return err
+		}
+		exprs[i] = left + " == " + right
+	}
+	filter := "FILTER " + strings.Join(exprs, " AND ")
+	if !src.left {
+		jc.lines = append(jc.lines, fmt.Sprintf("FOR %s IN %s", src.alias, quoteName(src.collection)), filter)
+		return nil
+	}
+	// a left join iterates over the matches, or over a single null if there are none
+	matches := jc.newVar("_m")
+	jc.lines = append(jc.lines,
+		fmt.Sprintf("LET %s = (FOR %s IN %s %s RETURN %s)", matches, src.alias, quoteName(src.collection), filter, src.alias),
+		fmt.Sprintf("FOR %s IN (LENGTH(%s) > 0 ? %s : [null])", src.alias, matches, matches))
+	return nil
+}
+
+// field returns the AQL attribute path of a field "<alias>.<path>" of a declared alias
+func (jc *joinCompiler) field(field string) (string, error) {
+	alias, path, ok := strings.Cut(field, ".")
+	if !ok || !jc.aliases[alias] {
+		return "", &ValidationError{Field: "field", Value: field, Reason: "must start with the alias of a collection joined before"}
+	}
+	return fieldPath(alias, path)
+}
+
+// row returns the object expression of a result row
+func (jc *joinCompiler) row(columns []Column) (string, error) {
+	var fields []string
+	if len(columns) == 0 {
+		for _, alias := range jc.order {
+			fields = append(fields, fmt.Sprintf("%s: %s", quoteName(alias), alias))
+		}
+		return "{" + strings.Join(fields, ", ") + "}", nil
+	}
+	seen := map[string]bool{}
+	for _, col := range columns {
+		path, err := jc.field(col.Field)
+		if err != nil {
+			return "", err
+		}
+		as := col.As
+		if as == "" {
+			as = col.Field[strings.LastIndexByte(col.Field, '.')+1:]
+		}
+		name, err := objectKey(as)
+		if err != nil {
+			return "", err
+		}
+		if seen[as] {
+			return "", &ValidationError{Field: "column", Value: as, Reason: "is selected twice"}
+		}
+		seen[as] = true
+		fields = append(fields, fmt.Sprintf("%s: %s", name, path))
+	}
+	return "{" + strings.Join(fields, ", ") + "}", nil
+}
+
+// isAlias reports whether alias can be used as an AQL variable. Aliases start with a
+// letter, so they never clash with the variables of the compiler.
+func isAlias(alias string) bool {
+	if alias == "" || alias[0]|0x20 < 'a' || alias[0]|0x20 > 'z' || aqlKeywords[strings.ToUpper(alias)] {
+		return false
+	}
+	for i := 0; i < len(alias); i++ {
+		if alias[i] == '$' || !isIdentChar(alias[i]) {
+			return false
+		}
+	}
+	return true
+}
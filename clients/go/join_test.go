@@ -0,0 +1,91 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoin_AQL(t *testing.T) {
+	aql, bindVars, err := NewJoin("orders", "o").
+		Inner("customers", "c", On("c._key", "o.customer_id")).
+		Left("payments", "p", On("p.order_id", "o._key"), On("p.currency", "c.address.currency")).
+		Where(Eq("o.status", "open"), Gte("o.total", 10)).
+		Select(Col("o._key", "order"), Col("c.name", "customer"), Col("p.amount", "")).
+		OrderBy(Desc("o.total"), Asc("c.name")).
+		Limit(20).
+		AQL()
+	require.NoError(t, err)
+
+	assert.Equal(t, "FOR o IN `orders`\n"+
+		"FOR c IN `customers`\n"+
+		"FILTER c.`_key` == o.`customer_id`\n"+
+		"LET _m1 = (FOR p IN `payments` FILTER p.`order_id` == o.`_key` AND p.`currency` == c.`address`.`currency` RETURN p)\n"+
+		"FOR p IN (LENGTH(_m1) > 0 ? _m1 : [null])\n"+
+		"FILTER o.`status` == @p0 AND o.`total` >= @p1\n"+
+		"SORT o.`total` DESC, c.`name` ASC\n"+
+		"LIMIT 20\n"+
+		"RETURN {`order`: o.`_key`, `customer`: c.`name`, `amount`: p.`amount`}", aql)
+	assert.Equal(t, map[string]interface{}{"p0": "open", "p1": 10}, bindVars)
+}
+
+func TestJoin_AQL_AllColumns(t *testing.T) {
+	aql, bindVars, err := NewJoin("orders", "o").Inner("customers", "c", On("c._key", "o.customer_id")).AQL()
+	require.NoError(t, err)
+	assert.Equal(t, "FOR o IN `orders`\nFOR c IN `customers`\nFILTER c.`_key` == o.`customer_id`\nRETURN {`o`: o, `c`: c}", aql)
+	assert.Empty(t, bindVars)
+}
+
+func TestJoin_AQL_Invalid(t *testing.T) {
+	for name, join := range map[string]*Join{
+		"collection":      NewJoin("bad name", "o"),
+		"alias keyword":   NewJoin("orders", "for"),
+		"alias digit":     NewJoin("orders", "1o"),
+		"alias generated": NewJoin("orders", "_m1"),
+		"alias twice":     NewJoin("orders", "o").Inner("customers", "o", On("o.a", "o.b")),
+		"no condition":    NewJoin("orders", "o").Inner("customers", "c"),
+		"later alias":     NewJoin("orders", "o").Inner("customers", "c", On("c._key", "p.customer_id")).Inner("payments", "p", On("p.order_id", "o._key")),
+		"unknown alias":   NewJoin("orders", "o").Where(Eq("x.status", "open")),
+		"no alias":        NewJoin("orders", "o").OrderBy(Asc("total")),
+		"operator":        NewJoin("orders", "o").Where(Condition{Field: "o.a", Op: "LIKE", Value: "x"}),
+		"field injection": NewJoin("orders", "o").Select(Col("o.a` == 1 OR `b", "a")),
+		"column twice":    NewJoin("orders", "o").Select(Col("o.name", ""), Col("o.address.name", "")),
+		"negative limit":  NewJoin("orders", "o").Limit(-1),
+	} {
+		_, _, err := join.AQL()
+		assert.ErrorIs(t, err, ErrInvalidInput, name)
+	}
+}
+
+func TestClient_QueryJoin(t *testing.T) {
+	var body map[string]interface{}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"data": [{"order": "o1", "customer": "Ada", "paid": 12.5}, {"order": "o2", "customer": "Grace", "paid": null}]}`))
+	})
+
+	var rows []struct {
+		Order    string   `json:"order"`
+		Customer string   `json:"customer"`
+		Paid     *float64 `json:"paid"`
+	}
+	err := client.QueryJoin(context.Background(), NewJoin("orders", "o").
+		Inner("customers", "c", On("c._key", "o.customer_id")).
+		Left("payments", "p", On("p.order_id", "o._key")).
+		Where(Eq("c.country", "DE")).
+		Select(Col("o._key", "order"), Col("c.name", "customer"), Col("p.amount", "paid")), &rows)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "Ada", rows[0].Customer)
+	assert.Equal(t, 12.5, *rows[0].Paid)
+	assert.Nil(t, rows[1].Paid)
+	assert.Contains(t, body["query"], "LENGTH(_m1) > 0")
+	assert.Equal(t, map[string]interface{}{"p0": "DE"}, body["bind_vars"])
+
+	err = client.QueryJoin(context.Background(), NewJoin("orders", "o").Limit(-1), &rows)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
@@ -0,0 +1,39 @@
+package themisdb
+
+import (
+	"net/url"
+	"strings"
+)
+
+// EncodeKey escapes a model, collection, or UUID so it forms exactly one URL path segment.
+// Slashes, unicode, and reserved characters are percent-encoded, so Get("a/b") addresses
+// the key "a/b" instead of a nested path.
+func EncodeKey(key string) string {
+	escaped := url.PathEscape(key)
+	// "." and ".." would be collapsed by path normalization on proxies and servers
+	if escaped == "." || escaped == ".." {
+		escaped = strings.ReplaceAll(escaped, ".", "%2E")
+	}
+	return escaped
+}
+
+// DecodeKey reverses EncodeKey, e.g. for keys returned percent-encoded by the server
+func DecodeKey(encoded string) (string, error) {
+	return url.PathUnescape(encoded)
+}
+
+// joinPath builds an API path from a prefix and encoded key segments
+func joinPath(prefix string, keys ...string) string {
+	var b strings.Builder
+	b.WriteString(prefix)
+	for _, key := range keys {
+		b.WriteByte('/')
+		b.WriteString(EncodeKey(key))
+	}
+	return b.String()
+}
+
+// entityPath returns the API path of an entity
+func entityPath(model, collection, uuid string) string {
+	return joinPath("/api", model, collection, uuid)
+}
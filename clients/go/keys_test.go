@@ -0,0 +1,53 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeKey(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"user-123", "user-123"},
+		{"a/b", "a%2Fb"},
+		{"straße", "stra%C3%9Fe"},
+		{"what?#%", "what%3F%23%25"},
+		{"with space", "with%20space"},
+		{".", "%2E"},
+		{"..", "%2E%2E"},
+		{"a.b", "a.b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			encoded := EncodeKey(tt.key)
+			assert.Equal(t, tt.expected, encoded)
+
+			decoded, err := DecodeKey(encoded)
+			require.NoError(t, err)
+			assert.Equal(t, tt.key, decoded)
+		})
+	}
+}
+
+func TestDecodeKey_Invalid(t *testing.T) {
+	_, err := DecodeKey("%zz")
+	assert.Error(t, err)
+}
+
+func TestClient_GetEncodesKeys(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/relational/users/a%2Fb%3F", r.URL.EscapedPath())
+		w.Write([]byte(`{"name":"Alice"}`))
+	})
+
+	var result map[string]interface{}
+	require.NoError(t, client.Get(context.Background(), "relational", "users", "a/b?", &result))
+	assert.Equal(t, "Alice", result["name"])
+}
@@ -0,0 +1,102 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Lease is a named, time-bounded lock held by a single holder
+type Lease struct {
+	// Name identifies the leased resource
+	Name string `json:"name"`
+	// Holder identifies the current owner
+	Holder string `json:"holder"`
+	// Token is a fencing token that increases every time the lease changes hands.
+	// Pass it along with writes guarded by the lease so stale holders can be rejected.
+	Token int64 `json:"token"`
+	// ExpiresAt is when the lease lapses unless renewed
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// AcquireLease takes the lease name for holder for the duration ttl.
+// It fails with ErrLeaseHeld if another holder owns an unexpired lease.
+func (c *Client) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (*Lease, error) {
+	body := map[string]interface{}{
+		"name":   name,
+		"holder": holder,
+		"ttl_ms": ttl.Milliseconds(),
+	}
+	return c.leaseRequest(ctx, "/lease/acquire", name, holder, body, ErrLeaseHeld)
+}
+
+// RenewLease extends a held lease by ttl. It fails with ErrLeaseLost if the lease
+// expired and was taken by another holder in the meantime.
+func (c *Client) RenewLease(ctx context.Context, lease *Lease, ttl time.Duration) (*Lease, error) {
+	body := map[string]interface{}{
+		"name":   lease.Name,
+		"holder": lease.Holder,
+		"token":  lease.Token,
+		"ttl_ms": ttl.Milliseconds(),
+	}
+	return c.leaseRequest(ctx, "/lease/renew", lease.Name, lease.Holder, body, ErrLeaseLost)
+}
+
+// ReleaseLease gives up a held lease so other holders can acquire it immediately
+func (c *Client) ReleaseLease(ctx context.Context, lease *Lease) error {
+	body := map[string]interface{}{
+		"name":   lease.Name,
+		"holder": lease.Holder,
+		"token":  lease.Token,
+	}
+	_, err := c.leaseRequest(ctx, "/lease/release", lease.Name, lease.Holder, body, ErrLeaseLost)
+	return err
+}
+
+// GetLease returns the current lease of name, or nil if it is not held
+func (c *Client) GetLease(ctx context.Context, name string) (*Lease, error) {
+	if err := validateKey("name", name); err != nil {
+		return nil, err
+	}
+
+	var lease Lease
+	resp, err := c.send(ctx, "GET", joinPath("/lease", name), nil, nil)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lease %s: %w", name, err)
+	}
+	if err := json.Unmarshal(resp.Body, &lease); err != nil {
+		return nil, fmt.Errorf("failed to decode lease %s: %w", name, err)
+	}
+	return &lease, nil
+}
+
+// leaseRequest performs a lease operation, mapping 409 Conflict to conflictErr
+func (c *Client) leaseRequest(ctx context.Context, path, name, holder string, body map[string]interface{}, conflictErr error) (*Lease, error) {
+	if err := validateKey("name", name); err != nil {
+		return nil, err
+	}
+	if err := validateKey("holder", holder); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.send(ctx, "POST", path, body, nil)
+	if resp != nil && resp.StatusCode == http.StatusConflict {
+		return nil, fmt.Errorf("%w: %s", conflictErr, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lease %s: %w", name, err)
+	}
+
+	var lease Lease
+	if len(resp.Body) > 0 {
+		if err := json.Unmarshal(resp.Body, &lease); err != nil {
+			return nil, fmt.Errorf("failed to decode lease %s: %w", name, err)
+		}
+	}
+	return &lease, nil
+}
@@ -0,0 +1,343 @@
+package themisdb
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LiveRowOp is the kind of change of a live query row
+type LiveRowOp string
+
+// Live query row changes
+const (
+	LiveRowAdded   LiveRowOp = "add"
+	LiveRowUpdated LiveRowOp = "update"
+	LiveRowRemoved LiveRowOp = "remove"
+)
+
+// LiveRow is a row of a live query result set, identified by the key of its source document
+type LiveRow struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// LiveRowChange is an incremental change of a live query result set. Value is empty for removals.
+type LiveRowChange struct {
+	Op LiveRowOp `json:"op"`
+	LiveRow
+}
+
+// LiveQueryUpdate is delivered to a LiveQueryHandler for every server event
+type LiveQueryUpdate struct {
+	// Version is the result set version after the update
+	Version uint64
+	// Reset reports that Rows replaces the complete result set. It is set on the first
+	// update and after a reconnect the server could not resume from the last version.
+	Reset bool
+	// Rows is the complete result set of a reset
+	Rows []LiveRow
+	// Changes are the incremental changes since the previous update
+	Changes []LiveRowChange
+}
+
+// LiveQueryHandler processes live query updates in order. Returning an error ends the live query.
+type LiveQueryHandler func(update *LiveQueryUpdate) error
+
+// LiveQueryOptions configures a live query
+type LiveQueryOptions struct {
+	// BindVars are the bind parameters of the query
+	BindVars map[string]interface{}
+	// MaxReconnectDelay caps the backoff between reconnect attempts (default: 10s)
+	MaxReconnectDelay time.Duration
+	// OnError is called with connection errors that trigger a reconnect
+	OnError func(error)
+}
+
+// errLiveQueryExpired reports that the server no longer knows a subscription
+var errLiveQueryExpired = errors.New("live query subscription expired")
+
+// liveQuery is a live query subscription
+type liveQuery struct {
+	client  *Client
+	aql     string
+	opts    LiveQueryOptions
+	id      string
+	version uint64
+	retry   time.Duration
+}
+
+// LiveQuery registers a continuous AQL query with the server and calls handler with the
+// result set and its incremental changes, streamed as server-sent events. Dropped
+// connections are resumed from the last received version, and the query is registered
+// again if the server has discarded it. LiveQuery blocks until ctx is done or handler
+// returns an error, and requires the HTTP transport.
+//
+//	err := client.LiveQuery(ctx, "FOR o IN orders FILTER o.status == 'open' RETURN o",
+//	    func(u *themisdb.LiveQueryUpdate) error {
+//	        if u.Reset {
+//	            board.Replace(u.Rows)
+//	        }
+//	        board.Apply(u.Changes)
+//	        return nil
+//	    })
+func (c *Client) LiveQuery(ctx context.Context, aql string, handler LiveQueryHandler) error {
+	return c.LiveQueryWithOptions(ctx, aql, nil, handler)
+}
+
+// LiveQueryWithOptions is LiveQuery with bind parameters and reconnect options
+func (c *Client) LiveQueryWithOptions(ctx context.Context, aql string, opts *LiveQueryOptions, handler LiveQueryHandler) error {
+	if _, ok := c.transport.(*httpTransport); !ok {
+		return fmt.Errorf("%w: live queries require the HTTP transport", ErrUnsupportedByTransport)
+	}
+
+	q := &liveQuery{client: c, aql: aql}
+	if opts != nil {
+		q.opts = *opts
+	}
+	if q.opts.MaxReconnectDelay <= 0 {
+		q.opts.MaxReconnectDelay = 10 * time.Second
+	}
+	defer q.unsubscribe()
+
+	backoff := 100 * time.Millisecond
+	for {
+		if q.id == "" {
+			if err := q.subscribe(ctx); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				if !liveRetryable(err) {
+					return err
+				}
+				if err := q.wait(ctx, err, &backoff); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		connected, err := q.consume(ctx, handler)
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.Is(err, errLiveQueryExpired):
+			q.id, q.version = "", 0
+			continue
+		case err != nil && !liveRetryable(err):
+			var fatal *liveFatalError
+			if errors.As(err, &fatal) {
+				return fatal.err
+			}
+			return err
+		}
+		if connected {
+			backoff = 100 * time.Millisecond
+		}
+		if err == nil {
+			// The server ended the stream, resume right away
+			continue
+		}
+		if err := q.wait(ctx, err, &backoff); err != nil {
+			return err
+		}
+	}
+}
+
+// liveRetryable reports whether a live query error is transient
+func liveRetryable(err error) bool {
+	if errors.Is(err, ErrInvalidInput) {
+		return false
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	var fatal *liveFatalError
+	return !errors.As(err, &fatal)
+}
+
+// wait reports err and sleeps before the next reconnect attempt
+func (q *liveQuery) wait(ctx context.Context, err error, backoff *time.Duration) error {
+	if q.opts.OnError != nil {
+		q.opts.OnError(err)
+	}
+	delay := *backoff
+	if q.retry > delay {
+		delay = q.retry
+	}
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if *backoff < q.opts.MaxReconnectDelay {
+		*backoff *= 2
+		if *backoff > q.opts.MaxReconnectDelay {
+			*backoff = q.opts.MaxReconnectDelay
+		}
+	}
+	return nil
+}
+
+// subscribe registers the query with the server
+func (q *liveQuery) subscribe(ctx context.Context) error {
+	body := map[string]interface{}{"query": q.aql}
+	if len(q.opts.BindVars) > 0 {
+		body["bind_vars"] = q.opts.BindVars
+	}
+	var result struct {
+		SubscriptionID string `json:"subscription_id"`
+	}
+	if err := q.client.request(ctx, "POST", "/api/query/live", body, &result, nil); err != nil {
+		return fmt.Errorf("failed to register live query: %w", err)
+	}
+	q.id = result.SubscriptionID
+	return nil
+}
+
+// unsubscribe removes the subscription from the server, best effort
+func (q *liveQuery) unsubscribe() {
+	if q.id == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	q.client.request(ctx, "DELETE", joinPath("/api/query/live", q.id), nil, nil, nil)
+}
+
+// liveFatalError wraps handler errors and server-reported query failures, which end the live query
+type liveFatalError struct {
+	err error
+}
+
+func (e *liveFatalError) Error() string { return e.err.Error() }
+func (e *liveFatalError) Unwrap() error { return e.err }
+
+// consume streams events of the subscription to handler until the stream ends.
+// connected reports whether the stream was established.
+func (q *liveQuery) consume(ctx context.Context, handler LiveQueryHandler) (connected bool, err error) {
+	headers := map[string]string{"Accept": "text/event-stream"}
+	if q.version > 0 {
+		headers["Last-Event-ID"] = strconv.FormatUint(q.version, 10)
+	}
+	resp, err := q.client.stream(ctx, "GET", joinPath("/api/query/live", q.id), nil, headers)
+	if errors.Is(err, ErrNotFound) {
+		return false, errLiveQueryExpired
+	}
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	err = readEvents(resp.Body, func(event, id, data string) error {
+		return q.dispatch(event, id, data, handler)
+	})
+	if err == io.EOF {
+		return true, nil
+	}
+	return true, err
+}
+
+// dispatch decodes a server-sent event and passes it to handler
+func (q *liveQuery) dispatch(event, id, data string, handler LiveQueryHandler) error {
+	update := &LiveQueryUpdate{}
+	switch event {
+	case "retry":
+		if ms, err := strconv.Atoi(data); err == nil {
+			q.retry = time.Duration(ms) * time.Millisecond
+		}
+		return nil
+	case "snapshot":
+		var payload struct {
+			Rows []LiveRow `json:"rows"`
+		}
+		if err := json.Unmarshal([]byte(data), &payload); err != nil {
+			return fmt.Errorf("failed to decode live query snapshot: %w", err)
+		}
+		update.Reset, update.Rows = true, payload.Rows
+	case "change":
+		var payload struct {
+			Changes []LiveRowChange `json:"changes"`
+		}
+		if err := json.Unmarshal([]byte(data), &payload); err != nil {
+			return fmt.Errorf("failed to decode live query change: %w", err)
+		}
+		update.Changes = payload.Changes
+	case "error":
+		var payload struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal([]byte(data), &payload)
+		return &liveFatalError{err: fmt.Errorf("live query failed: %s", payload.Message)}
+	default:
+		return nil
+	}
+
+	if id != "" {
+		version, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid live query version %q", id)
+		}
+		update.Version = version
+	}
+	if err := handler(update); err != nil {
+		return &liveFatalError{err: err}
+	}
+	q.version = update.Version
+	return nil
+}
+
+// readEvents parses a text/event-stream and calls fn for every event. A "retry" field
+// is reported as an event named "retry" with the delay in data. It returns io.EOF
+// when the stream ends.
+func readEvents(r io.Reader, fn func(event, id, data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var event, id string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if len(data) > 0 {
+				if event == "" {
+					event = "message"
+				}
+				if err := fn(event, id, strings.Join(data, "\n")); err != nil {
+					return err
+				}
+			}
+			event, data = "", nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		case "id":
+			id = value
+		case "retry":
+			if err := fn("retry", "", value); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read event stream: %w", err)
+	}
+	return io.EOF
+}
@@ -0,0 +1,137 @@
+package themisdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// liveQueryServer registers live queries and replays scripted event streams, one per connection
+type liveQueryServer struct {
+	mu            sync.Mutex
+	subscriptions int
+	unsubscribed  []string
+	lastEventIDs  []string
+	accepts       []string
+	streams       []func(w http.ResponseWriter)
+}
+
+func (s *liveQueryServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/api/query/live":
+		s.subscriptions++
+		fmt.Fprintf(w, `{"subscription_id":"sub-%d"}`, s.subscriptions)
+		s.mu.Unlock()
+	case r.Method == "DELETE":
+		s.unsubscribed = append(s.unsubscribed, strings.TrimPrefix(r.URL.Path, "/api/query/live/"))
+		s.mu.Unlock()
+	case r.Method == "GET":
+		s.accepts = append(s.accepts, r.Header.Get("Accept"))
+		s.lastEventIDs = append(s.lastEventIDs, r.Header.Get("Last-Event-ID"))
+		if len(s.streams) == 0 {
+			s.mu.Unlock()
+			<-r.Context().Done()
+			return
+		}
+		stream := s.streams[0]
+		s.streams = s.streams[1:]
+		s.mu.Unlock()
+		stream(w)
+	default:
+		s.mu.Unlock()
+		http.NotFound(w, r)
+	}
+}
+
+func sse(events ...string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, e := range events {
+			fmt.Fprint(w, e+"\n\n")
+		}
+		w.(http.Flusher).Flush()
+	}
+}
+
+func TestClient_LiveQuery(t *testing.T) {
+	server := &liveQueryServer{streams: []func(w http.ResponseWriter){
+		sse(
+			": heartbeat",
+			"retry: 10",
+			"event: snapshot\nid: 1\ndata: {\"rows\":[{\"key\":\"orders:1\",\"value\":{\"total\":5}}]}",
+			"event: change\nid: 2\ndata: {\"changes\":[{\"op\":\"add\",\"key\":\"orders:2\",\n"+
+				"data: \"value\":{\"total\":7}}]}",
+		),
+		func(w http.ResponseWriter) { http.Error(w, "restarting", http.StatusServiceUnavailable) },
+		sse("event: change\nid: 3\ndata: {\"changes\":[{\"op\":\"remove\",\"key\":\"orders:1\"}]}"),
+		func(w http.ResponseWriter) { http.NotFound(w, nil) },
+		sse("event: snapshot\nid: 1\ndata: {\"rows\":[]}"),
+	}}
+	client := newTestClient(t, server.serveHTTP)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var updates []*LiveQueryUpdate
+	var connErrs []error
+	err := client.LiveQueryWithOptions(ctx, "FOR o IN orders FILTER o.total > @min RETURN o",
+		&LiveQueryOptions{
+			BindVars: map[string]interface{}{"min": 1},
+			OnError:  func(err error) { connErrs = append(connErrs, err) },
+		},
+		func(u *LiveQueryUpdate) error {
+			updates = append(updates, u)
+			if len(updates) == 4 {
+				cancel()
+			}
+			return nil
+		})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	require.Len(t, updates, 4)
+	assert.Equal(t, &LiveQueryUpdate{Version: 1, Reset: true, Rows: []LiveRow{{Key: "orders:1", Value: []byte(`{"total":5}`)}}}, updates[0])
+	assert.Equal(t, []LiveRowChange{{Op: LiveRowAdded, LiveRow: LiveRow{Key: "orders:2", Value: []byte(`{"total":7}`)}}}, updates[1].Changes)
+	assert.Equal(t, &LiveQueryUpdate{Version: 3, Changes: []LiveRowChange{{Op: LiveRowRemoved, LiveRow: LiveRow{Key: "orders:1"}}}}, updates[2])
+	assert.True(t, updates[3].Reset, "a resubscription starts with a snapshot")
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, []string{"", "2", "2", "3", ""}, server.lastEventIDs)
+	assert.Equal(t, "text/event-stream", server.accepts[0])
+	assert.Equal(t, 2, server.subscriptions)
+	assert.Equal(t, []string{"sub-2"}, server.unsubscribed)
+	require.Len(t, connErrs, 1)
+	assert.ErrorIs(t, connErrs[0], ErrUnavailable)
+}
+
+func TestClient_LiveQueryErrors(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "syntax error near RETURN", http.StatusBadRequest)
+	})
+	err := client.LiveQuery(context.Background(), "FOR o IN", func(*LiveQueryUpdate) error { return nil })
+	assert.ErrorContains(t, err, "syntax error near RETURN")
+
+	server := &liveQueryServer{streams: []func(w http.ResponseWriter){
+		sse("event: snapshot\nid: 1\ndata: {\"rows\":[]}"),
+		sse("event: error\ndata: {\"message\":\"collection dropped\"}"),
+	}}
+	client = newTestClient(t, server.serveHTTP)
+	errStop := errors.New("stop")
+	err = client.LiveQuery(context.Background(), "FOR o IN orders RETURN o", func(*LiveQueryUpdate) error { return errStop })
+	assert.Equal(t, errStop, err)
+	err = client.LiveQuery(context.Background(), "FOR o IN orders RETURN o", func(*LiveQueryUpdate) error { return nil })
+	assert.EqualError(t, err, "live query failed: collection dropped")
+
+	grpc := NewClient(Config{Protocol: ProtocolGRPC, Endpoints: []string{"localhost:1"}, Timeout: time.Second})
+	defer grpc.Close()
+	err = grpc.LiveQuery(context.Background(), "FOR o IN orders RETURN o", func(*LiveQueryUpdate) error { return nil })
+	assert.ErrorIs(t, err, ErrUnsupportedByTransport)
+}
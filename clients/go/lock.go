@@ -0,0 +1,190 @@
+package themisdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LockOptions holds distributed lock configuration
+type LockOptions struct {
+	// TTL is how long WithLock holds a lock without renewal; it is renewed every TTL/3
+	// while the function runs (default: 30s)
+	TTL time.Duration
+	// RetryInterval is how often Acquire retries while the lock is held elsewhere (default: 100ms)
+	RetryInterval time.Duration
+}
+
+// Locks hands out mutually exclusive, time-bounded locks on application-defined keys,
+// e.g. to run a singleton job on one of several instances. Locks are leases named
+// "lock/<key>", so each acquisition carries a fencing token.
+type Locks struct {
+	client *Client
+	opts   LockOptions
+}
+
+// Lock is a held lock. Every acquisition has its own holder, so a lock excludes
+// other goroutines of the same process as well as other processes.
+type Lock struct {
+	client *Client
+	key    string
+
+	mu    sync.Mutex
+	lease *Lease
+}
+
+// Locks returns the distributed locks of the server
+func (c *Client) Locks(opts LockOptions) *Locks {
+	if opts.TTL <= 0 {
+		opts.TTL = 30 * time.Second
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = 100 * time.Millisecond
+	}
+	return &Locks{client: c, opts: opts}
+}
+
+// TryAcquire takes the lock key for ttl if it is free. It fails with ErrLeaseHeld if
+// the lock is held elsewhere.
+func (ls *Locks) TryAcquire(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	if err := validateKey("key", key); err != nil {
+		return nil, err
+	}
+	lease, err := ls.client.AcquireLease(ctx, "lock/"+key, randomToken(16), ttl)
+	if err != nil {
+		return nil, err
+	}
+	return &Lock{client: ls.client, key: key, lease: lease}, nil
+}
+
+// Acquire blocks until it takes the lock key for ttl or ctx is done. The lock must be
+// renewed with Renew before ttl elapses and given up with Release.
+func (ls *Locks) Acquire(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	for {
+		lock, err := ls.TryAcquire(ctx, key, ttl)
+		if !errors.Is(err, ErrLeaseHeld) {
+			return lock, err
+		}
+
+		select {
+		case <-time.After(ls.opts.RetryInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// WithLock runs fn while holding the lock key, renewing it in the background and
+// releasing it when fn returns. If the lock is lost while fn runs, the context passed
+// to fn is canceled and WithLock returns an error matching ErrLeaseLost.
+//
+//	err := locks.WithLock(ctx, "nightly-report", func(ctx context.Context, lock *themisdb.Lock) error {
+//		return report.Generate(ctx, lock.Token())
+//	})
+func (ls *Locks) WithLock(ctx context.Context, key string, fn func(ctx context.Context, lock *Lock) error) error {
+	lock, err := ls.Acquire(ctx, key, ls.opts.TTL)
+	if err != nil {
+		return fmt.Errorf("failed to lock %s: %w", key, err)
+	}
+
+	fnCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var lost atomic.Bool
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if !ls.keepAlive(lock, stop) {
+			lost.Store(true)
+			cancel()
+		}
+	}()
+
+	fnErr := fn(fnCtx, lock)
+	close(stop)
+	<-done
+
+	if lost.Load() {
+		if fnErr != nil {
+			return fmt.Errorf("%w: %s: %v", ErrLeaseLost, key, fnErr)
+		}
+		return fmt.Errorf("%w: %s", ErrLeaseLost, key)
+	}
+	if err := lock.Release(ctx); err != nil && fnErr == nil {
+		return err
+	}
+	return fnErr
+}
+
+// keepAlive renews lock every TTL/3 until stop is closed and reports false if the lock was lost
+func (ls *Locks) keepAlive(lock *Lock, stop chan struct{}) bool {
+	ticker := time.NewTicker(ls.opts.TTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return true
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), ls.opts.TTL/3)
+		err := lock.Renew(ctx, ls.opts.TTL)
+		cancel()
+		if errors.Is(err, ErrLeaseLost) || (err != nil && !time.Now().Before(lock.ExpiresAt())) {
+			return false
+		}
+	}
+}
+
+// Key returns the locked key
+func (l *Lock) Key() string {
+	return l.key
+}
+
+// Token returns the fencing token of this acquisition. Pass it along with writes
+// guarded by the lock so the server can reject a holder whose lock has expired.
+func (l *Lock) Token() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lease.Token
+}
+
+// ExpiresAt returns when the lock lapses unless renewed
+func (l *Lock) ExpiresAt() time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lease.ExpiresAt
+}
+
+// Renew extends the lock by ttl. It fails with ErrLeaseLost if the lock expired and
+// was taken by another holder in the meantime.
+func (l *Lock) Renew(ctx context.Context, ttl time.Duration) error {
+	l.mu.Lock()
+	lease := l.lease
+	l.mu.Unlock()
+
+	renewed, err := l.client.RenewLease(ctx, lease, ttl)
+	if err != nil {
+		return fmt.Errorf("failed to renew lock %s: %w", l.key, err)
+	}
+	l.mu.Lock()
+	l.lease = renewed
+	l.mu.Unlock()
+	return nil
+}
+
+// Release gives up the lock so others can acquire it immediately. It fails with
+// ErrLeaseLost if the lock had already expired and been taken by another holder.
+func (l *Lock) Release(ctx context.Context) error {
+	l.mu.Lock()
+	lease := l.lease
+	l.mu.Unlock()
+
+	if err := l.client.ReleaseLease(ctx, lease); err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", l.key, err)
+	}
+	return nil
+}
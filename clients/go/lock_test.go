@@ -0,0 +1,100 @@
+package themisdb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocks_AcquireRelease(t *testing.T) {
+	client, server := newLeaseClient(t)
+	locks := client.Locks(LockOptions{RetryInterval: 5 * time.Millisecond})
+	ctx := context.Background()
+
+	lock, err := locks.Acquire(ctx, "report", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "report", lock.Key())
+	assert.Equal(t, int64(1), lock.Token())
+	assert.Contains(t, server.leases, "lock/report")
+
+	_, err = locks.TryAcquire(ctx, "report", time.Minute)
+	assert.ErrorIs(t, err, ErrLeaseHeld, "a lock excludes other holders of the same process")
+
+	expires := lock.ExpiresAt()
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, lock.Renew(ctx, time.Minute))
+	assert.True(t, lock.ExpiresAt().After(expires))
+
+	acquired := make(chan *Lock)
+	go func() {
+		next, err := locks.Acquire(ctx, "report", time.Minute)
+		assert.NoError(t, err)
+		acquired <- next
+	}()
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, lock.Release(ctx))
+	next := <-acquired
+	assert.Greater(t, next.Token(), lock.Token(), "each acquisition has a higher fencing token")
+
+	shortCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = locks.Acquire(shortCtx, "report", time.Minute)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	server.rejectRenew = true
+	assert.ErrorIs(t, next.Renew(ctx, time.Minute), ErrLeaseLost)
+	_, err = locks.TryAcquire(ctx, "", time.Minute)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestLocks_WithLock(t *testing.T) {
+	client, server := newLeaseClient(t)
+	locks := client.Locks(LockOptions{TTL: 30 * time.Millisecond, RetryInterval: time.Millisecond})
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	running, maxRunning, runs := 0, 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := locks.WithLock(ctx, "singleton", func(ctx context.Context, lock *Lock) error {
+				mu.Lock()
+				running++
+				runs++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+				time.Sleep(40 * time.Millisecond) // longer than the TTL: renewal keeps the lock
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 4, runs)
+	assert.Equal(t, 1, maxRunning)
+	assert.Empty(t, server.leases, "the lock is released after fn returns")
+
+	failure := errors.New("job failed")
+	err := locks.WithLock(ctx, "singleton", func(ctx context.Context, lock *Lock) error { return failure })
+	assert.ErrorIs(t, err, failure)
+	assert.Empty(t, server.leases)
+
+	server.rejectRenew = true
+	err = locks.WithLock(ctx, "singleton", func(ctx context.Context, lock *Lock) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	assert.ErrorIs(t, err, ErrLeaseLost, "losing the lock cancels fn")
+}
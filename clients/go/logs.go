@@ -0,0 +1,163 @@
+package themisdb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogFilter selects the server log entries to stream
+type LogFilter struct {
+	// Level is the minimum level streamed (default: all levels)
+	Level LogLevel
+	// Components restricts the stream to these components, e.g. "storage" or "query"
+	Components []string
+	// Contains restricts the stream to messages containing this text
+	Contains string
+	// Since replays entries logged after this time before tailing, zero tails new entries only
+	Since time.Time
+	// PollTimeout is the long-poll timeout of log requests (default: 20s)
+	PollTimeout time.Duration
+}
+
+// LogEntry is a single server log line
+type LogEntry struct {
+	Seq       uint64    `json:"seq"`
+	Time      time.Time `json:"time"`
+	Node      string    `json:"node"`
+	Level     LogLevel  `json:"level"`
+	Component string    `json:"component"`
+	Message   string    `json:"message"`
+}
+
+// String formats the entry as a log line
+func (e LogEntry) String() string {
+	return fmt.Sprintf("%s %-5s [%s] %s: %s", e.Time.UTC().Format("2006-01-02T15:04:05.000Z"), strings.ToUpper(string(e.Level)), e.Component, e.Node, e.Message)
+}
+
+// LogStream tails the log of a server node. Entries are delivered on Entries until
+// the context is cancelled or a request fails; Err reports the failure afterwards.
+type LogStream struct {
+	// Entries receives the log entries in order and is closed when the stream ends
+	Entries <-chan LogEntry
+
+	err  error
+	done chan struct{}
+}
+
+// Err returns the error that ended the stream once Entries is closed, or nil if
+// the stream ended because its context was cancelled
+func (s *LogStream) Err() error {
+	<-s.done
+	return s.err
+}
+
+// WriteTo writes every entry as a line to w until the stream ends. It implements io.WriterTo.
+func (s *LogStream) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	for entry := range s.Entries {
+		n, err := fmt.Fprintln(w, entry)
+		written += int64(n)
+		if err != nil {
+			for range s.Entries {
+			}
+			return written, err
+		}
+	}
+	return written, s.Err()
+}
+
+// StreamLogs tails the log of a server node with long polling. nodeID selects a node
+// of the cluster (see Client.Topology); an empty nodeID streams the node serving the request.
+//
+//	stream := client.Admin().StreamLogs(ctx, "node-1", themisdb.LogFilter{Level: themisdb.LogLevelWarn})
+//	_, err := stream.WriteTo(os.Stdout)
+func (a *Admin) StreamLogs(ctx context.Context, nodeID string, filter LogFilter) *LogStream {
+	entries := make(chan LogEntry, 100)
+	stream := &LogStream{Entries: entries, done: make(chan struct{})}
+
+	if filter.PollTimeout <= 0 {
+		filter.PollTimeout = 20 * time.Second
+	}
+	if err := filter.validate(nodeID); err != nil {
+		stream.err = err
+		close(entries)
+		close(stream.done)
+		return stream
+	}
+
+	go func() {
+		defer close(stream.done)
+		defer close(entries)
+		stream.err = a.tailLogs(ctx, nodeID, filter, entries)
+	}()
+	return stream
+}
+
+// validate checks the filter and node ID
+func (f *LogFilter) validate(nodeID string) error {
+	switch f.Level {
+	case "", LogLevelTrace, LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError:
+	default:
+		return &ValidationError{Field: "log level", Value: string(f.Level), Reason: "must be trace, debug, info, warn, or error"}
+	}
+	if nodeID != "" {
+		return validateName("node", nodeID)
+	}
+	return nil
+}
+
+// tailLogs long-polls the log of a node and sends its entries until ctx is done
+func (a *Admin) tailLogs(ctx context.Context, nodeID string, filter LogFilter, entries chan<- LogEntry) error {
+	path := "/admin/logs"
+	if nodeID != "" {
+		path = joinPath("/admin/nodes", nodeID) + "/logs"
+	}
+
+	query := url.Values{}
+	if filter.Level != "" {
+		query.Set("level", string(filter.Level))
+	}
+	if len(filter.Components) > 0 {
+		query.Set("component", strings.Join(filter.Components, ","))
+	}
+	if filter.Contains != "" {
+		query.Set("contains", filter.Contains)
+	}
+	if !filter.Since.IsZero() {
+		query.Set("since", filter.Since.UTC().Format(time.RFC3339Nano))
+	} else {
+		query.Set("tail", "true")
+	}
+	query.Set("wait_ms", strconv.FormatInt(filter.PollTimeout.Milliseconds(), 10))
+
+	for {
+		var result struct {
+			Entries []LogEntry `json:"entries"`
+			Next    uint64     `json:"next"`
+		}
+		if err := a.client.request(ctx, "GET", path+"?"+query.Encode(), nil, &result, nil); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to stream logs: %w", err)
+		}
+
+		for _, entry := range result.Entries {
+			select {
+			case entries <- entry:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		if result.Next > 0 {
+			query.Del("since")
+			query.Del("tail")
+			query.Set("after", strconv.FormatUint(result.Next, 10))
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package themisdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logServer serves the log of a single node with long polling
+type logServer struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	paths   []string
+}
+
+func (s *logServer) append(level LogLevel, component, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, LogEntry{
+		Seq:       uint64(len(s.entries) + 1),
+		Time:      time.Date(2026, 10, 15, 12, 0, len(s.entries), 0, time.UTC),
+		Node:      "node-1",
+		Level:     level,
+		Component: component,
+		Message:   message,
+	})
+}
+
+func (s *logServer) firstPath() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.paths) == 0 {
+		return ""
+	}
+	return s.paths[0]
+}
+
+func (s *logServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	after, _ := strconv.ParseUint(query.Get("after"), 10, 64)
+	wait, _ := strconv.Atoi(query.Get("wait_ms"))
+	levels := map[LogLevel]int{LogLevelTrace: 0, LogLevelDebug: 1, LogLevelInfo: 2, LogLevelWarn: 3, LogLevelError: 4}
+
+	s.mu.Lock()
+	s.paths = append(s.paths, r.URL.Path)
+	if query.Get("tail") == "true" {
+		after = uint64(len(s.entries))
+	}
+	s.mu.Unlock()
+
+	deadline := time.Now().Add(time.Duration(wait) * time.Millisecond)
+	for {
+		s.mu.Lock()
+		var entries []LogEntry
+		for _, e := range s.entries {
+			if e.Seq <= after || levels[e.Level] < levels[LogLevel(query.Get("level"))] {
+				continue
+			}
+			if c := query.Get("component"); c != "" && !containsString(strings.Split(c, ","), e.Component) {
+				continue
+			}
+			entries = append(entries, e)
+		}
+		next := len(s.entries)
+		s.mu.Unlock()
+
+		if len(entries) > 0 || time.Now().After(deadline) {
+			json.NewEncoder(w).Encode(map[string]interface{}{"entries": entries, "next": next})
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAdmin_StreamLogs(t *testing.T) {
+	server := &logServer{}
+	server.append(LogLevelError, "storage", "before the stream")
+	client := newTestClient(t, server.serveHTTP)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := client.Admin().StreamLogs(ctx, "node-1", LogFilter{
+		Level:       LogLevelWarn,
+		Components:  []string{"storage", "raft"},
+		PollTimeout: 50 * time.Millisecond,
+	})
+	require.Eventually(t, func() bool { return server.firstPath() != "" }, time.Second, 5*time.Millisecond)
+
+	server.append(LogLevelInfo, "storage", "compaction finished")
+	server.append(LogLevelWarn, "query", "slow query")
+	server.append(LogLevelWarn, "storage", "write stall")
+	server.append(LogLevelError, "raft", "leader lost")
+
+	first := <-stream.Entries
+	second := <-stream.Entries
+	assert.Equal(t, "write stall", first.Message)
+	assert.Equal(t, "leader lost", second.Message)
+	assert.Equal(t, "2026-10-15T12:00:04.000Z ERROR [raft] node-1: leader lost", second.String())
+
+	cancel()
+	for range stream.Entries {
+	}
+	assert.NoError(t, stream.Err())
+	assert.Equal(t, "/admin/nodes/node-1/logs", server.firstPath())
+}
+
+func TestAdmin_StreamLogsWriteTo(t *testing.T) {
+	server := &logServer{}
+	server.append(LogLevelInfo, "query", "old entry")
+	server.append(LogLevelInfo, "query", "replayed entry")
+	client := newTestClient(t, server.serveHTTP)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	stream := client.Admin().StreamLogs(ctx, "", LogFilter{
+		Since:       time.Date(2026, 10, 15, 12, 0, 0, 500, time.UTC),
+		PollTimeout: 20 * time.Millisecond,
+	})
+
+	var buf bytes.Buffer
+	_, err := stream.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "old entry")
+	assert.Contains(t, buf.String(), "replayed entry\n")
+	assert.Equal(t, "/admin/logs", server.firstPath())
+}
+
+func TestAdmin_StreamLogsErrors(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "node not found", http.StatusNotFound)
+	})
+
+	stream := client.Admin().StreamLogs(context.Background(), "node-9", LogFilter{})
+	_, err := stream.WriteTo(&bytes.Buffer{})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	stream = client.Admin().StreamLogs(context.Background(), "node-1", LogFilter{Level: "verbose"})
+	assert.ErrorIs(t, stream.Err(), ErrInvalidInput)
+	_, open := <-stream.Entries
+	assert.False(t, open)
+}
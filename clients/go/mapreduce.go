@@ -0,0 +1,266 @@
+package themisdb
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Mapper is called for every document of a MapReduce job and emits any number of
+// key/value pairs. Values must be JSON-encodable.
+type Mapper func(uuid string, doc json.RawMessage, emit func(key string, value interface{})) error
+
+// Reducer combines two values emitted for the same key into one. Values are combined in
+// arrival order as they are emitted and again when spilled runs are merged, so the
+// reducer must be associative and commutative, e.g. a sum, minimum, or set union. a and
+// b are the JSON encodings of emitted or previously reduced values.
+type Reducer func(key string, a, b json.RawMessage) (interface{}, error)
+
+// MapReduceJob describes a client-side aggregation over a collection
+type MapReduceJob struct {
+	Map    Mapper
+	Reduce Reducer
+	// Scan selects the documents to read
+	Scan ScanOptions
+	// MaxKeys bounds the number of distinct keys held in memory (default: 100000). When
+	// it is exceeded the partial results are spilled to SpillDir; without SpillDir the
+	// job fails with ErrMemoryLimit.
+	MaxKeys int
+	// SpillDir is the directory for temporary files of spilled partial results
+	SpillDir string
+}
+
+// MapReduceReport summarizes a MapReduce job
+type MapReduceReport struct {
+	// Documents counts the documents mapped
+	Documents int64
+	// Keys counts the distinct keys passed to the output
+	Keys int64
+	// Spills counts the partial results written to SpillDir
+	Spills int
+}
+
+// MapReduce streams the documents of a collection through job.Map, combines the values
+// emitted for each key with job.Reduce, and calls out with every key and its reduced
+// value in key order. It is meant for ad-hoc analytics that a server-side aggregate
+// cannot express; memory stays bounded by MaxKeys, spilling sorted partial results to
+// disk if SpillDir is set.
+//
+//	report, err := client.MapReduce(ctx, "document", "orders", themisdb.MapReduceJob{
+//		Map: func(uuid string, doc json.RawMessage, emit func(string, interface{})) error {
+//			var o Order
+//			if err := json.Unmarshal(doc, &o); err != nil {
+//				return err
+//			}
+//			emit(o.Country, o.Total)
+//			return nil
+//		},
+//		Reduce: func(key string, a, b json.RawMessage) (interface{}, error) {
+//			var x, y float64
+//			json.Unmarshal(a, &x)
+//			json.Unmarshal(b, &y)
+//			return x + y, nil
+//		},
+//	}, func(country string, total json.RawMessage) error { ... })
+func (c *Client) MapReduce(ctx context.Context, model, collection string, job MapReduceJob, out func(key string, value json.RawMessage) error) (*MapReduceReport, error) {
+	if job.Map == nil || job.Reduce == nil {
+		return nil, &ValidationError{Field: "map reduce job", Value: collection, Reason: "needs Map and Reduce"}
+	}
+	if job.MaxKeys <= 0 {
+		job.MaxKeys = 100000
+	}
+
+	mr := &mapReduce{job: job, report: &MapReduceReport{}, values: make(map[string]json.RawMessage)}
+	defer mr.cleanup()
+
+	it := c.Scan(ctx, model, collection, job.Scan)
+	for it.Next() {
+		mr.report.Documents++
+		emit := func(key string, value interface{}) {
+			if mr.err == nil {
+				mr.err = mr.add(key, value)
+			}
+		}
+		if err := job.Map(it.UUID(), it.Document(), emit); err != nil {
+			return mr.report, fmt.Errorf("map reduce %s/%s: map %s: %w", model, collection, it.UUID(), err)
+		}
+		if mr.err != nil {
+			return mr.report, fmt.Errorf("map reduce %s/%s: %w", model, collection, mr.err)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return mr.report, fmt.Errorf("map reduce %s/%s: %w", model, collection, err)
+	}
+
+	if err := mr.output(out); err != nil {
+		return mr.report, fmt.Errorf("map reduce %s/%s: %w", model, collection, err)
+	}
+	return mr.report, nil
+}
+
+// mapReduce is the state of a running MapReduce job
+type mapReduce struct {
+	job    MapReduceJob
+	report *MapReduceReport
+	values map[string]json.RawMessage
+	spills []*os.File
+	err    error
+}
+
+// spillEntry is a line of a spill file
+type spillEntry struct {
+	Key   string          `json:"k"`
+	Value json.RawMessage `json:"v"`
+}
+
+// add reduces an emitted value into the in-memory results, spilling them when full
+func (mr *mapReduce) add(key string, value interface{}) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode value of %s: %w", key, err)
+	}
+	if current, ok := mr.values[key]; ok {
+		if encoded, err = mr.reduce(key, current, encoded); err != nil {
+			return err
+		}
+	} else if len(mr.values) >= mr.job.MaxKeys {
+		if err := mr.spill(); err != nil {
+			return err
+		}
+	}
+	mr.values[key] = encoded
+	return nil
+}
+
+// reduce combines two values of key
+func (mr *mapReduce) reduce(key string, a, b json.RawMessage) (json.RawMessage, error) {
+	reduced, err := mr.job.Reduce(key, a, b)
+	if err != nil {
+		return nil, fmt.Errorf("reduce %s: %w", key, err)
+	}
+	encoded, err := json.Marshal(reduced)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode value of %s: %w", key, err)
+	}
+	return encoded, nil
+}
+
+// spill writes the in-memory results sorted by key to a temporary file and clears them
+func (mr *mapReduce) spill() error {
+	if mr.job.SpillDir == "" {
+		return fmt.Errorf("%w: more than %d keys and no spill directory", ErrMemoryLimit, mr.job.MaxKeys)
+	}
+	f, err := os.CreateTemp(mr.job.SpillDir, "themis-mapreduce-*.ndjson")
+	if err != nil {
+		return fmt.Errorf("failed to spill: %w", err)
+	}
+	mr.spills = append(mr.spills, f)
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, key := range sortedKeys(mr.values) {
+		if err := enc.Encode(spillEntry{Key: key, Value: mr.values[key]}); err != nil {
+			return fmt.Errorf("failed to spill: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to spill: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to spill: %w", err)
+	}
+	mr.values = make(map[string]json.RawMessage)
+	mr.report.Spills++
+	return nil
+}
+
+// output passes the reduced values to out in key order, merging spilled runs
+func (mr *mapReduce) output(out func(key string, value json.RawMessage) error) error {
+	if len(mr.spills) == 0 {
+		for _, key := range sortedKeys(mr.values) {
+			mr.report.Keys++
+			if err := out(key, mr.values[key]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(mr.values) > 0 {
+		if err := mr.spill(); err != nil {
+			return err
+		}
+	}
+
+	runs := make([]*json.Decoder, len(mr.spills))
+	heads := make([]*spillEntry, len(mr.spills))
+	advance := func(i int) error {
+		var entry spillEntry
+		if err := runs[i].Decode(&entry); err == io.EOF {
+			heads[i] = nil
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read spill: %w", err)
+		}
+		heads[i] = &entry
+		return nil
+	}
+	for i, f := range mr.spills {
+		runs[i] = json.NewDecoder(bufio.NewReader(f))
+		if err := advance(i); err != nil {
+			return err
+		}
+	}
+
+	for {
+		min := -1
+		for i, head := range heads {
+			if head != nil && (min < 0 || head.Key < heads[min].Key) {
+				min = i
+			}
+		}
+		if min < 0 {
+			return nil
+		}
+		key, value := heads[min].Key, heads[min].Value
+		for i, head := range heads {
+			if head == nil || head.Key != key {
+				continue
+			}
+			if i != min {
+				var err error
+				if value, err = mr.reduce(key, value, head.Value); err != nil {
+					return err
+				}
+			}
+			if err := advance(i); err != nil {
+				return err
+			}
+		}
+		mr.report.Keys++
+		if err := out(key, value); err != nil {
+			return err
+		}
+	}
+}
+
+// cleanup removes the spill files
+func (mr *mapReduce) cleanup() {
+	for _, f := range mr.spills {
+		f.Close()
+		os.Remove(f.Name())
+	}
+}
+
+// sortedKeys returns the keys of values in ascending order
+func sortedKeys(values map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
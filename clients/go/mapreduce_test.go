@@ -0,0 +1,109 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wordCount maps documents with a text field to the number of occurrences of each word
+var wordCount = MapReduceJob{
+	Map: func(uuid string, doc json.RawMessage, emit func(string, interface{})) error {
+		var d struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(doc, &d); err != nil {
+			return err
+		}
+		for _, word := range strings.Fields(d.Text) {
+			emit(word, 1)
+		}
+		return nil
+	},
+	Reduce: func(key string, a, b json.RawMessage) (interface{}, error) {
+		var x, y int
+		json.Unmarshal(a, &x)
+		json.Unmarshal(b, &y)
+		return x + y, nil
+	},
+}
+
+func TestClient_MapReduce(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	ctx := context.Background()
+	texts := []string{"the quick brown fox", "the lazy dog", "a quick dog", "the end", "fox and dog"}
+	for i, text := range texts {
+		require.NoError(t, client.Put(ctx, "document", "notes", string(rune('a'+i)), map[string]string{"text": text}))
+	}
+	want := map[string]int{"the": 3, "quick": 2, "brown": 1, "fox": 2, "lazy": 1, "dog": 3, "a": 1, "end": 1, "and": 1}
+
+	collect := func(job MapReduceJob) (map[string]int, []string, *MapReduceReport, error) {
+		counts := map[string]int{}
+		var keys []string
+		report, err := client.MapReduce(ctx, "document", "notes", job, func(key string, value json.RawMessage) error {
+			var n int
+			require.NoError(t, json.Unmarshal(value, &n))
+			counts[key] = n
+			keys = append(keys, key)
+			return nil
+		})
+		return counts, keys, report, err
+	}
+
+	counts, keys, report, err := collect(wordCount)
+	require.NoError(t, err)
+	assert.Equal(t, want, counts)
+	assert.IsIncreasing(t, keys, "keys are passed in order")
+	assert.Equal(t, &MapReduceReport{Documents: 5, Keys: 9}, report)
+
+	dir := t.TempDir()
+	spilling := wordCount
+	spilling.MaxKeys = 3
+	spilling.SpillDir = dir
+	counts, keys, report, err = collect(spilling)
+	require.NoError(t, err)
+	assert.Equal(t, want, counts, "spilled partial results are merged")
+	assert.IsIncreasing(t, keys)
+	assert.Equal(t, int64(9), report.Keys)
+	assert.Greater(t, report.Spills, 1)
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files, "spill files are removed")
+
+	spilling.SpillDir = ""
+	_, _, _, err = collect(spilling)
+	assert.ErrorIs(t, err, ErrMemoryLimit)
+}
+
+func TestClient_MapReduceErrors(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	ctx := context.Background()
+	require.NoError(t, client.Put(ctx, "document", "notes", "a", map[string]string{"text": "one two"}))
+	failure := errors.New("boom")
+
+	failing := wordCount
+	failing.Map = func(uuid string, doc json.RawMessage, emit func(string, interface{})) error { return failure }
+	_, err := client.MapReduce(ctx, "document", "notes", failing, func(string, json.RawMessage) error { return nil })
+	assert.ErrorIs(t, err, failure)
+	assert.ErrorContains(t, err, "map a")
+
+	_, err = client.MapReduce(ctx, "document", "notes", wordCount, func(string, json.RawMessage) error { return failure })
+	assert.ErrorIs(t, err, failure)
+
+	unencodable := wordCount
+	unencodable.Map = func(uuid string, doc json.RawMessage, emit func(string, interface{})) error {
+		emit("x", func() {})
+		return nil
+	}
+	_, err = client.MapReduce(ctx, "document", "notes", unencodable, func(string, json.RawMessage) error { return nil })
+	assert.ErrorContains(t, err, "failed to encode value of x")
+
+	_, err = client.MapReduce(ctx, "document", "notes", MapReduceJob{Map: wordCount.Map}, nil)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
@@ -0,0 +1,186 @@
+package themisdb
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memoryStore is an in-memory server for entity, merge patch, scan, bulk, and increment
+// requests.
+// Transactions are acknowledged but writes are applied immediately.
+type memoryStore struct {
+	mu        sync.Mutex
+	docs      map[string]map[string]interface{}
+	revisions map[string]int
+	patches   int
+	prepares  int
+	commits   int
+	rollbacks int
+}
+
+// newMemoryClient returns a client backed by an in-memory store
+func newMemoryClient(t testing.TB) (*Client, *memoryStore) {
+	store := &memoryStore{docs: make(map[string]map[string]interface{}), revisions: make(map[string]int)}
+	return newTestClient(t, store.serveHTTP), store
+}
+
+func (s *memoryStore) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/transaction/begin":
+		json.NewEncoder(w).Encode(map[string]string{"transaction_id": "tx-" + strconv.Itoa(s.commits+s.rollbacks)})
+		return
+	case "/transaction/prepare":
+		s.prepares++
+		return
+	case "/transaction/commit":
+		s.commits++
+		return
+	case "/transaction/rollback":
+		s.rollbacks++
+		return
+	}
+
+	if strings.HasSuffix(r.URL.Path, "/_increment") {
+		var body struct {
+			UUID  string `json:"uuid"`
+			Field string `json:"field"`
+			Delta int64  `json:"delta"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		key := strings.TrimSuffix(r.URL.Path, "_increment") + body.UUID
+		if s.docs[key] == nil {
+			s.docs[key] = map[string]interface{}{}
+		}
+		current, _ := s.docs[key][body.Field].(float64)
+		s.docs[key][body.Field] = current + float64(body.Delta)
+		s.revisions[key]++
+		json.NewEncoder(w).Encode(map[string]int64{"value": int64(current) + body.Delta})
+		return
+	}
+
+	if strings.HasSuffix(r.URL.Path, "/_bulk") {
+		prefix := strings.TrimSuffix(r.URL.Path, "_bulk")
+		var body struct {
+			Documents  []ScanEntry `json:"documents"`
+			Deletes    []string    `json:"deletes"`
+			OnConflict string      `json:"on_conflict"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		written, skipped := 0, 0
+		for _, entry := range body.Documents {
+			if _, exists := s.docs[prefix+entry.UUID]; exists {
+				if body.OnConflict == "fail" {
+					w.WriteHeader(http.StatusConflict)
+					return
+				}
+				if body.OnConflict == "skip" {
+					skipped++
+					continue
+				}
+			}
+		}
+		for _, entry := range body.Documents {
+			if _, exists := s.docs[prefix+entry.UUID]; exists && body.OnConflict == "skip" {
+				continue
+			}
+			doc := map[string]interface{}{}
+			json.Unmarshal(entry.Document, &doc)
+			s.docs[prefix+entry.UUID] = doc
+			s.revisions[prefix+entry.UUID]++
+			written++
+		}
+		for _, uuid := range body.Deletes {
+			delete(s.docs, prefix+uuid)
+		}
+		json.NewEncoder(w).Encode(map[string]int{"written": written, "skipped": skipped})
+		return
+	}
+
+	if strings.HasSuffix(r.URL.Path, "/_scan") {
+		prefix := strings.TrimSuffix(r.URL.Path, "_scan")
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		after := r.URL.Query().Get("start_after")
+		uuidPrefix := r.URL.Query().Get("prefix")
+		var keys []string
+		for key := range s.docs {
+			if strings.HasPrefix(key, prefix+uuidPrefix) && key[len(prefix):] > after {
+				keys = append(keys, key[len(prefix):])
+			}
+		}
+		sort.Strings(keys)
+		hasMore := len(keys) > limit
+		if hasMore {
+			keys = keys[:limit]
+		}
+		items := make([]map[string]interface{}, 0, len(keys))
+		for _, key := range keys {
+			items = append(items, map[string]interface{}{"uuid": key, "document": s.docs[prefix+key]})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items, "has_more": hasMore})
+		return
+	}
+
+	doc, exists := s.docs[r.URL.Path]
+	etag := `"` + strconv.Itoa(s.revisions[r.URL.Path]) + `"`
+	match := r.Header.Get("If-Match")
+	if (exists && r.Header.Get("If-None-Match") == "*") || (match != "" && (!exists || match != etag)) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	if r.Method != "GET" {
+		s.revisions[r.URL.Path]++
+	}
+	switch r.Method {
+	case "GET":
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		json.NewEncoder(w).Encode(doc)
+	case "PUT":
+		doc = map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&doc)
+		s.docs[r.URL.Path] = doc
+		w.WriteHeader(http.StatusNoContent)
+	case "PATCH":
+		if !exists && r.URL.Query().Get("upsert") != "true" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if !exists {
+			doc = map[string]interface{}{}
+			s.docs[r.URL.Path] = doc
+		}
+		var patch map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&patch)
+		for k, v := range patch {
+			if v == nil {
+				delete(doc, k)
+			} else {
+				doc[k] = v
+			}
+		}
+		s.patches++
+		if exists {
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusCreated)
+		}
+	case "DELETE":
+		delete(s.docs, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,96 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// QueryWarning is a non-fatal notice the server attached to a query result
+type QueryWarning struct {
+	// Code identifies the warning, e.g. "deprecated_function"
+	Code string `json:"code"`
+	// Message describes the warning
+	Message string `json:"message"`
+}
+
+// ResultMeta describes a query result beyond its rows
+type ResultMeta struct {
+	// TotalCount is the number of matching rows before LIMIT, or -1 if the server did not
+	// count them
+	TotalCount int64
+	// Returned is the number of rows in the result
+	Returned int
+	// ExecutionTime is the server-side execution time
+	ExecutionTime time.Duration
+	// CursorID identifies a server-side cursor holding further rows; empty if the result
+	// is complete
+	CursorID string
+	// HasMore reports whether further rows can be fetched with CursorID
+	HasMore bool
+	// Warnings are non-fatal notices such as deprecations
+	Warnings []QueryWarning
+}
+
+// QueryWithMeta executes an AQL query with per-query options, decodes its data into
+// result, and returns the metadata the server reported with it, e.g. to show accurate
+// pagination or to surface deprecation warnings
+func (c *Client) QueryWithMeta(ctx context.Context, aql string, opts *QueryOptions, result interface{}) (*ResultMeta, error) {
+	if ctx, tx := c.contextTx(ctx); tx != nil {
+		return tx.QueryWithMeta(ctx, aql, opts, result)
+	}
+	res, err := c.execQuery(ctx, aql, opts, nil, result, nil)
+	if err != nil {
+		return nil, err
+	}
+	return res.meta(), nil
+}
+
+// QueryWithMeta executes an AQL query within the transaction and returns the metadata
+// of its result
+func (tx *Transaction) QueryWithMeta(ctx context.Context, aql string, opts *QueryOptions, result interface{}) (*ResultMeta, error) {
+	ctx, headers, release, err := tx.acquire(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	res, err := tx.client.execQuery(ctx, aql, opts, nil, result, headers)
+	if err != nil {
+		return nil, err
+	}
+	return res.meta(), nil
+}
+
+// meta returns the metadata of r, counting the rows if the server did not report it
+func (r *QueryResult) meta() *ResultMeta {
+	meta := &ResultMeta{
+		TotalCount:    -1,
+		ExecutionTime: millisToDuration(r.ExecutionTimeMs),
+		CursorID:      r.Cursor,
+		HasMore:       r.HasMore,
+		Warnings:      r.Warnings,
+	}
+	if r.Total != nil {
+		meta.TotalCount = *r.Total
+	}
+	switch {
+	case r.Count != nil:
+		meta.Returned = *r.Count
+	default:
+		meta.Returned = r.rows()
+	}
+	return meta
+}
+
+// rows counts the rows in Data: the elements of an array, or 1 for any other value
+// but null
+func (r *QueryResult) rows() int {
+	if len(r.Data) == 0 || string(r.Data) == "null" {
+		return 0
+	}
+	var rows []json.RawMessage
+	if json.Unmarshal(r.Data, &rows) == nil {
+		return len(rows)
+	}
+	return 1
+}
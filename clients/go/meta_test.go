@@ -0,0 +1,68 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_QueryWithMeta(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"data":[{"name":"Alice"},{"name":"Bob"}],
+			"total":120,"count":2,"execution_time_ms":3.5,
+			"cursor":"c-42","has_more":true,
+			"warnings":[{"code":"deprecated_function","message":"CONCAT_SEPARATOR is deprecated"}]
+		}`))
+	})
+
+	var users []map[string]string
+	meta, err := client.QueryWithMeta(context.Background(), "FOR u IN users LIMIT 2 RETURN u", nil, &users)
+	require.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, int64(120), meta.TotalCount)
+	assert.Equal(t, 2, meta.Returned)
+	assert.Equal(t, 3500*time.Microsecond, meta.ExecutionTime)
+	assert.Equal(t, "c-42", meta.CursorID)
+	assert.True(t, meta.HasMore)
+	assert.Equal(t, []QueryWarning{{Code: "deprecated_function", Message: "CONCAT_SEPARATOR is deprecated"}}, meta.Warnings)
+}
+
+func TestClient_QueryWithMeta_Unreported(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[1,2,3]}`))
+	})
+
+	meta, err := client.QueryWithMeta(context.Background(), "FOR i IN 1..3 RETURN i", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-1), meta.TotalCount)
+	assert.Equal(t, 3, meta.Returned)
+	assert.Empty(t, meta.CursorID)
+	assert.Empty(t, meta.Warnings)
+}
+
+func TestTransaction_QueryWithMeta(t *testing.T) {
+	var txHeader string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/transaction/begin":
+			w.Write([]byte(`{"transaction_id":"tx-1"}`))
+		case "/api/query":
+			txHeader = r.Header.Get("X-Transaction-Id")
+			w.Write([]byte(`{"data":[],"total":0,"count":0}`))
+		}
+	})
+	ctx := context.Background()
+	tx, err := client.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+
+	meta, err := tx.QueryWithMeta(ctx, "FOR u IN users RETURN u", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "tx-1", txHeader)
+	assert.Equal(t, int64(0), meta.TotalCount)
+	assert.Equal(t, 0, meta.Returned)
+}
@@ -0,0 +1,112 @@
+package themisdb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Phase is a step of an expand/contract schema migration
+type Phase string
+
+const (
+	// PhaseExpand: the new field is added, writers populate both old and new fields
+	PhaseExpand Phase = "expand"
+	// PhaseMigrate: existing documents are backfilled, readers prefer the new field
+	PhaseMigrate Phase = "migrate"
+	// PhaseContract: readers and writers use only the new field, the old one can be dropped
+	PhaseContract Phase = "contract"
+)
+
+// migrationsCollection stores the phase and backfill checkpoint of each migration
+const migrationsCollection = "_migrations"
+
+// Migration coordinates an online schema change using the expand/contract pattern.
+// Its phase and backfill checkpoint are stored in ThemisDB, so every service instance
+// observes the same cutover and an interrupted backfill resumes where it stopped.
+type Migration struct {
+	client *Client
+	name   string
+}
+
+// Migration returns the migration with the given name
+func (c *Client) Migration(name string) *Migration {
+	return &Migration{client: c, name: name}
+}
+
+// migrationState is the stored document of a migration
+type migrationState struct {
+	Phase Phase `json:"phase,omitempty"`
+	backfillState
+}
+
+// state loads the stored state, returning the zero state if the migration was never started
+func (m *Migration) state(ctx context.Context) (*migrationState, error) {
+	var state migrationState
+	if err := m.client.loadCheckpoint(ctx, migrationsCollection, m.name, &state); err != nil {
+		return nil, fmt.Errorf("failed to load migration %s: %w", m.name, err)
+	}
+	return &state, nil
+}
+
+// Phase returns the current phase, PhaseExpand if the migration was never started
+func (m *Migration) Phase(ctx context.Context) (Phase, error) {
+	state, err := m.state(ctx)
+	if err != nil {
+		return "", err
+	}
+	if state.Phase == "" {
+		return PhaseExpand, nil
+	}
+	return state.Phase, nil
+}
+
+// SetPhase moves the migration to the given phase, e.g. to cut readers over after a backfill
+func (m *Migration) SetPhase(ctx context.Context, phase Phase) error {
+	switch phase {
+	case PhaseExpand, PhaseMigrate, PhaseContract:
+	default:
+		return &ValidationError{Field: "phase", Value: string(phase), Reason: "must be expand, migrate, or contract"}
+	}
+	if err := m.client.saveCheckpoint(ctx, migrationsCollection, m.name, map[string]interface{}{"phase": phase}); err != nil {
+		return fmt.Errorf("failed to save migration %s: %w", m.name, err)
+	}
+	return nil
+}
+
+// Backfill applies opts.Transform to every document of model/collection, checkpointing
+// its progress with the migration. Calling Backfill again after an interruption resumes
+// from the last checkpoint; once a backfill completed it is a no-op.
+func (m *Migration) Backfill(ctx context.Context, model, collection string, opts BackfillOptions) (*BackfillReport, error) {
+	return m.client.runBackfill(ctx, migrationsCollection, m.name, model, collection, opts)
+}
+
+// DualRead decodes the first of fields present in doc into v and returns its name,
+// so readers can prefer a new field and fall back to the old one during a migration.
+// Fields use dotted paths; an empty name is returned if none of them is set.
+func DualRead(doc json.RawMessage, v interface{}, fields ...string) (string, error) {
+	// numbers stay json.Number, so integers beyond 2^53 reach v intact
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	var decoded map[string]interface{}
+	if err := dec.Decode(&decoded); err != nil {
+		return "", fmt.Errorf("failed to unmarshal document: %w", err)
+	}
+
+	for _, field := range fields {
+		value, ok := lookupField(decoded, field)
+		if !ok || value == nil {
+			continue
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal field %s: %w", field, err)
+		}
+		if err := json.Unmarshal(raw, v); err != nil {
+			return "", fmt.Errorf("failed to unmarshal field %s: %w", field, err)
+		}
+		return field, nil
+	}
+	return "", nil
+}
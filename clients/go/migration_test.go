@@ -0,0 +1,95 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigration_Phase(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	ctx := context.Background()
+	m := client.Migration("split-name")
+
+	phase, err := m.Phase(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, PhaseExpand, phase)
+
+	require.NoError(t, m.SetPhase(ctx, PhaseContract))
+	phase, err = m.Phase(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, PhaseContract, phase)
+
+	assert.ErrorIs(t, m.SetPhase(ctx, "done"), ErrInvalidInput)
+}
+
+func TestMigration_BackfillResumes(t *testing.T) {
+	client, store := newMemoryClient(t)
+	ctx := context.Background()
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		require.NoError(t, client.Put(ctx, "relational", "users", id, map[string]string{"name": "Ada Lovelace"}))
+	}
+
+	errStop := errors.New("stop")
+	var calls int
+	transform := func(uuid string, doc json.RawMessage) (interface{}, error) {
+		calls++
+		if calls == 4 {
+			return nil, errStop
+		}
+		var user map[string]interface{}
+		require.NoError(t, json.Unmarshal(doc, &user))
+		if _, done := user["first_name"]; done {
+			return nil, nil
+		}
+		parts := strings.SplitN(user["name"].(string), " ", 2)
+		return map[string]interface{}{"first_name": parts[0], "last_name": parts[1]}, nil
+	}
+
+	m := client.Migration("split-name")
+	report, err := m.Backfill(ctx, "relational", "users", BackfillOptions{Transform: transform, BatchSize: 2})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, "3", report.Cursor)
+
+	report, err = m.Backfill(ctx, "relational", "users", BackfillOptions{Transform: transform, BatchSize: 2, RatePerSecond: 1000})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), report.Scanned)
+	assert.Equal(t, int64(2), report.Updated)
+	assert.Equal(t, int64(5), report.Processed)
+	assert.Equal(t, "5", report.Cursor)
+	assert.Equal(t, "Lovelace", store.docs["/api/relational/users/5"]["last_name"])
+
+	calls = 0
+	report, err = m.Backfill(ctx, "relational", "users", BackfillOptions{Transform: transform})
+	require.NoError(t, err)
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, int64(5), report.Processed)
+}
+
+func TestDualRead(t *testing.T) {
+	var name string
+	field, err := DualRead(json.RawMessage(`{"name":"Ada","profile":{"display_name":"Countess"}}`), &name, "profile.display_name", "name")
+	require.NoError(t, err)
+	assert.Equal(t, "profile.display_name", field)
+	assert.Equal(t, "Countess", name)
+
+	field, err = DualRead(json.RawMessage(`{"name":"Ada"}`), &name, "profile.display_name", "name")
+	require.NoError(t, err)
+	assert.Equal(t, "name", field)
+	assert.Equal(t, "Ada", name)
+
+	field, err = DualRead(json.RawMessage(`{}`), &name, "name")
+	require.NoError(t, err)
+	assert.Empty(t, field)
+
+	var id int64
+	field, err = DualRead(json.RawMessage(`{"ids":{"external":9007199254740993}}`), &id, "ids.external")
+	require.NoError(t, err)
+	assert.Equal(t, "ids.external", field)
+	assert.Equal(t, int64(9007199254740993), id, "integers beyond 2^53 are not rounded")
+}
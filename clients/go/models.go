@@ -0,0 +1,78 @@
+package themisdb
+
+import (
+	"context"
+	"fmt"
+)
+
+// Data model names accepted as the model argument of entity operations
+const (
+	// ModelRelational stores rows in tables
+	ModelRelational = "relational"
+	// ModelDocument stores schemaless JSON documents
+	ModelDocument = "document"
+	// ModelGraph stores vertices and edges
+	ModelGraph = "graph"
+	// ModelTimeseries stores timestamped measurements
+	ModelTimeseries = "timeseries"
+	// ModelKV stores opaque values by key
+	ModelKV = "kv"
+)
+
+// ModelLimits holds the per-model limits enforced by the server.
+// Zero means the server does not advertise a limit.
+type ModelLimits struct {
+	// MaxDocumentSize is the largest accepted document in bytes
+	MaxDocumentSize int64 `json:"max_document_size,omitempty"`
+	// MaxKeyLength is the longest accepted UUID in bytes
+	MaxKeyLength int `json:"max_key_length,omitempty"`
+	// MaxBatchSize is the largest number of entities per batch request
+	MaxBatchSize int `json:"max_batch_size,omitempty"`
+	// MaxCollections is the number of collections the model may hold
+	MaxCollections int `json:"max_collections,omitempty"`
+}
+
+// ModelInfo describes a data model and whether it is enabled on the server
+type ModelInfo struct {
+	Name     string      `json:"name"`
+	Enabled  bool        `json:"enabled"`
+	Features []string    `json:"features,omitempty"`
+	Limits   ModelLimits `json:"limits"`
+}
+
+// HasFeature reports whether the model advertises the named feature, e.g. "transactions"
+func (m ModelInfo) HasFeature(feature string) bool {
+	for _, f := range m.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// Models lists the data models known to the server, including disabled ones,
+// so generic tools can adapt to the models and limits of a deployment.
+func (c *Client) Models(ctx context.Context) ([]ModelInfo, error) {
+	var response struct {
+		Models []ModelInfo `json:"models"`
+	}
+	if err := c.request(ctx, "GET", "/api/models", nil, &response, nil); err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+	return response.Models, nil
+}
+
+// Model returns the description of a single data model.
+// ok is false if the server does not know the model.
+func (c *Client) Model(ctx context.Context, name string) (info ModelInfo, ok bool, err error) {
+	models, err := c.Models(ctx)
+	if err != nil {
+		return ModelInfo{}, false, err
+	}
+	for _, m := range models {
+		if m.Name == name {
+			return m, true, nil
+		}
+	}
+	return ModelInfo{}, false, nil
+}
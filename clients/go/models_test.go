@@ -0,0 +1,42 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Models(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/api/models", r.URL.Path)
+		w.Write([]byte(`{"models":[
+			{"name":"relational","enabled":true,"features":["transactions","indexes"],"limits":{"max_document_size":1048576,"max_batch_size":1000}},
+			{"name":"timeseries","enabled":false,"limits":{}}
+		]}`))
+	})
+	ctx := context.Background()
+
+	models, err := client.Models(ctx)
+	require.NoError(t, err)
+	require.Len(t, models, 2)
+	assert.Equal(t, ModelRelational, models[0].Name)
+	assert.True(t, models[0].Enabled)
+	assert.True(t, models[0].HasFeature("transactions"))
+	assert.False(t, models[0].HasFeature("vector"))
+	assert.Equal(t, int64(1048576), models[0].Limits.MaxDocumentSize)
+	assert.Equal(t, 1000, models[0].Limits.MaxBatchSize)
+	assert.False(t, models[1].Enabled)
+
+	info, ok, err := client.Model(ctx, ModelTimeseries)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, info.Enabled)
+
+	_, ok, err = client.Model(ctx, ModelKV)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
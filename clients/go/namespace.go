@@ -0,0 +1,90 @@
+package themisdb
+
+import "context"
+
+// headerNamespace scopes a request to a tenant namespace on the server
+const headerNamespace = "X-Themis-Namespace"
+
+// namespaceKey is the context key of WithNamespace
+type namespaceKey struct{}
+
+// WithNamespace returns a context whose requests are scoped to namespace ns.
+// It overrides the namespace of the client the request is sent with.
+func WithNamespace(ctx context.Context, ns string) context.Context {
+	return context.WithValue(ctx, namespaceKey{}, ns)
+}
+
+// NamespaceFromContext returns the namespace attached by WithNamespace
+func NamespaceFromContext(ctx context.Context) (string, bool) {
+	ns, ok := ctx.Value(namespaceKey{}).(string)
+	return ns, ok
+}
+
+// Namespace returns a client whose requests are scoped to namespace ns. The derived
+// client shares endpoints, transport, interceptors, caches, query logging, topology, enums, and
+// schemas with c; closing it is a no-op, close the root client instead.
+func (c *Client) Namespace(ns string) *Client {
+	d := c.derive()
+	d.namespace = ns
+	return d
+}
+
+// derive returns a client sharing the state of the root client, with the namespace
+// and snapshot of c
+func (c *Client) derive() *Client {
+	root := c
+	if c.root != nil {
+		root = c.root
+	}
+	d := &Client{
+		root:        root,
+		namespace:   c.namespace,
+		snapshot:    c.snapshot,
+		httpClient:  root.httpClient,
+		transport:   root.transport,
+		timeouts:    root.timeouts,
+		maxRetries:  root.maxRetries,
+		enums:       root.enums,
+		schemas:     root.schemas,
+		handler:     root.handler,
+		queryLog:    root.queryLog,
+		cache:       root.cache,
+		negotiation: root.negotiation,
+		stats:       root.stats,
+		hooks:       root.hooks,
+		writes:      root.writes,
+		fieldStats:  root.fieldStats,
+		codec:       root.codec,
+	}
+	if d.snapshot != nil {
+		d.cache = nil
+	}
+	return d
+}
+
+// namespaceOf returns the namespace of ctx, or else of the client
+func (c *Client) namespaceOf(ctx context.Context) string {
+	if ns, ok := NamespaceFromContext(ctx); ok {
+		return ns
+	}
+	return c.namespace
+}
+
+// withNamespace adds the namespace of ctx or the client to req
+func (c *Client) withNamespace(ctx context.Context, req *Request) error {
+	ns := c.namespaceOf(ctx)
+	if ns == "" || req.Header[headerNamespace] != "" {
+		return nil
+	}
+	if err := validateName("namespace", ns); err != nil {
+		return err
+	}
+
+	headers := make(map[string]string, len(req.Header)+1)
+	for key, value := range req.Header {
+		headers[key] = value
+	}
+	headers[headerNamespace] = ns
+	req.Header = headers
+	return nil
+}
@@ -0,0 +1,52 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Namespace(t *testing.T) {
+	var namespaces []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		namespaces = append(namespaces, r.Header.Get("X-Themis-Namespace"))
+		w.Write([]byte(`{}`))
+	})
+	ctx := context.Background()
+	var result map[string]interface{}
+
+	require.NoError(t, client.Get(ctx, "relational", "users", "1", &result))
+	require.NoError(t, client.Get(WithNamespace(ctx, "tenant-a"), "relational", "users", "1", &result))
+
+	tenant := client.Namespace("tenant-b")
+	require.NoError(t, tenant.Get(ctx, "relational", "users", "1", &result))
+	require.NoError(t, tenant.Get(WithNamespace(ctx, "tenant-c"), "relational", "users", "1", &result))
+	require.NoError(t, tenant.Namespace("tenant-d").Put(ctx, "relational", "users", "1", result))
+	require.NoError(t, tenant.Close())
+
+	assert.Equal(t, []string{"", "tenant-a", "tenant-b", "tenant-c", "tenant-d"}, namespaces)
+
+	// enums registered on the root client apply to derived clients
+	client.RegisterEnum("relational", "users", "status", "active")
+	err := tenant.Put(ctx, "relational", "users", "1", map[string]string{"status": "deleted"})
+	assert.ErrorIs(t, err, ErrInvalidEnumValue)
+}
+
+func TestClient_NamespaceConfig(t *testing.T) {
+	var namespace string
+	base := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		namespace = r.Header.Get("X-Themis-Namespace")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client := NewClient(Config{Endpoints: base.endpoints, Namespace: "tenant-a"})
+	ctx := context.Background()
+
+	require.NoError(t, client.Delete(ctx, "relational", "users", "1"))
+	assert.Equal(t, "tenant-a", namespace)
+
+	err := client.Delete(WithNamespace(ctx, "bad/namespace"), "relational", "users", "1")
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
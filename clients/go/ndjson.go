@@ -0,0 +1,179 @@
+package themisdb
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ImportConflict decides what Import does with documents whose UUID already exists
+type ImportConflict string
+
+const (
+	// ImportOverwrite replaces existing documents
+	ImportOverwrite ImportConflict = "overwrite"
+	// ImportSkip keeps existing documents and counts the imported ones as skipped
+	ImportSkip ImportConflict = "skip"
+	// ImportFail stops the import with ErrConflict; the failing batch is not written
+	ImportFail ImportConflict = "fail"
+)
+
+// ImportOptions configures Import
+type ImportOptions struct {
+	// BatchSize is the number of documents written per bulk request (default: 500)
+	BatchSize int
+	// OnConflict decides what happens to existing documents (default: ImportOverwrite)
+	OnConflict ImportConflict
+	// OnProgress, if set, is called after every batch
+	OnProgress func(ImportReport)
+}
+
+// ImportReport summarizes an import
+type ImportReport struct {
+	// Read counts the documents read from the input
+	Read int64
+	// Written counts the documents stored
+	Written int64
+	// Skipped counts the documents kept unchanged under ImportSkip
+	Skipped int64
+}
+
+// Export writes every document of a collection to w as newline-delimited JSON, one
+// {"uuid": ..., "document": ...} object per line in UUID order, and returns the number
+// of documents written. Its output is the input of Import.
+func (c *Client) Export(ctx context.Context, model, collection string, w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	var n int64
+	it := c.Scan(ctx, model, collection, ScanOptions{BatchSize: 1000})
+	for it.Next() {
+		if err := enc.Encode(ScanEntry{UUID: it.UUID(), Document: it.Document()}); err != nil {
+			return n, fmt.Errorf("failed to export %s/%s: %w", model, collection, err)
+		}
+		n++
+	}
+	if err := it.Err(); err != nil {
+		return n, fmt.Errorf("failed to export %s/%s: %w", model, collection, err)
+	}
+	if err := bw.Flush(); err != nil {
+		return n, fmt.Errorf("failed to export %s/%s: %w", model, collection, err)
+	}
+	return n, nil
+}
+
+// Import reads newline-delimited JSON as written by Export from r and stores the
+// documents in a collection with batched bulk writes. Blank lines are ignored.
+// Documents are checked against registered enums and schemas before their batch is
+// sent. Import stops at the first invalid line or failed batch; the report counts the
+// documents stored until then.
+func (c *Client) Import(ctx context.Context, model, collection string, r io.Reader, opts ImportOptions) (*ImportReport, error) {
+	if err := validateCollection(model, collection); err != nil {
+		return nil, err
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	if opts.OnConflict == "" {
+		opts.OnConflict = ImportOverwrite
+	}
+
+	report := &ImportReport{}
+	batch := make([]ScanEntry, 0, opts.BatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		written, skipped, err := c.bulkWrite(ctx, model, collection, batch, nil, opts.OnConflict)
+		if err != nil {
+			return err
+		}
+		report.Written += written
+		report.Skipped += skipped
+		batch = batch[:0]
+		if opts.OnProgress != nil {
+			opts.OnProgress(*report)
+		}
+		return nil
+	}
+
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return report, fmt.Errorf("failed to import %s/%s: %w", model, collection, err)
+		}
+		if data = bytes.TrimSpace(data); len(data) > 0 {
+			entry, parseErr := c.parseImportLine(model, collection, data)
+			if parseErr != nil {
+				return report, fmt.Errorf("failed to import %s/%s: line %d: %w", model, collection, line, parseErr)
+			}
+			report.Read++
+			batch = append(batch, entry)
+			if len(batch) == opts.BatchSize {
+				if err := flush(); err != nil {
+					return report, fmt.Errorf("failed to import %s/%s: %w", model, collection, err)
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	if err := flush(); err != nil {
+		return report, fmt.Errorf("failed to import %s/%s: %w", model, collection, err)
+	}
+	return report, nil
+}
+
+// parseImportLine decodes and validates a line of Import input
+func (c *Client) parseImportLine(model, collection string, data []byte) (ScanEntry, error) {
+	var entry ScanEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return entry, err
+	}
+	if err := validateKey("uuid", entry.UUID); err != nil {
+		return entry, err
+	}
+	if len(entry.Document) == 0 || bytes.Equal(entry.Document, []byte("null")) {
+		return entry, errors.New("document is missing")
+	}
+	if err := c.validateDocument(model, collection, entry.Document); err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// bulkWrite stores documents and removes the documents with the UUIDs deletes in a single
+// request and returns how many documents were written and skipped
+func (c *Client) bulkWrite(ctx context.Context, model, collection string, docs []ScanEntry, deletes []string, onConflict ImportConflict) (written, skipped int64, err error) {
+	path := joinPath("/api", model, collection) + "/_bulk"
+	body := map[string]interface{}{
+		"documents":   docs,
+		"on_conflict": onConflict,
+	}
+	if len(deletes) > 0 {
+		body["deletes"] = deletes
+	}
+
+	var response struct {
+		Written int64 `json:"written"`
+		Skipped int64 `json:"skipped"`
+	}
+	err = c.request(ctx, "POST", path, body, &response, nil)
+	if c.cache != nil {
+		for _, doc := range docs {
+			c.cache.invalidate(ctx, c, &Request{Path: entityPath(model, collection, doc.UUID)})
+		}
+		for _, uuid := range deletes {
+			c.cache.invalidate(ctx, c, &Request{Path: entityPath(model, collection, uuid)})
+		}
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("bulk write failed: %w", err)
+	}
+	return response.Written, response.Skipped, nil
+}
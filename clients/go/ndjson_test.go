@@ -0,0 +1,91 @@
+package themisdb
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ExportImport(t *testing.T) {
+	client, store := newMemoryClient(t)
+	ctx := context.Background()
+	for _, id := range []string{"u3", "u1", "u2", "u5", "u4"} {
+		require.NoError(t, client.Put(ctx, "relational", "users", id, map[string]string{"name": "user " + id}))
+	}
+
+	var buf bytes.Buffer
+	n, err := client.Export(ctx, "relational", "users", &buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 5)
+	assert.JSONEq(t, `{"uuid":"u1","document":{"name":"user u1"}}`, lines[0])
+
+	var progress []ImportReport
+	report, err := client.Import(ctx, "relational", "archive", &buf, ImportOptions{
+		BatchSize:  2,
+		OnProgress: func(r ImportReport) { progress = append(progress, r) },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &ImportReport{Read: 5, Written: 5}, report)
+	assert.Equal(t, []ImportReport{{Read: 2, Written: 2}, {Read: 4, Written: 4}, {Read: 5, Written: 5}}, progress)
+	assert.Equal(t, map[string]interface{}{"name": "user u4"}, store.docs["/api/relational/archive/u4"])
+}
+
+func TestClient_ImportConflicts(t *testing.T) {
+	client, store := newMemoryClient(t)
+	ctx := context.Background()
+	require.NoError(t, client.Put(ctx, "relational", "users", "u1", map[string]string{"name": "kept"}))
+	input := `{"uuid":"u1","document":{"name":"imported"}}` + "\n\n" + `{"uuid":"u2","document":{"name":"new"}}`
+
+	report, err := client.Import(ctx, "relational", "users", strings.NewReader(input), ImportOptions{OnConflict: ImportSkip})
+	require.NoError(t, err)
+	assert.Equal(t, &ImportReport{Read: 2, Written: 1, Skipped: 1}, report, "blank lines are ignored")
+	assert.Equal(t, "kept", store.docs["/api/relational/users/u1"]["name"])
+
+	_, err = client.Import(ctx, "relational", "users", strings.NewReader(input), ImportOptions{OnConflict: ImportFail})
+	assert.ErrorIs(t, err, ErrConflict)
+
+	report, err = client.Import(ctx, "relational", "users", strings.NewReader(input), ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, &ImportReport{Read: 2, Written: 2}, report)
+	assert.Equal(t, "imported", store.docs["/api/relational/users/u1"]["name"])
+}
+
+func TestClient_ImportInvalid(t *testing.T) {
+	client, store := newMemoryClient(t)
+	ctx := context.Background()
+	require.NoError(t, client.RegisterSchema("relational", "orders", map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"amount"},
+	}))
+
+	tests := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"malformed", `{"uuid":"o1","document":{"amount":1}}` + "\n" + `{"uuid":`, "line 2: unexpected end of JSON input"},
+		{"missing uuid", `{"document":{"amount":1}}`, "line 1: invalid uuid"},
+		{"missing document", `{"uuid":"o1"}`, "line 1: document is missing"},
+		{"schema", "\n" + `{"uuid":"o1","document":{"note":"x"}}`, "line 2: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := client.Import(ctx, "relational", "orders", strings.NewReader(tt.input), ImportOptions{})
+			require.Error(t, err)
+			assert.ErrorContains(t, err, tt.err)
+			assert.Zero(t, report.Written, "nothing is written before the invalid line's batch")
+		})
+	}
+	_, err := client.Import(ctx, "relational", "orders", strings.NewReader(`{"uuid":"o1","document":{}}`), ImportOptions{})
+	assert.ErrorIs(t, err, ErrSchemaViolation)
+	assert.Empty(t, store.docs)
+
+	_, err = client.Import(ctx, "relational", "bad/name", strings.NewReader(""), ImportOptions{})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
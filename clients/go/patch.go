@@ -0,0 +1,72 @@
+package themisdb
+
+import (
+	"context"
+)
+
+// Content types of partial updates sent by Patch
+const (
+	// ContentTypeJSONPatch marks an RFC 6902 JSON Patch body
+	ContentTypeJSONPatch = "application/json-patch+json"
+	// ContentTypeMergePatch marks an RFC 7386 JSON merge patch body
+	ContentTypeMergePatch = "application/merge-patch+json"
+)
+
+// PatchOperation is a single RFC 6902 JSON Patch operation
+type PatchOperation struct {
+	// Op is one of "add", "remove", "replace", "move", "copy", or "test"
+	Op string `json:"op"`
+	// Path is a JSON Pointer to the target location, e.g. "/address/city"
+	Path string `json:"path"`
+	// From is the source location of "move" and "copy"
+	From string `json:"from,omitempty"`
+	// Value is the operand of "add", "replace", and "test"
+	Value interface{} `json:"value,omitempty"`
+}
+
+// JSONPatch is an ordered list of operations applied atomically by the server
+type JSONPatch []PatchOperation
+
+// Patch partially updates an entity.
+// A JSONPatch (or []PatchOperation) is sent as RFC 6902 JSON Patch; any other value is
+// sent as an RFC 7386 merge patch, where fields set to null are removed from the document.
+func (c *Client) Patch(ctx context.Context, model, collection, uuid string, patch interface{}) error {
+	if ctx, tx := c.contextTx(ctx); tx != nil {
+		return tx.Patch(ctx, model, collection, uuid, patch)
+	}
+	return c.patch(ctx, model, collection, uuid, patch, nil)
+}
+
+// patch sends a partial update with the content type matching the patch format
+func (c *Client) patch(ctx context.Context, model, collection, uuid string, patch interface{}, headers map[string]string) error {
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return err
+	}
+
+	h := map[string]string{}
+	for key, value := range headers {
+		h[key] = value
+	}
+	switch patch.(type) {
+	case JSONPatch, []PatchOperation:
+		h["Content-Type"] = ContentTypeJSONPatch
+	default:
+		if err := c.validateEnums(model, collection, patch); err != nil {
+			return err
+		}
+		h["Content-Type"] = ContentTypeMergePatch
+	}
+
+	path := entityPath(model, collection, uuid)
+	return c.request(ctx, "PATCH", path, patch, nil, h)
+}
+
+// Patch partially updates an entity within the transaction
+func (tx *Transaction) Patch(ctx context.Context, model, collection, uuid string, patch interface{}) error {
+	ctx, headers, release, err := tx.acquire(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return tx.client.patch(ctx, model, collection, uuid, patch, headers)
+}
@@ -0,0 +1,49 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_PatchJSONPatch(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PATCH", r.Method)
+		assert.Equal(t, "/api/document/users/1", r.URL.Path)
+		assert.Equal(t, ContentTypeJSONPatch, r.Header.Get("Content-Type"))
+		var ops []map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&ops))
+		assert.Equal(t, []map[string]interface{}{
+			{"op": "replace", "path": "/email", "value": "alice@example.org"},
+			{"op": "remove", "path": "/phone"},
+		}, ops)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	err := client.Patch(context.Background(), "document", "users", "1", JSONPatch{
+		{Op: "replace", Path: "/email", Value: "alice@example.org"},
+		{Op: "remove", Path: "/phone"},
+	})
+	require.NoError(t, err)
+}
+
+func TestClient_PatchMergePatch(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, ContentTypeMergePatch, r.Header.Get("Content-Type"))
+		assert.Equal(t, "tx-1", r.Header.Get("X-Transaction-Id"))
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]interface{}{"status": "shipped", "note": nil}, body)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	client.RegisterEnum("relational", "orders", "status", "pending", "shipped")
+	ctx := context.Background()
+
+	tx := &Transaction{client: client, transactionID: "tx-1", active: true}
+	require.NoError(t, tx.Patch(ctx, "relational", "orders", "1", map[string]interface{}{"status": "shipped", "note": nil}))
+	assert.ErrorIs(t, tx.Patch(ctx, "relational", "orders", "1", map[string]interface{}{"status": "lost"}), ErrInvalidEnumValue)
+}
@@ -0,0 +1,301 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+// Collections used by the transactional pattern helpers
+const (
+	uniqueCollectionPrefix = "_unique_"
+	streamsCollection      = "_streams"
+	eventsCollection       = "_events"
+)
+
+// maxTransactionAttempts bounds the attempts of RunTransaction
+const maxTransactionAttempts = 5
+
+// RunTransaction runs fn in a snapshot transaction and commits it. If fn or the commit
+// fails with ErrConflict because a concurrent transaction wrote the same documents, the
+// transaction is rolled back and fn runs again in a new one, up to 5 times with
+// jittered backoff. fn must therefore only have effects through tx; any other error
+// rolls back and is returned as is. Commit errors other than conflicts are not retried,
+// as the commit may have been applied.
+func (c *Client) RunTransaction(ctx context.Context, fn func(ctx context.Context, tx *Transaction) error) error {
+	backoff := 10 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		err := c.runTransactionOnce(ctx, fn)
+		if err == nil || !errors.Is(err, ErrConflict) || attempt == maxTransactionAttempts {
+			return err
+		}
+		select {
+		case <-time.After(backoff + time.Duration(rand.Int63n(int64(backoff)))):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// runTransactionOnce runs fn in a new snapshot transaction and commits it
+func (c *Client) runTransactionOnce(ctx context.Context, fn func(ctx context.Context, tx *Transaction) error) error {
+	tx, err := c.BeginTransaction(ctx, &TransactionOptions{IsolationLevel: Snapshot})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if tx.IsActive() {
+			tx.Rollback(ctx)
+		}
+	}()
+	if err := fn(ctx, tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Transfer moves Amount from the balance of one document to that of another
+type Transfer struct {
+	Model      string
+	Collection string
+	From       string
+	To         string
+	// Field is the integer balance field of both documents (default: "balance")
+	Field string
+	// Amount is the positive amount moved, in the smallest unit such as cents
+	Amount int64
+	// AllowOverdraft lets the balance of From become negative
+	AllowOverdraft bool
+}
+
+// Transfer atomically subtracts t.Amount from the balance of t.From and adds it to
+// t.To, retrying on write conflicts with concurrent transfers. It fails with
+// ErrInsufficientFunds if the balance of From is too low and ErrNotFound if a document
+// does not exist; neither balance changes then.
+func (c *Client) Transfer(ctx context.Context, t Transfer) error {
+	if err := validateEntity(t.Model, t.Collection, t.From); err != nil {
+		return err
+	}
+	if err := validateKey("uuid", t.To); err != nil {
+		return err
+	}
+	if t.From == t.To {
+		return &ValidationError{Field: "transfer", Value: t.From, Reason: "source and destination must differ"}
+	}
+	if t.Amount <= 0 {
+		return &ValidationError{Field: "amount", Value: strconv.FormatInt(t.Amount, 10), Reason: "must be positive"}
+	}
+	if t.Field == "" {
+		t.Field = "balance"
+	}
+
+	err := c.RunTransaction(ctx, func(ctx context.Context, tx *Transaction) error {
+		from, err := readBalance(ctx, tx, t.Model, t.Collection, t.From, t.Field)
+		if err != nil {
+			return err
+		}
+		to, err := readBalance(ctx, tx, t.Model, t.Collection, t.To, t.Field)
+		if err != nil {
+			return err
+		}
+		if from < t.Amount && !t.AllowOverdraft {
+			return fmt.Errorf("%w: %s has %d, needs %d", ErrInsufficientFunds, t.From, from, t.Amount)
+		}
+		if err := tx.Patch(ctx, t.Model, t.Collection, t.From, map[string]int64{t.Field: from - t.Amount}); err != nil {
+			return err
+		}
+		return tx.Patch(ctx, t.Model, t.Collection, t.To, map[string]int64{t.Field: to + t.Amount})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to transfer %d from %s to %s: %w", t.Amount, t.From, t.To, err)
+	}
+	return nil
+}
+
+// readBalance reads the integer field of a document within tx; a missing field is zero
+func readBalance(ctx context.Context, tx *Transaction, model, collection, uuid, field string) (int64, error) {
+	var doc map[string]json.RawMessage
+	if err := tx.Get(ctx, model, collection, uuid, &doc); err != nil {
+		return 0, err
+	}
+	raw, ok := doc[field]
+	if !ok || string(raw) == "null" {
+		return 0, nil
+	}
+	var balance int64
+	if err := json.Unmarshal(raw, &balance); err != nil {
+		return 0, fmt.Errorf("%s of %s is not an integer: %s", field, uuid, raw)
+	}
+	return balance, nil
+}
+
+// uniqueClaim is the stored record of a claimed value
+type uniqueClaim struct {
+	Owner     string `json:"owner"`
+	ClaimedAt int64  `json:"claimed_at"`
+}
+
+// ClaimUnique reserves value within scope for owner, e.g. an email address for a user
+// ID, and runs fn in the same transaction, so the claim and the writes of fn take
+// effect together or not at all. fn may be nil. If value is already claimed by another
+// owner it fails with ErrAlreadyExists; claiming it again for the same owner succeeds
+// without running fn, so a retried request is idempotent. Claims are stored in the
+// relational collection "_unique_<scope>".
+func (c *Client) ClaimUnique(ctx context.Context, scope, value, owner string, fn func(ctx context.Context, tx *Transaction) error) error {
+	collection := uniqueCollectionPrefix + scope
+	if err := validateEntity(ModelRelational, collection, value); err != nil {
+		return err
+	}
+	if err := validateKey("owner", owner); err != nil {
+		return err
+	}
+
+	err := c.RunTransaction(ctx, func(ctx context.Context, tx *Transaction) error {
+		err := tx.Create(ctx, ModelRelational, collection, value, uniqueClaim{Owner: owner, ClaimedAt: time.Now().UnixMilli()})
+		if errors.Is(err, ErrAlreadyExists) {
+			var claim uniqueClaim
+			err := tx.Get(ctx, ModelRelational, collection, value, &claim)
+			if errors.Is(err, ErrNotFound) {
+				// claimed by a concurrent transaction that has not committed yet
+				return fmt.Errorf("%w: concurrent claim", ErrConflict)
+			}
+			if err != nil {
+				return err
+			}
+			if claim.Owner == owner {
+				return nil
+			}
+			return fmt.Errorf("%w: claimed by %s", ErrAlreadyExists, claim.Owner)
+		}
+		if err != nil || fn == nil {
+			return err
+		}
+		return fn(ctx, tx)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to claim %s %q: %w", scope, value, err)
+	}
+	return nil
+}
+
+// ReleaseUnique releases a value claimed by owner. Releasing a value that is not claimed
+// succeeds; releasing one claimed by another owner fails with ErrPermissionDenied.
+func (c *Client) ReleaseUnique(ctx context.Context, scope, value, owner string) error {
+	collection := uniqueCollectionPrefix + scope
+	if err := validateEntity(ModelRelational, collection, value); err != nil {
+		return err
+	}
+
+	err := c.RunTransaction(ctx, func(ctx context.Context, tx *Transaction) error {
+		var claim uniqueClaim
+		err := tx.Get(ctx, ModelRelational, collection, value, &claim)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if claim.Owner != owner {
+			return fmt.Errorf("%w: claimed by %s", ErrPermissionDenied, claim.Owner)
+		}
+		return tx.Delete(ctx, ModelRelational, collection, value)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release %s %q: %w", scope, value, err)
+	}
+	return nil
+}
+
+// StoredEvent is an event of a stream
+type StoredEvent struct {
+	Stream  string `json:"stream"`
+	Version int64  `json:"version"`
+	// Time is when the event was appended, in Unix milliseconds
+	Time int64           `json:"time"`
+	Data json.RawMessage `json:"data"`
+}
+
+// streamHead is the stored version of a stream
+type streamHead struct {
+	Version int64 `json:"version"`
+}
+
+// AppendEvent appends event to stream if the stream is at expectedVersion, 0 for a new
+// stream, and returns the new version. It fails with ErrWrongExpectedVersion if
+// another writer appended first; the caller should then reload the stream and decide
+// again. Write conflicts are retried, re-checking the version each time. Events are
+// stored in the relational collection "_events", versions in "_streams".
+func (c *Client) AppendEvent(ctx context.Context, stream string, expectedVersion int64, event interface{}) (int64, error) {
+	if err := validateKey("stream", stream); err != nil {
+		return 0, err
+	}
+	if expectedVersion < 0 {
+		return 0, &ValidationError{Field: "expected version", Value: strconv.FormatInt(expectedVersion, 10), Reason: "must not be negative"}
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	version := expectedVersion + 1
+	err = c.RunTransaction(ctx, func(ctx context.Context, tx *Transaction) error {
+		var head streamHead
+		if err := tx.Get(ctx, ModelRelational, streamsCollection, stream, &head); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		if head.Version != expectedVersion {
+			return fmt.Errorf("%w: stream is at version %d, expected %d", ErrWrongExpectedVersion, head.Version, expectedVersion)
+		}
+		stored := StoredEvent{Stream: stream, Version: version, Time: time.Now().UnixMilli(), Data: data}
+		if err := tx.Create(ctx, ModelRelational, eventsCollection, eventKey(stream, version), stored); err != nil {
+			if errors.Is(err, ErrAlreadyExists) {
+				return fmt.Errorf("%w: version %d exists", ErrConflict, version)
+			}
+			return err
+		}
+		return tx.Put(ctx, ModelRelational, streamsCollection, stream, streamHead{Version: version})
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to append to stream %s: %w", stream, err)
+	}
+	return version, nil
+}
+
+// ReadEvents returns the events of stream after version afterVersion in version order
+func (c *Client) ReadEvents(ctx context.Context, stream string, afterVersion int64) ([]StoredEvent, error) {
+	if err := validateKey("stream", stream); err != nil {
+		return nil, err
+	}
+	opts := ScanOptions{Prefix: stream + "/"}
+	if afterVersion > 0 {
+		opts.StartAfter = eventKey(stream, afterVersion)
+	}
+
+	var events []StoredEvent
+	it := c.Scan(ctx, ModelRelational, eventsCollection, opts)
+	for it.Next() {
+		var event StoredEvent
+		if err := it.Decode(&event); err != nil {
+			return nil, fmt.Errorf("failed to decode event %s: %w", it.UUID(), err)
+		}
+		if event.Stream != stream {
+			// an event of a stream whose name starts with stream + "/"
+			continue
+		}
+		events = append(events, event)
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stream %s: %w", stream, err)
+	}
+	return events, nil
+}
+
+// eventKey returns the key of an event; versions are zero-padded so keys sort by version
+func eventKey(stream string, version int64) string {
+	return fmt.Sprintf("%s/%019d", stream, version)
+}
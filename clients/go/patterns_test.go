@@ -0,0 +1,138 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newConflictClient returns a memory-backed client whose server answers the first n
+// requests matching method and path suffix with 409 Conflict
+func newConflictClient(t *testing.T, method, suffix string, n int32) (*Client, *memoryStore) {
+	store := &memoryStore{docs: map[string]map[string]interface{}{}, revisions: map[string]int{}}
+	var conflicts int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == method && strings.HasSuffix(r.URL.Path, suffix) && atomic.AddInt32(&conflicts, 1) <= n {
+			http.Error(w, "write conflict", http.StatusConflict)
+			return
+		}
+		store.serveHTTP(w, r)
+	})
+	return client, store
+}
+
+func TestClient_RunTransaction_Retry(t *testing.T) {
+	client, store := newConflictClient(t, "POST", "/transaction/commit", 2)
+	ctx := context.Background()
+
+	attempts := 0
+	err := client.RunTransaction(ctx, func(ctx context.Context, tx *Transaction) error {
+		attempts++
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 1, store.commits)
+	assert.Equal(t, 2, store.rollbacks)
+
+	client, _ = newConflictClient(t, "POST", "/transaction/commit", 100)
+	attempts = 0
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *Transaction) error {
+		attempts++
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, maxTransactionAttempts, attempts)
+}
+
+func TestClient_Transfer(t *testing.T) {
+	client, store := newConflictClient(t, "PATCH", "/accounts/alice", 1)
+	ctx := context.Background()
+	require.NoError(t, client.Put(ctx, "relational", "accounts", "alice", map[string]interface{}{"owner": "Alice", "balance": 100}))
+	require.NoError(t, client.Put(ctx, "relational", "accounts", "bob", map[string]interface{}{"owner": "Bob"}))
+
+	require.NoError(t, client.Transfer(ctx, Transfer{Model: "relational", Collection: "accounts", From: "alice", To: "bob", Amount: 30}))
+	assert.Equal(t, float64(70), store.docs["/api/relational/accounts/alice"]["balance"])
+	assert.Equal(t, float64(30), store.docs["/api/relational/accounts/bob"]["balance"])
+	assert.Equal(t, "Alice", store.docs["/api/relational/accounts/alice"]["owner"])
+
+	err := client.Transfer(ctx, Transfer{Model: "relational", Collection: "accounts", From: "bob", To: "alice", Amount: 31})
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+	assert.Equal(t, float64(30), store.docs["/api/relational/accounts/bob"]["balance"])
+
+	require.NoError(t, client.Transfer(ctx, Transfer{Model: "relational", Collection: "accounts", From: "bob", To: "alice", Amount: 31, AllowOverdraft: true}))
+	assert.Equal(t, float64(-1), store.docs["/api/relational/accounts/bob"]["balance"])
+
+	err = client.Transfer(ctx, Transfer{Model: "relational", Collection: "accounts", From: "alice", To: "carol", Amount: 1})
+	assert.ErrorIs(t, err, ErrNotFound)
+	err = client.Transfer(ctx, Transfer{Model: "relational", Collection: "accounts", From: "alice", To: "alice", Amount: 1})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	err = client.Transfer(ctx, Transfer{Model: "relational", Collection: "accounts", From: "alice", To: "bob"})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestClient_ClaimUnique(t *testing.T) {
+	client, store := newMemoryClient(t)
+	ctx := context.Background()
+
+	calls := 0
+	createUser := func(ctx context.Context, tx *Transaction) error {
+		calls++
+		return tx.Put(ctx, "relational", "users", "u1", map[string]string{"email": "a@example.com"})
+	}
+	require.NoError(t, client.ClaimUnique(ctx, "email", "a@example.com", "u1", createUser))
+	assert.Equal(t, "u1", store.docs["/api/relational/_unique_email/a@example.com"]["owner"])
+	assert.Contains(t, store.docs, "/api/relational/users/u1")
+
+	require.NoError(t, client.ClaimUnique(ctx, "email", "a@example.com", "u1", createUser), "claiming again is idempotent")
+	assert.Equal(t, 1, calls)
+
+	err := client.ClaimUnique(ctx, "email", "a@example.com", "u2", nil)
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+
+	assert.ErrorIs(t, client.ReleaseUnique(ctx, "email", "a@example.com", "u2"), ErrPermissionDenied)
+	require.NoError(t, client.ReleaseUnique(ctx, "email", "a@example.com", "u1"))
+	require.NoError(t, client.ReleaseUnique(ctx, "email", "a@example.com", "u1"))
+	require.NoError(t, client.ClaimUnique(ctx, "email", "a@example.com", "u2", nil))
+}
+
+func TestClient_AppendEvent(t *testing.T) {
+	// a concurrent writer creates the first event between our version check and write
+	client, _ := newConflictClient(t, "PUT", "/order-1/0000000000000000001", 1)
+	ctx := context.Background()
+
+	version, err := client.AppendEvent(ctx, "order-1", 0, map[string]string{"type": "created"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), version)
+	version, err = client.AppendEvent(ctx, "order-1", 1, map[string]string{"type": "paid"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), version)
+	_, err = client.AppendEvent(ctx, "order-10", 0, map[string]string{"type": "created"})
+	require.NoError(t, err)
+	_, err = client.AppendEvent(ctx, "order-1/eu", 0, map[string]string{"type": "created"})
+	require.NoError(t, err)
+
+	_, err = client.AppendEvent(ctx, "order-1", 1, map[string]string{"type": "cancelled"})
+	assert.ErrorIs(t, err, ErrWrongExpectedVersion)
+
+	events, err := client.ReadEvents(ctx, "order-1", 0)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, int64(1), events[0].Version)
+	assert.JSONEq(t, `{"type":"paid"}`, string(events[1].Data))
+
+	events, err = client.ReadEvents(ctx, "order-1", 1)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, int64(2), events[0].Version)
+
+	events, err = client.ReadEvents(ctx, "order-1/eu", 0)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "order-1/eu", events[0].Stream)
+}
@@ -0,0 +1,145 @@
+package themisdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// healthPath is the endpoint answering liveness checks
+const healthPath = "/health"
+
+// endpointKey is the context key of requests sent to one endpoint, bypassing replica
+// routing, hedging, and load balancing
+type endpointKey struct{}
+
+// withEndpoint returns a context whose requests are sent to endpoint
+func withEndpoint(ctx context.Context, endpoint string) context.Context {
+	return context.WithValue(ctx, endpointKey{}, endpoint)
+}
+
+// Ping checks that the server behind the active endpoint is reachable and healthy
+func (c *Client) Ping(ctx context.Context) error {
+	if err := c.request(ctx, "GET", healthPath, nil, nil, nil); err != nil {
+		return fmt.Errorf("ping failed: %w", err)
+	}
+	return nil
+}
+
+// EndpointCheck is the outcome of verifying one endpoint
+type EndpointCheck struct {
+	Endpoint string
+	// Replica is set for the endpoints of Config.Replicas
+	Replica bool
+	// Reachable reports that the endpoint answered the health check
+	Reachable bool
+	// Authenticated reports that the endpoint accepted the credentials of the client
+	Authenticated bool
+	// Compatible reports that the server is at least Config.MinServerVersion
+	Compatible bool
+	// Latency is the round trip time of the health check
+	Latency time.Duration
+	// Info is the server info, nil if the endpoint did not provide it
+	Info *ServerInfo
+	// Err is the first problem found, nil if the endpoint passed every check
+	Err error
+}
+
+// EndpointReport is the outcome of VerifyEndpoints
+type EndpointReport struct {
+	Endpoints []EndpointCheck
+}
+
+// Err returns the problems of all failed endpoints joined, nil if every endpoint
+// passed
+func (r *EndpointReport) Err() error {
+	var errs []error
+	for _, check := range r.Endpoints {
+		if check.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", check.Endpoint, check.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// VerifyEndpoints checks every endpoint and replica of the client concurrently, so
+// that misconfiguration fails at startup rather than on the first real request. Each
+// endpoint must answer the health check, accept the credentials added by the
+// interceptors of the client, and run at least Config.MinServerVersion. The report
+// lists every endpoint; the error, if not nil, is the joined error of the failed ones.
+//
+//	report, err := client.VerifyEndpoints(ctx)
+//	if err != nil {
+//		log.Fatalf("ThemisDB misconfigured: %v", err)
+//	}
+func (c *Client) VerifyEndpoints(ctx context.Context) (*EndpointReport, error) {
+	root := c
+	if c.root != nil {
+		root = c.root
+	}
+	root.mu.RLock()
+	endpoints := append([]string(nil), root.endpoints...)
+	replicas := append([]string(nil), root.replicas...)
+	root.mu.RUnlock()
+
+	report := &EndpointReport{Endpoints: make([]EndpointCheck, 0, len(endpoints)+len(replicas))}
+	for _, endpoint := range endpoints {
+		report.Endpoints = append(report.Endpoints, EndpointCheck{Endpoint: strings.TrimSuffix(endpoint, "/")})
+	}
+	for _, replica := range replicas {
+		report.Endpoints = append(report.Endpoints, EndpointCheck{Endpoint: strings.TrimSuffix(replica, "/"), Replica: true})
+	}
+
+	var wg sync.WaitGroup
+	for i := range report.Endpoints {
+		wg.Add(1)
+		go func(check *EndpointCheck) {
+			defer wg.Done()
+			c.verifyEndpoint(withEndpoint(ctx, check.Endpoint), check, root.minServerVersion)
+		}(&report.Endpoints[i])
+	}
+	wg.Wait()
+	return report, report.Err()
+}
+
+// verifyEndpoint runs the checks of one endpoint, stopping at the first failure
+func (c *Client) verifyEndpoint(ctx context.Context, check *EndpointCheck, minVersion string) {
+	start := time.Now()
+	err := c.request(ctx, "GET", healthPath, nil, nil, nil)
+	check.Latency = time.Since(start)
+	if err != nil && !errors.Is(err, ErrUnauthenticated) && !errors.Is(err, ErrPermissionDenied) {
+		check.Err = fmt.Errorf("unreachable: %w", err)
+		return
+	}
+	check.Reachable = true
+
+	var info ServerInfo
+	err = c.request(ctx, "GET", serverInfoPath, nil, &info, nil)
+	switch {
+	case errors.Is(err, ErrUnauthenticated) || errors.Is(err, ErrPermissionDenied):
+		check.Err = fmt.Errorf("credentials rejected: %w", err)
+		return
+	case errors.Is(err, ErrNotFound):
+		// the server predates ServerInfo
+	case err != nil:
+		check.Err = fmt.Errorf("failed to get server info: %w", err)
+		return
+	default:
+		check.Info = &info
+	}
+	check.Authenticated = true
+
+	switch {
+	case minVersion == "":
+	case check.Info == nil:
+		check.Err = fmt.Errorf("%w: server does not report its version, requires %s", ErrVersionMismatch, minVersion)
+		return
+	case !check.Info.AtLeast(minVersion):
+		check.Err = fmt.Errorf("%w: server runs %s, requires %s", ErrVersionMismatch, check.Info.Version, minVersion)
+		return
+	}
+	check.Compatible = true
+}
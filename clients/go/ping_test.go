@@ -0,0 +1,110 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// infoServer answers health checks and serves the info of a server version, if the
+// request carries the expected token
+func infoServer(t *testing.T, version, token string) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Write([]byte(`{"status": "ok"}`))
+		case "/info":
+			if r.Header.Get("Authorization") != "Bearer "+token {
+				http.Error(w, "invalid token", http.StatusUnauthorized)
+				return
+			}
+			if version == "" {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(`{"version": "` + version + `"}`))
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestClient_Ping(t *testing.T) {
+	client := NewClient(Config{Endpoints: []string{infoServer(t, "2.4.0", "secret").URL}})
+	require.NoError(t, client.Ping(context.Background()))
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	client = NewClient(Config{Endpoints: []string{down.URL}, MaxRetries: 1})
+	assert.ErrorContains(t, client.Ping(context.Background()), "ping failed")
+}
+
+func TestClient_VerifyEndpoints(t *testing.T) {
+	good := infoServer(t, "2.4.0", "secret")
+	old := infoServer(t, "2.3.9", "secret")
+	rotated := infoServer(t, "2.4.0", "other")
+	unversioned := infoServer(t, "", "secret")
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	auth := func(ctx context.Context, req *Request, next Handler) (*Response, error) {
+		headers := map[string]string{"Authorization": "Bearer secret"}
+		for key, value := range req.Header {
+			headers[key] = value
+		}
+		req.Header = headers
+		return next(ctx, req)
+	}
+	client := NewClient(Config{
+		Endpoints:        []string{good.URL + "/", old.URL, rotated.URL, down.URL},
+		Replicas:         []string{unversioned.URL},
+		Interceptors:     []Interceptor{auth},
+		MaxRetries:       1,
+		MinServerVersion: "2.4",
+	})
+	defer client.Close()
+
+	report, err := client.Namespace("tenant-a").VerifyEndpoints(context.Background())
+	require.Error(t, err)
+	require.Len(t, report.Endpoints, 5)
+
+	ok := report.Endpoints[0]
+	assert.Equal(t, good.URL, ok.Endpoint)
+	assert.True(t, ok.Reachable && ok.Authenticated && ok.Compatible)
+	assert.NoError(t, ok.Err)
+	assert.Equal(t, "2.4.0", ok.Info.Version)
+	assert.Positive(t, ok.Latency)
+
+	assert.True(t, report.Endpoints[1].Authenticated)
+	assert.False(t, report.Endpoints[1].Compatible)
+	assert.ErrorIs(t, report.Endpoints[1].Err, ErrVersionMismatch)
+
+	assert.True(t, report.Endpoints[2].Reachable)
+	assert.False(t, report.Endpoints[2].Authenticated)
+	assert.ErrorIs(t, report.Endpoints[2].Err, ErrUnauthenticated)
+
+	assert.False(t, report.Endpoints[3].Reachable)
+	assert.ErrorContains(t, report.Endpoints[3].Err, "unreachable")
+
+	replica := report.Endpoints[4]
+	assert.True(t, replica.Replica)
+	assert.True(t, replica.Authenticated)
+	assert.Nil(t, replica.Info)
+	assert.ErrorIs(t, replica.Err, ErrVersionMismatch)
+
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+	assert.ErrorContains(t, err, down.URL+": unreachable")
+
+	client = NewClient(Config{Endpoints: []string{good.URL}, Replicas: []string{unversioned.URL}, Interceptors: []Interceptor{auth}})
+	defer client.Close()
+	report, err = client.VerifyEndpoints(context.Background())
+	require.NoError(t, err)
+	assert.True(t, report.Endpoints[1].Compatible, "any version is accepted without MinServerVersion")
+}
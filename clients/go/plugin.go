@@ -0,0 +1,82 @@
+package themisdb
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// PluginFactory builds a sub-client for a custom server model or extension.
+// The sub-client talks to the server through c.Do.
+type PluginFactory func(c *Client) interface{}
+
+// pluginRegistry holds the factories registered with RegisterPlugin
+var pluginRegistry = struct {
+	mu        sync.RWMutex
+	factories map[string]PluginFactory
+}{factories: make(map[string]PluginFactory)}
+
+// pluginCache holds the sub-clients a Client has built from registered factories
+type pluginCache struct {
+	mu        sync.Mutex
+	instances map[string]interface{}
+}
+
+// RegisterPlugin makes a sub-client factory available under name, typically from an
+// init function of the extension package. It panics if name is registered twice or
+// factory is nil.
+func RegisterPlugin(name string, factory PluginFactory) {
+	pluginRegistry.mu.Lock()
+	defer pluginRegistry.mu.Unlock()
+
+	if factory == nil {
+		panic("themisdb: RegisterPlugin factory is nil")
+	}
+	if _, dup := pluginRegistry.factories[name]; dup {
+		panic("themisdb: RegisterPlugin called twice for plugin " + name)
+	}
+	pluginRegistry.factories[name] = factory
+}
+
+// Plugins returns the sorted names of the registered plugins
+func Plugins() []string {
+	pluginRegistry.mu.RLock()
+	defer pluginRegistry.mu.RUnlock()
+
+	names := make([]string, 0, len(pluginRegistry.factories))
+	for name := range pluginRegistry.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Plugin returns the sub-client registered under name, building it on first use.
+// Extension packages usually wrap this in a typed accessor:
+//
+//	func Geo(c *themisdb.Client) *GeoClient {
+//		p, _ := c.Plugin("geo")
+//		return p.(*GeoClient)
+//	}
+func (c *Client) Plugin(name string) (interface{}, error) {
+	c.plugins.mu.Lock()
+	defer c.plugins.mu.Unlock()
+
+	if p, ok := c.plugins.instances[name]; ok {
+		return p, nil
+	}
+
+	pluginRegistry.mu.RLock()
+	factory, ok := pluginRegistry.factories[name]
+	pluginRegistry.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPlugin, name)
+	}
+
+	if c.plugins.instances == nil {
+		c.plugins.instances = make(map[string]interface{})
+	}
+	p := factory(c)
+	c.plugins.instances[name] = p
+	return p, nil
+}
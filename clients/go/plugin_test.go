@@ -0,0 +1,65 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type echoPlugin struct {
+	client *Client
+}
+
+func (p *echoPlugin) Echo(ctx context.Context, msg string) (string, error) {
+	resp, err := p.client.Do(ctx, &Request{Method: "POST", Path: "/ext/echo", Body: []byte(msg)})
+	if err != nil {
+		return "", err
+	}
+	return string(resp.Body), nil
+}
+
+func init() {
+	RegisterPlugin("test-echo", func(c *Client) interface{} {
+		return &echoPlugin{client: c}
+	})
+}
+
+func TestClient_Plugin(t *testing.T) {
+	assert.Contains(t, Plugins(), "test-echo")
+	assert.Panics(t, func() {
+		RegisterPlugin("test-echo", func(c *Client) interface{} { return nil })
+	})
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/ext/echo", r.URL.Path)
+		w.Write([]byte("hello"))
+	})
+
+	p, err := client.Plugin("test-echo")
+	require.NoError(t, err)
+	again, err := client.Plugin("test-echo")
+	require.NoError(t, err)
+	assert.Same(t, p, again)
+
+	msg, err := p.(*echoPlugin).Echo(context.Background(), "hello")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", msg)
+
+	_, err = client.Plugin("missing")
+	assert.ErrorIs(t, err, ErrUnknownPlugin)
+}
+
+func TestClient_DoErrorStatus(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte("conflict"))
+	})
+
+	resp, err := client.Do(context.Background(), &Request{Method: "GET", Path: "/ext/thing"})
+	assert.EqualError(t, err, "request failed with status 409: conflict")
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
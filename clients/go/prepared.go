@@ -0,0 +1,123 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// preparedQueries holds the server handles of a Client's prepared queries by name
+type preparedQueries struct {
+	mu      sync.RWMutex
+	queries map[string]*preparedQuery
+}
+
+// preparedQuery is a query prepared on the server
+type preparedQuery struct {
+	aql    string
+	handle string
+}
+
+// Prepare parses and plans aql once on the server and stores the returned handle under
+// name, so ExecutePrepared only sends bind parameters. Preparing a name again replaces
+// its query; if the query differs only in formatting, keyword case, or comments, the
+// handle is kept without asking the server.
+func (c *Client) Prepare(ctx context.Context, name, aql string) error {
+	if err := validateName("prepared query", name); err != nil {
+		return err
+	}
+	if aql == "" {
+		return &ValidationError{Field: "query", Value: name, Reason: "must not be empty"}
+	}
+	c.prepared.mu.RLock()
+	query, ok := c.prepared.queries[name]
+	c.prepared.mu.RUnlock()
+	if ok && compactAQL(query.aql) == compactAQL(aql) {
+		return nil
+	}
+	handle, err := c.prepare(ctx, aql)
+	if err != nil {
+		return fmt.Errorf("failed to prepare query %s: %w", name, err)
+	}
+
+	c.prepared.mu.Lock()
+	defer c.prepared.mu.Unlock()
+	if c.prepared.queries == nil {
+		c.prepared.queries = make(map[string]*preparedQuery)
+	}
+	c.prepared.queries[name] = &preparedQuery{aql: aql, handle: handle}
+	return nil
+}
+
+// ExecutePrepared executes the query prepared under name with params as bind variables
+// and decodes its data into result. If the server no longer knows the handle, e.g.
+// after a restart, the query is prepared again transparently and retried once. It fails
+// with ErrNotPrepared if name was not prepared with this client.
+func (c *Client) ExecutePrepared(ctx context.Context, name string, params map[string]interface{}, result interface{}) error {
+	c.prepared.mu.RLock()
+	query, ok := c.prepared.queries[name]
+	c.prepared.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotPrepared, name)
+	}
+
+	err := c.executePrepared(ctx, query.aql, query.handle, params, result)
+	if !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	handle, err := c.prepare(ctx, query.aql)
+	if err != nil {
+		return fmt.Errorf("failed to prepare query %s again: %w", name, err)
+	}
+	c.prepared.mu.Lock()
+	if c.prepared.queries[name] == query {
+		c.prepared.queries[name] = &preparedQuery{aql: query.aql, handle: handle}
+	}
+	c.prepared.mu.Unlock()
+	return c.executePrepared(ctx, query.aql, handle, params, result)
+}
+
+// prepare registers aql on the server and returns its handle
+func (c *Client) prepare(ctx context.Context, aql string) (string, error) {
+	var response struct {
+		Handle string `json:"handle"`
+	}
+	if err := c.readRequest(ctx, "POST", "/api/query/prepare", map[string]interface{}{"query": aql}, &response, nil); err != nil {
+		return "", err
+	}
+	if response.Handle == "" {
+		return "", fmt.Errorf("server returned no handle")
+	}
+	return response.Handle, nil
+}
+
+// executePrepared executes the handle of the prepared query aql and decodes its data
+// into result; like a query, it is only hedged if aql is read-only
+func (c *Client) executePrepared(ctx context.Context, aql, handle string, params map[string]interface{}, result interface{}) error {
+	body := map[string]interface{}{"handle": handle}
+	if len(params) > 0 {
+		body["bind_vars"] = params
+	}
+	var response struct {
+		Data json.RawMessage `json:"data"`
+	}
+	req, err := c.newRequest("POST", "/api/query/execute", body, nil)
+	if err != nil {
+		return err
+	}
+	req.ReadOnly = readOnlyAQL(aql)
+	req.Idempotent = req.ReadOnly
+	if err := c.doDecode(ctx, req, &response); err != nil {
+		return err
+	}
+	if result == nil || len(response.Data) == 0 {
+		return nil
+	}
+	if err := c.decode(response.Data, result); err != nil {
+		return fmt.Errorf("failed to unmarshal query result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// preparedServer prepares queries and answers executions with the bind variables it received
+type preparedServer struct {
+	mu       sync.Mutex
+	handles  map[string]string
+	prepares int
+}
+
+func (s *preparedServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Query    string                 `json:"query"`
+		Handle   string                 `json:"handle"`
+		BindVars map[string]interface{} `json:"bind_vars"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/api/query/prepare":
+		s.prepares++
+		handle := fmt.Sprintf("h%d", s.prepares)
+		s.handles[handle] = body.Query
+		json.NewEncoder(w).Encode(map[string]string{"handle": handle})
+	case "/api/query/execute":
+		if _, ok := s.handles[body.Handle]; !ok {
+			http.Error(w, "unknown handle", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": []interface{}{body.BindVars}})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// restart forgets all prepared handles
+func (s *preparedServer) restart() {
+	s.mu.Lock()
+	s.handles = map[string]string{}
+	s.mu.Unlock()
+}
+
+func TestClient_ExecutePrepared(t *testing.T) {
+	server := &preparedServer{handles: map[string]string{}}
+	client := newTestClient(t, server.serveHTTP)
+	ctx := context.Background()
+
+	require.NoError(t, client.Prepare(ctx, "user_by_email", "FOR u IN users FILTER u.email == @email RETURN u"))
+	assert.Equal(t, "FOR u IN users FILTER u.email == @email RETURN u", server.handles["h1"])
+
+	var rows []map[string]string
+	require.NoError(t, client.ExecutePrepared(ctx, "user_by_email", map[string]interface{}{"email": "a@example.com"}, &rows))
+	assert.Equal(t, []map[string]string{{"email": "a@example.com"}}, rows)
+	assert.Equal(t, 1, server.prepares)
+
+	server.restart()
+	require.NoError(t, client.ExecutePrepared(ctx, "user_by_email", map[string]interface{}{"email": "b@example.com"}, &rows))
+	assert.Equal(t, []map[string]string{{"email": "b@example.com"}}, rows)
+	assert.Equal(t, 2, server.prepares, "query is prepared again after a restart")
+
+	require.NoError(t, client.ExecutePrepared(ctx, "user_by_email", map[string]interface{}{"email": "c@example.com"}, &rows))
+	assert.Equal(t, 2, server.prepares, "new handle is cached")
+
+	require.NoError(t, client.Prepare(ctx, "user_by_email", "for u in users\n  filter u.email == @email // by email\n  return u"))
+	assert.Equal(t, 2, server.prepares, "an equivalent query keeps its handle")
+	require.NoError(t, client.Prepare(ctx, "user_by_email", "FOR u IN users FILTER u.mail == @email RETURN u"))
+	assert.Equal(t, 3, server.prepares)
+}
+
+func TestClient_ExecutePrepared_Errors(t *testing.T) {
+	server := &preparedServer{handles: map[string]string{}}
+	client := newTestClient(t, server.serveHTTP)
+	ctx := context.Background()
+
+	err := client.ExecutePrepared(ctx, "missing", nil, nil)
+	assert.ErrorIs(t, err, ErrNotPrepared)
+
+	assert.ErrorIs(t, client.Prepare(ctx, "", "RETURN 1"), ErrInvalidInput)
+	assert.ErrorIs(t, client.Prepare(ctx, "empty", ""), ErrInvalidInput)
+
+	// namespaced clients keep their own handles
+	require.NoError(t, client.Prepare(ctx, "one", "RETURN 1"))
+	assert.ErrorIs(t, client.Namespace("tenant").ExecutePrepared(ctx, "one", nil, nil), ErrNotPrepared)
+}
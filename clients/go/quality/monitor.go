@@ -0,0 +1,54 @@
+package quality
+
+import (
+	"context"
+	"time"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+// Monitor evaluates rules periodically
+type Monitor struct {
+	// Client runs the rule queries
+	Client themisdb.ThemisClient
+	Rules  []Rule
+	// Interval is the time between checks (default: 5m)
+	Interval time.Duration
+	// Sink receives every report, e.g. to export Result.Score, Failed, and Newest as
+	// metrics
+	Sink func(*Report)
+	// OnViolation, if set, is called for every rule that fails or cannot be evaluated
+	OnViolation func(Result)
+}
+
+// Run checks the rules immediately and then every Interval until ctx is done, and
+// returns ctx.Err()
+func (m *Monitor) Run(ctx context.Context) error {
+	interval := m.Interval
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report := Check(ctx, m.Client, m.Rules...)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if m.Sink != nil {
+			m.Sink(report)
+		}
+		if m.OnViolation != nil {
+			for _, violation := range report.Violations() {
+				m.OnViolation(violation)
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
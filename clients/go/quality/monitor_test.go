@@ -0,0 +1,48 @@
+package quality
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/makr-code/ThemisDB/clients/go/themistest"
+)
+
+func TestMonitor_Run(t *testing.T) {
+	client, fake := themistest.NewClient(t)
+	rule := Completeness("customers", "email", 1)
+	var mu sync.Mutex
+	failed := 0
+	fake.HandleQuery(rule.Query(), func(map[string]interface{}) (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed++
+		return []interface{}{map[string]interface{}{"total": 10, "failed": failed - 1}}, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var reports []*Report
+	var violations []Result
+	m := &Monitor{
+		Client:   client,
+		Rules:    []Rule{rule},
+		Interval: 5 * time.Millisecond,
+		Sink: func(r *Report) {
+			reports = append(reports, r)
+			if len(reports) == 3 {
+				cancel()
+			}
+		},
+		OnViolation: func(r Result) { violations = append(violations, r) },
+	}
+	assert.ErrorIs(t, m.Run(ctx), context.Canceled)
+
+	assert.Len(t, reports, 3)
+	assert.True(t, reports[0].Passed())
+	assert.Len(t, violations, 2, "later checks find missing emails")
+	assert.Equal(t, int64(2), violations[1].Failed)
+	assert.Equal(t, 0.8, violations[1].Score)
+}
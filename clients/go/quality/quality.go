@@ -0,0 +1,291 @@
+// Package quality declares data-quality rules for ThemisDB collections and evaluates
+// them with server-side AQL aggregates, so data-quality monitoring needs no separate
+// pipeline.
+//
+// A Rule checks one property of a collection: that a field is present (Completeness),
+// unique (Unique), refers to existing documents (References), or has recent values
+// (Fresh). Check evaluates rules once and returns a Report; a Monitor evaluates them
+// periodically and passes every Report to a sink, e.g. to export the scores as metrics
+// or to alert on violations.
+//
+//	rules := []quality.Rule{
+//		quality.Completeness("customers", "email", 0.99),
+//		quality.Unique("customers", "email"),
+//		quality.References("orders", "customer_id", "customers", "_key"),
+//		quality.Fresh("events", "occurred_at", time.Hour),
+//	}
+//	report := quality.Check(ctx, client, rules...)
+//	for _, v := range report.Violations() {
+//		log.Printf("%s: %d of %d failed, e.g. %v", v.Rule.Name, v.Failed, v.Checked, v.Examples)
+//	}
+package quality
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+// Kind is the property a Rule checks
+type Kind string
+
+// Rule kinds
+const (
+	// KindCompleteness requires that at least MinRate of the documents have a non-null Field
+	KindCompleteness Kind = "completeness"
+	// KindUniqueness requires that no two documents share a non-null value of Field
+	KindUniqueness Kind = "uniqueness"
+	// KindReferential requires that every non-null Field matches RefField of a document
+	// in RefCollection
+	KindReferential Kind = "referential"
+	// KindFreshness requires that the newest timestamp in Field is at most MaxAge old
+	KindFreshness Kind = "freshness"
+)
+
+// Rule is a data-quality rule for a field of a collection. Rules are usually built
+// with Completeness, Unique, References, or Fresh.
+type Rule struct {
+	// Name identifies the rule in reports (default: "<kind>:<collection>.<field>")
+	Name       string
+	Kind       Kind
+	Collection string
+	// Field is the dotted path of the checked field
+	Field string
+	// MinRate is the minimum fraction of documents with the field, for completeness rules
+	MinRate float64
+	// RefCollection and RefField are the referenced documents, for referential rules
+	RefCollection string
+	RefField      string
+	// MaxAge is the maximum age of the newest timestamp, for freshness rules. Timestamps
+	// are RFC 3339 strings or Unix milliseconds.
+	MaxAge time.Duration
+	// Examples is the number of violating document keys or duplicated values reported
+	// (default: 10)
+	Examples int
+}
+
+// Completeness requires that at least minRate (0 to 1) of the documents of collection
+// have a non-null field
+func Completeness(collection, field string, minRate float64) Rule {
+	return Rule{Kind: KindCompleteness, Collection: collection, Field: field, MinRate: minRate}
+}
+
+// Unique requires that no two documents of collection share a non-null value of field
+func Unique(collection, field string) Rule {
+	return Rule{Kind: KindUniqueness, Collection: collection, Field: field}
+}
+
+// References requires that every non-null field of collection matches refField of a
+// document in refCollection, e.g. References("orders", "customer_id", "customers", "_key")
+func References(collection, field, refCollection, refField string) Rule {
+	return Rule{Kind: KindReferential, Collection: collection, Field: field, RefCollection: refCollection, RefField: refField}
+}
+
+// Fresh requires that the newest timestamp in field of collection is at most maxAge old
+func Fresh(collection, field string, maxAge time.Duration) Rule {
+	return Rule{Kind: KindFreshness, Collection: collection, Field: field, MaxAge: maxAge}
+}
+
+// withDefaults returns r with its name and example count filled in
+func (r Rule) withDefaults() Rule {
+	if r.Name == "" {
+		r.Name = fmt.Sprintf("%s:%s.%s", r.Kind, r.Collection, r.Field)
+	}
+	if r.Examples <= 0 {
+		r.Examples = 10
+	}
+	return r
+}
+
+// validate checks the names and parameters of r, which are embedded in its query
+func (r Rule) validate() error {
+	names := []string{r.Collection, r.Field}
+	switch r.Kind {
+	case KindCompleteness:
+		if r.MinRate < 0 || r.MinRate > 1 {
+			return fmt.Errorf("%w: rule %s: min rate %v is not between 0 and 1", themisdb.ErrInvalidInput, r.Name, r.MinRate)
+		}
+	case KindUniqueness:
+	case KindReferential:
+		names = append(names, r.RefCollection, r.RefField)
+	case KindFreshness:
+		if r.MaxAge <= 0 {
+			return fmt.Errorf("%w: rule %s: max age must be positive", themisdb.ErrInvalidInput, r.Name)
+		}
+	default:
+		return fmt.Errorf("%w: rule %s: unknown kind %q", themisdb.ErrInvalidInput, r.Name, r.Kind)
+	}
+	for _, name := range names {
+		if name == "" || strings.ContainsAny(name, "`\n") || strings.Contains(name, "..") {
+			return fmt.Errorf("%w: rule %s: invalid name %q", themisdb.ErrInvalidInput, r.Name, name)
+		}
+	}
+	return nil
+}
+
+// Query returns the AQL query evaluating r. It returns a single object with the number
+// of checked documents (total), the number of violations (failed), examples of
+// violations (examples), and for freshness rules the newest timestamp (newest).
+func (r Rule) Query() string {
+	r = r.withDefaults()
+	c, f := quote(r.Collection), path("d", r.Field)
+	total := fmt.Sprintf("FIRST(FOR d IN %s COLLECT WITH COUNT INTO n RETURN n)", c)
+
+	switch r.Kind {
+	case KindCompleteness:
+		return fmt.Sprintf("RETURN {total: %s, failed: FIRST(FOR d IN %s FILTER %s == null COLLECT WITH COUNT INTO n RETURN n), examples: (FOR d IN %s FILTER %s == null LIMIT %d RETURN d._key)}",
+			total, c, f, c, f, r.Examples)
+	case KindUniqueness:
+		groups := fmt.Sprintf("FOR d IN %s FILTER %s != null COLLECT v = %s WITH COUNT INTO n FILTER n > 1", c, f, f)
+		return fmt.Sprintf("RETURN {total: FIRST(FOR d IN %s FILTER %s != null COLLECT WITH COUNT INTO n RETURN n), failed: FIRST(%s COLLECT AGGREGATE dup = SUM(n) RETURN dup), examples: (%s SORT n DESC LIMIT %d RETURN v)}",
+			c, f, groups, groups, r.Examples)
+	case KindReferential:
+		dangling := fmt.Sprintf("FOR d IN %s FILTER %s != null FILTER LENGTH(FOR r IN %s FILTER %s == %s LIMIT 1 RETURN 1) == 0",
+			c, f, quote(r.RefCollection), path("r", r.RefField), f)
+		return fmt.Sprintf("RETURN {total: FIRST(FOR d IN %s FILTER %s != null COLLECT WITH COUNT INTO n RETURN n), failed: FIRST(%s COLLECT WITH COUNT INTO n RETURN n), examples: (%s LIMIT %d RETURN d._key)}",
+			c, f, dangling, dangling, r.Examples)
+	case KindFreshness:
+		return fmt.Sprintf("RETURN {total: %s, newest: FIRST(FOR d IN %s FILTER %s != null SORT %s DESC LIMIT 1 RETURN %s)}",
+			total, c, f, f, f)
+	}
+	return ""
+}
+
+// quote returns name as a quoted AQL identifier
+func quote(name string) string {
+	return "`" + name + "`"
+}
+
+// path returns the AQL attribute path of a dotted field within variable v
+func path(v, field string) string {
+	var b strings.Builder
+	b.WriteString(v)
+	for _, part := range strings.Split(field, ".") {
+		b.WriteString("." + quote(part))
+	}
+	return b.String()
+}
+
+// Result is the outcome of evaluating a rule
+type Result struct {
+	Rule Rule
+	// Passed reports whether the rule holds
+	Passed bool
+	// Checked counts the documents the rule applies to
+	Checked int64
+	// Failed counts the violating documents
+	Failed int64
+	// Score is the fraction of checked documents that pass, from 0 to 1; 1 for an empty
+	// collection and for freshness rules that pass, 0 for those that fail
+	Score float64
+	// Examples are keys of violating documents, or duplicated values for uniqueness rules
+	Examples []interface{}
+	// Newest is the newest timestamp found by a freshness rule
+	Newest time.Time
+	// Err is set if the rule could not be evaluated; Passed is false then
+	Err error
+}
+
+// Report holds the results of evaluating rules at one point in time
+type Report struct {
+	Time    time.Time
+	Results []Result
+}
+
+// Violations returns the results of the rules that failed or could not be evaluated
+func (r *Report) Violations() []Result {
+	var violations []Result
+	for _, result := range r.Results {
+		if !result.Passed {
+			violations = append(violations, result)
+		}
+	}
+	return violations
+}
+
+// Passed reports whether all rules hold
+func (r *Report) Passed() bool {
+	return len(r.Violations()) == 0
+}
+
+// ruleStats is the result of a rule's query
+type ruleStats struct {
+	Total    int64         `json:"total"`
+	Failed   int64         `json:"failed"`
+	Examples []interface{} `json:"examples"`
+	Newest   interface{}   `json:"newest"`
+}
+
+// Check evaluates rules one after another and reports their results. Rules that cannot
+// be evaluated are reported with Result.Err set instead of aborting the check.
+func Check(ctx context.Context, client themisdb.ThemisClient, rules ...Rule) *Report {
+	report := &Report{Time: time.Now()}
+	for _, rule := range rules {
+		report.Results = append(report.Results, evaluate(ctx, client, rule.withDefaults(), report.Time))
+	}
+	return report
+}
+
+// evaluate runs the query of rule and judges its result at now
+func evaluate(ctx context.Context, client themisdb.ThemisClient, rule Rule, now time.Time) Result {
+	result := Result{Rule: rule}
+	if result.Err = rule.validate(); result.Err != nil {
+		return result
+	}
+	var rows []ruleStats
+	if err := client.Query(ctx, rule.Query(), &rows); err != nil {
+		result.Err = fmt.Errorf("rule %s: %w", rule.Name, err)
+		return result
+	}
+	if len(rows) != 1 {
+		result.Err = fmt.Errorf("rule %s: query returned %d rows, expected 1", rule.Name, len(rows))
+		return result
+	}
+	stats := rows[0]
+	result.Checked, result.Failed, result.Examples = stats.Total, stats.Failed, stats.Examples
+
+	if rule.Kind == KindFreshness {
+		newest, err := parseTimestamp(stats.Newest)
+		if err != nil {
+			result.Err = fmt.Errorf("rule %s: %w", rule.Name, err)
+			return result
+		}
+		result.Newest = newest
+		result.Passed = !newest.IsZero() && now.Sub(newest) <= rule.MaxAge
+		if result.Passed {
+			result.Score = 1
+		}
+		return result
+	}
+
+	result.Score = 1
+	if result.Checked > 0 {
+		result.Score = float64(result.Checked-result.Failed) / float64(result.Checked)
+	}
+	if rule.Kind == KindCompleteness {
+		result.Passed = result.Score >= rule.MinRate
+	} else {
+		result.Passed = result.Failed == 0
+	}
+	return result
+}
+
+// parseTimestamp decodes an RFC 3339 string or Unix milliseconds; nil is the zero time
+func parseTimestamp(v interface{}) (time.Time, error) {
+	switch v := v.(type) {
+	case nil:
+		return time.Time{}, nil
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q: %w", v, err)
+		}
+		return t, nil
+	case float64:
+		return time.UnixMilli(int64(v)), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %v", v)
+}
@@ -0,0 +1,101 @@
+package quality
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+	"github.com/makr-code/ThemisDB/clients/go/themistest"
+)
+
+// stub answers the query of rule with a single stats object
+func stub(fake *themistest.Fake, rule Rule, stats map[string]interface{}) {
+	fake.HandleQuery(rule.Query(), func(map[string]interface{}) (interface{}, error) {
+		return []interface{}{stats}, nil
+	})
+}
+
+func TestCheck(t *testing.T) {
+	client, fake := themistest.NewClient(t)
+	now := time.Now().UTC()
+
+	complete := Completeness("customers", "email", 0.9)
+	incomplete := Completeness("customers", "address.city", 0.9)
+	unique := Unique("customers", "email")
+	dangling := References("orders", "customer_id", "customers", "_key")
+	fresh := Fresh("events", "occurred_at", time.Hour)
+	stale := Fresh("audit", "at", time.Hour)
+	stub(fake, complete, map[string]interface{}{"total": 100, "failed": 5, "examples": []string{"c7"}})
+	stub(fake, incomplete, map[string]interface{}{"total": 100, "failed": 20, "examples": []string{"c1", "c2"}})
+	stub(fake, unique, map[string]interface{}{"total": 95, "failed": 0, "examples": []string{}})
+	stub(fake, dangling, map[string]interface{}{"total": 40, "failed": 2, "examples": []string{"o3", "o9"}})
+	stub(fake, fresh, map[string]interface{}{"total": 10, "newest": now.Add(-time.Minute).Format(time.RFC3339Nano)})
+	stub(fake, stale, map[string]interface{}{"total": 10, "newest": now.Add(-2 * time.Hour).UnixMilli()})
+
+	report := Check(context.Background(), client, complete, incomplete, unique, dangling, fresh, stale)
+	require.Len(t, report.Results, 6)
+	for _, r := range report.Results {
+		require.NoError(t, r.Err, r.Rule.Name)
+	}
+
+	assert.True(t, report.Results[0].Passed)
+	assert.Equal(t, 0.95, report.Results[0].Score)
+	assert.Equal(t, "completeness:customers.email", report.Results[0].Rule.Name)
+	assert.False(t, report.Results[1].Passed)
+	assert.Equal(t, 0.8, report.Results[1].Score)
+	assert.True(t, report.Results[2].Passed)
+	assert.False(t, report.Results[3].Passed)
+	assert.Equal(t, int64(2), report.Results[3].Failed)
+	assert.Equal(t, []interface{}{"o3", "o9"}, report.Results[3].Examples)
+	assert.True(t, report.Results[4].Passed)
+	assert.Equal(t, 1.0, report.Results[4].Score)
+	assert.False(t, report.Results[5].Passed)
+	assert.WithinDuration(t, now.Add(-2*time.Hour), report.Results[5].Newest, time.Millisecond)
+
+	assert.False(t, report.Passed())
+	var failed []string
+	for _, v := range report.Violations() {
+		failed = append(failed, v.Rule.Name)
+	}
+	assert.Equal(t, []string{"completeness:customers.address.city", "referential:orders.customer_id", "freshness:audit.at"}, failed)
+}
+
+func TestCheck_Errors(t *testing.T) {
+	client, fake := themistest.NewClient(t)
+	broken := Unique("customers", "email")
+	fake.HandleQuery(broken.Query(), func(map[string]interface{}) (interface{}, error) {
+		return []interface{}{}, nil
+	})
+
+	report := Check(context.Background(), client,
+		broken,
+		Completeness("customers", "email", 1.5),
+		Fresh("events", "at", 0),
+		Unique("bad`name", "email"),
+		Rule{Kind: "made-up", Collection: "c", Field: "f"},
+		Completeness("unstubbed", "field", 1),
+	)
+	require.Len(t, report.Results, 6)
+	assert.ErrorContains(t, report.Results[0].Err, "query returned 0 rows")
+	for _, r := range report.Results[1:5] {
+		assert.ErrorIs(t, r.Err, themisdb.ErrInvalidInput, r.Rule.Name)
+	}
+	assert.Error(t, report.Results[5].Err, "the fake cannot evaluate the aggregate")
+	assert.Len(t, report.Violations(), 6)
+}
+
+func TestRule_Query(t *testing.T) {
+	assert.Equal(t,
+		"RETURN {total: FIRST(FOR d IN `customers` COLLECT WITH COUNT INTO n RETURN n), failed: FIRST(FOR d IN `customers` FILTER d.`address`.`city` == null COLLECT WITH COUNT INTO n RETURN n), examples: (FOR d IN `customers` FILTER d.`address`.`city` == null LIMIT 10 RETURN d._key)}",
+		Completeness("customers", "address.city", 1).Query())
+	assert.Contains(t,
+		References("orders", "customer_id", "customers", "_key").Query(),
+		"FILTER LENGTH(FOR r IN `customers` FILTER r.`_key` == d.`customer_id` LIMIT 1 RETURN 1) == 0")
+	rule := Unique("customers", "email")
+	rule.Examples = 3
+	assert.Contains(t, rule.Query(), "SORT n DESC LIMIT 3 RETURN v")
+}
@@ -0,0 +1,112 @@
+package themisdb
+
+import (
+	"math/rand"
+	"regexp"
+	"time"
+)
+
+// redacted replaces the values of redacted bind variables and query literals
+const redacted = "[REDACTED]"
+
+// QueryLogEntry describes an executed query
+type QueryLogEntry struct {
+	// Time is when the query was sent
+	Time time.Time
+	// Query is the AQL text, with string literals replaced if RedactLiterals is set
+	Query string
+	// Fingerprint is CanonicalizeAQL of Query, equal for entries of the same query
+	// sent with different formatting or bind variable names
+	Fingerprint string
+	// BindVars are the bind variables after redaction
+	BindVars map[string]interface{}
+	// Duration is the latency of the query including decoding
+	Duration time.Duration
+	// Rows is the number of result rows
+	Rows int
+	// Endpoint is the server that executed the query, empty if it was not reached
+	Endpoint string
+	// TransactionID is set for queries run within a transaction
+	TransactionID string
+	// Err is the error of a failed query
+	Err error
+}
+
+// QueryLogOptions configures query logging. Queries are sampled at SampleRate;
+// failed queries and queries slower than SlowThreshold are always logged.
+type QueryLogOptions struct {
+	// Sink receives the log entries; it is called synchronously and must be fast
+	Sink func(QueryLogEntry)
+	// SampleRate is the fraction of queries logged, between 0 and 1 (default: 1)
+	SampleRate float64
+	// SlowThreshold logs every query slower than this, zero disables it
+	SlowThreshold time.Duration
+	// RedactBindVars lists bind variables whose values are never logged
+	RedactBindVars []string
+	// RedactBindVarPattern redacts the bind variables whose names match, e.g. (?i)password|ssn
+	RedactBindVarPattern *regexp.Regexp
+	// RedactLiterals replaces string literals in the query text
+	RedactLiterals bool
+}
+
+// queryLogger samples queries into QueryLogOptions.Sink
+type queryLogger struct {
+	opts   QueryLogOptions
+	redact map[string]bool
+}
+
+// newQueryLogger returns a logger for opts, or nil if query logging is disabled
+func newQueryLogger(opts *QueryLogOptions) *queryLogger {
+	if opts == nil || opts.Sink == nil {
+		return nil
+	}
+	o := *opts
+	if o.SampleRate <= 0 || o.SampleRate > 1 {
+		o.SampleRate = 1
+	}
+	redact := make(map[string]bool, len(o.RedactBindVars))
+	for _, name := range o.RedactBindVars {
+		redact[name] = true
+	}
+	return &queryLogger{opts: o, redact: redact}
+}
+
+// stringLiteral matches single- and double-quoted AQL string literals
+var stringLiteral = regexp.MustCompile(`'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"`)
+
+// log emits an entry for a query if it is sampled, slow, or failed
+func (l *queryLogger) log(aql string, opts *QueryOptions, headers map[string]string, start time.Time, resp *Response, result *QueryResult, err error) {
+	duration := time.Since(start)
+	slow := l.opts.SlowThreshold > 0 && duration >= l.opts.SlowThreshold
+	if err == nil && !slow && rand.Float64() >= l.opts.SampleRate {
+		return
+	}
+
+	entry := QueryLogEntry{
+		Time:          start,
+		Query:         aql,
+		Duration:      duration,
+		TransactionID: headers["X-Transaction-Id"],
+		Err:           err,
+	}
+	if l.opts.RedactLiterals {
+		entry.Query = stringLiteral.ReplaceAllString(aql, "'"+redacted+"'")
+	}
+	entry.Fingerprint = CanonicalizeAQL(entry.Query)
+	if opts != nil && len(opts.BindVars) > 0 {
+		entry.BindVars = make(map[string]interface{}, len(opts.BindVars))
+		for name, value := range opts.BindVars {
+			if l.redact[name] || (l.opts.RedactBindVarPattern != nil && l.opts.RedactBindVarPattern.MatchString(name)) {
+				value = redacted
+			}
+			entry.BindVars[name] = value
+		}
+	}
+	if resp != nil {
+		entry.Endpoint = resp.Endpoint
+	}
+	if err == nil {
+		entry.Rows = result.rows()
+	}
+	l.opts.Sink(entry)
+}
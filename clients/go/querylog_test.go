@@ -0,0 +1,85 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_QueryLog(t *testing.T) {
+	var body map[string]interface{}
+	base := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["query"] == "BROKEN" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"data":[{"name":"alice"},{"name":"bob"}]}`))
+	})
+
+	var entries []QueryLogEntry
+	client := NewClient(Config{
+		Endpoints: base.endpoints,
+		QueryLog: &QueryLogOptions{
+			Sink:                 func(e QueryLogEntry) { entries = append(entries, e) },
+			RedactBindVars:       []string{"email"},
+			RedactBindVarPattern: regexp.MustCompile(`(?i)password`),
+			RedactLiterals:       true,
+		},
+	})
+	ctx := context.Background()
+
+	var rows []map[string]string
+	opts := &QueryOptions{BindVars: map[string]interface{}{"email": "alice@example.com", "userPassword": "secret", "limit": 10}}
+	require.NoError(t, client.QueryWithOptions(ctx, `FOR u IN users FILTER u.email == @email AND u.city == 'Berlin' LIMIT @limit RETURN u`, opts, &rows))
+	assert.Equal(t, map[string]interface{}{"email": "alice@example.com", "userPassword": "secret", "limit": float64(10)}, body["bind_vars"])
+
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, `FOR u IN users FILTER u.email == @email AND u.city == '[REDACTED]' LIMIT @limit RETURN u`, entry.Query)
+	assert.Equal(t, `FOR u IN users FILTER u.email == @p1 AND u.city == '[REDACTED]' LIMIT @p2 RETURN u`, entry.Fingerprint)
+	assert.Equal(t, map[string]interface{}{"email": "[REDACTED]", "userPassword": "[REDACTED]", "limit": 10}, entry.BindVars)
+	assert.Equal(t, 2, entry.Rows)
+	assert.Equal(t, base.endpoints[0], entry.Endpoint)
+	assert.Positive(t, entry.Duration)
+	assert.NoError(t, entry.Err)
+
+	tx := &Transaction{client: client, transactionID: "tx-1", active: true}
+	require.NoError(t, tx.Query(ctx, "FOR u IN users RETURN u", &rows))
+	assert.Equal(t, "tx-1", entries[1].TransactionID)
+
+	assert.Error(t, client.Query(ctx, "BROKEN", &rows))
+	require.Len(t, entries, 3)
+	assert.Error(t, entries[2].Err)
+}
+
+func TestClient_QueryLogSampling(t *testing.T) {
+	base := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[]}`))
+	})
+
+	var logged int
+	client := NewClient(Config{
+		Endpoints: base.endpoints,
+		QueryLog:  &QueryLogOptions{Sink: func(QueryLogEntry) { logged++ }, SampleRate: 0.1},
+	})
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, client.Query(context.Background(), "FOR u IN users RETURN u", nil))
+	}
+	assert.InDelta(t, 100, logged, 60)
+
+	// slow queries bypass sampling
+	logged = 0
+	client = NewClient(Config{
+		Endpoints: base.endpoints,
+		QueryLog:  &QueryLogOptions{Sink: func(QueryLogEntry) { logged++ }, SampleRate: 1e-9, SlowThreshold: time.Nanosecond},
+	})
+	require.NoError(t, client.Query(context.Background(), "FOR u IN users RETURN u", nil))
+	assert.Equal(t, 1, logged)
+}
@@ -0,0 +1,259 @@
+package themisdb
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// QueueOptions holds job queue configuration
+type QueueOptions struct {
+	// VisibilityTimeout hides a dequeued message from other consumers until it is
+	// acked, nacked, or the timeout expires (default: 30s)
+	VisibilityTimeout time.Duration
+	// MaxReceives moves a message to the dead-letter collection once it was dequeued
+	// this many times without being acked (default: 5)
+	MaxReceives int
+}
+
+// Queue is a durable job queue stored in a ThemisDB collection. Consumers claim
+// messages with conditional updates, so each message is processed by one consumer
+// at a time; unacknowledged messages become visible again after the visibility timeout.
+type Queue struct {
+	client     *Client
+	name       string
+	collection string
+	deadLetter string
+	opts       QueueOptions
+}
+
+// Message is a job taken from a Queue
+type Message struct {
+	// ID orders messages by enqueue time
+	ID string
+	// Body is the JSON-encoded payload
+	Body json.RawMessage
+	// EnqueuedAt is when the message was enqueued
+	EnqueuedAt time.Time
+	// Attempts counts how often the message was dequeued, including this time
+	Attempts int
+	// Receipt identifies this delivery; Ack and Nack fail once it is superseded
+	Receipt string
+	// Error is the last error recorded by Nack
+	Error string
+}
+
+// Decode unmarshals the message body into v
+func (m *Message) Decode(v interface{}) error {
+	if err := json.Unmarshal(m.Body, v); err != nil {
+		return fmt.Errorf("failed to unmarshal message %s: %w", m.ID, err)
+	}
+	return nil
+}
+
+// queueMessage is the stored document of a message
+type queueMessage struct {
+	Body       json.RawMessage `json:"body"`
+	EnqueuedAt int64           `json:"enqueued_at"`
+	VisibleAt  int64           `json:"visible_at"`
+	Attempts   int             `json:"attempts"`
+	Receipt    string          `json:"receipt,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// message converts the stored document into a Message
+func (m *queueMessage) message(id string) *Message {
+	return &Message{
+		ID:         id,
+		Body:       m.Body,
+		EnqueuedAt: time.UnixMilli(m.EnqueuedAt),
+		Attempts:   m.Attempts,
+		Receipt:    m.Receipt,
+		Error:      m.Error,
+	}
+}
+
+// Queue returns the job queue name. Messages are stored in the collection "_queue_<name>",
+// dead letters in "_dlq_<name>".
+func (c *Client) Queue(name string, opts QueueOptions) *Queue {
+	if opts.VisibilityTimeout <= 0 {
+		opts.VisibilityTimeout = 30 * time.Second
+	}
+	if opts.MaxReceives <= 0 {
+		opts.MaxReceives = 5
+	}
+	return &Queue{
+		client:     c,
+		name:       name,
+		collection: "_queue_" + name,
+		deadLetter: "_dlq_" + name,
+		opts:       opts,
+	}
+}
+
+// Enqueue adds a message with the JSON encoding of body and returns its ID
+func (q *Queue) Enqueue(ctx context.Context, body interface{}) (string, error) {
+	return q.EnqueueDelayed(ctx, body, 0)
+}
+
+// EnqueueDelayed adds a message that becomes visible to consumers after delay
+func (q *Queue) EnqueueDelayed(ctx context.Context, body interface{}, delay time.Duration) (string, error) {
+	if err := validateName("queue", q.name); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	now := time.Now()
+	id := fmt.Sprintf("%020d-%s", now.UnixNano(), randomToken(4))
+	msg := queueMessage{
+		Body:       data,
+		EnqueuedAt: now.UnixMilli(),
+		VisibleAt:  now.Add(delay).UnixMilli(),
+	}
+	if err := q.client.Create(ctx, ModelRelational, q.collection, id, msg); err != nil {
+		return "", fmt.Errorf("failed to enqueue message: %w", err)
+	}
+	return id, nil
+}
+
+// Dequeue claims the oldest visible message, hiding it for the visibility timeout.
+// It returns nil if no message is visible.
+func (q *Queue) Dequeue(ctx context.Context) (*Message, error) {
+	if err := validateName("queue", q.name); err != nil {
+		return nil, err
+	}
+
+	it := q.client.Scan(ctx, ModelRelational, q.collection, ScanOptions{BatchSize: 50})
+	for it.Next() {
+		var candidate queueMessage
+		if err := it.Decode(&candidate); err != nil {
+			return nil, err
+		}
+		if candidate.VisibleAt > time.Now().UnixMilli() {
+			continue
+		}
+
+		msg, err := q.claim(ctx, it.UUID())
+		if err != nil {
+			return nil, err
+		}
+		if msg != nil {
+			return msg, nil
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("failed to dequeue from %s: %w", q.name, err)
+	}
+	return nil, nil
+}
+
+// claim takes a visible message for this consumer, returning nil if another consumer won
+// or the message was moved to the dead-letter collection
+func (q *Queue) claim(ctx context.Context, id string) (*Message, error) {
+	var stored queueMessage
+	rev, exists, err := q.client.getVersioned(ctx, q.collection, id, &stored)
+	if err != nil || !exists {
+		return nil, err
+	}
+	now := time.Now()
+	if stored.VisibleAt > now.UnixMilli() {
+		return nil, nil
+	}
+	if stored.Attempts >= q.opts.MaxReceives {
+		return nil, q.deadLetterMessage(ctx, id, rev, &stored)
+	}
+
+	stored.Attempts++
+	stored.VisibleAt = now.Add(q.opts.VisibilityTimeout).UnixMilli()
+	stored.Receipt = randomToken(8)
+	err = q.client.putVersioned(ctx, q.collection, id, &stored, rev, true)
+	if err == ErrConflict {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim message %s: %w", id, err)
+	}
+	return stored.message(id), nil
+}
+
+// deadLetterMessage moves an exhausted message to the dead-letter collection
+func (q *Queue) deadLetterMessage(ctx context.Context, id, rev string, stored *queueMessage) error {
+	if err := q.client.Put(ctx, ModelRelational, q.deadLetter, id, stored); err != nil {
+		return fmt.Errorf("failed to dead-letter message %s: %w", id, err)
+	}
+	if err := q.client.deleteVersioned(ctx, q.collection, id, rev); err != nil && err != ErrConflict {
+		return fmt.Errorf("failed to dead-letter message %s: %w", id, err)
+	}
+	return nil
+}
+
+// Ack removes a processed message. It fails with ErrConflict if the visibility timeout
+// expired and the message was redelivered in the meantime.
+func (q *Queue) Ack(ctx context.Context, msg *Message) error {
+	var stored queueMessage
+	rev, exists, err := q.client.getVersioned(ctx, q.collection, msg.ID, &stored)
+	if err != nil {
+		return fmt.Errorf("failed to ack message %s: %w", msg.ID, err)
+	}
+	if !exists || stored.Receipt != msg.Receipt {
+		return fmt.Errorf("failed to ack message %s: %w", msg.ID, ErrConflict)
+	}
+
+	if err := q.client.deleteVersioned(ctx, q.collection, msg.ID, rev); err != nil {
+		return fmt.Errorf("failed to ack message %s: %w", msg.ID, err)
+	}
+	return nil
+}
+
+// Nack returns a message to the queue, visible again after delay, recording cause
+// as the message's last error. Messages nacked MaxReceives times are dead-lettered.
+func (q *Queue) Nack(ctx context.Context, msg *Message, delay time.Duration, cause error) error {
+	var stored queueMessage
+	rev, exists, err := q.client.getVersioned(ctx, q.collection, msg.ID, &stored)
+	if err != nil {
+		return fmt.Errorf("failed to nack message %s: %w", msg.ID, err)
+	}
+	if !exists || stored.Receipt != msg.Receipt {
+		return fmt.Errorf("failed to nack message %s: %w", msg.ID, ErrConflict)
+	}
+
+	stored.Receipt = ""
+	stored.VisibleAt = time.Now().Add(delay).UnixMilli()
+	if cause != nil {
+		stored.Error = cause.Error()
+	}
+	if err := q.client.putVersioned(ctx, q.collection, msg.ID, &stored, rev, true); err != nil {
+		return fmt.Errorf("failed to nack message %s: %w", msg.ID, err)
+	}
+	return nil
+}
+
+// DeadLetters returns up to limit messages from the dead-letter collection
+func (q *Queue) DeadLetters(ctx context.Context, limit int) ([]*Message, error) {
+	var messages []*Message
+	it := q.client.Scan(ctx, ModelRelational, q.deadLetter, ScanOptions{BatchSize: limit})
+	for len(messages) < limit && it.Next() {
+		var stored queueMessage
+		if err := it.Decode(&stored); err != nil {
+			return nil, err
+		}
+		messages = append(messages, stored.message(it.UUID()))
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list dead letters of %s: %w", q.name, err)
+	}
+	return messages, nil
+}
+
+// randomToken returns n random bytes, hex-encoded
+func randomToken(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,120 @@
+package themisdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueue_EnqueueDequeueAck(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	ctx := context.Background()
+	q := client.Queue("emails", QueueOptions{VisibilityTimeout: time.Minute})
+
+	for _, to := range []string{"a@example.com", "b@example.com"} {
+		_, err := q.Enqueue(ctx, map[string]string{"to": to})
+		require.NoError(t, err)
+	}
+	_, err := q.EnqueueDelayed(ctx, map[string]string{"to": "later@example.com"}, time.Hour)
+	require.NoError(t, err)
+
+	first, err := q.Dequeue(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, first)
+	var body map[string]string
+	require.NoError(t, first.Decode(&body))
+	assert.Equal(t, "a@example.com", body["to"])
+	assert.Equal(t, 1, first.Attempts)
+
+	second, err := q.Dequeue(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, second)
+	require.NoError(t, second.Decode(&body))
+	assert.Equal(t, "b@example.com", body["to"])
+
+	// the first message is invisible and the delayed one not yet due
+	none, err := q.Dequeue(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, none)
+
+	require.NoError(t, q.Ack(ctx, first))
+	assert.ErrorIs(t, q.Ack(ctx, first), ErrConflict)
+}
+
+func TestQueue_VisibilityTimeoutAndDeadLetter(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	ctx := context.Background()
+	q := client.Queue("reports", QueueOptions{VisibilityTimeout: 10 * time.Millisecond, MaxReceives: 2})
+
+	id, err := q.Enqueue(ctx, "report-1")
+	require.NoError(t, err)
+
+	msg, err := q.Dequeue(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+	require.NoError(t, q.Nack(ctx, msg, 0, errors.New("renderer crashed")))
+
+	msg, err = q.Dequeue(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+	assert.Equal(t, 2, msg.Attempts)
+	assert.Equal(t, "renderer crashed", msg.Error)
+
+	// the visibility timeout expires without an ack, the stale receipt is rejected
+	time.Sleep(20 * time.Millisecond)
+	msg2, err := q.Dequeue(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, msg2)
+	assert.ErrorIs(t, q.Ack(ctx, msg), ErrConflict)
+
+	dead, err := q.DeadLetters(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, id, dead[0].ID)
+	assert.Equal(t, 2, dead[0].Attempts)
+}
+
+func TestQueue_ConcurrentConsumers(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	ctx := context.Background()
+	q := client.Queue("jobs", QueueOptions{})
+
+	for i := 0; i < 20; i++ {
+		_, err := q.Enqueue(ctx, i)
+		require.NoError(t, err)
+	}
+
+	var mu sync.Mutex
+	seen := map[int]int{}
+	var wg sync.WaitGroup
+	for c := 0; c < 5; c++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				msg, err := q.Dequeue(ctx)
+				if !assert.NoError(t, err) || msg == nil {
+					return
+				}
+				var n int
+				assert.NoError(t, msg.Decode(&n))
+				mu.Lock()
+				seen[n]++
+				mu.Unlock()
+				assert.NoError(t, q.Ack(ctx, msg))
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, seen, 20)
+	for n, count := range seen {
+		assert.Equal(t, 1, count, fmt.Sprintf("message %d", n))
+	}
+}
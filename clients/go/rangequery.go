@@ -0,0 +1,229 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RangeQuery is a query over a range of a field that QueryRange splits into
+// sub-ranges queried concurrently
+type RangeQuery struct {
+	// AQL must restrict the partitioned field to the bind variables @range_start
+	// (inclusive) and @range_end (exclusive), e.g.
+	// "FOR o IN orders FILTER o.total >= @range_start AND o.total < @range_end SORT o.created RETURN o"
+	AQL string
+	// Options are applied to every sub-query; their BindVars are extended by the range bounds
+	Options *QueryOptions
+	// Start and End bound the range, Start inclusive and End exclusive. Both must be
+	// int64, float64, or time.Time.
+	Start, End interface{}
+	// Partitions is the number of sub-ranges queried concurrently (default: 4). Integer
+	// ranges shorter than Partitions use one sub-range per value.
+	Partitions int
+	// SortBy is the dotted field path the query sorts by. The sorted results of the
+	// sub-queries are merged by it; if empty they are concatenated in range order,
+	// which keeps the order of queries sorted by the partitioned field.
+	SortBy string
+	// Descending merges by SortBy in descending order
+	Descending bool
+}
+
+// QueryRange runs q as concurrent sub-queries over sub-ranges of [q.Start, q.End) and
+// decodes the merged rows into result, which must be a pointer to a slice. It speeds
+// up large range scans on servers that execute each query on a single thread. The
+// first failing sub-query cancels the others.
+func (c *Client) QueryRange(ctx context.Context, q RangeQuery, result interface{}) error {
+	if !strings.Contains(q.AQL, "@range_start") || !strings.Contains(q.AQL, "@range_end") {
+		return &ValidationError{Field: "range query", Value: q.AQL, Reason: "must use the bind variables @range_start and @range_end"}
+	}
+	if q.Partitions <= 0 {
+		q.Partitions = 4
+	}
+	bounds, err := splitRange(q.Start, q.End, q.Partitions)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	parts := make([][]json.RawMessage, len(bounds)-1)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for i := range parts {
+		opts := QueryOptions{}
+		if q.Options != nil {
+			opts = *q.Options
+		}
+		bindVars := make(map[string]interface{}, len(opts.BindVars)+2)
+		for name, value := range opts.BindVars {
+			bindVars[name] = value
+		}
+		bindVars["range_start"] = bounds[i]
+		bindVars["range_end"] = bounds[i+1]
+		opts.BindVars = bindVars
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := c.QueryWithOptions(ctx, q.AQL, &opts, &parts[i]); err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("range query partition [%v, %v): %w", bounds[i], bounds[i+1], err)
+					cancel()
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+
+	rows, err := mergeRanges(parts, q.SortBy, q.Descending)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("failed to decode range query result: %w", err)
+	}
+	return nil
+}
+
+// splitRange returns the n+1 bounds of n equal sub-ranges of [start, end)
+func splitRange(start, end interface{}, n int) ([]interface{}, error) {
+	var bounds []interface{}
+	switch s := start.(type) {
+	case int64:
+		e, ok := end.(int64)
+		if !ok || e <= s {
+			break
+		}
+		if int64(n) > e-s {
+			n = int(e - s)
+		}
+		step := (e - s) / int64(n)
+		for i := 0; i < n; i++ {
+			bounds = append(bounds, s+int64(i)*step)
+		}
+		return append(bounds, e), nil
+	case float64:
+		e, ok := end.(float64)
+		if !ok || !(e > s) || math.IsInf(e-s, 0) {
+			break
+		}
+		for i := 0; i < n; i++ {
+			bounds = append(bounds, s+(e-s)*float64(i)/float64(n))
+		}
+		return append(bounds, e), nil
+	case time.Time:
+		e, ok := end.(time.Time)
+		if !ok || !e.After(s) {
+			break
+		}
+		step := e.Sub(s) / time.Duration(n)
+		for i := 0; i < n; i++ {
+			bounds = append(bounds, s.Add(time.Duration(i)*step))
+		}
+		return append(bounds, e), nil
+	}
+	return nil, &ValidationError{Field: "range", Value: fmt.Sprintf("[%v, %v)", start, end), Reason: "bounds must be int64, float64, or time.Time with start before end"}
+}
+
+// mergeRanges merges the sorted rows of consecutive sub-ranges by the field sortBy
+func mergeRanges(parts [][]json.RawMessage, sortBy string, descending bool) ([]json.RawMessage, error) {
+	total := 0
+	for _, part := range parts {
+		total += len(part)
+	}
+	merged := make([]json.RawMessage, 0, total)
+	if sortBy == "" {
+		for _, part := range parts {
+			merged = append(merged, part...)
+		}
+		return merged, nil
+	}
+
+	keys := make([][]interface{}, len(parts))
+	for i, part := range parts {
+		keys[i] = make([]interface{}, len(part))
+		for j, row := range part {
+			var doc map[string]interface{}
+			if err := json.Unmarshal(row, &doc); err != nil {
+				return nil, fmt.Errorf("failed to sort range query result by %s: %w", sortBy, err)
+			}
+			keys[i][j], _ = lookupField(doc, sortBy)
+		}
+	}
+
+	next := make([]int, len(parts))
+	for len(merged) < total {
+		best := -1
+		for i := range parts {
+			if next[i] == len(parts[i]) {
+				continue
+			}
+			if best < 0 {
+				best = i
+				continue
+			}
+			c := compareValues(keys[i][next[i]], keys[best][next[best]])
+			if (!descending && c < 0) || (descending && c > 0) {
+				best = i
+			}
+		}
+		merged = append(merged, parts[best][next[best]])
+		next[best]++
+	}
+	return merged, nil
+}
+
+// compareValues orders decoded JSON scalars: null, then booleans, numbers, and strings
+func compareValues(a, b interface{}) int {
+	rank := func(v interface{}) int {
+		switch v.(type) {
+		case nil:
+			return 0
+		case bool:
+			return 1
+		case float64:
+			return 2
+		case string:
+			return 3
+		}
+		return 4
+	}
+	if ra, rb := rank(a), rank(b); ra != rb {
+		return ra - rb
+	}
+	switch a := a.(type) {
+	case bool:
+		if a == b.(bool) {
+			return 0
+		}
+		if !a {
+			return -1
+		}
+		return 1
+	case float64:
+		b := b.(float64)
+		if a < b {
+			return -1
+		}
+		if a > b {
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(a, b.(string))
+	}
+	return 0
+}
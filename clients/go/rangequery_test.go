@@ -0,0 +1,137 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type rangeOrder struct {
+	ID      int    `json:"id"`
+	Created string `json:"created"`
+}
+
+// rangeServer answers range queries over orders 0-99 sorted by created, which runs
+// opposite to id, descending if the bind variable desc is true
+func rangeServer(t *testing.T, mu *sync.Mutex, seen *[]map[string]interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			BindVars map[string]interface{} `json:"bind_vars"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		*seen = append(*seen, body.BindVars)
+		mu.Unlock()
+
+		start, end := body.BindVars["range_start"].(float64), body.BindVars["range_end"].(float64)
+		var rows []rangeOrder
+		for id := 0; id < 100; id++ {
+			if float64(id) >= start && float64(id) < end {
+				rows = append(rows, rangeOrder{ID: id, Created: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(100-id) * time.Hour).Format(time.RFC3339)})
+			}
+		}
+		desc := body.BindVars["desc"] == true
+		sort.Slice(rows, func(i, j int) bool { return (rows[i].Created < rows[j].Created) != desc })
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": rows})
+	}
+}
+
+func TestClient_QueryRange(t *testing.T) {
+	var mu sync.Mutex
+	var seen []map[string]interface{}
+	client := newTestClient(t, rangeServer(t, &mu, &seen))
+	ctx := context.Background()
+	aql := "FOR o IN orders FILTER o.id >= @range_start AND o.id < @range_end AND o.status == @status SORT o.created RETURN o"
+
+	var orders []rangeOrder
+	err := client.QueryRange(ctx, RangeQuery{
+		AQL:        aql,
+		Options:    &QueryOptions{BindVars: map[string]interface{}{"status": "open"}},
+		Start:      int64(0),
+		End:        int64(100),
+		Partitions: 3,
+		SortBy:     "created",
+	}, &orders)
+	require.NoError(t, err)
+	require.Len(t, orders, 100)
+	for i, o := range orders {
+		assert.Equal(t, 99-i, o.ID, "rows are merged by created across partitions")
+	}
+
+	require.Len(t, seen, 3)
+	var starts []float64
+	for _, vars := range seen {
+		assert.Equal(t, "open", vars["status"])
+		starts = append(starts, vars["range_start"].(float64))
+	}
+	sort.Float64s(starts)
+	assert.Equal(t, []float64{0, 33, 66}, starts)
+
+	orders = nil
+	require.NoError(t, client.QueryRange(ctx, RangeQuery{
+		AQL:        aql,
+		Options:    &QueryOptions{BindVars: map[string]interface{}{"desc": true}},
+		Start:      10.0,
+		End:        20.0,
+		SortBy:     "created",
+		Descending: true,
+	}, &orders))
+	require.Len(t, orders, 10)
+	assert.Equal(t, 10, orders[0].ID)
+	assert.Equal(t, 19, orders[9].ID)
+
+	orders = nil
+	require.NoError(t, client.QueryRange(ctx, RangeQuery{AQL: aql, Start: int64(0), End: int64(4), Partitions: 8}, &orders))
+	assert.Equal(t, []int{0, 1, 2, 3}, []int{orders[0].ID, orders[1].ID, orders[2].ID, orders[3].ID}, "without SortBy partitions are concatenated in range order")
+}
+
+func TestSplitRange(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bounds, err := splitRange(start, start.Add(4*time.Hour), 2)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{start, start.Add(2 * time.Hour), start.Add(4 * time.Hour)}, bounds)
+
+	bounds, err = splitRange(int64(0), int64(10), 3)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(0), int64(3), int64(6), int64(10)}, bounds, "the last sub-range takes the remainder")
+
+	for _, r := range [][2]interface{}{{int64(5), int64(5)}, {1.0, int64(2)}, {"a", "z"}, {start, start}} {
+		_, err := splitRange(r[0], r[1], 4)
+		assert.ErrorIs(t, err, ErrInvalidInput, "%v", r)
+	}
+}
+
+func TestClient_QueryRangeErrors(t *testing.T) {
+	var calls sync.WaitGroup
+	calls.Add(4)
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			BindVars map[string]interface{} `json:"bind_vars"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		calls.Done()
+		if body.BindVars["range_start"].(float64) == 50 {
+			http.Error(w, "out of memory", http.StatusBadRequest)
+			return
+		}
+		<-r.Context().Done()
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var rows []interface{}
+	err := client.QueryRange(ctx, RangeQuery{AQL: "FOR o IN orders FILTER o.id >= @range_start AND o.id < @range_end RETURN o", Start: int64(0), End: int64(100)}, &rows)
+	assert.ErrorContains(t, err, "range query partition [50, 75)")
+	assert.NoError(t, ctx.Err(), "the failing partition cancels the others")
+	calls.Wait()
+
+	err = client.QueryRange(ctx, RangeQuery{AQL: "FOR o IN orders RETURN o", Start: int64(0), End: int64(100)}, &rows)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
@@ -0,0 +1,157 @@
+package themisdb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// rateLimitsCollection stores the state of each rate limiter
+const rateLimitsCollection = "_ratelimits"
+
+// FixedWindowLimiter allows at most limit events per window across all processes
+// sharing its name. Counts reset at window boundaries aligned to the Unix epoch.
+type FixedWindowLimiter struct {
+	client *Client
+	name   string
+	limit  int64
+	window time.Duration
+}
+
+// fixedWindowState is the stored document of a fixed-window limiter
+type fixedWindowState struct {
+	Window int64 `json:"window"`
+	Count  int64 `json:"count"`
+}
+
+// FixedWindowLimiter returns the fixed-window rate limiter name
+func (c *Client) FixedWindowLimiter(name string, limit int, window time.Duration) *FixedWindowLimiter {
+	return &FixedWindowLimiter{client: c, name: name, limit: int64(limit), window: window}
+}
+
+// Allow reports whether one event may happen now
+func (l *FixedWindowLimiter) Allow(ctx context.Context) (bool, error) {
+	return l.AllowN(ctx, 1)
+}
+
+// AllowN reports whether n events may happen now, consuming them if so
+func (l *FixedWindowLimiter) AllowN(ctx context.Context, n int) (bool, error) {
+	var state fixedWindowState
+	allowed := false
+	err := l.client.casUpdate(ctx, rateLimitsCollection, l.name, &state, func(exists bool) (bool, error) {
+		window := time.Now().UnixNano() / int64(l.window)
+		if state.Window != window {
+			state = fixedWindowState{Window: window}
+		}
+		if state.Count+int64(n) > l.limit {
+			allowed = false
+			return false, nil
+		}
+		state.Count += int64(n)
+		allowed = true
+		return true, nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to check rate limit %s: %w", l.name, err)
+	}
+	return allowed, nil
+}
+
+// TokenBucketLimiter allows bursts of up to burst events and refills at rate events
+// per second, shared across all processes using its name
+type TokenBucketLimiter struct {
+	client *Client
+	name   string
+	rate   float64
+	burst  float64
+}
+
+// tokenBucketState is the stored document of a token bucket
+type tokenBucketState struct {
+	Tokens    float64 `json:"tokens"`
+	UpdatedAt int64   `json:"updated_at"`
+}
+
+// TokenBucketLimiter returns the token-bucket rate limiter name
+func (c *Client) TokenBucketLimiter(name string, rate float64, burst int) *TokenBucketLimiter {
+	return &TokenBucketLimiter{client: c, name: name, rate: rate, burst: float64(burst)}
+}
+
+// Allow reports whether one event may happen now
+func (l *TokenBucketLimiter) Allow(ctx context.Context) (bool, error) {
+	ok, _, err := l.take(ctx, 1)
+	return ok, err
+}
+
+// AllowN reports whether n events may happen now, consuming n tokens if so
+func (l *TokenBucketLimiter) AllowN(ctx context.Context, n int) (bool, error) {
+	ok, _, err := l.take(ctx, n)
+	return ok, err
+}
+
+// Wait blocks until a token is available or ctx is done
+func (l *TokenBucketLimiter) Wait(ctx context.Context) error {
+	return l.wait(ctx, time.Time{})
+}
+
+// WaitWithin is Wait bounded by d. It returns a *TimeoutError matching ErrTimeout
+// as soon as the bucket cannot refill a token within d, without sleeping first.
+func (l *TokenBucketLimiter) WaitWithin(ctx context.Context, d time.Duration) error {
+	deadline := time.Now().Add(d)
+	return within(ctx, "wait for rate limit "+l.name, d, func(ctx context.Context) error {
+		return l.wait(ctx, deadline)
+	})
+}
+
+// wait takes a token, sleeping while the bucket is empty. It fails with
+// context.DeadlineExceeded as soon as the next token arrives after a non-zero deadline.
+func (l *TokenBucketLimiter) wait(ctx context.Context, deadline time.Time) error {
+	for {
+		ok, wait, err := l.take(ctx, 1)
+		if err != nil || ok {
+			return err
+		}
+		if !deadline.IsZero() && time.Now().Add(wait).After(deadline) {
+			return context.DeadlineExceeded
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// take consumes n tokens if available, otherwise returns how long until they will be
+func (l *TokenBucketLimiter) take(ctx context.Context, n int) (bool, time.Duration, error) {
+	if float64(n) > l.burst {
+		return false, 0, fmt.Errorf("rate limit %s: %d tokens exceed burst %v", l.name, n, l.burst)
+	}
+
+	var state tokenBucketState
+	allowed := false
+	var wait time.Duration
+	err := l.client.casUpdate(ctx, rateLimitsCollection, l.name, &state, func(exists bool) (bool, error) {
+		now := time.Now().UnixMilli()
+		if !exists {
+			state.Tokens = l.burst
+		} else if elapsed := now - state.UpdatedAt; elapsed > 0 {
+			state.Tokens = math.Min(l.burst, state.Tokens+float64(elapsed)/1000*l.rate)
+		}
+		state.UpdatedAt = now
+
+		if state.Tokens < float64(n) {
+			allowed = false
+			wait = time.Duration((float64(n) - state.Tokens) / l.rate * float64(time.Second))
+			return false, nil
+		}
+		state.Tokens -= float64(n)
+		allowed = true
+		return true, nil
+	})
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to check rate limit %s: %w", l.name, err)
+	}
+	return allowed, wait, nil
+}
@@ -0,0 +1,135 @@
+package themisdb
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// allowConcurrently calls allow from n goroutines and returns how many were allowed
+func allowConcurrently(t *testing.T, n int, allow func() (bool, error)) int32 {
+	var allowed int32
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := allow()
+			assert.NoError(t, err)
+			if ok {
+				atomic.AddInt32(&allowed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	return allowed
+}
+
+func TestFixedWindowLimiter_Contention(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	ctx := context.Background()
+	limiter := client.FixedWindowLimiter("api", 10, time.Hour)
+
+	allowed := allowConcurrently(t, 40, func() (bool, error) { return limiter.Allow(ctx) })
+	assert.Equal(t, int32(10), allowed)
+
+	ok, err := limiter.AllowN(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestFixedWindowLimiter_Resets(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	ctx := context.Background()
+	limiter := client.FixedWindowLimiter("api", 1, 20*time.Millisecond)
+
+	// align to the start of a window
+	time.Sleep(time.Duration(20e6 - time.Now().UnixNano()%20e6))
+	ok, err := limiter.Allow(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = limiter.Allow(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	time.Sleep(25 * time.Millisecond)
+	ok, err = limiter.Allow(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestTokenBucketLimiter_Contention(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	ctx := context.Background()
+	limiter := client.TokenBucketLimiter("api", 0.001, 5)
+
+	allowed := allowConcurrently(t, 30, func() (bool, error) { return limiter.Allow(ctx) })
+	assert.Equal(t, int32(5), allowed)
+
+	_, err := limiter.AllowN(ctx, 6)
+	assert.Error(t, err)
+}
+
+func TestTokenBucketLimiter_Wait(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	limiter := client.TokenBucketLimiter("api", 100, 1)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, limiter.Wait(ctx))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 15*time.Millisecond)
+}
+
+func TestTokenBucketLimiter_WaitWithin(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	ctx := context.Background()
+	limiter := client.TokenBucketLimiter("api", 1, 1)
+
+	require.NoError(t, limiter.WaitWithin(ctx, time.Second))
+	start := time.Now()
+	err := limiter.WaitWithin(ctx, 200*time.Millisecond)
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.Less(t, time.Since(start), 100*time.Millisecond, "fails without waiting for a token that cannot arrive in time")
+
+	limiter = client.TokenBucketLimiter("fast", 100, 1)
+	require.NoError(t, limiter.WaitWithin(ctx, time.Second))
+	require.NoError(t, limiter.WaitWithin(ctx, time.Second))
+}
+
+func BenchmarkFixedWindowLimiter_Allow(b *testing.B) {
+	client, _ := newMemoryClient(b)
+	ctx := context.Background()
+	limiter := client.FixedWindowLimiter("bench", 1<<30, time.Hour)
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := limiter.Allow(ctx); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkTokenBucketLimiter_Allow(b *testing.B) {
+	client, _ := newMemoryClient(b)
+	ctx := context.Background()
+	limiter := client.TokenBucketLimiter("bench", 1e9, 1<<30)
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := limiter.Allow(ctx); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,373 @@
+package themisdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Action is an operation a role may be granted on a collection
+type Action string
+
+const (
+	// ActionRead allows reads, scans, and queries
+	ActionRead Action = "read"
+	// ActionWrite allows creating, updating, and deleting documents
+	ActionWrite Action = "write"
+	// ActionAdmin allows managing the collection, e.g. its indexes and schema
+	ActionAdmin Action = "admin"
+)
+
+// Permission grants actions on a collection. An empty Model or Collection matches all
+// models or all collections of the model.
+type Permission struct {
+	Model      string   `json:"model,omitempty"`
+	Collection string   `json:"collection,omitempty"`
+	Actions    []Action `json:"actions"`
+}
+
+// Role is a named set of permissions
+type Role struct {
+	Name        string       `json:"name"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// User is an account of the server
+type User struct {
+	Name      string    `json:"name"`
+	Roles     []string  `json:"roles"`
+	Disabled  bool      `json:"disabled"`
+	CreatedAt time.Time `json:"created_at"`
+	// PasswordChangedAt is when the password was last set
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+}
+
+// UserOptions describes a user to create
+type UserOptions struct {
+	Name string `json:"name"`
+	// Password is optional for users authenticating with API keys only
+	Password string   `json:"password,omitempty"`
+	Roles    []string `json:"roles,omitempty"`
+}
+
+// APIKey is an API key of a user. The secret Key is only returned when the key is
+// created or rotated.
+type APIKey struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Key  string `json:"key,omitempty"`
+	// ExpiresAt is zero for a key that does not expire
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	// LastUsedAt is zero for a key that was never used
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+// Users manages the users of the server and their credentials
+type Users struct {
+	client *Client
+}
+
+// Roles manages the roles of the server and their permissions
+type Roles struct {
+	client *Client
+}
+
+// Users returns the user management of the server
+func (a *Admin) Users() *Users {
+	return &Users{client: a.client}
+}
+
+// Roles returns the role management of the server
+func (a *Admin) Roles() *Roles {
+	return &Roles{client: a.client}
+}
+
+// Create creates a user
+func (u *Users) Create(ctx context.Context, opts UserOptions) (*User, error) {
+	if err := validateKey("user", opts.Name); err != nil {
+		return nil, err
+	}
+	for _, role := range opts.Roles {
+		if err := validateName("role", role); err != nil {
+			return nil, err
+		}
+	}
+	var user User
+	if err := u.client.request(ctx, "POST", "/admin/users", opts, &user, nil); err != nil {
+		return nil, fmt.Errorf("failed to create user %s: %w", opts.Name, err)
+	}
+	return &user, nil
+}
+
+// Get returns a user; it fails with ErrNotFound if there is none with that name
+func (u *Users) Get(ctx context.Context, name string) (*User, error) {
+	if err := validateKey("user", name); err != nil {
+		return nil, err
+	}
+	var user User
+	if err := u.client.request(ctx, "GET", joinPath("/admin/users", name), nil, &user, nil); err != nil {
+		return nil, fmt.Errorf("failed to get user %s: %w", name, err)
+	}
+	return &user, nil
+}
+
+// List returns all users
+func (u *Users) List(ctx context.Context) ([]User, error) {
+	var response struct {
+		Users []User `json:"users"`
+	}
+	if err := u.client.request(ctx, "GET", "/admin/users", nil, &response, nil); err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	return response.Users, nil
+}
+
+// Delete deletes a user together with its API keys
+func (u *Users) Delete(ctx context.Context, name string) error {
+	if err := validateKey("user", name); err != nil {
+		return err
+	}
+	if err := u.client.request(ctx, "DELETE", joinPath("/admin/users", name), nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete user %s: %w", name, err)
+	}
+	return nil
+}
+
+// SetRoles replaces the roles of a user
+func (u *Users) SetRoles(ctx context.Context, name string, roles ...string) error {
+	if err := validateKey("user", name); err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if err := validateName("role", role); err != nil {
+			return err
+		}
+	}
+	if roles == nil {
+		roles = []string{}
+	}
+	body := map[string]interface{}{
+		"roles": roles,
+	}
+	if err := u.client.request(ctx, "PATCH", joinPath("/admin/users", name), body, nil, nil); err != nil {
+		return fmt.Errorf("failed to set roles of user %s: %w", name, err)
+	}
+	return nil
+}
+
+// SetDisabled disables or enables a user; a disabled user cannot authenticate
+func (u *Users) SetDisabled(ctx context.Context, name string, disabled bool) error {
+	if err := validateKey("user", name); err != nil {
+		return err
+	}
+	body := map[string]interface{}{
+		"disabled": disabled,
+	}
+	if err := u.client.request(ctx, "PATCH", joinPath("/admin/users", name), body, nil, nil); err != nil {
+		return fmt.Errorf("failed to update user %s: %w", name, err)
+	}
+	return nil
+}
+
+// SetPassword replaces the password of a user. Sessions authenticated with the old
+// password are revoked by the server.
+func (u *Users) SetPassword(ctx context.Context, name, password string) error {
+	if err := validateKey("user", name); err != nil {
+		return err
+	}
+	if password == "" {
+		return &ValidationError{Field: "password", Value: name, Reason: "must not be empty"}
+	}
+	body := map[string]interface{}{
+		"password": password,
+	}
+	if err := u.client.request(ctx, "PUT", joinPath("/admin/users", name)+"/password", body, nil, nil); err != nil {
+		return fmt.Errorf("failed to set password of user %s: %w", name, err)
+	}
+	return nil
+}
+
+// CreateAPIKey creates an API key for a user, valid for ttl or without expiry if ttl
+// is 0. The returned key holds the secret, which cannot be read again.
+func (u *Users) CreateAPIKey(ctx context.Context, user, name string, ttl time.Duration) (*APIKey, error) {
+	if err := validateKey("user", user); err != nil {
+		return nil, err
+	}
+	body := map[string]interface{}{
+		"name": name,
+	}
+	if ttl > 0 {
+		body["ttl_seconds"] = int64(ttl / time.Second)
+	}
+	var key APIKey
+	if err := u.client.request(ctx, "POST", joinPath("/admin/users", user)+"/api-keys", body, &key, nil); err != nil {
+		return nil, fmt.Errorf("failed to create API key for user %s: %w", user, err)
+	}
+	return &key, nil
+}
+
+// ListAPIKeys returns the API keys of a user, without their secrets
+func (u *Users) ListAPIKeys(ctx context.Context, user string) ([]APIKey, error) {
+	if err := validateKey("user", user); err != nil {
+		return nil, err
+	}
+	var response struct {
+		Keys []APIKey `json:"api_keys"`
+	}
+	if err := u.client.request(ctx, "GET", joinPath("/admin/users", user)+"/api-keys", nil, &response, nil); err != nil {
+		return nil, fmt.Errorf("failed to list API keys of user %s: %w", user, err)
+	}
+	return response.Keys, nil
+}
+
+// RotateAPIKey replaces an API key of a user with a new secret under the same ID. The
+// old secret stays valid for grace, so that deployments can switch over without
+// downtime; 0 revokes it at once.
+func (u *Users) RotateAPIKey(ctx context.Context, user, id string, grace time.Duration) (*APIKey, error) {
+	if err := validateKey("user", user); err != nil {
+		return nil, err
+	}
+	if err := validateKey("API key", id); err != nil {
+		return nil, err
+	}
+	body := map[string]interface{}{
+		"grace_seconds": int64(grace / time.Second),
+	}
+	var key APIKey
+	if err := u.client.request(ctx, "POST", joinPath("/admin/users", user, "api-keys", id)+"/rotate", body, &key, nil); err != nil {
+		return nil, fmt.Errorf("failed to rotate API key %s of user %s: %w", id, user, err)
+	}
+	return &key, nil
+}
+
+// RevokeAPIKey deletes an API key of a user
+func (u *Users) RevokeAPIKey(ctx context.Context, user, id string) error {
+	if err := validateKey("user", user); err != nil {
+		return err
+	}
+	if err := validateKey("API key", id); err != nil {
+		return err
+	}
+	if err := u.client.request(ctx, "DELETE", joinPath("/admin/users", user, "api-keys", id), nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to revoke API key %s of user %s: %w", id, user, err)
+	}
+	return nil
+}
+
+// Create creates a role with permissions
+func (r *Roles) Create(ctx context.Context, role Role) error {
+	if err := validateName("role", role.Name); err != nil {
+		return err
+	}
+	for _, p := range role.Permissions {
+		if err := p.validate(); err != nil {
+			return err
+		}
+	}
+	if role.Permissions == nil {
+		role.Permissions = []Permission{}
+	}
+	if err := r.client.request(ctx, "POST", "/admin/roles", role, nil, nil); err != nil {
+		return fmt.Errorf("failed to create role %s: %w", role.Name, err)
+	}
+	return nil
+}
+
+// Get returns a role with its permissions
+func (r *Roles) Get(ctx context.Context, name string) (*Role, error) {
+	if err := validateName("role", name); err != nil {
+		return nil, err
+	}
+	var role Role
+	if err := r.client.request(ctx, "GET", joinPath("/admin/roles", name), nil, &role, nil); err != nil {
+		return nil, fmt.Errorf("failed to get role %s: %w", name, err)
+	}
+	return &role, nil
+}
+
+// List returns all roles
+func (r *Roles) List(ctx context.Context) ([]Role, error) {
+	var response struct {
+		Roles []Role `json:"roles"`
+	}
+	if err := r.client.request(ctx, "GET", "/admin/roles", nil, &response, nil); err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	return response.Roles, nil
+}
+
+// Delete deletes a role; users keep their other roles
+func (r *Roles) Delete(ctx context.Context, name string) error {
+	if err := validateName("role", name); err != nil {
+		return err
+	}
+	if err := r.client.request(ctx, "DELETE", joinPath("/admin/roles", name), nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete role %s: %w", name, err)
+	}
+	return nil
+}
+
+// Grant adds the actions of p on its collection to a role
+func (r *Roles) Grant(ctx context.Context, role string, p Permission) error {
+	return r.change(ctx, role, "grant", p)
+}
+
+// Revoke removes the actions of p on its collection from a role
+func (r *Roles) Revoke(ctx context.Context, role string, p Permission) error {
+	return r.change(ctx, role, "revoke", p)
+}
+
+// change grants or revokes a permission
+func (r *Roles) change(ctx context.Context, role, op string, p Permission) error {
+	if err := validateName("role", role); err != nil {
+		return err
+	}
+	if err := p.validate(); err != nil {
+		return err
+	}
+	if err := r.client.request(ctx, "POST", joinPath("/admin/roles", role)+"/"+op, p, nil, nil); err != nil {
+		return fmt.Errorf("failed to %s %v on %s to role %s: %w", op, p.Actions, p.scope(), role, err)
+	}
+	return nil
+}
+
+// validate checks the scope and actions of a permission
+func (p Permission) validate() error {
+	if p.Model != "" {
+		if err := validateName("model", p.Model); err != nil {
+			return err
+		}
+	}
+	if p.Collection != "" {
+		if p.Model == "" {
+			return &ValidationError{Field: "permission", Value: p.Collection, Reason: "a collection requires a model"}
+		}
+		if err := validateName("collection", p.Collection); err != nil {
+			return err
+		}
+	}
+	if len(p.Actions) == 0 {
+		return &ValidationError{Field: "actions", Value: p.scope(), Reason: "must not be empty"}
+	}
+	for _, a := range p.Actions {
+		switch a {
+		case ActionRead, ActionWrite, ActionAdmin:
+		default:
+			return &ValidationError{Field: "actions", Value: string(a), Reason: "must be read, write, or admin"}
+		}
+	}
+	return nil
+}
+
+// scope describes the collections a permission applies to
+func (p Permission) scope() string {
+	switch {
+	case p.Model == "":
+		return "*"
+	case p.Collection == "":
+		return p.Model + "/*"
+	default:
+		return p.Model + "/" + p.Collection
+	}
+}
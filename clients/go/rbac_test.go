@@ -0,0 +1,143 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rbacRequest is a request recorded by the user and role handlers
+type rbacRequest struct {
+	method string
+	path   string
+	body   map[string]interface{}
+}
+
+func TestUsers(t *testing.T) {
+	var requests []rbacRequest
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, rbacRequest{r.Method, r.URL.EscapedPath(), body})
+		switch {
+		case r.URL.Path == "/admin/users" && r.Method == "GET":
+			json.NewEncoder(w).Encode(map[string]interface{}{"users": []User{{Name: "ada", Roles: []string{"analyst"}}}})
+		case r.URL.Path == "/admin/users/ghost":
+			http.Error(w, "unknown user", http.StatusNotFound)
+		case r.URL.Path == "/admin/users/ada/api-keys" && r.Method == "GET":
+			json.NewEncoder(w).Encode(map[string]interface{}{"api_keys": []APIKey{{ID: "k1", Name: "reporting"}}})
+		case r.Method == "POST" && r.URL.Path != "/admin/users":
+			json.NewEncoder(w).Encode(APIKey{ID: "k1", Name: "reporting", Key: "secret"})
+		default:
+			json.NewEncoder(w).Encode(User{Name: "ada", Roles: []string{"analyst"}})
+		}
+	})
+	users := client.Admin().Users()
+	ctx := context.Background()
+
+	user, err := users.Create(ctx, UserOptions{Name: "ada", Password: "pw", Roles: []string{"analyst"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"analyst"}, user.Roles)
+	list, err := users.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	_, err = users.Get(ctx, "ghost")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, users.SetRoles(ctx, "ada"))
+	require.NoError(t, users.SetDisabled(ctx, "ada", true))
+	require.NoError(t, users.SetPassword(ctx, "ada", "new"))
+
+	key, err := users.CreateAPIKey(ctx, "ada", "reporting", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", key.Key)
+	keys, err := users.ListAPIKeys(ctx, "ada")
+	require.NoError(t, err)
+	assert.Equal(t, "k1", keys[0].ID)
+	_, err = users.RotateAPIKey(ctx, "ada", "k1", 10*time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, users.RevokeAPIKey(ctx, "ada", "k1"))
+	require.NoError(t, users.Delete(ctx, "ada"))
+
+	assert.Equal(t, []rbacRequest{
+		{"POST", "/admin/users", map[string]interface{}{"name": "ada", "password": "pw", "roles": []interface{}{"analyst"}}},
+		{"GET", "/admin/users", nil},
+		{"GET", "/admin/users/ghost", nil},
+		{"PATCH", "/admin/users/ada", map[string]interface{}{"roles": []interface{}{}}},
+		{"PATCH", "/admin/users/ada", map[string]interface{}{"disabled": true}},
+		{"PUT", "/admin/users/ada/password", map[string]interface{}{"password": "new"}},
+		{"POST", "/admin/users/ada/api-keys", map[string]interface{}{"name": "reporting", "ttl_seconds": 3600.0}},
+		{"GET", "/admin/users/ada/api-keys", nil},
+		{"POST", "/admin/users/ada/api-keys/k1/rotate", map[string]interface{}{"grace_seconds": 600.0}},
+		{"DELETE", "/admin/users/ada/api-keys/k1", nil},
+		{"DELETE", "/admin/users/ada", nil},
+	}, requests)
+}
+
+func TestRoles(t *testing.T) {
+	var requests []rbacRequest
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, rbacRequest{r.Method, r.URL.Path, body})
+		if r.Method == "GET" && r.URL.Path == "/admin/roles" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"roles": []Role{{Name: "analyst"}}})
+			return
+		}
+		json.NewEncoder(w).Encode(Role{Name: "analyst", Permissions: []Permission{{Model: "relational", Actions: []Action{ActionRead}}}})
+	})
+	roles := client.Admin().Roles()
+	ctx := context.Background()
+
+	require.NoError(t, roles.Create(ctx, Role{Name: "analyst"}))
+	read := Permission{Model: "relational", Collection: "orders", Actions: []Action{ActionRead}}
+	require.NoError(t, roles.Grant(ctx, "analyst", read))
+	require.NoError(t, roles.Revoke(ctx, "analyst", Permission{Actions: []Action{ActionWrite, ActionAdmin}}))
+	role, err := roles.Get(ctx, "analyst")
+	require.NoError(t, err)
+	assert.Equal(t, []Action{ActionRead}, role.Permissions[0].Actions)
+	list, err := roles.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+	require.NoError(t, roles.Delete(ctx, "analyst"))
+
+	assert.Equal(t, []rbacRequest{
+		{"POST", "/admin/roles", map[string]interface{}{"name": "analyst", "permissions": []interface{}{}}},
+		{"POST", "/admin/roles/analyst/grant", map[string]interface{}{"model": "relational", "collection": "orders", "actions": []interface{}{"read"}}},
+		{"POST", "/admin/roles/analyst/revoke", map[string]interface{}{"actions": []interface{}{"write", "admin"}}},
+		{"GET", "/admin/roles/analyst", nil},
+		{"GET", "/admin/roles", nil},
+		{"DELETE", "/admin/roles/analyst", nil},
+	}, requests)
+}
+
+func TestRBAC_Validation(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+	ctx := context.Background()
+	users, roles := client.Admin().Users(), client.Admin().Roles()
+
+	_, err := users.Create(ctx, UserOptions{})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	_, err = users.Create(ctx, UserOptions{Name: "ada", Roles: []string{"bad role"}})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	assert.ErrorIs(t, users.SetPassword(ctx, "ada", ""), ErrInvalidInput)
+	_, err = users.RotateAPIKey(ctx, "ada", "", 0)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	for _, p := range []Permission{
+		{Model: "relational"},
+		{Model: "relational", Actions: []Action{"delete"}},
+		{Collection: "orders", Actions: []Action{ActionRead}},
+		{Model: "relational", Collection: "or ders", Actions: []Action{ActionRead}},
+	} {
+		assert.ErrorIs(t, roles.Grant(ctx, "analyst", p), ErrInvalidInput, "%+v", p)
+	}
+	assert.ErrorIs(t, roles.Create(ctx, Role{Name: "a/b"}), ErrInvalidInput)
+}
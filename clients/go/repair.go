@@ -0,0 +1,163 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ConflictPolicy decides which side wins when a document differs between two clusters
+type ConflictPolicy string
+
+const (
+	// SourceWins overwrites divergent target documents with the source version
+	SourceWins ConflictPolicy = "source_wins"
+	// TargetWins only copies documents missing on the target
+	TargetWins ConflictPolicy = "target_wins"
+	// NewestWins keeps whichever version was updated last
+	NewestWins ConflictPolicy = "newest_wins"
+)
+
+// RangeEntry describes a single document within a key range
+type RangeEntry struct {
+	UUID      string          `json:"uuid"`
+	Hash      string          `json:"hash"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Document  json.RawMessage `json:"document"`
+}
+
+// RangeEntries lists the documents of a collection within a key range, with their hashes
+func (a *Admin) RangeEntries(ctx context.Context, collection string, r KeyRange) ([]RangeEntry, error) {
+	if err := validateName("collection", collection); err != nil {
+		return nil, err
+	}
+	path := joinPath("/admin/collections", collection) + "/range"
+
+	var response struct {
+		Entries []RangeEntry `json:"entries"`
+	}
+	if err := a.client.request(ctx, "POST", path, r, &response, nil); err != nil {
+		return nil, fmt.Errorf("failed to list range entries: %w", err)
+	}
+	return response.Entries, nil
+}
+
+// RepairOptions holds read repair configuration
+type RepairOptions struct {
+	// Model is the data model of the collection (default: relational)
+	Model string
+	// Policy resolves documents that exist on both sides with different hashes (default: SourceWins)
+	Policy ConflictPolicy
+	// Resolve, if set, overrides Policy and returns the document to write to the target.
+	// Returning nil leaves the target document untouched.
+	Resolve func(uuid string, source, target RangeEntry) json.RawMessage
+	// DeleteExtraneous removes target documents that do not exist on the source
+	DeleteExtraneous bool
+	// DryRun reports the planned changes without writing to the target
+	DryRun bool
+}
+
+// RepairReport summarizes the changes made by RepairRange
+type RepairReport struct {
+	Copied    []string
+	Replaced  []string
+	Deleted   []string
+	Unchanged int
+	Skipped   []string
+}
+
+// RepairRange copies divergent documents in a key range from source to target.
+// It is intended for operator-driven consistency repair of ranges reported by DiffChecksums.
+func RepairRange(ctx context.Context, source, target *Client, collection string, r KeyRange, opts RepairOptions) (*RepairReport, error) {
+	if opts.Model == "" {
+		opts.Model = "relational"
+	}
+	if opts.Policy == "" {
+		opts.Policy = SourceWins
+	}
+
+	sourceEntries, err := source.Admin().RangeEntries(ctx, collection, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source range: %w", err)
+	}
+	targetEntries, err := target.Admin().RangeEntries(ctx, collection, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read target range: %w", err)
+	}
+
+	existing := make(map[string]RangeEntry, len(targetEntries))
+	for _, e := range targetEntries {
+		existing[e.UUID] = e
+	}
+
+	report := &RepairReport{}
+	write := func(uuid string, doc json.RawMessage) error {
+		if opts.DryRun {
+			return nil
+		}
+		if err := target.Put(ctx, opts.Model, collection, uuid, doc); err != nil {
+			return fmt.Errorf("failed to repair %s: %w", uuid, err)
+		}
+		return nil
+	}
+
+	for _, src := range sourceEntries {
+		dst, ok := existing[src.UUID]
+		delete(existing, src.UUID)
+
+		if !ok {
+			if err := write(src.UUID, src.Document); err != nil {
+				return report, err
+			}
+			report.Copied = append(report.Copied, src.UUID)
+			continue
+		}
+		if src.Hash == dst.Hash {
+			report.Unchanged++
+			continue
+		}
+
+		doc := resolveConflict(src, dst, opts)
+		if doc == nil {
+			report.Skipped = append(report.Skipped, src.UUID)
+			continue
+		}
+		if err := write(src.UUID, doc); err != nil {
+			return report, err
+		}
+		report.Replaced = append(report.Replaced, src.UUID)
+	}
+
+	if opts.DeleteExtraneous {
+		for _, dst := range targetEntries {
+			if _, extra := existing[dst.UUID]; !extra {
+				continue
+			}
+			if !opts.DryRun {
+				if err := target.Delete(ctx, opts.Model, collection, dst.UUID); err != nil {
+					return report, fmt.Errorf("failed to delete %s: %w", dst.UUID, err)
+				}
+			}
+			report.Deleted = append(report.Deleted, dst.UUID)
+		}
+	}
+
+	return report, nil
+}
+
+// resolveConflict returns the document to write for a divergent entry, or nil to keep the target
+func resolveConflict(src, dst RangeEntry, opts RepairOptions) json.RawMessage {
+	if opts.Resolve != nil {
+		return opts.Resolve(src.UUID, src, dst)
+	}
+	switch opts.Policy {
+	case TargetWins:
+		return nil
+	case NewestWins:
+		if dst.UpdatedAt.After(src.UpdatedAt) {
+			return nil
+		}
+	}
+	return src.Document
+}
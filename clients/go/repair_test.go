@@ -0,0 +1,82 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRangeClient serves entries for the range endpoint and records writes
+func newRangeClient(t *testing.T, entries []RangeEntry) (*Client, *[]string) {
+	var mu sync.Mutex
+	var writes []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/admin/collections/users/range" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"entries": entries})
+			return
+		}
+		mu.Lock()
+		writes = append(writes, r.Method+" "+strings.TrimPrefix(r.URL.Path, "/api/relational/users/"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return client, &writes
+}
+
+func TestRepairRange(t *testing.T) {
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := old.Add(time.Hour)
+
+	sourceEntries := []RangeEntry{
+		{UUID: "a", Hash: "ha", UpdatedAt: old, Document: json.RawMessage(`{"n":1}`)},
+		{UUID: "b", Hash: "hb", UpdatedAt: old, Document: json.RawMessage(`{"n":2}`)},
+		{UUID: "c", Hash: "hc", UpdatedAt: old, Document: json.RawMessage(`{"n":3}`)},
+	}
+	targetEntries := []RangeEntry{
+		{UUID: "b", Hash: "hb", UpdatedAt: old},
+		{UUID: "c", Hash: "hc-target", UpdatedAt: newer},
+		{UUID: "d", Hash: "hd", UpdatedAt: old},
+	}
+
+	t.Run("source wins", func(t *testing.T) {
+		source, _ := newRangeClient(t, sourceEntries)
+		target, writes := newRangeClient(t, targetEntries)
+
+		report, err := RepairRange(context.Background(), source, target, "users", KeyRange{}, RepairOptions{DeleteExtraneous: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a"}, report.Copied)
+		assert.Equal(t, []string{"c"}, report.Replaced)
+		assert.Equal(t, []string{"d"}, report.Deleted)
+		assert.Equal(t, 1, report.Unchanged)
+		assert.Equal(t, []string{"PUT a", "PUT c", "DELETE d"}, *writes)
+	})
+
+	t.Run("newest wins", func(t *testing.T) {
+		source, _ := newRangeClient(t, sourceEntries)
+		target, writes := newRangeClient(t, targetEntries)
+
+		report, err := RepairRange(context.Background(), source, target, "users", KeyRange{}, RepairOptions{Policy: NewestWins})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"c"}, report.Skipped)
+		assert.Empty(t, report.Deleted)
+		assert.Equal(t, []string{"PUT a"}, *writes)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		source, _ := newRangeClient(t, sourceEntries)
+		target, writes := newRangeClient(t, targetEntries)
+
+		report, err := RepairRange(context.Background(), source, target, "users", KeyRange{}, RepairOptions{DryRun: true, DeleteExtraneous: true})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a"}, report.Copied)
+		assert.Equal(t, []string{"d"}, report.Deleted)
+		assert.Empty(t, *writes)
+	})
+}
@@ -0,0 +1,243 @@
+package themisdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheEntry is a cached Get response
+type CacheEntry struct {
+	// Body is the JSON-encoded entity
+	Body []byte
+	// ETag is the revision of the entity, used to revalidate stale entries
+	ETag string
+	// FreshUntil is when the entry must be revalidated with the server
+	FreshUntil time.Time
+}
+
+// CacheBackend stores cached Get responses. Implementations backed by Redis or
+// ristretto let several clients share a cache; they must be safe for concurrent use.
+type CacheBackend interface {
+	Get(key string) (CacheEntry, bool)
+	Set(key string, entry CacheEntry, ttl time.Duration)
+	Delete(key string)
+}
+
+// ResponseCacheOptions configures the client-side Get cache. Entries are served
+// without a round trip for TTL, then revalidated with If-None-Match. Local writes to
+// an entity, including increments and bulk writes by Import and Writer, invalidate
+// its entry; AQL queries that may write invalidate all entries of the namespace cached
+// until then. Writes by other clients become visible after TTL.
+type ResponseCacheOptions struct {
+	// TTL is how long an entry is served without contacting the server (default: 30s)
+	TTL time.Duration
+	// RevalidateFor keeps stale entries for ETag revalidation this long after TTL (default: 5m)
+	RevalidateFor time.Duration
+	// MaxEntries bounds the default in-memory backend (default: 10000)
+	MaxEntries int
+	// Backend replaces the in-memory LRU backend
+	Backend CacheBackend
+	// StaleIfError serves an expired entry when the server cannot be reached or fails
+	// with a server error, as long as the entry expired at most this long ago; zero
+	// disables it. GetWithMeta reports such responses as Stale.
+	StaleIfError time.Duration
+}
+
+// GetMeta describes how a Get was served
+type GetMeta struct {
+	// Cached reports that the entity was served from the client cache without a new
+	// response body, either fresh or revalidated
+	Cached bool
+	// Stale reports an expired entry served because the server was unavailable (see
+	// ResponseCacheOptions.StaleIfError)
+	Stale bool
+	// Age is how long ago a stale entry expired
+	Age time.Duration
+}
+
+// responseCache caches Get responses keyed by namespace and entity path
+type responseCache struct {
+	opts    ResponseCacheOptions
+	backend CacheBackend
+
+	mu sync.Mutex
+	// flushed is when each namespace was last written by an AQL query; entries cached
+	// before are dropped
+	flushed map[string]time.Time
+}
+
+// newResponseCache returns a cache for opts, or nil if caching is disabled
+func newResponseCache(opts *ResponseCacheOptions) *responseCache {
+	if opts == nil {
+		return nil
+	}
+	o := *opts
+	if o.TTL <= 0 {
+		o.TTL = 30 * time.Second
+	}
+	if o.RevalidateFor <= 0 {
+		o.RevalidateFor = 5 * time.Minute
+	}
+	if o.MaxEntries <= 0 {
+		o.MaxEntries = 10000
+	}
+	backend := o.Backend
+	if backend == nil {
+		backend = &memoryCacheBackend{lru: newLRUCache(o.MaxEntries)}
+	}
+	return &responseCache{opts: o, backend: backend, flushed: map[string]time.Time{}}
+}
+
+// GetWithMeta retrieves an entity by UUID like Get and reports whether it was served
+// from the client cache, and whether the cached entry was stale
+func (c *Client) GetWithMeta(ctx context.Context, model, collection, uuid string, result interface{}) (*GetMeta, error) {
+	if ctx, tx := c.contextTx(ctx); tx != nil {
+		return &GetMeta{}, tx.Get(ctx, model, collection, uuid, result)
+	}
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return nil, err
+	}
+	path := entityPath(model, collection, uuid)
+	if c.cache != nil {
+		return c.cache.get(ctx, c, path, result)
+	}
+	if err := c.readRequest(ctx, "GET", path, nil, result, nil); err != nil {
+		return nil, err
+	}
+	return &GetMeta{}, nil
+}
+
+// get serves the entity at path from the cache, revalidating or fetching it as needed
+func (rc *responseCache) get(ctx context.Context, c *Client, path string, result interface{}) (*GetMeta, error) {
+	ns := c.namespaceOf(ctx)
+	key := ns + path
+	entry, cached := rc.backend.Get(key)
+	if cached && rc.flushedAfter(ns, entry) {
+		rc.backend.Delete(key)
+		entry, cached = CacheEntry{}, false
+	}
+	if cached && time.Now().Before(entry.FreshUntil) {
+		return &GetMeta{Cached: true}, rc.decode(c, path, entry, result)
+	}
+
+	var headers map[string]string
+	if cached && entry.ETag != "" {
+		headers = map[string]string{"If-None-Match": entry.ETag}
+	}
+	req, err := c.newRequest("GET", path, nil, headers)
+	if err != nil {
+		return nil, err
+	}
+	req.Idempotent = true
+
+	resp, err := c.Do(ctx, req)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			rc.backend.Delete(key)
+		}
+		if age := time.Since(entry.FreshUntil); cached && rc.servesStale(ctx, resp) && age <= rc.opts.StaleIfError {
+			return &GetMeta{Cached: true, Stale: true, Age: age}, rc.decode(c, path, entry, result)
+		}
+		return nil, err
+	}
+	meta := &GetMeta{}
+	if resp.StatusCode == http.StatusNotModified && cached {
+		entry.FreshUntil = time.Now().Add(rc.opts.TTL)
+		meta.Cached = true
+	} else {
+		entry = CacheEntry{Body: resp.Body, ETag: resp.Header.Get("ETag"), FreshUntil: time.Now().Add(rc.opts.TTL)}
+	}
+	rc.backend.Set(key, entry, rc.opts.TTL+rc.retainFor())
+	return meta, rc.decode(c, path, entry, result)
+}
+
+// servesStale reports whether a failed request may be answered with a stale entry:
+// the server was unreachable or failed, and the caller is still waiting
+func (rc *responseCache) servesStale(ctx context.Context, resp *Response) bool {
+	if rc.opts.StaleIfError <= 0 || ctx.Err() != nil {
+		return false
+	}
+	return resp == nil || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// retainFor is how long entries are kept after they expire, for revalidation and
+// stale-if-error
+func (rc *responseCache) retainFor() time.Duration {
+	if rc.opts.StaleIfError > rc.opts.RevalidateFor {
+		return rc.opts.StaleIfError
+	}
+	return rc.opts.RevalidateFor
+}
+
+// invalidate drops the entry of the entity written by req, or all entries of its
+// namespace if req is an AQL query that may write
+func (rc *responseCache) invalidate(ctx context.Context, c *Client, req *Request) {
+	if req.ReadOnly {
+		return
+	}
+	path := req.Path
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	ns := req.Header[headerNamespace]
+	if ns == "" {
+		ns = c.namespaceOf(ctx)
+	}
+	switch {
+	case path == "/api/query" || path == "/api/query/execute":
+		rc.mu.Lock()
+		rc.flushed[ns] = time.Now()
+		rc.mu.Unlock()
+	case strings.HasPrefix(path, "/api/") && strings.Count(path, "/") == 4:
+		rc.backend.Delete(ns + path)
+	}
+}
+
+// flushedAfter reports whether an AQL query wrote to namespace ns after entry was cached
+func (rc *responseCache) flushedAfter(ns string, entry CacheEntry) bool {
+	rc.mu.Lock()
+	flushed, ok := rc.flushed[ns]
+	rc.mu.Unlock()
+	return ok && entry.FreshUntil.Add(-rc.opts.TTL).Before(flushed)
+}
+
+// decode decodes the body of a cache entry into result and records its fields (see
+// Config.FieldStats)
+func (rc *responseCache) decode(c *Client, path string, entry CacheEntry, result interface{}) error {
+	if result == nil || len(entry.Body) == 0 {
+		return nil
+	}
+	if err := c.decode(entry.Body, result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	c.fieldStats.record(collectionOf(&Request{Path: path}), entry.Body, result)
+	return nil
+}
+
+// memoryCacheBackend is the default in-memory CacheBackend
+type memoryCacheBackend struct {
+	lru *lruCache
+}
+
+// Get implements CacheBackend
+func (m *memoryCacheBackend) Get(key string) (CacheEntry, bool) {
+	value, ok := m.lru.get(key)
+	if !ok {
+		return CacheEntry{}, false
+	}
+	return value.(CacheEntry), true
+}
+
+// Set implements CacheBackend
+func (m *memoryCacheBackend) Set(key string, entry CacheEntry, ttl time.Duration) {
+	m.lru.set(key, entry, time.Now().Add(ttl))
+}
+
+// Delete implements CacheBackend
+func (m *memoryCacheBackend) Delete(key string) {
+	m.lru.delete(key)
+}
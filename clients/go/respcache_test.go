@@ -0,0 +1,195 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statusRecorder is an interceptor recording the status codes of GET responses
+type statusRecorder struct {
+	statuses []int
+}
+
+func (s *statusRecorder) intercept(ctx context.Context, req *Request, next Handler) (*Response, error) {
+	resp, err := next(ctx, req)
+	if req.Method == "GET" && resp != nil {
+		s.statuses = append(s.statuses, resp.StatusCode)
+	}
+	return resp, err
+}
+
+func TestClient_ResponseCache(t *testing.T) {
+	base, _ := newMemoryClient(t)
+	recorder := &statusRecorder{}
+	client := NewClient(Config{
+		Endpoints:    base.endpoints,
+		Cache:        &ResponseCacheOptions{TTL: 50 * time.Millisecond},
+		Interceptors: []Interceptor{recorder.intercept},
+	})
+	ctx := context.Background()
+	require.NoError(t, client.Put(ctx, "relational", "users", "1", map[string]string{"name": "alice"}))
+
+	var user map[string]string
+	require.NoError(t, client.Get(ctx, "relational", "users", "1", &user))
+	require.NoError(t, client.Get(ctx, "relational", "users", "1", &user))
+	assert.Equal(t, "alice", user["name"])
+	assert.Equal(t, []int{http.StatusOK}, recorder.statuses)
+
+	// stale entries are revalidated with their ETag
+	time.Sleep(60 * time.Millisecond)
+	user = nil
+	require.NoError(t, client.Get(ctx, "relational", "users", "1", &user))
+	assert.Equal(t, "alice", user["name"])
+	assert.Equal(t, []int{http.StatusOK, http.StatusNotModified}, recorder.statuses)
+
+	// local writes invalidate
+	require.NoError(t, client.Patch(ctx, "relational", "users", "1", map[string]string{"name": "bob"}))
+	require.NoError(t, client.Get(ctx, "relational", "users", "1", &user))
+	assert.Equal(t, "bob", user["name"])
+	require.NoError(t, client.Delete(ctx, "relational", "users", "1"))
+	assert.Error(t, client.Get(ctx, "relational", "users", "1", &user))
+}
+
+func TestClient_ResponseCacheNamespaces(t *testing.T) {
+	base, _ := newMemoryClient(t)
+	backend := &memoryCacheBackend{lru: newLRUCache(10)}
+	client := NewClient(Config{Endpoints: base.endpoints, Cache: &ResponseCacheOptions{Backend: backend}})
+	ctx := context.Background()
+
+	tenant := client.Namespace("tenant-a")
+	require.NoError(t, tenant.Put(ctx, "relational", "users", "1", map[string]string{"name": "alice"}))
+	var user map[string]string
+	require.NoError(t, tenant.Get(ctx, "relational", "users", "1", &user))
+	_, cached := backend.Get("tenant-a/api/relational/users/1")
+	assert.True(t, cached)
+
+	require.NoError(t, client.Put(WithNamespace(ctx, "tenant-a"), "relational", "users", "1", map[string]string{"name": "bob"}))
+	_, cached = backend.Get("tenant-a/api/relational/users/1")
+	assert.False(t, cached)
+}
+
+func TestClient_ResponseCacheBulkWrites(t *testing.T) {
+	store := &memoryStore{docs: make(map[string]map[string]interface{}), revisions: make(map[string]int)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/query" {
+			store.serveHTTP(w, r)
+			return
+		}
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if strings.HasPrefix(body.Query, "FOR u IN users UPDATE") {
+			store.mu.Lock()
+			store.docs["/api/relational/users/u2"]["name"] = "updated"
+			store.revisions["/api/relational/users/u2"]++
+			store.mu.Unlock()
+		}
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+	recorder := &statusRecorder{}
+	client := NewClient(Config{
+		Endpoints:    []string{server.URL},
+		Cache:        &ResponseCacheOptions{TTL: time.Hour},
+		Interceptors: []Interceptor{recorder.intercept},
+	})
+	defer client.Close()
+	ctx := context.Background()
+	get := func(uuid string) string {
+		var user map[string]string
+		require.NoError(t, client.Get(ctx, "relational", "users", uuid, &user))
+		return user["name"]
+	}
+
+	require.NoError(t, client.Put(ctx, "relational", "users", "u1", map[string]string{"name": "alice"}))
+	require.NoError(t, client.Put(ctx, "relational", "users", "u2", map[string]string{"name": "bob"}))
+	assert.Equal(t, "alice", get("u1"))
+	assert.Equal(t, "bob", get("u2"))
+
+	_, err := client.Import(ctx, "relational", "users", strings.NewReader(`{"uuid":"u1","document":{"name":"imported"}}`), ImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "imported", get("u1"), "Import invalidates the entries of its documents")
+	assert.Equal(t, "bob", get("u2"))
+
+	writer := client.Writer(WriterOptions{})
+	require.NoError(t, writer.Put(ctx, "relational", "users", "u2", map[string]string{"name": "written"}))
+	require.NoError(t, writer.Delete(ctx, "relational", "users", "u1"))
+	require.NoError(t, writer.Close(ctx))
+	assert.Equal(t, "written", get("u2"), "Writer flushes invalidate the entries of their documents")
+	assert.ErrorIs(t, client.Get(ctx, "relational", "users", "u1", nil), ErrNotFound)
+
+	requests := len(recorder.statuses)
+	require.NoError(t, client.Query(ctx, "FOR u IN users RETURN u", nil))
+	assert.Equal(t, "written", get("u2"))
+	assert.Len(t, recorder.statuses, requests, "read-only queries keep the cache")
+	require.NoError(t, client.Query(ctx, "FOR u IN users UPDATE u WITH {name: 'updated'} IN users", nil))
+	assert.Equal(t, "updated", get("u2"), "AQL writes invalidate the namespace")
+}
+
+func TestClient_ResponseCacheStaleIfError(t *testing.T) {
+	var mu sync.Mutex
+	status := http.StatusOK
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if status != http.StatusOK {
+			http.Error(w, "unavailable", status)
+			return
+		}
+		w.Write([]byte(`{"name": "alice"}`))
+	})
+	client = NewClient(Config{
+		Endpoints:  client.endpoints,
+		MaxRetries: 1,
+		Cache:      &ResponseCacheOptions{TTL: 20 * time.Millisecond, StaleIfError: 200 * time.Millisecond},
+	})
+	defer client.Close()
+	setStatus := func(code int) {
+		mu.Lock()
+		defer mu.Unlock()
+		status = code
+	}
+	ctx := context.Background()
+
+	var user map[string]string
+	meta, err := client.GetWithMeta(ctx, "relational", "users", "1", &user)
+	require.NoError(t, err)
+	assert.Equal(t, &GetMeta{}, meta)
+	meta, err = client.GetWithMeta(ctx, "relational", "users", "1", &user)
+	require.NoError(t, err)
+	assert.Equal(t, &GetMeta{Cached: true}, meta)
+
+	// the server fails after the entry expired
+	time.Sleep(30 * time.Millisecond)
+	setStatus(http.StatusServiceUnavailable)
+	user = nil
+	meta, err = client.GetWithMeta(ctx, "relational", "users", "1", &user)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user["name"])
+	assert.True(t, meta.Stale)
+	assert.Positive(t, meta.Age)
+	require.NoError(t, client.Get(ctx, "relational", "users", "1", &user))
+
+	// client errors and uncached entities are not served stale
+	setStatus(http.StatusForbidden)
+	assert.ErrorIs(t, client.Get(ctx, "relational", "users", "1", &user), ErrPermissionDenied)
+	setStatus(http.StatusServiceUnavailable)
+	assert.ErrorIs(t, client.Get(ctx, "relational", "users", "2", &user), ErrUnavailable)
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.Error(t, client.Get(cancelled, "relational", "users", "1", &user))
+
+	// beyond the staleness bound the error is returned
+	time.Sleep(200 * time.Millisecond)
+	assert.ErrorIs(t, client.Get(ctx, "relational", "users", "1", &user), ErrUnavailable)
+}
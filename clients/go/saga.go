@@ -0,0 +1,215 @@
+package themisdb
+
+import (
+	"context"
+	"fmt"
+)
+
+// sagasCollection stores the state of each saga
+const sagasCollection = "_sagas"
+
+// SagaStatus is the state of a saga
+type SagaStatus string
+
+const (
+	// SagaRunning: steps are being executed
+	SagaRunning SagaStatus = "running"
+	// SagaCompleted: all steps succeeded
+	SagaCompleted SagaStatus = "completed"
+	// SagaCompensating: a step failed and completed steps are being compensated
+	SagaCompensating SagaStatus = "compensating"
+	// SagaCompensated: a step failed and all completed steps were compensated
+	SagaCompensated SagaStatus = "compensated"
+	// SagaFailed: a compensation failed, manual intervention is required
+	SagaFailed SagaStatus = "failed"
+)
+
+// SagaStep is a single step of a saga and the action that undoes it
+type SagaStep struct {
+	// Name identifies the step in the stored saga state and must be unique within the saga
+	Name string
+	// Action performs the step. Writes through tx commit atomically with the step's state.
+	Action func(ctx context.Context, tx *Transaction) error
+	// Compensate undoes a completed step, nil if the step needs no compensation
+	Compensate func(ctx context.Context, tx *Transaction) error
+}
+
+// SagaError reports a saga that did not complete
+type SagaError struct {
+	// Saga is the ID of the saga
+	Saga string
+	// Step is the name of the step that failed
+	Step string
+	// Err is the error returned by the failed step
+	Err error
+	// CompensationErr is set if compensating a completed step failed as well
+	CompensationErr error
+}
+
+// Error implements error
+func (e *SagaError) Error() string {
+	if e.CompensationErr != nil {
+		return fmt.Sprintf("saga %s failed at step %s: %v (compensation failed: %v)", e.Saga, e.Step, e.Err, e.CompensationErr)
+	}
+	return fmt.Sprintf("saga %s aborted at step %s: %v", e.Saga, e.Step, e.Err)
+}
+
+// Unwrap returns the error of the failed step
+func (e *SagaError) Unwrap() error {
+	return e.Err
+}
+
+// Is allows matching with errors.Is(err, ErrSagaAborted)
+func (e *SagaError) Is(target error) bool {
+	return target == ErrSagaAborted
+}
+
+// Saga coordinates a multi-step workflow that cannot run in a single ACID transaction.
+// Each step runs in its own transaction together with the update of the saga's stored
+// state; if a step fails, the completed steps are compensated in reverse order.
+// Running a saga again with the same ID resumes it after a crash.
+type Saga struct {
+	client *Client
+	id     string
+	steps  []SagaStep
+}
+
+// Saga returns the saga with the given ID and steps
+func (c *Client) Saga(id string, steps ...SagaStep) *Saga {
+	return &Saga{client: c, id: id, steps: steps}
+}
+
+// sagaState is the stored document of a saga
+type sagaState struct {
+	Status     SagaStatus `json:"status,omitempty"`
+	Completed  []string   `json:"completed"`
+	FailedStep string     `json:"failed_step,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// Status returns the stored status of the saga, empty if it was never run
+func (s *Saga) Status(ctx context.Context) (SagaStatus, error) {
+	state, err := s.state(ctx)
+	if err != nil {
+		return "", err
+	}
+	return state.Status, nil
+}
+
+// Run executes the remaining steps of the saga. It returns nil once all steps completed,
+// or a *SagaError matching ErrSagaAborted if a step failed.
+func (s *Saga) Run(ctx context.Context) error {
+	state, err := s.state(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch state.Status {
+	case SagaCompleted:
+		return nil
+	case SagaCompensated, SagaFailed:
+		return &SagaError{Saga: s.id, Step: state.FailedStep, Err: fmt.Errorf("%s", state.Error)}
+	case SagaCompensating:
+		return s.compensate(ctx, state, fmt.Errorf("%s", state.Error))
+	case "":
+		state.Status = SagaRunning
+		state.Completed = []string{}
+		if err := s.save(ctx, map[string]interface{}{"status": state.Status, "completed": state.Completed}); err != nil {
+			return err
+		}
+	}
+
+	for _, step := range s.steps[len(state.Completed):] {
+		completed := append(append([]string{}, state.Completed...), step.Name)
+		err := s.client.runInTransaction(ctx, func(tx *Transaction) error {
+			if err := step.Action(ctx, tx); err != nil {
+				return err
+			}
+			return tx.Patch(ctx, ModelRelational, sagasCollection, s.id, map[string]interface{}{"completed": completed})
+		})
+		if err != nil {
+			state.FailedStep = step.Name
+			if saveErr := s.save(ctx, map[string]interface{}{
+				"status":      SagaCompensating,
+				"failed_step": step.Name,
+				"error":       err.Error(),
+			}); saveErr != nil {
+				return &SagaError{Saga: s.id, Step: step.Name, Err: err, CompensationErr: saveErr}
+			}
+			return s.compensate(ctx, state, err)
+		}
+		state.Completed = completed
+	}
+
+	return s.save(ctx, map[string]interface{}{"status": SagaCompleted})
+}
+
+// compensate undoes the completed steps in reverse order
+func (s *Saga) compensate(ctx context.Context, state *sagaState, cause error) error {
+	steps := make(map[string]SagaStep, len(s.steps))
+	for _, step := range s.steps {
+		steps[step.Name] = step
+	}
+
+	for i := len(state.Completed) - 1; i >= 0; i-- {
+		step, ok := steps[state.Completed[i]]
+		if !ok {
+			return &SagaError{Saga: s.id, Step: state.FailedStep, Err: cause,
+				CompensationErr: fmt.Errorf("unknown step %s", state.Completed[i])}
+		}
+		remaining := state.Completed[:i]
+		err := s.client.runInTransaction(ctx, func(tx *Transaction) error {
+			if step.Compensate != nil {
+				if err := step.Compensate(ctx, tx); err != nil {
+					return err
+				}
+			}
+			return tx.Patch(ctx, ModelRelational, sagasCollection, s.id, map[string]interface{}{"completed": remaining})
+		})
+		if err != nil {
+			compensationErr := fmt.Errorf("failed to compensate step %s: %w", step.Name, err)
+			if saveErr := s.save(ctx, map[string]interface{}{"status": SagaFailed}); saveErr != nil {
+				compensationErr = fmt.Errorf("%w (and %v)", compensationErr, saveErr)
+			}
+			return &SagaError{Saga: s.id, Step: state.FailedStep, Err: cause, CompensationErr: compensationErr}
+		}
+	}
+
+	if err := s.save(ctx, map[string]interface{}{"status": SagaCompensated, "completed": []string{}}); err != nil {
+		return &SagaError{Saga: s.id, Step: state.FailedStep, Err: cause, CompensationErr: err}
+	}
+	return &SagaError{Saga: s.id, Step: state.FailedStep, Err: cause}
+}
+
+// state loads the stored state of the saga
+func (s *Saga) state(ctx context.Context) (*sagaState, error) {
+	var state sagaState
+	if err := s.client.loadCheckpoint(ctx, sagasCollection, s.id, &state); err != nil {
+		return nil, fmt.Errorf("failed to load saga %s: %w", s.id, err)
+	}
+	if len(state.Completed) > len(s.steps) {
+		return nil, fmt.Errorf("saga %s has %d completed steps but only %d are defined", s.id, len(state.Completed), len(s.steps))
+	}
+	return &state, nil
+}
+
+// save merges fields into the stored state of the saga
+func (s *Saga) save(ctx context.Context, fields map[string]interface{}) error {
+	if err := s.client.saveCheckpoint(ctx, sagasCollection, s.id, fields); err != nil {
+		return fmt.Errorf("failed to save saga %s: %w", s.id, err)
+	}
+	return nil
+}
+
+// runInTransaction runs fn in a new transaction, committing if it succeeds and rolling back otherwise
+func (c *Client) runInTransaction(ctx context.Context, fn func(tx *Transaction) error) error {
+	tx, err := c.BeginTransaction(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+	return tx.Commit(ctx)
+}
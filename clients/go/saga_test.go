@@ -0,0 +1,87 @@
+package themisdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSteps returns saga steps that log their actions and compensations to log
+func recordingSteps(log *[]string, failAt string) []SagaStep {
+	var steps []SagaStep
+	for _, name := range []string{"reserve", "charge", "ship"} {
+		name := name
+		steps = append(steps, SagaStep{
+			Name: name,
+			Action: func(ctx context.Context, tx *Transaction) error {
+				if name == failAt {
+					return errors.New(name + " unavailable")
+				}
+				*log = append(*log, name)
+				return tx.Put(ctx, "relational", "orders", name, map[string]bool{"done": true})
+			},
+			Compensate: func(ctx context.Context, tx *Transaction) error {
+				*log = append(*log, "undo "+name)
+				return tx.Delete(ctx, "relational", "orders", name)
+			},
+		})
+	}
+	return steps
+}
+
+func TestSaga_Completes(t *testing.T) {
+	client, store := newMemoryClient(t)
+	ctx := context.Background()
+	var log []string
+
+	saga := client.Saga("order-1", recordingSteps(&log, "")...)
+	require.NoError(t, saga.Run(ctx))
+	assert.Equal(t, []string{"reserve", "charge", "ship"}, log)
+	assert.Equal(t, 3, store.commits)
+
+	status, err := saga.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, SagaCompleted, status)
+
+	require.NoError(t, saga.Run(ctx))
+	assert.Len(t, log, 3)
+}
+
+func TestSaga_Compensates(t *testing.T) {
+	client, store := newMemoryClient(t)
+	ctx := context.Background()
+	var log []string
+
+	saga := client.Saga("order-2", recordingSteps(&log, "ship")...)
+	err := saga.Run(ctx)
+	assert.ErrorIs(t, err, ErrSagaAborted)
+	var sagaErr *SagaError
+	require.ErrorAs(t, err, &sagaErr)
+	assert.Equal(t, "ship", sagaErr.Step)
+	assert.NoError(t, sagaErr.CompensationErr)
+
+	assert.Equal(t, []string{"reserve", "charge", "undo charge", "undo reserve"}, log)
+	assert.Equal(t, 1, store.rollbacks)
+	assert.NotContains(t, store.docs, "/api/relational/orders/reserve")
+
+	status, err := saga.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, SagaCompensated, status)
+	assert.ErrorIs(t, saga.Run(ctx), ErrSagaAborted)
+}
+
+func TestSaga_Resumes(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	ctx := context.Background()
+	require.NoError(t, client.Put(ctx, ModelRelational, sagasCollection, "order-3", map[string]interface{}{
+		"status":    SagaRunning,
+		"completed": []string{"reserve"},
+	}))
+
+	var log []string
+	require.NoError(t, client.Saga("order-3", recordingSteps(&log, "")...).Run(ctx))
+	assert.Equal(t, []string{"charge", "ship"}, log)
+}
@@ -0,0 +1,141 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+)
+
+// CollectionSample is a random sample of a collection and statistics derived from it
+type CollectionSample struct {
+	Collection string
+	// Total is the number of documents in the collection
+	Total int64
+	// Documents are the sampled documents
+	Documents []ScanEntry
+	// Fields holds statistics per field path; nested objects are flattened to dotted
+	// paths, arrays count as single values
+	Fields map[string]FieldStats
+	// Sizes is a histogram of the encoded document sizes, in ascending bucket order
+	Sizes []SizeBucket
+}
+
+// FieldStats describes a field within a sample
+type FieldStats struct {
+	// Present counts the sampled documents with a non-null value
+	Present int
+	// NullRate is the fraction of sampled documents in which the field is missing or null
+	NullRate float64
+	// Distinct counts the distinct values in the sample
+	Distinct int
+	// Cardinality estimates the number of distinct values in the whole collection,
+	// scaling the sample with the GEE estimator: values seen once are assumed to be
+	// sqrt(Total/n) times more frequent in the collection, values seen repeatedly not
+	Cardinality int64
+}
+
+// SizeBucket counts documents with an encoded size in [Min, Max) bytes
+type SizeBucket struct {
+	Min   int
+	Max   int
+	Count int
+}
+
+// SampleCollection fetches a uniform random sample of up to n documents from a collection
+// and computes field null rates, cardinality estimates, and a size histogram from it,
+// e.g. for query tuning or to monitor data quality. Statistics are estimates; larger
+// samples are more accurate.
+func (a *Admin) SampleCollection(ctx context.Context, collection string, n int) (*CollectionSample, error) {
+	if err := validateName("collection", collection); err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		return nil, &ValidationError{Field: "sample size", Value: strconv.Itoa(n), Reason: "must be positive"}
+	}
+	path := joinPath("/admin/collections", collection) + "/sample?size=" + strconv.Itoa(n)
+
+	var response struct {
+		Total     int64       `json:"total"`
+		Documents []ScanEntry `json:"documents"`
+	}
+	if err := a.client.readRequest(ctx, "GET", path, nil, &response, nil); err != nil {
+		return nil, fmt.Errorf("failed to sample collection %s: %w", collection, err)
+	}
+
+	sample := &CollectionSample{Collection: collection, Total: response.Total, Documents: response.Documents}
+	sample.Fields, sample.Sizes = sampleStats(response.Documents, response.Total)
+	return sample, nil
+}
+
+// sampleStats computes field statistics and a power-of-two size histogram of docs
+func sampleStats(docs []ScanEntry, total int64) (map[string]FieldStats, []SizeBucket) {
+	values := make(map[string]map[string]int)
+	buckets := make(map[int]int)
+	for _, entry := range docs {
+		buckets[bits.Len(uint(len(entry.Document)))]++
+
+		var doc map[string]interface{}
+		if json.Unmarshal(entry.Document, &doc) != nil {
+			continue
+		}
+		flattenFields("", doc, func(path string, value interface{}) {
+			if values[path] == nil {
+				values[path] = make(map[string]int)
+			}
+			if value != nil {
+				encoded, _ := json.Marshal(value)
+				values[path][string(encoded)]++
+			}
+		})
+	}
+
+	n := len(docs)
+	fields := make(map[string]FieldStats, len(values))
+	for path, counts := range values {
+		stats := FieldStats{Distinct: len(counts)}
+		once := 0
+		for _, count := range counts {
+			stats.Present += count
+			if count == 1 {
+				once++
+			}
+		}
+		stats.NullRate = float64(n-stats.Present) / float64(n)
+		stats.Cardinality = int64(len(counts) - once)
+		if once > 0 {
+			scale := 1.0
+			if total > int64(n) {
+				scale = math.Sqrt(float64(total) / float64(n))
+			}
+			stats.Cardinality += int64(math.Round(scale * float64(once)))
+		}
+		fields[path] = stats
+	}
+
+	sizes := make([]SizeBucket, 0, len(buckets))
+	for b, count := range buckets {
+		bucket := SizeBucket{Max: 1 << b, Count: count}
+		if b > 0 {
+			bucket.Min = 1 << (b - 1)
+		}
+		sizes = append(sizes, bucket)
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i].Min < sizes[j].Min })
+	return fields, sizes
+}
+
+// flattenFields calls fn with the dotted path and value of every non-object field of doc
+func flattenFields(prefix string, doc map[string]interface{}, fn func(path string, value interface{})) {
+	for name, value := range doc {
+		path := prefix + name
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenFields(path+".", nested, fn)
+			continue
+		}
+		fn(path, value)
+	}
+}
@@ -0,0 +1,49 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdmin_SampleCollection(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/admin/collections/users/sample", r.URL.Path)
+		assert.Equal(t, "4", r.URL.Query().Get("size"))
+		w.Write([]byte(`{"total":400,"documents":[
+			{"uuid":"1","document":{"country":"DE","email":"a@x","address":{"city":"Berlin"}}},
+			{"uuid":"2","document":{"country":"DE","email":null,"address":{"city":"Bonn"}}},
+			{"uuid":"3","document":{"country":"FR","email":"c@x","tags":["a","b"]}},
+			{"uuid":"4","document":{"country":"DE","email":"d@x","address":{"city":"Berlin"},"note":"long enough to land in the next size bucket"}}
+		]}`))
+	})
+
+	sample, err := client.Admin().SampleCollection(context.Background(), "users", 4)
+	require.NoError(t, err)
+	assert.Equal(t, int64(400), sample.Total)
+	require.Len(t, sample.Documents, 4)
+
+	country := sample.Fields["country"]
+	assert.Equal(t, FieldStats{Present: 4, NullRate: 0, Distinct: 2, Cardinality: 1 + 10}, country,
+		"DE repeats, FR was seen once and is scaled by sqrt(400/4)")
+	email := sample.Fields["email"]
+	assert.Equal(t, 3, email.Present)
+	assert.Equal(t, 0.25, email.NullRate)
+	assert.Equal(t, int64(30), email.Cardinality, "unique values look unique in the collection")
+	city := sample.Fields["address.city"]
+	assert.Equal(t, 0.25, city.NullRate, "nested fields are flattened")
+	assert.Equal(t, 2, city.Distinct)
+	assert.Equal(t, 1, sample.Fields["tags"].Distinct, "arrays count as single values")
+	assert.NotContains(t, sample.Fields, "address")
+
+	require.Len(t, sample.Sizes, 2)
+	assert.Equal(t, SizeBucket{Min: 32, Max: 64, Count: 3}, sample.Sizes[0])
+	assert.Equal(t, SizeBucket{Min: 64, Max: 128, Count: 1}, sample.Sizes[1])
+
+	_, err = client.Admin().SampleCollection(context.Background(), "users", 0)
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
@@ -0,0 +1,145 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// ScanOptions holds collection scan configuration
+type ScanOptions struct {
+	// BatchSize is the number of documents fetched per round trip (default: 100)
+	BatchSize int
+	// Prefix restricts the scan to UUIDs starting with Prefix
+	Prefix string
+	// StartAfter resumes the scan after this UUID, e.g. a previous Scanner.Cursor()
+	StartAfter string
+}
+
+// ScanEntry is a single document returned by a scan
+type ScanEntry struct {
+	UUID     string          `json:"uuid"`
+	Document json.RawMessage `json:"document"`
+}
+
+// Scanner iterates over all documents of a collection in UUID order.
+// It fetches pages lazily with keyset pagination, so it is safe to use on collections
+// that do not fit in memory. A Scanner is not safe for concurrent use.
+//
+//	it := client.Scan(ctx, "relational", "users", themisdb.ScanOptions{BatchSize: 500})
+//	for it.Next() {
+//		var u User
+//		if err := it.Decode(&u); err != nil { ... }
+//	}
+//	if err := it.Err(); err != nil { ... }
+type Scanner struct {
+	client     *Client
+	ctx        context.Context
+	model      string
+	collection string
+	opts       ScanOptions
+
+	page    []ScanEntry
+	pos     int
+	current ScanEntry
+	cursor  string
+	hasMore bool
+	err     error
+}
+
+// Scan returns an iterator over every document of a collection.
+// Errors, including invalid arguments, are reported by Scanner.Err.
+func (c *Client) Scan(ctx context.Context, model, collection string, opts ScanOptions) *Scanner {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	return &Scanner{
+		client:     c,
+		ctx:        ctx,
+		model:      model,
+		collection: collection,
+		opts:       opts,
+		cursor:     opts.StartAfter,
+		hasMore:    true,
+		err:        validateCollection(model, collection),
+	}
+}
+
+// Next advances to the next document, fetching the next page when needed.
+// It returns false at the end of the collection or on error.
+func (s *Scanner) Next() bool {
+	if s.err != nil {
+		return false
+	}
+	for s.pos >= len(s.page) {
+		if !s.hasMore {
+			return false
+		}
+		if err := s.fetch(); err != nil {
+			s.err = err
+			return false
+		}
+	}
+
+	s.current = s.page[s.pos]
+	s.pos++
+	s.cursor = s.current.UUID
+	return true
+}
+
+// fetch loads the page following the cursor
+func (s *Scanner) fetch() error {
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(s.opts.BatchSize))
+	if s.opts.Prefix != "" {
+		query.Set("prefix", s.opts.Prefix)
+	}
+	if s.cursor != "" {
+		query.Set("start_after", s.cursor)
+	}
+	path := joinPath("/api", s.model, s.collection) + "/_scan?" + query.Encode()
+
+	var response struct {
+		Items   []ScanEntry `json:"items"`
+		HasMore bool        `json:"has_more"`
+	}
+	if err := s.client.request(s.ctx, "GET", path, nil, &response, nil); err != nil {
+		return fmt.Errorf("failed to scan collection: %w", err)
+	}
+
+	s.page = response.Items
+	s.pos = 0
+	s.hasMore = response.HasMore && len(response.Items) > 0
+	return nil
+}
+
+// UUID returns the UUID of the current document
+func (s *Scanner) UUID() string {
+	return s.current.UUID
+}
+
+// Document returns the raw JSON of the current document
+func (s *Scanner) Document() json.RawMessage {
+	return s.current.Document
+}
+
+// Decode unmarshals the current document into v
+func (s *Scanner) Decode(v interface{}) error {
+	if err := json.Unmarshal(s.current.Document, v); err != nil {
+		return fmt.Errorf("failed to unmarshal document %s: %w", s.current.UUID, err)
+	}
+	return nil
+}
+
+// Cursor returns the UUID of the last document returned by Next.
+// Passing it as ScanOptions.StartAfter resumes the scan after that document.
+func (s *Scanner) Cursor() string {
+	return s.cursor
+}
+
+// Err returns the error that stopped the iteration, if any
+func (s *Scanner) Err() error {
+	return s.err
+}
@@ -0,0 +1,64 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Scan(t *testing.T) {
+	keys := []string{"u1", "u2", "u3", "u4", "u5"}
+	var pages int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		pages++
+		assert.Equal(t, "/api/relational/users/_scan", r.URL.Path)
+		assert.Equal(t, "u", r.URL.Query().Get("prefix"))
+		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+		require.NoError(t, err)
+
+		var items []ScanEntry
+		after := r.URL.Query().Get("start_after")
+		for _, k := range keys {
+			if k > after && len(items) < limit {
+				items = append(items, ScanEntry{UUID: k, Document: json.RawMessage(fmt.Sprintf(`{"name":%q}`, k))})
+			}
+		}
+		hasMore := len(items) == limit && items[len(items)-1].UUID != keys[len(keys)-1]
+		json.NewEncoder(w).Encode(map[string]interface{}{"items": items, "has_more": hasMore})
+	})
+
+	it := client.Scan(context.Background(), "relational", "users", ScanOptions{BatchSize: 2, Prefix: "u", StartAfter: "u1"})
+	var names []string
+	for it.Next() {
+		var doc struct {
+			Name string `json:"name"`
+		}
+		require.NoError(t, it.Decode(&doc))
+		assert.Equal(t, doc.Name, it.UUID())
+		names = append(names, doc.Name)
+	}
+	require.NoError(t, it.Err())
+	assert.Equal(t, []string{"u2", "u3", "u4", "u5"}, names)
+	assert.Equal(t, "u5", it.Cursor())
+	assert.Equal(t, 2, pages)
+}
+
+func TestClient_ScanError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	it := client.Scan(context.Background(), "relational", "users", ScanOptions{})
+	assert.False(t, it.Next())
+	assert.Error(t, it.Err())
+
+	it = client.Scan(context.Background(), "relational", "", ScanOptions{})
+	assert.False(t, it.Next())
+	assert.ErrorIs(t, it.Err(), ErrInvalidInput)
+}
@@ -0,0 +1,491 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// SchemaViolation is one failed constraint of a document
+type SchemaViolation struct {
+	// Path locates the failing value in dotted form, e.g. "items[2].sku"; empty for
+	// the document itself
+	Path string
+	// Message describes the failed constraint
+	Message string
+}
+
+// String formats the violation as "path: message"
+func (v SchemaViolation) String() string {
+	path := v.Path
+	if path == "" {
+		path = "(document)"
+	}
+	return path + ": " + v.Message
+}
+
+// SchemaError reports a document that does not match the JSON schema registered for
+// its collection
+type SchemaError struct {
+	Model      string
+	Collection string
+	// Violations lists every failed constraint, ordered by path
+	Violations []SchemaViolation
+}
+
+// Error implements error
+func (e *SchemaError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		parts[i] = v.String()
+	}
+	return fmt.Sprintf("document does not match schema of %s/%s: %s", e.Model, e.Collection, strings.Join(parts, "; "))
+}
+
+// Unwrap allows matching with errors.Is(err, ErrSchemaViolation)
+func (e *SchemaError) Unwrap() error {
+	return ErrSchemaViolation
+}
+
+// SetSchema registers a JSON schema that the server enforces on writes to the collection.
+// A nil schema removes it. Use Client.RegisterSchema to check documents client-side as well.
+func (a *Admin) SetSchema(ctx context.Context, model, collection string, jsonSchema map[string]interface{}) error {
+	if err := validateCollection(model, collection); err != nil {
+		return err
+	}
+	if jsonSchema != nil {
+		if _, err := compileSchema(jsonSchema); err != nil {
+			return err
+		}
+	}
+	path := joinPath("/admin/collections", collection, "schema") + "?model=" + url.QueryEscape(model)
+	body := map[string]interface{}{
+		"schema": jsonSchema,
+	}
+	if err := a.client.request(ctx, "PUT", path, body, nil, nil); err != nil {
+		return fmt.Errorf("failed to set schema of %s/%s: %w", model, collection, err)
+	}
+	return nil
+}
+
+// Schema returns the JSON schema registered for the collection, nil if there is none
+func (a *Admin) Schema(ctx context.Context, model, collection string) (map[string]interface{}, error) {
+	if err := validateCollection(model, collection); err != nil {
+		return nil, err
+	}
+	path := joinPath("/admin/collections", collection, "schema") + "?model=" + url.QueryEscape(model)
+
+	var response struct {
+		Schema map[string]interface{} `json:"schema"`
+	}
+	resp, err := a.client.send(ctx, "GET", path, nil, nil)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema of %s/%s: %w", model, collection, err)
+	}
+	if err := json.Unmarshal(resp.Body, &response); err != nil {
+		return nil, fmt.Errorf("failed to get schema of %s/%s: failed to decode response: %w", model, collection, err)
+	}
+	return response.Schema, nil
+}
+
+// schemaRegistry holds the compiled schemas registered with RegisterSchema per model/collection
+type schemaRegistry struct {
+	mu      sync.RWMutex
+	schemas map[string]*schemaNode
+}
+
+// RegisterSchema validates documents written through Put, Create, PutEntity, and
+// PutWithVector client-side against a JSON schema, typically the one returned by
+// Admin.Schema, so that invalid documents fail with a *SchemaError listing all
+// failing paths before a request is sent. Merge patches are partial documents and
+// are not checked. A nil schema removes the registration.
+//
+// The supported keywords are type, enum, const, properties, required,
+// additionalProperties, items, minItems, maxItems, uniqueItems, minLength, maxLength,
+// pattern, minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf, allOf,
+// anyOf, oneOf, and not; other keywords are ignored.
+func (c *Client) RegisterSchema(model, collection string, jsonSchema map[string]interface{}) error {
+	var node *schemaNode
+	if jsonSchema != nil {
+		var err error
+		if node, err = compileSchema(jsonSchema); err != nil {
+			return err
+		}
+	}
+
+	c.schemas.mu.Lock()
+	defer c.schemas.mu.Unlock()
+	if c.schemas.schemas == nil {
+		c.schemas.schemas = make(map[string]*schemaNode)
+	}
+	if node == nil {
+		delete(c.schemas.schemas, model+"/"+collection)
+	} else {
+		c.schemas.schemas[model+"/"+collection] = node
+	}
+	return nil
+}
+
+// validateDocument checks a complete document against the enums and the schema
+// registered for model/collection
+func (c *Client) validateDocument(model, collection string, data interface{}) error {
+	if err := c.validateEnums(model, collection, data); err != nil {
+		return err
+	}
+
+	c.schemas.mu.RLock()
+	node := c.schemas.schemas[model+"/"+collection]
+	c.schemas.mu.RUnlock()
+	if node == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal document for validation: %w", err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return fmt.Errorf("failed to decode document for validation: %w", err)
+	}
+
+	var violations []SchemaViolation
+	node.validate("", doc, &violations)
+	if len(violations) == 0 {
+		return nil
+	}
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].Path < violations[j].Path })
+	return &SchemaError{Model: model, Collection: collection, Violations: violations}
+}
+
+// schemaNode is a compiled JSON schema
+type schemaNode struct {
+	types                []string
+	enum                 []interface{}
+	constant             interface{}
+	hasConst             bool
+	properties           map[string]*schemaNode
+	required             []string
+	additionalProperties *schemaNode
+	noAdditional         bool
+	items                *schemaNode
+	minItems, maxItems   *int
+	uniqueItems          bool
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+	minimum, maximum     *float64
+	exclusiveMinimum     *float64
+	exclusiveMaximum     *float64
+	multipleOf           *float64
+	allOf, anyOf, oneOf  []*schemaNode
+	not                  *schemaNode
+}
+
+// compileSchema parses a JSON schema given as decoded JSON
+func compileSchema(schema map[string]interface{}) (*schemaNode, error) {
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return compileNode("", decoded)
+}
+
+// compileNode compiles the schema at path within the schema document
+func compileNode(path string, v interface{}) (*schemaNode, error) {
+	if b, ok := v.(bool); ok {
+		// true accepts everything, false nothing
+		if b {
+			return &schemaNode{}, nil
+		}
+		return &schemaNode{not: &schemaNode{}}, nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid schema at %q: expected an object", path)
+	}
+
+	n := &schemaNode{}
+	var err error
+	switch t := m["type"].(type) {
+	case nil:
+	case string:
+		n.types = []string{t}
+	case []interface{}:
+		for _, e := range t {
+			s, ok := e.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid schema at %q: type must be a string or an array of strings", path)
+			}
+			n.types = append(n.types, s)
+		}
+	default:
+		return nil, fmt.Errorf("invalid schema at %q: type must be a string or an array of strings", path)
+	}
+	for _, t := range n.types {
+		switch t {
+		case "object", "array", "string", "number", "integer", "boolean", "null":
+		default:
+			return nil, fmt.Errorf("invalid schema at %q: unknown type %q", path, t)
+		}
+	}
+
+	if e, ok := m["enum"]; ok {
+		if n.enum, ok = e.([]interface{}); !ok {
+			return nil, fmt.Errorf("invalid schema at %q: enum must be an array", path)
+		}
+	}
+	n.constant, n.hasConst = m["const"]
+
+	if props, ok := m["properties"].(map[string]interface{}); ok {
+		n.properties = make(map[string]*schemaNode, len(props))
+		for name, sub := range props {
+			if n.properties[name], err = compileNode(joinSchemaPath(path, name), sub); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if req, ok := m["required"].([]interface{}); ok {
+		for _, e := range req {
+			s, ok := e.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid schema at %q: required must be an array of strings", path)
+			}
+			n.required = append(n.required, s)
+		}
+	}
+	switch ap := m["additionalProperties"].(type) {
+	case bool:
+		n.noAdditional = !ap
+	case map[string]interface{}:
+		if n.additionalProperties, err = compileNode(path+".additionalProperties", ap); err != nil {
+			return nil, err
+		}
+	}
+	if items, ok := m["items"]; ok {
+		if n.items, err = compileNode(path+"[]", items); err != nil {
+			return nil, err
+		}
+	}
+	n.uniqueItems, _ = m["uniqueItems"].(bool)
+
+	for key, dst := range map[string]**int{"minItems": &n.minItems, "maxItems": &n.maxItems, "minLength": &n.minLength, "maxLength": &n.maxLength} {
+		if f, ok := m[key].(float64); ok {
+			i := int(f)
+			*dst = &i
+		}
+	}
+	for key, dst := range map[string]**float64{"minimum": &n.minimum, "maximum": &n.maximum, "exclusiveMinimum": &n.exclusiveMinimum, "exclusiveMaximum": &n.exclusiveMaximum, "multipleOf": &n.multipleOf} {
+		if f, ok := m[key].(float64); ok {
+			*dst = &f
+		}
+	}
+	if p, ok := m["pattern"].(string); ok {
+		if n.pattern, err = regexp.Compile(p); err != nil {
+			return nil, fmt.Errorf("invalid schema at %q: invalid pattern: %w", path, err)
+		}
+	}
+
+	for key, dst := range map[string]*[]*schemaNode{"allOf": &n.allOf, "anyOf": &n.anyOf, "oneOf": &n.oneOf} {
+		subs, ok := m[key].([]interface{})
+		if !ok {
+			continue
+		}
+		for _, sub := range subs {
+			node, err := compileNode(path, sub)
+			if err != nil {
+				return nil, err
+			}
+			*dst = append(*dst, node)
+		}
+	}
+	if not, ok := m["not"]; ok {
+		if n.not, err = compileNode(path, not); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// validate appends the violations of v, located at path, to violations
+func (n *schemaNode) validate(path string, v interface{}, violations *[]SchemaViolation) {
+	fail := func(format string, args ...interface{}) {
+		*violations = append(*violations, SchemaViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(n.types) > 0 && !matchesType(n.types, v) {
+		fail("expected %s, got %s", strings.Join(n.types, " or "), jsonType(v))
+		return
+	}
+	if n.enum != nil && !containsJSON(n.enum, v) {
+		fail("value %v is not one of the allowed values", v)
+	}
+	if n.hasConst && !reflect.DeepEqual(n.constant, v) {
+		fail("value must be %v", n.constant)
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, name := range n.required {
+			if _, ok := v[name]; !ok {
+				*violations = append(*violations, SchemaViolation{Path: joinSchemaPath(path, name), Message: "required property is missing"})
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if sub, ok := n.properties[name]; ok {
+				sub.validate(joinSchemaPath(path, name), v[name], violations)
+			} else if n.noAdditional {
+				*violations = append(*violations, SchemaViolation{Path: joinSchemaPath(path, name), Message: "property is not allowed"})
+			} else if n.additionalProperties != nil {
+				n.additionalProperties.validate(joinSchemaPath(path, name), v[name], violations)
+			}
+		}
+	case []interface{}:
+		if n.minItems != nil && len(v) < *n.minItems {
+			fail("must have at least %d items", *n.minItems)
+		}
+		if n.maxItems != nil && len(v) > *n.maxItems {
+			fail("must have at most %d items", *n.maxItems)
+		}
+		if n.uniqueItems {
+			for i := range v {
+				if containsJSON(v[:i], v[i]) {
+					fail("items must be unique")
+					break
+				}
+			}
+		}
+		if n.items != nil {
+			for i, item := range v {
+				n.items.validate(fmt.Sprintf("%s[%d]", path, i), item, violations)
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if n.minLength != nil && length < *n.minLength {
+			fail("must be at least %d characters", *n.minLength)
+		}
+		if n.maxLength != nil && length > *n.maxLength {
+			fail("must be at most %d characters", *n.maxLength)
+		}
+		if n.pattern != nil && !n.pattern.MatchString(v) {
+			fail("must match pattern %s", n.pattern)
+		}
+	case float64:
+		if n.minimum != nil && v < *n.minimum {
+			fail("must be >= %v", *n.minimum)
+		}
+		if n.maximum != nil && v > *n.maximum {
+			fail("must be <= %v", *n.maximum)
+		}
+		if n.exclusiveMinimum != nil && v <= *n.exclusiveMinimum {
+			fail("must be > %v", *n.exclusiveMinimum)
+		}
+		if n.exclusiveMaximum != nil && v >= *n.exclusiveMaximum {
+			fail("must be < %v", *n.exclusiveMaximum)
+		}
+		if n.multipleOf != nil && *n.multipleOf > 0 {
+			if q := v / *n.multipleOf; math.Abs(q-math.Round(q)) > 1e-9 {
+				fail("must be a multiple of %v", *n.multipleOf)
+			}
+		}
+	}
+
+	for _, sub := range n.allOf {
+		sub.validate(path, v, violations)
+	}
+	if len(n.anyOf) > 0 && countMatches(n.anyOf, v) == 0 {
+		fail("must match at least one schema of anyOf")
+	}
+	if len(n.oneOf) > 0 {
+		if matches := countMatches(n.oneOf, v); matches != 1 {
+			fail("must match exactly one schema of oneOf, matches %d", matches)
+		}
+	}
+	if n.not != nil && countMatches([]*schemaNode{n.not}, v) == 1 {
+		fail("must not match the schema of not")
+	}
+}
+
+// countMatches returns how many of schemas v matches
+func countMatches(schemas []*schemaNode, v interface{}) int {
+	matches := 0
+	for _, s := range schemas {
+		var violations []SchemaViolation
+		s.validate("", v, &violations)
+		if len(violations) == 0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+// matchesType reports whether the decoded JSON value v has one of types
+func matchesType(types []string, v interface{}) bool {
+	actual := jsonType(v)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType returns the JSON schema type of a decoded JSON value
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// containsJSON reports whether values contains a value equal to v
+func containsJSON(values []interface{}, v interface{}) bool {
+	for _, e := range values {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// joinSchemaPath appends a property name to a dotted path
+func joinSchemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,162 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var orderSchema = map[string]interface{}{
+	"type":     "object",
+	"required": []string{"id", "total", "items"},
+	"properties": map[string]interface{}{
+		"id":     map[string]interface{}{"type": "string", "pattern": "^o-[0-9]+$"},
+		"total":  map[string]interface{}{"type": "number", "minimum": 0},
+		"status": map[string]interface{}{"enum": []string{"pending", "shipped"}},
+		"items": map[string]interface{}{
+			"type":     "array",
+			"minItems": 1,
+			"items": map[string]interface{}{
+				"type":                 "object",
+				"required":             []string{"sku", "qty"},
+				"additionalProperties": false,
+				"properties": map[string]interface{}{
+					"sku": map[string]interface{}{"type": "string", "minLength": 3},
+					"qty": map[string]interface{}{"type": "integer", "exclusiveMinimum": 0},
+				},
+			},
+		},
+		"note": map[string]interface{}{"anyOf": []interface{}{
+			map[string]interface{}{"type": "null"},
+			map[string]interface{}{"type": "string", "maxLength": 5},
+		}},
+	},
+}
+
+func TestClient_RegisterSchema(t *testing.T) {
+	var writes int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writes++
+		w.WriteHeader(http.StatusNoContent)
+	})
+	require.NoError(t, client.RegisterSchema("relational", "orders", orderSchema))
+	ctx := context.Background()
+
+	valid := map[string]interface{}{
+		"id": "o-1", "total": 9.5, "status": "pending", "note": nil,
+		"items": []map[string]interface{}{{"sku": "abc", "qty": 2}},
+	}
+	require.NoError(t, client.Put(ctx, "relational", "orders", "o-1", valid))
+	require.NoError(t, client.Put(ctx, "relational", "users", "u-1", map[string]int{"total": -1}), "other collections are not checked")
+
+	err := client.Put(ctx, "relational", "orders", "o-2", map[string]interface{}{
+		"id": "x", "total": -1, "status": "lost", "note": "too long",
+		"items": []map[string]interface{}{{"sku": "ab", "qty": 1.5, "color": "red"}, {"qty": 1}},
+	})
+	assert.ErrorIs(t, err, ErrSchemaViolation)
+	var schemaErr *SchemaError
+	require.ErrorAs(t, err, &schemaErr)
+	var paths []string
+	for _, v := range schemaErr.Violations {
+		paths = append(paths, v.Path)
+	}
+	assert.Equal(t, []string{"id", "items[0].color", "items[0].qty", "items[0].sku", "items[1].sku", "note", "status", "total"}, paths)
+	assert.Contains(t, err.Error(), "items[1].sku: required property is missing")
+
+	err = client.Create(ctx, "relational", "orders", "o-3", map[string]interface{}{"id": "o-3"})
+	require.ErrorAs(t, err, &schemaErr)
+	assert.Len(t, schemaErr.Violations, 2)
+	assert.Equal(t, 2, writes)
+
+	require.NoError(t, client.Patch(ctx, "relational", "orders", "o-1", map[string]interface{}{"total": 3}), "patches are partial")
+	require.NoError(t, client.RegisterSchema("relational", "orders", nil))
+	require.NoError(t, client.Put(ctx, "relational", "orders", "o-4", map[string]interface{}{}))
+}
+
+func TestClient_RegisterSchemaInvalid(t *testing.T) {
+	client := NewClient(Config{Endpoints: []string{"http://localhost:8080"}})
+	assert.ErrorContains(t, client.RegisterSchema("relational", "orders", map[string]interface{}{"type": "text"}), `unknown type "text"`)
+	assert.ErrorContains(t, client.RegisterSchema("relational", "orders", map[string]interface{}{
+		"properties": map[string]interface{}{"id": map[string]interface{}{"pattern": "("}},
+	}), `invalid schema at "id"`)
+}
+
+func TestSchema_Keywords(t *testing.T) {
+	tests := []struct {
+		schema string
+		value  string
+		valid  bool
+	}{
+		{`{"type": ["string", "null"]}`, `null`, true},
+		{`{"type": "integer"}`, `1.5`, false},
+		{`{"type": "number"}`, `2`, true},
+		{`{"const": {"a": 1}}`, `{"a": 1}`, true},
+		{`{"multipleOf": 0.5}`, `1.5`, true},
+		{`{"multipleOf": 0.5}`, `1.2`, false},
+		{`{"maximum": 3, "exclusiveMaximum": 3}`, `3`, false},
+		{`{"uniqueItems": true}`, `[1, 2, 1]`, false},
+		{`{"maxItems": 2}`, `[1, 2, 3]`, false},
+		{`{"additionalProperties": {"type": "integer"}}`, `{"a": 1, "b": "x"}`, false},
+		{`{"allOf": [{"minimum": 1}, {"maximum": 2}]}`, `3`, false},
+		{`{"oneOf": [{"type": "integer"}, {"minimum": 0}]}`, `2`, false},
+		{`{"oneOf": [{"type": "integer"}, {"minimum": 0}]}`, `-2`, true},
+		{`{"not": {"type": "string"}}`, `"s"`, false},
+		{`{"properties": {"a": false}}`, `{"a": 1}`, false},
+		{`{"minLength": 2}`, `"äö"`, true},
+	}
+	for _, tt := range tests {
+		var schema map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(tt.schema), &schema))
+		node, err := compileSchema(schema)
+		require.NoError(t, err, tt.schema)
+		var value interface{}
+		require.NoError(t, json.Unmarshal([]byte(tt.value), &value))
+
+		var violations []SchemaViolation
+		node.validate("", value, &violations)
+		assert.Equal(t, tt.valid, len(violations) == 0, "%s against %s: %v", tt.value, tt.schema, violations)
+	}
+}
+
+func TestAdmin_SetSchema(t *testing.T) {
+	var stored json.RawMessage
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/admin/collections/orders/schema", r.URL.Path)
+		assert.Equal(t, "document", r.URL.Query().Get("model"))
+		switch r.Method {
+		case "PUT":
+			var body struct {
+				Schema json.RawMessage `json:"schema"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			stored = body.Schema
+			w.WriteHeader(http.StatusNoContent)
+		case "GET":
+			if stored == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte(`{"schema":` + string(stored) + `}`))
+		}
+	})
+	ctx := context.Background()
+	admin := client.Admin()
+
+	schema, err := admin.Schema(ctx, "document", "orders")
+	require.NoError(t, err)
+	assert.Nil(t, schema)
+
+	require.NoError(t, admin.SetSchema(ctx, "document", "orders", orderSchema))
+	schema, err = admin.Schema(ctx, "document", "orders")
+	require.NoError(t, err)
+	assert.Equal(t, "object", schema["type"])
+	require.NoError(t, client.RegisterSchema("document", "orders", schema))
+	assert.ErrorIs(t, client.Put(ctx, "document", "orders", "o-1", map[string]interface{}{}), ErrSchemaViolation)
+
+	assert.Error(t, admin.SetSchema(ctx, "document", "orders", map[string]interface{}{"type": 1}))
+	assert.ErrorIs(t, admin.SetSchema(ctx, "document", "bad/name", orderSchema), ErrInvalidInput)
+}
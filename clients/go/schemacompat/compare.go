@@ -0,0 +1,220 @@
+package schemacompat
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Change is a difference between two snapshots
+type Change struct {
+	// Path is the type name followed by the dotted JSON path of the field; "[]" stands
+	// for array elements and "{}" for map values
+	Path string
+	// Breaking is set if documents written with the old layout no longer decode into the
+	// new one, or decode with data silently dropped
+	Breaking bool
+	Message  string
+}
+
+// String implements fmt.Stringer
+func (c Change) String() string {
+	if c.Breaking {
+		return fmt.Sprintf("%s: breaking: %s", c.Path, c.Message)
+	}
+	return fmt.Sprintf("%s: %s", c.Path, c.Message)
+}
+
+// Compare reports the changes from old to new, ordered by type name and field order
+func Compare(old, new *Snapshot) []Change {
+	var changes []Change
+	for _, name := range old.typeNames() {
+		newType, ok := new.Types[name]
+		if !ok {
+			changes = append(changes, Change{Path: name, Breaking: true, Message: "type removed"})
+			continue
+		}
+		changes = compareTypes(changes, name, *old.Types[name], *newType)
+	}
+	for _, name := range new.typeNames() {
+		if _, ok := old.Types[name]; !ok {
+			changes = append(changes, Change{Path: name, Message: "type added"})
+		}
+	}
+	return changes
+}
+
+// HasBreaking reports whether changes contain a breaking change
+func HasBreaking(changes []Change) bool {
+	for _, c := range changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// compareTypes appends the changes from the layout old to new at path
+func compareTypes(changes []Change, path string, old, new Type) []Change {
+	if old.Kind != new.Kind {
+		if new.Kind == KindAny || (old.Kind == KindInteger && new.Kind == KindNumber) {
+			return append(changes, Change{Path: path, Message: fmt.Sprintf("widened from %s to %s", describe(old), describe(new))})
+		}
+		return append(changes, Change{Path: path, Breaking: true, Message: fmt.Sprintf("changed from %s to %s", describe(old), describe(new))})
+	}
+
+	switch old.Kind {
+	case KindInteger, KindNumber:
+		if old.Format == new.Format {
+			return changes
+		}
+		if widens(old.Format, new.Format) {
+			return append(changes, Change{Path: path, Message: fmt.Sprintf("widened from %s to %s", old.Format, new.Format)})
+		}
+		return append(changes, Change{Path: path, Breaking: true, Message: fmt.Sprintf("narrowed from %s to %s", old.Format, new.Format)})
+	case KindString:
+		switch {
+		case old.Format == new.Format:
+		case new.Format == "":
+			return append(changes, Change{Path: path, Message: fmt.Sprintf("widened from %s to %s", describe(old), describe(new))})
+		default:
+			return append(changes, Change{Path: path, Breaking: true, Message: fmt.Sprintf("changed from %s to %s", describe(old), describe(new))})
+		}
+	case KindAny:
+		if old.Format != new.Format {
+			return append(changes, Change{Path: path, Breaking: true, Message: fmt.Sprintf("custom encoding changed from %s to %s", describe(old), describe(new))})
+		}
+	case KindArray:
+		return compareTypes(changes, path+"[]", *old.Elem, *new.Elem)
+	case KindMap:
+		return compareTypes(changes, path+"{}", *old.Elem, *new.Elem)
+	case KindObject:
+		if old.Ref != "" || new.Ref != "" {
+			if old.Ref != new.Ref {
+				return append(changes, Change{Path: path, Breaking: true, Message: fmt.Sprintf("changed from %s to %s", describe(old), describe(new))})
+			}
+			return changes
+		}
+		return compareFields(changes, path, old.Fields, new.Fields)
+	}
+	return changes
+}
+
+// compareFields appends the changes between the fields of two objects at path
+func compareFields(changes []Change, path string, old, new []Field) []Change {
+	newByName := make(map[string]Field, len(new))
+	newByGoName := make(map[string]Field, len(new))
+	for _, f := range new {
+		newByName[f.Name] = f
+		newByGoName[f.GoName] = f
+	}
+	oldNames := fieldNames(old)
+
+	// a field whose Go name moved to a new JSON key is renamed
+	renamed := make(map[string]bool)
+	renames := make(map[string]string)
+	for _, f := range old {
+		if _, ok := newByName[f.Name]; ok {
+			continue
+		}
+		if nf, ok := newByGoName[f.GoName]; ok {
+			if _, reused := oldNames[nf.Name]; !reused {
+				renamed[nf.Name] = true
+				renames[f.Name] = nf.Name
+			}
+		}
+	}
+
+	for _, f := range old {
+		fieldPath := path + "." + f.Name
+		if nf, ok := newByName[f.Name]; ok {
+			changes = compareTypes(changes, fieldPath, f.Type, nf.Type)
+			continue
+		}
+		if name, ok := renames[f.Name]; ok {
+			changes = append(changes, Change{Path: fieldPath, Breaking: true, Message: fmt.Sprintf("renamed to %q; stored values are no longer read", name)})
+			continue
+		}
+		message := "removed; stored values are no longer read"
+		if candidates := sameType(f, oldNames, renamed, new); len(candidates) > 0 {
+			message += fmt.Sprintf(" (renamed to %s?)", strings.Join(candidates, " or "))
+		}
+		changes = append(changes, Change{Path: fieldPath, Breaking: true, Message: message})
+	}
+
+	for _, f := range new {
+		if _, ok := oldNames[f.Name]; ok || renamed[f.Name] {
+			continue
+		}
+		message := "added"
+		if !f.Optional {
+			message += "; stored documents decode it as the zero value"
+		}
+		changes = append(changes, Change{Path: path + "." + f.Name, Message: message})
+	}
+	return changes
+}
+
+// fieldNames returns the JSON names of fields
+func fieldNames(fields []Field) map[string]struct{} {
+	names := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		names[f.Name] = struct{}{}
+	}
+	return names
+}
+
+// sameType returns the names of fields added in new, i.e. neither in oldNames nor
+// renamed, with the same layout as removed
+func sameType(removed Field, oldNames map[string]struct{}, renamed map[string]bool, new []Field) []string {
+	var candidates []string
+	for _, f := range new {
+		if _, ok := oldNames[f.Name]; ok || renamed[f.Name] {
+			continue
+		}
+		if len(compareTypes(nil, "", removed.Type, f.Type)) == 0 {
+			candidates = append(candidates, fmt.Sprintf("%q", f.Name))
+		}
+	}
+	return candidates
+}
+
+// integerBits maps Go integer types to their signedness and size
+var integerBits = map[string]struct {
+	signed bool
+	bits   int
+}{
+	"int8": {true, 8}, "int16": {true, 16}, "int32": {true, 32}, "int64": {true, 64}, "int": {true, 64},
+	"uint8": {false, 8}, "uint16": {false, 16}, "uint32": {false, 32}, "uint64": {false, 64}, "uint": {false, 64}, "uintptr": {false, 64},
+}
+
+// widens reports whether every value of the Go number type from fits into to
+func widens(from, to string) bool {
+	if from == "float32" && to == "float64" {
+		return true
+	}
+	f, ok1 := integerBits[from]
+	t, ok2 := integerBits[to]
+	if !ok1 || !ok2 {
+		return false
+	}
+	switch {
+	case f.signed == t.signed:
+		return t.bits >= f.bits
+	case !f.signed && t.signed:
+		return t.bits > f.bits
+	}
+	return false
+}
+
+// describe returns a short description of a layout for messages
+func describe(t Type) string {
+	switch {
+	case t.Ref != "":
+		return t.Ref
+	case t.Format != "":
+		return fmt.Sprintf("%s (%s)", t.Kind, t.Format)
+	case t.Elem != nil:
+		return fmt.Sprintf("%s of %s", t.Kind, describe(*t.Elem))
+	}
+	return string(t.Kind)
+}
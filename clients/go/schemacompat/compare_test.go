@@ -0,0 +1,94 @@
+package schemacompat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type addressV1 struct {
+	Zip int32 `json:"zip"`
+}
+
+type addressV3 struct {
+	Zip  int64  `json:"zip"`
+	Note string `json:"note,omitempty"`
+}
+
+type userV1 struct {
+	Name    string            `json:"name"`
+	Email   string            `json:"email"`
+	Age     int32             `json:"age"`
+	Score   float64           `json:"score"`
+	Phone   string            `json:"phone"`
+	Address addressV1         `json:"address"`
+	Roles   []string          `json:"roles"`
+	Labels  map[string]int    `json:"labels"`
+	Meta    map[string]string `json:"meta"`
+}
+
+type addressV2 struct {
+	Zip     string `json:"zip"`
+	Country string `json:"country,omitempty"`
+}
+
+type userV2 struct {
+	Name    string            `json:"full_name"`
+	Mail    string            `json:"mail"`
+	Age     int64             `json:"age"`
+	Score   float32           `json:"score"`
+	Address addressV2         `json:"address"`
+	Roles   []int             `json:"roles"`
+	Labels  map[string]int64  `json:"labels"`
+	Meta    interface{}       `json:"meta"`
+	Active  bool              `json:"active"`
+	Extra   map[string]string `json:"extra,omitempty"`
+}
+
+// snapshotAs takes a snapshot of model recorded under name
+func snapshotAs(t *testing.T, name string, model interface{}) *Snapshot {
+	snap, err := Take(model)
+	require.NoError(t, err)
+	for _, layout := range snap.Types {
+		return &Snapshot{Types: map[string]*Type{name: layout}}
+	}
+	return nil
+}
+
+func TestCompare(t *testing.T) {
+	changes := Compare(snapshotAs(t, "User", userV1{}), snapshotAs(t, "User", userV2{}))
+
+	var lines []string
+	for _, c := range changes {
+		lines = append(lines, c.String())
+	}
+	assert.Equal(t, []string{
+		`User.name: breaking: renamed to "full_name"; stored values are no longer read`,
+		`User.email: breaking: removed; stored values are no longer read (renamed to "mail"?)`,
+		`User.age: widened from int32 to int64`,
+		`User.score: breaking: narrowed from float64 to float32`,
+		`User.phone: breaking: removed; stored values are no longer read (renamed to "mail"?)`,
+		`User.address.zip: breaking: changed from integer (int32) to string`,
+		`User.address.country: added`,
+		`User.roles[]: breaking: changed from string to integer (int)`,
+		`User.labels{}: widened from int to int64`,
+		`User.meta: widened from map of string to any`,
+		`User.mail: added; stored documents decode it as the zero value`,
+		`User.active: added; stored documents decode it as the zero value`,
+		`User.extra: added`,
+	}, lines)
+	assert.True(t, HasBreaking(changes))
+}
+
+func TestCompare_Types(t *testing.T) {
+	old := &Snapshot{Types: map[string]*Type{"A": {Kind: KindObject}, "B": {Kind: KindObject}}}
+	new := &Snapshot{Types: map[string]*Type{"B": {Kind: KindObject}, "C": {Kind: KindObject}}}
+	assert.Equal(t, []Change{
+		{Path: "A", Breaking: true, Message: "type removed"},
+		{Path: "C", Message: "type added"},
+	}, Compare(old, new))
+
+	compatible := Compare(snapshotAs(t, "Address", addressV1{}), snapshotAs(t, "Address", addressV3{}))
+	assert.False(t, HasBreaking(compatible))
+}
@@ -0,0 +1,250 @@
+// Package schemacompat detects breaking changes in the JSON serialization of Go model
+// types before they are deployed against documents already stored in ThemisDB.
+//
+// Take records the layout encoding/json gives a set of model structs: JSON field names,
+// value kinds, and nested objects, arrays, and maps. Snapshots are saved as JSON files,
+// usually checked in next to the models, and a later version is compared against them
+// with Compare or the themiscompat command:
+//
+//	snap, err := schemacompat.Take(models.User{}, models.Order{})
+//	old, err := schemacompat.Load("testdata/models.schema.json")
+//	for _, c := range schemacompat.Compare(old, snap) {
+//		if c.Breaking {
+//			t.Errorf("%s", c)
+//		}
+//	}
+//
+// Renamed fields, removed fields, and kind changes that stored documents no longer
+// decode into are breaking; added fields and widened numbers are not.
+package schemacompat
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kind is the JSON kind of a value
+type Kind string
+
+// Value kinds
+const (
+	KindString  Kind = "string"
+	KindInteger Kind = "integer"
+	KindNumber  Kind = "number"
+	KindBoolean Kind = "boolean"
+	KindObject  Kind = "object"
+	KindArray   Kind = "array"
+	KindMap     Kind = "map"
+	// KindAny is an interface or a type with a custom JSON encoding
+	KindAny Kind = "any"
+)
+
+// Type is the serialized layout of a Go type
+type Type struct {
+	Kind Kind `json:"kind"`
+	// Format refines the kind: the Go integer or float type, "date-time" for time.Time,
+	// "base64" for []byte, or the name of a type with a custom encoding
+	Format string `json:"format,omitempty"`
+	// Ref names a struct type whose layout is recorded at an outer level, for recursive
+	// types
+	Ref string `json:"ref,omitempty"`
+	// Fields are the fields of an object, in declaration order
+	Fields []Field `json:"fields,omitempty"`
+	// Elem is the element type of an array or the value type of a map
+	Elem *Type `json:"elem,omitempty"`
+}
+
+// Field is a serialized struct field
+type Field struct {
+	// Name is the JSON key
+	Name string `json:"name"`
+	// GoName is the name of the Go field, used to tell renames from replacements
+	GoName string `json:"go_name"`
+	Type   Type   `json:"type"`
+	// Optional is set for omitempty and pointer fields
+	Optional bool `json:"optional,omitempty"`
+}
+
+// Snapshot holds the layouts of model types by Go type name
+type Snapshot struct {
+	Types map[string]*Type `json:"types"`
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Take records the layouts of models, which are structs or pointers to structs. Fields
+// tagged themis:"uuid" or themis:"rev" are skipped, as the client does not store them
+// in the document.
+func Take(models ...interface{}) (*Snapshot, error) {
+	snap := &Snapshot{Types: make(map[string]*Type, len(models))}
+	for _, model := range models {
+		t := reflect.TypeOf(model)
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct || t.Name() == "" {
+			return nil, fmt.Errorf("model must be a named struct, got %T", model)
+		}
+		if _, ok := snap.Types[t.Name()]; ok {
+			return nil, fmt.Errorf("model type %s listed twice", t.Name())
+		}
+		layout := layoutOf(t, map[reflect.Type]bool{})
+		snap.Types[t.Name()] = &layout
+	}
+	return snap, nil
+}
+
+// Load reads a snapshot saved with Save
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+	return &snap, nil
+}
+
+// Save writes the snapshot as indented JSON, so changes show up in code review
+func (s *Snapshot) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// layoutOf returns the serialized layout of t; visiting holds the struct types being
+// recorded, to stop at recursive references
+func layoutOf(t reflect.Type, visiting map[reflect.Type]bool) Type {
+	if t == timeType {
+		return Type{Kind: KindString, Format: "date-time"}
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		return Type{Kind: KindAny, Format: t.String()}
+	}
+	if t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return Type{Kind: KindString, Format: t.String()}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return layoutOf(t.Elem(), visiting)
+	case reflect.String:
+		return Type{Kind: KindString}
+	case reflect.Bool:
+		return Type{Kind: KindBoolean}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return Type{Kind: KindInteger, Format: t.Kind().String()}
+	case reflect.Float32, reflect.Float64:
+		return Type{Kind: KindNumber, Format: t.Kind().String()}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return Type{Kind: KindString, Format: "base64"}
+		}
+		elem := layoutOf(t.Elem(), visiting)
+		return Type{Kind: KindArray, Elem: &elem}
+	case reflect.Map:
+		elem := layoutOf(t.Elem(), visiting)
+		return Type{Kind: KindMap, Elem: &elem}
+	case reflect.Struct:
+		if visiting[t] {
+			return Type{Kind: KindObject, Ref: t.Name()}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		return Type{Kind: KindObject, Fields: fieldsOf(t, visiting)}
+	}
+	return Type{Kind: KindAny}
+}
+
+// fieldsOf returns the serialized fields of struct type t. Like encoding/json, fields of
+// untagged embedded structs are promoted unless t declares a field of the same name.
+func fieldsOf(t reflect.Type, visiting map[reflect.Type]bool) []Field {
+	var fields, promoted []Field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if themis := sf.Tag.Get("themis"); themis == "uuid" || themis == "rev" {
+			continue
+		}
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		ft := sf.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			promoted = append(promoted, fieldsOf(ft, visiting)...)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		field := Field{
+			Name:     name,
+			GoName:   sf.Name,
+			Type:     layoutOf(sf.Type, visiting),
+			Optional: sf.Type.Kind() == reflect.Ptr || hasOption(opts, "omitempty"),
+		}
+		if hasOption(opts, "string") {
+			switch field.Type.Kind {
+			case KindInteger, KindNumber, KindBoolean:
+				field.Type = Type{Kind: KindString, Format: "quoted " + string(field.Type.Kind)}
+			}
+		}
+		fields = append(fields, field)
+	}
+
+	declared := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		declared[f.Name] = true
+	}
+	for _, f := range promoted {
+		if !declared[f.Name] {
+			fields = append(fields, f)
+			declared[f.Name] = true
+		}
+	}
+	return fields
+}
+
+// hasOption reports whether the comma-separated json tag options contain option
+func hasOption(opts, option string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// typeNames returns the type names of s in ascending order
+func (s *Snapshot) typeNames() []string {
+	names := make([]string, 0, len(s.Types))
+	for name := range s.Types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
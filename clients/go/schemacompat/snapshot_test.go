@@ -0,0 +1,77 @@
+package schemacompat
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type Audit struct {
+	CreatedBy string `json:"created_by"`
+	Note      string `json:"note"`
+}
+
+type Node struct {
+	Name     string  `json:"name"`
+	Children []*Node `json:"children,omitempty"`
+}
+
+type Account struct {
+	ID  string `json:"id" themis:"uuid"`
+	Rev string `json:"rev" themis:"rev"`
+	Audit
+	Note     string            `json:"note"`
+	Balance  int64             `json:"balance,string"`
+	Limit    *float64          `json:"limit"`
+	Opened   time.Time         `json:"opened"`
+	Key      []byte            `json:"key,omitempty"`
+	Tags     map[string]string `json:"tags"`
+	Tree     Node              `json:"tree"`
+	Extra    interface{}       `json:"extra"`
+	Internal string            `json:"-"`
+	secret   string
+}
+
+func TestTake(t *testing.T) {
+	snap, err := Take(&Account{})
+	require.NoError(t, err)
+	account := snap.Types["Account"]
+	require.NotNil(t, account)
+	assert.Equal(t, KindObject, account.Kind)
+
+	str := Type{Kind: KindString}
+	assert.Equal(t, []Field{
+		{Name: "note", GoName: "Note", Type: str},
+		{Name: "balance", GoName: "Balance", Type: Type{Kind: KindString, Format: "quoted integer"}},
+		{Name: "limit", GoName: "Limit", Type: Type{Kind: KindNumber, Format: "float64"}, Optional: true},
+		{Name: "opened", GoName: "Opened", Type: Type{Kind: KindString, Format: "date-time"}},
+		{Name: "key", GoName: "Key", Type: Type{Kind: KindString, Format: "base64"}, Optional: true},
+		{Name: "tags", GoName: "Tags", Type: Type{Kind: KindMap, Elem: &str}},
+		{Name: "tree", GoName: "Tree", Type: Type{Kind: KindObject, Fields: []Field{
+			{Name: "name", GoName: "Name", Type: str},
+			{Name: "children", GoName: "Children", Type: Type{Kind: KindArray, Elem: &Type{Kind: KindObject, Ref: "Node"}}, Optional: true},
+		}}},
+		{Name: "extra", GoName: "Extra", Type: Type{Kind: KindAny}},
+		{Name: "created_by", GoName: "CreatedBy", Type: str},
+	}, account.Fields, "uuid, rev, ignored, and unexported fields are skipped; the outer note shadows the embedded one")
+
+	_, err = Take(Account{}, &Account{})
+	assert.Error(t, err)
+	_, err = Take("not a struct")
+	assert.Error(t, err)
+}
+
+func TestSnapshot_SaveLoad(t *testing.T) {
+	snap, err := Take(Account{}, Node{})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "models.schema.json")
+	require.NoError(t, snap.Save(path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, snap, loaded)
+	assert.Empty(t, Compare(snap, loaded))
+}
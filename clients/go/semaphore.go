@@ -0,0 +1,130 @@
+package themisdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// semaphoresCollection stores the holders of each semaphore
+const semaphoresCollection = "_semaphores"
+
+// SemaphoreOptions holds distributed semaphore configuration
+type SemaphoreOptions struct {
+	// TTL bounds how long a slot is held without Refresh, so crashed holders
+	// cannot block the semaphore forever (default: 30s)
+	TTL time.Duration
+	// RetryInterval is how often Acquire retries while all slots are taken (default: 100ms)
+	RetryInterval time.Duration
+}
+
+// Semaphore is a distributed counting semaphore limiting the number of concurrent
+// holders across processes. Slots are recorded in a single document updated with
+// compare-and-swap, so acquisition is atomic without server-side locking.
+type Semaphore struct {
+	client *Client
+	name   string
+	limit  int
+	opts   SemaphoreOptions
+}
+
+// semaphoreState is the stored document of a semaphore, mapping holders to the
+// Unix millisecond at which their slot expires
+type semaphoreState struct {
+	Holders map[string]int64 `json:"holders"`
+}
+
+// Semaphore returns the semaphore name allowing at most limit concurrent holders
+func (c *Client) Semaphore(name string, limit int, opts SemaphoreOptions) *Semaphore {
+	if opts.TTL <= 0 {
+		opts.TTL = 30 * time.Second
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = 100 * time.Millisecond
+	}
+	return &Semaphore{client: c, name: name, limit: limit, opts: opts}
+}
+
+// TryAcquire takes a slot for holder if one is free and reports whether it succeeded.
+// Acquiring a slot already held by holder refreshes it.
+func (s *Semaphore) TryAcquire(ctx context.Context, holder string) (bool, error) {
+	if err := validateKey("holder", holder); err != nil {
+		return false, err
+	}
+
+	var state semaphoreState
+	acquired := false
+	err := s.client.casUpdate(ctx, semaphoresCollection, s.name, &state, func(exists bool) (bool, error) {
+		now := time.Now()
+		if state.Holders == nil {
+			state.Holders = make(map[string]int64)
+		}
+		for h, expires := range state.Holders {
+			if expires <= now.UnixMilli() {
+				delete(state.Holders, h)
+			}
+		}
+		if _, held := state.Holders[holder]; !held && len(state.Holders) >= s.limit {
+			acquired = false
+			return false, nil
+		}
+		state.Holders[holder] = now.Add(s.opts.TTL).UnixMilli()
+		acquired = true
+		return true, nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire semaphore %s: %w", s.name, err)
+	}
+	return acquired, nil
+}
+
+// Acquire blocks until holder obtains a slot or ctx is done
+func (s *Semaphore) Acquire(ctx context.Context, holder string) error {
+	for {
+		ok, err := s.TryAcquire(ctx, holder)
+		if err != nil || ok {
+			return err
+		}
+		select {
+		case <-time.After(s.opts.RetryInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// AcquireWithin is Acquire bounded by d. It returns a *TimeoutError matching
+// ErrTimeout if no slot became free within d.
+func (s *Semaphore) AcquireWithin(ctx context.Context, holder string, d time.Duration) error {
+	return within(ctx, "acquire semaphore "+s.name, d, func(ctx context.Context) error {
+		return s.Acquire(ctx, holder)
+	})
+}
+
+// Refresh extends the slot of holder by TTL; long-running holders call it periodically
+func (s *Semaphore) Refresh(ctx context.Context, holder string) error {
+	ok, err := s.TryAcquire(ctx, holder)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: semaphore %s slot of %s expired", ErrLeaseLost, s.name, holder)
+	}
+	return nil
+}
+
+// Release frees the slot of holder
+func (s *Semaphore) Release(ctx context.Context, holder string) error {
+	var state semaphoreState
+	err := s.client.casUpdate(ctx, semaphoresCollection, s.name, &state, func(exists bool) (bool, error) {
+		if _, held := state.Holders[holder]; !held {
+			return false, nil
+		}
+		delete(state.Holders, holder)
+		return true, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to release semaphore %s: %w", s.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package themisdb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSemaphore_Contention(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	sem := client.Semaphore("exports", 3, SemaphoreOptions{RetryInterval: time.Millisecond})
+
+	var active, maxActive int32
+	var wg sync.WaitGroup
+	for i := 0; i < 12; i++ {
+		wg.Add(1)
+		go func(holder string) {
+			defer wg.Done()
+			if !assert.NoError(t, sem.Acquire(ctx, holder)) {
+				return
+			}
+			n := atomic.AddInt32(&active, 1)
+			for {
+				max := atomic.LoadInt32(&maxActive)
+				if n <= max || atomic.CompareAndSwapInt32(&maxActive, max, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&active, -1)
+			assert.NoError(t, sem.Release(ctx, holder))
+		}(fmt.Sprintf("worker-%d", i))
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, maxActive, int32(3))
+	assert.Greater(t, maxActive, int32(0))
+}
+
+func TestSemaphore_ExpiredHolder(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	ctx := context.Background()
+	sem := client.Semaphore("exports", 1, SemaphoreOptions{TTL: 20 * time.Millisecond})
+
+	ok, err := sem.TryAcquire(ctx, "crashed")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = sem.TryAcquire(ctx, "worker")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	time.Sleep(30 * time.Millisecond)
+	ok, err = sem.TryAcquire(ctx, "worker")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.ErrorIs(t, sem.Refresh(ctx, "crashed"), ErrLeaseLost)
+}
+
+func BenchmarkSemaphore_AcquireRelease(b *testing.B) {
+	client, _ := newMemoryClient(b)
+	ctx := context.Background()
+	sem := client.Semaphore("bench", 4, SemaphoreOptions{RetryInterval: time.Millisecond})
+
+	var id int64
+	b.RunParallel(func(pb *testing.PB) {
+		holder := fmt.Sprintf("holder-%d", atomic.AddInt64(&id, 1))
+		for pb.Next() {
+			if err := sem.Acquire(ctx, holder); err != nil {
+				b.Error(err)
+				return
+			}
+			if err := sem.Release(ctx, holder); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func TestSemaphore_AcquireWithin(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	ctx := context.Background()
+	sem := client.Semaphore("exports", 1, SemaphoreOptions{RetryInterval: time.Millisecond})
+
+	require.NoError(t, sem.AcquireWithin(ctx, "a", time.Second))
+	err := sem.AcquireWithin(ctx, "b", 20*time.Millisecond)
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	var timeout *TimeoutError
+	require.ErrorAs(t, err, &timeout)
+	assert.Equal(t, "acquire semaphore exports", timeout.Op)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	err = sem.AcquireWithin(canceled, "b", time.Second)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, ErrTimeout, "the caller's context is not a timeout of the bound")
+
+	require.NoError(t, sem.Release(ctx, "a"))
+	require.NoError(t, sem.AcquireWithin(ctx, "b", time.Second))
+}
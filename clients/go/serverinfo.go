@@ -0,0 +1,190 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// serverInfoPath is the endpoint describing the server
+const serverInfoPath = "/info"
+
+// negotiationRetry is the time after a failed ServerInfo request before negotiation
+// tries again
+const negotiationRetry = 30 * time.Second
+
+// Optional server capabilities reported by ServerInfo
+const (
+	FeatureVectorSearch ServerFeature = "vector_search"
+	FeatureSnapshots    ServerFeature = "snapshots"
+)
+
+// ServerLimits are the request limits of a server; zero means not limited or not
+// reported
+type ServerLimits struct {
+	MaxRequestBytes int64 `json:"max_request_bytes"`
+	MaxBatchSize    int   `json:"max_batch_size"`
+	// MaxTransactionTimeout bounds TransactionOptions.Timeout
+	MaxTransactionTimeout time.Duration `json:"-"`
+}
+
+// UnmarshalJSON decodes max_transaction_timeout_ms into MaxTransactionTimeout
+func (l *ServerLimits) UnmarshalJSON(data []byte) error {
+	type plain ServerLimits
+	aux := struct {
+		*plain
+		MaxTransactionTimeoutMs int64 `json:"max_transaction_timeout_ms"`
+	}{plain: (*plain)(l)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	l.MaxTransactionTimeout = time.Duration(aux.MaxTransactionTimeoutMs) * time.Millisecond
+	return nil
+}
+
+// ServerInfo describes the version and capabilities of a server
+type ServerInfo struct {
+	Version string `json:"version"`
+	// Build is the commit the server was built from
+	Build     string    `json:"build"`
+	BuildDate time.Time `json:"build_date"`
+	// Features are the enabled optional capabilities, e.g. FeatureVectorSearch
+	Features []ServerFeature `json:"features"`
+	// Compression lists the content encodings the server accepts
+	Compression []string     `json:"compression"`
+	Limits      ServerLimits `json:"limits"`
+}
+
+// HasFeature reports whether the server has feature enabled
+func (i *ServerInfo) HasFeature(feature ServerFeature) bool {
+	for _, f := range i.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// AtLeast reports whether the server version is version or newer. Versions compare
+// by their dot-separated numbers, e.g. 1.10.0 is newer than 1.9.2; a "v" prefix and
+// pre-release or build suffixes are ignored.
+func (i *ServerInfo) AtLeast(version string) bool {
+	return compareVersions(i.Version, version) >= 0
+}
+
+// compareVersions returns -1, 0, or 1 if version a is older than, equal to, or newer
+// than b
+func compareVersions(a, b string) int {
+	x, y := versionParts(a), versionParts(b)
+	for len(x) < len(y) {
+		x = append(x, 0)
+	}
+	for len(y) < len(x) {
+		y = append(y, 0)
+	}
+	for i := range x {
+		switch {
+		case x[i] < y[i]:
+			return -1
+		case x[i] > y[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// versionParts returns the numbers of a version, 0 for parts that are not numeric
+func versionParts(version string) []int {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	var parts []int
+	for _, s := range strings.Split(version, ".") {
+		n, _ := strconv.Atoi(s)
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// ServerInfo returns the version, features, and limits of the server. With
+// Config.Negotiate the result also updates the capabilities the client relies on.
+func (c *Client) ServerInfo(ctx context.Context) (*ServerInfo, error) {
+	var info ServerInfo
+	if err := c.readRequest(ctx, "GET", serverInfoPath, nil, &info, nil); err != nil {
+		return nil, fmt.Errorf("failed to get server info: %w", err)
+	}
+	if c.negotiation != nil {
+		c.negotiation.apply(c, &info)
+	}
+	return &info, nil
+}
+
+// negotiation holds the server capabilities learned with Config.Negotiate. It is
+// shared by the root client and the clients derived from it.
+type negotiation struct {
+	mu       sync.Mutex
+	resolved bool
+	info     *ServerInfo
+	retryAt  time.Time
+}
+
+// negotiate fetches the server info unless it is known already. Failures leave the
+// capabilities unknown, which restricts nothing, and are retried after a while.
+func (c *Client) negotiate(ctx context.Context) *ServerInfo {
+	n := c.negotiation
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.resolved || time.Now().Before(n.retryAt) {
+		return n.info
+	}
+
+	var info ServerInfo
+	err := c.readRequest(ctx, "GET", serverInfoPath, nil, &info, nil)
+	var statusErr *StatusError
+	switch {
+	case err == nil:
+		n.store(c, &info)
+	case errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusNotFound || statusErr.StatusCode == http.StatusNotImplemented):
+		// the server predates ServerInfo
+		n.resolved = true
+	default:
+		n.retryAt = time.Now().Add(negotiationRetry)
+	}
+	return n.info
+}
+
+// apply records info as the server capabilities
+func (n *negotiation) apply(c *Client, info *ServerInfo) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.store(c, info)
+}
+
+// store records info and restricts compression to the encodings the server accepts;
+// n.mu must be held
+func (n *negotiation) store(c *Client, info *ServerInfo) {
+	n.info = info
+	n.resolved = true
+	if t, ok := c.transport.(*httpTransport); ok && t.compression != nil && info.Compression != nil {
+		t.compression.restrict(info.Compression)
+	}
+}
+
+// requireFeature fails with ErrFeatureUnsupported if negotiation found that the
+// server does not support feature
+func (c *Client) requireFeature(ctx context.Context, feature ServerFeature) error {
+	if c.negotiation == nil {
+		return nil
+	}
+	if info := c.negotiate(ctx); info != nil && !info.HasFeature(feature) {
+		return fmt.Errorf("%w: %s on server %s", ErrFeatureUnsupported, feature, info.Version)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testServerInfo = `{
+	"version": "v2.3.1-rc1",
+	"build": "9f1c2ab",
+	"build_date": "2026-09-01T12:00:00Z",
+	"features": ["cdc", "snapshots"],
+	"compression": ["gzip"],
+	"limits": {"max_request_bytes": 16777216, "max_batch_size": 1000, "max_transaction_timeout_ms": 300000}
+}`
+
+func TestClient_ServerInfo(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/info", r.URL.Path)
+		w.Write([]byte(testServerInfo))
+	})
+	info, err := client.ServerInfo(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "9f1c2ab", info.Build)
+	assert.Equal(t, 2026, info.BuildDate.Year())
+	assert.True(t, info.HasFeature(FeatureCDC))
+	assert.False(t, info.HasFeature(FeatureVectorSearch))
+	assert.Equal(t, ServerLimits{MaxRequestBytes: 16 << 20, MaxBatchSize: 1000, MaxTransactionTimeout: 5 * time.Minute}, info.Limits)
+
+	assert.True(t, info.AtLeast("2.3"))
+	assert.True(t, info.AtLeast("v2.3.1"))
+	assert.True(t, info.AtLeast("2.2.10"))
+	assert.False(t, info.AtLeast("2.10.0"))
+	assert.False(t, info.AtLeast("3"))
+}
+
+func TestClient_Negotiate(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	status := http.StatusOK
+	base := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("Content-Encoding"))
+		if r.URL.Path == "/info" {
+			w.WriteHeader(status)
+			w.Write([]byte(testServerInfo))
+			return
+		}
+		w.Write([]byte(`{"results": [], "data": []}`))
+	})
+	ctx := context.Background()
+	client := NewClient(Config{
+		Endpoints:   base.endpoints,
+		Negotiate:   true,
+		Compression: &CompressionOptions{Algorithm: CompressionZstd, MinSize: 10},
+	})
+	defer client.Close()
+
+	// the server does not accept zstd, so bodies fall back to gzip
+	require.NoError(t, client.Put(ctx, "document", "notes", "n1", map[string]string{"notes": strings.Repeat("a", 100)}))
+	_, err := client.VectorSearch(ctx, "docs", VectorQuery{Vector: []float32{1}})
+	assert.ErrorIs(t, err, ErrFeatureUnsupported)
+	var docs []interface{}
+	require.NoError(t, client.Namespace("tenant-a").AtSnapshot(SnapshotID("s1")).Query(ctx, "FOR d IN docs RETURN d", &docs))
+	assert.Equal(t, []string{"GET /info ", "PUT /api/document/notes/n1 gzip", "POST /api/query "}, requests)
+
+	// a server predating ServerInfo restricts nothing
+	requests, status = nil, http.StatusNotFound
+	client = NewClient(Config{Endpoints: base.endpoints, Negotiate: true})
+	defer client.Close()
+	_, err = client.VectorSearch(ctx, "docs", VectorQuery{Vector: []float32{1}})
+	require.NoError(t, err)
+	_, err = client.VectorSearch(ctx, "docs", VectorQuery{Vector: []float32{1}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GET /info ", "POST /vector/search ", "POST /vector/search "}, requests)
+}
+
+func TestCompression_Restrict(t *testing.T) {
+	c := newCompression(&CompressionOptions{Algorithm: CompressionZstd})
+	assert.Equal(t, CompressionZstd, c.current())
+	c.restrict([]string{"br", CompressionZstd, CompressionGzip})
+	assert.Equal(t, CompressionZstd, c.current())
+	c.restrict([]string{CompressionGzip})
+	assert.Equal(t, CompressionGzip, c.current())
+	assert.True(t, strings.HasPrefix(c.acceptEncoding(), "gzip"))
+	c.restrict([]string{})
+	assert.Equal(t, "", c.current())
+
+	body := []byte(strings.Repeat("a", 2000))
+	encoded, encoding, err := c.encode(body)
+	require.NoError(t, err)
+	assert.Equal(t, "", encoding)
+	assert.Equal(t, body, encoded)
+}
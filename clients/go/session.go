@@ -0,0 +1,227 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SessionStoreOptions holds session store configuration
+type SessionStoreOptions struct {
+	// Collection stores the sessions in the kv model (default: "_sessions")
+	Collection string
+	// IdleTimeout enables sliding expiration: sessions expire after this long without
+	// a Find, but never after the expiry passed to Commit. Zero disables it.
+	IdleTimeout time.Duration
+	// CleanupInterval is the period of background GC of expired sessions, zero disables it
+	CleanupInterval time.Duration
+	// GCBatchSize is the number of expired sessions deleted per transaction (default: 100)
+	GCBatchSize int
+}
+
+// SessionStore keeps HTTP sessions in ThemisDB. Its methods match the Store, CtxStore,
+// and IterableStore interfaces of github.com/alexedwards/scs/v2, so it can be assigned
+// to SessionManager.Store directly; other session libraries such as gorilla/sessions
+// wrap FindCtx, CommitCtx, and DeleteCtx in a small adapter.
+type SessionStore struct {
+	client *Client
+	opts   SessionStoreOptions
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// storedSession is a session document. ExpiresAt slides with IdleTimeout and is
+// capped by Deadline, the absolute expiry requested by the session library.
+type storedSession struct {
+	Data      []byte `json:"data"`
+	ExpiresAt int64  `json:"expires_at"`
+	Deadline  int64  `json:"deadline"`
+}
+
+// SessionStore returns a session store and starts its background GC if configured.
+// Call StopCleanup to stop the GC.
+func (c *Client) SessionStore(opts SessionStoreOptions) *SessionStore {
+	if opts.Collection == "" {
+		opts.Collection = "_sessions"
+	}
+	if opts.GCBatchSize <= 0 {
+		opts.GCBatchSize = 100
+	}
+	s := &SessionStore{client: c, opts: opts, stop: make(chan struct{})}
+	if opts.CleanupInterval > 0 {
+		go s.runCleanup()
+	}
+	return s
+}
+
+// Find returns the data of an unexpired session (scs.Store)
+func (s *SessionStore) Find(token string) ([]byte, bool, error) {
+	return s.FindCtx(context.Background(), token)
+}
+
+// Commit stores the session data until expiry (scs.Store)
+func (s *SessionStore) Commit(token string, b []byte, expiry time.Time) error {
+	return s.CommitCtx(context.Background(), token, b, expiry)
+}
+
+// Delete removes a session (scs.Store)
+func (s *SessionStore) Delete(token string) error {
+	return s.DeleteCtx(context.Background(), token)
+}
+
+// All returns the data of all unexpired sessions by token (scs.IterableStore)
+func (s *SessionStore) All() (map[string][]byte, error) {
+	return s.AllCtx(context.Background())
+}
+
+// FindCtx returns the data of an unexpired session and, with IdleTimeout set, extends
+// its expiry once less than half of the idle timeout is left
+func (s *SessionStore) FindCtx(ctx context.Context, token string) ([]byte, bool, error) {
+	if err := validateKey("token", token); err != nil {
+		return nil, false, err
+	}
+
+	var session storedSession
+	resp, err := s.client.send(ctx, "GET", entityPath(ModelKV, s.opts.Collection, token), nil, nil)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find session: %w", err)
+	}
+	if err := json.Unmarshal(resp.Body, &session); err != nil {
+		return nil, false, fmt.Errorf("failed to decode session: %w", err)
+	}
+
+	now := time.Now()
+	if session.ExpiresAt <= now.UnixMilli() {
+		return nil, false, nil
+	}
+	if s.opts.IdleTimeout > 0 && session.ExpiresAt-now.UnixMilli() < (s.opts.IdleTimeout/2).Milliseconds() {
+		expiresAt := s.slidingExpiry(now, session.Deadline)
+		if expiresAt > session.ExpiresAt {
+			patch := map[string]int64{"expires_at": expiresAt}
+			if err := s.client.Patch(ctx, ModelKV, s.opts.Collection, token, patch); err != nil {
+				return nil, false, fmt.Errorf("failed to extend session: %w", err)
+			}
+		}
+	}
+	return session.Data, true, nil
+}
+
+// CommitCtx stores the session data until expiry
+func (s *SessionStore) CommitCtx(ctx context.Context, token string, b []byte, expiry time.Time) error {
+	session := storedSession{
+		Data:      b,
+		ExpiresAt: s.slidingExpiry(time.Now(), expiry.UnixMilli()),
+		Deadline:  expiry.UnixMilli(),
+	}
+	if err := s.client.Put(ctx, ModelKV, s.opts.Collection, token, session); err != nil {
+		return fmt.Errorf("failed to commit session: %w", err)
+	}
+	return nil
+}
+
+// DeleteCtx removes a session; deleting an unknown session is not an error
+func (s *SessionStore) DeleteCtx(ctx context.Context, token string) error {
+	if err := validateKey("token", token); err != nil {
+		return err
+	}
+	resp, err := s.client.send(ctx, "DELETE", entityPath(ModelKV, s.opts.Collection, token), nil, nil)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// AllCtx returns the data of all unexpired sessions by token
+func (s *SessionStore) AllCtx(ctx context.Context) (map[string][]byte, error) {
+	sessions := map[string][]byte{}
+	now := time.Now().UnixMilli()
+	it := s.client.Scan(ctx, ModelKV, s.opts.Collection, ScanOptions{BatchSize: s.opts.GCBatchSize})
+	for it.Next() {
+		var session storedSession
+		if err := it.Decode(&session); err != nil {
+			return nil, err
+		}
+		if session.ExpiresAt > now {
+			sessions[it.UUID()] = session.Data
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// GC deletes expired sessions in transactions of GCBatchSize deletes and returns
+// the number of sessions removed
+func (s *SessionStore) GC(ctx context.Context) (int, error) {
+	var expired []string
+	now := time.Now().UnixMilli()
+	it := s.client.Scan(ctx, ModelKV, s.opts.Collection, ScanOptions{BatchSize: s.opts.GCBatchSize})
+	for it.Next() {
+		var session storedSession
+		if err := it.Decode(&session); err != nil {
+			return 0, err
+		}
+		if session.ExpiresAt <= now {
+			expired = append(expired, it.UUID())
+		}
+	}
+	if err := it.Err(); err != nil {
+		return 0, fmt.Errorf("failed to collect sessions: %w", err)
+	}
+
+	removed := 0
+	for start := 0; start < len(expired); start += s.opts.GCBatchSize {
+		batch := expired[start:min(start+s.opts.GCBatchSize, len(expired))]
+		err := s.client.runInTransaction(ctx, func(tx *Transaction) error {
+			for _, token := range batch {
+				if err := tx.Delete(ctx, ModelKV, s.opts.Collection, token); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return removed, fmt.Errorf("failed to collect sessions: %w", err)
+		}
+		removed += len(batch)
+	}
+	return removed, nil
+}
+
+// StopCleanup stops the background GC
+func (s *SessionStore) StopCleanup() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// runCleanup collects expired sessions until StopCleanup is called
+func (s *SessionStore) runCleanup() {
+	ticker := time.NewTicker(s.opts.CleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.GC(context.Background())
+		}
+	}
+}
+
+// slidingExpiry returns the expiry of a session used at now, capped by deadline
+func (s *SessionStore) slidingExpiry(now time.Time, deadline int64) int64 {
+	if s.opts.IdleTimeout <= 0 {
+		return deadline
+	}
+	return min(now.Add(s.opts.IdleTimeout).UnixMilli(), deadline)
+}
@@ -0,0 +1,83 @@
+package themisdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionStore_FindCommitDelete(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	store := client.SessionStore(SessionStoreOptions{})
+
+	data, found, err := store.Find("missing")
+	require.NoError(t, err)
+	assert.False(t, found)
+	assert.Nil(t, data)
+
+	require.NoError(t, store.Commit("token-1", []byte("user=alice"), time.Now().Add(time.Hour)))
+	data, found, err = store.Find("token-1")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []byte("user=alice"), data)
+
+	require.NoError(t, store.Commit("token-2", []byte("expired"), time.Now().Add(-time.Second)))
+	_, found, err = store.Find("token-2")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	all, err := store.All()
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"token-1": []byte("user=alice")}, all)
+
+	require.NoError(t, store.Delete("token-1"))
+	require.NoError(t, store.Delete("token-1"))
+	_, found, err = store.Find("token-1")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestSessionStore_SlidingExpiration(t *testing.T) {
+	client, mem := newMemoryClient(t)
+	store := client.SessionStore(SessionStoreOptions{IdleTimeout: 100 * time.Millisecond})
+	ctx := context.Background()
+
+	require.NoError(t, store.CommitCtx(ctx, "token", []byte("data"), time.Now().Add(time.Hour)))
+
+	// each Find within the idle timeout keeps the session alive
+	for i := 0; i < 4; i++ {
+		time.Sleep(60 * time.Millisecond)
+		_, found, err := store.FindCtx(ctx, "token")
+		require.NoError(t, err)
+		require.True(t, found)
+	}
+	assert.Positive(t, mem.patches)
+
+	time.Sleep(150 * time.Millisecond)
+	_, found, err := store.FindCtx(ctx, "token")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestSessionStore_GC(t *testing.T) {
+	client, mem := newMemoryClient(t)
+	store := client.SessionStore(SessionStoreOptions{GCBatchSize: 2})
+	ctx := context.Background()
+
+	for _, token := range []string{"a", "b", "c"} {
+		require.NoError(t, store.CommitCtx(ctx, token, []byte(token), time.Now().Add(-time.Minute)))
+	}
+	require.NoError(t, store.CommitCtx(ctx, "live", []byte("live"), time.Now().Add(time.Hour)))
+
+	removed, err := store.GC(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, removed)
+	assert.Equal(t, 2, mem.commits)
+
+	all, err := store.AllCtx(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+}
@@ -0,0 +1,104 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_AtSnapshot(t *testing.T) {
+	var requests []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("X-Themis-Snapshot")+r.Header.Get("X-Themis-Snapshot-Time")+" "+r.Header.Get("X-Themis-Namespace"))
+		w.Write([]byte(`{"data": [], "documents": [], "missing": []}`))
+	})
+	ctx := context.Background()
+	var result map[string]interface{}
+	var docs []map[string]interface{}
+
+	snap := client.Namespace("tenant-a").AtSnapshot(SnapshotID("snap-42"))
+	ref, ok := snap.Snapshot()
+	assert.True(t, ok)
+	assert.Equal(t, "snap-42", ref.ID)
+	_, ok = client.Snapshot()
+	assert.False(t, ok)
+
+	require.NoError(t, snap.Get(ctx, "relational", "orders", "1", &result))
+	require.NoError(t, snap.Query(ctx, "FOR o IN orders RETURN o", &docs))
+	_, err := snap.GetMany(ctx, "relational", "orders", []string{"1", "2"}, &docs)
+	require.NoError(t, err)
+
+	// writes and transactions are rejected before they are sent
+	assert.ErrorIs(t, snap.Put(ctx, "relational", "orders", "1", result), ErrReadOnly)
+	assert.ErrorIs(t, snap.Delete(ctx, "relational", "orders", "1"), ErrReadOnly)
+	_, err = snap.BeginTransaction(ctx, nil)
+	assert.ErrorIs(t, err, ErrReadOnly)
+	assert.ErrorIs(t, snap.Query(ctx, "FOR o IN orders UPDATE o WITH {seen: true} IN orders", nil), ErrReadOnly)
+	assert.ErrorIs(t, snap.Query(ctx, "INSERT {total: 1} INTO orders", nil), ErrReadOnly)
+
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+	require.NoError(t, client.AtSnapshot(SnapshotAt(at)).Namespace("tenant-b").Get(ctx, "relational", "orders", "1", &result))
+	require.NoError(t, client.Put(ctx, "relational", "orders", "1", result))
+
+	assert.Equal(t, []string{
+		"GET /api/relational/orders/1 snap-42 tenant-a",
+		"POST /api/query snap-42 tenant-a",
+		"POST /api/relational/orders/_mget snap-42 tenant-a",
+		"GET /api/relational/orders/1 2026-01-02T02:04:05Z tenant-b",
+		"PUT /api/relational/orders/1  ",
+	}, requests)
+}
+
+func TestClient_AtSnapshotInvalid(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+	var result map[string]interface{}
+	for _, ref := range []SnapshotRef{{}, {ID: "a/b"}, {ID: "snap-1", Time: time.Now()}} {
+		err := client.AtSnapshot(ref).Get(context.Background(), "relational", "orders", "1", &result)
+		assert.ErrorIs(t, err, ErrInvalidInput, "%+v", ref)
+	}
+}
+
+func TestClient_ReadAsOf(t *testing.T) {
+	var requests []string
+	base := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/info" {
+			w.Write([]byte(`{"version": "v2.0.0", "features": ["cdc"]}`))
+			return
+		}
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.Header.Get("X-Themis-Snapshot")+r.Header.Get("X-Themis-Snapshot-Time")+" "+r.Header.Get("X-Themis-Consistency"))
+		w.Write([]byte(`{"data": []}`))
+	})
+	ctx := context.Background()
+	var result map[string]interface{}
+	var docs []map[string]interface{}
+
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+	require.NoError(t, base.GetWithOptions(ctx, "relational", "orders", "1", &result, &ReadOptions{AsOf: at}))
+	require.NoError(t, base.QueryWithOptions(ctx, "FOR o IN orders RETURN o", &QueryOptions{Read: &ReadOptions{SnapshotID: "snap-42", Consistency: ConsistencyEventual}}, &docs))
+	require.NoError(t, base.Get(ctx, "relational", "orders", "1", &result))
+	assert.Equal(t, []string{
+		"GET /api/relational/orders/1 2026-01-02T02:04:05Z ",
+		"POST /api/query snap-42 eventual",
+		"GET /api/relational/orders/1  ",
+	}, requests)
+
+	// a snapshot selected per read conflicts with the snapshot of the client
+	err := base.AtSnapshot(SnapshotID("snap-1")).GetWithOptions(ctx, "relational", "orders", "1", &result, &ReadOptions{AsOf: at})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	err = base.GetWithOptions(ctx, "relational", "orders", "1", &result, &ReadOptions{AsOf: at, SnapshotID: "snap-1"})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+
+	client := NewClient(Config{Endpoints: base.endpoints, Negotiate: true})
+	defer client.Close()
+	err = client.GetWithOptions(ctx, "relational", "orders", "1", &result, &ReadOptions{AsOf: at})
+	assert.ErrorIs(t, err, ErrFeatureUnsupported)
+	err = client.QueryWithOptions(ctx, "FOR o IN orders RETURN o", &QueryOptions{Read: &ReadOptions{AsOf: at}}, &docs)
+	assert.ErrorIs(t, err, ErrFeatureUnsupported)
+	assert.Len(t, requests, 3)
+}
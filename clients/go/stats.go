@@ -0,0 +1,271 @@
+package themisdb
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds of the latency histograms, chosen to
+// match common SLO thresholds such as 10ms point reads or 250ms queries
+var DefaultLatencyBuckets = []time.Duration{
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Operation kinds of OperationStats, matching the fields of OperationTimeouts
+const (
+	OperationRead        = "read"
+	OperationWrite       = "write"
+	OperationQuery       = "query"
+	OperationTransaction = "transaction"
+)
+
+// operationOf returns the kind of operation of req
+func operationOf(req *Request) string {
+	path, _, _ := strings.Cut(req.Path, "?")
+	switch {
+	case req.Header["X-Transaction-Id"] != "" || strings.HasPrefix(path, "/transaction/"):
+		return OperationTransaction
+	case path == "/api/query" || strings.HasPrefix(path, "/api/query/"):
+		return OperationQuery
+	case req.Idempotent || req.Method == "GET" || req.Method == "HEAD":
+		return OperationRead
+	}
+	return OperationWrite
+}
+
+// collectionOf returns "<model>/<collection>" for requests on a collection, empty
+// for other requests
+func collectionOf(req *Request) string {
+	path, _, _ := strings.Cut(req.Path, "?")
+	rest, ok := strings.CutPrefix(path, "/api/")
+	if !ok || rest == "query" || strings.HasPrefix(rest, "query/") {
+		return ""
+	}
+	parts := strings.SplitN(rest, "/", 3)
+	if len(parts) < 2 || parts[1] == "" {
+		return ""
+	}
+	return parts[0] + "/" + parts[1]
+}
+
+// LatencyBucket is a bucket of a latency histogram
+type LatencyBucket struct {
+	// UpperBound is the inclusive upper bound of the bucket
+	UpperBound time.Duration
+	// Count is the number of requests at or below UpperBound, including those of the
+	// smaller buckets
+	Count uint64
+}
+
+// OperationStats is the latency histogram of the requests of one kind of operation on
+// one collection
+type OperationStats struct {
+	// Operation is OperationRead, OperationWrite, OperationQuery, or OperationTransaction
+	Operation string
+	// Collection is "<model>/<collection>", empty for requests not addressed to a
+	// collection, such as queries
+	Collection string
+	// Count is the number of requests, Errors the number that failed or were answered
+	// with a server error
+	Count  uint64
+	Errors uint64
+	// Sum is the total latency of the requests
+	Sum time.Duration
+	// Buckets are cumulative, in ascending order; requests slower than the last bound
+	// are only included in Count
+	Buckets []LatencyBucket
+}
+
+// Within returns the fraction of requests that completed within d, e.g. to check an
+// SLO of 99% of point reads within 10ms. It is exact if d is a bucket bound and
+// otherwise rounds d down to the next smaller bound.
+func (s OperationStats) Within(d time.Duration) float64 {
+	if s.Count == 0 {
+		return 1
+	}
+	var within uint64
+	for _, b := range s.Buckets {
+		if b.UpperBound > d {
+			break
+		}
+		within = b.Count
+	}
+	return float64(within) / float64(s.Count)
+}
+
+// Quantile estimates the latency below which the fraction q of requests completed,
+// by linear interpolation within its bucket. It returns the last bound if the
+// quantile falls beyond it.
+func (s OperationStats) Quantile(q float64) time.Duration {
+	if s.Count == 0 || len(s.Buckets) == 0 {
+		return 0
+	}
+	rank := q * float64(s.Count)
+	var lower time.Duration
+	var below uint64
+	for _, b := range s.Buckets {
+		if float64(b.Count) >= rank {
+			inBucket := b.Count - below
+			if inBucket == 0 {
+				return b.UpperBound
+			}
+			fraction := (rank - float64(below)) / float64(inBucket)
+			return lower + time.Duration(fraction*float64(b.UpperBound-lower))
+		}
+		lower, below = b.UpperBound, b.Count
+	}
+	return lower
+}
+
+// opKey identifies a latency histogram
+type opKey struct {
+	operation  string
+	collection string
+}
+
+// histogram counts latencies in buckets; counts[len(bounds)] holds the requests
+// slower than the last bound
+type histogram struct {
+	counts []atomic.Uint64
+	sum    atomic.Int64
+	errors atomic.Uint64
+}
+
+// latencyStats holds the latency histograms of a client and the clients derived
+// from it
+type latencyStats struct {
+	bounds []time.Duration
+
+	mu  sync.RWMutex
+	ops map[opKey]*histogram
+}
+
+// newLatencyStats returns histograms with the given bounds, DefaultLatencyBuckets if
+// there are none
+func newLatencyStats(bounds []time.Duration) *latencyStats {
+	if len(bounds) == 0 {
+		bounds = DefaultLatencyBuckets
+	}
+	sorted := append([]time.Duration(nil), bounds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return &latencyStats{bounds: sorted, ops: map[opKey]*histogram{}}
+}
+
+// observe records the latency and outcome of req
+func (s *latencyStats) observe(req *Request, latency time.Duration, failed bool) {
+	key := opKey{operation: operationOf(req), collection: collectionOf(req)}
+	s.mu.RLock()
+	h := s.ops[key]
+	s.mu.RUnlock()
+	if h == nil {
+		s.mu.Lock()
+		if h = s.ops[key]; h == nil {
+			h = &histogram{counts: make([]atomic.Uint64, len(s.bounds)+1)}
+			s.ops[key] = h
+		}
+		s.mu.Unlock()
+	}
+
+	i := sort.Search(len(s.bounds), func(i int) bool { return latency <= s.bounds[i] })
+	h.counts[i].Add(1)
+	h.sum.Add(int64(latency))
+	if failed {
+		h.errors.Add(1)
+	}
+}
+
+// snapshot returns the histograms sorted by operation and collection
+func (s *latencyStats) snapshot() []OperationStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := make([]OperationStats, 0, len(s.ops))
+	for key, h := range s.ops {
+		op := OperationStats{
+			Operation:  key.operation,
+			Collection: key.collection,
+			Errors:     h.errors.Load(),
+			Sum:        time.Duration(h.sum.Load()),
+			Buckets:    make([]LatencyBucket, len(s.bounds)),
+		}
+		for i, bound := range s.bounds {
+			op.Count += h.counts[i].Load()
+			op.Buckets[i] = LatencyBucket{UpperBound: bound, Count: op.Count}
+		}
+		op.Count += h.counts[len(s.bounds)].Load()
+		stats = append(stats, op)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Operation != stats[j].Operation {
+			return stats[i].Operation < stats[j].Operation
+		}
+		return stats[i].Collection < stats[j].Collection
+	})
+	return stats
+}
+
+// Stats returns the client-side latency histograms of the requests sent so far, per
+// kind of operation and collection. Latencies are measured around the interceptors
+// and include retries, hedging, and failover. Clients derived with Namespace or
+// AtSnapshot share the histograms of their root client.
+func (c *Client) Stats() []OperationStats {
+	return c.stats.snapshot()
+}
+
+// WritePrometheus writes the latency histograms in the Prometheus text exposition
+// format, as histogram themisdb_client_request_duration_seconds and counter
+// themisdb_client_request_errors_total
+func (c *Client) WritePrometheus(w io.Writer) error {
+	var b strings.Builder
+	stats := c.Stats()
+
+	b.WriteString("# HELP themisdb_client_request_duration_seconds Latency of ThemisDB requests measured by the client.\n")
+	b.WriteString("# TYPE themisdb_client_request_duration_seconds histogram\n")
+	for _, s := range stats {
+		labels := fmt.Sprintf(`operation=%q,collection=%q`, s.Operation, s.Collection)
+		for _, bucket := range s.Buckets {
+			le := strconv.FormatFloat(bucket.UpperBound.Seconds(), 'g', -1, 64)
+			fmt.Fprintf(&b, "themisdb_client_request_duration_seconds_bucket{%s,le=%q} %d\n", labels, le, bucket.Count)
+		}
+		fmt.Fprintf(&b, "themisdb_client_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, s.Count)
+		fmt.Fprintf(&b, "themisdb_client_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(s.Sum.Seconds(), 'g', -1, 64))
+		fmt.Fprintf(&b, "themisdb_client_request_duration_seconds_count{%s} %d\n", labels, s.Count)
+	}
+
+	b.WriteString("# HELP themisdb_client_request_errors_total ThemisDB requests that failed or were answered with a server error.\n")
+	b.WriteString("# TYPE themisdb_client_request_errors_total counter\n")
+	for _, s := range stats {
+		fmt.Fprintf(&b, "themisdb_client_request_errors_total{operation=%q,collection=%q} %d\n", s.Operation, s.Collection, s.Errors)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// PrometheusHandler returns an HTTP handler serving WritePrometheus, to be mounted
+// at a metrics path or merged into an existing exporter
+func (c *Client) PrometheusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.WritePrometheus(w)
+	})
+}
@@ -0,0 +1,105 @@
+package themisdb
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Stats(t *testing.T) {
+	delays := map[string]time.Duration{"/api/relational/orders/slow": 30 * time.Millisecond}
+	base := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delays[r.URL.Path])
+		if r.URL.Path == "/api/relational/orders/broken" {
+			http.Error(w, "disk error", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"data": []}`))
+	})
+	client := NewClient(Config{
+		Endpoints:      base.endpoints,
+		MaxRetries:     1,
+		LatencyBuckets: []time.Duration{time.Second, 10 * time.Millisecond},
+	})
+	defer client.Close()
+	ctx := context.Background()
+	var doc map[string]interface{}
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, client.Get(ctx, "relational", "orders", "o1", &doc))
+	}
+	require.NoError(t, client.Namespace("tenant-a").Get(ctx, "relational", "orders", "slow", &doc))
+	assert.Error(t, client.Get(ctx, "relational", "orders", "broken", &doc))
+	require.NoError(t, client.Put(ctx, "relational", "orders", "o1", map[string]int{"total": 1}))
+	var rows []interface{}
+	require.NoError(t, client.Query(ctx, "FOR o IN orders RETURN o", &rows))
+
+	stats := client.Stats()
+	require.Len(t, stats, 3)
+	query, read, write := stats[0], stats[1], stats[2]
+	assert.Equal(t, OperationQuery, query.Operation)
+	assert.Equal(t, "", query.Collection)
+	assert.Equal(t, uint64(1), query.Count)
+	assert.Equal(t, OperationWrite, write.Operation)
+
+	assert.Equal(t, OperationRead, read.Operation)
+	assert.Equal(t, "relational/orders", read.Collection)
+	assert.Equal(t, uint64(5), read.Count)
+	assert.Equal(t, uint64(1), read.Errors)
+	assert.Equal(t, 10*time.Millisecond, read.Buckets[0].UpperBound, "bounds are sorted")
+	assert.Equal(t, uint64(4), read.Buckets[0].Count)
+	assert.Equal(t, uint64(5), read.Buckets[1].Count)
+	assert.Equal(t, 0.8, read.Within(10*time.Millisecond))
+	assert.Equal(t, 0.8, read.Within(20*time.Millisecond))
+	assert.Equal(t, 1.0, read.Within(time.Minute))
+	assert.GreaterOrEqual(t, read.Sum, 30*time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	client.PrometheusHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	metrics := string(body)
+	assert.True(t, strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4"))
+	assert.Contains(t, metrics, "# TYPE themisdb_client_request_duration_seconds histogram\n")
+	assert.Contains(t, metrics, `themisdb_client_request_duration_seconds_bucket{operation="read",collection="relational/orders",le="0.01"} 4`+"\n")
+	assert.Contains(t, metrics, `themisdb_client_request_duration_seconds_bucket{operation="read",collection="relational/orders",le="+Inf"} 5`+"\n")
+	assert.Contains(t, metrics, `themisdb_client_request_duration_seconds_count{operation="query",collection=""} 1`+"\n")
+	assert.Contains(t, metrics, `themisdb_client_request_errors_total{operation="read",collection="relational/orders"} 1`+"\n")
+}
+
+func TestOperationStats_Quantile(t *testing.T) {
+	stats := OperationStats{
+		Count: 100,
+		Buckets: []LatencyBucket{
+			{UpperBound: 10 * time.Millisecond, Count: 90},
+			{UpperBound: 20 * time.Millisecond, Count: 90},
+			{UpperBound: 50 * time.Millisecond, Count: 98},
+		},
+	}
+	assert.Equal(t, 5*time.Millisecond, stats.Quantile(0.45))
+	assert.Equal(t, 10*time.Millisecond, stats.Quantile(0.9))
+	assert.Equal(t, 35*time.Millisecond, stats.Quantile(0.94))
+	assert.Equal(t, 50*time.Millisecond, stats.Quantile(0.99), "beyond the last bound")
+	assert.Equal(t, time.Duration(0), OperationStats{}.Quantile(0.99))
+	assert.Equal(t, 1.0, OperationStats{}.Within(time.Millisecond))
+}
+
+func TestCollectionOf(t *testing.T) {
+	for path, want := range map[string]string{
+		"/api/relational/orders/o1":       "relational/orders",
+		"/api/relational/orders/_mget":    "relational/orders",
+		"/api/relational/orders?limit=10": "relational/orders",
+		"/api/query":                      "",
+		"/api/query/execute":              "",
+		"/admin/collections":              "",
+		"/api/relational":                 "",
+	} {
+		assert.Equal(t, want, collectionOf(&Request{Path: path}), path)
+	}
+}
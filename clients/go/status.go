@@ -0,0 +1,183 @@
+package themisdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusClientClosedRequest is the de facto HTTP status of cancelled requests
+const statusClientClosedRequest = 499
+
+// StatusError is returned for server responses with a status code >= 400.
+// It matches the canonical sentinels with errors.Is, e.g. ErrNotFound for 404.
+type StatusError struct {
+	// StatusCode is the HTTP status, gRPC codes are mapped onto it
+	StatusCode int
+	// Message is the response body
+	Message string
+}
+
+// Error implements error
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Message)
+}
+
+// Is matches the sentinel of the status code
+func (e *StatusError) Is(target error) bool {
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	case http.StatusUnauthorized:
+		return target == ErrUnauthenticated
+	case http.StatusForbidden:
+		return target == ErrPermissionDenied
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return target == ErrConflict
+	case http.StatusTooManyRequests:
+		return target == ErrRateLimited
+	case http.StatusServiceUnavailable:
+		return target == ErrUnavailable
+	}
+	return false
+}
+
+// ErrorFromHTTPStatus returns the error of a response with the given status and
+// body, or nil for statuses below 400
+func ErrorFromHTTPStatus(code int, message string) error {
+	if code < 400 {
+		return nil
+	}
+	return &StatusError{StatusCode: code, Message: message}
+}
+
+// ErrorFromGRPCStatus returns the error of a gRPC status, or nil for codes.OK
+func ErrorFromGRPCStatus(st *status.Status) error {
+	if st.Code() == codes.OK {
+		return nil
+	}
+	return &StatusError{StatusCode: httpStatusFromCode(st.Code()), Message: st.Message()}
+}
+
+// HTTPStatus maps an error returned by the client onto the HTTP status a proxying
+// service should answer with. nil maps to 200 and unknown errors to 500.
+func HTTPStatus(err error) int {
+	var statusErr *StatusError
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case errors.Is(err, ErrTransactionNodeLost):
+		// the transaction must be retried, whatever status its node answered with
+		return http.StatusServiceUnavailable
+	case errors.Is(err, ErrCommitIncomplete):
+		// some participants committed and others did not; retrying cannot repair that
+		return http.StatusInternalServerError
+	case errors.As(err, &statusErr):
+		return statusErr.StatusCode
+	case errors.Is(err, ErrInvalidInput), errors.Is(err, ErrInvalidEnumValue):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrAlreadyExists), errors.Is(err, ErrLeaseHeld), errors.Is(err, ErrLeaseLost), errors.Is(err, ErrSagaAborted),
+		errors.Is(err, ErrInsufficientFunds), errors.Is(err, ErrWrongExpectedVersion):
+		return http.StatusConflict
+	case errors.Is(err, ErrConflict), errors.Is(err, ErrTransactionNotActive), errors.Is(err, ErrTransactionExpired):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrUnknownPlugin):
+		return http.StatusNotFound
+	case errors.Is(err, ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrPermissionDenied), errors.Is(err, ErrReadOnly):
+		return http.StatusForbidden
+	case errors.Is(err, ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, ErrUnsupportedByTransport), errors.Is(err, ErrFeatureUnsupported):
+		return http.StatusNotImplemented
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return statusClientClosedRequest
+	case errors.Is(err, ErrUnavailable), errors.As(err, &netErr):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// GRPCCode maps an error returned by the client onto the canonical gRPC code.
+// nil maps to codes.OK and unknown errors to codes.Unknown.
+func GRPCCode(err error) codes.Code {
+	var statusErr *StatusError
+	switch {
+	case err == nil:
+		return codes.OK
+	case errors.Is(err, ErrTransactionNodeLost):
+		return codes.Unavailable
+	case errors.Is(err, ErrCommitIncomplete):
+		return codes.DataLoss
+	case errors.As(err, &statusErr):
+		return codeFromHTTPStatus(statusErr.StatusCode)
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, ErrAlreadyExists):
+		return codes.AlreadyExists
+	case errors.Is(err, ErrSagaAborted), errors.Is(err, ErrConflict):
+		return codes.Aborted
+	case errors.Is(err, ErrLeaseHeld), errors.Is(err, ErrLeaseLost), errors.Is(err, ErrTransactionNotActive),
+		errors.Is(err, ErrTransactionExpired), errors.Is(err, ErrInsufficientFunds), errors.Is(err, ErrWrongExpectedVersion),
+		errors.Is(err, ErrReadOnly):
+		return codes.FailedPrecondition
+	}
+	return codeFromHTTPStatus(HTTPStatus(err))
+}
+
+// codeFromHTTPStatus maps an HTTP status onto the equivalent gRPC code,
+// the inverse of httpStatusFromCode
+func codeFromHTTPStatus(code int) codes.Code {
+	switch code {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable, http.StatusBadGateway:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	case statusClientClosedRequest:
+		return codes.Canceled
+	}
+	if code < 400 {
+		return codes.OK
+	}
+	return codes.Unknown
+}
+
+// GRPCStatus converts an error returned by the client into a gRPC status, e.g. to
+// return it from a gRPC handler with GRPCStatus(err).Err()
+func GRPCStatus(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return status.New(GRPCCode(err), statusErr.Message)
+	}
+	return status.New(GRPCCode(err), err.Error())
+}
@@ -0,0 +1,78 @@
+package themisdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("entity not found"))
+	})
+
+	err := client.Get(context.Background(), "relational", "users", "1", nil)
+	assert.EqualError(t, err, "request failed with status 404: entity not found")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	assert.Equal(t, http.StatusNotFound, HTTPStatus(err))
+	assert.Equal(t, codes.NotFound, GRPCCode(err))
+	assert.Equal(t, "entity not found", GRPCStatus(err).Message())
+}
+
+func TestErrorMapping(t *testing.T) {
+	tests := []struct {
+		err        error
+		httpStatus int
+		code       codes.Code
+	}{
+		{nil, http.StatusOK, codes.OK},
+		{&ValidationError{Field: "uuid", Reason: "must not be empty"}, http.StatusBadRequest, codes.InvalidArgument},
+		{fmt.Errorf("create: %w", ErrAlreadyExists), http.StatusConflict, codes.AlreadyExists},
+		{ErrConflict, http.StatusPreconditionFailed, codes.Aborted},
+		{ErrLeaseHeld, http.StatusConflict, codes.FailedPrecondition},
+		{fmt.Errorf("%w: transaction tx-1", ErrTransactionExpired), http.StatusPreconditionFailed, codes.FailedPrecondition},
+		{ErrUnsupportedByTransport, http.StatusNotImplemented, codes.Unimplemented},
+		{fmt.Errorf("%w: vector_search", ErrFeatureUnsupported), http.StatusNotImplemented, codes.Unimplemented},
+		{fmt.Errorf("%w: snapshot clients cannot write", ErrReadOnly), http.StatusForbidden, codes.FailedPrecondition},
+		{&TransactionNodeError{TransactionID: "tx-1", Err: &StatusError{StatusCode: http.StatusMisdirectedRequest}}, http.StatusServiceUnavailable, codes.Unavailable},
+		{&CoordinatorError{ID: "tx-1", Decision: DecisionCommit, Participant: 1, Err: &StatusError{StatusCode: http.StatusServiceUnavailable}}, http.StatusInternalServerError, codes.DataLoss},
+		{context.DeadlineExceeded, http.StatusGatewayTimeout, codes.DeadlineExceeded},
+		{context.Canceled, 499, codes.Canceled},
+		{&StatusError{StatusCode: http.StatusPreconditionFailed}, http.StatusPreconditionFailed, codes.FailedPrecondition},
+		{&StatusError{StatusCode: http.StatusTooManyRequests}, http.StatusTooManyRequests, codes.ResourceExhausted},
+		{&StatusError{StatusCode: http.StatusServiceUnavailable}, http.StatusServiceUnavailable, codes.Unavailable},
+		{errors.New("boom"), http.StatusInternalServerError, codes.Unknown},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.httpStatus, HTTPStatus(tt.err), "%v", tt.err)
+		assert.Equal(t, tt.code, GRPCCode(tt.err), "%v", tt.err)
+	}
+}
+
+func TestErrorFromStatus(t *testing.T) {
+	assert.NoError(t, ErrorFromHTTPStatus(http.StatusOK, ""))
+	assert.ErrorIs(t, ErrorFromHTTPStatus(http.StatusForbidden, "denied"), ErrPermissionDenied)
+	assert.ErrorIs(t, ErrorFromHTTPStatus(http.StatusConflict, "stale"), ErrConflict)
+
+	assert.NoError(t, ErrorFromGRPCStatus(status.New(codes.OK, "")))
+	err := ErrorFromGRPCStatus(status.New(codes.Unauthenticated, "token expired"))
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+	assert.Equal(t, codes.Unauthenticated, GRPCCode(err))
+
+	// round trip through both representations
+	for _, code := range []codes.Code{codes.InvalidArgument, codes.NotFound, codes.PermissionDenied, codes.ResourceExhausted, codes.Unimplemented} {
+		assert.Equal(t, code, GRPCCode(ErrorFromGRPCStatus(status.New(code, ""))))
+	}
+}
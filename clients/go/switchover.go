@@ -0,0 +1,190 @@
+package themisdb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PromoteReplica makes a follower the leader of the cluster; the former leader
+// becomes a follower. The server rejects the promotion with ErrConflict if the
+// follower has not applied the leader's log.
+func (a *Admin) PromoteReplica(ctx context.Context, nodeID string) error {
+	if err := validateName("node", nodeID); err != nil {
+		return err
+	}
+	if err := a.client.request(ctx, "POST", joinPath("/admin/nodes", nodeID)+"/promote", nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to promote node %s: %w", nodeID, err)
+	}
+	return nil
+}
+
+// writeGate holds back the writes of a client and its derived clients during a
+// switchover
+type writeGate struct {
+	mu sync.Mutex
+	// resume is closed when writes resume, nil while they are not paused
+	resume chan struct{}
+}
+
+// pause holds back writes until release is called
+func (g *writeGate) pause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resume == nil {
+		g.resume = make(chan struct{})
+	}
+}
+
+// release lets the writes held back by pause continue
+func (g *writeGate) release() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resume != nil {
+		close(g.resume)
+		g.resume = nil
+	}
+}
+
+// wait blocks req while writes are paused, unless it is a read or an administrative
+// request, which the switchover itself sends
+func (g *writeGate) wait(ctx context.Context, req *Request) error {
+	g.mu.Lock()
+	resume := g.resume
+	g.mu.Unlock()
+	if resume == nil || operationOf(req) == OperationRead || strings.HasPrefix(req.Path, "/admin/") {
+		return nil
+	}
+	select {
+	case <-resume:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SwitchoverOptions configures SwitchPrimary
+type SwitchoverOptions struct {
+	// MaxLag is the replication lag of the new leader at which it is promoted
+	// (default: 0, it must have applied the whole log)
+	MaxLag time.Duration
+	// PollInterval is the interval of the replication status checks (default: 1s)
+	PollInterval time.Duration
+}
+
+// SwitchoverReport describes a completed switchover
+type SwitchoverReport struct {
+	OldLeader ClusterMember
+	NewLeader ClusterMember
+	// WritesPaused is how long writes through the client were held back
+	WritesPaused time.Duration
+}
+
+// SwitchPrimary runs a planned failover to the follower nodeID: it pauses writes sent
+// through the client and the clients derived from it, waits until the follower has
+// caught up with the leader, promotes it, and routes requests to it before writes
+// resume. Reads continue throughout. If the follower does not catch up before ctx is
+// done, or the promotion fails, the leader is left unchanged and writes resume
+// against it.
+//
+// Writes of other processes are not paused; the server rejects them on the former
+// leader once the promotion is done, and the follower may not catch up while they
+// continue at a high rate, so pause them first or set MaxLag.
+func (c *Client) SwitchPrimary(ctx context.Context, nodeID string, opts *SwitchoverOptions) (*SwitchoverReport, error) {
+	if err := validateName("node", nodeID); err != nil {
+		return nil, err
+	}
+	var o SwitchoverOptions
+	if opts != nil {
+		o = *opts
+	}
+	root := c
+	if c.root != nil {
+		root = c.root
+	}
+	admin := root.Admin()
+
+	members, err := admin.ClusterMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &SwitchoverReport{}
+	for _, m := range members {
+		switch {
+		case m.Role == RoleLeader:
+			report.OldLeader = m
+		case m.ID == nodeID:
+			report.NewLeader = m
+		}
+	}
+	switch {
+	case report.OldLeader.ID == nodeID:
+		return nil, fmt.Errorf("node %s is already the leader", nodeID)
+	case report.NewLeader.ID == "":
+		return nil, fmt.Errorf("node %s is not a member of the cluster", nodeID)
+	case report.NewLeader.Role != RoleFollower:
+		return nil, fmt.Errorf("node %s is a %s, not a follower", nodeID, report.NewLeader.Role)
+	case report.OldLeader.ID == "":
+		return nil, fmt.Errorf("cluster topology has no leader")
+	}
+
+	start := time.Now()
+	root.writes.pause()
+	defer func() {
+		root.writes.release()
+		report.WritesPaused = time.Since(start)
+	}()
+
+	if _, err := admin.WaitForCatchUp(ctx, nodeID, o.MaxLag, o.PollInterval); err != nil {
+		return nil, fmt.Errorf("node %s did not catch up: %w", nodeID, err)
+	}
+	if err := admin.PromoteReplica(ctx, nodeID); err != nil {
+		return nil, err
+	}
+
+	for i := range members {
+		switch members[i].ID {
+		case report.OldLeader.ID:
+			members[i].Role = RoleFollower
+		case nodeID:
+			members[i].Role = RoleLeader
+		}
+	}
+	report.NewLeader.Role, report.OldLeader.Role = RoleLeader, RoleFollower
+	root.repoint(members, report.OldLeader.Endpoint, report.NewLeader.Endpoint)
+	return report, nil
+}
+
+// repoint routes requests to the leader of members after a switchover from oldLeader
+// to newLeader. The former leader replaces the new one among configured replicas.
+func (c *Client) repoint(members []ClusterMember, oldLeader, newLeader string) {
+	if c.discovery != nil {
+		c.applyTopology(members)
+		if c.discovery.replicas {
+			return
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.discovery == nil {
+		endpoints := []string{newLeader}
+		for _, m := range members {
+			if m.Role == RoleFollower {
+				endpoints = append(endpoints, m.Endpoint)
+			}
+		}
+		c.endpoints = endpoints
+		c.activeIdx = 0
+	}
+	replicas := make([]string, len(c.replicas))
+	for i, replica := range c.replicas {
+		if strings.TrimSuffix(replica, "/") == strings.TrimSuffix(newLeader, "/") {
+			replica = oldLeader
+		}
+		replicas[i] = replica
+	}
+	c.replicas = replicas
+}
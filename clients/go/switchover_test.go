@@ -0,0 +1,140 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// switchoverCluster simulates a leader and two followers; n2 catches up after a few
+// status polls
+type switchoverCluster struct {
+	mu       sync.Mutex
+	servers  map[string]*httptest.Server
+	leader   string
+	polls    int
+	writes   []string
+	promoted chan struct{}
+}
+
+func newSwitchoverCluster(t *testing.T) *switchoverCluster {
+	cluster := &switchoverCluster{servers: map[string]*httptest.Server{}, leader: "n1", promoted: make(chan struct{})}
+	for _, id := range []string{"n1", "n2", "n3"} {
+		id := id
+		cluster.servers[id] = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cluster.serveHTTP(id, w, r)
+		}))
+		t.Cleanup(cluster.servers[id].Close)
+	}
+	return cluster
+}
+
+func (s *switchoverCluster) serveHTTP(node string, w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case r.URL.Path == "/cluster/members":
+		var members []ClusterMember
+		for _, id := range []string{"n1", "n2", "n3"} {
+			role := RoleFollower
+			if id == s.leader {
+				role = RoleLeader
+			}
+			members = append(members, ClusterMember{ID: id, Endpoint: s.servers[id].URL, Role: role})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"members": members})
+	case r.URL.Path == "/admin/nodes/n2/status":
+		s.polls++
+		applied := 7 + s.polls
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "n2", "applied_sequence": min(applied, 10), "leader_sequence": 10, "replication_lag_ms": 10 - min(applied, 10)})
+	case r.URL.Path == "/admin/nodes/n2/promote":
+		s.leader = "n2"
+		close(s.promoted)
+	case r.Method == "GET":
+		w.Write([]byte(`{}`))
+	case node != s.leader:
+		http.Error(w, "not the leader", http.StatusMisdirectedRequest)
+	default:
+		s.writes = append(s.writes, node)
+	}
+}
+
+func TestClient_SwitchPrimary(t *testing.T) {
+	cluster := newSwitchoverCluster(t)
+	n1, n2, n3 := cluster.servers["n1"].URL, cluster.servers["n2"].URL, cluster.servers["n3"].URL
+	client := NewClient(Config{Endpoints: []string{n1}, Replicas: []string{n2, n3}})
+	defer client.Close()
+	tenant := client.Namespace("tenant-a")
+	ctx := context.Background()
+
+	type result struct {
+		report *SwitchoverReport
+		err    error
+	}
+	switched := make(chan result, 1)
+	go func() {
+		report, err := tenant.SwitchPrimary(ctx, "n2", &SwitchoverOptions{PollInterval: 20 * time.Millisecond})
+		switched <- result{report, err}
+	}()
+	require.Eventually(t, func() bool {
+		cluster.mu.Lock()
+		defer cluster.mu.Unlock()
+		return cluster.polls > 0
+	}, time.Second, time.Millisecond)
+
+	var doc map[string]interface{}
+	require.NoError(t, tenant.Get(ctx, "relational", "orders", "o1", &doc), "reads continue")
+	written := make(chan error, 1)
+	go func() { written <- tenant.Put(ctx, "relational", "orders", "o1", map[string]int{"total": 1}) }()
+	select {
+	case err := <-written:
+		t.Fatalf("write was not paused: %v", err)
+	case <-cluster.promoted:
+	}
+
+	r := <-switched
+	require.NoError(t, r.err)
+	require.NoError(t, <-written)
+	assert.Equal(t, "n1", r.report.OldLeader.ID)
+	assert.Equal(t, RoleFollower, r.report.OldLeader.Role)
+	assert.Equal(t, "n2", r.report.NewLeader.ID)
+	assert.Equal(t, RoleLeader, r.report.NewLeader.Role)
+	assert.Positive(t, r.report.WritesPaused)
+	assert.Equal(t, []string{"n2"}, cluster.writes, "the paused write went to the new leader")
+	assert.Equal(t, []string{n2, n1, n3}, client.endpoints)
+	assert.Equal(t, []string{n1, n3}, client.replicas)
+
+	_, err := client.SwitchPrimary(ctx, "n2", nil)
+	assert.ErrorContains(t, err, "already the leader")
+	_, err = client.SwitchPrimary(ctx, "n9", nil)
+	assert.ErrorContains(t, err, "not a member")
+}
+
+func TestClient_SwitchPrimaryTimeout(t *testing.T) {
+	var promoted bool
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cluster/members":
+			w.Write([]byte(`{"members": [{"id": "n1", "endpoint": "http://n1", "role": "leader"}, {"id": "n2", "endpoint": "http://n2", "role": "follower"}]}`))
+		case "/admin/nodes/n2/status":
+			w.Write([]byte(`{"id": "n2", "applied_sequence": 5, "leader_sequence": 10, "replication_lag_ms": 2000}`))
+		case "/admin/nodes/n2/promote":
+			promoted = true
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := client.SwitchPrimary(ctx, "n2", &SwitchoverOptions{MaxLag: time.Second, PollInterval: 10 * time.Millisecond})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "node n2 did not catch up")
+	assert.False(t, promoted)
+	require.NoError(t, client.Put(context.Background(), "relational", "orders", "o1", map[string]int{"total": 1}), "writes resume")
+}
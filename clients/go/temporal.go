@@ -0,0 +1,332 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// temporalIndexPrefix prefixes the collection holding the current timeline of each entity
+const temporalIndexPrefix = "_temporal_"
+
+// openEnd is the end of a version valid until further notice
+const openEnd = math.MaxInt64
+
+// Temporal stores the history of entities along two time axes: valid time, when a
+// version is true in the business domain, and transaction time, when the database
+// learned it. Writes never change a stored version's interval or data; a version
+// overlapped by a later write is marked superseded and the parts of it that remain
+// valid are stored again, so every earlier state stays queryable. Times are stored
+// with millisecond precision.
+type Temporal struct {
+	client     *Client
+	model      string
+	collection string
+}
+
+// TemporalVersion is a version of an entity, valid from ValidFrom until ValidTo
+type TemporalVersion struct {
+	// Key is the UUID of the version document, "<id>/<sequence>"
+	Key string
+	ID  string
+	// ValidFrom is inclusive
+	ValidFrom time.Time
+	// ValidTo is exclusive, zero for a version valid until further notice
+	ValidTo time.Time
+	// RecordedAt is when the version was written
+	RecordedAt time.Time
+	// SupersededAt is when a later write replaced the version, zero if it is current
+	SupersededAt time.Time
+	Data         json.RawMessage
+}
+
+// Decode unmarshals the data of the version into v
+func (v TemporalVersion) Decode(result interface{}) error {
+	return json.Unmarshal(v.Data, result)
+}
+
+// temporalRecord is the stored document of a version, with times in Unix milliseconds
+type temporalRecord struct {
+	ID           string          `json:"id"`
+	ValidFrom    int64           `json:"valid_from"`
+	ValidTo      *int64          `json:"valid_to"`
+	RecordedAt   int64           `json:"recorded_at"`
+	SupersededAt *int64          `json:"superseded_at"`
+	Data         json.RawMessage `json:"data"`
+}
+
+// version converts a stored record into a TemporalVersion
+func (r temporalRecord) version(key string) TemporalVersion {
+	v := TemporalVersion{Key: key, ID: r.ID, ValidFrom: time.UnixMilli(r.ValidFrom), RecordedAt: time.UnixMilli(r.RecordedAt), Data: r.Data}
+	if r.ValidTo != nil {
+		v.ValidTo = time.UnixMilli(*r.ValidTo)
+	}
+	if r.SupersededAt != nil {
+		v.SupersededAt = time.UnixMilli(*r.SupersededAt)
+	}
+	return v
+}
+
+// temporalIndex is the current timeline of an entity, ordered by From
+type temporalIndex struct {
+	// Seq numbers the versions of the entity
+	Seq     int64          `json:"seq"`
+	Current []temporalSpan `json:"current"`
+}
+
+// temporalSpan is a current version of an entity; To is nil if it is valid until
+// further notice
+type temporalSpan struct {
+	Key  string `json:"key"`
+	From int64  `json:"from"`
+	To   *int64 `json:"to"`
+}
+
+// end returns the end of the span, openEnd if it is valid until further notice
+func (s temporalSpan) end() int64 {
+	if s.To == nil {
+		return openEnd
+	}
+	return *s.To
+}
+
+// Temporal returns the temporal view of a collection. Versions are stored in the
+// collection itself, keyed "<id>/<sequence>"; the current timeline of each entity is
+// kept in "_temporal_<collection>" of the same model.
+func (c *Client) Temporal(model, collection string) *Temporal {
+	return &Temporal{client: c, model: model, collection: collection}
+}
+
+// Put records data as the state of entity id from validFrom until the start of the
+// next later version, or until further notice if there is none, as for a slowly
+// changing dimension of type 2. Versions valid at validFrom are cut off there.
+func (t *Temporal) Put(ctx context.Context, id string, data interface{}, validFrom time.Time) error {
+	return t.write(ctx, id, data, validFrom.UnixMilli(), 0, true)
+}
+
+// PutInterval records data as the state of entity id from validFrom until validTo,
+// or until further notice if validTo is zero, e.g. to correct the past. Versions
+// overlapping the interval are cut off or split around it.
+func (t *Temporal) PutInterval(ctx context.Context, id string, data interface{}, validFrom, validTo time.Time) error {
+	to := int64(openEnd)
+	if !validTo.IsZero() {
+		to = validTo.UnixMilli()
+	}
+	return t.write(ctx, id, data, validFrom.UnixMilli(), to, false)
+}
+
+// End records that entity id ceases to exist at validTo: versions valid afterwards are
+// cut off there
+func (t *Temporal) End(ctx context.Context, id string, validTo time.Time) error {
+	return t.write(ctx, id, nil, validTo.UnixMilli(), openEnd, false)
+}
+
+// AsOf decodes the data of entity id valid at time at into result and returns the
+// version. It fails with ErrNotFound if no version is valid at that time.
+func (t *Temporal) AsOf(ctx context.Context, id string, at time.Time, result interface{}) (*TemporalVersion, error) {
+	if err := t.validate(id); err != nil {
+		return nil, err
+	}
+	var idx temporalIndex
+	err := t.client.Get(ctx, t.model, temporalIndexPrefix+t.collection, id, &idx)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("failed to read timeline of %s: %w", id, err)
+	}
+	ms := at.UnixMilli()
+	for _, span := range idx.Current {
+		if span.From <= ms && ms < span.end() {
+			var r temporalRecord
+			if err := t.client.Get(ctx, t.model, t.collection, span.Key, &r); err != nil {
+				return nil, fmt.Errorf("failed to read version %s: %w", span.Key, err)
+			}
+			return t.decode(r.version(span.Key), result)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s has no version valid at %s", ErrNotFound, id, at.Format(time.RFC3339))
+}
+
+// AsOfKnown is like AsOf, but answers as the database would have at time knownAt,
+// ignoring versions recorded later and restoring those superseded later
+func (t *Temporal) AsOfKnown(ctx context.Context, id string, at, knownAt time.Time, result interface{}) (*TemporalVersion, error) {
+	history, err := t.History(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range history {
+		known := !v.RecordedAt.After(knownAt) && (v.SupersededAt.IsZero() || v.SupersededAt.After(knownAt))
+		valid := !v.ValidFrom.After(at) && (v.ValidTo.IsZero() || v.ValidTo.After(at))
+		if known && valid {
+			return t.decode(v, result)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s had no version valid at %s as of %s", ErrNotFound, id, at.Format(time.RFC3339), knownAt.Format(time.RFC3339))
+}
+
+// History returns every version of entity id ever recorded, current and superseded,
+// in the order they were recorded
+func (t *Temporal) History(ctx context.Context, id string) ([]TemporalVersion, error) {
+	if err := t.validate(id); err != nil {
+		return nil, err
+	}
+	var versions []TemporalVersion
+	it := t.client.Scan(ctx, t.model, t.collection, ScanOptions{Prefix: id + "/"})
+	for it.Next() {
+		var r temporalRecord
+		if err := it.Decode(&r); err != nil {
+			return nil, fmt.Errorf("failed to decode version %s: %w", it.UUID(), err)
+		}
+		versions = append(versions, r.version(it.UUID()))
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history of %s: %w", id, err)
+	}
+	return versions, nil
+}
+
+// QueryAsOf returns the current version of every entity of the collection valid at
+// time at, e.g. to report on the state of a dimension at the end of a quarter
+func (t *Temporal) QueryAsOf(ctx context.Context, at time.Time) ([]TemporalVersion, error) {
+	aql := "FOR v IN " + quoteName(t.collection) +
+		" FILTER v.superseded_at == null AND v.valid_from <= @at AND (v.valid_to == null OR v.valid_to > @at)" +
+		" SORT v.id RETURN {key: v._key, version: v}"
+	var rows []struct {
+		Key     string         `json:"key"`
+		Version temporalRecord `json:"version"`
+	}
+	opts := &QueryOptions{BindVars: map[string]interface{}{"at": at.UnixMilli()}}
+	if err := t.client.QueryWithOptions(ctx, aql, opts, &rows); err != nil {
+		return nil, fmt.Errorf("failed to query %s as of %s: %w", t.collection, at.Format(time.RFC3339), err)
+	}
+	versions := make([]TemporalVersion, len(rows))
+	for i, row := range rows {
+		versions[i] = row.Version.version(row.Key)
+	}
+	return versions, nil
+}
+
+// write records data, or a gap if data is nil, as valid from from until to in one
+// transaction; if untilNext is set, to is the start of the next current version
+func (t *Temporal) write(ctx context.Context, id string, data interface{}, from, to int64, untilNext bool) error {
+	if err := t.validate(id); err != nil {
+		return err
+	}
+	var raw json.RawMessage
+	if data != nil {
+		if err := t.client.validateDocument(t.model, t.collection, data); err != nil {
+			return err
+		}
+		var err error
+		if raw, err = json.Marshal(data); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", id, err)
+		}
+	}
+
+	err := t.client.RunTransaction(ctx, func(ctx context.Context, tx *Transaction) error {
+		var idx temporalIndex
+		if err := tx.Get(ctx, t.model, temporalIndexPrefix+t.collection, id, &idx); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		to := to
+		if untilNext {
+			to = openEnd
+			for _, span := range idx.Current {
+				if span.From > from && span.From < to {
+					to = span.From
+				}
+			}
+		}
+		if from >= to {
+			return &ValidationError{Field: "valid time", Value: id, Reason: "interval must end after it starts"}
+		}
+
+		now := time.Now().UnixMilli()
+		var current []temporalSpan
+		for _, span := range idx.Current {
+			if span.end() <= from || span.From >= to {
+				current = append(current, span)
+				continue
+			}
+			// supersede the overlapped version and store the parts outside [from, to) again
+			var old temporalRecord
+			if err := tx.Get(ctx, t.model, t.collection, span.Key, &old); err != nil {
+				return err
+			}
+			old.SupersededAt = &now
+			if err := tx.Put(ctx, t.model, t.collection, span.Key, old); err != nil {
+				return err
+			}
+			if span.From < from {
+				kept, err := t.insert(ctx, tx, &idx, id, span.From, from, old.Data, now)
+				if err != nil {
+					return err
+				}
+				current = append(current, kept)
+			}
+			if span.end() > to {
+				kept, err := t.insert(ctx, tx, &idx, id, to, span.end(), old.Data, now)
+				if err != nil {
+					return err
+				}
+				current = append(current, kept)
+			}
+		}
+		if raw != nil {
+			span, err := t.insert(ctx, tx, &idx, id, from, to, raw, now)
+			if err != nil {
+				return err
+			}
+			current = append(current, span)
+		}
+		sort.Slice(current, func(i, j int) bool { return current[i].From < current[j].From })
+		idx.Current = current
+		return tx.Put(ctx, t.model, temporalIndexPrefix+t.collection, id, idx)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write %s/%s %s: %w", t.model, t.collection, id, err)
+	}
+	return nil
+}
+
+// insert stores a new version valid from from until to and returns its span
+func (t *Temporal) insert(ctx context.Context, tx *Transaction, idx *temporalIndex, id string, from, to int64, data json.RawMessage, now int64) (temporalSpan, error) {
+	idx.Seq++
+	span := temporalSpan{Key: fmt.Sprintf("%s/%010d", id, idx.Seq), From: from}
+	if to != openEnd {
+		span.To = &to
+	}
+	r := temporalRecord{ID: id, ValidFrom: from, ValidTo: span.To, RecordedAt: now, Data: data}
+	if err := tx.Create(ctx, t.model, t.collection, span.Key, r); err != nil {
+		if errors.Is(err, ErrAlreadyExists) {
+			return span, fmt.Errorf("%w: version %s exists", ErrConflict, span.Key)
+		}
+		return span, err
+	}
+	return span, nil
+}
+
+// decode unmarshals the data of v into result, if it is not nil
+func (t *Temporal) decode(v TemporalVersion, result interface{}) (*TemporalVersion, error) {
+	if result != nil {
+		if err := v.Decode(result); err != nil {
+			return nil, fmt.Errorf("failed to decode version %s: %w", v.Key, err)
+		}
+	}
+	return &v, nil
+}
+
+// validate checks the collection and the entity ID, which must not contain '/' as it
+// separates the ID from the sequence in version keys
+func (t *Temporal) validate(id string) error {
+	if err := validateEntity(t.model, t.collection, id); err != nil {
+		return err
+	}
+	if strings.Contains(id, "/") {
+		return &ValidationError{Field: "id", Value: id, Reason: "must not contain '/'"}
+	}
+	return nil
+}
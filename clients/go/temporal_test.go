@@ -0,0 +1,135 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type price struct {
+	Amount int `json:"amount"`
+}
+
+// day returns midnight UTC of a day in January 2024
+func day(d int) time.Time {
+	return time.Date(2024, time.January, d, 0, 0, 0, 0, time.UTC)
+}
+
+// priceAsOf returns the amount valid at at, or -1 if there is none
+func priceAsOf(t *testing.T, tv *Temporal, at time.Time) int {
+	var p price
+	_, err := tv.AsOf(context.Background(), "sku-1", at, &p)
+	if err != nil {
+		require.ErrorIs(t, err, ErrNotFound)
+		return -1
+	}
+	return p.Amount
+}
+
+func TestTemporal_Put(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	tv := client.Temporal("relational", "prices")
+	ctx := context.Background()
+
+	require.NoError(t, tv.Put(ctx, "sku-1", price{100}, day(1)))
+	require.NoError(t, tv.Put(ctx, "sku-1", price{120}, day(10)))
+	// a late arrival is valid until the next known version
+	require.NoError(t, tv.Put(ctx, "sku-1", price{110}, day(5)))
+
+	assert.Equal(t, -1, priceAsOf(t, tv, day(1).Add(-time.Millisecond)))
+	assert.Equal(t, 100, priceAsOf(t, tv, day(1)))
+	assert.Equal(t, 100, priceAsOf(t, tv, day(4)))
+	assert.Equal(t, 110, priceAsOf(t, tv, day(5)))
+	assert.Equal(t, 120, priceAsOf(t, tv, day(10)))
+	assert.Equal(t, 120, priceAsOf(t, tv, day(31)))
+
+	v, err := tv.AsOf(ctx, "sku-1", day(6), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "sku-1", v.ID)
+	assert.True(t, v.ValidFrom.Equal(day(5)))
+	assert.True(t, v.ValidTo.Equal(day(10)))
+	assert.True(t, v.SupersededAt.IsZero())
+}
+
+func TestTemporal_PutIntervalAndEnd(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	tv := client.Temporal("relational", "prices")
+	ctx := context.Background()
+
+	require.NoError(t, tv.Put(ctx, "sku-1", price{100}, day(1)))
+	require.NoError(t, tv.PutInterval(ctx, "sku-1", price{80}, day(5), day(8)))
+	assert.Equal(t, 100, priceAsOf(t, tv, day(4)))
+	assert.Equal(t, 80, priceAsOf(t, tv, day(5)))
+	assert.Equal(t, 100, priceAsOf(t, tv, day(8)), "the remainder of the split version stays valid")
+
+	require.NoError(t, tv.End(ctx, "sku-1", day(20)))
+	assert.Equal(t, 100, priceAsOf(t, tv, day(19)))
+	assert.Equal(t, -1, priceAsOf(t, tv, day(20)))
+
+	history, err := tv.History(ctx, "sku-1")
+	require.NoError(t, err)
+	require.Len(t, history, 5)
+	current := 0
+	for _, v := range history {
+		if v.SupersededAt.IsZero() {
+			current++
+		}
+	}
+	assert.Equal(t, 3, current, "[1, 5) 100, [5, 8) 80, [8, 20) 100")
+
+	err = tv.PutInterval(ctx, "sku-1", price{1}, day(8), day(8))
+	assert.ErrorIs(t, err, ErrInvalidInput)
+	err = tv.Put(ctx, "a/b", price{1}, day(1))
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
+
+func TestTemporal_AsOfKnown(t *testing.T) {
+	client, _ := newMemoryClient(t)
+	tv := client.Temporal("relational", "prices")
+	ctx := context.Background()
+
+	require.NoError(t, tv.Put(ctx, "sku-1", price{100}, day(1)))
+	time.Sleep(5 * time.Millisecond)
+	beforeCorrection := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	require.NoError(t, tv.PutInterval(ctx, "sku-1", price{90}, day(1), day(3)))
+
+	var p price
+	_, err := tv.AsOfKnown(ctx, "sku-1", day(2), beforeCorrection, &p)
+	require.NoError(t, err)
+	assert.Equal(t, 100, p.Amount, "the correction was not known yet")
+	_, err = tv.AsOfKnown(ctx, "sku-1", day(2), time.Now(), &p)
+	require.NoError(t, err)
+	assert.Equal(t, 90, p.Amount)
+
+	_, err = tv.AsOfKnown(ctx, "sku-1", day(2), day(1), &p)
+	assert.ErrorIs(t, err, ErrNotFound, "nothing was recorded in 2024-01")
+}
+
+func TestTemporal_QueryAsOf(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query    string                 `json:"query"`
+			BindVars map[string]interface{} `json:"bind_vars"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Contains(t, body.Query, "FOR v IN `prices` FILTER v.superseded_at == null AND v.valid_from <= @at")
+		assert.Equal(t, float64(day(5).UnixMilli()), body.BindVars["at"])
+		w.Write([]byte(`{"data":[{"key":"sku-1/0000000002","version":{"id":"sku-1","valid_from":1704067200000,"valid_to":null,"recorded_at":1704067200000,"superseded_at":null,"data":{"amount":100}}}]}`))
+	})
+
+	versions, err := client.Temporal("relational", "prices").QueryAsOf(context.Background(), day(5))
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.Equal(t, "sku-1/0000000002", versions[0].Key)
+	assert.True(t, versions[0].ValidFrom.Equal(day(1)))
+	assert.True(t, versions[0].ValidTo.IsZero())
+	var p price
+	require.NoError(t, versions[0].Decode(&p))
+	assert.Equal(t, 100, p.Amount)
+}
@@ -0,0 +1,776 @@
+package themistest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The built-in evaluator supports queries of the form
+//
+//	FOR v IN collection
+//	  [FILTER expr]...
+//	  [SORT v.field [ASC|DESC], ...]
+//	  [LIMIT [offset,] count]
+//	  RETURN v | v.field | {name: v.field, ...}
+//
+// where expr combines comparisons (==, !=, <, <=, >, >=, IN, NOT IN, LIKE) of
+// attribute paths, literals, and bind parameters with AND, OR, NOT, and parentheses.
+// Names may be quoted in backticks. v._key is the UUID of the document; it is not part
+// of the returned documents.
+// Other queries can be stubbed with Fake.HandleQuery.
+
+// token is a lexical token of a query
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenBind
+	tokenOperator
+	// tokenName is a name quoted in backticks, which is never a keyword
+	tokenName
+)
+
+// lex splits a query into tokens
+func lex(input string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(input); {
+		c := rune(input[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '\'' || c == '"':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(input) && rune(input[j]) != c; j++ {
+				if input[j] == '\\' && j+1 < len(input) {
+					j++
+				}
+				b.WriteByte(input[j])
+			}
+			if j >= len(input) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			tokens = append(tokens, token{tokenString, b.String()})
+			i = j + 1
+		case c == '`':
+			j := strings.IndexByte(input[i+1:], '`')
+			if j < 0 {
+				return nil, fmt.Errorf("unterminated name at offset %d", i)
+			}
+			tokens = append(tokens, token{tokenName, input[i+1 : i+1+j]})
+			i += j + 2
+		case c == '@':
+			j := i + 1
+			for j < len(input) && isIdentChar(rune(input[j])) {
+				j++
+			}
+			tokens = append(tokens, token{tokenBind, input[i+1 : j]})
+			i = j
+		case unicode.IsDigit(c) || (c == '-' && i+1 < len(input) && unicode.IsDigit(rune(input[i+1])) && expectsOperand(tokens)):
+			j := i + 1
+			for j < len(input) && (unicode.IsDigit(rune(input[j])) || input[j] == '.' || input[j] == 'e' || input[j] == 'E') {
+				j++
+			}
+			tokens = append(tokens, token{tokenNumber, input[i:j]})
+			i = j
+		case isIdentChar(c):
+			j := i
+			for j < len(input) && isIdentChar(rune(input[j])) {
+				j++
+			}
+			tokens = append(tokens, token{tokenIdent, input[i:j]})
+			i = j
+		default:
+			op := string(c)
+			if i+1 < len(input) {
+				switch two := input[i : i+2]; two {
+				case "==", "!=", "<=", ">=", "&&", "||":
+					op = two
+				}
+			}
+			if !strings.Contains("==!=<=>=&&||<>!.,()[]{}:", op) {
+				return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+			}
+			tokens = append(tokens, token{tokenOperator, op})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF}), nil
+}
+
+func isIdentChar(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// expectsOperand reports whether a '-' after tokens starts a negative number
+func expectsOperand(tokens []token) bool {
+	if len(tokens) == 0 {
+		return true
+	}
+	last := tokens[len(tokens)-1]
+	return last.kind == tokenOperator && last.value != ")" && last.value != "]" && last.value != "}" ||
+		last.kind == tokenIdent && isKeyword(last.value)
+}
+
+func isKeyword(s string) bool {
+	switch strings.ToUpper(s) {
+	case "FOR", "IN", "FILTER", "SORT", "LIMIT", "RETURN", "AND", "OR", "NOT", "LIKE", "ASC", "DESC":
+		return true
+	}
+	return false
+}
+
+// expr is an evaluable expression
+type expr interface {
+	eval(doc interface{}, bindVars map[string]interface{}) (interface{}, error)
+}
+
+// pathExpr reads an attribute path of the loop variable
+type pathExpr []string
+
+func (p pathExpr) eval(doc interface{}, _ map[string]interface{}) (interface{}, error) {
+	if k, ok := doc.(keyedDoc); ok {
+		if len(p) == 1 && p[0] == "_key" {
+			return k.key, nil
+		}
+		doc = k.doc
+	}
+	for _, field := range p {
+		m, ok := doc.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		doc = m[field]
+	}
+	return doc, nil
+}
+
+// keyedDoc is a stored document together with its UUID, which queries read as the
+// system attribute _key
+type keyedDoc struct {
+	key string
+	doc interface{}
+}
+
+type literalExpr struct{ value interface{} }
+
+func (l literalExpr) eval(interface{}, map[string]interface{}) (interface{}, error) {
+	return l.value, nil
+}
+
+type bindExpr string
+
+func (b bindExpr) eval(_ interface{}, bindVars map[string]interface{}) (interface{}, error) {
+	value, ok := bindVars[string(b)]
+	if !ok {
+		return nil, fmt.Errorf("bind parameter @%s not set", string(b))
+	}
+	return value, nil
+}
+
+type arrayExpr []expr
+
+func (a arrayExpr) eval(doc interface{}, bindVars map[string]interface{}) (interface{}, error) {
+	out := make([]interface{}, len(a))
+	for i, e := range a {
+		value, err := e.eval(doc, bindVars)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = value
+	}
+	return out, nil
+}
+
+type objectExpr struct {
+	keys   []string
+	values []expr
+}
+
+func (o objectExpr) eval(doc interface{}, bindVars map[string]interface{}) (interface{}, error) {
+	out := make(map[string]interface{}, len(o.keys))
+	for i, key := range o.keys {
+		value, err := o.values[i].eval(doc, bindVars)
+		if err != nil {
+			return nil, err
+		}
+		out[key] = value
+	}
+	return out, nil
+}
+
+type notExpr struct{ operand expr }
+
+func (n notExpr) eval(doc interface{}, bindVars map[string]interface{}) (interface{}, error) {
+	value, err := n.operand.eval(doc, bindVars)
+	if err != nil {
+		return nil, err
+	}
+	return !truthy(value), nil
+}
+
+type binaryExpr struct {
+	op          string
+	left, right expr
+}
+
+func (b binaryExpr) eval(doc interface{}, bindVars map[string]interface{}) (interface{}, error) {
+	left, err := b.left.eval(doc, bindVars)
+	if err != nil {
+		return nil, err
+	}
+	switch b.op {
+	case "AND":
+		if !truthy(left) {
+			return false, nil
+		}
+	case "OR":
+		if truthy(left) {
+			return true, nil
+		}
+	}
+	right, err := b.right.eval(doc, bindVars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch b.op {
+	case "AND", "OR":
+		return truthy(right), nil
+	case "==":
+		return compare(left, right) == 0, nil
+	case "!=":
+		return compare(left, right) != 0, nil
+	case "<":
+		return compare(left, right) < 0, nil
+	case "<=":
+		return compare(left, right) <= 0, nil
+	case ">":
+		return compare(left, right) > 0, nil
+	case ">=":
+		return compare(left, right) >= 0, nil
+	case "IN", "NOT IN":
+		list, ok := right.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("right operand of %s must be an array", b.op)
+		}
+		found := false
+		for _, item := range list {
+			if compare(left, item) == 0 {
+				found = true
+				break
+			}
+		}
+		return found == (b.op == "IN"), nil
+	case "LIKE":
+		s, ok1 := left.(string)
+		pattern, ok2 := right.(string)
+		return ok1 && ok2 && like(s, pattern), nil
+	}
+	return nil, fmt.Errorf("unsupported operator %s", b.op)
+}
+
+// sortKey is an attribute of a SORT clause
+type sortKey struct {
+	value expr
+	desc  bool
+}
+
+// parsedQuery is a query supported by the built-in evaluator
+type parsedQuery struct {
+	variable   string
+	collection string
+	filters    []expr
+	sort       []sortKey
+	offset     expr
+	limit      expr
+	result     expr
+}
+
+// parser is a recursive descent parser over tokens
+type parser struct {
+	tokens   []token
+	pos      int
+	variable string
+}
+
+// parseQuery parses a query supported by the built-in evaluator
+func parseQuery(aql string) (*parsedQuery, error) {
+	tokens, err := lex(aql)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	q := &parsedQuery{}
+
+	if err := p.expectKeyword("FOR"); err != nil {
+		return nil, err
+	}
+	if q.variable, err = p.ident(); err != nil {
+		return nil, err
+	}
+	p.variable = q.variable
+	if err := p.expectKeyword("IN"); err != nil {
+		return nil, err
+	}
+	if q.collection, err = p.ident(); err != nil {
+		return nil, err
+	}
+
+	for {
+		switch {
+		case p.keyword("FILTER"):
+			filter, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			q.filters = append(q.filters, filter)
+		case p.keyword("SORT"):
+			for {
+				value, err := p.parseOperand()
+				if err != nil {
+					return nil, err
+				}
+				key := sortKey{value: value}
+				if p.keyword("DESC") {
+					key.desc = true
+				} else {
+					p.keyword("ASC")
+				}
+				q.sort = append(q.sort, key)
+				if !p.operator(",") {
+					break
+				}
+			}
+		case p.keyword("LIMIT"):
+			count, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			if p.operator(",") {
+				q.offset = count
+				if count, err = p.parseOperand(); err != nil {
+					return nil, err
+				}
+			}
+			q.limit = count
+		case p.keyword("RETURN"):
+			if q.result, err = p.parseOperand(); err != nil {
+				return nil, err
+			}
+			if p.peek().kind != tokenEOF {
+				return nil, fmt.Errorf("unsupported query: unexpected %q after RETURN", p.peek().value)
+			}
+			return q, nil
+		default:
+			return nil, fmt.Errorf("unsupported query: unexpected %q", p.peek().value)
+		}
+	}
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// keyword consumes the next token if it is the given keyword
+func (p *parser) keyword(kw string) bool {
+	if t := p.peek(); t.kind == tokenIdent && strings.EqualFold(t.value, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// operator consumes the next token if it is the given operator
+func (p *parser) operator(op string) bool {
+	if t := p.peek(); t.kind == tokenOperator && t.value == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(kw string) error {
+	if !p.keyword(kw) {
+		return fmt.Errorf("unsupported query: expected %s, got %q", kw, p.peek().value)
+	}
+	return nil
+}
+
+func (p *parser) ident() (string, error) {
+	t := p.next()
+	if t.kind == tokenName {
+		return t.value, nil
+	}
+	if t.kind != tokenIdent || isKeyword(t.value) {
+		return "", fmt.Errorf("unsupported query: expected a name, got %q", t.value)
+	}
+	return t.value, nil
+}
+
+func (p *parser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("OR") || p.operator("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{op: "OR", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("AND") || p.operator("&&") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{op: "AND", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (expr, error) {
+	if p.keyword("NOT") || p.operator("!") {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (expr, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	var op string
+	switch t := p.peek(); {
+	case t.kind == tokenOperator && strings.Contains(" == != < <= > >= ", " "+t.value+" "):
+		op = t.value
+		p.pos++
+	case p.keyword("IN"):
+		op = "IN"
+	case p.keyword("LIKE"):
+		op = "LIKE"
+	case p.keyword("NOT"):
+		if !p.keyword("IN") {
+			return nil, fmt.Errorf("unsupported query: expected IN after NOT, got %q", p.peek().value)
+		}
+		op = "NOT IN"
+	default:
+		return left, nil
+	}
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return binaryExpr{op: op, left: left, right: right}, nil
+}
+
+func (p *parser) parseOperand() (expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return literalExpr{t.value}, nil
+	case tokenNumber:
+		n, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", t.value)
+		}
+		return literalExpr{n}, nil
+	case tokenBind:
+		return bindExpr(t.value), nil
+	case tokenIdent:
+		switch strings.ToLower(t.value) {
+		case "true":
+			return literalExpr{true}, nil
+		case "false":
+			return literalExpr{false}, nil
+		case "null":
+			return literalExpr{nil}, nil
+		}
+		if t.value != p.variable {
+			return nil, fmt.Errorf("unsupported query: unknown variable %q", t.value)
+		}
+		var path pathExpr
+		for {
+			if p.operator(".") {
+				field, err := p.ident()
+				if err != nil {
+					return nil, err
+				}
+				path = append(path, field)
+			} else if p.operator("[") {
+				field := p.next()
+				if field.kind != tokenString || !p.operator("]") {
+					return nil, fmt.Errorf("unsupported query: expected a quoted attribute name in []")
+				}
+				path = append(path, field.value)
+			} else {
+				return path, nil
+			}
+		}
+	case tokenOperator:
+		switch t.value {
+		case "(":
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if !p.operator(")") {
+				return nil, fmt.Errorf("unsupported query: expected )")
+			}
+			return inner, nil
+		case "[":
+			var items arrayExpr
+			for !p.operator("]") {
+				item, err := p.parseOperand()
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+				if !p.operator(",") && p.peek().value != "]" {
+					return nil, fmt.Errorf("unsupported query: expected , or ]")
+				}
+			}
+			return items, nil
+		case "{":
+			var obj objectExpr
+			for !p.operator("}") {
+				key := p.next()
+				if key.kind != tokenIdent && key.kind != tokenString {
+					return nil, fmt.Errorf("unsupported query: expected an attribute name, got %q", key.value)
+				}
+				if !p.operator(":") {
+					return nil, fmt.Errorf("unsupported query: expected :")
+				}
+				value, err := p.parseOperand()
+				if err != nil {
+					return nil, err
+				}
+				obj.keys = append(obj.keys, key.value)
+				obj.values = append(obj.values, value)
+				if !p.operator(",") && p.peek().value != "}" {
+					return nil, fmt.Errorf("unsupported query: expected , or }")
+				}
+			}
+			return obj, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported query: unexpected %q", t.value)
+}
+
+// run evaluates the query over the documents of its collection
+func (q *parsedQuery) run(docs []interface{}, bindVars map[string]interface{}) ([]interface{}, error) {
+	var matched []interface{}
+	for _, doc := range docs {
+		keep := true
+		for _, filter := range q.filters {
+			value, err := filter.eval(doc, bindVars)
+			if err != nil {
+				return nil, err
+			}
+			if !truthy(value) {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			matched = append(matched, doc)
+		}
+	}
+
+	if len(q.sort) > 0 {
+		var sortErr error
+		sort.SliceStable(matched, func(i, j int) bool {
+			for _, key := range q.sort {
+				a, err1 := key.value.eval(matched[i], bindVars)
+				b, err2 := key.value.eval(matched[j], bindVars)
+				if err1 != nil || err2 != nil {
+					sortErr = fmt.Errorf("failed to evaluate sort key")
+					return false
+				}
+				if c := compare(a, b); c != 0 {
+					return (c < 0) != key.desc
+				}
+			}
+			return false
+		})
+		if sortErr != nil {
+			return nil, sortErr
+		}
+	}
+
+	if q.limit != nil {
+		offset, err := intValue(q.offset, bindVars)
+		if err != nil {
+			return nil, err
+		}
+		count, err := intValue(q.limit, bindVars)
+		if err != nil {
+			return nil, err
+		}
+		if offset > len(matched) {
+			offset = len(matched)
+		}
+		matched = matched[offset:]
+		if count < len(matched) {
+			matched = matched[:count]
+		}
+	}
+
+	results := make([]interface{}, 0, len(matched))
+	for _, doc := range matched {
+		value, err := q.result.eval(doc, bindVars)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, value)
+	}
+	return results, nil
+}
+
+// intValue evaluates a LIMIT operand, nil evaluates to 0
+func intValue(e expr, bindVars map[string]interface{}) (int, error) {
+	if e == nil {
+		return 0, nil
+	}
+	value, err := e.eval(nil, bindVars)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := value.(float64)
+	if !ok || n < 0 {
+		return 0, fmt.Errorf("LIMIT requires a non-negative number")
+	}
+	return int(n), nil
+}
+
+// truthy converts a value to a boolean like AQL
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	}
+	return true
+}
+
+// typeRank orders values of different types like AQL: null < bool < number < string < array < object
+func typeRank(v interface{}) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 1
+	case float64:
+		return 2
+	case string:
+		return 3
+	case []interface{}:
+		return 4
+	}
+	return 5
+}
+
+// compare orders two decoded JSON values
+func compare(a, b interface{}) int {
+	if ra, rb := typeRank(a), typeRank(b); ra != rb {
+		return ra - rb
+	}
+	switch a := a.(type) {
+	case bool:
+		switch {
+		case a == b.(bool):
+			return 0
+		case !a:
+			return -1
+		}
+		return 1
+	case float64:
+		switch b := b.(float64); {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(a, b.(string))
+	case []interface{}:
+		b := b.([]interface{})
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if c := compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+		return len(a) - len(b)
+	case nil:
+		return 0
+	}
+	if reflect.DeepEqual(a, b) {
+		return 0
+	}
+	return 1
+}
+
+// like matches s against a LIKE pattern with % and _ wildcards
+func like(s, pattern string) bool {
+	if pattern == "" {
+		return s == ""
+	}
+	switch pattern[0] {
+	case '%':
+		for i := 0; i <= len(s); i++ {
+			if like(s[i:], pattern[1:]) {
+				return true
+			}
+		}
+		return false
+	case '_':
+		return s != "" && like(s[1:], pattern[1:])
+	case '\\':
+		if len(pattern) > 1 {
+			return s != "" && s[0] == pattern[1] && like(s[1:], pattern[2:])
+		}
+	}
+	return s != "" && s[0] == pattern[0] && like(s[1:], pattern[1:])
+}
@@ -0,0 +1,95 @@
+package themistest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+func TestParseQuery(t *testing.T) {
+	docs := []interface{}{
+		map[string]interface{}{"name": "a", "tags": []interface{}{"x"}, "addr": map[string]interface{}{"city": "Berlin"}, "n": -1.0},
+		map[string]interface{}{"name": "b", "addr": map[string]interface{}{"city": "Paris"}, "n": 2.0},
+		map[string]interface{}{"name": "c", "n": nil},
+	}
+	tests := []struct {
+		aql      string
+		bindVars map[string]interface{}
+		want     []interface{}
+	}{
+		{"FOR d IN c RETURN d.name", nil, []interface{}{"a", "b", "c"}},
+		{"for d in c filter d.addr.city == 'Paris' return d.name", nil, []interface{}{"b"}},
+		{"FOR d IN c FILTER d.addr['city'] IN @cities RETURN d.name", map[string]interface{}{"cities": []interface{}{"Berlin"}}, []interface{}{"a"}},
+		{"FOR d IN c FILTER d.name NOT IN ['a', 'b'] RETURN d.name", nil, []interface{}{"c"}},
+		{"FOR d IN c FILTER d.n > -2 RETURN d.name", nil, []interface{}{"a", "b"}},
+		{"FOR d IN `c` FILTER d.`addr`.`city` == 'Berlin' RETURN d.`name`", nil, []interface{}{"a"}},
+		{"FOR d IN c FILTER !(d.name == 'a' || d.name == 'b') RETURN d.name", nil, []interface{}{"c"}},
+		{"FOR d IN c FILTER d.tags RETURN d.name", nil, []interface{}{"a"}},
+		{"FOR d IN c SORT d.n DESC LIMIT 1, 5 RETURN d.name", nil, []interface{}{"a", "c"}},
+		{"FOR d IN c FILTER d.name LIKE '_' FILTER d.n == null RETURN {n: d.name, city: d.addr.city}", nil,
+			[]interface{}{map[string]interface{}{"n": "c", "city": nil}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.aql, func(t *testing.T) {
+			q, err := parseQuery(tt.aql)
+			require.NoError(t, err)
+			assert.Equal(t, "c", q.collection)
+			got, err := q.run(docs, tt.bindVars)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseQueryUnsupported(t *testing.T) {
+	for _, aql := range []string{
+		"RETURN 1",
+		"FOR d IN c COLLECT x = d.a RETURN x",
+		"FOR d IN c FILTER e.a == 1 RETURN d",
+		"FOR d IN c RETURN d LIMIT 1",
+		"FOR d IN c FILTER d.a == 'unterminated RETURN d",
+	} {
+		_, err := parseQuery(aql)
+		assert.Error(t, err, aql)
+	}
+
+	q, err := parseQuery("FOR d IN c FILTER d.a == @missing RETURN d")
+	require.NoError(t, err)
+	_, err = q.run([]interface{}{map[string]interface{}{}}, nil)
+	assert.EqualError(t, err, "bind parameter @missing not set")
+}
+
+func TestCompareAndLike(t *testing.T) {
+	ordered := []interface{}{nil, false, true, -1.0, 2.0, "", "a", []interface{}{1.0}, map[string]interface{}{}}
+	for i := 1; i < len(ordered); i++ {
+		assert.Negative(t, compare(ordered[i-1], ordered[i]), "%v < %v", ordered[i-1], ordered[i])
+	}
+	assert.True(t, like("Berlin", "B%n"))
+	assert.True(t, like("50%", `50\%`))
+	assert.False(t, like("Berlin", "B_n"))
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	doc := map[string]interface{}{"a": map[string]interface{}{"b": 1.0}, "list": []interface{}{"x", "z"}}
+	got, err := applyJSONPatch(doc, themisdb.JSONPatch{
+		{Op: "test", Path: "/a/b", Value: 1.0},
+		{Op: "add", Path: "/list/1", Value: "y"},
+		{Op: "add", Path: "/list/-", Value: "end"},
+		{Op: "move", From: "/a/b", Path: "/c"},
+		{Op: "copy", From: "/c", Path: "/d"},
+		{Op: "replace", Path: "/d", Value: 2.0},
+		{Op: "remove", Path: "/list/0"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"a": map[string]interface{}{}, "c": 1.0, "d": 2.0, "list": []interface{}{"y", "z", "end"},
+	}, got)
+
+	_, err = applyJSONPatch(got, themisdb.JSONPatch{{Op: "test", Path: "/c", Value: 2.0}})
+	assert.Error(t, err)
+	_, err = applyJSONPatch(got, themisdb.JSONPatch{{Op: "replace", Path: "/missing", Value: 1}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,913 @@
+// Package themistest provides an in-memory ThemisDB for unit tests.
+//
+// Fake implements themisdb.Transport, so the client returned by NewClient is a
+// regular *themisdb.Client: entity reads and writes, scans, transactions, the
+// changefeed, and a subset of AQL are served from memory without a running server or
+// HTTP mocks.
+//
+//	client, fake := themistest.NewClient(t)
+//	fake.Seed("relational", "users", "u1", map[string]interface{}{"name": "Ada", "age": 36})
+//
+//	svc := NewUserService(client) // accepts themisdb.ThemisClient
+//	...
+//	doc, ok := fake.Document("relational", "users", "u1")
+package themistest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+// Endpoint is the endpoint of clients created by NewClient
+const Endpoint = "memory://themistest"
+
+// QueryFunc answers a stubbed query with the value returned as the query's data
+type QueryFunc func(bindVars map[string]interface{}) (interface{}, error)
+
+// docKey identifies a document
+type docKey struct {
+	namespace  string
+	model      string
+	collection string
+	uuid       string
+}
+
+// txWrite is a write buffered by a transaction, doc is nil for deletes
+type txWrite struct {
+	doc     interface{}
+	deleted bool
+}
+
+// fakeTx is an open transaction; order holds the written keys in first-write order,
+// the order in which a commit applies them
+type fakeTx struct {
+	writes map[docKey]txWrite
+	order  []docKey
+}
+
+// changeEvent is a committed write recorded on the changefeed
+type changeEvent struct {
+	Sequence    uint64  `json:"sequence"`
+	Type        string  `json:"type"`
+	Key         string  `json:"key"`
+	Value       *string `json:"value"`
+	TimestampMs int64   `json:"timestamp_ms"`
+	namespace   string
+}
+
+// failure is an injected error response
+type failure struct {
+	status  int
+	message string
+}
+
+// Fake is an in-memory ThemisDB server
+type Fake struct {
+	mu        sync.Mutex
+	docs      map[docKey]interface{}
+	revisions map[docKey]int
+	txs       map[string]*fakeTx
+	txSeq     int
+	queries   map[string]QueryFunc
+	failures  []failure
+	requests  []themisdb.Request
+	changes   []changeEvent
+	// changed is closed and replaced whenever a change is recorded, waking long polls
+	changed chan struct{}
+}
+
+// New creates an empty in-memory server
+func New() *Fake {
+	return &Fake{
+		docs:      make(map[docKey]interface{}),
+		revisions: make(map[docKey]int),
+		txs:       make(map[string]*fakeTx),
+		queries:   make(map[string]QueryFunc),
+		changed:   make(chan struct{}),
+	}
+}
+
+// NewClient returns a client backed by a new in-memory server. The client is closed
+// when the test finishes.
+func NewClient(t testing.TB) (*themisdb.Client, *Fake) {
+	fake := New()
+	client := fake.NewClient(themisdb.Config{})
+	t.Cleanup(func() { client.Close() })
+	return client, fake
+}
+
+// NewClient returns a client backed by f. Endpoints and Transport of config are replaced,
+// other options such as Interceptors or Namespace apply as usual.
+func (f *Fake) NewClient(config themisdb.Config) *themisdb.Client {
+	config.Endpoints = []string{Endpoint}
+	config.Replicas = nil
+	config.Discovery = nil
+	config.Transport = f
+	return themisdb.NewClient(config)
+}
+
+// Seed stores a document outside of any transaction
+func (f *Fake) Seed(model, collection, uuid string, doc interface{}) error {
+	return f.SeedNamespace("", model, collection, uuid, doc)
+}
+
+// SeedNamespace stores a document in a namespace outside of any transaction
+func (f *Fake) SeedNamespace(namespace, model, collection, uuid string, doc interface{}) error {
+	value, err := normalize(doc)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := docKey{namespace, model, collection, uuid}
+	f.docs[key] = value
+	f.revisions[key]++
+	return nil
+}
+
+// Document returns a committed document as decoded JSON
+func (f *Fake) Document(model, collection, uuid string) (map[string]interface{}, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	doc, ok := f.docs[docKey{"", model, collection, uuid}]
+	if !ok {
+		return nil, false
+	}
+	m, _ := deepCopy(doc).(map[string]interface{})
+	return m, true
+}
+
+// Len returns the number of committed documents in a collection
+func (f *Fake) Len(model, collection string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for key := range f.docs {
+		if key.namespace == "" && key.model == model && key.collection == collection {
+			n++
+		}
+	}
+	return n
+}
+
+// HandleQuery stubs the result of an AQL query that the built-in evaluator does not
+// support. The query text must match exactly, ignoring surrounding whitespace.
+func (f *Fake) HandleQuery(aql string, fn QueryFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries[strings.TrimSpace(aql)] = fn
+}
+
+// FailNext makes the next request fail with the given HTTP status and message, e.g.
+// http.StatusServiceUnavailable to test retry and error handling paths
+func (f *Fake) FailNext(status int, message string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = append(f.failures, failure{status: status, message: message})
+}
+
+// Requests returns the requests served so far
+func (f *Fake) Requests() []themisdb.Request {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]themisdb.Request(nil), f.requests...)
+}
+
+// OpenTransactions returns the number of transactions neither committed nor rolled back
+func (f *Fake) OpenTransactions() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.txs)
+}
+
+// Reset removes all documents, transactions, stubs, and recorded requests
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.docs = make(map[docKey]interface{})
+	f.revisions = make(map[docKey]int)
+	f.txs = make(map[string]*fakeTx)
+	f.queries = make(map[string]QueryFunc)
+	f.failures = nil
+	f.requests = nil
+	f.changes = nil
+}
+
+// Close implements themisdb.Transport
+func (f *Fake) Close() error {
+	return nil
+}
+
+// RoundTrip implements themisdb.Transport
+func (f *Fake) RoundTrip(ctx context.Context, endpoint string, req *themisdb.Request) (*themisdb.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, *req)
+	if len(f.failures) > 0 {
+		fail := f.failures[0]
+		f.failures = f.failures[1:]
+		return errorResponse(fail.status, fail.message), nil
+	}
+
+	u, err := url.Parse(req.Path)
+	if err != nil {
+		return errorResponse(http.StatusBadRequest, err.Error()), nil
+	}
+	var resp *themisdb.Response
+	if u.Path == "/changefeed" {
+		resp = f.changefeed(ctx, req, u.Query())
+	} else {
+		resp = f.serve(req, u)
+	}
+	resp.Endpoint = endpoint
+	return resp, nil
+}
+
+// serve dispatches a request by path
+func (f *Fake) serve(req *themisdb.Request, u *url.URL) *themisdb.Response {
+	switch u.Path {
+	case "/transaction/begin":
+		f.txSeq++
+		id := "tx-" + strconv.Itoa(f.txSeq)
+		f.txs[id] = &fakeTx{writes: make(map[docKey]txWrite)}
+		return jsonResponse(http.StatusOK, map[string]string{"transaction_id": id})
+	case "/transaction/prepare", "/transaction/heartbeat":
+		return f.ackTransaction(req)
+	case "/transaction/commit", "/transaction/rollback":
+		return f.endTransaction(req, u.Path == "/transaction/commit")
+	case "/api/query":
+		return f.query(req)
+	}
+
+	segments := strings.Split(strings.TrimPrefix(u.EscapedPath(), "/api/"), "/")
+	if !strings.HasPrefix(u.Path, "/api/") || len(segments) != 3 {
+		return errorResponse(http.StatusNotFound, fmt.Sprintf("themistest: unsupported endpoint %s %s", req.Method, u.Path))
+	}
+	for i, segment := range segments {
+		decoded, err := url.PathUnescape(segment)
+		if err != nil {
+			return errorResponse(http.StatusBadRequest, err.Error())
+		}
+		segments[i] = decoded
+	}
+
+	tx, resp := f.transaction(req)
+	if resp != nil {
+		return resp
+	}
+	ns := req.Header["X-Themis-Namespace"]
+	switch {
+	case segments[2] == "_mget" && req.Method == "POST":
+		return f.getMany(tx, ns, segments[0], segments[1], req)
+	case segments[2] == "_scan" && req.Method == "GET":
+		return f.scan(tx, ns, segments[0], segments[1], u.Query())
+	case segments[2] == "_bulk" && req.Method == "POST":
+		return f.bulkWrite(tx, ns, segments[0], segments[1], req)
+	case segments[2] == "_increment" && req.Method == "POST":
+		return f.increment(tx, ns, segments[0], segments[1], req)
+	}
+
+	key := docKey{ns, segments[0], segments[1], segments[2]}
+	switch req.Method {
+	case "GET":
+		return f.getEntity(tx, key, req)
+	case "PUT":
+		return f.putEntity(tx, key, req)
+	case "PATCH":
+		return f.patchEntity(tx, key, req, u.Query().Get("upsert") == "true")
+	case "DELETE":
+		f.write(tx, key, nil, true)
+		return &themisdb.Response{StatusCode: http.StatusNoContent, Header: http.Header{}}
+	}
+	return errorResponse(http.StatusMethodNotAllowed, "themistest: unsupported method "+req.Method)
+}
+
+// transaction returns the transaction a request runs in, or an error response if
+// the X-Transaction-Id header names an unknown transaction
+func (f *Fake) transaction(req *themisdb.Request) (*fakeTx, *themisdb.Response) {
+	id, ok := req.Header["X-Transaction-Id"]
+	if !ok {
+		return nil, nil
+	}
+	tx, ok := f.txs[id]
+	if !ok {
+		return nil, errorResponse(http.StatusNotFound, "themistest: unknown transaction "+id)
+	}
+	return tx, nil
+}
+
+// endTransaction applies (commit) or discards (rollback) the writes of a transaction
+func (f *Fake) endTransaction(req *themisdb.Request, commit bool) *themisdb.Response {
+	var body struct {
+		TransactionID string `json:"transaction_id"`
+	}
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		return errorResponse(http.StatusBadRequest, err.Error())
+	}
+	tx, ok := f.txs[body.TransactionID]
+	if !ok {
+		return errorResponse(http.StatusNotFound, "themistest: unknown transaction "+body.TransactionID)
+	}
+	delete(f.txs, body.TransactionID)
+	if commit {
+		for _, key := range tx.order {
+			w := tx.writes[key]
+			f.write(nil, key, w.doc, w.deleted)
+		}
+	}
+	return &themisdb.Response{StatusCode: http.StatusNoContent, Header: http.Header{}}
+}
+
+// ackTransaction acknowledges the first phase of a two-phase commit or a heartbeat of a
+// known transaction; writes stay buffered until the commit
+func (f *Fake) ackTransaction(req *themisdb.Request) *themisdb.Response {
+	var body struct {
+		TransactionID string `json:"transaction_id"`
+	}
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		return errorResponse(http.StatusBadRequest, err.Error())
+	}
+	if _, ok := f.txs[body.TransactionID]; !ok {
+		return errorResponse(http.StatusNotFound, "themistest: unknown transaction "+body.TransactionID)
+	}
+	return &themisdb.Response{StatusCode: http.StatusNoContent, Header: http.Header{}}
+}
+
+// lookup returns a document as seen by a transaction, or the committed document if tx is nil
+func (f *Fake) lookup(tx *fakeTx, key docKey) (interface{}, bool) {
+	if tx != nil {
+		if w, ok := tx.writes[key]; ok {
+			return w.doc, !w.deleted
+		}
+	}
+	doc, ok := f.docs[key]
+	return doc, ok
+}
+
+// write stores or deletes a document, buffered in tx if it is not nil
+func (f *Fake) write(tx *fakeTx, key docKey, doc interface{}, deleted bool) {
+	if tx != nil {
+		if _, ok := tx.writes[key]; !ok {
+			tx.order = append(tx.order, key)
+		}
+		tx.writes[key] = txWrite{doc: doc, deleted: deleted}
+		return
+	}
+	f.revisions[key]++
+	event := changeEvent{
+		Sequence:    uint64(len(f.changes) + 1),
+		Type:        "PUT",
+		Key:         key.collection + ":" + key.uuid,
+		TimestampMs: time.Now().UnixMilli(),
+		namespace:   key.namespace,
+	}
+	if deleted {
+		delete(f.docs, key)
+		event.Type = "DELETE"
+	} else {
+		f.docs[key] = doc
+		if data, err := json.Marshal(doc); err == nil {
+			value := string(data)
+			event.Value = &value
+		}
+	}
+	f.changes = append(f.changes, event)
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// changefeed returns the changes after from_seq whose key starts with key_prefix,
+// waiting up to long_poll_ms for one. It is called with f.mu held and releases it
+// while waiting.
+func (f *Fake) changefeed(ctx context.Context, req *themisdb.Request, query url.Values) *themisdb.Response {
+	from, _ := strconv.ParseUint(query.Get("from_seq"), 10, 64)
+	limit := 100
+	if v := query.Get("limit"); v != "" {
+		limit, _ = strconv.Atoi(v)
+	}
+	wait, _ := strconv.Atoi(query.Get("long_poll_ms"))
+	prefix := query.Get("key_prefix")
+	ns := req.Header["X-Themis-Namespace"]
+
+	deadline := time.NewTimer(time.Duration(wait) * time.Millisecond)
+	defer deadline.Stop()
+	for {
+		events := []changeEvent{}
+		for _, e := range f.changes {
+			if len(events) == limit {
+				break
+			}
+			if e.Sequence > from && e.namespace == ns && strings.HasPrefix(e.Key, prefix) {
+				events = append(events, e)
+			}
+		}
+		if len(events) > 0 || limit == 0 || wait <= 0 {
+			return jsonResponse(http.StatusOK, map[string]interface{}{"events": events, "latest_sequence": len(f.changes)})
+		}
+
+		changed := f.changed
+		f.mu.Unlock()
+		select {
+		case <-changed:
+		case <-deadline.C:
+			wait = 0
+		case <-ctx.Done():
+		}
+		f.mu.Lock()
+		if ctx.Err() != nil {
+			return errorResponse(http.StatusServiceUnavailable, ctx.Err().Error())
+		}
+	}
+}
+
+// etag returns the entity tag of a document's current revision
+func (f *Fake) etag(key docKey) string {
+	return `"` + strconv.Itoa(f.revisions[key]) + `"`
+}
+
+// checkPreconditions evaluates If-Match and If-None-Match: * of a write
+func (f *Fake) checkPreconditions(req *themisdb.Request, key docKey, exists bool) *themisdb.Response {
+	if exists && req.Header["If-None-Match"] == "*" {
+		return errorResponse(http.StatusPreconditionFailed, "document already exists")
+	}
+	if match := req.Header["If-Match"]; match != "" && (!exists || match != f.etag(key)) {
+		return errorResponse(http.StatusPreconditionFailed, "revision mismatch")
+	}
+	return nil
+}
+
+func (f *Fake) getEntity(tx *fakeTx, key docKey, req *themisdb.Request) *themisdb.Response {
+	doc, ok := f.lookup(tx, key)
+	if !ok {
+		return errorResponse(http.StatusNotFound, "document not found")
+	}
+	etag := f.etag(key)
+	if req.Header["If-None-Match"] == etag {
+		return &themisdb.Response{StatusCode: http.StatusNotModified, Header: http.Header{"Etag": {etag}}}
+	}
+	resp := jsonResponse(http.StatusOK, doc)
+	resp.Header.Set("ETag", etag)
+	return resp
+}
+
+func (f *Fake) putEntity(tx *fakeTx, key docKey, req *themisdb.Request) *themisdb.Response {
+	_, exists := f.lookup(tx, key)
+	if resp := f.checkPreconditions(req, key, exists); resp != nil {
+		return resp
+	}
+	var doc interface{}
+	if err := json.Unmarshal(req.Body, &doc); err != nil {
+		return errorResponse(http.StatusBadRequest, err.Error())
+	}
+	f.write(tx, key, doc, false)
+	resp := &themisdb.Response{StatusCode: http.StatusNoContent, Header: http.Header{}}
+	if tx == nil {
+		resp.Header.Set("ETag", f.etag(key))
+	}
+	return resp
+}
+
+func (f *Fake) patchEntity(tx *fakeTx, key docKey, req *themisdb.Request, upsert bool) *themisdb.Response {
+	doc, exists := f.lookup(tx, key)
+	if !exists && !upsert {
+		return errorResponse(http.StatusNotFound, "document not found")
+	}
+	if resp := f.checkPreconditions(req, key, exists); resp != nil {
+		return resp
+	}
+
+	var patched interface{}
+	var err error
+	if req.Header["Content-Type"] == themisdb.ContentTypeJSONPatch {
+		var ops themisdb.JSONPatch
+		if err = json.Unmarshal(req.Body, &ops); err == nil {
+			patched, err = applyJSONPatch(deepCopy(doc), ops)
+		}
+	} else {
+		var patch interface{}
+		if err = json.Unmarshal(req.Body, &patch); err == nil {
+			patched = mergePatch(deepCopy(doc), patch)
+		}
+	}
+	if err != nil {
+		return errorResponse(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	f.write(tx, key, patched, false)
+	if !exists {
+		return &themisdb.Response{StatusCode: http.StatusCreated, Header: http.Header{}}
+	}
+	return &themisdb.Response{StatusCode: http.StatusNoContent, Header: http.Header{}}
+}
+
+func (f *Fake) getMany(tx *fakeTx, ns, model, collection string, req *themisdb.Request) *themisdb.Response {
+	var body struct {
+		UUIDs []string `json:"uuids"`
+	}
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		return errorResponse(http.StatusBadRequest, err.Error())
+	}
+	docs := []interface{}{}
+	missing := []string{}
+	for _, uuid := range body.UUIDs {
+		if doc, ok := f.lookup(tx, docKey{ns, model, collection, uuid}); ok {
+			docs = append(docs, doc)
+		} else {
+			missing = append(missing, uuid)
+		}
+	}
+	return jsonResponse(http.StatusOK, map[string]interface{}{"documents": docs, "missing": missing})
+}
+
+// bulkWrite stores a batch of documents and removes the documents listed in deletes;
+// with on_conflict "fail" nothing is written if any of the documents exists
+func (f *Fake) bulkWrite(tx *fakeTx, ns, model, collection string, req *themisdb.Request) *themisdb.Response {
+	var body struct {
+		Documents []struct {
+			UUID     string      `json:"uuid"`
+			Document interface{} `json:"document"`
+		} `json:"documents"`
+		Deletes    []string `json:"deletes"`
+		OnConflict string   `json:"on_conflict"`
+	}
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		return errorResponse(http.StatusBadRequest, err.Error())
+	}
+	if body.OnConflict == string(themisdb.ImportFail) {
+		for _, d := range body.Documents {
+			if _, exists := f.lookup(tx, docKey{ns, model, collection, d.UUID}); exists {
+				return errorResponse(http.StatusConflict, "document "+d.UUID+" already exists")
+			}
+		}
+	}
+	written, skipped := 0, 0
+	for _, d := range body.Documents {
+		key := docKey{ns, model, collection, d.UUID}
+		if _, exists := f.lookup(tx, key); exists && body.OnConflict == string(themisdb.ImportSkip) {
+			skipped++
+			continue
+		}
+		f.write(tx, key, d.Document, false)
+		written++
+	}
+	for _, uuid := range body.Deletes {
+		f.write(tx, docKey{ns, model, collection, uuid}, nil, true)
+	}
+	return jsonResponse(http.StatusOK, map[string]interface{}{"written": written, "skipped": skipped})
+}
+
+// increment adds delta to a numeric field, creating the document and field as needed
+func (f *Fake) increment(tx *fakeTx, ns, model, collection string, req *themisdb.Request) *themisdb.Response {
+	var body struct {
+		UUID  string `json:"uuid"`
+		Field string `json:"field"`
+		Delta int64  `json:"delta"`
+	}
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		return errorResponse(http.StatusBadRequest, err.Error())
+	}
+	key := docKey{ns, model, collection, body.UUID}
+	current, _ := f.lookup(tx, key)
+	doc, ok := deepCopy(current).(map[string]interface{})
+	if !ok {
+		doc = map[string]interface{}{}
+	}
+
+	parent := doc
+	parts := strings.Split(body.Field, ".")
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := parent[part].(map[string]interface{})
+		if !ok {
+			if parent[part] != nil {
+				return errorResponse(http.StatusUnprocessableEntity, body.Field+" is not an object")
+			}
+			child = map[string]interface{}{}
+			parent[part] = child
+		}
+		parent = child
+	}
+	field := parts[len(parts)-1]
+	value, ok := parent[field].(float64)
+	if !ok && parent[field] != nil {
+		return errorResponse(http.StatusUnprocessableEntity, body.Field+" is not a number")
+	}
+	value += float64(body.Delta)
+	parent[field] = value
+	f.write(tx, key, doc, false)
+	return jsonResponse(http.StatusOK, map[string]interface{}{"value": int64(value)})
+}
+
+func (f *Fake) scan(tx *fakeTx, ns, model, collection string, query url.Values) *themisdb.Response {
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit <= 0 {
+		limit = 100
+	}
+	prefix, after := query.Get("prefix"), query.Get("start_after")
+
+	var uuids []string
+	for _, key := range f.keys(tx, ns, model, collection) {
+		if strings.HasPrefix(key.uuid, prefix) && key.uuid > after {
+			uuids = append(uuids, key.uuid)
+		}
+	}
+	hasMore := len(uuids) > limit
+	if hasMore {
+		uuids = uuids[:limit]
+	}
+	items := make([]map[string]interface{}, 0, len(uuids))
+	for _, uuid := range uuids {
+		doc, _ := f.lookup(tx, docKey{ns, model, collection, uuid})
+		items = append(items, map[string]interface{}{"uuid": uuid, "document": doc})
+	}
+	return jsonResponse(http.StatusOK, map[string]interface{}{"items": items, "has_more": hasMore})
+}
+
+// keys returns the keys of the documents in a collection visible to tx, sorted by UUID.
+// An empty model matches the collection in every model.
+func (f *Fake) keys(tx *fakeTx, ns, model, collection string) []docKey {
+	seen := map[docKey]bool{}
+	match := func(key docKey) bool {
+		return key.namespace == ns && key.collection == collection && (model == "" || key.model == model)
+	}
+	for key := range f.docs {
+		if match(key) {
+			seen[key] = true
+		}
+	}
+	if tx != nil {
+		for key, w := range tx.writes {
+			if match(key) {
+				seen[key] = !w.deleted
+			}
+		}
+	}
+
+	keys := make([]docKey, 0, len(seen))
+	for key, visible := range seen {
+		if visible {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].uuid != keys[j].uuid {
+			return keys[i].uuid < keys[j].uuid
+		}
+		return keys[i].model < keys[j].model
+	})
+	return keys
+}
+
+func (f *Fake) query(req *themisdb.Request) *themisdb.Response {
+	var body struct {
+		Query    string                 `json:"query"`
+		BindVars map[string]interface{} `json:"bind_vars"`
+	}
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		return errorResponse(http.StatusBadRequest, err.Error())
+	}
+
+	if fn, ok := f.queries[strings.TrimSpace(body.Query)]; ok {
+		data, err := fn(body.BindVars)
+		if err != nil {
+			return errorResponse(http.StatusBadRequest, err.Error())
+		}
+		return jsonResponse(http.StatusOK, map[string]interface{}{"data": data})
+	}
+
+	tx, resp := f.transaction(req)
+	if resp != nil {
+		return resp
+	}
+	q, err := parseQuery(body.Query)
+	if err != nil {
+		return errorResponse(http.StatusBadRequest, "themistest: "+err.Error())
+	}
+	ns := req.Header["X-Themis-Namespace"]
+	var docs []interface{}
+	for _, key := range f.keys(tx, ns, "", q.collection) {
+		doc, _ := f.lookup(tx, key)
+		docs = append(docs, keyedDoc{key: key.uuid, doc: doc})
+	}
+	data, err := q.run(docs, body.BindVars)
+	if err != nil {
+		return errorResponse(http.StatusBadRequest, "themistest: "+err.Error())
+	}
+	return jsonResponse(http.StatusOK, map[string]interface{}{"data": data})
+}
+
+// jsonResponse encodes v as the body of a response
+func jsonResponse(status int, v interface{}) *themisdb.Response {
+	body, _ := json.Marshal(v)
+	return &themisdb.Response{StatusCode: status, Header: http.Header{"Content-Type": {"application/json"}}, Body: body}
+}
+
+// errorResponse returns a response with a plain text error message
+func errorResponse(status int, message string) *themisdb.Response {
+	return &themisdb.Response{StatusCode: status, Header: http.Header{}, Body: []byte(message)}
+}
+
+// normalize converts v to its decoded JSON representation
+func normalize(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal document: %w", err)
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal document: %w", err)
+	}
+	return out, nil
+}
+
+// deepCopy copies decoded JSON so patches don't modify stored documents
+func deepCopy(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			out[key] = deepCopy(value)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, value := range v {
+			out[i] = deepCopy(value)
+		}
+		return out
+	}
+	return v
+}
+
+// mergePatch applies an RFC 7386 merge patch
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+		} else {
+			t[key] = mergePatch(t[key], value)
+		}
+	}
+	return t
+}
+
+// applyJSONPatch applies RFC 6902 operations in order
+func applyJSONPatch(doc interface{}, ops themisdb.JSONPatch) (interface{}, error) {
+	var err error
+	for _, op := range ops {
+		switch op.Op {
+		case "add", "replace":
+			doc, err = setPointer(doc, op.Path, deepCopy(op.Value), op.Op == "add")
+		case "remove":
+			doc, _, err = removePointer(doc, op.Path)
+		case "test":
+			var value interface{}
+			if value, err = getPointer(doc, op.Path); err == nil && !reflect.DeepEqual(value, op.Value) {
+				err = fmt.Errorf("test failed at %s", op.Path)
+			}
+		case "move", "copy":
+			var value interface{}
+			if op.Op == "move" {
+				doc, value, err = removePointer(doc, op.From)
+			} else if value, err = getPointer(doc, op.From); err == nil {
+				value = deepCopy(value)
+			}
+			if err == nil {
+				doc, err = setPointer(doc, op.Path, value, true)
+			}
+		default:
+			err = fmt.Errorf("unknown operation %q", op.Op)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// splitPointer splits a JSON Pointer into unescaped reference tokens
+func splitPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func getPointer(doc interface{}, pointer string) (interface{}, error) {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	for _, token := range tokens {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if doc, ok = v[token]; !ok {
+				return nil, fmt.Errorf("path %s not found", pointer)
+			}
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("path %s not found", pointer)
+			}
+			doc = v[i]
+		default:
+			return nil, fmt.Errorf("path %s not found", pointer)
+		}
+	}
+	return doc, nil
+}
+
+// setPointer adds (insert) or replaces the value at a JSON Pointer and returns the updated document
+func setPointer(doc interface{}, pointer string, value interface{}, insert bool) (interface{}, error) {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	parentPointer := pointer[:strings.LastIndex(pointer, "/")]
+	parent, err := getPointer(doc, parentPointer)
+	if err != nil {
+		return nil, err
+	}
+	last := tokens[len(tokens)-1]
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := p[last]; !ok && !insert {
+			return nil, fmt.Errorf("path %s not found", pointer)
+		}
+		p[last] = value
+		return doc, nil
+	case []interface{}:
+		i := len(p)
+		if last != "-" {
+			if i, err = strconv.Atoi(last); err != nil || i < 0 || i > len(p) || (!insert && i == len(p)) {
+				return nil, fmt.Errorf("path %s not found", pointer)
+			}
+		}
+		if insert {
+			p = append(p[:i], append([]interface{}{value}, p[i:]...)...)
+		} else {
+			p[i] = value
+		}
+		return setPointer(doc, parentPointer, p, false)
+	}
+	return nil, fmt.Errorf("path %s not found", pointer)
+}
+
+// removePointer removes the value at a JSON Pointer and returns the updated document and the removed value
+func removePointer(doc interface{}, pointer string) (interface{}, interface{}, error) {
+	value, err := getPointer(doc, pointer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pointer == "" {
+		return nil, value, nil
+	}
+	parentPointer := pointer[:strings.LastIndex(pointer, "/")]
+	parent, _ := getPointer(doc, parentPointer)
+	tokens, _ := splitPointer(pointer)
+	last := tokens[len(tokens)-1]
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		delete(p, last)
+		return doc, value, nil
+	case []interface{}:
+		i, _ := strconv.Atoi(last)
+		doc, err = setPointer(doc, parentPointer, append(p[:i:i], p[i+1:]...), false)
+		return doc, value, err
+	}
+	return nil, nil, fmt.Errorf("path %s not found", pointer)
+}
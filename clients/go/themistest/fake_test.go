@@ -0,0 +1,291 @@
+package themistest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+type user struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+// userService depends on the client interface only
+type userService struct {
+	db themisdb.ThemisClient
+}
+
+func (s *userService) rename(ctx context.Context, id, name string) error {
+	var u user
+	if err := s.db.Get(ctx, "relational", "users", id, &u); err != nil {
+		return err
+	}
+	u.Name = name
+	return s.db.Put(ctx, "relational", "users", id, u)
+}
+
+func TestFake_Entities(t *testing.T) {
+	client, fake := NewClient(t)
+	ctx := context.Background()
+
+	require.NoError(t, fake.Seed("relational", "users", "u1", user{Name: "Ada", Age: 36}))
+	svc := &userService{db: client}
+	require.NoError(t, svc.rename(ctx, "u1", "Ada Lovelace"))
+
+	doc, ok := fake.Document("relational", "users", "u1")
+	require.True(t, ok)
+	assert.Equal(t, "Ada Lovelace", doc["name"])
+
+	err := svc.rename(ctx, "missing", "x")
+	assert.ErrorIs(t, err, themisdb.ErrNotFound)
+
+	assert.ErrorIs(t, client.Create(ctx, "relational", "users", "u1", user{}), themisdb.ErrAlreadyExists)
+	created, err := client.Upsert(ctx, "relational", "users", "u2", map[string]interface{}{"name": "Grace"})
+	require.NoError(t, err)
+	assert.True(t, created)
+	require.NoError(t, client.Patch(ctx, "relational", "users", "u2", themisdb.JSONPatch{
+		{Op: "add", Path: "/age", Value: 85},
+	}))
+
+	var users []user
+	missing, err := client.GetMany(ctx, "relational", "users", []string{"u1", "u2", "u3"}, &users)
+	require.NoError(t, err)
+	assert.Equal(t, []user{{Name: "Ada Lovelace", Age: 36}, {Name: "Grace", Age: 85}}, users)
+	assert.Equal(t, []string{"u3"}, missing)
+
+	scanner := client.Scan(ctx, "relational", "users", themisdb.ScanOptions{BatchSize: 1})
+	var uuids []string
+	for scanner.Next() {
+		uuids = append(uuids, scanner.UUID())
+	}
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, []string{"u1", "u2"}, uuids)
+
+	require.NoError(t, client.Delete(ctx, "relational", "users", "u1"))
+	assert.Equal(t, 1, fake.Len("relational", "users"))
+}
+
+func TestFake_Entity(t *testing.T) {
+	client, _ := NewClient(t)
+	ctx := context.Background()
+
+	type account struct {
+		ID      string `json:"-" themis:"uuid"`
+		Rev     string `json:"-" themis:"rev"`
+		Balance int    `json:"balance"`
+	}
+	a := &account{Balance: 10}
+	require.NoError(t, client.PutEntity(ctx, a))
+	stale := *a
+	a.Balance = 20
+	require.NoError(t, client.PutEntity(ctx, a))
+	assert.ErrorIs(t, client.PutEntity(ctx, &stale), themisdb.ErrConflict)
+}
+
+func TestFake_Transactions(t *testing.T) {
+	client, fake := NewClient(t)
+	ctx := context.Background()
+	require.NoError(t, fake.Seed("relational", "accounts", "a", map[string]interface{}{"balance": 100}))
+
+	tx, err := client.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.Put(ctx, "relational", "accounts", "a", map[string]interface{}{"balance": 50}))
+	require.NoError(t, tx.Put(ctx, "relational", "accounts", "b", map[string]interface{}{"balance": 50}))
+
+	var balance struct {
+		Balance int `json:"balance"`
+	}
+	require.NoError(t, tx.Get(ctx, "relational", "accounts", "a", &balance))
+	assert.Equal(t, 50, balance.Balance)
+	require.NoError(t, client.Get(ctx, "relational", "accounts", "a", &balance))
+	assert.Equal(t, 100, balance.Balance, "uncommitted writes are not visible outside the transaction")
+
+	var inTx []map[string]interface{}
+	require.NoError(t, tx.Query(ctx, "FOR a IN accounts RETURN a", &inTx))
+	assert.Len(t, inTx, 2)
+
+	require.NoError(t, tx.Commit(ctx))
+	assert.Equal(t, 2, fake.Len("relational", "accounts"))
+
+	tx, err = client.BeginTransaction(ctx, &themisdb.TransactionOptions{Heartbeat: time.Millisecond})
+	require.NoError(t, err)
+	require.NoError(t, tx.Delete(ctx, "relational", "accounts", "a"))
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, tx.Err(), "heartbeats of open transactions are acknowledged")
+	require.NoError(t, tx.Rollback(ctx))
+	assert.Equal(t, 2, fake.Len("relational", "accounts"))
+	assert.Equal(t, 0, fake.OpenTransactions())
+}
+
+func TestFake_Changefeed(t *testing.T) {
+	client, _ := NewClient(t)
+	ctx := context.Background()
+	require.NoError(t, client.Put(ctx, "relational", "users", "u1", user{Name: "Ada"}))
+	start, err := client.LatestChangeSequence(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), start)
+
+	tx, err := client.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.Put(ctx, "relational", "orders", "o2", map[string]int{"total": 2}))
+	require.NoError(t, tx.Put(ctx, "relational", "orders", "o1", map[string]int{"total": 1}))
+	require.NoError(t, tx.Delete(ctx, "relational", "users", "u1"))
+	changes, err := client.ReadChanges(ctx, start, themisdb.ChangesOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, changes, "uncommitted writes are not on the changefeed")
+	require.NoError(t, tx.Commit(ctx))
+
+	changes, err = client.ReadChanges(ctx, start, themisdb.ChangesOptions{})
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+	assert.Equal(t, []string{"o2", "o1", "u1"}, []string{changes[0].UUID, changes[1].UUID, changes[2].UUID}, "commits apply writes in order")
+	assert.Equal(t, "DELETE", changes[2].Type)
+	assert.JSONEq(t, `{"total":2}`, string(changes[0].Document))
+
+	changes, err = client.ReadChanges(ctx, 0, themisdb.ChangesOptions{Collection: "users"})
+	require.NoError(t, err)
+	assert.Len(t, changes, 2)
+
+	done := make(chan []themisdb.Change)
+	go func() {
+		changes, _ := client.ReadChanges(ctx, 4, themisdb.ChangesOptions{Wait: 5 * time.Second})
+		done <- changes
+	}()
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, client.Put(ctx, "relational", "users", "u2", user{Name: "Grace"}))
+	select {
+	case changes := <-done:
+		require.Len(t, changes, 1)
+		assert.Equal(t, uint64(5), changes[0].Sequence)
+	case <-time.After(time.Second):
+		t.Fatal("long poll did not return after a write")
+	}
+}
+
+func TestFake_TwoPhaseCommit(t *testing.T) {
+	orders, ordersFake := NewClient(t)
+	ledger, ledgerFake := NewClient(t)
+	ctx := context.Background()
+
+	tx1, err := orders.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	tx2, err := ledger.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx1.Put(ctx, "relational", "orders", "o1", map[string]interface{}{"amount": 5}))
+	require.NoError(t, tx2.Put(ctx, "relational", "entries", "e1", map[string]interface{}{"amount": -5}))
+
+	require.NoError(t, themisdb.NewCoordinator(ledger.DecisionLog()).Commit(ctx, "transfer-1", tx1, tx2))
+	assert.Equal(t, 1, ordersFake.Len("relational", "orders"))
+	assert.Equal(t, 1, ledgerFake.Len("relational", "entries"))
+	assert.Zero(t, ledgerFake.Len("relational", "_decisions"))
+	assert.Zero(t, ordersFake.OpenTransactions())
+
+	assert.ErrorIs(t, orders.PreparedTransaction("tx-unknown").Commit(ctx), themisdb.ErrNotFound)
+}
+
+func TestFake_Import(t *testing.T) {
+	client, fake := NewClient(t)
+	ctx := context.Background()
+	require.NoError(t, fake.Seed("relational", "users", "1", user{"Ada", 36}))
+
+	input := `{"uuid":"1","document":{"name":"Grace"}}` + "\n" + `{"uuid":"2","document":{"name":"Alan"}}` + "\n"
+	report, err := client.Import(ctx, "relational", "users", strings.NewReader(input), themisdb.ImportOptions{OnConflict: themisdb.ImportSkip})
+	require.NoError(t, err)
+	assert.Equal(t, &themisdb.ImportReport{Read: 2, Written: 1, Skipped: 1}, report)
+	_, err = client.Import(ctx, "relational", "users", strings.NewReader(input), themisdb.ImportOptions{OnConflict: themisdb.ImportFail})
+	assert.ErrorIs(t, err, themisdb.ErrConflict)
+
+	var buf strings.Builder
+	n, err := client.Export(ctx, "relational", "users", &buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.Equal(t, `{"uuid":"1","document":{"age":36,"name":"Ada"}}`+"\n"+`{"uuid":"2","document":{"name":"Alan"}}`+"\n", buf.String())
+}
+
+func TestFake_Increment(t *testing.T) {
+	client, fake := NewClient(t)
+	ctx := context.Background()
+	require.NoError(t, fake.Seed("relational", "pages", "home", map[string]interface{}{"title": "Home"}))
+
+	value, err := client.Increment(ctx, "relational", "pages", "home", "stats.views", 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), value)
+	var page map[string]interface{}
+	require.NoError(t, client.Get(ctx, "relational", "pages", "home", &page))
+	assert.Equal(t, map[string]interface{}{"title": "Home", "stats": map[string]interface{}{"views": 3.0}}, page)
+
+	_, err = client.Increment(ctx, "relational", "pages", "home", "title", 1)
+	assert.Equal(t, http.StatusUnprocessableEntity, themisdb.HTTPStatus(err))
+
+	seq := client.Sequence("orders", themisdb.SequenceOptions{BlockSize: 5})
+	for want := int64(1); want <= 7; want++ {
+		got, err := seq.Next(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+}
+
+func TestFake_Query(t *testing.T) {
+	client, fake := NewClient(t)
+	ctx := context.Background()
+	for id, u := range map[string]user{"1": {"Ada", 36}, "2": {"Grace", 85}, "3": {"Alan", 41}, "4": {"Edsger", 72}} {
+		require.NoError(t, fake.Seed("relational", "users", id, u))
+	}
+
+	var names []string
+	err := client.QueryWithOptions(ctx,
+		`FOR u IN users FILTER u.age > @min AND u.name != "Edsger" SORT u.age DESC LIMIT 2 RETURN u.name`,
+		&themisdb.QueryOptions{BindVars: map[string]interface{}{"min": 40}}, &names)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Grace", "Alan"}, names)
+
+	var projected []map[string]interface{}
+	require.NoError(t, client.Query(ctx, `FOR u IN users FILTER u.name LIKE 'A%' SORT u.name RETURN {name: u.name}`, &projected))
+	assert.Equal(t, []map[string]interface{}{{"name": "Ada"}, {"name": "Alan"}}, projected)
+
+	var keys []string
+	require.NoError(t, client.Query(ctx, `FOR u IN users FILTER u._key IN ["2", "3"] SORT u._key RETURN u._key`, &keys))
+	assert.Equal(t, []string{"2", "3"}, keys)
+
+	fake.HandleQuery("FOR u IN users COLLECT WITH COUNT INTO n RETURN n", func(map[string]interface{}) (interface{}, error) {
+		return []int{4}, nil
+	})
+	var counts []int
+	require.NoError(t, client.Query(ctx, "FOR u IN users COLLECT WITH COUNT INTO n RETURN n", &counts))
+	assert.Equal(t, []int{4}, counts)
+
+	err = client.Query(ctx, "FOR u IN users COLLECT g = u.age RETURN g", &counts)
+	assert.Equal(t, http.StatusBadRequest, themisdb.HTTPStatus(err))
+	assert.Contains(t, err.Error(), "unsupported query")
+}
+
+func TestFake_NamespacesAndFailures(t *testing.T) {
+	client, fake := NewClient(t)
+	ctx := context.Background()
+
+	require.NoError(t, client.Namespace("tenant-a").Put(ctx, "relational", "users", "u1", user{Name: "Ada"}))
+	assert.ErrorIs(t, client.Get(ctx, "relational", "users", "u1", &user{}), themisdb.ErrNotFound)
+	assert.Equal(t, 0, fake.Len("relational", "users"))
+
+	fake.FailNext(http.StatusServiceUnavailable, "overloaded")
+	err := client.Put(ctx, "relational", "users", "u1", user{})
+	var statusErr *themisdb.StatusError
+	require.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusServiceUnavailable, statusErr.StatusCode)
+	assert.ErrorIs(t, err, themisdb.ErrUnavailable)
+	require.NoError(t, client.Put(ctx, "relational", "users", "u1", user{}))
+
+	requests := fake.Requests()
+	assert.Equal(t, "tenant-a", requests[0].Header["X-Themis-Namespace"])
+	fake.Reset()
+	assert.Empty(t, fake.Requests())
+}
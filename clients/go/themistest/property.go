@@ -0,0 +1,406 @@
+package themistest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+// PropertyOptions configures CheckQueries
+type PropertyOptions struct {
+	// Seed selects the generated documents and queries
+	Seed int64
+	// Documents is the number of generated documents (default: 40)
+	Documents int
+	// Queries is the number of generated queries (default: 200)
+	Queries int
+	// Model and Collection receive the documents (default: document/themistest_props).
+	// The collection should not hold other documents.
+	Model      string
+	Collection string
+}
+
+// Mismatch is a query whose result differs from the expected result
+type Mismatch struct {
+	// Client is the index of the client in the CheckQueries arguments
+	Client   int
+	Query    string
+	BindVars map[string]interface{}
+	Want     []interface{}
+	Got      []interface{}
+	// Err is set if the query failed
+	Err error
+}
+
+func (m Mismatch) String() string {
+	bindVars, _ := json.Marshal(m.BindVars)
+	if m.Err != nil {
+		return fmt.Sprintf("client %d: %s %s: %v", m.Client, m.Query, bindVars, m.Err)
+	}
+	want, _ := json.Marshal(m.Want)
+	got, _ := json.Marshal(m.Got)
+	return fmt.Sprintf("client %d: %s %s:\n  want %s\n  got  %s", m.Client, m.Query, bindVars, want, got)
+}
+
+// CheckQueries is a property test of AQL filtering and result decoding. It writes
+// random documents through every client, then runs random FILTER queries and compares
+// each client's decoded result with the result AQL semantics prescribe, including
+// comparisons with missing attributes, LIKE patterns, IN, and LIMIT. Passing both a
+// Fake client and a client of a real server finds incompatibilities between the two:
+//
+//	client, _ := themistest.NewClient(t)
+//	server := themisdb.NewClient(themisdb.Config{Endpoints: []string{endpoint}})
+//	mismatches, err := themistest.CheckQueries(ctx, themistest.PropertyOptions{Seed: 7}, client, server)
+//
+// The documents are deleted again when CheckQueries returns.
+func CheckQueries(ctx context.Context, opts PropertyOptions, clients ...themisdb.ThemisClient) ([]Mismatch, error) {
+	if opts.Documents <= 0 {
+		opts.Documents = 40
+	}
+	if opts.Queries <= 0 {
+		opts.Queries = 200
+	}
+	if opts.Model == "" {
+		opts.Model = themisdb.ModelDocument
+	}
+	if opts.Collection == "" {
+		opts.Collection = "themistest_props"
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	docs := make([]map[string]interface{}, opts.Documents)
+	for i := range docs {
+		docs[i] = propDocument(rng, i)
+	}
+	for _, client := range clients {
+		defer func(client themisdb.ThemisClient) {
+			for _, doc := range docs {
+				client.Delete(context.Background(), opts.Model, opts.Collection, doc["id"].(string))
+			}
+		}(client)
+		for _, doc := range docs {
+			if err := client.Put(ctx, opts.Model, opts.Collection, doc["id"].(string), doc); err != nil {
+				return nil, fmt.Errorf("failed to write %s: %w", doc["id"], err)
+			}
+		}
+	}
+
+	var mismatches []Mismatch
+	for i := 0; i < opts.Queries; i++ {
+		q := newPropQuery(rng, opts.Collection)
+		want := q.expect(docs)
+		for c, client := range clients {
+			var got []interface{}
+			err := client.QueryWithOptions(ctx, q.aql, &themisdb.QueryOptions{BindVars: q.bindVars}, &got)
+			if err != nil {
+				if ctx.Err() != nil {
+					return mismatches, ctx.Err()
+				}
+				mismatches = append(mismatches, Mismatch{Client: c, Query: q.aql, BindVars: q.bindVars, Want: want, Err: err})
+				continue
+			}
+			if got == nil {
+				got = []interface{}{}
+			}
+			if !reflect.DeepEqual(want, got) {
+				mismatches = append(mismatches, Mismatch{Client: c, Query: q.aql, BindVars: q.bindVars, Want: want, Got: got})
+			}
+		}
+	}
+	return mismatches, nil
+}
+
+var propStrings = []string{"", "alpha", "alps", "beta", "beta_2", "gamma", "delta"}
+
+// propDocument returns the i-th random document
+func propDocument(rng *rand.Rand, i int) map[string]interface{} {
+	doc := map[string]interface{}{
+		"id":     fmt.Sprintf("p-%03d", i),
+		"n":      rng.Intn(11) - 5,
+		"f":      float64(rng.Intn(2001)-1000) / 100,
+		"s":      propStrings[rng.Intn(len(propStrings))],
+		"b":      rng.Intn(2) == 0,
+		"tags":   []interface{}{},
+		"nested": map[string]interface{}{"x": rng.Intn(5)},
+	}
+	for _, tag := range []string{"x", "y", "z"} {
+		if rng.Intn(2) == 0 {
+			doc["tags"] = append(doc["tags"].([]interface{}), tag)
+		}
+	}
+	if rng.Intn(2) == 0 {
+		doc["opt"] = rng.Intn(5)
+	}
+	if rng.Intn(5) == 0 {
+		doc["n"] = nil
+	}
+	return doc
+}
+
+// propQuery is a generated query together with its oracle
+type propQuery struct {
+	rng      *rand.Rand
+	aql      string
+	bindVars map[string]interface{}
+	filter   func(doc map[string]interface{}) bool
+	offset   int
+	count    int
+	project  func(doc map[string]interface{}) interface{}
+}
+
+// newPropQuery generates a query over collection
+func newPropQuery(rng *rand.Rand, collection string) *propQuery {
+	q := &propQuery{rng: rng, bindVars: map[string]interface{}{}, count: -1}
+	var aql strings.Builder
+	fmt.Fprintf(&aql, "FOR d IN %s", collection)
+	var filters []func(map[string]interface{}) bool
+	for i := rng.Intn(3); i >= 0; i-- {
+		text, filter := q.predicate(2)
+		fmt.Fprintf(&aql, " FILTER %s", text)
+		filters = append(filters, filter)
+	}
+	q.filter = func(doc map[string]interface{}) bool {
+		for _, f := range filters {
+			if !f(doc) {
+				return false
+			}
+		}
+		return true
+	}
+	aql.WriteString(" SORT d.id")
+	if rng.Intn(3) == 0 {
+		q.offset, q.count = rng.Intn(5), rng.Intn(10)
+		fmt.Fprintf(&aql, " LIMIT %d, %d", q.offset, q.count)
+	}
+	switch rng.Intn(3) {
+	case 0:
+		aql.WriteString(" RETURN d")
+		q.project = func(doc map[string]interface{}) interface{} { return doc }
+	case 1:
+		aql.WriteString(" RETURN d.id")
+		q.project = func(doc map[string]interface{}) interface{} { return doc["id"] }
+	default:
+		aql.WriteString(" RETURN {id: d.id, opt: d.opt, x: d.nested.x}")
+		q.project = func(doc map[string]interface{}) interface{} {
+			return map[string]interface{}{"id": doc["id"], "opt": doc["opt"], "x": propValue(doc, "nested.x")}
+		}
+	}
+	if len(q.bindVars) == 0 {
+		q.bindVars = nil
+	}
+	q.aql = aql.String()
+	return q
+}
+
+// expect returns the decoded result the query must produce over docs
+func (q *propQuery) expect(docs []map[string]interface{}) []interface{} {
+	var matched []map[string]interface{}
+	for _, doc := range docs {
+		if q.filter(doc) {
+			matched = append(matched, doc)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i]["id"].(string) < matched[j]["id"].(string) })
+	if q.count >= 0 {
+		matched = matched[min(q.offset, len(matched)):min(q.offset+q.count, len(matched))]
+	}
+	rows := make([]interface{}, len(matched))
+	for i, doc := range matched {
+		rows[i] = q.project(doc)
+	}
+	// Round-trip through JSON so numbers compare like decoded results
+	data, _ := json.Marshal(rows)
+	var want []interface{}
+	json.Unmarshal(data, &want)
+	return want
+}
+
+// literal renders v as an AQL literal or, sometimes, as a bind parameter
+func (q *propQuery) literal(v interface{}) string {
+	if q.rng.Intn(3) == 0 {
+		name := fmt.Sprintf("v%d", len(q.bindVars))
+		q.bindVars[name] = v
+		return "@" + name
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+var propOperators = []string{"==", "!=", "<", "<=", ">", ">="}
+
+// predicate generates a filter expression and its oracle
+func (q *propQuery) predicate(depth int) (string, func(map[string]interface{}) bool) {
+	kind := q.rng.Intn(9)
+	if depth == 0 {
+		kind %= 6
+	}
+	switch kind {
+	case 0, 1:
+		field := []string{"n", "f", "opt", "nested.x"}[q.rng.Intn(4)]
+		op := propOperators[q.rng.Intn(len(propOperators))]
+		var v interface{} = q.rng.Intn(11) - 5
+		if q.rng.Intn(8) == 0 {
+			v = nil
+		}
+		text := fmt.Sprintf("d.%s %s %s", field, op, q.literal(v))
+		return text, func(doc map[string]interface{}) bool { return propCompare(op, propValue(doc, field), v) }
+	case 2:
+		op := propOperators[q.rng.Intn(len(propOperators))]
+		v := propStrings[q.rng.Intn(len(propStrings))]
+		text := fmt.Sprintf("d.s %s %s", op, q.literal(v))
+		return text, func(doc map[string]interface{}) bool { return propCompare(op, doc["s"], v) }
+	case 3:
+		pattern := []string{"al%", "%ta", "beta\\_%", "_eta%", "%", "gamma"}[q.rng.Intn(6)]
+		re := propLikeRegexp(pattern)
+		text := fmt.Sprintf("d.s LIKE %s", q.literal(pattern))
+		return text, func(doc map[string]interface{}) bool {
+			s, ok := doc["s"].(string)
+			return ok && re.MatchString(s)
+		}
+	case 4:
+		values := []interface{}{}
+		for _, s := range propStrings {
+			if q.rng.Intn(3) == 0 {
+				values = append(values, s)
+			}
+		}
+		not := q.rng.Intn(2) == 0
+		op := "IN"
+		if not {
+			op = "NOT IN"
+		}
+		text := fmt.Sprintf("d.s %s %s", op, q.literal(values))
+		return text, func(doc map[string]interface{}) bool { return propContains(values, doc["s"]) != not }
+	case 5:
+		if q.rng.Intn(2) == 0 {
+			v := q.rng.Intn(2) == 0
+			return fmt.Sprintf("d.b == %s", q.literal(v)), func(doc map[string]interface{}) bool { return doc["b"] == v }
+		}
+		tag := []string{"x", "y", "z"}[q.rng.Intn(3)]
+		return fmt.Sprintf("%s IN d.tags", q.literal(tag)), func(doc map[string]interface{}) bool {
+			return propContains(doc["tags"].([]interface{}), tag)
+		}
+	case 6:
+		text, f := q.predicate(depth - 1)
+		return fmt.Sprintf("NOT (%s)", text), func(doc map[string]interface{}) bool { return !f(doc) }
+	default:
+		left, lf := q.predicate(depth - 1)
+		right, rf := q.predicate(depth - 1)
+		if kind == 7 {
+			return fmt.Sprintf("(%s AND %s)", left, right), func(doc map[string]interface{}) bool { return lf(doc) && rf(doc) }
+		}
+		return fmt.Sprintf("(%s OR %s)", left, right), func(doc map[string]interface{}) bool { return lf(doc) || rf(doc) }
+	}
+}
+
+// propValue returns the value at a dotted path, nil if it is missing
+func propValue(doc map[string]interface{}, path string) interface{} {
+	var v interface{} = doc
+	for _, part := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[part]
+	}
+	return v
+}
+
+// propCompare applies a comparison operator with AQL ordering: null < bool < number < string
+func propCompare(op string, a, b interface{}) bool {
+	c := propOrder(a, b)
+	switch op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+func propOrder(a, b interface{}) int {
+	rank := func(v interface{}) int {
+		switch v.(type) {
+		case nil:
+			return 0
+		case bool:
+			return 1
+		case int, float64:
+			return 2
+		default:
+			return 3
+		}
+	}
+	if ra, rb := rank(a), rank(b); ra != rb {
+		return ra - rb
+	}
+	switch a := a.(type) {
+	case bool:
+		return map[bool]int{false: 0, true: 1}[a] - map[bool]int{false: 0, true: 1}[b.(bool)]
+	case int, float64:
+		fa, fb := propFloat(a), propFloat(b)
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(a, b.(string))
+	}
+	return 0
+}
+
+func propFloat(v interface{}) float64 {
+	if i, ok := v.(int); ok {
+		return float64(i)
+	}
+	return v.(float64)
+}
+
+func propContains(values []interface{}, v interface{}) bool {
+	for _, candidate := range values {
+		if propOrder(candidate, v) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// propLikeRegexp translates a LIKE pattern with % and _ wildcards and \ escapes
+func propLikeRegexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			}
+		case '%':
+			b.WriteString("(?s).*")
+		case '_':
+			b.WriteString("(?s).")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
@@ -0,0 +1,64 @@
+package themistest
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	themisdb "github.com/makr-code/ThemisDB/clients/go"
+)
+
+func TestCheckQueries(t *testing.T) {
+	client, fake := NewClient(t)
+	mismatches, err := CheckQueries(context.Background(), PropertyOptions{Seed: 1, Queries: 500}, client)
+	require.NoError(t, err)
+	for _, m := range mismatches {
+		t.Error(m)
+	}
+	assert.Zero(t, fake.Len(themisdb.ModelDocument, "themistest_props"), "documents are removed")
+}
+
+func TestCheckQueries_DetectsMismatch(t *testing.T) {
+	client, fake := NewClient(t)
+	fake.Seed(themisdb.ModelDocument, "themistest_props", "p-000a", map[string]interface{}{"id": "p-000a", "s": "alpha", "n": 0})
+
+	mismatches, err := CheckQueries(context.Background(), PropertyOptions{Seed: 2, Queries: 50}, client)
+	require.NoError(t, err)
+	require.NotEmpty(t, mismatches, "the stray document shows up in results")
+	assert.Equal(t, 0, mismatches[0].Client)
+	assert.Contains(t, mismatches[0].String(), "want ")
+}
+
+// TestCheckQueries_Server compares the fake with a real server at THEMISDB_ENDPOINT
+func TestCheckQueries_Server(t *testing.T) {
+	endpoint := os.Getenv("THEMISDB_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("THEMISDB_ENDPOINT is not set")
+	}
+	fake, _ := NewClient(t)
+	server := themisdb.NewClient(themisdb.Config{Endpoints: []string{endpoint}})
+	defer server.Close()
+
+	mismatches, err := CheckQueries(context.Background(), PropertyOptions{Seed: 3}, fake, server)
+	require.NoError(t, err)
+	for _, m := range mismatches {
+		t.Error(m)
+	}
+}
+
+func FuzzCheckQueries(f *testing.F) {
+	for _, seed := range []int64{1, 42, 1 << 40} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, seed int64) {
+		client, _ := NewClient(t)
+		mismatches, err := CheckQueries(context.Background(), PropertyOptions{Seed: seed, Documents: 20, Queries: 30}, client)
+		require.NoError(t, err)
+		for _, m := range mismatches {
+			t.Error(m)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package themisdb
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimitOptions configures client-side rate limiting and the handling of 429
+// responses (see Config.RateLimit)
+type RateLimitOptions struct {
+	// Rate is the sustained number of requests per second; 0 leaves the rate
+	// unlimited and only handles 429 responses
+	Rate float64
+	// Burst is the number of requests that may be sent at once after a quiet period
+	// (default: 1)
+	Burst int
+	// MaxRetryAfter bounds the wait before retrying a request answered with 429;
+	// responses asking for a longer wait are returned as ErrRateLimited (default: 30s)
+	MaxRetryAfter time.Duration
+}
+
+// retryBackoff is the first wait after a 429 response without Retry-After, doubled
+// on every further retry of the request
+const retryBackoff = 100 * time.Millisecond
+
+// throttle is a token bucket that is paused while the server asks clients to back off
+type throttle struct {
+	rate          float64
+	burst         float64
+	maxRetryAfter time.Duration
+	maxRetries    int
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	paused time.Time
+}
+
+// newThrottle returns the throttle of opts, or nil if rate limiting is disabled
+func newThrottle(opts *RateLimitOptions, maxRetries int) *throttle {
+	if opts == nil {
+		return nil
+	}
+	t := &throttle{rate: opts.Rate, burst: float64(opts.Burst), maxRetryAfter: opts.MaxRetryAfter, maxRetries: maxRetries}
+	if t.burst < 1 {
+		t.burst = 1
+	}
+	if t.maxRetryAfter <= 0 {
+		t.maxRetryAfter = 30 * time.Second
+	}
+	t.tokens = t.burst
+	return t
+}
+
+// reserve takes a token and returns how long the caller must wait before sending.
+// Tokens go negative while requests queue, so they are served in order.
+func (t *throttle) reserve(now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var delay time.Duration
+	if t.rate > 0 {
+		if !t.last.IsZero() {
+			t.tokens = math.Min(t.burst, t.tokens+now.Sub(t.last).Seconds()*t.rate)
+		}
+		t.last = now
+		t.tokens--
+		if t.tokens < 0 {
+			delay = time.Duration(-t.tokens / t.rate * float64(time.Second))
+		}
+	}
+	if pause := t.paused.Sub(now); pause > delay {
+		delay = pause
+	}
+	return delay
+}
+
+// cancel returns a token taken by reserve for a request that was not sent
+func (t *throttle) cancel() {
+	if t.rate <= 0 {
+		return
+	}
+	t.mu.Lock()
+	t.tokens = math.Min(t.burst, t.tokens+1)
+	t.mu.Unlock()
+}
+
+// wait blocks until a request may be sent or ctx is done
+func (t *throttle) wait(ctx context.Context) error {
+	delay := t.reserve(time.Now())
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		t.cancel()
+		return ctx.Err()
+	}
+}
+
+// pause holds back all requests for d
+func (t *throttle) pause(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if until := time.Now().Add(d); until.After(t.paused) {
+		t.paused = until
+	}
+}
+
+// retryAfter returns the wait requested by a 429 response, or the backoff of the
+// given retry if it does not carry a valid Retry-After header
+func retryAfter(header http.Header, retry int) time.Duration {
+	if value := strings.TrimSpace(header.Get("Retry-After")); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+		if at, err := http.ParseTime(value); err == nil {
+			return max(time.Until(at), 0)
+		}
+	}
+	return retryBackoff << retry
+}
+
+// roundTripThrottled sends req once the rate limit allows it, and retries it after the
+// requested wait while the server answers with 429. The 429 response is returned if
+// the retries are exhausted, the wait exceeds MaxRetryAfter, or ctx would expire first.
+func (c *Client) roundTripThrottled(ctx context.Context, req *Request) (*Response, error) {
+	for retry := 0; ; retry++ {
+		if err := c.throttle.wait(ctx); err != nil {
+			return nil, err
+		}
+		resp, err := c.roundTrip(ctx, req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || retry >= c.throttle.maxRetries {
+			return resp, err
+		}
+		delay := retryAfter(resp.Header, retry)
+		if delay > c.throttle.maxRetryAfter {
+			return resp, nil
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return resp, nil
+		}
+		c.throttle.pause(delay)
+	}
+}
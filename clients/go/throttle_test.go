@@ -0,0 +1,89 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_RateLimit(t *testing.T) {
+	base := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	})
+	client := NewClient(Config{Endpoints: base.endpoints, RateLimit: &RateLimitOptions{Rate: 50, Burst: 2}})
+	defer client.Close()
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, client.Namespace("tenant-a").Put(context.Background(), "relational", "orders", "o1", map[string]int{"total": 1}))
+		}()
+	}
+	wg.Wait()
+	assert.GreaterOrEqual(t, time.Since(start), 75*time.Millisecond, "4 requests beyond the burst at 50/s")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	err := client.Delete(ctx, "relational", "orders", "o1")
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "the bucket is empty for the next 20ms")
+}
+
+func TestClient_RetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	base := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		case 2:
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		default:
+			w.Write([]byte(`{"total": 1}`))
+		}
+	})
+	client := NewClient(Config{Endpoints: base.endpoints, RateLimit: &RateLimitOptions{}})
+	defer client.Close()
+
+	start := time.Now()
+	var order map[string]int
+	require.NoError(t, client.Get(context.Background(), "relational", "orders", "o1", &order))
+	assert.Equal(t, 1, order["total"])
+	assert.Equal(t, int32(3), calls.Load())
+	assert.GreaterOrEqual(t, time.Since(start), time.Second+retryBackoff)
+
+	calls.Store(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	err := client.Get(ctx, "relational", "orders", "o1", &order)
+	assert.ErrorIs(t, err, ErrRateLimited, "the deadline expires before Retry-After")
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	calls.Store(0)
+	client = NewClient(Config{Endpoints: base.endpoints, MaxRetries: 1, RateLimit: &RateLimitOptions{MaxRetryAfter: 500 * time.Millisecond}})
+	defer client.Close()
+	err = client.Get(context.Background(), "relational", "orders", "o1", &order)
+	assert.ErrorIs(t, err, ErrRateLimited, "Retry-After exceeds MaxRetryAfter")
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRetryAfter(t *testing.T) {
+	header := http.Header{}
+	assert.Equal(t, retryBackoff, retryAfter(header, 0))
+	assert.Equal(t, 4*retryBackoff, retryAfter(header, 2))
+	header.Set("Retry-After", "3")
+	assert.Equal(t, 3*time.Second, retryAfter(header, 0))
+	header.Set("Retry-After", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	assert.Equal(t, time.Duration(0), retryAfter(header, 0))
+	header.Set("Retry-After", "soon")
+	assert.Equal(t, retryBackoff, retryAfter(header, 0))
+}
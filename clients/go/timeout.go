@@ -0,0 +1,84 @@
+package themisdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// OperationTimeouts bounds requests by kind of operation. Each request is bounded by
+// the tighter of its context deadline and the timeout of its kind; a zero field uses
+// Config.Timeout. Streaming requests such as live queries and blob transfers are only
+// bounded by their context.
+type OperationTimeouts struct {
+	// Read bounds entity reads, scans, and other requests without side effects
+	Read time.Duration
+	// Write bounds entity writes and other requests with side effects
+	Write time.Duration
+	// Query bounds AQL queries, including prepared ones
+	Query time.Duration
+	// Transaction bounds begin, commit, and rollback and the requests made within a
+	// transaction
+	Transaction time.Duration
+}
+
+// withDefault returns t with zero fields set to d
+func (t OperationTimeouts) withDefault(d time.Duration) OperationTimeouts {
+	for _, field := range []*time.Duration{&t.Read, &t.Write, &t.Query, &t.Transaction} {
+		if *field <= 0 {
+			*field = d
+		}
+	}
+	return t
+}
+
+// of returns the timeout of req
+func (t OperationTimeouts) of(req *Request) time.Duration {
+	switch operationOf(req) {
+	case OperationTransaction:
+		return t.Transaction
+	case OperationQuery:
+		return t.Query
+	case OperationRead:
+		return t.Read
+	}
+	return t.Write
+}
+
+// TimeoutError reports a blocking operation that gave up after its time bound, as
+// returned by the Within variants such as Semaphore.AcquireWithin
+type TimeoutError struct {
+	// Op names the operation, e.g. "acquire semaphore jobs"
+	Op string
+	// Timeout is the time bound that elapsed
+	Timeout time.Duration
+}
+
+// Error implements error
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %v", e.Op, e.Timeout)
+}
+
+// Unwrap allows matching with errors.Is(err, ErrTimeout)
+func (e *TimeoutError) Unwrap() error {
+	return ErrTimeout
+}
+
+// Is allows matching with errors.Is(err, context.DeadlineExceeded), like the error
+// of an operation bounded by a context deadline
+func (e *TimeoutError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+// within runs fn with ctx bounded by d and reports the expiry of d, but not of ctx
+// itself, as a *TimeoutError for op
+func within(ctx context.Context, op string, d time.Duration, fn func(ctx context.Context) error) error {
+	bounded, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	err := fn(bounded)
+	if err != nil && ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+		return &TimeoutError{Op: op, Timeout: d}
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperationTimeouts_Of(t *testing.T) {
+	timeouts := OperationTimeouts{Read: 1, Query: 3}.withDefault(5)
+	assert.Equal(t, OperationTimeouts{Read: 1, Write: 5, Query: 3, Transaction: 5}, timeouts)
+
+	tests := []struct {
+		req  Request
+		want time.Duration
+	}{
+		{Request{Method: "GET", Path: "/api/relational/users/u1"}, 1},
+		{Request{Method: "POST", Path: "/api/relational/users/_mget", Idempotent: true}, 1},
+		{Request{Method: "PUT", Path: "/api/relational/users/u1"}, 5},
+		{Request{Method: "POST", Path: "/api/query"}, 3},
+		{Request{Method: "POST", Path: "/api/query/execute", Idempotent: true}, 3},
+		{Request{Method: "POST", Path: "/transaction/commit"}, 5},
+		{Request{Method: "GET", Path: "/api/relational/users/u1", Header: map[string]string{"X-Transaction-Id": "tx-1"}}, 5},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, timeouts.of(&tt.req), "%s %s", tt.req.Method, tt.req.Path)
+	}
+}
+
+func TestClient_OperationTimeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-r.Context().Done():
+			return
+		}
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+	client := NewClient(Config{
+		Endpoints:         []string{server.URL},
+		Timeout:           50 * time.Millisecond,
+		OperationTimeouts: OperationTimeouts{Read: 20 * time.Millisecond, Query: time.Second},
+	})
+	defer client.Close()
+	ctx := context.Background()
+
+	var doc map[string]interface{}
+	err := client.Get(ctx, "relational", "users", "u1", &doc)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "reads are bounded by the read timeout")
+
+	err = client.Put(ctx, "relational", "users", "u1", map[string]string{"name": "Ada"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "writes without a timeout use Config.Timeout")
+
+	var rows []map[string]interface{}
+	require.NoError(t, client.Query(ctx, "FOR u IN users RETURN u", &rows), "queries may run longer than Config.Timeout")
+
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	err = client.Query(short, "FOR u IN users RETURN u", &rows)
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "an earlier context deadline takes precedence")
+}
@@ -0,0 +1,207 @@
+package themisdb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Supported wire protocols for Config.Protocol
+const (
+	// ProtocolHTTP sends JSON over HTTP (default)
+	ProtocolHTTP = "http"
+	// ProtocolGRPC sends protobuf-encoded RPCs over gRPC
+	ProtocolGRPC = "grpc"
+)
+
+// Request is a protocol-independent API request
+type Request struct {
+	// Method is the HTTP verb of the API operation
+	Method string
+	// Path is the API path, e.g. /api/relational/users/123
+	Path string
+	// Header holds extra request headers such as X-Transaction-Id
+	Header map[string]string
+	// Body is the JSON-encoded request body, nil if the operation has none
+	Body []byte
+	// Idempotent marks reads that may be sent to more than one endpoint (see Config.Hedging)
+	Idempotent bool
+	// ReadOnly marks requests other than GET and HEAD that do not modify data, such as
+	// read-only queries; a client pinned with AtSnapshot sends no others
+	ReadOnly bool
+}
+
+// Response is a protocol-independent API response
+type Response struct {
+	// StatusCode is the HTTP status, gRPC status codes are mapped onto it
+	StatusCode int
+	// Header holds response headers (or gRPC header metadata)
+	Header http.Header
+	// Body is the JSON-encoded response body
+	Body []byte
+	// Endpoint is the server endpoint that produced the response
+	Endpoint string
+}
+
+// Transport sends API requests to a single server endpoint
+type Transport interface {
+	// RoundTrip executes req against endpoint and returns the raw response.
+	// Server-side errors are reported through Response.StatusCode, not the error.
+	RoundTrip(ctx context.Context, endpoint string, req *Request) (*Response, error)
+	// Close releases connections held by the transport
+	Close() error
+}
+
+// httpTransport is the default JSON over HTTP transport
+type httpTransport struct {
+	client      *http.Client
+	compression *compression
+}
+
+// newHTTPTransport creates an HTTP transport using the given HTTP client
+func newHTTPTransport(client *http.Client) *httpTransport {
+	return &httpTransport{client: client}
+}
+
+// RoundTrip implements Transport
+func (t *httpTransport) RoundTrip(ctx context.Context, endpoint string, req *Request) (*Response, error) {
+	body, encoding := req.Body, ""
+	if t.compression != nil && body != nil {
+		var err error
+		if body, encoding, err = t.compression.encode(body); err != nil {
+			return nil, err
+		}
+	}
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, endpoint+req.Path, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	for key, value := range req.Header {
+		httpReq.Header.Set(key, value)
+	}
+	if encoding != "" {
+		httpReq.Header.Set("Content-Encoding", encoding)
+	}
+	if t.compression != nil {
+		// Setting Accept-Encoding turns off the gzip handling of net/http
+		httpReq.Header.Set("Accept-Encoding", t.compression.acceptEncoding())
+	}
+
+	resp, err := t.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	decoded, err := decodeBody(resp.Header, resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	defer decoded.Close()
+	respBody, err := io.ReadAll(decoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       respBody,
+	}, nil
+}
+
+// Close implements Transport
+func (t *httpTransport) Close() error {
+	t.client.CloseIdleConnections()
+	return nil
+}
+
+// stream sends a request whose body or response is streamed instead of buffered, e.g.
+// server-sent events or blobs. It requires the HTTP transport and, unlike Do, bypasses
+// Config.Timeout; ctx bounds the request. The request passes through the interceptors,
+// hooks, and latency stats like any other, but without its body, and the Response seen
+// by interceptors has none either; the body cannot be replayed, so interceptors must
+// call next at most once. Error statuses are returned as *StatusError with the response
+// closed.
+func (c *Client) stream(ctx context.Context, method, path string, body io.Reader, headers map[string]string) (*http.Response, error) {
+	if _, ok := c.transport.(*httpTransport); !ok {
+		return nil, fmt.Errorf("%w: streaming requires the HTTP transport", ErrUnsupportedByTransport)
+	}
+	root := c
+	if root.root != nil {
+		root = root.root
+	}
+	req := &Request{Method: method, Path: path, Header: headers}
+	if err := c.withNamespace(ctx, req); err != nil {
+		return nil, err
+	}
+	if root.hooks != nil {
+		ctx = withAttempts(ctx)
+	}
+
+	var httpResp *http.Response
+	send := func(ctx context.Context, req *Request) (*Response, error) {
+		if httpResp != nil {
+			httpResp.Body.Close()
+			httpResp = nil
+		}
+		endpoint := root.getEndpoint()
+		sendTo := func() (*Response, error) {
+			resp, err := root.streamTo(ctx, endpoint, req, body)
+			if err != nil {
+				return nil, err
+			}
+			httpResp = resp
+			return &Response{StatusCode: resp.StatusCode, Header: resp.Header, Endpoint: endpoint}, nil
+		}
+		if root.hooks != nil {
+			return root.hooks.observe(ctx, endpoint, req, sendTo)
+		}
+		return sendTo()
+	}
+
+	start := time.Now()
+	resp, err := chainInterceptors(root.interceptors, send)(ctx, req)
+	root.stats.observe(req, time.Since(start), err != nil || resp == nil || resp.StatusCode >= http.StatusInternalServerError)
+	switch {
+	case err != nil:
+		if httpResp != nil {
+			httpResp.Body.Close()
+		}
+		return nil, fmt.Errorf("request failed: %w", err)
+	case httpResp == nil:
+		return nil, fmt.Errorf("request failed: an interceptor answered a streamed request without sending it")
+	case httpResp.StatusCode >= 400:
+		defer httpResp.Body.Close()
+		data, _ := io.ReadAll(httpResp.Body)
+		return nil, &StatusError{StatusCode: httpResp.StatusCode, Message: string(data)}
+	}
+	return httpResp, nil
+}
+
+// streamTo sends req with body to endpoint and returns the unread response
+func (c *Client) streamTo(ctx context.Context, endpoint string, req *Request, body io.Reader) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, endpoint+req.Path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, value := range req.Header {
+		httpReq.Header.Set(key, value)
+	}
+	if cl := httpReq.Header.Get("Content-Length"); cl != "" {
+		httpReq.ContentLength, _ = strconv.ParseInt(cl, 10, 64)
+		httpReq.Header.Del("Content-Length")
+	}
+	return (&http.Client{Transport: c.httpClient.Transport}).Do(httpReq)
+}
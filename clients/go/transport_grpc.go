@@ -0,0 +1,213 @@
+package themisdb
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// grpcService is the fully qualified name of the ThemisDB gRPC service (see proto/themisdb.proto)
+const grpcService = "/themisdb.v1.ThemisDB/"
+
+// grpcTransport sends API requests as gRPC calls whose messages carry the JSON
+// documents of the HTTP API as bytes
+type grpcTransport struct {
+	mu    sync.Mutex
+	conns map[string]*grpc.ClientConn
+}
+
+// newGRPCTransport creates a gRPC transport with lazily dialed connections
+func newGRPCTransport() *grpcTransport {
+	return &grpcTransport{conns: make(map[string]*grpc.ClientConn)}
+}
+
+// RoundTrip implements Transport
+func (t *grpcTransport) RoundTrip(ctx context.Context, endpoint string, req *Request) (*Response, error) {
+	method, args, err := grpcCall(req)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := t.conn(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.Header) > 0 {
+		md := metadata.MD{}
+		for key, value := range req.Header {
+			md.Set(strings.ToLower(key), value)
+		}
+		ctx = metadata.NewOutgoingContext(ctx, md)
+	}
+
+	var header metadata.MD
+	out := &wrapperspb.BytesValue{}
+	err = conn.Invoke(ctx, grpcService+method, args, out, grpc.Header(&header))
+
+	resp := &Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	for key, values := range header {
+		for _, value := range values {
+			resp.Header.Add(key, value)
+		}
+	}
+
+	if err != nil {
+		st, ok := status.FromError(err)
+		if !ok || st.Code() == codes.Unavailable || st.Code() == codes.Canceled || st.Code() == codes.DeadlineExceeded {
+			return nil, err
+		}
+		resp.StatusCode = httpStatusFromCode(st.Code())
+		resp.Body = []byte(st.Message())
+		return resp, nil
+	}
+
+	if len(out.GetValue()) == 0 {
+		resp.StatusCode = http.StatusNoContent
+		return resp, nil
+	}
+	resp.Body = out.GetValue()
+	return resp, nil
+}
+
+// Close implements Transport
+func (t *grpcTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var firstErr error
+	for endpoint, conn := range t.conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(t.conns, endpoint)
+	}
+	return firstErr
+}
+
+// conn returns the connection for endpoint, dialing it on first use
+func (t *grpcTransport) conn(endpoint string) (*grpc.ClientConn, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if conn, ok := t.conns[endpoint]; ok {
+		return conn, nil
+	}
+
+	target, secure := grpcTarget(endpoint)
+	creds := insecure.NewCredentials()
+	if secure {
+		creds = credentials.NewTLS(&tls.Config{})
+	}
+
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial grpc endpoint %s: %w", endpoint, err)
+	}
+	t.conns[endpoint] = conn
+	return conn, nil
+}
+
+// grpcTarget strips the URL scheme from an endpoint and reports whether TLS is required
+func grpcTarget(endpoint string) (string, bool) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return endpoint, false
+	}
+	return u.Host, u.Scheme == "https" || u.Scheme == "grpcs"
+}
+
+// grpcCall maps an API request onto a gRPC method name and its argument, the JSON
+// request body with the path parameters added. The body is passed on undecoded, so
+// that numbers keep their precision.
+func grpcCall(req *Request) (string, *wrapperspb.BytesValue, error) {
+	var method string
+	var params map[string]string
+	segments := strings.Split(strings.Trim(req.Path, "/"), "/")
+	switch {
+	case req.Path == "/api/query" && req.Method == "POST":
+		method = "Query"
+	case req.Path == "/transaction/begin" && req.Method == "POST":
+		method = "BeginTransaction"
+	case req.Path == "/transaction/commit" && req.Method == "POST":
+		method = "CommitTransaction"
+	case req.Path == "/transaction/rollback" && req.Method == "POST":
+		method = "RollbackTransaction"
+	case len(segments) == 4 && segments[0] == "api":
+		switch req.Method {
+		case "GET":
+			method = "Get"
+		case "PUT":
+			method = "Put"
+		case "DELETE":
+			method = "Delete"
+		}
+		if method != "" {
+			params = map[string]string{}
+			for i, key := range []string{"model", "collection", "uuid"} {
+				value, err := url.PathUnescape(segments[i+1])
+				if err != nil {
+					return "", nil, fmt.Errorf("invalid path segment %q: %w", segments[i+1], err)
+				}
+				params[key] = value
+			}
+		}
+	}
+	if method == "" {
+		return "", nil, fmt.Errorf("%w: %s %s", ErrUnsupportedByTransport, req.Method, req.Path)
+	}
+	if params == nil {
+		return method, wrapperspb.Bytes(req.Body), nil
+	}
+
+	// entity calls carry the path parameters and the document
+	fields := make(map[string]json.RawMessage, len(params)+1)
+	for key, value := range params {
+		fields[key], _ = json.Marshal(value)
+	}
+	if method == "Put" {
+		fields["document"] = req.Body
+	}
+	args, err := json.Marshal(fields)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to encode grpc request: %w", err)
+	}
+	return method, wrapperspb.Bytes(args), nil
+}
+
+// httpStatusFromCode maps a gRPC status code onto the equivalent HTTP status
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.FailedPrecondition:
+		return http.StatusPreconditionFailed
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,129 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// startGRPCServer starts a fake ThemisDB gRPC server answering every RPC with handler,
+// which receives and returns JSON documents
+func startGRPCServer(t *testing.T, handler func(method string, md metadata.MD, args []byte) ([]byte, error)) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer(grpc.UnknownServiceHandler(func(srv interface{}, stream grpc.ServerStream) error {
+		method, _ := grpc.MethodFromServerStream(stream)
+		args := &wrapperspb.BytesValue{}
+		if err := stream.RecvMsg(args); err != nil {
+			return err
+		}
+		md, _ := metadata.FromIncomingContext(stream.Context())
+		out, err := handler(method, md, args.GetValue())
+		if err != nil {
+			return err
+		}
+		return stream.SendMsg(wrapperspb.Bytes(out))
+	}))
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	return "grpc://" + lis.Addr().String()
+}
+
+func TestGRPCTransport_CRUD(t *testing.T) {
+	endpoint := startGRPCServer(t, func(method string, md metadata.MD, args []byte) ([]byte, error) {
+		var fields map[string]interface{}
+		require.NoError(t, json.Unmarshal(args, &fields))
+		switch method {
+		case "/themisdb.v1.ThemisDB/Get":
+			assert.Equal(t, "users", fields["collection"])
+			if fields["uuid"] == "missing" {
+				return nil, status.Error(codes.NotFound, "entity not found")
+			}
+			return []byte(`{"name": "Alice"}`), nil
+		case "/themisdb.v1.ThemisDB/Put":
+			assert.Equal(t, []string{"tx-1"}, md.Get("x-transaction-id"))
+			assert.Equal(t, map[string]interface{}{"name": "Bob"}, fields["document"])
+			return nil, nil
+		case "/themisdb.v1.ThemisDB/Query":
+			assert.Equal(t, "FOR u IN users RETURN u", fields["query"])
+			return []byte(`{"data": ["a", "b"]}`), nil
+		}
+		return nil, status.Error(codes.Unimplemented, method)
+	})
+
+	client := NewClient(Config{Endpoints: []string{endpoint}, Protocol: ProtocolGRPC})
+	defer client.Close()
+	ctx := context.Background()
+
+	var user map[string]interface{}
+	require.NoError(t, client.Get(ctx, "relational", "users", "1", &user))
+	assert.Equal(t, "Alice", user["name"])
+
+	err := client.Get(ctx, "relational", "users", "missing", &user)
+	assert.EqualError(t, err, "request failed with status 404: entity not found")
+
+	tx := &Transaction{client: client, transactionID: "tx-1", active: true}
+	require.NoError(t, tx.Put(ctx, "relational", "users", "2", map[string]string{"name": "Bob"}))
+
+	var rows []string
+	require.NoError(t, client.Query(ctx, "FOR u IN users RETURN u", &rows))
+	assert.Equal(t, []string{"a", "b"}, rows)
+}
+
+func TestGRPCTransport_Int64(t *testing.T) {
+	type counter struct {
+		ID    int64  `json:"id"`
+		Count uint64 `json:"count"`
+	}
+	var stored []byte
+	endpoint := startGRPCServer(t, func(method string, md metadata.MD, args []byte) ([]byte, error) {
+		var fields struct {
+			Document json.RawMessage `json:"document"`
+		}
+		require.NoError(t, json.Unmarshal(args, &fields))
+		if method == "/themisdb.v1.ThemisDB/Put" {
+			stored = fields.Document
+			return nil, nil
+		}
+		return stored, nil
+	})
+
+	client := NewClient(Config{Endpoints: []string{endpoint}, Protocol: ProtocolGRPC})
+	defer client.Close()
+	ctx := context.Background()
+
+	// 2^53 + 1 and 2^64 - 1 are not representable as float64
+	in := counter{ID: 1<<53 + 1, Count: 1<<64 - 1}
+	require.NoError(t, client.Put(ctx, "relational", "counters", "c1", in))
+	assert.Equal(t, `{"id":9007199254740993,"count":18446744073709551615}`, string(stored))
+
+	var out counter
+	require.NoError(t, client.Get(ctx, "relational", "counters", "c1", &out))
+	assert.Equal(t, in, out)
+}
+
+func TestGRPCCall_Unsupported(t *testing.T) {
+	_, _, err := grpcCall(&Request{Method: "GET", Path: "/admin/stats"})
+	assert.ErrorIs(t, err, ErrUnsupportedByTransport)
+}
+
+func TestGRPCTarget(t *testing.T) {
+	target, secure := grpcTarget("https://db.example.com:443")
+	assert.Equal(t, "db.example.com:443", target)
+	assert.True(t, secure)
+
+	target, secure = grpcTarget("grpc://localhost:9090")
+	assert.Equal(t, "localhost:9090", target)
+	assert.False(t, secure)
+}
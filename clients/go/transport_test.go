@@ -0,0 +1,68 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubTransport struct {
+	requests []*Request
+	response *Response
+	closed   bool
+}
+
+func (s *stubTransport) RoundTrip(ctx context.Context, endpoint string, req *Request) (*Response, error) {
+	s.requests = append(s.requests, req)
+	return s.response, nil
+}
+
+func (s *stubTransport) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestHTTPTransport_RoundTrip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GET", r.Method)
+		assert.Equal(t, "/api/relational/users/123", r.URL.Path)
+		assert.Equal(t, "tx-1", r.Header.Get("X-Transaction-Id"))
+		w.Header().Set("ETag", "v1")
+		w.Write([]byte(`{"name":"Alice"}`))
+	}))
+	defer server.Close()
+
+	transport := newHTTPTransport(http.DefaultClient)
+	resp, err := transport.RoundTrip(context.Background(), server.URL, &Request{
+		Method: "GET",
+		Path:   "/api/relational/users/123",
+		Header: map[string]string{"X-Transaction-Id": "tx-1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "v1", resp.Header.Get("ETag"))
+	assert.JSONEq(t, `{"name":"Alice"}`, string(resp.Body))
+}
+
+func TestClient_CustomTransport(t *testing.T) {
+	stub := &stubTransport{response: &Response{StatusCode: http.StatusOK, Body: []byte(`{"name":"Alice"}`)}}
+	client := NewClient(Config{Transport: stub})
+
+	var result map[string]interface{}
+	err := client.Get(context.Background(), "relational", "users", "123", &result)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", result["name"])
+	require.Len(t, stub.requests, 1)
+	assert.Equal(t, "/api/relational/users/123", stub.requests[0].Path)
+
+	stub.response = &Response{StatusCode: http.StatusNotFound, Body: []byte("not found")}
+	err = client.Get(context.Background(), "relational", "users", "404", &result)
+	assert.EqualError(t, err, "request failed with status 404: not found")
+
+	require.NoError(t, client.Close())
+	assert.True(t, stub.closed)
+}
@@ -0,0 +1,252 @@
+package themisdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// decisionsCollection stores the decisions of the DecisionLog returned by Client.DecisionLog
+const decisionsCollection = "_decisions"
+
+// Decision is the outcome a Coordinator decided for a distributed transaction
+type Decision string
+
+const (
+	// DecisionCommit: all participants prepared and must commit
+	DecisionCommit Decision = "commit"
+	// DecisionAbort: a participant failed to prepare and all must roll back
+	DecisionAbort Decision = "abort"
+)
+
+// Participant takes part in a two-phase commit. *Transaction implements it; adapters
+// let other resource managers, e.g. an XA datasource, join the same Coordinator.
+type Participant interface {
+	// Prepare makes the participant's changes durable without applying them. After a
+	// successful Prepare the participant must be able to commit, even after a crash.
+	Prepare(ctx context.Context) error
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// DecisionLog durably records the decisions of a Coordinator, so that a coordinator
+// restarted after a crash can finish in-doubt transactions with Coordinator.Recover
+type DecisionLog interface {
+	// Record stores the decision for the transaction id. It must be durable when Record returns.
+	Record(ctx context.Context, id string, decision Decision) error
+	// Lookup returns the decision recorded for id, empty if there is none
+	Lookup(ctx context.Context, id string) (Decision, error)
+	// Forget removes the decision once all participants have applied it
+	Forget(ctx context.Context, id string) error
+}
+
+// CoordinatorError reports a distributed transaction that did not commit on all participants
+type CoordinatorError struct {
+	// ID identifies the distributed transaction
+	ID string
+	// Decision is DecisionAbort if the transaction was rolled back, DecisionCommit if it
+	// was decided to commit but some participants have not committed yet
+	Decision Decision
+	// Participant is the index of the first participant that failed, -1 if recording
+	// the decision failed
+	Participant int
+	// Err is the error of the failed participant or decision log
+	Err error
+}
+
+// Error implements error
+func (e *CoordinatorError) Error() string {
+	if e.Decision == DecisionCommit {
+		return fmt.Sprintf("transaction %s is incomplete: participant %d failed to commit: %v", e.ID, e.Participant, e.Err)
+	}
+	if e.Participant < 0 {
+		return fmt.Sprintf("transaction %s aborted: failed to record decision: %v", e.ID, e.Err)
+	}
+	return fmt.Sprintf("transaction %s aborted: participant %d failed to prepare: %v", e.ID, e.Participant, e.Err)
+}
+
+// Unwrap returns the error of the failed participant or decision log
+func (e *CoordinatorError) Unwrap() error {
+	return e.Err
+}
+
+// Is allows matching with errors.Is(err, ErrTransactionAborted) and
+// errors.Is(err, ErrCommitIncomplete)
+func (e *CoordinatorError) Is(target error) bool {
+	if e.Decision == DecisionCommit {
+		return target == ErrCommitIncomplete
+	}
+	return target == ErrTransactionAborted
+}
+
+// Coordinator commits transactions spanning several participants, e.g. ThemisDB
+// clusters, atomically with the two-phase commit protocol: all participants are
+// prepared, the decision is recorded in the DecisionLog, and then applied to all
+// participants. A transaction without a recorded decision is presumed aborted.
+type Coordinator struct {
+	log DecisionLog
+}
+
+// NewCoordinator returns a Coordinator recording its decisions in log. Without a log,
+// a crash between recording and applying a commit decision cannot be recovered.
+func NewCoordinator(log DecisionLog) *Coordinator {
+	return &Coordinator{log: log}
+}
+
+// Commit prepares all participants in order and commits them if all prepared. If a
+// participant fails to prepare, all participants are rolled back and Commit returns a
+// *CoordinatorError matching ErrTransactionAborted. If a participant fails to commit
+// after the commit decision was recorded, the error matches ErrCommitIncomplete and
+// Recover must be called with the same id to finish the transaction.
+//
+//	coord := themisdb.NewCoordinator(ledger.DecisionLog())
+//	err := coord.Commit(ctx, "transfer-"+id, ordersTx, ledgerTx)
+func (co *Coordinator) Commit(ctx context.Context, id string, participants ...Participant) error {
+	for i, p := range participants {
+		if err := p.Prepare(ctx); err != nil {
+			rollbackAll(ctx, participants)
+			return &CoordinatorError{ID: id, Decision: DecisionAbort, Participant: i, Err: err}
+		}
+	}
+
+	if co.log != nil {
+		if err := co.log.Record(ctx, id, DecisionCommit); err != nil {
+			rollbackAll(ctx, participants)
+			return &CoordinatorError{ID: id, Decision: DecisionAbort, Participant: -1, Err: err}
+		}
+	}
+
+	var failed *CoordinatorError
+	for i, p := range participants {
+		if err := p.Commit(ctx); err != nil && failed == nil {
+			failed = &CoordinatorError{ID: id, Decision: DecisionCommit, Participant: i, Err: err}
+		}
+	}
+	if failed != nil {
+		return failed
+	}
+	return co.forget(ctx, id)
+}
+
+// Recover finishes the transaction id after a crash or a failed Commit: it commits all
+// participants if a commit decision was recorded and rolls them back otherwise.
+// Participants that already finished are skipped. Themis participants of another
+// process are resumed with Client.PreparedTransaction.
+func (co *Coordinator) Recover(ctx context.Context, id string, participants ...Participant) error {
+	decision := DecisionAbort
+	if co.log != nil {
+		recorded, err := co.log.Lookup(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to look up decision of transaction %s: %w", id, err)
+		}
+		if recorded != "" {
+			decision = recorded
+		}
+	}
+
+	var errs []error
+	for i, p := range participants {
+		var err error
+		if decision == DecisionCommit {
+			err = p.Commit(ctx)
+		} else {
+			err = p.Rollback(ctx)
+		}
+		if err != nil && !finished(err) {
+			errs = append(errs, fmt.Errorf("participant %d: %w", i, err))
+		}
+	}
+	if len(errs) > 0 {
+		return &CoordinatorError{ID: id, Decision: decision, Participant: -1, Err: errors.Join(errs...)}
+	}
+	return co.forget(ctx, id)
+}
+
+// forget removes the decision of a completed transaction from the log
+func (co *Coordinator) forget(ctx context.Context, id string) error {
+	if co.log == nil {
+		return nil
+	}
+	if err := co.log.Forget(ctx, id); err != nil {
+		return fmt.Errorf("failed to forget decision of transaction %s: %w", id, err)
+	}
+	return nil
+}
+
+// rollbackAll rolls back participants, ignoring errors; participants that cannot roll
+// back are left to the server's transaction timeout
+func rollbackAll(ctx context.Context, participants []Participant) {
+	for _, p := range participants {
+		p.Rollback(ctx)
+	}
+}
+
+// finished reports whether err means the participant has already ended its transaction
+func finished(err error) bool {
+	return errors.Is(err, ErrTransactionNotActive) || errors.Is(err, ErrNotFound)
+}
+
+// Prepare runs the first phase of a two-phase commit: the server makes the transaction's
+// changes durable and guarantees that a later Commit succeeds. A prepared transaction
+// accepts no further operations, only Commit and Rollback.
+func (tx *Transaction) Prepare(ctx context.Context) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if !tx.active {
+		return ErrTransactionNotActive
+	}
+	if tx.prepared {
+		return nil
+	}
+	ctx = pinTransaction(ctx, tx)
+
+	reqBody := map[string]interface{}{
+		"transaction_id": tx.transactionID,
+	}
+
+	if err := tx.client.request(ctx, "POST", "/transaction/prepare", reqBody, nil, nil); err != nil {
+		return fmt.Errorf("failed to prepare transaction: %w", err)
+	}
+
+	tx.prepared = true
+	return nil
+}
+
+// PreparedTransaction returns the prepared transaction id, e.g. one prepared by a
+// coordinator that crashed, so that Coordinator.Recover can commit or roll it back
+func (c *Client) PreparedTransaction(id string) *Transaction {
+	return &Transaction{client: c, transactionID: id, active: true, prepared: true}
+}
+
+// DecisionLog returns a DecisionLog that stores decisions in the collection _decisions
+func (c *Client) DecisionLog() DecisionLog {
+	return &decisionLog{client: c}
+}
+
+// decisionLog is the DecisionLog stored in a ThemisDB collection
+type decisionLog struct {
+	client *Client
+}
+
+func (l *decisionLog) Record(ctx context.Context, id string, decision Decision) error {
+	return l.client.saveCheckpoint(ctx, decisionsCollection, id, map[string]interface{}{"decision": decision})
+}
+
+func (l *decisionLog) Lookup(ctx context.Context, id string) (Decision, error) {
+	var state struct {
+		Decision Decision `json:"decision"`
+	}
+	if err := l.client.loadCheckpoint(ctx, decisionsCollection, id, &state); err != nil {
+		return "", err
+	}
+	return state.Decision, nil
+}
+
+func (l *decisionLog) Forget(ctx context.Context, id string) error {
+	err := l.client.Delete(ctx, ModelRelational, decisionsCollection, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
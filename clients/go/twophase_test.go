@@ -0,0 +1,162 @@
+package themisdb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubParticipant is a Participant that records its calls and fails where configured
+type stubParticipant struct {
+	name                  string
+	calls                 *[]string
+	prepareErr, commitErr error
+}
+
+func (p *stubParticipant) Prepare(context.Context) error {
+	*p.calls = append(*p.calls, "prepare "+p.name)
+	return p.prepareErr
+}
+
+func (p *stubParticipant) Commit(context.Context) error {
+	*p.calls = append(*p.calls, "commit "+p.name)
+	return p.commitErr
+}
+
+func (p *stubParticipant) Rollback(context.Context) error {
+	*p.calls = append(*p.calls, "rollback "+p.name)
+	return nil
+}
+
+// mapDecisionLog is an in-memory DecisionLog
+type mapDecisionLog struct {
+	mu        sync.Mutex
+	decisions map[string]Decision
+	err       error
+}
+
+func (l *mapDecisionLog) Record(_ context.Context, id string, d Decision) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return l.err
+	}
+	l.decisions[id] = d
+	return nil
+}
+
+func (l *mapDecisionLog) Lookup(_ context.Context, id string) (Decision, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.decisions[id], nil
+}
+
+func (l *mapDecisionLog) Forget(_ context.Context, id string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.decisions, id)
+	return nil
+}
+
+func TestCoordinator_Commit(t *testing.T) {
+	orders, ordersStore := newMemoryClient(t)
+	ledger, ledgerStore := newMemoryClient(t)
+	ctx := context.Background()
+
+	tx1, err := orders.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	tx2, err := ledger.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx1.Put(ctx, "relational", "orders", "o1", map[string]int{"amount": 5}))
+	require.NoError(t, tx2.Put(ctx, "relational", "entries", "e1", map[string]int{"amount": -5}))
+
+	log := ledger.DecisionLog()
+	require.NoError(t, NewCoordinator(log).Commit(ctx, "transfer-1", tx1, tx2))
+	assert.Equal(t, 1, ordersStore.prepares)
+	assert.Equal(t, 1, ledgerStore.prepares)
+	assert.Equal(t, 1, ordersStore.commits)
+	assert.Equal(t, 1, ledgerStore.commits)
+	assert.False(t, tx1.IsActive())
+
+	decision, err := log.Lookup(ctx, "transfer-1")
+	require.NoError(t, err)
+	assert.Empty(t, decision, "the decision is forgotten once applied")
+}
+
+func TestCoordinator_PrepareFails(t *testing.T) {
+	var calls []string
+	log := &mapDecisionLog{decisions: map[string]Decision{}}
+	a := &stubParticipant{name: "a", calls: &calls}
+	b := &stubParticipant{name: "b", calls: &calls, prepareErr: errors.New("disk full")}
+
+	err := NewCoordinator(log).Commit(context.Background(), "t1", a, b)
+	assert.ErrorIs(t, err, ErrTransactionAborted)
+	assert.ErrorContains(t, err, "disk full")
+	var coordErr *CoordinatorError
+	require.ErrorAs(t, err, &coordErr)
+	assert.Equal(t, 1, coordErr.Participant)
+	assert.Equal(t, []string{"prepare a", "prepare b", "rollback a", "rollback b"}, calls)
+	assert.Empty(t, log.decisions, "aborts are presumed, not recorded")
+}
+
+func TestCoordinator_RecordFails(t *testing.T) {
+	var calls []string
+	log := &mapDecisionLog{decisions: map[string]Decision{}, err: errors.New("log unavailable")}
+	a := &stubParticipant{name: "a", calls: &calls}
+
+	err := NewCoordinator(log).Commit(context.Background(), "t1", a)
+	assert.ErrorIs(t, err, ErrTransactionAborted)
+	var coordErr *CoordinatorError
+	require.ErrorAs(t, err, &coordErr)
+	assert.Equal(t, -1, coordErr.Participant)
+	assert.Equal(t, []string{"prepare a", "rollback a"}, calls)
+}
+
+func TestCoordinator_Recover(t *testing.T) {
+	var calls []string
+	log := &mapDecisionLog{decisions: map[string]Decision{}}
+	a := &stubParticipant{name: "a", calls: &calls}
+	b := &stubParticipant{name: "b", calls: &calls, commitErr: ErrUnavailable}
+	coord := NewCoordinator(log)
+	ctx := context.Background()
+
+	err := coord.Commit(ctx, "t1", a, b)
+	assert.ErrorIs(t, err, ErrCommitIncomplete)
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, DecisionCommit, log.decisions["t1"])
+
+	calls = nil
+	a.commitErr = ErrTransactionNotActive
+	assert.ErrorIs(t, coord.Recover(ctx, "t1", a, b), ErrCommitIncomplete)
+	b.commitErr = nil
+	require.NoError(t, coord.Recover(ctx, "t1", a, b))
+	assert.Equal(t, []string{"commit a", "commit b", "commit a", "commit b"}, calls)
+	assert.Empty(t, log.decisions)
+
+	calls = nil
+	require.NoError(t, coord.Recover(ctx, "t2", a, b))
+	assert.Equal(t, []string{"rollback a", "rollback b"}, calls, "undecided transactions are rolled back")
+}
+
+func TestTransaction_Prepare(t *testing.T) {
+	client, store := newMemoryClient(t)
+	ctx := context.Background()
+
+	tx, err := client.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.Prepare(ctx))
+	require.NoError(t, tx.Prepare(ctx))
+	assert.Equal(t, 1, store.prepares)
+	assert.ErrorIs(t, tx.Put(ctx, "relational", "orders", "o1", map[string]int{}), ErrTransactionPrepared)
+	require.NoError(t, tx.Commit(ctx))
+	assert.ErrorIs(t, tx.Prepare(ctx), ErrTransactionNotActive)
+
+	resumed := client.PreparedTransaction("tx-1")
+	assert.True(t, resumed.IsActive())
+	require.NoError(t, resumed.Rollback(ctx))
+	assert.Equal(t, 1, store.rollbacks)
+}
@@ -0,0 +1,50 @@
+package themisdb
+
+import "context"
+
+// txKey is the context key of ContextWithTx
+type txKey struct{}
+
+// ContextWithTx returns a context that makes Client operations take part in tx. Get,
+// GetWithOptions, GetMany, Put, Create, Upsert, Patch, Delete, GetEntity, PutEntity,
+// DeleteEntity, Query, and QueryWithOptions called with the context run within tx, so
+// code deep in the call stack joins a transaction without taking a *Transaction
+// parameter. Clients of other clusters than the one tx was begun on ignore it.
+//
+//	tx, _ := client.BeginTransaction(ctx, nil)
+//	ctx = themisdb.ContextWithTx(ctx, tx)
+//	if err := billing.Charge(ctx, client, order); err != nil { // calls client.Put(ctx, ...)
+//	    tx.Rollback(ctx)
+//	    return err
+//	}
+//	return tx.Commit(ctx)
+func ContextWithTx(ctx context.Context, tx *Transaction) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext returns the transaction attached by ContextWithTx
+func TxFromContext(ctx context.Context) (*Transaction, bool) {
+	tx, ok := ctx.Value(txKey{}).(*Transaction)
+	return tx, ok && tx != nil
+}
+
+// contextTx returns the transaction of ctx if it was begun on c's cluster, together
+// with ctx scoped to c's namespace, so namespaced clients keep their namespace
+func (c *Client) contextTx(ctx context.Context) (context.Context, *Transaction) {
+	tx, ok := TxFromContext(ctx)
+	if !ok || rootClient(tx.client) != rootClient(c) {
+		return ctx, nil
+	}
+	if _, ok := NamespaceFromContext(ctx); !ok && c.namespace != tx.client.namespace {
+		ctx = WithNamespace(ctx, c.namespace)
+	}
+	return ctx, tx
+}
+
+// rootClient returns the client c was derived from, or c itself
+func rootClient(c *Client) *Client {
+	if c.root != nil {
+		return c.root
+	}
+	return c
+}
@@ -0,0 +1,69 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextWithTx(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path+" tx="+r.Header.Get("X-Transaction-Id")+" ns="+r.Header.Get(headerNamespace))
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/transaction/begin":
+			w.Write([]byte(`{"transaction_id":"tx-1"}`))
+		case "/api/query":
+			w.Write([]byte(`{"data":[]}`))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	})
+	ctx := context.Background()
+	tx, err := client.BeginTransaction(ctx, nil)
+	require.NoError(t, err)
+
+	_, ok := TxFromContext(ctx)
+	assert.False(t, ok)
+	txCtx := ContextWithTx(ctx, tx)
+	got, ok := TxFromContext(txCtx)
+	require.True(t, ok)
+	assert.Same(t, tx, got)
+
+	// Library code that only knows the client joins the transaction
+	charge := func(ctx context.Context, c ThemisClient) error {
+		if err := c.Put(ctx, "relational", "invoices", "i1", map[string]int{"total": 5}); err != nil {
+			return err
+		}
+		var rows []interface{}
+		return c.Query(ctx, "FOR i IN invoices RETURN i", &rows)
+	}
+	require.NoError(t, charge(txCtx, client))
+	require.NoError(t, client.Namespace("acme").Delete(txCtx, "relational", "invoices", "i0"))
+	require.NoError(t, client.Get(ctx, "relational", "invoices", "i1", nil))
+
+	other := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("X-Transaction-Id"), "clients of other clusters ignore the transaction")
+		w.Write([]byte(`{}`))
+	})
+	require.NoError(t, other.Put(txCtx, "relational", "audit", "a1", map[string]int{"n": 1}))
+
+	require.NoError(t, tx.Commit(ctx))
+	assert.ErrorIs(t, client.Put(txCtx, "relational", "invoices", "i2", nil), ErrTransactionNotActive)
+
+	assert.Equal(t, []string{
+		"POST /transaction/begin tx= ns=",
+		"PUT /api/relational/invoices/i1 tx=tx-1 ns=",
+		"POST /api/query tx=tx-1 ns=",
+		"DELETE /api/relational/invoices/i0 tx=tx-1 ns=acme",
+		"GET /api/relational/invoices/i1 tx= ns=",
+		"POST /transaction/commit tx= ns=",
+	}, requests)
+}
@@ -0,0 +1,181 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NodeStatus is the state of a cluster node relevant to rolling upgrades
+type NodeStatus struct {
+	ID      string `json:"id"`
+	Role    string `json:"role"`
+	Version string `json:"version"`
+	// Draining reports that the node rejects new requests and transactions
+	Draining bool `json:"draining"`
+	// ActiveRequests and OpenTransactions count work still in flight on the node
+	ActiveRequests   int `json:"active_requests"`
+	OpenTransactions int `json:"open_transactions"`
+	// AppliedSequence is the last replication log entry applied by the node,
+	// LeaderSequence the last one written by the leader
+	AppliedSequence uint64 `json:"applied_sequence"`
+	LeaderSequence  uint64 `json:"leader_sequence"`
+	// ReplicationLag is the age of the oldest entry not yet applied by the node
+	ReplicationLag time.Duration `json:"-"`
+}
+
+// UnmarshalJSON decodes replication_lag_ms into ReplicationLag
+func (s *NodeStatus) UnmarshalJSON(data []byte) error {
+	type plain NodeStatus
+	aux := struct {
+		*plain
+		ReplicationLagMs int64 `json:"replication_lag_ms"`
+	}{plain: (*plain)(s)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	s.ReplicationLag = time.Duration(aux.ReplicationLagMs) * time.Millisecond
+	return nil
+}
+
+// Drained reports whether the node is draining and has no work in flight
+func (s *NodeStatus) Drained() bool {
+	return s.Draining && s.ActiveRequests == 0 && s.OpenTransactions == 0
+}
+
+// CaughtUp reports whether the node has applied the leader's log up to a lag of maxLag
+func (s *NodeStatus) CaughtUp(maxLag time.Duration) bool {
+	return s.AppliedSequence >= s.LeaderSequence || s.ReplicationLag <= maxLag
+}
+
+// ClusterMembers returns the members of the cluster as reported by the active endpoint
+func (a *Admin) ClusterMembers(ctx context.Context) ([]ClusterMember, error) {
+	var result struct {
+		Members []ClusterMember `json:"members"`
+	}
+	if err := a.client.request(ctx, "GET", "/cluster/members", nil, &result, nil); err != nil {
+		return nil, fmt.Errorf("failed to list cluster members: %w", err)
+	}
+	return result.Members, nil
+}
+
+// NodeStatus returns the upgrade-relevant state of a cluster node
+func (a *Admin) NodeStatus(ctx context.Context, nodeID string) (*NodeStatus, error) {
+	if err := validateName("node", nodeID); err != nil {
+		return nil, err
+	}
+	var status NodeStatus
+	if err := a.client.request(ctx, "GET", joinPath("/admin/nodes", nodeID)+"/status", nil, &status, nil); err != nil {
+		return nil, fmt.Errorf("failed to get status of node %s: %w", nodeID, err)
+	}
+	return &status, nil
+}
+
+// DrainNode puts a node into drain mode: it finishes in-flight requests and
+// transactions but rejects new ones, and a leader hands over leadership first
+func (a *Admin) DrainNode(ctx context.Context, nodeID string) error {
+	if err := validateName("node", nodeID); err != nil {
+		return err
+	}
+	if err := a.client.request(ctx, "POST", joinPath("/admin/nodes", nodeID)+"/drain", nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to drain node %s: %w", nodeID, err)
+	}
+	return nil
+}
+
+// UndrainNode takes a node out of drain mode so it serves requests again
+func (a *Admin) UndrainNode(ctx context.Context, nodeID string) error {
+	if err := validateName("node", nodeID); err != nil {
+		return err
+	}
+	if err := a.client.request(ctx, "DELETE", joinPath("/admin/nodes", nodeID)+"/drain", nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to undrain node %s: %w", nodeID, err)
+	}
+	return nil
+}
+
+// WaitForDrain polls a draining node every interval (default: 1s) until no requests
+// or transactions are in flight, and returns its final status
+func (a *Admin) WaitForDrain(ctx context.Context, nodeID string, interval time.Duration) (*NodeStatus, error) {
+	return a.waitForNode(ctx, nodeID, interval, func(s *NodeStatus) (bool, error) {
+		if !s.Draining {
+			return false, fmt.Errorf("node %s is not draining", nodeID)
+		}
+		return s.Drained(), nil
+	})
+}
+
+// WaitForCatchUp polls a node every interval (default: 1s) until its replication lag
+// is at most maxLag, e.g. after restarting it with a new version
+func (a *Admin) WaitForCatchUp(ctx context.Context, nodeID string, maxLag, interval time.Duration) (*NodeStatus, error) {
+	return a.waitForNode(ctx, nodeID, interval, func(s *NodeStatus) (bool, error) {
+		return s.CaughtUp(maxLag), nil
+	})
+}
+
+// waitForNode polls the status of a node until done reports true. Status requests that
+// fail, e.g. while the node restarts, are retried until ctx is done.
+func (a *Admin) waitForNode(ctx context.Context, nodeID string, interval time.Duration, done func(*NodeStatus) (bool, error)) (*NodeStatus, error) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	var lastErr error
+	for {
+		status, err := a.NodeStatus(ctx, nodeID)
+		if err == nil {
+			ok, err := done(status)
+			if err != nil || ok {
+				return status, err
+			}
+		} else if ctx.Err() == nil {
+			lastErr = err
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			if lastErr != nil {
+				return nil, fmt.Errorf("%w (last error: %v)", ctx.Err(), lastErr)
+			}
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// VerifyVersions returns the server version of every cluster member and fails with
+// ErrVersionMismatch unless all run the same version, which must equal expected if
+// it is not empty. Run it before and after a rolling upgrade.
+func (a *Admin) VerifyVersions(ctx context.Context, expected string) (map[string]string, error) {
+	members, err := a.ClusterMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string]string, len(members))
+	counts := map[string][]string{}
+	for _, m := range members {
+		status, err := a.NodeStatus(ctx, m.ID)
+		if err != nil {
+			return nil, err
+		}
+		versions[m.ID] = status.Version
+		counts[status.Version] = append(counts[status.Version], m.ID)
+	}
+
+	if len(counts) > 1 || (expected != "" && len(counts) == 1 && counts[expected] == nil) {
+		var parts []string
+		for version, nodes := range counts {
+			sort.Strings(nodes)
+			parts = append(parts, fmt.Sprintf("%s on %s", version, strings.Join(nodes, ", ")))
+		}
+		sort.Strings(parts)
+		if expected != "" {
+			return versions, fmt.Errorf("%w: expected %s, found %s", ErrVersionMismatch, expected, strings.Join(parts, "; "))
+		}
+		return versions, fmt.Errorf("%w: found %s", ErrVersionMismatch, strings.Join(parts, "; "))
+	}
+	return versions, nil
+}
@@ -0,0 +1,134 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// upgradeServer simulates the node status and drain endpoints of a cluster
+type upgradeServer struct {
+	mu    sync.Mutex
+	nodes map[string]map[string]interface{}
+	polls int
+}
+
+func (s *upgradeServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path == "/cluster/members" {
+		var members []ClusterMember
+		for _, id := range []string{"n1", "n2", "n3"} {
+			members = append(members, ClusterMember{ID: id, Endpoint: "http://" + id, Role: s.nodes[id]["role"].(string)})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"members": members})
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/admin/nodes/"), "/")
+	node, ok := s.nodes[parts[0]]
+	if !ok || len(parts) != 2 {
+		http.Error(w, "unknown node", http.StatusNotFound)
+		return
+	}
+	switch {
+	case parts[1] == "drain" && r.Method == "POST":
+		node["draining"] = true
+		w.WriteHeader(http.StatusNoContent)
+	case parts[1] == "drain" && r.Method == "DELETE":
+		node["draining"] = false
+		w.WriteHeader(http.StatusNoContent)
+	case parts[1] == "status":
+		s.polls++
+		// In-flight work completes and replication catches up over a few polls
+		if n := node["active_requests"].(int); n > 0 && node["draining"] == true {
+			node["active_requests"] = n - 1
+		}
+		if lag := node["replication_lag_ms"].(int); lag > 0 {
+			node["replication_lag_ms"] = lag / 10
+			node["applied_sequence"] = node["applied_sequence"].(int) + 5
+		}
+		node["id"] = parts[0]
+		json.NewEncoder(w).Encode(node)
+	}
+}
+
+func newUpgradeServer() *upgradeServer {
+	node := func(role, version string) map[string]interface{} {
+		return map[string]interface{}{
+			"role": role, "version": version, "draining": false, "active_requests": 3, "open_transactions": 0,
+			"applied_sequence": 100, "leader_sequence": 100, "replication_lag_ms": 0,
+		}
+	}
+	return &upgradeServer{nodes: map[string]map[string]interface{}{
+		"n1": node(RoleLeader, "1.4.0"),
+		"n2": node(RoleFollower, "1.4.0"),
+		"n3": node(RoleFollower, "1.4.0"),
+	}}
+}
+
+func TestAdmin_RollingUpgrade(t *testing.T) {
+	server := newUpgradeServer()
+	admin := newTestClient(t, server.serveHTTP).Admin()
+	ctx := context.Background()
+
+	versions, err := admin.VerifyVersions(ctx, "1.4.0")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"n1": "1.4.0", "n2": "1.4.0", "n3": "1.4.0"}, versions)
+
+	_, err = admin.WaitForDrain(ctx, "n2", time.Millisecond)
+	assert.ErrorContains(t, err, "not draining")
+
+	require.NoError(t, admin.DrainNode(ctx, "n2"))
+	status, err := admin.WaitForDrain(ctx, "n2", time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, status.Drained())
+
+	// The node restarts with the new version and is behind the leader
+	server.mu.Lock()
+	server.nodes["n2"]["version"] = "1.5.0"
+	server.nodes["n2"]["applied_sequence"] = 80
+	server.nodes["n2"]["replication_lag_ms"] = 5000
+	server.mu.Unlock()
+
+	_, err = admin.VerifyVersions(ctx, "")
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+	assert.ErrorContains(t, err, "1.4.0 on n1, n3; 1.5.0 on n2")
+
+	status, err = admin.WaitForCatchUp(ctx, "n2", 100*time.Millisecond, time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, 50*time.Millisecond, status.ReplicationLag)
+	assert.Equal(t, uint64(90), status.AppliedSequence)
+	require.NoError(t, admin.UndrainNode(ctx, "n2"))
+
+	server.mu.Lock()
+	server.nodes["n1"]["version"] = "1.5.0"
+	server.nodes["n3"]["version"] = "1.5.0"
+	server.mu.Unlock()
+	_, err = admin.VerifyVersions(ctx, "1.5.0")
+	require.NoError(t, err)
+	_, err = admin.VerifyVersions(ctx, "1.6.0")
+	assert.ErrorContains(t, err, "expected 1.6.0, found 1.5.0 on n1, n2, n3")
+}
+
+func TestAdmin_WaitForNodeTimeout(t *testing.T) {
+	server := newUpgradeServer()
+	admin := newTestClient(t, server.serveHTTP).Admin()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := admin.WaitForCatchUp(ctx, "n9", time.Second, time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "unknown node")
+
+	_, err = admin.NodeStatus(context.Background(), "")
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
@@ -0,0 +1,85 @@
+package themisdb
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Create inserts a new entity and fails with ErrAlreadyExists if the UUID is taken
+func (c *Client) Create(ctx context.Context, model, collection, uuid string, data interface{}) error {
+	if ctx, tx := c.contextTx(ctx); tx != nil {
+		return tx.Create(ctx, model, collection, uuid, data)
+	}
+	return c.create(ctx, model, collection, uuid, data, nil)
+}
+
+// Upsert merges data into an existing entity or creates it if missing.
+// Fields absent from data are kept, fields set to null are removed (RFC 7386).
+// created reports whether the entity did not exist before.
+func (c *Client) Upsert(ctx context.Context, model, collection, uuid string, data interface{}) (created bool, err error) {
+	if ctx, tx := c.contextTx(ctx); tx != nil {
+		return tx.Upsert(ctx, model, collection, uuid, data)
+	}
+	return c.upsert(ctx, model, collection, uuid, data, nil)
+}
+
+// create sends a conditional PUT that only succeeds if the entity does not exist
+func (c *Client) create(ctx context.Context, model, collection, uuid string, data interface{}, headers map[string]string) error {
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return err
+	}
+	if err := c.validateDocument(model, collection, data); err != nil {
+		return err
+	}
+
+	h := map[string]string{"If-None-Match": "*"}
+	for key, value := range headers {
+		h[key] = value
+	}
+	resp, err := c.send(ctx, "PUT", entityPath(model, collection, uuid), data, h)
+	if resp != nil && (resp.StatusCode == http.StatusPreconditionFailed || resp.StatusCode == http.StatusConflict) {
+		return fmt.Errorf("%w: %s/%s/%s", ErrAlreadyExists, model, collection, uuid)
+	}
+	return err
+}
+
+// upsert sends a merge patch that creates the entity if it does not exist
+func (c *Client) upsert(ctx context.Context, model, collection, uuid string, data interface{}, headers map[string]string) (bool, error) {
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return false, err
+	}
+	if err := c.validateEnums(model, collection, data); err != nil {
+		return false, err
+	}
+
+	h := map[string]string{"Content-Type": ContentTypeMergePatch}
+	for key, value := range headers {
+		h[key] = value
+	}
+	resp, err := c.send(ctx, "PATCH", entityPath(model, collection, uuid)+"?upsert=true", data, h)
+	if err != nil {
+		return false, err
+	}
+	return resp.StatusCode == http.StatusCreated, nil
+}
+
+// Create inserts a new entity within the transaction and fails with ErrAlreadyExists if the UUID is taken
+func (tx *Transaction) Create(ctx context.Context, model, collection, uuid string, data interface{}) error {
+	ctx, headers, release, err := tx.acquire(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return tx.client.create(ctx, model, collection, uuid, data, headers)
+}
+
+// Upsert merges data into an entity within the transaction or creates it if missing
+func (tx *Transaction) Upsert(ctx context.Context, model, collection, uuid string, data interface{}) (bool, error) {
+	ctx, headers, release, err := tx.acquire(ctx, true)
+	if err != nil {
+		return false, err
+	}
+	defer release()
+	return tx.client.upsert(ctx, model, collection, uuid, data, headers)
+}
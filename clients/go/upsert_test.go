@@ -0,0 +1,69 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Create(t *testing.T) {
+	existing := map[string]bool{"1": true}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method)
+		assert.Equal(t, "*", r.Header.Get("If-None-Match"))
+		uuid := r.URL.Path[len("/api/relational/users/"):]
+		if existing[uuid] {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		existing[uuid] = true
+		w.WriteHeader(http.StatusCreated)
+	})
+	ctx := context.Background()
+
+	require.NoError(t, client.Create(ctx, "relational", "users", "2", map[string]string{"name": "Bob"}))
+	assert.ErrorIs(t, client.Create(ctx, "relational", "users", "2", map[string]string{"name": "Bob"}), ErrAlreadyExists)
+
+	tx := &Transaction{client: client, transactionID: "tx-1", active: true}
+	assert.ErrorIs(t, tx.Create(ctx, "relational", "users", "1", map[string]string{"name": "Alice"}), ErrAlreadyExists)
+}
+
+func TestClient_Upsert(t *testing.T) {
+	docs := map[string]map[string]interface{}{}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PATCH", r.Method)
+		assert.Equal(t, "true", r.URL.Query().Get("upsert"))
+		assert.Equal(t, ContentTypeMergePatch, r.Header.Get("Content-Type"))
+		var patch map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&patch))
+
+		uuid := r.URL.Path[len("/api/document/users/"):]
+		doc, ok := docs[uuid]
+		if !ok {
+			doc = map[string]interface{}{}
+			docs[uuid] = doc
+		}
+		for k, v := range patch {
+			doc[k] = v
+		}
+		if ok {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusCreated)
+		}
+	})
+	ctx := context.Background()
+
+	created, err := client.Upsert(ctx, "document", "users", "1", map[string]string{"name": "Alice"})
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	created, err = client.Upsert(ctx, "document", "users", "1", map[string]string{"email": "alice@example.com"})
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, map[string]interface{}{"name": "Alice", "email": "alice@example.com"}, docs["1"])
+}
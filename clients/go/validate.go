@@ -0,0 +1,90 @@
+package themisdb
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// maxNameLength bounds model and collection names
+	maxNameLength = 128
+	// maxKeyLength bounds entity UUIDs in bytes
+	maxKeyLength = 1024
+)
+
+// ValidationError reports an invalid argument detected before a request is sent
+type ValidationError struct {
+	// Field is the name of the invalid argument, e.g. "collection"
+	Field string
+	// Value is the rejected value
+	Value string
+	// Reason describes why the value was rejected
+	Reason string
+}
+
+// Error implements error
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s %q: %s", e.Field, e.Value, e.Reason)
+}
+
+// Unwrap allows matching with errors.Is(err, ErrInvalidInput)
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidInput
+}
+
+// validateName checks a model or collection name: 1-128 characters of
+// letters, digits, '_', '-' and '.', not starting with '-' or '.'
+func validateName(field, value string) error {
+	if value == "" {
+		return &ValidationError{Field: field, Value: value, Reason: "must not be empty"}
+	}
+	if len(value) > maxNameLength {
+		return &ValidationError{Field: field, Value: value, Reason: fmt.Sprintf("must be at most %d characters", maxNameLength)}
+	}
+	if value[0] == '-' || value[0] == '.' {
+		return &ValidationError{Field: field, Value: value, Reason: "must start with a letter, digit, or underscore"}
+	}
+	for _, r := range value {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.') {
+			return &ValidationError{Field: field, Value: value, Reason: fmt.Sprintf("contains invalid character %q (allowed: letters, digits, '_', '-', '.')", r)}
+		}
+	}
+	return nil
+}
+
+// validateKey checks an entity UUID. Keys may contain any printable unicode
+// including slashes, since they are percent-encoded into the URL path.
+func validateKey(field, value string) error {
+	if value == "" {
+		return &ValidationError{Field: field, Value: value, Reason: "must not be empty"}
+	}
+	if len(value) > maxKeyLength {
+		return &ValidationError{Field: field, Value: value, Reason: fmt.Sprintf("must be at most %d bytes", maxKeyLength)}
+	}
+	if !utf8.ValidString(value) {
+		return &ValidationError{Field: field, Value: value, Reason: "must be valid UTF-8"}
+	}
+	for _, r := range value {
+		if unicode.IsControl(r) {
+			return &ValidationError{Field: field, Value: value, Reason: fmt.Sprintf("contains control character %U", r)}
+		}
+	}
+	return nil
+}
+
+// validateCollection checks the model and collection of an operation
+func validateCollection(model, collection string) error {
+	if err := validateName("model", model); err != nil {
+		return err
+	}
+	return validateName("collection", collection)
+}
+
+// validateEntity checks the model, collection, and UUID of an entity operation
+func validateEntity(model, collection, uuid string) error {
+	if err := validateCollection(model, collection); err != nil {
+		return err
+	}
+	return validateKey("uuid", uuid)
+}
@@ -0,0 +1,72 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		valid bool
+	}{
+		{"simple", "users", true},
+		{"with separators", "user_events-v2.archive", true},
+		{"empty", "", false},
+		{"leading dot", ".users", false},
+		{"leading dash", "-users", false},
+		{"slash", "users/admin", false},
+		{"space", "my users", false},
+		{"non-ascii", "benutzer_ä", false},
+		{"too long", strings.Repeat("a", maxNameLength+1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateName("collection", tt.value)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidInput)
+			}
+		})
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	assert.NoError(t, validateKey("uuid", "a/b?c"))
+	assert.NoError(t, validateKey("uuid", "日本"))
+	assert.ErrorIs(t, validateKey("uuid", ""), ErrInvalidInput)
+	assert.ErrorIs(t, validateKey("uuid", "a\nb"), ErrInvalidInput)
+	assert.ErrorIs(t, validateKey("uuid", "\xff"), ErrInvalidInput)
+	assert.ErrorIs(t, validateKey("uuid", strings.Repeat("a", maxKeyLength+1)), ErrInvalidInput)
+}
+
+func TestClient_RejectsInvalidInput(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+	ctx := context.Background()
+
+	err := client.Get(ctx, "relational", "", "1", nil)
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "collection", validationErr.Field)
+	assert.Contains(t, err.Error(), "must not be empty")
+
+	assert.ErrorIs(t, client.Put(ctx, "relational", "users", "", map[string]string{}), ErrInvalidInput)
+	assert.ErrorIs(t, client.Delete(ctx, "rel ational", "users", "1"), ErrInvalidInput)
+
+	_, err = client.GetMany(ctx, "relational", "users", []string{"1", ""}, nil)
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "uuids[1]", validationErr.Field)
+
+	tx := &Transaction{client: client, transactionID: "tx-1", active: true}
+	assert.ErrorIs(t, tx.Get(ctx, "relational", "users/admin", "1", nil), ErrInvalidInput)
+}
@@ -0,0 +1,94 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// VectorQuery describes a k-nearest-neighbor search
+type VectorQuery struct {
+	// Vector is the query embedding
+	Vector []float32
+	// TopK is the number of results to return (default: 10)
+	TopK int
+}
+
+// VectorResult is a single scored vector search hit
+type VectorResult struct {
+	// ID is the UUID of the matching document
+	ID string `json:"pk"`
+	// Distance between the query and the document embedding, lower is closer
+	Distance float64 `json:"distance"`
+	// Score is the similarity of the match, higher is closer, or nil if the server
+	// reports only the distance
+	Score *float64 `json:"score,omitempty"`
+	// Document holds the stored fields if returned by the server
+	Document json.RawMessage `json:"document,omitempty"`
+}
+
+// VectorSearch returns the documents of collection whose embeddings are nearest to q.Vector,
+// ranked by the distance function of the server's vector index
+func (c *Client) VectorSearch(ctx context.Context, collection string, q VectorQuery) ([]VectorResult, error) {
+	if err := validateName("collection", collection); err != nil {
+		return nil, err
+	}
+	if len(q.Vector) == 0 {
+		return nil, fmt.Errorf("vector search requires a query vector")
+	}
+	if err := c.requireFeature(ctx, FeatureVectorSearch); err != nil {
+		return nil, err
+	}
+	if q.TopK <= 0 {
+		q.TopK = 10
+	}
+
+	body := map[string]interface{}{
+		"collection": collection,
+		"vector":     q.Vector,
+		"k":          q.TopK,
+	}
+
+	var response struct {
+		Results []VectorResult `json:"results"`
+	}
+	if err := c.readRequest(ctx, "POST", "/vector/search", body, &response, nil); err != nil {
+		return nil, fmt.Errorf("failed to search vectors: %w", err)
+	}
+	return response.Results, nil
+}
+
+// PutWithVector creates or updates an entity together with its embedding
+func (c *Client) PutWithVector(ctx context.Context, model, collection, uuid string, data interface{}, vector []float32) error {
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return err
+	}
+	if err := c.validateDocument(model, collection, data); err != nil {
+		return err
+	}
+	if err := c.requireFeature(ctx, FeatureVectorSearch); err != nil {
+		return err
+	}
+
+	body := map[string]interface{}{
+		"model":      model,
+		"collection": collection,
+		"items": []map[string]interface{}{{
+			"pk":     uuid,
+			"vector": vector,
+			"fields": data,
+		}},
+	}
+
+	var response struct {
+		Inserted int `json:"inserted"`
+		Errors   int `json:"errors"`
+	}
+	if err := c.request(ctx, "POST", "/vector/batch_insert", body, &response, nil); err != nil {
+		return fmt.Errorf("failed to put vector: %w", err)
+	}
+	if response.Errors > 0 {
+		return fmt.Errorf("failed to put vector: server rejected entity %s", uuid)
+	}
+	return nil
+}
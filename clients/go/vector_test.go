@@ -0,0 +1,65 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_VectorSearch(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/vector/search", r.URL.Path)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "docs", body["collection"])
+		assert.Equal(t, []interface{}{0.5, 0.25}, body["vector"])
+		assert.Equal(t, float64(2), body["k"])
+		w.Write([]byte(`{"results":[{"pk":"d1","distance":0.5,"score":0},{"pk":"d2","distance":1}],"k":2,"count":2}`))
+	})
+
+	results, err := client.VectorSearch(context.Background(), "docs", VectorQuery{
+		Vector: []float32{0.5, 0.25},
+		TopK:   2,
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "d1", results[0].ID)
+	assert.Equal(t, 0.5, results[0].Distance)
+	require.NotNil(t, results[0].Score)
+	assert.Equal(t, 0.0, *results[0].Score, "a score of 0 reported by the server is kept")
+	assert.Nil(t, results[1].Score, "the score is left to the server")
+}
+
+func TestClient_VectorSearch_EmptyVector(t *testing.T) {
+	client := NewClient(Config{})
+	_, err := client.VectorSearch(context.Background(), "docs", VectorQuery{})
+	assert.Error(t, err)
+}
+
+func TestClient_PutWithVector(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/vector/batch_insert", r.URL.Path)
+		var body struct {
+			Collection string `json:"collection"`
+			Items      []struct {
+				PK     string            `json:"pk"`
+				Vector []float32         `json:"vector"`
+				Fields map[string]string `json:"fields"`
+			} `json:"items"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "docs", body.Collection)
+		require.Len(t, body.Items, 1)
+		assert.Equal(t, "d1", body.Items[0].PK)
+		assert.Equal(t, []float32{1, 0}, body.Items[0].Vector)
+		assert.Equal(t, "hello", body.Items[0].Fields["text"])
+		w.Write([]byte(`{"inserted":1,"errors":0}`))
+	})
+
+	err := client.PutWithVector(context.Background(), "document", "docs", "d1", map[string]string{"text": "hello"}, []float32{1, 0})
+	require.NoError(t, err)
+}
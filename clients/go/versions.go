@@ -0,0 +1,85 @@
+package themisdb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// DocumentVersion describes a version of an entity kept in the server's MVCC history
+type DocumentVersion struct {
+	// Version increases with every write of the entity, starting at 1
+	Version uint64 `json:"version"`
+	// CommittedAt is the commit time of the write
+	CommittedAt time.Time `json:"committed_at"`
+	// TransactionID is the transaction of the write, empty for single writes
+	TransactionID string `json:"transaction_id,omitempty"`
+	// Deleted marks a version recording the deletion of the entity
+	Deleted bool `json:"deleted"`
+}
+
+// versionPath returns the path of the history of an entity, or of one of its versions
+func versionPath(model, collection, uuid string, version uint64) string {
+	path := entityPath(model, collection, uuid) + "/_versions"
+	if version > 0 {
+		path += "/" + strconv.FormatUint(version, 10)
+	}
+	return path
+}
+
+// validateVersion checks the entity and that version is set
+func validateVersion(model, collection, uuid string, version uint64) error {
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return err
+	}
+	if version == 0 {
+		return &ValidationError{Field: "version", Value: "0", Reason: "versions start at 1"}
+	}
+	return nil
+}
+
+// ListVersions returns the versions of an entity still kept by the server, oldest
+// first. The server discards versions older than its history retention, so the list
+// may not start at version 1.
+func (c *Client) ListVersions(ctx context.Context, model, collection, uuid string) ([]DocumentVersion, error) {
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return nil, err
+	}
+	var result struct {
+		Versions []DocumentVersion `json:"versions"`
+	}
+	if err := c.readRequest(ctx, "GET", versionPath(model, collection, uuid, 0), nil, &result, nil); err != nil {
+		return nil, fmt.Errorf("failed to list versions of %s: %w", uuid, err)
+	}
+	return result.Versions, nil
+}
+
+// GetVersion decodes an entity as it was written in version into result. It fails
+// with ErrNotFound if the version does not exist, was discarded, or records a deletion.
+func (c *Client) GetVersion(ctx context.Context, model, collection, uuid string, version uint64, result interface{}) error {
+	if err := validateVersion(model, collection, uuid, version); err != nil {
+		return err
+	}
+	if err := c.readRequest(ctx, "GET", versionPath(model, collection, uuid, version), nil, result, nil); err != nil {
+		return fmt.Errorf("failed to get version %d of %s: %w", version, uuid, err)
+	}
+	return nil
+}
+
+// RevertTo restores an entity to its state in version by writing it again as a new
+// version, so the history of the entity is kept and the revert can be undone in turn.
+// Reverting to a version that records a deletion deletes the entity.
+func (c *Client) RevertTo(ctx context.Context, model, collection, uuid string, version uint64) error {
+	if err := validateVersion(model, collection, uuid, version); err != nil {
+		return err
+	}
+	err := c.request(ctx, "POST", versionPath(model, collection, uuid, version)+"/revert", nil, nil, nil)
+	if c.cache != nil {
+		c.cache.invalidate(ctx, c, &Request{Path: entityPath(model, collection, uuid)})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revert %s to version %d: %w", uuid, version, err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// versionServer keeps the full history of a single entity
+type versionServer struct {
+	mu       sync.Mutex
+	versions []json.RawMessage // nil records a deletion
+}
+
+func (s *versionServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/api/relational/docs/d1")
+	parts := strings.Split(strings.TrimPrefix(path, "/_versions"), "/")
+	switch {
+	case path == "" && r.Method == "PUT":
+		body, _ := io.ReadAll(r.Body)
+		s.versions = append(s.versions, body)
+	case path == "" && r.Method == "GET":
+		if len(s.versions) == 0 || s.versions[len(s.versions)-1] == nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(s.versions[len(s.versions)-1])
+	case path == "" && r.Method == "DELETE":
+		s.versions = append(s.versions, nil)
+	case path == "/_versions":
+		var versions []map[string]interface{}
+		for i, v := range s.versions {
+			versions = append(versions, map[string]interface{}{"version": i + 1, "committed_at": time.Unix(int64(1000+i), 0).UTC(), "deleted": v == nil})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"versions": versions})
+	default:
+		version, _ := strconv.Atoi(parts[1])
+		if version < 1 || version > len(s.versions) || s.versions[version-1] == nil {
+			http.NotFound(w, r)
+			return
+		}
+		if len(parts) == 3 && parts[2] == "revert" && r.Method == "POST" {
+			s.versions = append(s.versions, s.versions[version-1])
+			return
+		}
+		w.Write(s.versions[version-1])
+	}
+}
+
+func TestClient_Versions(t *testing.T) {
+	server := &versionServer{}
+	base := newTestClient(t, server.serveHTTP)
+	client := NewClient(Config{Endpoints: base.endpoints, Cache: &ResponseCacheOptions{TTL: time.Minute}})
+	defer client.Close()
+	ctx := context.Background()
+
+	require.NoError(t, client.Put(ctx, "relational", "docs", "d1", map[string]string{"title": "draft"}))
+	require.NoError(t, client.Put(ctx, "relational", "docs", "d1", map[string]string{"title": "final"}))
+	require.NoError(t, client.Delete(ctx, "relational", "docs", "d1"))
+
+	versions, err := client.ListVersions(ctx, "relational", "docs", "d1")
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.Equal(t, uint64(1), versions[0].Version)
+	assert.Equal(t, time.Unix(1000, 0).UTC(), versions[0].CommittedAt)
+	assert.False(t, versions[1].Deleted)
+	assert.True(t, versions[2].Deleted)
+
+	var doc map[string]string
+	require.NoError(t, client.GetVersion(ctx, "relational", "docs", "d1", 1, &doc))
+	assert.Equal(t, "draft", doc["title"])
+	assert.ErrorIs(t, client.GetVersion(ctx, "relational", "docs", "d1", 3, &doc), ErrNotFound, "deletions have no content")
+	assert.ErrorIs(t, client.GetVersion(ctx, "relational", "docs", "d1", 0, &doc), ErrInvalidInput)
+
+	require.NoError(t, client.RevertTo(ctx, "relational", "docs", "d1", 2))
+	require.NoError(t, client.Get(ctx, "relational", "docs", "d1", &doc))
+	assert.Equal(t, "final", doc["title"])
+	require.NoError(t, client.RevertTo(ctx, "relational", "docs", "d1", 1))
+	require.NoError(t, client.Get(ctx, "relational", "docs", "d1", &doc))
+	assert.Equal(t, "draft", doc["title"], "reverting invalidates the cached entity")
+
+	versions, err = client.ListVersions(ctx, "relational", "docs", "d1")
+	require.NoError(t, err)
+	assert.Len(t, versions, 5, "reverts are new versions")
+
+	err = client.AtSnapshot(SnapshotID("s1")).RevertTo(ctx, "relational", "docs", "d1", 1)
+	assert.ErrorIs(t, err, ErrReadOnly)
+}
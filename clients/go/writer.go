@@ -0,0 +1,270 @@
+package themisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WriterOptions configures a Writer
+type WriterOptions struct {
+	// BatchSize is the number of operations sent per bulk request; a background flush
+	// starts as soon as this many are buffered (default: 500)
+	BatchSize int
+	// FlushInterval is the longest time an operation waits in the buffer (default: 1s)
+	FlushInterval time.Duration
+	// MaxPending bounds the operations buffered or being flushed; Put and Delete block
+	// while it is reached (default: 10 × BatchSize)
+	MaxPending int
+	// OnError is called with the error and the operations of every failed bulk request.
+	// The operations are not retried beyond the client's retry policy.
+	OnError func(err error, ops []WriteOp)
+}
+
+// WriteOp is a buffered write of a Writer
+type WriteOp struct {
+	Model      string
+	Collection string
+	UUID       string
+	// Document is the encoded document of a put and nil for a delete
+	Document json.RawMessage
+}
+
+// writeKey identifies the document a WriteOp applies to
+type writeKey struct {
+	model, collection, uuid string
+}
+
+// Writer buffers puts and deletes and stores them with bulk requests in the background,
+// trading per-write acknowledgement for throughput in log-style ingest workloads. A
+// write buffered after another write of the same document replaces it, so each flush
+// applies the last write per document. Writes are not part of context transactions.
+type Writer struct {
+	client *Client
+	opts   WriterOptions
+
+	mu       sync.Mutex
+	pending  []WriteOp
+	index    map[writeKey]int
+	inflight int
+	space    chan struct{}
+	closed   bool
+
+	// flushMu serializes flushes, so batches are applied in the order they were buffered
+	flushMu sync.Mutex
+
+	kick chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Writer returns a buffered writer flushing in the background until Close
+func (c *Client) Writer(opts WriterOptions) *Writer {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	if opts.MaxPending <= 0 {
+		opts.MaxPending = 10 * opts.BatchSize
+	}
+	w := &Writer{
+		client: c,
+		opts:   opts,
+		index:  make(map[writeKey]int),
+		space:  make(chan struct{}),
+		kick:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Put buffers a write of data. It fails only if the write is invalid, the writer is
+// closed, or ctx ends while waiting for buffer space; storage errors go to OnError.
+func (w *Writer) Put(ctx context.Context, model, collection, uuid string, data interface{}) error {
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return err
+	}
+	if err := w.client.validateDocument(model, collection, data); err != nil {
+		return err
+	}
+	doc, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode document %s: %w", uuid, err)
+	}
+	return w.enqueue(ctx, WriteOp{Model: model, Collection: collection, UUID: uuid, Document: doc})
+}
+
+// Delete buffers the removal of a document
+func (w *Writer) Delete(ctx context.Context, model, collection, uuid string) error {
+	if err := validateEntity(model, collection, uuid); err != nil {
+		return err
+	}
+	return w.enqueue(ctx, WriteOp{Model: model, Collection: collection, UUID: uuid})
+}
+
+// Pending returns the number of operations buffered and not yet flushed
+func (w *Writer) Pending() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.pending)
+}
+
+// Flush stores the buffered operations and returns the first error of their bulk
+// requests; every failed request is also passed to OnError
+func (w *Writer) Flush(ctx context.Context) error {
+	return w.flush(ctx)
+}
+
+// Close stops the background flushes and flushes the remaining operations. Put and
+// Delete fail with ErrWriterClosed afterwards.
+func (w *Writer) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.space)
+	w.mu.Unlock()
+
+	close(w.stop)
+	<-w.done
+	return w.flush(ctx)
+}
+
+// enqueue buffers op, waiting while MaxPending operations are buffered or in flight
+func (w *Writer) enqueue(ctx context.Context, op WriteOp) error {
+	key := writeKey{op.Model, op.Collection, op.UUID}
+	for {
+		w.mu.Lock()
+		if w.closed {
+			w.mu.Unlock()
+			return ErrWriterClosed
+		}
+		if i, ok := w.index[key]; ok {
+			w.pending[i] = op
+			w.mu.Unlock()
+			return nil
+		}
+		if len(w.pending)+w.inflight < w.opts.MaxPending {
+			w.index[key] = len(w.pending)
+			w.pending = append(w.pending, op)
+			full := len(w.pending) >= w.opts.BatchSize
+			w.mu.Unlock()
+			if full {
+				select {
+				case w.kick <- struct{}{}:
+				default:
+				}
+			}
+			return nil
+		}
+		space := w.space
+		w.mu.Unlock()
+
+		select {
+		case <-space:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// run flushes when a batch is full or FlushInterval passed, until Close
+func (w *Writer) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-w.kick:
+		case <-ticker.C:
+		}
+		w.flush(context.Background())
+	}
+}
+
+// flush sends the buffered operations in bulk requests of BatchSize per collection
+func (w *Writer) flush(ctx context.Context) error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	ops := w.pending
+	w.pending = nil
+	w.index = make(map[writeKey]int)
+	w.inflight = len(ops)
+	w.mu.Unlock()
+
+	defer func() {
+		w.mu.Lock()
+		w.inflight = 0
+		if !w.closed {
+			close(w.space)
+			w.space = make(chan struct{})
+		}
+		w.mu.Unlock()
+	}()
+
+	var firstErr error
+	for start := 0; start < len(ops); start += w.opts.BatchSize {
+		end := start + w.opts.BatchSize
+		if end > len(ops) {
+			end = len(ops)
+		}
+		for _, batch := range groupWrites(ops[start:end]) {
+			if err := w.send(ctx, batch); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				if w.opts.OnError != nil {
+					w.opts.OnError(err, batch)
+				}
+			}
+		}
+	}
+	return firstErr
+}
+
+// send applies the operations of one collection with a single bulk request
+func (w *Writer) send(ctx context.Context, batch []WriteOp) error {
+	var docs []ScanEntry
+	var deletes []string
+	for _, op := range batch {
+		if op.Document == nil {
+			deletes = append(deletes, op.UUID)
+		} else {
+			docs = append(docs, ScanEntry{UUID: op.UUID, Document: op.Document})
+		}
+	}
+	model, collection := batch[0].Model, batch[0].Collection
+	if _, _, err := w.client.bulkWrite(ctx, model, collection, docs, deletes, ImportOverwrite); err != nil {
+		return fmt.Errorf("failed to write %d operations to %s/%s: %w", len(batch), model, collection, err)
+	}
+	return nil
+}
+
+// groupWrites splits ops by collection, in the order each collection first appears
+func groupWrites(ops []WriteOp) [][]WriteOp {
+	var groups [][]WriteOp
+	index := make(map[[2]string]int)
+	for _, op := range ops {
+		key := [2]string{op.Model, op.Collection}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], op)
+	}
+	return groups
+}
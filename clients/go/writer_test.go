@@ -0,0 +1,148 @@
+package themisdb
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBulkCountingClient returns a client backed by an in-memory store that counts bulk requests
+func newBulkCountingClient(t *testing.T) (*Client, *memoryStore, func() int) {
+	store := &memoryStore{docs: make(map[string]map[string]interface{}), revisions: make(map[string]int)}
+	var mu sync.Mutex
+	bulks := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/_bulk") {
+			mu.Lock()
+			bulks++
+			mu.Unlock()
+		}
+		store.serveHTTP(w, r)
+	})
+	return client, store, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return bulks
+	}
+}
+
+func TestWriter_Flush(t *testing.T) {
+	client, store, bulks := newBulkCountingClient(t)
+	ctx := context.Background()
+	require.NoError(t, client.Put(ctx, "relational", "logs", "old", map[string]string{"msg": "old"}))
+
+	w := client.Writer(WriterOptions{FlushInterval: time.Hour})
+	defer w.Close(ctx)
+	require.NoError(t, w.Put(ctx, "relational", "logs", "l1", map[string]string{"msg": "first"}))
+	require.NoError(t, w.Put(ctx, "relational", "logs", "l2", map[string]string{"msg": "second"}))
+	require.NoError(t, w.Put(ctx, "relational", "logs", "l1", map[string]string{"msg": "replaced"}))
+	require.NoError(t, w.Delete(ctx, "relational", "logs", "l2"))
+	require.NoError(t, w.Delete(ctx, "relational", "logs", "old"))
+	require.NoError(t, w.Put(ctx, "relational", "metrics", "m1", map[string]int{"value": 1}))
+	assert.Equal(t, 4, w.Pending(), "writes of the same document are coalesced")
+	assert.Equal(t, 0, bulks())
+
+	require.NoError(t, w.Flush(ctx))
+	assert.Equal(t, 0, w.Pending())
+	assert.Equal(t, 2, bulks(), "one bulk request per collection")
+	assert.Equal(t, "replaced", store.docs["/api/relational/logs/l1"]["msg"])
+	assert.NotContains(t, store.docs, "/api/relational/logs/l2")
+	assert.NotContains(t, store.docs, "/api/relational/logs/old")
+	assert.Equal(t, float64(1), store.docs["/api/relational/metrics/m1"]["value"])
+}
+
+func TestWriter_BackgroundFlush(t *testing.T) {
+	t.Run("batch size", func(t *testing.T) {
+		client, store, bulks := newBulkCountingClient(t)
+		ctx := context.Background()
+		w := client.Writer(WriterOptions{BatchSize: 2, FlushInterval: time.Hour})
+		defer w.Close(ctx)
+
+		require.NoError(t, w.Put(ctx, "relational", "logs", "l1", map[string]string{"msg": "a"}))
+		require.NoError(t, w.Put(ctx, "relational", "logs", "l2", map[string]string{"msg": "b"}))
+		assert.Eventually(t, func() bool { return bulks() == 1 }, time.Second, 5*time.Millisecond)
+		store.mu.Lock()
+		assert.Len(t, store.docs, 2)
+		store.mu.Unlock()
+	})
+
+	t.Run("interval", func(t *testing.T) {
+		client, _, bulks := newBulkCountingClient(t)
+		ctx := context.Background()
+		w := client.Writer(WriterOptions{FlushInterval: 10 * time.Millisecond})
+		defer w.Close(ctx)
+
+		require.NoError(t, w.Put(ctx, "relational", "logs", "l1", map[string]string{"msg": "a"}))
+		assert.Eventually(t, func() bool { return bulks() == 1 && w.Pending() == 0 }, time.Second, 5*time.Millisecond)
+	})
+}
+
+func TestWriter_OnError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid document", http.StatusBadRequest)
+	})
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var failed []WriteOp
+	w := client.Writer(WriterOptions{
+		FlushInterval: time.Hour,
+		OnError: func(err error, ops []WriteOp) {
+			mu.Lock()
+			defer mu.Unlock()
+			failed = append(failed, ops...)
+		},
+	})
+	defer w.Close(ctx)
+	require.NoError(t, w.Put(ctx, "relational", "logs", "l1", map[string]string{"msg": "a"}))
+	require.NoError(t, w.Delete(ctx, "relational", "logs", "l2"))
+
+	err := w.Flush(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "relational/logs")
+	require.Len(t, failed, 2)
+	assert.JSONEq(t, `{"msg":"a"}`, string(failed[0].Document))
+	assert.Equal(t, WriteOp{Model: "relational", Collection: "logs", UUID: "l2"}, failed[1])
+	assert.Equal(t, 0, w.Pending(), "failed operations are dropped")
+}
+
+func TestWriter_MaxPending(t *testing.T) {
+	client, _, _ := newBulkCountingClient(t)
+	ctx := context.Background()
+	w := client.Writer(WriterOptions{MaxPending: 1, FlushInterval: time.Hour})
+	defer w.Close(ctx)
+
+	require.NoError(t, w.Put(ctx, "relational", "logs", "l1", map[string]string{"msg": "a"}))
+	require.NoError(t, w.Put(ctx, "relational", "logs", "l1", map[string]string{"msg": "b"}), "a buffered document can be replaced")
+
+	full, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, w.Put(full, "relational", "logs", "l2", map[string]string{"msg": "c"}), context.DeadlineExceeded)
+
+	done := make(chan error)
+	go func() { done <- w.Put(ctx, "relational", "logs", "l2", map[string]string{"msg": "c"}) }()
+	require.NoError(t, w.Flush(ctx))
+	require.NoError(t, <-done, "flushing frees buffer space")
+}
+
+func TestWriter_Close(t *testing.T) {
+	client, store, _ := newBulkCountingClient(t)
+	ctx := context.Background()
+	w := client.Writer(WriterOptions{FlushInterval: time.Hour})
+
+	require.NoError(t, w.Put(ctx, "relational", "logs", "l1", map[string]string{"msg": "a"}))
+	require.NoError(t, w.Close(ctx))
+	assert.Contains(t, store.docs, "/api/relational/logs/l1")
+	assert.ErrorIs(t, w.Put(ctx, "relational", "logs", "l2", nil), ErrWriterClosed)
+	assert.NoError(t, w.Close(ctx))
+
+	w = client.Writer(WriterOptions{})
+	defer w.Close(ctx)
+	assert.ErrorIs(t, w.Put(ctx, "relational", "", "l1", nil), ErrInvalidInput)
+}